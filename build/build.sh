@@ -269,6 +269,14 @@ build_client2() {
     popd >/dev/null
 }
 
+build_libsdk() {
+    pre_build
+    pushd $SrcPath >/dev/null
+    echo -n "build libcfs.so    "
+    go build $MODFLAGS -ldflags "${LDFlags}" -buildmode=c-shared -o ${BuildBinPath}/libcfs.so ${SrcPath}/libsdk/*.go  && echo "success" || echo "failed"
+    popd >/dev/null
+}
+
 build_authtool() {
     pre_build
     pushd $SrcPath >/dev/null
@@ -316,6 +324,9 @@ case "$cmd" in
     "client2")
         build_client2
         ;;
+    "libsdk")
+        build_libsdk
+        ;;
     "authtool")
         build_authtool
         ;;
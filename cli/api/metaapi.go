@@ -49,7 +49,7 @@ type Inode struct {
 	LinkTarget []byte // SymLink target name
 	NLink      uint32 // NodeLink counts
 	Flag       int32
-	Reserved   uint64 // reserved space
+	QuotaId    uint64 // root inode of the directory quota
 	Extents    []proto.ExtentKey
 }
 
@@ -72,7 +72,7 @@ func (i *Inode) String() string {
 	buff.WriteString(fmt.Sprintf("LinkT[%s]", i.LinkTarget))
 	buff.WriteString(fmt.Sprintf("NLink[%d]", i.NLink))
 	buff.WriteString(fmt.Sprintf("Flag[%d]", i.Flag))
-	buff.WriteString(fmt.Sprintf("Reserved[%d]", i.Reserved))
+	buff.WriteString(fmt.Sprintf("QuotaId[%d]", i.QuotaId))
 	buff.WriteString(fmt.Sprintf("Extents[%s]", i.Extents))
 	buff.WriteString("}")
 	return buff.String()
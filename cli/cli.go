@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"github.com/chubaofs/chubaofs/cli/cmd"
 	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/util"
 	"github.com/chubaofs/chubaofs/util/log"
 	"github.com/spf13/cobra"
 	"os"
@@ -35,6 +36,9 @@ func runCLI() (err error) {
 		fmt.Printf("init cli log err[%v]", err)
 		return
 	}
+	if err = util.InitTLS(cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSCAFile, false, false); err != nil {
+		return fmt.Errorf("init cli tls: %v", err)
+	}
 	cfsCli := setupCommands(cfg)
 	if err = cfsCli.Execute(); err != nil {
 		log.LogErrorf("Command fail, err:%v", err)
@@ -47,19 +51,45 @@ func setupCommands(cfg *cmd.Config) *cobra.Command {
 	mc.SetTimeout(cfg.Timeout)
 	cfsRootCmd := cmd.NewRootCmd(mc)
 	var completionCmd = &cobra.Command{
-		Use:   "completion",
-		Short: "Generate completion bash file",
-		Long: `To use the completion, tool "bash-completion" is demanded,
+		Use:   "completion [bash|zsh|fish|powershell]",
+		Short: "Generate completion script",
+		Long: `To use the bash completion, tool "bash-completion" is demanded,
 then execute the following command:
    $ ./cfs-cli completion   
    # Bash completion file "cfs-cli.sh" will be generated under the present working directory
    $ echo 'source /usr/share/bash-completion/bash_completion' >> ~/.bashrc
    $ echo 'source {path of cfs-cli.sh}' >>~/.bashrc
    $ source ~/.bashrc
+
+The completion script of the other shells is written to the standard output:
+   $ ./cfs-cli completion zsh > "${fpath[1]}/_cfs-cli"
+   $ ./cfs-cli completion fish > ~/.config/fish/completions/cfs-cli.fish
+   PS> ./cfs-cli completion powershell | Out-String | Invoke-Expression
 `,
-		Example: "cfs-cli completion",
+		Example:   "cfs-cli completion",
+		ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+		Args:      cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := cfsRootCmd.CFSCmd.GenBashCompletionFile("cfs-cli.sh"); err != nil {
+			var err error
+			if len(args) > 0 {
+				switch args[0] {
+				case "bash":
+					err = cfsRootCmd.CFSCmd.GenBashCompletion(os.Stdout)
+				case "zsh":
+					err = cfsRootCmd.CFSCmd.GenZshCompletion(os.Stdout)
+				case "fish":
+					err = cfsRootCmd.CFSCmd.GenFishCompletion(os.Stdout, true)
+				case "powershell":
+					err = cfsRootCmd.CFSCmd.GenPowerShellCompletion(os.Stdout)
+				default:
+					err = fmt.Errorf("unsupported shell [%v]", args[0])
+				}
+				if err != nil {
+					_, _ = fmt.Fprintf(os.Stderr, "generate completion failed: %v\n", err)
+				}
+				return
+			}
+			if err = cfsRootCmd.CFSCmd.GenBashCompletionFile("cfs-cli.sh"); err != nil {
 				_, _ = fmt.Fprintf(os.Stdout, "generate bash file failed")
 			}
 			_, _ = fmt.Fprintf(os.Stdout, `File "cfs-cli.sh" has been generated successfully under the present working directory,
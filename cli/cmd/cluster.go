@@ -17,6 +17,7 @@ package cmd
 import (
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/sdk/master"
@@ -36,9 +37,13 @@ func (cmd *ChubaoFSCmd) newClusterCmd(client *master.MasterClient) *cobra.Comman
 	clusterCmd.AddCommand(
 		newClusterInfoCmd(client),
 		newClusterStatCmd(client),
+		newClusterOverviewCmd(client),
 		newClusterFreezeCmd(client),
 		newClusterSetThresholdCmd(client),
 		newClusterDeleteParasCmd(client),
+		newClusterWriteLimitCmd(client),
+		newClusterAuditCmd(client),
+		newClusterTopologyCmd(client),
 	)
 	return clusterCmd
 }
@@ -46,13 +51,21 @@ func (cmd *ChubaoFSCmd) newClusterCmd(client *master.MasterClient) *cobra.Comman
 const (
 	cmdClusterInfoShort      = "Show cluster summary information"
 	cmdClusterStatShort      = "Show cluster status information"
+	cmdClusterOverviewShort  = "Show capacity, partition health and pending repairs of the cluster"
 	cmdClusterFreezeShort    = "Freeze cluster"
 	cmdClusterThresholdShort = "Set memory threshold of metanodes"
 	cmdClusterDelParaShort   = "Set delete parameters"
+	cmdClusterWriteLimShort  = "Set write throttles of datanodes in MB/s"
+	cmdClusterAuditShort     = "Show the audit log of the admin operations"
+	cmdClusterTopologyShort  = "Show capacity of the fault domains region/zone/rack/host"
 	nodeDeleteBatchCountKey  = "batchCount"
 	nodeMarkDeleteRateKey    = "markDeleteRate"
 	nodeDeleteWorkerSleepMs  = "deleteWorkerSleepMs"
 	nodeAutoRepairRateKey    = "autoRepairRate"
+	nodeDiskWriteRateKey     = "diskWriteRate"
+	nodeDiskRepairRateKey    = "diskRepairRate"
+	nodeDpWriteRateKey       = "dpWriteRate"
+	clusterDeleteRateKey     = "clusterDeleteRate"
 )
 
 func newClusterInfoCmd(client *master.MasterClient) *cobra.Command {
@@ -66,15 +79,22 @@ func newClusterInfoCmd(client *master.MasterClient) *cobra.Command {
 			if cv, err = client.AdminAPI().GetCluster(); err != nil {
 				errout("Error: %v", err)
 			}
-			stdout("[Cluster]\n")
-			stdout(formatClusterView(cv))
 			if delPara, err = client.AdminAPI().GetDeleteParas(); err != nil {
 				errout("Error: %v", err)
 			}
-			stdout(fmt.Sprintf("  BatchCount         : %v\n", delPara[nodeDeleteBatchCountKey]))
-			stdout(fmt.Sprintf("  MarkDeleteRate     : %v\n", delPara[nodeMarkDeleteRateKey]))
-			stdout(fmt.Sprintf("  DeleteWorkerSleepMs: %v\n", delPara[nodeDeleteWorkerSleepMs]))
-			stdout(fmt.Sprintf("  AutoRepairRate     : %v\n", delPara[nodeAutoRepairRateKey]))
+			if stdoutJSON(map[string]interface{}{"Cluster": cv, "DeleteParas": delPara}) {
+				return
+			}
+			stdout("[Cluster]\n")
+			stdout("%v", formatClusterView(cv))
+			stdout("  BatchCount         : %v\n", delPara[nodeDeleteBatchCountKey])
+			stdout("  MarkDeleteRate     : %v\n", delPara[nodeMarkDeleteRateKey])
+			stdout("  DeleteWorkerSleepMs: %v\n", delPara[nodeDeleteWorkerSleepMs])
+			stdout("  AutoRepairRate     : %v\n", delPara[nodeAutoRepairRateKey])
+			stdout("  ClusterDeleteRate  : %v\n", delPara[clusterDeleteRateKey])
+			stdout("  DiskWriteRate      : %v MB/s\n", delPara[nodeDiskWriteRateKey])
+			stdout("  DiskRepairRate     : %v MB/s\n", delPara[nodeDiskRepairRateKey])
+			stdout("  DpWriteRate        : %v MB/s\n", delPara[nodeDpWriteRateKey])
 			stdout("\n")
 		},
 	}
@@ -99,8 +119,40 @@ func newClusterStatCmd(client *master.MasterClient) *cobra.Command {
 				err = fmt.Errorf("Get cluster info fail:\n%v\n", err)
 				return
 			}
+			if stdoutJSON(cs) {
+				return
+			}
 			stdout("[Cluster Status]\n")
-			stdout(formatClusterStat(cs))
+			stdout("%v", formatClusterStat(cs))
+			stdout("\n")
+		},
+	}
+	return cmd
+}
+
+func newClusterOverviewCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpOverview,
+		Short: cmdClusterOverviewShort,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err error
+				ov  *proto.ClusterOverview
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if ov, err = client.AdminAPI().GetClusterOverview(); err != nil {
+				err = fmt.Errorf("Get cluster overview fail:\n%v\n", err)
+				return
+			}
+			if stdoutJSON(ov) {
+				return
+			}
+			stdout("[Cluster Overview]\n")
+			stdout("%v", formatClusterOverview(ov))
 			stdout("\n")
 		},
 	}
@@ -181,7 +233,7 @@ If the memory usage reaches this threshold, all the mata partition will be readO
 }
 
 func newClusterDeleteParasCmd(client *master.MasterClient) *cobra.Command {
-	var optAutoRepairRate, optMarkDeleteRate, optDelBatchCount, optDelWorkerSleepMs string
+	var optAutoRepairRate, optMarkDeleteRate, optDelBatchCount, optDelWorkerSleepMs, optClusterDelRate string
 	var cmd = &cobra.Command{
 		Use:   CliOpSetDelRate,
 		Short: cmdClusterDelParaShort,
@@ -195,8 +247,15 @@ func newClusterDeleteParasCmd(client *master.MasterClient) *cobra.Command {
 				}
 			}()
 
-			if err = client.AdminAPI().SetDeleteParas(optDelBatchCount, optMarkDeleteRate, optDelWorkerSleepMs, optAutoRepairRate); err != nil {
-				return
+			if optClusterDelRate != "" {
+				if err = client.AdminAPI().SetClusterDeleteRate(optClusterDelRate); err != nil {
+					return
+				}
+			}
+			if optClusterDelRate == "" || optDelBatchCount != "" || optMarkDeleteRate != "" || optDelWorkerSleepMs != "" || optAutoRepairRate != "" {
+				if err = client.AdminAPI().SetDeleteParas(optDelBatchCount, optMarkDeleteRate, optDelWorkerSleepMs, optAutoRepairRate); err != nil {
+					return
+				}
 			}
 			stdout("Delete parameters has been set successfully. \n")
 		},
@@ -205,6 +264,114 @@ func newClusterDeleteParasCmd(client *master.MasterClient) *cobra.Command {
 	cmd.Flags().StringVar(&optDelBatchCount, CliFlagDelBatchCount, "", "MetaNode delete batch count")
 	cmd.Flags().StringVar(&optDelWorkerSleepMs, CliFlagDelWorkerSleepMs, "", "MetaNode delete worker sleep time with millisecond. if 0 for no sleep")
 	cmd.Flags().StringVar(&optMarkDeleteRate, CliFlagMarkDelRate, "", "DataNode batch mark delete limit rate. if 0 for no infinity limit")
+	cmd.Flags().StringVar(&optClusterDelRate, CliFlagClusterDelRate, "", "Extents deleted by the whole cluster per second, shared by the nodes. if 0 for no limit")
+
+	return cmd
+}
+
+func newClusterWriteLimitCmd(client *master.MasterClient) *cobra.Command {
+	var optDiskWriteRate, optDiskRepairRate, optDpWriteRate string
+	var cmd = &cobra.Command{
+		Use:   CliOpWriteLimit,
+		Short: cmdClusterWriteLimShort,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err error
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+
+			if err = client.AdminAPI().SetWriteLimits(optDiskWriteRate, optDiskRepairRate, optDpWriteRate); err != nil {
+				return
+			}
+			stdout("Write limits has been set successfully. \n")
+		},
+	}
+	cmd.Flags().StringVar(&optDiskWriteRate, CliFlagDiskWriteRate, "", "Client write limit of each datanode disk in MB/s. if 0 for no limit")
+	cmd.Flags().StringVar(&optDiskRepairRate, CliFlagDiskRepairRate, "", "Repair write limit of each datanode disk in MB/s. if 0 for no limit")
+	cmd.Flags().StringVar(&optDpWriteRate, CliFlagDpWriteRate, "", "Client write limit of each data partition in MB/s. if 0 for no limit")
+
+	return cmd
+}
 
+func newClusterAuditCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optAPI, optOperator, optVol string
+		optSince                    time.Duration
+		optLimit                    int
+		optDetail                   bool
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpAudit,
+		Short: cmdClusterAuditShort,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err     error
+				start   int64
+				records []*proto.AdminAuditRecord
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if optSince > 0 {
+				start = time.Now().Add(-optSince).Unix()
+			}
+			if records, err = client.AdminAPI().GetAdminAudit(optAPI, optOperator, optVol, start, 0, optLimit); err != nil {
+				return
+			}
+			if stdoutJSON(records) {
+				return
+			}
+			stdout("%v\n", adminAuditTableHeader)
+			for _, record := range records {
+				stdout("%v\n", formatAdminAuditTableRow(record))
+				if optDetail && record.Msg != "" {
+					stdout("  %v\n", record.Msg)
+				}
+			}
+		},
+	}
+	cmd.Flags().StringVar(&optAPI, "api", "", "Show the calls of the API only, e.g. /vol/delete")
+	cmd.Flags().StringVar(&optOperator, "operator", "", "Show the calls of the operator only")
+	cmd.Flags().StringVar(&optVol, "vol", "", "Show the calls on the volume only")
+	cmd.Flags().DurationVar(&optSince, "since", 0, "Show the calls within the duration only, e.g. 24h")
+	cmd.Flags().IntVar(&optLimit, "limit", 100, "Max number of the calls to show from the newest")
+	cmd.Flags().BoolVarP(&optDetail, "detail", "d", false, "Display the reply messages of the calls")
+	return cmd
+}
+
+func newClusterTopologyCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliResourceTopology + " [PATH]",
+		Short: cmdClusterTopologyShort,
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err     error
+				path    string
+				domains []*proto.FaultDomainView
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if len(args) > 0 {
+				path = args[0]
+			}
+			if domains, err = client.AdminAPI().GetFaultDomains(path); err != nil {
+				return
+			}
+			if stdoutJSON(domains) {
+				return
+			}
+			stdout("%v", formatFaultDomains(domains))
+		},
+	}
 	return cmd
 }
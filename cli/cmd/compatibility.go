@@ -160,7 +160,7 @@ func verifyInode(client *api.MetaHttpClient, mp metanode.MetaPartition) (err err
 			LinkTarget: inode.LinkTarget,
 			NLink:      inode.NLink,
 			Flag:       inode.Flag,
-			Reserved:   inode.Reserved,
+			QuotaId:    inode.QuotaId,
 			Extents:    make([]proto.ExtentKey, 0),
 		}
 		inode.Extents.Range(func(ek proto.ExtentKey) bool {
@@ -44,8 +44,11 @@ var (
 )
 
 type Config struct {
-	MasterAddr []string `json:"masterAddr"`
-	Timeout    uint16   `json:"timeout"`
+	MasterAddr  []string `json:"masterAddr"`
+	Timeout     uint16   `json:"timeout"`
+	TLSCertFile string   `json:"tlsCertFile"`
+	TLSKeyFile  string   `json:"tlsKeyFile"`
+	TLSCAFile   string   `json:"tlsCAFile"`
 }
 
 func newConfigCmd() *cobra.Command {
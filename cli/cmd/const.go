@@ -19,6 +19,7 @@ const (
 	CliOpGet               = "get"
 	CliOpList              = "list"
 	CliOpStatus            = "stat"
+	CliOpOverview          = "overview"
 	CliOpCreate            = "create"
 	CliOpDelete            = "delete"
 	CliOpInfo              = "info"
@@ -36,6 +37,15 @@ const (
 	CliOpDelReplica        = "del-replica"
 	CliOpExpand              = "expand"
 	CliOpShrink              = "shrink"
+	CliOpRotateKey           = "rotate-key"
+	CliOpVerify              = "verify"
+	CliOpClients             = "clients"
+	CliOpEvictClient         = "evict-client"
+	CliOpReadOnly            = "read-only"
+	CliOpRename              = "rename"
+	CliOpReplicas            = "replicas"
+	CliOpWriteLimit          = "write-limit"
+	CliOpAudit               = "audit"
 
 	//Shorthand format of operation name
 	CliOpDecommissionShortHand = "dec"
@@ -69,10 +79,17 @@ const (
 	CliFlagINodeStartID       = "inode-start"
 	CliFlagId                 = "id"
 	CliFlagZoneName           = "zonename"
+	CliFlagEncrypt            = "encrypt"
+	CliFlagFlat               = "flat"
+	CliFlagCache              = "cache"
 	CliFlagAutoRepairRate     = "auto-repair-rate"
 	CliFlagDelBatchCount      = "delete-batch-count"
 	CliFlagDelWorkerSleepMs   = "delete-worker-sleep-ms"
 	CliFlagMarkDelRate        = "mark-delete-rate"
+	CliFlagClusterDelRate     = "cluster-delete-rate"
+	CliFlagDiskWriteRate      = "disk-write-rate"
+	CliFlagDiskRepairRate     = "disk-repair-rate"
+	CliFlagDpWriteRate        = "dp-write-rate"
 
 	//CliFlagSetDataPartitionCount	= "count" use dp-count instead
 
@@ -67,8 +67,7 @@ func newDataNodeListCmd(client *master.MasterClient) *cobra.Command {
 			sort.SliceStable(view.DataNodes, func(i, j int) bool {
 				return view.DataNodes[i].ID < view.DataNodes[j].ID
 			})
-			stdout("[Data nodes]\n")
-			stdout("%v\n", formatNodeViewTableHeader())
+			nodes := make([]proto.NodeView, 0, len(view.DataNodes))
 			for _, node := range view.DataNodes {
 				if optFilterStatus != "" &&
 					!strings.Contains(formatNodeStatus(node.Status), optFilterStatus) {
@@ -78,6 +77,14 @@ func newDataNodeListCmd(client *master.MasterClient) *cobra.Command {
 					!strings.Contains(formatYesNo(node.IsWritable), optFilterWritable) {
 					continue
 				}
+				nodes = append(nodes, node)
+			}
+			if stdoutJSON(nodes) {
+				return
+			}
+			stdout("[Data nodes]\n")
+			stdout("%v\n", formatNodeViewTableHeader())
+			for _, node := range nodes {
 				stdout("%v\n", formatNodeView(&node, true))
 			}
 		},
@@ -105,6 +112,9 @@ func newDataNodeInfoCmd(client *master.MasterClient) *cobra.Command {
 			if datanodeInfo, err = client.NodeAPI().GetDataNode(nodeAddr); err != nil {
 				return
 			}
+			if stdoutJSON(datanodeInfo) {
+				return
+			}
 			stdout("[Data node info]\n")
 			stdout(formatDataNodeDetail(datanodeInfo, false))
 
@@ -73,6 +73,9 @@ func newDataPartitionGetCmd(client *master.MasterClient) *cobra.Command {
 			if partition, err = client.AdminAPI().GetDataPartition("", partitionID); err != nil {
 				return
 			}
+			if stdoutJSON(partition) {
+				return
+			}
 			stdout(formatDataPartitionInfo(partition))
 		},
 	}
@@ -103,6 +106,9 @@ The "reset" command will be released in next version`,
 			if diagnosis, err = client.AdminAPI().DiagnoseDataPartition(); err != nil {
 				return
 			}
+			if stdoutJSON(diagnosis) {
+				return
+			}
 			stdout("[Inactive Data nodes]:\n")
 			stdout("%v\n", formatDataNodeDetailTableHeader())
 			for _, addr := range diagnosis.InactiveDataNodes {
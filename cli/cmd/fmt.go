@@ -17,6 +17,7 @@ package cmd
 import (
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -68,6 +69,85 @@ func formatClusterStat(cs *proto.ClusterStatInfo) string {
 	return sb.String()
 }
 
+var (
+	nodeOverviewTableHeader  = fmt.Sprintf(nodeOverviewTablePattern, "ID", "ADDRESS", "ZONE", "STATUS", "WRITABLE", "USED", "TOTAL", "PARTITIONS", "LEADERS", "TASKS")
+	nodeOverviewTablePattern = "%-6v    %-18v    %-10v    %-8v    %-8v    %-10v    %-10v    %-10v    %-8v    %-6v\n"
+
+	healthTableHeader  = fmt.Sprintf(healthTablePattern, "VOLUME", "TYPE", "TOTAL", "RW", "RO", "UNAVAIL", "NO LEADER")
+	healthTablePattern = "%-20v    %-14v    %-8v    %-8v    %-8v    %-8v    %-9v\n"
+
+	faultDomainTableHeader  = fmt.Sprintf(faultDomainTablePattern, "PATH", "LEVEL", "ROLE", "TOTAL/GB", "USED/GB", "AVAILABLE/GB", "USED RATIO", "NODES", "WRITABLE")
+	faultDomainTablePattern = "%-40v    %-6v    %-8v    %-10v    %-10v    %-12v    %-10v    %-6v    %-8v\n"
+)
+
+func formatClusterOverview(ov *proto.ClusterOverview) string {
+	var sb = strings.Builder{}
+	sb.WriteString(fmt.Sprintf("  Name       : %v\n", ov.Name))
+	sb.WriteString(fmt.Sprintf("  Master     : %v\n", ov.LeaderAddr))
+	sb.WriteString("\nDataNodes:\n")
+	sb.WriteString(nodeOverviewTableHeader)
+	for _, n := range ov.DataNodes {
+		sb.WriteString(formatNodeOverview(n))
+	}
+	sb.WriteString("\nMetaNodes:\n")
+	sb.WriteString(nodeOverviewTableHeader)
+	for _, n := range ov.MetaNodes {
+		sb.WriteString(formatNodeOverview(n))
+	}
+	sb.WriteString("\nPartitions:\n")
+	sb.WriteString(healthTableHeader)
+	for _, v := range ov.Vols {
+		sb.WriteString(formatPartitionsHealth(v.Name, "DataPartition", v.DataPartitions))
+		sb.WriteString(formatPartitionsHealth("", "MetaPartition", v.MetaPartitions))
+	}
+	sb.WriteString(formatPartitionsHealth("(cluster)", "DataPartition", ov.DataPartitions))
+	sb.WriteString(formatPartitionsHealth("", "MetaPartition", ov.MetaPartitions))
+	sb.WriteString("\nPending repairs:\n")
+	pr := ov.PendingRepairs
+	sb.WriteString(fmt.Sprintf("  Bad data partitions   : %v\n", countBadPartitions(pr.BadDataPartitions)))
+	sb.WriteString(fmt.Sprintf("  Bad meta partitions   : %v\n", countBadPartitions(pr.BadMetaPartitions)))
+	sb.WriteString(fmt.Sprintf("  Offline data nodes    : %v\n", pr.OfflineDataNodes))
+	sb.WriteString(fmt.Sprintf("  Offline meta nodes    : %v\n", pr.OfflineMetaNodes))
+	sb.WriteString(fmt.Sprintf("  Data node tasks       : %v\n", pr.DataNodePendingTasks))
+	sb.WriteString(fmt.Sprintf("  Meta node tasks       : %v\n", pr.MetaNodePendingTasks))
+	sb.WriteString("\nFault domains:\n")
+	sb.WriteString(formatFaultDomains(ov.FaultDomains))
+	return sb.String()
+}
+
+// formatFaultDomains lists the fault domains with the ones under them, the data nodes and the meta nodes in separate rows.
+func formatFaultDomains(domains []*proto.FaultDomainView) string {
+	var sb = strings.Builder{}
+	sb.WriteString(faultDomainTableHeader)
+	var walk func(domains []*proto.FaultDomainView)
+	walk = func(domains []*proto.FaultDomainView) {
+		for _, fd := range domains {
+			ds, ms := fd.DataNodeStat, fd.MetaNodeStat
+			sb.WriteString(fmt.Sprintf(faultDomainTablePattern, fd.Path, fd.Level, "DATANODE", ds.Total, ds.Used, ds.Avail, ds.UsedRatio, ds.TotalNodes, ds.WritableNodes))
+			sb.WriteString(fmt.Sprintf(faultDomainTablePattern, "", "", "METANODE", ms.Total, ms.Used, ms.Avail, ms.UsedRatio, ms.TotalNodes, ms.WritableNodes))
+			walk(fd.Children)
+		}
+	}
+	walk(domains)
+	return sb.String()
+}
+
+func formatNodeOverview(n *proto.NodeOverview) string {
+	return fmt.Sprintf(nodeOverviewTablePattern, n.ID, n.Addr, n.Zone, formatNodeStatus(n.IsActive), formatYesNo(n.IsWritable),
+		formatSize(n.Used), formatSize(n.Total), n.PartitionCount, n.LeaderCount, n.PendingTasks)
+}
+
+func formatPartitionsHealth(vol, partitionType string, h *proto.PartitionsHealth) string {
+	return fmt.Sprintf(healthTablePattern, vol, partitionType, h.Total, h.ReadWrite, h.ReadOnly, h.Unavailable, h.NoLeader)
+}
+
+func countBadPartitions(views []proto.BadPartitionView) (count int) {
+	for _, v := range views {
+		count += len(v.PartitionIDs)
+	}
+	return
+}
+
 var nodeViewTableRowPattern = "%-6v    %-18v    %-8v    %-8v"
 
 func formatNodeViewTableHeader() string {
@@ -95,12 +175,20 @@ func formatSimpleVolView(svv *proto.SimpleVolView) string {
 	sb.WriteString(fmt.Sprintf("  Owner                : %v\n", svv.Owner))
 	sb.WriteString(fmt.Sprintf("  Zone                 : %v\n", svv.ZoneName))
 	sb.WriteString(fmt.Sprintf("  Status               : %v\n", formatVolumeStatus(svv.Status)))
+	if svv.PurgeTime > 0 {
+		sb.WriteString(fmt.Sprintf("  Purge time           : %v\n", formatTime(svv.PurgeTime)))
+	}
 	sb.WriteString(fmt.Sprintf("  Capacity             : %v GB\n", svv.Capacity))
 	sb.WriteString(fmt.Sprintf("  Create time          : %v\n", svv.CreateTime))
 	sb.WriteString(fmt.Sprintf("  Authenticate         : %v\n", formatEnabledDisabled(svv.Authenticate)))
 	sb.WriteString(fmt.Sprintf("  Follower read        : %v\n", formatEnabledDisabled(svv.FollowerRead)))
 	sb.WriteString(fmt.Sprintf("  Enable token         : %v\n", formatEnabledDisabled(svv.EnableToken)))
 	sb.WriteString(fmt.Sprintf("  Cross zone           : %v\n", formatEnabledDisabled(svv.CrossZone)))
+	sb.WriteString(fmt.Sprintf("  Encryption           : %v\n", formatEnabledDisabled(svv.Encrypted)))
+	sb.WriteString(fmt.Sprintf("  Flat namespace       : %v\n", formatEnabledDisabled(svv.Flat)))
+	sb.WriteString(fmt.Sprintf("  Permission priority  : %v\n", formatPermissionPriority(svv.PermissionPriority)))
+	sb.WriteString(fmt.Sprintf("  Read only            : %v\n", formatYesNo(svv.ReadOnly)))
+	sb.WriteString(fmt.Sprintf("  Cache                : %v\n", formatYesNo(svv.Cache)))
 	sb.WriteString(fmt.Sprintf("  Inode count          : %v\n", svv.InodeCount))
 	sb.WriteString(fmt.Sprintf("  Dentry count         : %v\n", svv.DentryCount))
 	sb.WriteString(fmt.Sprintf("  Max metaPartition ID : %v\n", svv.MaxMetaPartitionID))
@@ -111,6 +199,21 @@ func formatSimpleVolView(svv *proto.SimpleVolView) string {
 	return sb.String()
 }
 
+func formatVolReplicaNumProgress(progress *proto.VolReplicaNumProgress) string {
+	var sb = strings.Builder{}
+	sb.WriteString(fmt.Sprintf("  Name                 : %v\n", progress.Name))
+	sb.WriteString(fmt.Sprintf("  Replicas             : %v -> %v\n", progress.OldReplicaNum, progress.NewReplicaNum))
+	sb.WriteString(fmt.Sprintf("  Status               : %v\n", progress.Status))
+	sb.WriteString(fmt.Sprintf("  Data partitions      : %v/%v done, %v failed\n", progress.Done, progress.Total, progress.Failed))
+	sb.WriteString(fmt.Sprintf("  Max migrations       : %v\n", progress.MaxMigrations))
+	sb.WriteString(fmt.Sprintf("  Start time           : %v\n", progress.StartTime))
+	sb.WriteString(fmt.Sprintf("  End time             : %v", progress.EndTime))
+	if progress.Msg != "" {
+		sb.WriteString(fmt.Sprintf("\n  Message              : %v", progress.Msg))
+	}
+	return sb.String()
+}
+
 func formatVolumeStatus(status uint8) string {
 	switch status {
 	case 0:
@@ -133,6 +236,52 @@ func formatVolInfoTableRow(vi *proto.VolInfo) string {
 		formatVolumeStatus(vi.Status), time.Unix(vi.CreateTime, 0).Local().Format(time.RFC1123))
 }
 
+var (
+	clientSessionTablePattern = "%-20v    %-15v    %-10v    %-32v    %-19v    %-8v"
+	clientSessionTableHeader  = fmt.Sprintf(clientSessionTablePattern, "ID", "HOST", "VERSION", "MOUNT POINT", "MOUNT TIME", "OPS/S")
+)
+
+func formatClientSessionTableRow(view *proto.ClientSessionView) string {
+	var rate float64
+	for _, r := range view.OpRates {
+		rate += r
+	}
+	return fmt.Sprintf(clientSessionTablePattern, view.ID, view.Host, view.Version, view.MountPoint,
+		formatTime(view.MountTime), strconv.FormatFloat(rate, 'f', 1, 64))
+}
+
+var (
+	adminAuditTablePattern = "%-19v    %-12v    %-15v    %-32v    %-6v    %v"
+	adminAuditTableHeader  = fmt.Sprintf(adminAuditTablePattern, "TIME", "OPERATOR", "ADDRESS", "API", "CODE", "PARAMS")
+)
+
+func formatAdminAuditTableRow(record *proto.AdminAuditRecord) string {
+	keys := make([]string, 0, len(record.Params))
+	for key := range record.Params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	params := make([]string, 0, len(keys))
+	for _, key := range keys {
+		params = append(params, fmt.Sprintf("%v=%v", key, record.Params[key]))
+	}
+	return fmt.Sprintf(adminAuditTablePattern, formatTime(record.Time), record.Operator, record.Addr, record.API,
+		record.Code, strings.Join(params, "&"))
+}
+
+func formatClientSessionOptions(view *proto.ClientSessionView) string {
+	keys := make([]string, 0, len(view.MountOptions))
+	for key := range view.MountOptions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var sb = strings.Builder{}
+	for _, key := range keys {
+		sb.WriteString(fmt.Sprintf("  %v=%v\n", key, view.MountOptions[key]))
+	}
+	return sb.String()
+}
+
 var (
 	dataPartitionTablePattern = "%-8v    %-8v    %-10v    %-10v     %-18v    %-18v"
 	dataPartitionTableHeader  = fmt.Sprintf(dataPartitionTablePattern,
@@ -335,6 +484,13 @@ func formatEnabledDisabled(b bool) string {
 	return "Disabled"
 }
 
+func formatPermissionPriority(priority string) string {
+	if priority == proto.PermissionPriorityNone {
+		return "None"
+	}
+	return priority
+}
+
 func formatNodeStatus(status bool) string {
 	if status {
 		return "Active"
@@ -441,10 +597,17 @@ func formatDataNodeDetail(dn *proto.DataNodeInfo, rowTable bool) string {
 	sb.WriteString(fmt.Sprintf("  Available           : %v\n", formatSize(dn.AvailableSpace)))
 	sb.WriteString(fmt.Sprintf("  Total               : %v\n", formatSize(dn.Total)))
 	sb.WriteString(fmt.Sprintf("  Zone                : %v\n", dn.ZoneName))
+	sb.WriteString(fmt.Sprintf("  Region              : %v\n", dn.Region))
+	sb.WriteString(fmt.Sprintf("  Rack                : %v\n", dn.Rack))
 	sb.WriteString(fmt.Sprintf("  IsActive            : %v\n", formatNodeStatus(dn.IsActive)))
 	sb.WriteString(fmt.Sprintf("  Report time         : %v\n", formatTimeToString(dn.ReportTime)))
 	sb.WriteString(fmt.Sprintf("  Partition count     : %v\n", dn.DataPartitionCount))
 	sb.WriteString(fmt.Sprintf("  Bad disks           : %v\n", dn.BadDisks))
+	for _, smart := range dn.DiskSmart {
+		if smart.PredictFailure {
+			sb.WriteString(fmt.Sprintf("  Failing disk        : %v(%v), %v\n", smart.Path, smart.Device, smart.Reason))
+		}
+	}
 	sb.WriteString(fmt.Sprintf("  Persist partitions  : %v\n", dn.PersistenceDataPartitions))
 	return sb.String()
 }
@@ -468,6 +631,8 @@ func formatMetaNodeDetail(mn *proto.MetaNodeInfo, rowTable bool) string {
 	sb.WriteString(fmt.Sprintf("  Used                : %v\n", formatSize(mn.Used)))
 	sb.WriteString(fmt.Sprintf("  Total               : %v\n", formatSize(mn.Total)))
 	sb.WriteString(fmt.Sprintf("  Zone                : %v\n", mn.ZoneName))
+	sb.WriteString(fmt.Sprintf("  Region              : %v\n", mn.Region))
+	sb.WriteString(fmt.Sprintf("  Rack                : %v\n", mn.Rack))
 	sb.WriteString(fmt.Sprintf("  IsActive            : %v\n", formatNodeStatus(mn.IsActive)))
 	sb.WriteString(fmt.Sprintf("  Report time         : %v\n", formatTimeToString(mn.ReportTime)))
 	sb.WriteString(fmt.Sprintf("  Partition count     : %v\n", mn.MetaPartitionCount))
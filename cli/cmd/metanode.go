@@ -68,8 +68,7 @@ func newMetaNodeListCmd(client *master.MasterClient) *cobra.Command {
 			sort.SliceStable(view.MetaNodes, func(i, j int) bool {
 				return view.MetaNodes[i].ID < view.MetaNodes[j].ID
 			})
-			stdout("[Meta nodes]\n")
-			stdout("%v\n", formatNodeViewTableHeader())
+			nodes := make([]proto.NodeView, 0, len(view.MetaNodes))
 			for _, node := range view.MetaNodes {
 				if optFilterStatus != "" &&
 					!strings.Contains(formatNodeStatus(node.Status), optFilterStatus) {
@@ -79,6 +78,14 @@ func newMetaNodeListCmd(client *master.MasterClient) *cobra.Command {
 					!strings.Contains(formatYesNo(node.IsWritable), optFilterWritable) {
 					continue
 				}
+				nodes = append(nodes, node)
+			}
+			if stdoutJSON(nodes) {
+				return
+			}
+			stdout("[Meta nodes]\n")
+			stdout("%v\n", formatNodeViewTableHeader())
+			for _, node := range nodes {
 				stdout("%v\n", formatNodeView(&node, true))
 			}
 		},
@@ -106,6 +113,9 @@ func newMetaNodeInfoCmd(client *master.MasterClient) *cobra.Command {
 			if metanodeInfo, err = client.NodeAPI().GetMetaNode(nodeAddr); err != nil {
 				return
 			}
+			if stdoutJSON(metanodeInfo) {
+				return
+			}
 			stdout("[Meta node info]\n")
 			stdout(formatMetaNodeDetail(metanodeInfo, false))
 
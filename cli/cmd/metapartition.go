@@ -39,6 +39,7 @@ func newMetaPartitionCmd(client *master.MasterClient) *cobra.Command {
 		newMetaPartitionDecommissionCmd(client),
 		newMetaPartitionReplicateCmd(client),
 		newMetaPartitionDeleteReplicaCmd(client),
+		newMetaPartitionVerifyCmd(client),
 	)
 	return cmd
 }
@@ -49,6 +50,7 @@ const (
 	cmdMetaPartitionDecommissionShort     = "Decommission a replication of the meta partition to a new address"
 	cmdMetaPartitionReplicateShort        = "Add a replication of the meta partition on a new address"
 	cmdMetaPartitionDeleteReplicaShort    = "Delete a replication of the meta partition on a fixed address"
+	cmdMetaPartitionVerifyShort           = "Compare the meta trees of the replications of the meta partition"
 	)
 
 func newMetaPartitionGetCmd(client *master.MasterClient) *cobra.Command {
@@ -73,6 +75,9 @@ func newMetaPartitionGetCmd(client *master.MasterClient) *cobra.Command {
 			if partition, err = client.ClientAPI().GetMetaPartition(partitionID); err != nil {
 				return
 			}
+			if stdoutJSON(partition) {
+				return
+			}
 			stdout(formatMetaPartitionInfo(partition))
 		},
 	}
@@ -103,6 +108,9 @@ the corrupt nodes, the few remaining replicas can not reach an agreement with on
 			if diagnosis, err = client.AdminAPI().DiagnoseMetaPartition(); err != nil {
 				return
 			}
+			if stdoutJSON(diagnosis) {
+				return
+			}
 			stdout("[Inactive Meta nodes]:\n")
 			stdout("%v\n", formatMetaNodeDetailTableHeader())
 			sort.SliceStable(diagnosis.InactiveMetaNodes, func(i, j int) bool {
@@ -265,3 +273,52 @@ func newMetaPartitionDeleteReplicaCmd(client *master.MasterClient) *cobra.Comman
 	}
 	return cmd
 }
+
+func newMetaPartitionVerifyCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpVerify + " [META PARTITION ID]",
+		Short: cmdMetaPartitionVerifyShort,
+		Long: `The leader proposes the verification through raft, so that each replica hashes its inode, dentry, extend,
+multipart and transaction trees at the same log index. The counts and hashes of the replicas are compared
+with those of the leader, a mismatch means the replicas diverge silently.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+				result      *proto.MetaPartitionVerifyResult
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitionID, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+				return
+			}
+			if result, err = client.AdminAPI().VerifyMetaPartition(partitionID); err != nil {
+				return
+			}
+			if stdoutJSON(result) {
+				return
+			}
+			stdout("[Meta partition %v of volume %v]\n", result.PartitionID, result.VolName)
+			for _, replica := range result.Replicas {
+				stdout("  %v  applyID(%v)", replica.Addr, replica.ApplyID)
+				for _, digest := range replica.Trees {
+					stdout("  %v(%v, %x)", digest.Tree, digest.Count, digest.Hash)
+				}
+				stdout("\n")
+			}
+			if result.Consistent {
+				stdout("The replicas are consistent.\n")
+				return
+			}
+			stdout("The replicas diverge:\n")
+			for _, mismatch := range result.Mismatches {
+				stdout("  %v\n", mismatch)
+			}
+		},
+	}
+	return cmd
+}
@@ -15,6 +15,7 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/chubaofs/chubaofs/util/log"
 	"os"
@@ -30,6 +31,14 @@ const (
 	cmdRootShort = "ChubaoFS Command Line Interface (CLI)"
 )
 
+// The formats of the output of the commands.
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+)
+
+var optOutput string
+
 type ChubaoFSCmd struct {
 	CFSCmd *cobra.Command
 }
@@ -41,6 +50,11 @@ func NewRootCmd(client *master.MasterClient) *ChubaoFSCmd {
 			Use:   path.Base(os.Args[0]),
 			Short: cmdRootShort,
 			Args:  cobra.MinimumNArgs(0),
+			PersistentPreRun: func(cmd *cobra.Command, args []string) {
+				if optOutput != OutputTable && optOutput != OutputJSON {
+					errout("Error: invalid output format [%v], should be %v or %v", optOutput, OutputTable, OutputJSON)
+				}
+			},
 			Run: func(cmd *cobra.Command, args []string) {
 				if optShowVersion {
 					stdout(proto.DumpVersion("CLI"))
@@ -51,6 +65,8 @@ func NewRootCmd(client *master.MasterClient) *ChubaoFSCmd {
 	}
 
 	cmd.CFSCmd.Flags().BoolVarP(&optShowVersion, "version", "v", false, "Show version information")
+	cmd.CFSCmd.PersistentFlags().StringVarP(&optOutput, "output", "o", OutputTable,
+		fmt.Sprintf("Output format of the info and list commands [%v, %v]", OutputTable, OutputJSON))
 
 	cmd.CFSCmd.AddCommand(
 		cmd.newClusterCmd(client),
@@ -71,6 +87,19 @@ func stdout(format string, a ...interface{}) {
 	_, _ = fmt.Fprintf(os.Stdout, format, a...)
 }
 
+// stdoutJSON prints the value in JSON if the JSON output is selected, and tells if it is printed.
+func stdoutJSON(v interface{}) bool {
+	if optOutput != OutputJSON {
+		return false
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		errout("Error: %v", err)
+	}
+	stdout("%s\n", data)
+	return true
+}
+
 func errout(format string, a ...interface{}) {
 	log.LogErrorf(format + "\n", a...)
 	_, _ = fmt.Fprintf(os.Stderr, format, a...)
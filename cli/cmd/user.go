@@ -291,6 +291,9 @@ func newUserInfoCmd(client *master.MasterClient) *cobra.Command {
 				err = fmt.Errorf("Get user info failed: %v\n", err)
 				return
 			}
+			if stdoutJSON(userInfo) {
+				return
+			}
 			printUserInfo(userInfo)
 		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -408,6 +411,9 @@ func newUserListCmd(client *master.MasterClient) *cobra.Command {
 			if users, err = client.UserAPI().ListUsers(optKeyword); err != nil {
 				return
 			}
+			if stdoutJSON(users) {
+				return
+			}
 			stdout("%v\n", userInfoTableHeader)
 			for _, user := range users {
 				stdout("%v\n", formatUserInfoTableRow(user))
@@ -47,8 +47,15 @@ func newVolCmd(client *master.MasterClient) *cobra.Command {
 		newVolSetCmd(client),
 		newVolInfoCmd(client),
 		newVolDeleteCmd(client),
+		newVolRestoreCmd(client),
+		newVolRotateKeyCmd(client),
 		newVolTransferCmd(client),
 		newVolAddDPCmd(client),
+		newVolClientsCmd(client),
+		newVolEvictClientCmd(client),
+		newVolReadOnlyCmd(client),
+		newVolRenameCmd(client),
+		newVolReplicasCmd(client),
 	)
 	return cmd
 }
@@ -74,6 +81,9 @@ func newVolListCmd(client *master.MasterClient) *cobra.Command {
 			if vols, err = client.AdminAPI().ListVols(optKeyword); err != nil {
 				return
 			}
+			if stdoutJSON(vols) {
+				return
+			}
 			stdout("%v\n", volumeInfoTableHeader)
 			for _, vol := range vols {
 				stdout("%v\n", formatVolInfoTableRow(vol))
@@ -103,6 +113,9 @@ func newVolCreateCmd(client *master.MasterClient) *cobra.Command {
 	var optFollowerRead bool
 	var optYes bool
 	var optZoneName string
+	var optEncrypt bool
+	var optFlat bool
+	var optCache bool
 	var cmd = &cobra.Command{
 		Use:   cmdVolCreateUse,
 		Short: cmdVolCreateShort,
@@ -116,6 +129,10 @@ func newVolCreateCmd(client *master.MasterClient) *cobra.Command {
 					errout("Error: %v", err)
 				}
 			}()
+			// the cache volume has a single replica by default
+			if optCache && !cmd.Flags().Changed(CliFlagReplicas) {
+				optReplicas = 1
+			}
 			// ask user for confirm
 			if !optYes {
 				stdout("Create a new volume:\n")
@@ -127,6 +144,9 @@ func newVolCreateCmd(client *master.MasterClient) *cobra.Command {
 				stdout("  Replicas            : %v\n", optReplicas)
 				stdout("  Allow follower read : %v\n", formatEnabledDisabled(optFollowerRead))
 				stdout("  ZoneName            : %v\n", optZoneName)
+				stdout("  Encryption          : %v\n", formatEnabledDisabled(optEncrypt))
+				stdout("  Flat namespace      : %v\n", formatEnabledDisabled(optFlat))
+				stdout("  Cache               : %v\n", formatEnabledDisabled(optCache))
 				stdout("\nConfirm (yes/no)[yes]: ")
 				var userConfirm string
 				_, _ = fmt.Scanln(&userConfirm)
@@ -138,7 +158,7 @@ func newVolCreateCmd(client *master.MasterClient) *cobra.Command {
 
 			err = client.AdminAPI().CreateVolume(
 				volumeName, userID, optMPCount, optDPSize,
-				optCapacity, optReplicas, optFollowerRead, optZoneName)
+				optCapacity, optReplicas, optFollowerRead, optZoneName, optEncrypt, optFlat, optCache)
 			if err != nil {
 				err = fmt.Errorf("Create volume failed case:\n%v\n", err)
 				return
@@ -153,6 +173,9 @@ func newVolCreateCmd(client *master.MasterClient) *cobra.Command {
 	cmd.Flags().IntVar(&optReplicas, CliFlagReplicas, cmdVolDefaultReplicas, "Specify data partition replicas number")
 	cmd.Flags().BoolVar(&optFollowerRead, CliFlagEnableFollowerRead, cmdVolDefaultFollowerReader, "Enable read form replica follower")
 	cmd.Flags().StringVar(&optZoneName, CliFlagZoneName, cmdVolDefaultZoneName, "Specify volume zone name")
+	cmd.Flags().BoolVar(&optEncrypt, CliFlagEncrypt, false, "Encrypt the data of the volume at rest")
+	cmd.Flags().BoolVar(&optFlat, CliFlagFlat, false, "Store the objects by their keys without directories, for the object storage only")
+	cmd.Flags().BoolVar(&optCache, CliFlagCache, false, "Create a cache volume with a single data replica without durability guarantee")
 	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
 	return cmd
 }
@@ -316,6 +339,10 @@ func newVolInfoCmd(client *master.MasterClient) *cobra.Command {
 				err = fmt.Errorf("Get volume info failed:\n%v\n", err)
 				return
 			}
+			if optOutput == OutputJSON {
+				err = printVolInfoJSON(client, svv, optMetaDetail, optDataDetail)
+				return
+			}
 			// print summary info
 			stdout("Summary:\n%s\n", formatSimpleVolView(svv))
 
@@ -366,6 +393,29 @@ func newVolInfoCmd(client *master.MasterClient) *cobra.Command {
 	return cmd
 }
 
+// printVolInfoJSON prints the volume and the details of the partitions if required in JSON.
+func printVolInfoJSON(client *master.MasterClient, svv *proto.SimpleVolView, metaDetail, dataDetail bool) (err error) {
+	var info = struct {
+		Summary        *proto.SimpleVolView
+		MetaPartitions []*proto.MetaPartitionView     `json:",omitempty"`
+		DataPartitions []*proto.DataPartitionResponse `json:",omitempty"`
+	}{Summary: svv}
+	if metaDetail {
+		if info.MetaPartitions, err = client.ClientAPI().GetMetaPartitions(svv.Name); err != nil {
+			return fmt.Errorf("Get volume metadata detail information failed:\n%v\n", err)
+		}
+	}
+	if dataDetail {
+		var view *proto.DataPartitionsView
+		if view, err = client.ClientAPI().GetDataPartitions(svv.Name); err != nil {
+			return fmt.Errorf("Get volume data detail information failed:\n%v\n", err)
+		}
+		info.DataPartitions = view.DataPartitions
+	}
+	stdoutJSON(info)
+	return
+}
+
 const (
 	cmdVolDeleteUse   = "delete [VOLUME NAME]"
 	cmdVolDeleteShort = "Delete a volume from cluster"
@@ -373,7 +423,8 @@ const (
 
 func newVolDeleteCmd(client *master.MasterClient) *cobra.Command {
 	var (
-		optYes bool
+		optYes   bool
+		optForce bool
 	)
 	var cmd = &cobra.Command{
 		Use:   cmdVolDeleteUse,
@@ -404,10 +455,15 @@ func newVolDeleteCmd(client *master.MasterClient) *cobra.Command {
 				return
 			}
 
-			if err = client.AdminAPI().DeleteVolume(volumeName, calcAuthKey(svv.Owner)); err != nil {
+			if err = client.AdminAPI().DeleteVolumeWithForce(volumeName, calcAuthKey(svv.Owner), optForce); err != nil {
 				err = fmt.Errorf("Delete volume failed:\n%v\n", err)
 				return
 			}
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err == nil && svv.PurgeTime > 0 {
+				stdout("Delete volume success, it can be restored before %v.\n", formatTime(svv.PurgeTime))
+				return
+			}
+			err = nil
 			stdout("Delete volume success.\n")
 		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -418,6 +474,62 @@ func newVolDeleteCmd(client *master.MasterClient) *cobra.Command {
 		},
 	}
 	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	cmd.Flags().BoolVarP(&optForce, "force", "f", false, "Purge the volume at once without the deletion delay")
+	return cmd
+}
+
+const (
+	cmdVolRestoreUse   = "restore [VOLUME NAME]"
+	cmdVolRestoreShort = "Restore a deleted volume before it is purged"
+)
+
+func newVolRestoreCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optYes bool
+	)
+	var cmd = &cobra.Command{
+		Use:   cmdVolRestoreUse,
+		Short: cmdVolRestoreShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			// ask user for confirm
+			if !optYes {
+				stdout("Restore volume [%v] (yes/no)[no]:", volumeName)
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+
+			var svv *proto.SimpleVolView
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
+				err = fmt.Errorf("Restore volume failed:\n%v\n", err)
+				return
+			}
+
+			if err = client.AdminAPI().RestoreVolume(volumeName, calcAuthKey(svv.Owner)); err != nil {
+				err = fmt.Errorf("Restore volume failed:\n%v\n", err)
+				return
+			}
+			stdout("Restore volume success.\n")
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
 	return cmd
 }
 
@@ -527,6 +639,306 @@ func newVolAddDPCmd(client *master.MasterClient) *cobra.Command {
 	return cmd
 }
 
+const (
+	cmdVolClientsUse   = CliOpClients + " [VOLUME NAME]"
+	cmdVolClientsShort = "List the clients mounting a volume"
+)
+
+func newVolClientsCmd(client *master.MasterClient) *cobra.Command {
+	var optDetail bool
+	var cmd = &cobra.Command{
+		Use:   cmdVolClientsUse,
+		Short: cmdVolClientsShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			var sessions []*proto.ClientSessionView
+			if sessions, err = client.AdminAPI().ListClients(volumeName); err != nil {
+				return
+			}
+			if stdoutJSON(sessions) {
+				return
+			}
+			stdout("%v\n", clientSessionTableHeader)
+			for _, session := range sessions {
+				stdout("%v\n", formatClientSessionTableRow(session))
+				if optDetail {
+					stdout("%v", formatClientSessionOptions(session))
+				}
+			}
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optDetail, "detail", "d", false, "Display the mount options of the clients")
+	return cmd
+}
+
+const (
+	cmdVolEvictClientUse   = CliOpEvictClient + " [VOLUME NAME] [CLIENT ID]"
+	cmdVolEvictClientShort = "Evict a client from a volume, releasing its locks and orphan inodes"
+)
+
+func newVolEvictClientCmd(client *master.MasterClient) *cobra.Command {
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   cmdVolEvictClientUse,
+		Short: cmdVolEvictClientShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			var clientID uint64
+			if clientID, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+				return
+			}
+			// ask user for confirm
+			if !optYes {
+				stdout("Evict client [%v] from volume [%v] (yes/no)[no]:", clientID, volumeName)
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			if err = client.AdminAPI().EvictClient(volumeName, clientID); err != nil {
+				err = fmt.Errorf("Evict client failed:\n%v\n", err)
+				return
+			}
+			stdout("Evict client success.\n")
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+const (
+	cmdVolReadOnlyUse   = CliOpReadOnly + " [VOLUME NAME] [ENABLE]"
+	cmdVolReadOnlyShort = "Freeze a volume read-only or thaw it"
+)
+
+func newVolReadOnlyCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdVolReadOnlyUse,
+		Short: cmdVolReadOnlyShort,
+		Long: `Freeze a volume read-only for the maintenance, the migration or the abuse containment.
+The meta nodes and the data nodes reject the writes to the frozen volume, and the clients get EROFS.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			var enable bool
+			if enable, err = strconv.ParseBool(args[1]); err != nil {
+				return
+			}
+			var svv *proto.SimpleVolView
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
+				return
+			}
+			if err = client.AdminAPI().SetVolumeReadOnly(volumeName, calcAuthKey(svv.Owner), enable); err != nil {
+				return
+			}
+			if enable {
+				stdout("Freeze volume [%v] read-only successful!\n", volumeName)
+			} else {
+				stdout("Thaw volume [%v] successful!\n", volumeName)
+			}
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+const (
+	cmdVolRenameUse   = CliOpRename + " [VOLUME NAME] [NEW NAME]"
+	cmdVolRenameShort = "Rename a volume"
+)
+
+func newVolRenameCmd(client *master.MasterClient) *cobra.Command {
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   cmdVolRenameUse,
+		Short: cmdVolRenameShort,
+		Long: `Rename a volume. The former name stays an alias of the volume, so that the mounted clients keep working,
+and it can not be taken by another volume until the volume is deleted.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			var newName = args[1]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			// ask user for confirm
+			if !optYes {
+				stdout("Rename volume [%v] to [%v] (yes/no)[no]:", volumeName, newName)
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			var svv *proto.SimpleVolView
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
+				return
+			}
+			if err = client.AdminAPI().RenameVolume(volumeName, newName, calcAuthKey(svv.Owner)); err != nil {
+				return
+			}
+			stdout("Rename volume [%v] to [%v] successful!\n", volumeName, newName)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+const (
+	cmdVolReplicasUse   = CliOpReplicas + " [VOLUME NAME] [REPLICA NUM]"
+	cmdVolReplicasShort = "Change the data replica number of a volume, or show the progress of the change"
+)
+
+func newVolReplicasCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optYes           bool
+		optMaxMigrations int
+	)
+	var cmd = &cobra.Command{
+		Use:   cmdVolReplicasUse,
+		Short: cmdVolReplicasShort,
+		Long: `Change the data replica number of a volume between 2 and 3. The existing data partitions are converted
+partition by partition in background, the progress is shown without the replica number.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if len(args) == 1 {
+				var progress *proto.VolReplicaNumProgress
+				if progress, err = client.AdminAPI().GetVolReplicaNumProgress(volumeName); err != nil {
+					return
+				}
+				stdout("%v\n", formatVolReplicaNumProgress(progress))
+				return
+			}
+			var replicaNum int
+			if replicaNum, err = strconv.Atoi(args[1]); err != nil {
+				return
+			}
+			// ask user for confirm
+			if !optYes {
+				stdout("Change data replicas of volume [%v] to [%v] (yes/no)[no]:", volumeName, replicaNum)
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			var svv *proto.SimpleVolView
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
+				return
+			}
+			if err = client.AdminAPI().SetVolumeReplicaNum(volumeName, calcAuthKey(svv.Owner), replicaNum, optMaxMigrations); err != nil {
+				return
+			}
+			stdout("Change data replicas of volume [%v] to [%v] successful, the data partitions are being converted.\n", volumeName, replicaNum)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	cmd.Flags().IntVar(&optMaxMigrations, "max-migrations", 0, "Max number of data partitions being recovered at a time")
+	return cmd
+}
+
+const (
+	cmdVolRotateKeyUse   = CliOpRotateKey + " [VOLUME NAME]"
+	cmdVolRotateKeyShort = "Re-wrap the data key of an encrypted volume with the active master key"
+)
+
+func newVolRotateKeyCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdVolRotateKeyUse,
+		Short: cmdVolRotateKeyShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			var svv *proto.SimpleVolView
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
+				err = fmt.Errorf("Rotate key of volume failed:\n%v\n", err)
+				return
+			}
+			if err = client.AdminAPI().RotateVolumeKey(volumeName, calcAuthKey(svv.Owner)); err != nil {
+				err = fmt.Errorf("Rotate key of volume failed:\n%v\n", err)
+				return
+			}
+			stdout("Rotate key of volume success.\n")
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
 const (
 	cmdExpandVolCmdShort = "Expand capacity of a volume"
 	cmdShrinkVolCmdShort = "Shrink capacity of a volume"
@@ -60,6 +60,9 @@ func newZoneListCmd(client *sdk.MasterClient) *cobra.Command {
 			if zones, err = client.AdminAPI().ListZones(); err != nil {
 				return
 			}
+			if stdoutJSON(zones) {
+				return
+			}
 			zoneTablePattern := "%-8v    %-10v\n"
 			stdout(zoneTablePattern, "ZONE", "STATUS")
 			for _, zone := range zones {
@@ -103,6 +106,9 @@ func newZoneInfoCmd(client *sdk.MasterClient) *cobra.Command {
 				err = fmt.Errorf("Zone[%v] not exists in cluster\n ", zoneName)
 				return
 			}
+			if stdoutJSON(zoneView) {
+				return
+			}
 			stdout(formatZoneView(zoneView))
 			return
 		},
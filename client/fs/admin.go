@@ -0,0 +1,89 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// ListOpenFiles lists the files opened by the processes on the mount point.
+func (s *Super) ListOpenFiles(w http.ResponseWriter, r *http.Request) {
+	for _, stat := range s.ec.StreamerStats() {
+		if stat.Refcnt <= 0 {
+			continue
+		}
+		w.Write([]byte(fmt.Sprintf("%v\tino(%v) handles(%v) size(%v)\n", s.nodePath(stat.Inode), stat.Inode, stat.Refcnt, stat.Size)))
+	}
+}
+
+// ListStreamers lists the state of the data streamers, i.e. the files opened or recently accessed.
+func (s *Super) ListStreamers(w http.ResponseWriter, r *http.Request) {
+	for _, stat := range s.ec.StreamerStats() {
+		w.Write([]byte(fmt.Sprintf("ino(%v) handles(%v) size(%v) dirty(%v) requests(%v) idle(%v)\n",
+			stat.Inode, stat.Refcnt, stat.Size, stat.Dirty, stat.Requests, stat.Idle)))
+	}
+}
+
+// GetOpStats shows the count and the latencies of the finished FUSE requests of each op since the mount.
+func (s *Super) GetOpStats(w http.ResponseWriter, r *http.Request) {
+	s.ops.Lock()
+	ops := make([]string, 0, len(s.ops.stats))
+	stats := make(map[string]opStat, len(s.ops.stats))
+	for op, stat := range s.ops.stats {
+		ops = append(ops, op)
+		stats[op] = *stat
+	}
+	s.ops.Unlock()
+	sort.Strings(ops)
+	for _, op := range ops {
+		stat := stats[op]
+		w.Write([]byte(fmt.Sprintf("%v\tcount(%v) avg(%v) max(%v)\n", op, stat.count,
+			stat.total/time.Duration(stat.count), stat.max)))
+	}
+}
+
+// ListInflightOps lists the FUSE requests being handled, the oldest first.
+func (s *Super) ListInflightOps(w http.ResponseWriter, r *http.Request) {
+	s.ops.Lock()
+	keys := make([]opKey, 0, len(s.ops.inflight))
+	for key := range s.ops.inflight {
+		keys = append(keys, key)
+	}
+	s.ops.Unlock()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].start.Before(keys[j].start) })
+	for _, key := range keys {
+		var path string
+		if key.name != "" {
+			path = s.entryPath(key.ino, key.name)
+		} else {
+			path = s.nodePath(key.ino)
+		}
+		w.Write([]byte(fmt.Sprintf("%v\tpath(%v) ino(%v) elapsed(%v)\n", key.op, path, key.ino, time.Since(key.start))))
+	}
+}
+
+// FlushCache drops the cached inodes, lookup misses and read data, so they are got from the cluster again.
+// The kernel caches of the mount point are not affected.
+func (s *Super) FlushCache(w http.ResponseWriter, r *http.Request) {
+	s.ic.Clear()
+	if s.ndcache != nil {
+		s.ndcache.Clear()
+	}
+	s.ec.ClearReadCache()
+	w.Write([]byte("Flush cache successfully\n"))
+}
@@ -0,0 +1,146 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"bazil.org/fuse"
+	"bazil.org/fuse/fs"
+
+	"github.com/chubaofs/chubaofs/util/audit"
+)
+
+// The maximum depth of the paths resolved for the audit, in case of a loop in the node cache.
+const maxAuditPathDepth = 4096
+
+// auditEntry describes a mutation of the filesystem by the dentries,
+// the paths are only resolved if the audit is enabled.
+type auditEntry struct {
+	op        string
+	parent    uint64 // the path is of the node ino if parent is zero
+	name      string
+	ino       uint64
+	dstParent uint64 // the destination of rename and link
+	dstName   string
+	detail    string
+}
+
+// audit records the mutation with the caller and the result of the request.
+func (s *Super) audit(hdr *fuse.Header, e *auditEntry, err error) {
+	if !audit.Enabled() {
+		return
+	}
+	entry := &audit.Entry{
+		Op:     e.op,
+		Inode:  e.ino,
+		Uid:    hdr.Uid,
+		Gid:    hdr.Gid,
+		Pid:    hdr.Pid,
+		Detail: e.detail,
+		Result: audit.ResultOf(err),
+	}
+	if e.parent != 0 {
+		entry.Path = s.entryPath(e.parent, e.name)
+	} else {
+		entry.Path = s.nodePath(e.ino)
+	}
+	if e.dstParent != 0 {
+		entry.Dst = s.entryPath(e.dstParent, e.dstName)
+	}
+	audit.Log(entry)
+}
+
+// setNodeDentry records the last known dentry of the node to resolve its path,
+// the caller must hold s.fslock.
+func setNodeDentry(node fs.Node, parent uint64, name string) {
+	switch n := node.(type) {
+	case *Dir:
+		n.parentIno, n.name = parent, name
+	case *File:
+		n.parentIno, n.name = parent, name
+	}
+}
+
+func nodeInode(node fs.Node) uint64 {
+	switch n := node.(type) {
+	case *Dir:
+		return n.info.Inode
+	case *File:
+		return n.info.Inode
+	}
+	return 0
+}
+
+func (s *Super) entryPath(parent uint64, name string) string {
+	return path.Join(s.nodePath(parent), name)
+}
+
+// nodePath resolves the path of the node under the mount point by the nodes in the cache.
+// The path starts with the inode of the first unknown ancestor if the path is broken,
+// and the path of a file with hard links is the last one looked up.
+func (s *Super) nodePath(ino uint64) string {
+	s.fslock.Lock()
+	defer s.fslock.Unlock()
+	var names []string
+	for depth := 0; ino != s.rootIno && depth < maxAuditPathDepth; depth++ {
+		var parent uint64
+		var name string
+		switch n := s.nodeCache[ino].(type) {
+		case *Dir:
+			parent, name = n.parentIno, n.name
+		case *File:
+			parent, name = n.parentIno, n.name
+		}
+		if name == "" {
+			names = append(names, fmt.Sprintf("<inode %v>", ino))
+			break
+		}
+		names = append(names, name)
+		ino = parent
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	if len(names) > 0 && strings.HasPrefix(names[0], "<inode ") {
+		return strings.Join(names, "/")
+	}
+	return "/" + strings.Join(names, "/")
+}
+
+func setattrDetail(req *fuse.SetattrRequest) string {
+	var attrs []string
+	if req.Valid.Mode() {
+		attrs = append(attrs, fmt.Sprintf("mode=%v", req.Mode))
+	}
+	if req.Valid.Uid() {
+		attrs = append(attrs, fmt.Sprintf("uid=%v", req.Uid))
+	}
+	if req.Valid.Gid() {
+		attrs = append(attrs, fmt.Sprintf("gid=%v", req.Gid))
+	}
+	if req.Valid.Size() {
+		attrs = append(attrs, fmt.Sprintf("size=%v", req.Size))
+	}
+	if req.Valid.Atime() {
+		attrs = append(attrs, fmt.Sprintf("atime=%v", req.Atime.Unix()))
+	}
+	if req.Valid.Mtime() {
+		attrs = append(attrs, fmt.Sprintf("mtime=%v", req.Mtime.Unix()))
+	}
+	return strings.Join(attrs, ",")
+}
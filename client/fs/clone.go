@@ -0,0 +1,97 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// CloneFile clones the src file to dst without copying the data.
+// The FICLONE ioctl is handled by the kernel with the remap_file_range of the file system which FUSE does not implement,
+// so it never reaches the client, and the files are cloned by the admin API of the client instead.
+func (s *Super) CloneFile(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		w.Write([]byte(err.Error()))
+		return
+	}
+	src, dst := r.FormValue("src"), r.FormValue("dst")
+	if src == "" || dst == "" {
+		w.Write([]byte("Clone file failed: src and dst are required\n"))
+		return
+	}
+	if err := s.cloneFile(src, dst); err != nil {
+		w.Write([]byte(fmt.Sprintf("Clone %v to %v failed: %v\n", src, dst, err)))
+		return
+	}
+	w.Write([]byte(fmt.Sprintf("Clone %v to %v successfully\n", src, dst)))
+}
+
+// cloneFile creates the dst file sharing the extents of the src file, the paths are relative to the mount point.
+func (s *Super) cloneFile(src, dst string) (err error) {
+	srcIno, mode, err := s.lookupPath(src)
+	if err != nil {
+		return
+	}
+	if !proto.IsRegular(mode) {
+		return syscall.EINVAL
+	}
+	dstDir, name := "", strings.Trim(dst, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		dstDir, name = name[:i], name[i+1:]
+	}
+	parentIno, mode, err := s.lookupPath(dstDir)
+	if err != nil {
+		return
+	}
+	if !proto.IsDir(mode) {
+		return syscall.ENOTDIR
+	}
+	// the data written to the src file so far is cloned
+	if s.ec.GetStreamer(srcIno) != nil {
+		if err = s.ec.Flush(srcIno); err != nil {
+			return
+		}
+	}
+	info, err := s.InodeGet(srcIno)
+	if err != nil {
+		return
+	}
+	if _, err = s.mw.Clone_ll(srcIno, parentIno, name, info.Uid, info.Gid); err != nil {
+		return
+	}
+	s.ndcache.Delete(parentIno, name)
+	s.ic.Delete(parentIno)
+	return
+}
+
+// lookupPath returns the inode and the mode of the path relative to the mount point.
+func (s *Super) lookupPath(path string) (ino uint64, mode uint32, err error) {
+	ino, mode = s.rootIno, proto.Mode(os.ModeDir|0755)
+	for _, name := range strings.Split(path, "/") {
+		if name == "" || name == "." {
+			continue
+		}
+		if ino, mode, err = s.mw.Lookup_ll(ino, name); err != nil {
+			return
+		}
+	}
+	return
+}
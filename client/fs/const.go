@@ -35,6 +35,7 @@ const (
 const (
 	DefaultInodeExpiration = 120 * time.Second
 	MaxInodeCache          = 10000000 // in terms of the number of items
+	MaxNegativeDentryCache = 1000000
 )
 
 const (
@@ -46,6 +47,11 @@ const (
 	DeleteExtentsTimeout = 600 * time.Second
 )
 
+const (
+	// the interval to retry a blocking file lock request
+	LockWaitInterval = 100 * time.Millisecond
+)
+
 var (
 	// The following two are used in the FUSE cache
 	// every time the lookup will be performed on the fly, and the result will not be cached
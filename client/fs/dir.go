@@ -16,6 +16,8 @@ package fs
 
 import (
 	"os"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -24,6 +26,8 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/meta"
+	"github.com/chubaofs/chubaofs/util/audit"
 	"github.com/chubaofs/chubaofs/util/exporter"
 	"github.com/chubaofs/chubaofs/util/log"
 )
@@ -33,6 +37,10 @@ type Dir struct {
 	super  *Super
 	info   *proto.InodeInfo
 	dcache *DentryCache
+
+	// the last known dentry to resolve the path for the audit, protected by super.fslock
+	parentIno uint64
+	name      string
 }
 
 // Functions that Dir needs to implement
@@ -45,7 +53,7 @@ var (
 	_ fs.NodeRemover         = (*Dir)(nil)
 	_ fs.NodeFsyncer         = (*Dir)(nil)
 	_ fs.NodeRequestLookuper = (*Dir)(nil)
-	_ fs.HandleReadDirAller  = (*Dir)(nil)
+	_ fs.NodeOpener          = (*Dir)(nil)
 	_ fs.NodeRenamer         = (*Dir)(nil)
 	_ fs.NodeSetattrer       = (*Dir)(nil)
 	_ fs.NodeSymlinker       = (*Dir)(nil)
@@ -53,6 +61,13 @@ var (
 	_ fs.NodeListxattrer     = (*Dir)(nil)
 	_ fs.NodeSetxattrer      = (*Dir)(nil)
 	_ fs.NodeRemovexattrer   = (*Dir)(nil)
+	_ fs.NodeAccesser        = (*Dir)(nil)
+)
+
+// Functions that DirHandle needs to implement
+var (
+	_ fs.HandleReader         = (*DirHandle)(nil)
+	_ fs.HandleReadDirPlusser = (*DirHandle)(nil)
 )
 
 // NewDir returns a new directory.
@@ -65,6 +80,7 @@ func NewDir(s *Super, i *proto.InodeInfo) fs.Node {
 
 // Attr set the attributes of a directory.
 func (d *Dir) Attr(ctx context.Context, a *fuse.Attr) error {
+	defer d.super.trackOp(d.super.startOp("getattr", d.info.Inode, ""))
 	ino := d.info.Inode
 	info, err := d.super.InodeGet(ino)
 	if err != nil {
@@ -72,29 +88,45 @@ func (d *Dir) Attr(ctx context.Context, a *fuse.Attr) error {
 		return ParseError(err)
 	}
 	fillAttr(info, a)
+	d.super.ids.mapAttr(a)
 	log.LogDebugf("TRACE Attr: inode(%v)", info)
 	return nil
 }
 
 // Create handles the create request.
 func (d *Dir) Create(ctx context.Context, req *fuse.CreateRequest, resp *fuse.CreateResponse) (fs.Node, fs.Handle, error) {
+	defer d.super.trackOp(d.super.startOp("create", d.info.Inode, req.Name))
 	start := time.Now()
 
 	var err error
 	metric := exporter.NewTPCnt("filecreate")
 	defer metric.Set(err)
+	defer func() {
+		d.super.audit(&req.Header, &auditEntry{op: "create", parent: d.info.Inode, name: req.Name}, err)
+	}()
 
-	info, err := d.super.mw.Create_ll(d.info.Inode, req.Name, proto.Mode(req.Mode.Perm()), req.Uid, req.Gid, nil)
+	mode, access, def, err := d.newInodeMode(req.Mode.Perm(), req.Umask)
+	if err != nil {
+		return nil, nil, ParseError(err)
+	}
+	uid, gid := d.super.ids.creator(req.Uid, req.Gid)
+	info, err := d.super.caller(req.Header).Create_ll(d.info.Inode, req.Name, mode, uid, gid, nil)
 	if err != nil {
 		log.LogErrorf("Create: parent(%v) req(%v) err(%v)", d.info.Inode, req, err)
 		return nil, nil, ParseError(err)
 	}
+	if err = d.super.inheritPosixACL(info.Inode, access, def); err != nil {
+		log.LogErrorf("Create: parent(%v) ino(%v) inherit acl err(%v)", d.info.Inode, info.Inode, err)
+		err = nil
+	}
 
 	d.super.ic.Put(info)
+	d.super.ndcache.Delete(d.info.Inode, req.Name)
 	child := NewFile(d.super, info)
 	d.super.ec.OpenStream(info.Inode)
 
 	d.super.fslock.Lock()
+	setNodeDentry(child, d.info.Inode, req.Name)
 	d.super.nodeCache[info.Inode] = child
 	d.super.fslock.Unlock()
 
@@ -110,8 +142,74 @@ func (d *Dir) Create(ctx context.Context, req *fuse.CreateRequest, resp *fuse.Cr
 	return child, child, nil
 }
 
+// Tmpfile handles the request to create an unnamed file by O_TMPFILE.
+// The file is created as an orphan inode held by this client, and it is either linked
+// into the namespace by linkat or removed once it is closed.
+func (d *Dir) Tmpfile(ctx context.Context, req *fuse.TmpfileRequest, resp *fuse.CreateResponse) (fs.Node, fs.Handle, error) {
+	defer d.super.trackOp(d.super.startOp("tmpfile", d.info.Inode, ""))
+	start := time.Now()
+
+	var err error
+	metric := exporter.NewTPCnt("tmpfile")
+	defer metric.Set(err)
+	defer func() {
+		d.super.audit(&req.Header, &auditEntry{op: "tmpfile", parent: d.info.Inode}, err)
+	}()
+
+	if err = d.super.access(req.Header, d.info.Inode, proto.PermWrite|proto.PermExec); err != nil {
+		return nil, nil, ParseError(err)
+	}
+
+	mode, access, def, err := d.newInodeMode(req.Mode.Perm(), req.Umask)
+	if err != nil {
+		return nil, nil, ParseError(err)
+	}
+	uid, gid := d.super.ids.creator(req.Uid, req.Gid)
+	info, err := d.super.mw.InodeCreate_ll(mode, uid, gid, nil)
+	if err != nil {
+		log.LogErrorf("Tmpfile: parent(%v) req(%v) err(%v)", d.info.Inode, req, err)
+		return nil, nil, ParseError(err)
+	}
+	if err = d.super.inheritPosixACL(info.Inode, access, def); err != nil {
+		log.LogErrorf("Tmpfile: parent(%v) ino(%v) inherit acl err(%v)", d.info.Inode, info.Inode, err)
+		err = nil
+	}
+
+	// hold the inode before it is unlinked, so that it is never deleted while it is open
+	held := true
+	if err = d.super.holdOrphan(info.Inode); err != nil {
+		log.LogWarnf("Tmpfile: parent(%v) ino(%v) hold err(%v)", d.info.Inode, info.Inode, err)
+		held, err = false, nil
+	}
+	if info, err = d.super.mw.InodeUnlink_ll(info.Inode); err != nil {
+		log.LogErrorf("Tmpfile: parent(%v) req(%v) unlink err(%v)", d.info.Inode, req, err)
+		return nil, nil, ParseError(err)
+	}
+	d.super.orphan.Put(info.Inode)
+	if held {
+		d.super.orphan.Hold(info.Inode)
+	}
+
+	d.super.ic.Put(info)
+	child := NewFile(d.super, info)
+	d.super.ec.OpenStream(info.Inode)
+
+	d.super.fslock.Lock()
+	d.super.nodeCache[info.Inode] = child
+	d.super.fslock.Unlock()
+
+	if d.super.keepCache {
+		resp.Flags |= fuse.OpenKeepCache
+	}
+
+	elapsed := time.Since(start)
+	log.LogDebugf("TRACE Tmpfile: parent(%v) req(%v) resp(%v) ino(%v) (%v)ns", d.info.Inode, req, resp, info.Inode, elapsed.Nanoseconds())
+	return child, child, nil
+}
+
 // Forget is called when the evict is invoked from the kernel.
 func (d *Dir) Forget() {
+	defer d.super.trackOp(d.super.startOp("forget", d.info.Inode, ""))
 	ino := d.info.Inode
 	defer func() {
 		log.LogDebugf("TRACE Forget: ino(%v)", ino)
@@ -126,22 +224,37 @@ func (d *Dir) Forget() {
 
 // Mkdir handles the mkdir request.
 func (d *Dir) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (fs.Node, error) {
+	defer d.super.trackOp(d.super.startOp("mkdir", d.info.Inode, req.Name))
 	start := time.Now()
 
 	var err error
 	metric := exporter.NewTPCnt("mkdir")
 	defer metric.Set(err)
+	defer func() {
+		d.super.audit(&req.Header, &auditEntry{op: "mkdir", parent: d.info.Inode, name: req.Name}, err)
+	}()
 
-	info, err := d.super.mw.Create_ll(d.info.Inode, req.Name, proto.Mode(os.ModeDir|req.Mode.Perm()), req.Uid, req.Gid, nil)
+	mode, access, def, err := d.newInodeMode(os.ModeDir|req.Mode.Perm(), req.Umask)
+	if err != nil {
+		return nil, ParseError(err)
+	}
+	uid, gid := d.super.ids.creator(req.Uid, req.Gid)
+	info, err := d.super.caller(req.Header).Create_ll(d.info.Inode, req.Name, mode, uid, gid, nil)
 	if err != nil {
 		log.LogErrorf("Mkdir: parent(%v) req(%v) err(%v)", d.info.Inode, req, err)
 		return nil, ParseError(err)
 	}
+	if err = d.super.inheritPosixACL(info.Inode, access, def); err != nil {
+		log.LogErrorf("Mkdir: parent(%v) ino(%v) inherit acl err(%v)", d.info.Inode, info.Inode, err)
+		err = nil
+	}
 
 	d.super.ic.Put(info)
+	d.super.ndcache.Delete(d.info.Inode, req.Name)
 	child := NewDir(d.super, info)
 
 	d.super.fslock.Lock()
+	setNodeDentry(child, d.info.Inode, req.Name)
 	d.super.nodeCache[info.Inode] = child
 	d.super.fslock.Unlock()
 
@@ -154,6 +267,7 @@ func (d *Dir) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (fs.Node, error
 
 // Remove handles the remove request.
 func (d *Dir) Remove(ctx context.Context, req *fuse.RemoveRequest) error {
+	defer d.super.trackOp(d.super.startOp("remove", d.info.Inode, req.Name))
 	start := time.Now()
 	d.dcache.Delete(req.Name)
 
@@ -161,7 +275,39 @@ func (d *Dir) Remove(ctx context.Context, req *fuse.RemoveRequest) error {
 	metric := exporter.NewTPCnt("remove")
 	defer metric.Set(err)
 
-	info, err := d.super.mw.Delete_ll(d.info.Inode, req.Name, req.Dir)
+	entry := &auditEntry{op: "unlink", parent: d.info.Inode, name: req.Name}
+	if req.Dir {
+		entry.op = "rmdir"
+	}
+	defer func() {
+		d.super.audit(&req.Header, entry, err)
+	}()
+
+	if !req.Dir {
+		var moved bool
+		if moved, err = d.moveToTrash(req.Name); err != nil {
+			log.LogErrorf("Remove: move to trash failed, parent(%v) name(%v) err(%v)", d.info.Inode, req.Name, err)
+			return ParseError(err)
+		}
+		if moved {
+			entry.detail = "trash"
+			d.super.ic.Delete(d.info.Inode)
+			return nil
+		}
+	} else if d.super.asyncRmdir {
+		var moved bool
+		if moved, err = d.moveToPendingDelete(req.Name); err != nil {
+			log.LogErrorf("Remove: move to pending delete failed, parent(%v) name(%v) err(%v)", d.info.Inode, req.Name, err)
+			return ParseError(err)
+		}
+		if moved {
+			entry.detail = "async"
+			d.super.ic.Delete(d.info.Inode)
+			return nil
+		}
+	}
+
+	info, err := d.super.caller(req.Header).Delete_ll(d.info.Inode, req.Name, req.Dir)
 	if err != nil {
 		log.LogErrorf("Remove: parent(%v) name(%v) err(%v)", d.info.Inode, req.Name, err)
 		return ParseError(err)
@@ -169,8 +315,11 @@ func (d *Dir) Remove(ctx context.Context, req *fuse.RemoveRequest) error {
 
 	d.super.ic.Delete(d.info.Inode)
 
+	if info != nil {
+		entry.ino = info.Inode
+	}
 	if info != nil && info.Nlink == 0 && !proto.IsDir(info.Mode) {
-		d.super.orphan.Put(info.Inode)
+		d.super.putOrphan(info.Inode)
 		log.LogDebugf("Remove: add to orphan inode list, ino(%v)", info.Inode)
 	}
 
@@ -180,11 +329,13 @@ func (d *Dir) Remove(ctx context.Context, req *fuse.RemoveRequest) error {
 }
 
 func (d *Dir) Fsync(ctx context.Context, req *fuse.FsyncRequest) error {
+	defer d.super.trackOp(d.super.startOp("fsyncdir", d.info.Inode, ""))
 	return nil
 }
 
 // Lookup handles the lookup request.
 func (d *Dir) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse.LookupResponse) (fs.Node, error) {
+	defer d.super.trackOp(d.super.startOp("lookup", d.info.Inode, req.Name))
 	var (
 		ino uint64
 		err error
@@ -194,9 +345,14 @@ func (d *Dir) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse.Lo
 
 	ino, ok := d.dcache.Get(req.Name)
 	if !ok {
-		ino, _, err = d.super.mw.Lookup_ll(d.info.Inode, req.Name)
+		if d.super.ndcache.Has(d.info.Inode, req.Name) {
+			return nil, fuse.ENOENT
+		}
+		ino, _, err = d.super.caller(req.Header).Lookup_ll(d.info.Inode, req.Name)
 		if err != nil {
-			if err != syscall.ENOENT {
+			if err == syscall.ENOENT {
+				d.super.ndcache.Put(d.info.Inode, req.Name)
+			} else {
 				log.LogErrorf("Lookup: parent(%v) name(%v) err(%v)", d.info.Inode, req.Name, err)
 			}
 			return nil, ParseError(err)
@@ -222,58 +378,215 @@ func (d *Dir) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse.Lo
 		}
 		d.super.nodeCache[ino] = child
 	}
+	setNodeDentry(child, d.info.Inode, req.Name)
 	d.super.fslock.Unlock()
 
 	resp.EntryValid = LookupValidDuration
 	return child, nil
 }
 
-// ReadDirAll gets all the dentries in a directory and puts them into the cache.
-func (d *Dir) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
+// Open returns a new handle to read the dentries of the directory.
+func (d *Dir) Open(ctx context.Context, req *fuse.OpenRequest, resp *fuse.OpenResponse) (fs.Handle, error) {
+	defer d.super.trackOp(d.super.startOp("opendir", d.info.Inode, ""))
+	if err := d.super.access(req.Header, d.info.Inode, proto.PermRead); err != nil {
+		return nil, ParseError(err)
+	}
+	return &DirHandle{d: d, caller: d.super.caller(req.Header)}, nil
+}
+
+// Access checks the permissions of the user on the directory for access(2).
+func (d *Dir) Access(ctx context.Context, req *fuse.AccessRequest) error {
+	if err := d.super.access(req.Header, d.info.Inode, req.Mask); err != nil {
+		return ParseError(err)
+	}
+	return nil
+}
+
+// DirHandle streams the dentries of a directory to the kernel in batches of meta.ReadDirLimit,
+// so the dentries of a huge directory are never held at once. The offset of a dentry is its
+// position in the stream, which the kernel passes back to continue the reading.
+type DirHandle struct {
+	sync.Mutex
+	d        *Dir
+	caller   *meta.Caller   // the user who opened the directory
+	children []proto.Dentry // the dentries not consumed by the kernel yet
+	pos      uint64         // the position of children[0] in the stream
+	marker   string         // the name of the last dentry read
+	eof      bool
+	dcache   *DentryCache
+}
+
+// Read replies the dentries starting from the offset of the request.
+func (h *DirHandle) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadResponse) error {
+	d := h.d
+	defer d.super.trackOp(d.super.startOp("readdir", d.info.Inode, ""))
 	start := time.Now()
 
 	var err error
 	metric := exporter.NewTPCnt("readdir")
 	defer metric.Set(err)
 
-	children, err := d.super.mw.ReadDir_ll(d.info.Inode)
-	if err != nil {
-		log.LogErrorf("Readdir: ino(%v) err(%v)", d.info.Inode, err)
-		return make([]fuse.Dirent, 0), ParseError(err)
+	h.Lock()
+	defer h.Unlock()
+	var n int
+	if n, err = h.fill(req, false); err != nil {
+		log.LogErrorf("Readdir: ino(%v) offset(%v) err(%v)", d.info.Inode, req.Offset, err)
+		return ParseError(err)
+	}
+	data := resp.Data[:0]
+	for i, child := range h.children[:n] {
+		data = req.AppendDirent(data, childDirent(child), h.pos+uint64(i)+1, nil)
 	}
+	resp.Data = data
 
-	inodes := make([]uint64, 0, len(children))
-	dirents := make([]fuse.Dirent, 0, len(children))
+	elapsed := time.Since(start)
+	log.LogDebugf("TRACE ReadDir: ino(%v) offset(%v) size(%v) (%v)ns", d.info.Inode, req.Offset, len(resp.Data), elapsed.Nanoseconds())
+	return nil
+}
+
+// ReadDirPlus replies the dentries starting from the offset of the request with their nodes,
+// which saves the kernel from looking up the dentries one by one.
+func (h *DirHandle) ReadDirPlus(ctx context.Context, req *fuse.ReadRequest) ([]fs.DirentPlus, error) {
+	d := h.d
+	defer d.super.trackOp(d.super.startOp("readdirplus", d.info.Inode, ""))
+	start := time.Now()
 
-	var dcache *DentryCache
-	if !d.super.disableDcache {
-		dcache = NewDentryCache()
+	var err error
+	metric := exporter.NewTPCnt("readdirplus")
+	defer metric.Set(err)
+
+	h.Lock()
+	defer h.Unlock()
+	var n int
+	if n, err = h.fill(req, true); err != nil {
+		log.LogErrorf("ReaddirPlus: ino(%v) offset(%v) err(%v)", d.info.Inode, req.Offset, err)
+		return nil, ParseError(err)
+	}
+	dirs := make([]fs.DirentPlus, 0, n)
+	d.super.fslock.Lock()
+	for i, child := range h.children[:n] {
+		dir := fs.DirentPlus{
+			Dirent:     childDirent(child),
+			Offset:     h.pos + uint64(i) + 1,
+			EntryValid: LookupValidDuration,
+		}
+		// the dentries of which the inodes are not got are looked up later by the kernel
+		if info := d.super.ic.Get(child.Inode); info != nil {
+			node, ok := d.super.nodeCache[child.Inode]
+			if !ok {
+				if proto.OsMode(info.Mode).IsDir() {
+					node = NewDir(d.super, info)
+				} else {
+					node = NewFile(d.super, info)
+				}
+				d.super.nodeCache[child.Inode] = node
+			}
+			setNodeDentry(node, d.info.Inode, child.Name)
+			dir.Node = node
+		}
+		dirs = append(dirs, dir)
 	}
+	d.super.fslock.Unlock()
 
-	for _, child := range children {
-		dentry := fuse.Dirent{
-			Inode: child.Inode,
-			Type:  ParseType(child.Type),
-			Name:  child.Name,
+	elapsed := time.Since(start)
+	log.LogDebugf("TRACE ReadDirPlus: ino(%v) offset(%v) entries(%v) (%v)ns", d.info.Inode, req.Offset, len(dirs), elapsed.Nanoseconds())
+	return dirs, nil
+}
+
+// fill drops the dentries before the offset of the request, and reads the dentries until
+// they are enough for the size of the request, it returns the number of the dentries fit in.
+func (h *DirHandle) fill(req *fuse.ReadRequest, plus bool) (n int, err error) {
+	offset := uint64(req.Offset)
+	// rewinddir(3), or a seek back to the dentries already consumed
+	if offset == 0 || offset < h.pos {
+		h.reset()
+	}
+	for {
+		if skip := offset - h.pos; skip > 0 {
+			if skip > uint64(len(h.children)) {
+				skip = uint64(len(h.children))
+			}
+			h.children = h.children[skip:]
+			h.pos += skip
+		}
+		// the kernel reads the dentries not fit in again from their offsets
+		size := 0
+		for n = 0; n < len(h.children); n++ {
+			if size += req.DirentSize(h.children[n].Name); size > req.Size {
+				break
+			}
 		}
-		inodes = append(inodes, child.Inode)
-		dirents = append(dirents, dentry)
-		dcache.Put(child.Name, child.Inode)
+		if h.eof || n < len(h.children) {
+			return
+		}
+		if err = h.readBatch(plus); err != nil {
+			return
+		}
+	}
+}
+
+func (h *DirHandle) reset() {
+	h.children, h.pos, h.marker, h.eof = nil, 0, "", false
+	if !h.d.super.disableDcache {
+		h.dcache = NewDentryCache()
 	}
+}
 
-	infos := d.super.mw.BatchInodeGet(inodes)
+// readBatch reads the next batch of the dentries, and puts them and their inodes into the caches.
+func (h *DirHandle) readBatch(plus bool) (err error) {
+	d := h.d
+	var (
+		children []proto.Dentry
+		infos    []*proto.InodeInfo
+	)
+	if plus {
+		children, infos, err = h.caller.ReadDirPlusLimit_ll(d.info.Inode, h.marker, meta.ReadDirLimit)
+	} else {
+		children, err = h.caller.ReadDirLimit_ll(d.info.Inode, h.marker, meta.ReadDirLimit)
+	}
+	if err != nil {
+		return
+	}
+	if uint64(len(children)) < meta.ReadDirLimit {
+		h.eof = true
+	}
+
+	got := make(map[uint64]bool, len(infos))
 	for _, info := range infos {
 		d.super.ic.Put(info)
+		got[info.Inode] = true
+	}
+	inodes := make([]uint64, 0, len(children))
+	for _, child := range children {
+		if !got[child.Inode] {
+			inodes = append(inodes, child.Inode)
+		}
+		h.dcache.Put(child.Name, child.Inode)
+		d.super.ndcache.Delete(d.info.Inode, child.Name)
+		h.marker = child.Name
 	}
-	d.dcache = dcache
+	h.children = append(h.children, children...)
 
-	elapsed := time.Since(start)
-	log.LogDebugf("TRACE ReadDir: ino(%v) (%v)ns", d.info.Inode, elapsed.Nanoseconds())
-	return dirents, nil
+	if len(inodes) > 0 {
+		for _, info := range d.super.mw.BatchInodeGet(inodes) {
+			d.super.ic.Put(info)
+		}
+	}
+	d.dcache = h.dcache
+	return
+}
+
+func childDirent(child proto.Dentry) fuse.Dirent {
+	return fuse.Dirent{
+		Inode: child.Inode,
+		Type:  ParseType(child.Type),
+		Name:  child.Name,
+	}
 }
 
 // Rename handles the rename request.
 func (d *Dir) Rename(ctx context.Context, req *fuse.RenameRequest, newDir fs.Node) error {
+	defer d.super.trackOp(d.super.startOp("rename", d.info.Inode, req.OldName))
 	dstDir, ok := newDir.(*Dir)
 	if !ok {
 		log.LogErrorf("Rename: NOT DIR, parent(%v) req(%v)", d.info.Inode, req)
@@ -286,12 +599,48 @@ func (d *Dir) Rename(ctx context.Context, req *fuse.RenameRequest, newDir fs.Nod
 	metric := exporter.NewTPCnt("rename")
 	defer metric.Set(err)
 
-	err = d.super.mw.Rename_ll(d.info.Inode, req.OldName, dstDir.info.Inode, req.NewName)
+	entry := &auditEntry{op: "rename", parent: d.info.Inode, name: req.OldName, dstParent: dstDir.info.Inode, dstName: req.NewName}
+	defer func() {
+		d.super.audit(&req.Header, entry, err)
+	}()
+	// the path of the source is resolved before the rename
+	if audit.Enabled() {
+		entry.ino, _, _ = d.super.mw.Lookup_ll(d.info.Inode, req.OldName)
+	}
+
+	// moving an entry out of the trash restores it
+	var (
+		trashed    uint64
+		deleteTime []byte
+	)
+	if trashIno := atomic.LoadUint64(&d.super.trashIno); trashIno != 0 && d.info.Inode == trashIno && dstDir.info.Inode != trashIno {
+		trashed, _, _ = d.super.mw.Lookup_ll(d.info.Inode, req.OldName)
+	}
+	if trashed != 0 {
+		if deleteTime, err = d.super.untrashInode(trashed); err != nil {
+			return ParseError(err)
+		}
+	}
+
+	err = d.super.caller(req.Header).Rename_ll(d.info.Inode, req.OldName, dstDir.info.Inode, req.NewName)
 	if err != nil {
 		log.LogErrorf("Rename: parent(%v) req(%v) err(%v)", d.info.Inode, req, err)
+		if trashed != 0 {
+			d.super.retrashInode(trashed, deleteTime)
+		}
 		return ParseError(err)
 	}
 
+	d.super.ndcache.Delete(dstDir.info.Inode, req.NewName)
+
+	if entry.ino != 0 {
+		d.super.fslock.Lock()
+		if node, ok := d.super.nodeCache[entry.ino]; ok {
+			setNodeDentry(node, dstDir.info.Inode, req.NewName)
+		}
+		d.super.fslock.Unlock()
+	}
+
 	d.super.ic.Delete(d.info.Inode)
 	d.super.ic.Delete(dstDir.info.Inode)
 
@@ -302,24 +651,46 @@ func (d *Dir) Rename(ctx context.Context, req *fuse.RenameRequest, newDir fs.Nod
 
 // Setattr handles the setattr request.
 func (d *Dir) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse.SetattrResponse) error {
+	defer d.super.trackOp(d.super.startOp("setattr", d.info.Inode, ""))
 	ino := d.info.Inode
 	start := time.Now()
 	info, err := d.super.InodeGet(ino)
+	defer func() {
+		d.super.audit(&req.Header, &auditEntry{op: "setattr", ino: ino, detail: setattrDetail(req)}, err)
+	}()
 	if err != nil {
 		log.LogErrorf("Setattr: ino(%v) err(%v)", ino, err)
 		return ParseError(err)
 	}
 
+	if req.Valid.Mode() {
+		var followsACL bool
+		if followsACL, err = d.super.modeFollowsACL(ino); err != nil {
+			return err
+		}
+		if followsACL {
+			err = fuse.EPERM
+			return err
+		}
+	}
+
+	d.super.ids.mapSetattr(req)
 	if valid := setattr(info, req); valid != 0 {
-		err = d.super.mw.Setattr(ino, valid, info.Mode, info.Uid, info.Gid, info.AccessTime.Unix(),
+		err = d.super.caller(req.Header).Setattr(ino, valid, info.Mode, info.Uid, info.Gid, info.AccessTime.Unix(),
 			info.ModifyTime.Unix())
 		if err != nil {
 			d.super.ic.Delete(ino)
 			return ParseError(err)
 		}
+		if valid&proto.AttrMode != 0 {
+			if err = d.super.chmodPosixACL(ino, info.Mode); err != nil {
+				return ParseError(err)
+			}
+		}
 	}
 
 	fillAttr(info, &resp.Attr)
+	d.super.ids.mapAttr(&resp.Attr)
 
 	elapsed := time.Since(start)
 	log.LogDebugf("TRACE Setattr: ino(%v) req(%v) inodeSize(%v) (%v)ns", ino, req, info.Size, elapsed.Nanoseconds())
@@ -327,6 +698,7 @@ func (d *Dir) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse.
 }
 
 func (d *Dir) Mknod(ctx context.Context, req *fuse.MknodRequest) (fs.Node, error) {
+	defer d.super.trackOp(d.super.startOp("mknod", d.info.Inode, req.Name))
 	if (req.Mode&os.ModeNamedPipe == 0 && req.Mode&os.ModeSocket == 0) || req.Rdev != 0 {
 		return nil, fuse.ENOSYS
 	}
@@ -336,17 +708,31 @@ func (d *Dir) Mknod(ctx context.Context, req *fuse.MknodRequest) (fs.Node, error
 	var err error
 	metric := exporter.NewTPCnt("mknod")
 	defer metric.Set(err)
+	defer func() {
+		d.super.audit(&req.Header, &auditEntry{op: "mknod", parent: d.info.Inode, name: req.Name}, err)
+	}()
 
-	info, err := d.super.mw.Create_ll(d.info.Inode, req.Name, proto.Mode(req.Mode), req.Uid, req.Gid, nil)
+	mode, access, def, err := d.newInodeMode(req.Mode, req.Umask)
+	if err != nil {
+		return nil, ParseError(err)
+	}
+	uid, gid := d.super.ids.creator(req.Uid, req.Gid)
+	info, err := d.super.caller(req.Header).Create_ll(d.info.Inode, req.Name, mode, uid, gid, nil)
 	if err != nil {
 		log.LogErrorf("Mknod: parent(%v) req(%v) err(%v)", d.info.Inode, req, err)
 		return nil, ParseError(err)
 	}
+	if err = d.super.inheritPosixACL(info.Inode, access, def); err != nil {
+		log.LogErrorf("Mknod: parent(%v) ino(%v) inherit acl err(%v)", d.info.Inode, info.Inode, err)
+		err = nil
+	}
 
 	d.super.ic.Put(info)
+	d.super.ndcache.Delete(d.info.Inode, req.Name)
 	child := NewFile(d.super, info)
 
 	d.super.fslock.Lock()
+	setNodeDentry(child, d.info.Inode, req.Name)
 	d.super.nodeCache[info.Inode] = child
 	d.super.fslock.Unlock()
 
@@ -357,23 +743,30 @@ func (d *Dir) Mknod(ctx context.Context, req *fuse.MknodRequest) (fs.Node, error
 
 // Symlink handles the symlink request.
 func (d *Dir) Symlink(ctx context.Context, req *fuse.SymlinkRequest) (fs.Node, error) {
+	defer d.super.trackOp(d.super.startOp("symlink", d.info.Inode, req.NewName))
 	parentIno := d.info.Inode
 	start := time.Now()
 
 	var err error
 	metric := exporter.NewTPCnt("symlink")
 	defer metric.Set(err)
+	defer func() {
+		d.super.audit(&req.Header, &auditEntry{op: "symlink", parent: parentIno, name: req.NewName, detail: req.Target}, err)
+	}()
 
-	info, err := d.super.mw.Create_ll(parentIno, req.NewName, proto.Mode(os.ModeSymlink|os.ModePerm), req.Uid, req.Gid, []byte(req.Target))
+	uid, gid := d.super.ids.creator(req.Uid, req.Gid)
+	info, err := d.super.caller(req.Header).Create_ll(parentIno, req.NewName, proto.Mode(os.ModeSymlink|os.ModePerm), uid, gid, []byte(req.Target))
 	if err != nil {
 		log.LogErrorf("Symlink: parent(%v) NewName(%v) err(%v)", parentIno, req.NewName, err)
 		return nil, ParseError(err)
 	}
 
 	d.super.ic.Put(info)
+	d.super.ndcache.Delete(parentIno, req.NewName)
 	child := NewFile(d.super, info)
 
 	d.super.fslock.Lock()
+	setNodeDentry(child, parentIno, req.NewName)
 	d.super.nodeCache[info.Inode] = child
 	d.super.fslock.Unlock()
 
@@ -384,6 +777,7 @@ func (d *Dir) Symlink(ctx context.Context, req *fuse.SymlinkRequest) (fs.Node, e
 
 // Link handles the link request.
 func (d *Dir) Link(ctx context.Context, req *fuse.LinkRequest, old fs.Node) (fs.Node, error) {
+	defer d.super.trackOp(d.super.startOp("link", d.info.Inode, req.NewName))
 	var oldInode *proto.InodeInfo
 	switch old := old.(type) {
 	case *File:
@@ -392,8 +786,9 @@ func (d *Dir) Link(ctx context.Context, req *fuse.LinkRequest, old fs.Node) (fs.
 		return nil, fuse.EPERM
 	}
 
-	if !proto.IsRegular(oldInode.Mode) {
-		log.LogErrorf("Link: not regular, parent(%v) name(%v) ino(%v) mode(%v)", d.info.Inode, req.NewName, oldInode.Inode, proto.OsMode(oldInode.Mode))
+	// symlinks are linked as themselves, only directories are refused
+	if proto.IsDir(oldInode.Mode) {
+		log.LogErrorf("Link: is directory, parent(%v) name(%v) ino(%v) mode(%v)", d.info.Inode, req.NewName, oldInode.Inode, proto.OsMode(oldInode.Mode))
 		return nil, fuse.EPERM
 	}
 
@@ -402,19 +797,26 @@ func (d *Dir) Link(ctx context.Context, req *fuse.LinkRequest, old fs.Node) (fs.
 	var err error
 	metric := exporter.NewTPCnt("link")
 	defer metric.Set(err)
+	defer func() {
+		d.super.audit(&req.Header, &auditEntry{op: "link", ino: oldInode.Inode, dstParent: d.info.Inode, dstName: req.NewName}, err)
+	}()
 
-	info, err := d.super.mw.Link(d.info.Inode, req.NewName, oldInode.Inode)
+	info, err := d.super.caller(req.Header).Link(d.info.Inode, req.NewName, oldInode.Inode)
 	if err != nil {
 		log.LogErrorf("Link: parent(%v) name(%v) ino(%v) err(%v)", d.info.Inode, req.NewName, oldInode.Inode, err)
 		return nil, ParseError(err)
 	}
 
 	d.super.ic.Put(info)
+	d.super.ndcache.Delete(d.info.Inode, req.NewName)
+	d.super.ic.Delete(d.info.Inode)
+	d.super.releaseOrphan(info.Inode)
 
 	d.super.fslock.Lock()
 	newFile, ok := d.super.nodeCache[info.Inode]
 	if !ok {
 		newFile = NewFile(d.super, info)
+		setNodeDentry(newFile, d.info.Inode, req.NewName)
 		d.super.nodeCache[info.Inode] = newFile
 	}
 	d.super.fslock.Unlock()
@@ -424,22 +826,41 @@ func (d *Dir) Link(ctx context.Context, req *fuse.LinkRequest, old fs.Node) (fs.
 	return newFile, nil
 }
 
-// Getxattr has not been implemented yet.
+// Getxattr returns the value of an extended attribute.
 func (d *Dir) Getxattr(ctx context.Context, req *fuse.GetxattrRequest, resp *fuse.GetxattrResponse) error {
-	return fuse.ENOSYS
+	defer d.super.trackOp(d.super.startOp("getxattr", d.info.Inode, ""))
+	if !d.super.xattrEnabled(req.Name) {
+		return fuse.ENOSYS
+	}
+	if req.Name == proto.DirStatXAttr {
+		return d.super.getDirStat(d.info.Inode, req, resp)
+	}
+	return d.super.getXattr(d.info.Inode, req, resp)
 }
 
-// Listxattr has not been implemented yet.
+// Listxattr lists the names of the extended attributes.
 func (d *Dir) Listxattr(ctx context.Context, req *fuse.ListxattrRequest, resp *fuse.ListxattrResponse) error {
-	return fuse.ENOSYS
+	defer d.super.trackOp(d.super.startOp("listxattr", d.info.Inode, ""))
+	if !d.super.enableXattr {
+		return fuse.ENOSYS
+	}
+	return d.super.listXattr(d.info.Inode, req, resp)
 }
 
-// Setxattr has not been implemented yet.
+// Setxattr sets an extended attribute.
 func (d *Dir) Setxattr(ctx context.Context, req *fuse.SetxattrRequest) error {
-	return fuse.ENOSYS
+	defer d.super.trackOp(d.super.startOp("setxattr", d.info.Inode, ""))
+	if !d.super.xattrEnabled(req.Name) {
+		return fuse.ENOSYS
+	}
+	return d.super.setXattr(d.info.Inode, req)
 }
 
-// Removexattr has not been implemented yet.
+// Removexattr removes an extended attribute.
 func (d *Dir) Removexattr(ctx context.Context, req *fuse.RemovexattrRequest) error {
-	return fuse.ENOSYS
+	defer d.super.trackOp(d.super.startOp("removexattr", d.info.Inode, ""))
+	if !d.super.xattrEnabled(req.Name) {
+		return fuse.ENOSYS
+	}
+	return d.super.removeXattr(d.info.Inode, req)
 }
@@ -17,6 +17,8 @@ package fs
 import (
 	"fmt"
 	"io"
+	"math"
+	"syscall"
 	"time"
 
 	"bazil.org/fuse"
@@ -35,6 +37,10 @@ type File struct {
 	super *Super
 	info  *proto.InodeInfo
 	sync.RWMutex
+
+	// the last known dentry to resolve the path for the audit, protected by super.fslock
+	parentIno uint64
+	name      string
 }
 
 // Functions that File needs to implement
@@ -54,6 +60,9 @@ var (
 	_ fs.NodeListxattrer   = (*File)(nil)
 	_ fs.NodeSetxattrer    = (*File)(nil)
 	_ fs.NodeRemovexattrer = (*File)(nil)
+	_ fs.HandleLocker      = (*File)(nil)
+	_ fs.HandleFallocater  = (*File)(nil)
+	_ fs.NodeAccesser      = (*File)(nil)
 )
 
 // NewFile returns a new file.
@@ -63,6 +72,7 @@ func NewFile(s *Super, i *proto.InodeInfo) fs.Node {
 
 // Attr sets the attributes of a file.
 func (f *File) Attr(ctx context.Context, a *fuse.Attr) error {
+	defer f.super.trackOp(f.super.startOp("getattr", f.info.Inode, ""))
 	ino := f.info.Inode
 	info, err := f.super.InodeGet(ino)
 	if err != nil {
@@ -75,10 +85,15 @@ func (f *File) Attr(ctx context.Context, a *fuse.Attr) error {
 	}
 
 	fillAttr(info, a)
+	f.super.ids.mapAttr(a)
 	fileSize, gen := f.fileSize(ino)
 	log.LogDebugf("Attr: ino(%v) fileSize(%v) gen(%v) inode.gen(%v)", ino, fileSize, gen, info.Generation)
 	if gen >= info.Generation {
 		a.Size = uint64(fileSize)
+	} else if f.super.writeCache || f.super.keepCache {
+		// the file is modified by another client, so the data cached by the kernel and the client is stale
+		f.super.ec.RefreshExtentsCache(ino)
+		f.super.invalidateData(f, ino)
 	}
 	if proto.IsSymlink(info.Mode) {
 		a.Size = uint64(len(info.Target))
@@ -90,6 +105,7 @@ func (f *File) Attr(ctx context.Context, a *fuse.Attr) error {
 
 // Forget evicts the inode of the current file. This can only happen when the inode is on the orphan list.
 func (f *File) Forget() {
+	defer f.super.trackOp(f.super.startOp("forget", f.info.Inode, ""))
 	ino := f.info.Inode
 	defer func() {
 		log.LogDebugf("TRACE Forget: ino(%v)", ino)
@@ -106,9 +122,13 @@ func (f *File) Forget() {
 		return
 	}
 
+	held := f.super.orphan.Held(ino)
 	if !f.super.orphan.Evict(ino) {
 		return
 	}
+	if held {
+		f.super.dropOrphanHold(ino)
+	}
 
 	if err := f.super.mw.Evict(ino); err != nil {
 		log.LogWarnf("Forget Evict: ino(%v) err(%v)", ino, err)
@@ -117,14 +137,23 @@ func (f *File) Forget() {
 
 // Open handles the open request.
 func (f *File) Open(ctx context.Context, req *fuse.OpenRequest, resp *fuse.OpenResponse) (handle fs.Handle, err error) {
+	defer f.super.trackOp(f.super.startOp("open", f.info.Inode, ""))
 	ino := f.info.Inode
 	start := time.Now()
 
+	if err = f.super.access(req.Header, ino, openMask(req.Flags)); err != nil {
+		return nil, ParseError(err)
+	}
+
 	f.super.ec.OpenStream(ino)
 
 	f.super.ec.RefreshExtentsCache(ino)
 
-	if f.super.keepCache {
+	if f.super.directIO || isDirectIOEnabled(req.Flags) {
+		// Bypass the page cache so that the reads and writes of the
+		// application are passed to the data nodes as they are.
+		resp.Flags |= fuse.OpenDirectIO
+	} else if f.super.keepCache {
 		resp.Flags |= fuse.OpenKeepCache
 	}
 
@@ -133,8 +162,17 @@ func (f *File) Open(ctx context.Context, req *fuse.OpenRequest, resp *fuse.OpenR
 	return f, nil
 }
 
+// Access checks the permissions of the user on the file for access(2).
+func (f *File) Access(ctx context.Context, req *fuse.AccessRequest) error {
+	if err := f.super.access(req.Header, f.info.Inode, req.Mask); err != nil {
+		return ParseError(err)
+	}
+	return nil
+}
+
 // Release handles the release request.
 func (f *File) Release(ctx context.Context, req *fuse.ReleaseRequest) (err error) {
+	defer f.super.trackOp(f.super.startOp("release", f.info.Inode, ""))
 	ino := f.info.Inode
 	log.LogDebugf("TRACE Release enter: ino(%v) req(%v)", ino, req)
 
@@ -148,6 +186,10 @@ func (f *File) Release(ctx context.Context, req *fuse.ReleaseRequest) (err error
 		return fuse.EIO
 	}
 
+	if f.super.enablePosixLock && req.ReleaseFlags&fuse.ReleaseFlockUnlock != 0 {
+		f.releaseLocks(uint64(req.LockOwner), true)
+	}
+
 	f.super.ic.Delete(ino)
 	elapsed := time.Since(start)
 	log.LogDebugf("TRACE Release: ino(%v) req(%v) (%v)ns", ino, req, elapsed.Nanoseconds())
@@ -156,6 +198,7 @@ func (f *File) Release(ctx context.Context, req *fuse.ReleaseRequest) (err error
 
 // Read handles the read request.
 func (f *File) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadResponse) (err error) {
+	defer f.super.trackOp(f.super.startOp("read", f.info.Inode, ""))
 	log.LogDebugf("TRACE Read enter: ino(%v) offset(%v) reqsize(%v) req(%v)", f.info.Inode, req.Offset, req.Size, req)
 
 	start := time.Now()
@@ -163,7 +206,12 @@ func (f *File) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadR
 	metric := exporter.NewTPCnt("fileread")
 	defer metric.Set(err)
 
-	size, err := f.super.ec.Read(f.info.Inode, resp.Data[fuse.OutHeaderSize:], int(req.Offset), req.Size)
+	var size int
+	if f.super.directIO || isDirectIOEnabled(req.FileFlags) {
+		size, err = f.super.ec.ReadDirect(f.info.Inode, resp.Data[fuse.OutHeaderSize:], int(req.Offset), req.Size)
+	} else {
+		size, err = f.super.ec.Read(f.info.Inode, resp.Data[fuse.OutHeaderSize:], int(req.Offset), req.Size)
+	}
 	if err != nil && err != io.EOF {
 		msg := fmt.Sprintf("Read: ino(%v) req(%v) err(%v) size(%v)", f.info.Inode, req, err, size)
 		f.super.handleError("Read", msg)
@@ -190,12 +238,22 @@ func (f *File) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadR
 
 // Write handles the write request.
 func (f *File) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.WriteResponse) (err error) {
+	defer f.super.trackOp(f.super.startOp("write", f.info.Inode, ""))
 	ino := f.info.Inode
 	reqlen := len(req.Data)
 	filesize, _ := f.fileSize(ino)
 
 	log.LogDebugf("TRACE Write enter: ino(%v) offset(%v) len(%v) filesize(%v) flags(%v) fileflags(%v) req(%v)", ino, req.Offset, reqlen, filesize, req.Flags, req.FileFlags, req)
 
+	if req.Offset+int64(reqlen) > int64(filesize) && f.super.mw.IsQuotaExceeded(f.info.QuotaId) {
+		log.LogWarnf("Write: ino(%v) offset(%v) len(%v) filesize(%v) quota(%v) exceeded", ino, req.Offset, reqlen, filesize, f.info.QuotaId)
+		return fuse.Errno(syscall.EDQUOT)
+	}
+
+	if f.super.mw.ReadOnly() {
+		return fuse.Errno(syscall.EROFS)
+	}
+
 	if req.Offset > int64(filesize) && reqlen == 1 && req.Data[0] == 0 {
 		// workaround: posix_fallocate would write 1 byte if fallocate is not supported.
 		err = f.super.ec.Truncate(ino, int(req.Offset)+reqlen)
@@ -214,14 +272,16 @@ func (f *File) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.Wri
 	var waitForFlush bool
 	var flags int
 
-	if isDirectIOEnabled(req.FileFlags) || (req.FileFlags&fuse.OpenSync != 0) {
+	if f.super.directIO || isDirectIOEnabled(req.FileFlags) || (req.FileFlags&fuse.OpenSync != 0) {
 		waitForFlush = true
 		if f.super.enSyncWrite {
 			flags |= proto.FlagsSyncWrite
 		}
 	}
 
-	if req.FileFlags&fuse.OpenAppend != 0 {
+	// With the kernel write-back cache, the kernel resolves the offset of
+	// appending writes itself and may write back pages out of order.
+	if req.FileFlags&fuse.OpenAppend != 0 && !f.super.writeCache {
 		flags |= proto.FlagsAppend
 	}
 
@@ -234,7 +294,7 @@ func (f *File) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.Wri
 	if err != nil {
 		msg := fmt.Sprintf("Write: ino(%v) offset(%v) len(%v) err(%v)", ino, req.Offset, reqlen, err)
 		f.super.handleError("Write", msg)
-		return fuse.EIO
+		return f.super.writeErrno()
 	}
 
 	resp.Size = size
@@ -246,7 +306,7 @@ func (f *File) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.Wri
 		if err = f.super.ec.Flush(ino); err != nil {
 			msg := fmt.Sprintf("Write: failed to wait for flush, ino(%v) offset(%v) len(%v) err(%v) req(%v)", ino, req.Offset, reqlen, err, req)
 			f.super.handleError("Wrtie", msg)
-			return fuse.EIO
+			return f.super.writeErrno()
 		}
 	}
 
@@ -256,9 +316,20 @@ func (f *File) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.Wri
 	return nil
 }
 
-// Flush only when fsyncOnClose is enabled.
+// Flush only when fsyncOnClose or writeCache is enabled.
+// In write cache mode the kernel writes back the dirty pages before the flush,
+// so the buffered data is always flushed to the datanodes upon close.
+// The fcntl locks of the closing owner are released on every flush if locking is enabled.
 func (f *File) Flush(ctx context.Context, req *fuse.FlushRequest) (err error) {
-	if !f.super.fsyncOnClose {
+	defer f.super.trackOp(f.super.startOp("flush", f.info.Inode, ""))
+	if f.super.enablePosixLock {
+		f.releaseLocks(req.LockOwner, false)
+	}
+	if !f.super.fsyncOnClose && !f.super.writeCache {
+		if f.super.enablePosixLock {
+			// ENOSYS would stop the kernel from sending further flushes
+			return nil
+		}
 		return fuse.ENOSYS
 	}
 	log.LogDebugf("TRACE Flush enter: ino(%v)", f.info.Inode)
@@ -271,7 +342,7 @@ func (f *File) Flush(ctx context.Context, req *fuse.FlushRequest) (err error) {
 	if err != nil {
 		msg := fmt.Sprintf("Flush: ino(%v) err(%v)", f.info.Inode, err)
 		f.super.handleError("Flush", msg)
-		return fuse.EIO
+		return f.super.writeErrno()
 	}
 	f.super.ic.Delete(f.info.Inode)
 	elapsed := time.Since(start)
@@ -280,14 +351,22 @@ func (f *File) Flush(ctx context.Context, req *fuse.FlushRequest) (err error) {
 }
 
 // Fsync hanldes the fsync request.
+// It is a barrier: all the data written before, including the pages written
+// back by the kernel write cache, is persisted on the datanodes and the extent
+// keys are committed to the metanode when it returns.
 func (f *File) Fsync(ctx context.Context, req *fuse.FsyncRequest) (err error) {
+	defer f.super.trackOp(f.super.startOp("fsync", f.info.Inode, ""))
 	log.LogDebugf("TRACE Fsync enter: ino(%v)", f.info.Inode)
 	start := time.Now()
+
+	metric := exporter.NewTPCnt("filefsync")
+	defer metric.Set(err)
+
 	err = f.super.ec.Flush(f.info.Inode)
 	if err != nil {
 		msg := fmt.Sprintf("Fsync: ino(%v) err(%v)", f.info.Inode, err)
 		f.super.handleError("Fsync", msg)
-		return fuse.EIO
+		return f.super.writeErrno()
 	}
 	f.super.ic.Delete(f.info.Inode)
 	elapsed := time.Since(start)
@@ -295,16 +374,68 @@ func (f *File) Fsync(ctx context.Context, req *fuse.FsyncRequest) (err error) {
 	return nil
 }
 
+// Fallocate handles the fallocate request.
+// The space is not preallocated, so the default mode only extends the file size and leaves a hole.
+// FALLOC_FL_PUNCH_HOLE removes the range from the extents of the file and releases the space on the datanodes,
+// and FALLOC_FL_ZERO_RANGE is done in the same way since a hole reads as zeros. The other modes are not supported.
+func (f *File) Fallocate(ctx context.Context, req *fuse.FallocateRequest) (err error) {
+	defer f.super.trackOp(f.super.startOp("fallocate", f.info.Inode, ""))
+	ino := f.info.Inode
+	log.LogDebugf("TRACE Fallocate enter: ino(%v) req(%v)", ino, req)
+
+	mode := req.Mode &^ fuse.FallocateKeepSize
+	if mode != 0 && mode != fuse.FallocatePunchHole && mode != fuse.FallocateZeroRange {
+		return fuse.Errno(syscall.EOPNOTSUPP)
+	}
+	if req.Offset < 0 || req.Length <= 0 {
+		return fuse.Errno(syscall.EINVAL)
+	}
+
+	metric := exporter.NewTPCnt("fallocate")
+	defer metric.Set(err)
+
+	defer f.super.ic.Delete(ino)
+	filesize, _ := f.fileSize(ino)
+	if mode != 0 {
+		if err = f.super.ec.PunchHole(ino, int(req.Offset), int(req.Length)); err != nil {
+			log.LogErrorf("Fallocate: ino(%v) req(%v) err(%v)", ino, req, err)
+			if err == syscall.ENOTSUP {
+				return fuse.Errno(syscall.EOPNOTSUPP)
+			}
+			return fuse.EIO
+		}
+	}
+	end := req.Offset + req.Length
+	if req.Mode&fuse.FallocateKeepSize != 0 || end <= int64(filesize) {
+		return nil
+	}
+	if f.super.mw.IsQuotaExceeded(f.info.QuotaId) {
+		return fuse.Errno(syscall.EDQUOT)
+	}
+	if err = f.super.ec.Truncate(ino, int(end)); err != nil {
+		log.LogErrorf("Fallocate: extend ino(%v) req(%v) err(%v)", ino, req, err)
+		return fuse.EIO
+	}
+	return nil
+}
+
 // Setattr handles the setattr request.
 func (f *File) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse.SetattrResponse) error {
+	defer f.super.trackOp(f.super.startOp("setattr", f.info.Inode, ""))
 	ino := f.info.Inode
 	start := time.Now()
+
+	var err error
+	defer func() {
+		f.super.audit(&req.Header, &auditEntry{op: "setattr", ino: ino, detail: setattrDetail(req)}, err)
+	}()
+
 	if req.Valid.Size() {
-		if err := f.super.ec.Flush(ino); err != nil {
+		if err = f.super.ec.Flush(ino); err != nil {
 			log.LogErrorf("Setattr: truncate wait for flush ino(%v) size(%v) err(%v)", ino, req.Size, err)
 			return ParseError(err)
 		}
-		if err := f.super.ec.Truncate(ino, int(req.Size)); err != nil {
+		if err = f.super.ec.Truncate(ino, int(req.Size)); err != nil {
 			log.LogErrorf("Setattr: truncate ino(%v) size(%v) err(%v)", ino, req.Size, err)
 			return ParseError(err)
 		}
@@ -324,16 +455,34 @@ func (f *File) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse
 		}
 	}
 
+	if req.Valid.Mode() {
+		var followsACL bool
+		if followsACL, err = f.super.modeFollowsACL(ino); err != nil {
+			return err
+		}
+		if followsACL {
+			err = fuse.EPERM
+			return err
+		}
+	}
+
+	f.super.ids.mapSetattr(req)
 	if valid := setattr(info, req); valid != 0 {
-		err = f.super.mw.Setattr(ino, valid, info.Mode, info.Uid, info.Gid, info.AccessTime.Unix(),
+		err = f.super.caller(req.Header).Setattr(ino, valid, info.Mode, info.Uid, info.Gid, info.AccessTime.Unix(),
 			info.ModifyTime.Unix())
 		if err != nil {
 			f.super.ic.Delete(ino)
 			return ParseError(err)
 		}
+		if valid&proto.AttrMode != 0 {
+			if err = f.super.chmodPosixACL(ino, info.Mode); err != nil {
+				return ParseError(err)
+			}
+		}
 	}
 
 	fillAttr(info, &resp.Attr)
+	f.super.ids.mapAttr(&resp.Attr)
 
 	elapsed := time.Since(start)
 	log.LogDebugf("TRACE Setattr: ino(%v) req(%v) (%v)ns", ino, req, elapsed.Nanoseconds())
@@ -342,6 +491,7 @@ func (f *File) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse
 
 // Readlink handles the readlink request.
 func (f *File) Readlink(ctx context.Context, req *fuse.ReadlinkRequest) (string, error) {
+	defer f.super.trackOp(f.super.startOp("readlink", f.info.Inode, ""))
 	ino := f.info.Inode
 	info, err := f.super.InodeGet(ino)
 	if err != nil {
@@ -352,85 +502,169 @@ func (f *File) Readlink(ctx context.Context, req *fuse.ReadlinkRequest) (string,
 	return string(info.Target), nil
 }
 
-// Getxattr has not been implemented yet.
+// Getxattr returns the value of an extended attribute.
 func (f *File) Getxattr(ctx context.Context, req *fuse.GetxattrRequest, resp *fuse.GetxattrResponse) error {
-	if !f.super.enableXattr {
+	defer f.super.trackOp(f.super.startOp("getxattr", f.info.Inode, ""))
+	if !f.super.xattrEnabled(req.Name) {
 		return fuse.ENOSYS
 	}
-	ino := f.info.Inode
-	name := req.Name
-	size := req.Size
-	pos := req.Position
-	info, err := f.super.mw.XAttrGet_ll(ino, name)
-	if err != nil {
-		log.LogErrorf("GetXattr: ino(%v) name(%v) err(%v)", ino, name, err)
-		return ParseError(err)
+	return f.super.getXattr(f.info.Inode, req, resp)
+}
+
+// Listxattr lists the names of the extended attributes.
+func (f *File) Listxattr(ctx context.Context, req *fuse.ListxattrRequest, resp *fuse.ListxattrResponse) error {
+	defer f.super.trackOp(f.super.startOp("listxattr", f.info.Inode, ""))
+	if !f.super.enableXattr {
+		return fuse.ENOSYS
 	}
-	value := info.Get(name)
-	if pos > 0 {
-		value = value[pos:]
+	return f.super.listXattr(f.info.Inode, req, resp)
+}
+
+// Setxattr sets an extended attribute.
+func (f *File) Setxattr(ctx context.Context, req *fuse.SetxattrRequest) error {
+	defer f.super.trackOp(f.super.startOp("setxattr", f.info.Inode, ""))
+	if !f.super.xattrEnabled(req.Name) {
+		return fuse.ENOSYS
 	}
-	if size > 0 && size < uint32(len(value)) {
-		value = value[:size]
+	return f.super.setXattr(f.info.Inode, req)
+}
+
+// Removexattr removes an extended attribute.
+func (f *File) Removexattr(ctx context.Context, req *fuse.RemovexattrRequest) error {
+	defer f.super.trackOp(f.super.startOp("removexattr", f.info.Inode, ""))
+	if !f.super.xattrEnabled(req.Name) {
+		return fuse.ENOSYS
 	}
-	resp.Xattr = value
-	log.LogDebugf("TRACE GetXattr: ino(%v) name(%v)", ino, name)
-	return nil
+	return f.super.removeXattr(f.info.Inode, req)
 }
 
-// Listxattr has not been implemented yet.
-func (f *File) Listxattr(ctx context.Context, req *fuse.ListxattrRequest, resp *fuse.ListxattrResponse) error {
-	if !f.super.enableXattr {
+// Lock tries to acquire a file lock.
+func (f *File) Lock(ctx context.Context, req *fuse.LockRequest) error {
+	defer f.super.trackOp(f.super.startOp("setlk", f.info.Inode, ""))
+	if !f.super.enablePosixLock {
 		return fuse.ENOSYS
 	}
 	ino := f.info.Inode
-	_ = req.Size     // ignore currently
-	_ = req.Position // ignore currently
-
-	keys, err := f.super.mw.XAttrsList_ll(ino)
-	if err != nil {
-		log.LogErrorf("ListXattr: ino(%v) err(%v)", ino, err)
+	lock := f.newFileLock(req.LockOwner, req.Lock, req.LockFlags)
+	if err := f.super.mw.SetLock_ll(ino, lock); err != nil {
+		log.LogDebugf("Lock: ino(%v) lock(%v) err(%v)", ino, lock, err)
 		return ParseError(err)
 	}
-	for _, key := range keys {
-		resp.Append(key)
+	log.LogDebugf("TRACE Lock: ino(%v) lock(%v)", ino, lock)
+	return nil
+}
+
+// LockWait acquires a file lock, retrying until the lock is granted or the request is interrupted.
+func (f *File) LockWait(ctx context.Context, req *fuse.LockWaitRequest) error {
+	defer f.super.trackOp(f.super.startOp("setlkw", f.info.Inode, ""))
+	if !f.super.enablePosixLock {
+		return fuse.ENOSYS
 	}
-	log.LogDebugf("TRACE Listxattr: ino(%v)", ino)
+	ino := f.info.Inode
+	lock := f.newFileLock(req.LockOwner, req.Lock, req.LockFlags)
+	for {
+		err := f.super.mw.SetLock_ll(ino, lock)
+		if err == nil {
+			break
+		}
+		if err != syscall.EAGAIN {
+			log.LogErrorf("LockWait: ino(%v) lock(%v) err(%v)", ino, lock, err)
+			return ParseError(err)
+		}
+		select {
+		case <-ctx.Done():
+			log.LogDebugf("LockWait: interrupted, ino(%v) lock(%v)", ino, lock)
+			return fuse.EINTR
+		case <-time.After(LockWaitInterval):
+		}
+	}
+	log.LogDebugf("TRACE LockWait: ino(%v) lock(%v)", ino, lock)
 	return nil
 }
 
-// Setxattr has not been implemented yet.
-func (f *File) Setxattr(ctx context.Context, req *fuse.SetxattrRequest) error {
-	if !f.super.enableXattr {
+// Unlock releases a file lock.
+func (f *File) Unlock(ctx context.Context, req *fuse.UnlockRequest) error {
+	defer f.super.trackOp(f.super.startOp("unlock", f.info.Inode, ""))
+	if !f.super.enablePosixLock {
 		return fuse.ENOSYS
 	}
 	ino := f.info.Inode
-	name := req.Name
-	value := req.Xattr
-	// TODO： implement flag to improve compatible (Mofei Zhang)
-	if err := f.super.mw.XAttrSet_ll(ino, []byte(name), []byte(value)); err != nil {
-		log.LogErrorf("Setxattr: ino(%v) name(%v) err(%v)", ino, name, err)
+	lock := f.newFileLock(req.LockOwner, req.Lock, req.LockFlags)
+	if err := f.super.mw.SetLock_ll(ino, lock); err != nil {
+		log.LogErrorf("Unlock: ino(%v) lock(%v) err(%v)", ino, lock, err)
 		return ParseError(err)
 	}
-	log.LogDebugf("TRACE Setxattr: ino(%v) name(%v)", ino, name)
+	log.LogDebugf("TRACE Unlock: ino(%v) lock(%v)", ino, lock)
 	return nil
 }
 
-// Removexattr has not been implemented yet.
-func (f *File) Removexattr(ctx context.Context, req *fuse.RemovexattrRequest) error {
-	if !f.super.enableXattr {
+// QueryLock returns the lock which conflicts with the requested one.
+func (f *File) QueryLock(ctx context.Context, req *fuse.QueryLockRequest, resp *fuse.QueryLockResponse) error {
+	defer f.super.trackOp(f.super.startOp("getlk", f.info.Inode, ""))
+	if !f.super.enablePosixLock {
 		return fuse.ENOSYS
 	}
 	ino := f.info.Inode
-	name := req.Name
-	if err := f.super.mw.XAttrDel_ll(ino, name); err != nil {
-		log.LogErrorf("Removexattr: ino(%v) name(%v) err(%v)", ino, name, err)
+	lock := f.newFileLock(req.LockOwner, req.Lock, req.LockFlags)
+	conflict, err := f.super.mw.GetLock_ll(ino, lock)
+	if err != nil {
+		log.LogErrorf("QueryLock: ino(%v) lock(%v) err(%v)", ino, lock, err)
 		return ParseError(err)
 	}
-	log.LogDebugf("TRACE RemoveXattr: ino(%v) name(%v)", ino, name)
+	if conflict != nil {
+		resp.Lock = fuse.FileLock{
+			Start: conflict.Start,
+			End:   conflict.End,
+			Type:  fuse.LockRead,
+			PID:   conflict.Pid,
+		}
+		if conflict.Type == proto.FileLockWrite {
+			resp.Lock.Type = fuse.LockWrite
+		}
+	}
+	log.LogDebugf("TRACE QueryLock: ino(%v) lock(%v) conflict(%v)", ino, lock, conflict)
 	return nil
 }
 
+func (f *File) newFileLock(owner fuse.LockOwner, lk fuse.FileLock, flags fuse.LockFlags) *proto.FileLock {
+	lock := &proto.FileLock{
+		Client: f.super.clientID,
+		Owner:  uint64(owner),
+		Pid:    lk.PID,
+		Start:  lk.Start,
+		End:    lk.End,
+		Flock:  flags&fuse.LockFlock != 0,
+	}
+	switch lk.Type {
+	case fuse.LockRead:
+		lock.Type = proto.FileLockRead
+	case fuse.LockWrite:
+		lock.Type = proto.FileLockWrite
+	default:
+		lock.Type = proto.FileLockUnlock
+	}
+	if lock.Flock {
+		lock.Start, lock.End = 0, math.MaxUint64
+	}
+	return lock
+}
+
+// releaseLocks drops all the locks of the given owner on this file.
+func (f *File) releaseLocks(owner uint64, flock bool) {
+	ino := f.info.Inode
+	lock := &proto.FileLock{
+		Client: f.super.clientID,
+		Owner:  owner,
+		Start:  0,
+		End:    math.MaxUint64,
+		Type:   proto.FileLockUnlock,
+		Flock:  flock,
+	}
+	if err := f.super.mw.SetLock_ll(ino, lock); err != nil {
+		log.LogWarnf("releaseLocks: ino(%v) lock(%v) err(%v)", ino, lock, err)
+	}
+}
+
 func (f *File) fileSize(ino uint64) (size int, gen uint64) {
 	size, gen, valid := f.super.ec.FileSize(ino)
 	log.LogDebugf("fileSize: ino(%v) fileSize(%v) gen(%v) valid(%v)", ino, size, gen, valid)
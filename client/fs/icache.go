@@ -53,6 +53,13 @@ func NewInodeCache(exp time.Duration, maxElements int) *InodeCache {
 	return ic
 }
 
+// SetExpiration changes the expiration of the inodes put afterwards.
+func (ic *InodeCache) SetExpiration(exp time.Duration) {
+	ic.Lock()
+	ic.expiration = exp
+	ic.Unlock()
+}
+
 // Put puts the given inode info into the inode cache.
 func (ic *InodeCache) Put(info *proto.InodeInfo) {
 	ic.Lock()
@@ -103,6 +110,14 @@ func (ic *InodeCache) Delete(ino uint64) {
 	ic.Unlock()
 }
 
+// Clear deletes all the inode infos.
+func (ic *InodeCache) Clear() {
+	ic.Lock()
+	ic.cache = make(map[uint64]*list.Element)
+	ic.lruList.Init()
+	ic.Unlock()
+}
+
 // Foreground eviction cares more about the speed.
 // Background eviction evicts all expired items from the cache.
 // The caller should grab the WRITE lock of the inode cache.
@@ -0,0 +1,154 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bazil.org/fuse"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// The uid and gid of the nobody user, which the squashed users are mapped to by default.
+const (
+	DefaultAnonUid = 65534
+	DefaultAnonGid = 65534
+)
+
+// idMapper remaps the ownership between the local users of the mount and the users stored in the volume,
+// like the squash and mapping options of the NFS exports. A nil idMapper keeps the ownership as is.
+type idMapper struct {
+	rootSquash bool // the root user creates the inodes as the anonymous user
+	allSquash  bool // every user creates the inodes as the anonymous user
+	anonUid    uint32
+	anonGid    uint32
+
+	// the local ids to the ids in the volume, and the reverse for the attributes replied to the kernel
+	uidMap    map[uint32]uint32
+	gidMap    map[uint32]uint32
+	uidRevMap map[uint32]uint32
+	gidRevMap map[uint32]uint32
+}
+
+func newIDMapper(opt *proto.MountOptions) (m *idMapper, err error) {
+	if !opt.RootSquash && !opt.AllSquash && opt.UidMap == "" && opt.GidMap == "" {
+		return nil, nil
+	}
+	m = &idMapper{
+		rootSquash: opt.RootSquash,
+		allSquash:  opt.AllSquash,
+		anonUid:    DefaultAnonUid,
+		anonGid:    DefaultAnonGid,
+	}
+	if opt.AnonUid >= 0 {
+		m.anonUid = uint32(opt.AnonUid)
+	}
+	if opt.AnonGid >= 0 {
+		m.anonGid = uint32(opt.AnonGid)
+	}
+	if m.uidMap, m.uidRevMap, err = parseIDMap(opt.UidMap); err != nil {
+		return nil, fmt.Errorf("invalid uidMap: %v", err)
+	}
+	if m.gidMap, m.gidRevMap, err = parseIDMap(opt.GidMap); err != nil {
+		return nil, fmt.Errorf("invalid gidMap: %v", err)
+	}
+	return m, nil
+}
+
+// parseIDMap parses the mapping table in the form of "local:remote,local:remote", in which a remote id
+// can be mapped from only one local id so that the attributes are mapped back unambiguously.
+func parseIDMap(table string) (m, rev map[uint32]uint32, err error) {
+	m = make(map[uint32]uint32)
+	rev = make(map[uint32]uint32)
+	for _, pair := range strings.Split(table, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		ids := strings.Split(pair, ":")
+		if len(ids) != 2 {
+			return nil, nil, fmt.Errorf("malformed pair %q", pair)
+		}
+		var local, remote uint64
+		if local, err = strconv.ParseUint(strings.TrimSpace(ids[0]), 10, 32); err != nil {
+			return nil, nil, err
+		}
+		if remote, err = strconv.ParseUint(strings.TrimSpace(ids[1]), 10, 32); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := m[uint32(local)]; ok {
+			return nil, nil, fmt.Errorf("duplicate local id %v", local)
+		}
+		if _, ok := rev[uint32(remote)]; ok {
+			return nil, nil, fmt.Errorf("duplicate remote id %v", remote)
+		}
+		m[uint32(local)] = uint32(remote)
+		rev[uint32(remote)] = uint32(local)
+	}
+	return m, rev, nil
+}
+
+func mapID(m map[uint32]uint32, id uint32) uint32 {
+	if mapped, ok := m[id]; ok {
+		return mapped
+	}
+	return id
+}
+
+// creator returns the owner in the volume of the inodes created by the local user.
+func (m *idMapper) creator(uid, gid uint32) (uint32, uint32) {
+	if m == nil {
+		return uid, gid
+	}
+	if m.allSquash || (m.rootSquash && uid == 0) {
+		uid = m.anonUid
+	}
+	return mapID(m.uidMap, uid), m.group(gid)
+}
+
+// group returns the group in the volume of the local group, squashed the same as the creator.
+func (m *idMapper) group(gid uint32) uint32 {
+	if m == nil {
+		return gid
+	}
+	if m.allSquash || (m.rootSquash && gid == 0) {
+		gid = m.anonGid
+	}
+	return mapID(m.gidMap, gid)
+}
+
+// mapSetattr maps the new owner of a chown to the ids in the volume, which is not squashed.
+func (m *idMapper) mapSetattr(req *fuse.SetattrRequest) {
+	if m == nil {
+		return
+	}
+	if req.Valid.Uid() {
+		req.Uid = mapID(m.uidMap, req.Uid)
+	}
+	if req.Valid.Gid() {
+		req.Gid = mapID(m.gidMap, req.Gid)
+	}
+}
+
+// mapAttr maps the owner in the volume of the attributes replied to the kernel back to the local ids.
+func (m *idMapper) mapAttr(attr *fuse.Attr) {
+	if m == nil {
+		return
+	}
+	attr.Uid = mapID(m.uidRevMap, attr.Uid)
+	attr.Gid = mapID(m.gidRevMap, attr.Gid)
+}
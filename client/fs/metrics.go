@@ -0,0 +1,110 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/exporter"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// MetricFuseOpLatency is the histogram of the latencies of the FUSE requests in seconds, labelled by the op.
+const MetricFuseOpLatency = "fuse_op_latency_seconds"
+
+// opKey identifies a FUSE request in flight.
+type opKey struct {
+	op    string
+	ino   uint64
+	name  string
+	start time.Time
+}
+
+// opStat is the statistics of the finished FUSE requests of an op.
+type opStat struct {
+	count uint64
+	total time.Duration
+	max   time.Duration
+}
+
+// opTracker keeps the FUSE requests in flight and the statistics of the finished ones.
+type opTracker struct {
+	sync.Mutex
+	inflight map[opKey]int
+	stats    map[string]*opStat
+}
+
+func newOpTracker() *opTracker {
+	return &opTracker{
+		inflight: make(map[opKey]int),
+		stats:    make(map[string]*opStat),
+	}
+}
+
+// counts returns the number of the finished FUSE requests of each op.
+func (t *opTracker) counts() map[string]uint64 {
+	t.Lock()
+	defer t.Unlock()
+	counts := make(map[string]uint64, len(t.stats))
+	for op, stat := range t.stats {
+		counts[op] = stat.count
+	}
+	return counts
+}
+
+// startOp records a FUSE request in flight, its results are passed to trackOp by
+// defer s.trackOp(s.startOp(op, ino, name)).
+func (s *Super) startOp(op string, ino uint64, name string) (string, uint64, string, time.Time) {
+	start := time.Now()
+	s.ops.Lock()
+	s.ops.inflight[opKey{op: op, ino: ino, name: name, start: start}]++
+	s.ops.Unlock()
+	return op, ino, name, start
+}
+
+// trackOp observes the latency of a FUSE request started at start, and logs the request
+// if it takes longer than the slow op threshold. The path of the request is of the entry
+// name under the node ino if name is not empty, otherwise of the node ino itself.
+func (s *Super) trackOp(op string, ino uint64, name string, start time.Time) {
+	elapsed := time.Since(start)
+	key := opKey{op: op, ino: ino, name: name, start: start}
+	s.ops.Lock()
+	if s.ops.inflight[key]--; s.ops.inflight[key] <= 0 {
+		delete(s.ops.inflight, key)
+	}
+	stat, ok := s.ops.stats[op]
+	if !ok {
+		stat = &opStat{}
+		s.ops.stats[op] = stat
+	}
+	stat.count++
+	stat.total += elapsed
+	if elapsed > stat.max {
+		stat.max = elapsed
+	}
+	s.ops.Unlock()
+	exporter.NewHistogram(MetricFuseOpLatency, nil).ObserveWithLabels(elapsed.Seconds(), map[string]string{"vol": s.volname, "op": op})
+	if s.slowOpThreshold <= 0 || elapsed < s.slowOpThreshold {
+		return
+	}
+	var path string
+	if name != "" {
+		path = s.entryPath(ino, name)
+	} else {
+		path = s.nodePath(ino)
+	}
+	log.LogWarnf("slow op(%v) path(%v) ino(%v) cost(%v)", op, path, ino, elapsed)
+}
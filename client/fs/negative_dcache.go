@@ -0,0 +1,118 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type negativeDentry struct {
+	parent     uint64
+	name       string
+	expiration time.Time
+}
+
+type negativeDentryKey struct {
+	parent uint64
+	name   string
+}
+
+// NegativeDentryCache caches the lookup misses, so the repeated lookups of the nonexistent
+// dentries, e.g. the searches of the shared libraries, are answered without the meta nodes.
+// The dentries created by the other clients are only seen after the misses expire.
+type NegativeDentryCache struct {
+	sync.Mutex
+	cache       map[negativeDentryKey]*list.Element
+	lruList     *list.List
+	expiration  time.Duration
+	maxElements int
+}
+
+// NewNegativeDentryCache returns a new negative dentry cache.
+func NewNegativeDentryCache(exp time.Duration, maxElements int) *NegativeDentryCache {
+	return &NegativeDentryCache{
+		cache:       make(map[negativeDentryKey]*list.Element),
+		lruList:     list.New(),
+		expiration:  exp,
+		maxElements: maxElements,
+	}
+}
+
+// Put records that the dentry does not exist, the least recently put one is evicted if the cache is full.
+func (nc *NegativeDentryCache) Put(parent uint64, name string) {
+	if nc == nil {
+		return
+	}
+	key := negativeDentryKey{parent, name}
+	nc.Lock()
+	defer nc.Unlock()
+	if old, ok := nc.cache[key]; ok {
+		nc.lruList.Remove(old)
+	}
+	if nc.lruList.Len() >= nc.maxElements {
+		back := nc.lruList.Back()
+		dentry := nc.lruList.Remove(back).(*negativeDentry)
+		delete(nc.cache, negativeDentryKey{dentry.parent, dentry.name})
+	}
+	nc.cache[key] = nc.lruList.PushFront(&negativeDentry{
+		parent:     parent,
+		name:       name,
+		expiration: time.Now().Add(nc.expiration),
+	})
+}
+
+// Has tells if the dentry is known to be nonexistent.
+func (nc *NegativeDentryCache) Has(parent uint64, name string) bool {
+	if nc == nil {
+		return false
+	}
+	key := negativeDentryKey{parent, name}
+	nc.Lock()
+	defer nc.Unlock()
+	element, ok := nc.cache[key]
+	if !ok {
+		return false
+	}
+	if element.Value.(*negativeDentry).expiration.Before(time.Now()) {
+		nc.lruList.Remove(element)
+		delete(nc.cache, key)
+		return false
+	}
+	return true
+}
+
+// Delete deletes the dentry, which is created or renamed to.
+func (nc *NegativeDentryCache) Delete(parent uint64, name string) {
+	if nc == nil {
+		return
+	}
+	key := negativeDentryKey{parent, name}
+	nc.Lock()
+	defer nc.Unlock()
+	if element, ok := nc.cache[key]; ok {
+		nc.lruList.Remove(element)
+		delete(nc.cache, key)
+	}
+}
+
+// Clear deletes all the lookup misses.
+func (nc *NegativeDentryCache) Clear() {
+	nc.Lock()
+	nc.cache = make(map[negativeDentryKey]*list.Element)
+	nc.lruList.Init()
+	nc.Unlock()
+}
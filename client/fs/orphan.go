@@ -16,7 +16,12 @@ package fs
 
 import (
 	"container/list"
+	"strconv"
 	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
 )
 
 // OrphanInodeList defines the orphan inode list, which is a list of orphan inodes.
@@ -25,6 +30,7 @@ type OrphanInodeList struct {
 	sync.RWMutex
 	cache map[uint64]*list.Element
 	list  *list.List
+	held  map[uint64]bool
 }
 
 // NewOrphanInodeList returns a new orphan inode list.
@@ -32,6 +38,7 @@ func NewOrphanInodeList() *OrphanInodeList {
 	return &OrphanInodeList{
 		cache: make(map[uint64]*list.Element),
 		list:  list.New(),
+		held:  make(map[uint64]bool),
 	}
 }
 
@@ -56,5 +63,86 @@ func (l *OrphanInodeList) Evict(ino uint64) bool {
 	}
 	l.list.Remove(element)
 	delete(l.cache, ino)
+	delete(l.held, ino)
+	return true
+}
+
+// Hold marks the inode in the orphan inode list as held on the meta partition.
+func (l *OrphanInodeList) Hold(ino uint64) bool {
+	l.Lock()
+	defer l.Unlock()
+	if _, ok := l.cache[ino]; !ok {
+		return false
+	}
+	l.held[ino] = true
 	return true
 }
+
+// Held tells whether the inode in the orphan inode list is held on the meta partition.
+func (l *OrphanInodeList) Held(ino uint64) bool {
+	l.RLock()
+	defer l.RUnlock()
+	return l.held[ino]
+}
+
+// HeldInodes returns the inodes held on the meta partitions.
+func (l *OrphanInodeList) HeldInodes() []uint64 {
+	l.RLock()
+	defer l.RUnlock()
+	inodes := make([]uint64, 0, len(l.held))
+	for ino := range l.held {
+		inodes = append(inodes, ino)
+	}
+	return inodes
+}
+
+// putOrphan puts the inode unlinked into the orphan list, and holds it on the meta partition
+// if this client has it open, so that it is kept until the client closes it.
+func (s *Super) putOrphan(ino uint64) {
+	s.orphan.Put(ino)
+	if s.ec.GetStreamer(ino) == nil {
+		return
+	}
+	if err := s.holdOrphan(ino); err != nil {
+		log.LogWarnf("putOrphan: ino(%v) err(%v)", ino, err)
+		return
+	}
+	s.orphan.Hold(ino)
+}
+
+// holdOrphan holds the inode on the meta partition for the lease.
+func (s *Super) holdOrphan(ino uint64) error {
+	expire := time.Now().Add(proto.OrphanHoldLease).Unix()
+	return s.mw.XAttrSet_ll(ino, []byte(proto.OrphanHoldXAttr(s.clientID)), []byte(strconv.FormatInt(expire, 10)))
+}
+
+// dropOrphanHold drops the hold of this client on the inode.
+func (s *Super) dropOrphanHold(ino uint64) {
+	if err := s.mw.XAttrDel_ll(ino, proto.OrphanHoldXAttr(s.clientID)); err != nil {
+		log.LogWarnf("dropOrphanHold: ino(%v) err(%v)", ino, err)
+	}
+}
+
+// releaseOrphan removes the inode linked again, e.g. a temporary file linked by linkat, from the orphan list.
+func (s *Super) releaseOrphan(ino uint64) {
+	held := s.orphan.Held(ino)
+	if s.orphan.Evict(ino) && held {
+		s.dropOrphanHold(ino)
+	}
+}
+
+// renewOrphanHolds renews the holds of the orphan inodes before they expire.
+func (s *Super) renewOrphanHolds() {
+	t := time.NewTicker(proto.OrphanHoldLease / 3)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			for _, ino := range s.orphan.HeldInodes() {
+				if err := s.holdOrphan(ino); err != nil {
+					log.LogWarnf("renewOrphanHolds: ino(%v) err(%v)", ino, err)
+				}
+			}
+		}
+	}
+}
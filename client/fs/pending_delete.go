@@ -0,0 +1,51 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// moveToPendingDelete moves the non-empty directory into the pending delete directory, whose
+// entries are deleted in background by the meta partitions, instead of failing with ENOTEMPTY.
+// It returns false if the directory should be removed as usual, which is the case when it is empty.
+func (d *Dir) moveToPendingDelete(name string) (moved bool, err error) {
+	ino, _, err := d.super.mw.Lookup_ll(d.info.Inode, name)
+	if err != nil {
+		return false, err
+	}
+	info, err := d.super.InodeGet(ino)
+	if err != nil {
+		return false, err
+	}
+	if !proto.IsDir(info.Mode) || info.Nlink <= 2 {
+		return false, nil
+	}
+	pendingIno, err := d.super.markedDir(&d.super.pendingIno, proto.PendingDeleteDirName, proto.PendingDeleteDirXAttr)
+	if err != nil || pendingIno == d.info.Inode || pendingIno == ino {
+		return false, err
+	}
+
+	entry := proto.TrashEntryName(name, d.info.Inode, time.Now().Unix())
+	if err = d.super.mw.Rename_ll(d.info.Inode, name, pendingIno, entry); err != nil {
+		return false, err
+	}
+	d.super.ic.Delete(pendingIno)
+	log.LogDebugf("moveToPendingDelete: parent(%v) name(%v) ino(%v) entry(%v)", d.info.Inode, name, ino, entry)
+	return true, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"bazil.org/fuse"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/meta"
+)
+
+// The values of the permissionCheck mount option. The kernel checks the permissions by the mode bits
+// for the client checks, while the meta nodes check them by the credentials of the users for the server
+// checks, which the volumes enforcing the permissions require. The credentials are asserted by the client.
+const (
+	PermissionCheckNone   = ""
+	PermissionCheckClient = "client"
+	PermissionCheckServer = "server"
+)
+
+// caller returns the meta operations on behalf of the user of the request.
+func (s *Super) caller(h fuse.Header) *meta.Caller {
+	if !s.serverPermCheck {
+		return s.mw.As(nil)
+	}
+	return s.mw.As(s.credential(h))
+}
+
+// credential returns the user of the request in the volume, with the supplementary groups of the process.
+func (s *Super) credential(h fuse.Header) *proto.Credential {
+	uid, gid := s.ids.creator(h.Uid, h.Gid)
+	cred := &proto.Credential{Uid: uid, Gid: gid}
+	for _, g := range processGroups(h.Pid) {
+		cred.Gids = append(cred.Gids, s.ids.group(g))
+	}
+	return cred
+}
+
+// access checks if the user of the request is permitted to access the inode by the mask,
+// which is always permitted unless the permissions are checked on the server side.
+func (s *Super) access(h fuse.Header, ino uint64, mask uint32) error {
+	if !s.serverPermCheck {
+		return nil
+	}
+	return s.caller(h).InodeAccess_ll(ino, mask)
+}
+
+// openMask returns the permissions required to open a file by the flags.
+func openMask(flags fuse.OpenFlags) (mask uint32) {
+	switch {
+	case flags.IsReadOnly():
+		mask = proto.PermRead
+	case flags.IsWriteOnly():
+		mask = proto.PermWrite
+	case flags.IsReadWrite():
+		mask = proto.PermRead | proto.PermWrite
+	}
+	if flags&fuse.OpenTruncate != 0 {
+		mask |= proto.PermWrite
+	}
+	return
+}
+
+// processGroups returns the supplementary groups of the process, nil if they can not be read.
+func processGroups(pid uint32) []uint32 {
+	if pid == 0 {
+		return nil
+	}
+	f, err := os.Open(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "Groups:") {
+			continue
+		}
+		var gids []uint32
+		for _, field := range strings.Fields(strings.TrimPrefix(line, "Groups:")) {
+			if gid, err := strconv.ParseUint(field, 10, 32); err == nil {
+				gids = append(gids, uint32(gid))
+			}
+		}
+		return gids
+	}
+	return nil
+}
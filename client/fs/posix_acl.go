@@ -0,0 +1,140 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"os"
+	"syscall"
+
+	"bazil.org/fuse"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The POSIX ACLs are stored as the xattrs of the inodes, which the kernel reads to check the permissions
+// for the client checks, and the meta nodes read for the server checks. The mode bits are kept in step with
+// the access ACL, and the new inodes inherit the default ACL of the parent directory.
+
+// xattrEnabled checks whether the xattr is served, the ACLs are served without enableXattr as well.
+func (s *Super) xattrEnabled(name string) bool {
+	return s.enableXattr || (s.enablePosixACL && proto.IsPosixACLXAttr(name))
+}
+
+// getPosixACL returns the ACL of the inode, nil if it has none.
+func (s *Super) getPosixACL(ino uint64, name string) (proto.PosixACL, error) {
+	info, err := s.mw.XAttrGet_ll(ino, name)
+	if err != nil {
+		return nil, err
+	}
+	value := info.Get(name)
+	if len(value) == 0 {
+		return nil, nil
+	}
+	return proto.ParsePosixACL(value)
+}
+
+// setPosixACL sets the ACL of the inode, which is permitted to the owner only as chmod(2) is.
+// An access ACL updates the mode bits, and is removed if the mode bits are enough to represent it.
+func (s *Super) setPosixACL(ino uint64, req *fuse.SetxattrRequest) error {
+	acl, err := proto.ParsePosixACL(req.Xattr)
+	if err != nil {
+		log.LogErrorf("Setxattr: ino(%v) name(%v) err(%v)", ino, req.Name, err)
+		return syscall.EINVAL
+	}
+	info, err := s.InodeGet(ino)
+	if err != nil {
+		return ParseError(err)
+	}
+	mode := info.Mode
+	if req.Name == proto.XAttrPosixACLDefault {
+		if !proto.IsDir(mode) {
+			return syscall.EACCES
+		}
+	} else {
+		mode = mode&^0777 | acl.Mode()
+	}
+	if mode != info.Mode || s.serverPermCheck {
+		if err = s.caller(req.Header).Setattr(ino, proto.AttrMode, mode, 0, 0, 0, 0); err != nil {
+			return ParseError(err)
+		}
+		s.ic.Delete(ino)
+	}
+
+	if req.Name == proto.XAttrPosixACLAccess && acl.Equivalent() {
+		if err = s.mw.XAttrDel_ll(ino, req.Name); err != nil {
+			return ParseError(err)
+		}
+		return nil
+	}
+	if err = s.mw.XAttrSet_ll(ino, []byte(req.Name), acl.Bytes()); err != nil {
+		log.LogErrorf("Setxattr: ino(%v) name(%v) err(%v)", ino, req.Name, err)
+		return ParseError(err)
+	}
+	log.LogDebugf("TRACE Setxattr: ino(%v) name(%v) acl(%v)", ino, req.Name, acl)
+	return nil
+}
+
+// chmodPosixACL updates the access ACL of the inode by the new mode of a chmod.
+func (s *Super) chmodPosixACL(ino uint64, mode uint32) error {
+	if !s.enablePosixACL {
+		return nil
+	}
+	acl, err := s.getPosixACL(ino, proto.XAttrPosixACLAccess)
+	if err != nil || acl == nil {
+		return err
+	}
+	return s.mw.XAttrSet_ll(ino, []byte(proto.XAttrPosixACLAccess), acl.Chmod(mode).Bytes())
+}
+
+// newInodeMode returns the mode of a new inode in the directory, and the ACLs to set on it. The kernel
+// leaves the umask to the file system if the ACLs are enabled, which applies only if the directory has
+// no default ACL.
+func (d *Dir) newInodeMode(mode os.FileMode, umask os.FileMode) (newMode uint32, access, def proto.PosixACL, err error) {
+	newMode = proto.Mode(mode)
+	if !d.super.enablePosixACL {
+		return
+	}
+	if def, err = d.super.getPosixACL(d.info.Inode, proto.XAttrPosixACLDefault); err != nil {
+		log.LogErrorf("newInodeMode: parent(%v) err(%v)", d.info.Inode, err)
+		return
+	}
+	if def == nil {
+		return proto.Mode(mode &^ umask), nil, nil, nil
+	}
+	access, newMode = def.Inherit(newMode)
+	if access.Equivalent() {
+		access = nil
+	}
+	if !mode.IsDir() {
+		def = nil
+	}
+	return
+}
+
+// inheritPosixACL sets the ACLs inherited from the parent directory on the new inode.
+func (s *Super) inheritPosixACL(ino uint64, access, def proto.PosixACL) error {
+	if access != nil {
+		if err := s.mw.XAttrSet_ll(ino, []byte(proto.XAttrPosixACLAccess), access.Bytes()); err != nil {
+			return err
+		}
+	}
+	if def != nil {
+		if err := s.mw.XAttrSet_ll(ino, []byte(proto.XAttrPosixACLDefault), def.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
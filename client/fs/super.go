@@ -20,6 +20,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"golang.org/x/net/context"
@@ -30,6 +31,7 @@ import (
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/sdk/data/stream"
 	"github.com/chubaofs/chubaofs/sdk/meta"
+	"github.com/chubaofs/chubaofs/util"
 	"github.com/chubaofs/chubaofs/util/errors"
 	"github.com/chubaofs/chubaofs/util/log"
 	"github.com/chubaofs/chubaofs/util/ump"
@@ -41,19 +43,46 @@ type Super struct {
 	volname     string
 	owner       string
 	ic          *InodeCache
+	ndcache     *NegativeDentryCache // nil if the lookup misses are not cached
 	mw          *meta.MetaWrapper
 	ec          *stream.ExtentClient
 	orphan      *OrphanInodeList
 	enSyncWrite bool
 	keepCache   bool
+	writeCache  bool
 
 	nodeCache map[uint64]fs.Node
 	fslock    sync.Mutex
 
+	// notifies the kernel of the files modified by other clients, nil before serving
+	server *fs.Server
+
 	disableDcache bool
 	fsyncOnClose  bool
 	enableXattr   bool
 	rootIno       uint64
+	trashIno      uint64
+	pendingIno    uint64 // the inode of the pending delete directory
+	asyncRmdir    bool
+	directIO      bool
+
+	// the FUSE requests slower than the threshold are logged, disabled if not positive
+	slowOpThreshold time.Duration
+	ops             *opTracker
+
+	// clientID tells the locks and the orphan holds of this mount from those of other mounts,
+	// which are released by the meta nodes once the session of the mount expires
+	enablePosixLock bool
+	clientID        uint64
+
+	// remaps the ownership of the inodes, nil if neither squashed nor mapped
+	ids *idMapper
+
+	// sends the credentials of the users to the meta nodes, which check the permissions
+	serverPermCheck bool
+
+	// keeps the POSIX ACLs in the xattrs, see posix_acl.go
+	enablePosixACL bool
 }
 
 // Functions that Super needs to implement
@@ -72,12 +101,18 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 		Masters:       masters,
 		Authenticate:  opt.Authenticate,
 		TicketMess:    opt.TicketMess,
+		TokenKey:      opt.TokenKey,
 		ValidateOwner: opt.Authenticate || opt.AccessKey == "",
+		FollowerRead:  opt.MetaFollowerRead,
 	}
 	s.mw, err = meta.NewMetaWrapper(metaConfig)
 	if err != nil {
 		return nil, errors.Trace(err, "NewMetaWrapper failed!")
 	}
+	if s.mw.Flat() {
+		_ = s.mw.Close()
+		return nil, errors.New("volume of the flat namespace can not be mounted")
+	}
 
 	s.volname = opt.Volname
 	s.owner = opt.Owner
@@ -96,12 +131,37 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 		s.enSyncWrite = true
 	}
 	s.keepCache = opt.KeepCache
+	s.writeCache = opt.WriteCache
 	s.ic = NewInodeCache(inodeExpiration, MaxInodeCache)
+	if opt.NdcacheTimeout > 0 {
+		s.ndcache = NewNegativeDentryCache(time.Duration(opt.NdcacheTimeout)*time.Second, MaxNegativeDentryCache)
+	}
 	s.orphan = NewOrphanInodeList()
 	s.nodeCache = make(map[uint64]fs.Node)
 	s.disableDcache = opt.DisableDcache
 	s.fsyncOnClose = opt.FsyncOnClose
 	s.enableXattr = opt.EnableXattr
+	s.enablePosixLock = opt.EnablePosixLock
+	s.enablePosixACL = opt.EnablePosixACL
+	s.asyncRmdir = opt.AsyncRmdir
+	s.directIO = opt.DirectIO
+	if opt.SlowOpThreshold > 0 {
+		s.slowOpThreshold = time.Duration(opt.SlowOpThreshold) * time.Millisecond
+	}
+	s.ops = newOpTracker()
+	if s.ids, err = newIDMapper(opt); err != nil {
+		return nil, err
+	}
+	switch opt.PermissionCheck {
+	case PermissionCheckNone, PermissionCheckClient:
+	case PermissionCheckServer:
+		s.serverPermCheck = true
+		// the client acts as root on its own requests, e.g. to the trash
+		s.mw.SetDefaultCredential(&proto.Credential{})
+	default:
+		return nil, fmt.Errorf("invalid permissionCheck: %v", opt.PermissionCheck)
+	}
+	s.clientID = s.mw.ClientID()
 
 	var extentConfig = &stream.ExtentConfig{
 		Volume:            opt.Volname,
@@ -110,10 +170,19 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 		NearRead:          opt.NearRead,
 		ReadRate:          opt.ReadRate,
 		WriteRate:         opt.WriteRate,
+		ReadBps:           opt.ReadBps,
+		WriteBps:          opt.WriteBps,
+		ReadCacheSize:     opt.ReadCacheSize * util.MB,
+		PrefetchSize:      opt.PrefetchSize * util.MB,
+		ReadPolicy:        opt.ReadPolicy,
+		HedgeDelay:        time.Duration(opt.HedgeDelay) * time.Millisecond,
 		OnAppendExtentKey: s.mw.AppendExtentKey,
 		OnGetExtents:      s.mw.GetExtents,
 		OnTruncate:        s.mw.Truncate,
+		OnPunchHole:       s.mw.PunchHole,
 		OnEvictIcache:     s.ic.Delete,
+		OnIsShared:        s.mw.IsShared,
+		OnCheckShared:     s.mw.CheckShared,
 	}
 	s.ec, err = stream.NewExtentClient(extentConfig)
 	if err != nil {
@@ -124,10 +193,45 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 		return nil, err
 	}
 
+	// the mount goes on without the session if the master does not support it
+	mountTime := time.Now().Unix()
+	report := func() *proto.ClientSessionReport {
+		return &proto.ClientSessionReport{
+			Version:      proto.Version,
+			MountPoint:   opt.MountPoint,
+			MountOptions: opt.Reported,
+			MountTime:    mountTime,
+			Ops:          s.ops.counts(),
+		}
+	}
+	if err = s.mw.StartSession(report); err != nil {
+		log.LogWarnf("NewSuper: start client session failed, volume(%v) err(%v)", s.volname, err)
+		err = nil
+	}
+	go s.renewOrphanHolds()
+
 	log.LogInfof("NewSuper: cluster(%v) volname(%v) icacheExpiration(%v) LookupValidDuration(%v) AttrValidDuration(%v)", s.cluster, s.volname, inodeExpiration, LookupValidDuration, AttrValidDuration)
 	return s, nil
 }
 
+// SetServer sets the FUSE server to notify the kernel of the files modified by other clients.
+func (s *Super) SetServer(server *fs.Server) {
+	s.server = server
+}
+
+// invalidateData drops the page cache of the file in the kernel, and the dirty pages are written back first.
+// It is done in background, since the kernel may wait for the requests being served to write back the pages.
+func (s *Super) invalidateData(node fs.Node, ino uint64) {
+	if s.server == nil {
+		return
+	}
+	go func() {
+		if err := s.server.InvalidateNodeData(node); err != nil && err != fuse.ErrNotCached {
+			log.LogWarnf("invalidateData: ino(%v) err(%v)", ino, err)
+		}
+	}()
+}
+
 // Root returns the root directory where it resides.
 func (s *Super) Root() (fs.Node, error) {
 	inode, err := s.InodeGet(s.rootIno)
@@ -140,6 +244,7 @@ func (s *Super) Root() (fs.Node, error) {
 
 // Statfs handles the Statfs request and returns a set of statistics.
 func (s *Super) Statfs(ctx context.Context, req *fuse.StatfsRequest, resp *fuse.StatfsResponse) error {
+	defer s.trackOp(s.startOp("statfs", s.rootIno, ""))
 	total, used := s.mw.Statfs()
 	resp.Blocks = total / uint64(DefaultBlksize)
 	resp.Bfree = (total - used) / uint64(DefaultBlksize)
@@ -184,6 +289,47 @@ func (s *Super) SetRate(w http.ResponseWriter, r *http.Request) {
 			w.Write([]byte(fmt.Sprintf("Set write rate to %v successfully\n", msg)))
 		}
 	}
+
+	if rate := r.FormValue("readBps"); rate != "" {
+		val, err := strconv.Atoi(rate)
+		if err != nil {
+			w.Write([]byte("Set read bps failed\n"))
+		} else {
+			msg := s.ec.SetReadBps(val)
+			w.Write([]byte(fmt.Sprintf("Set read bps to %v successfully\n", msg)))
+		}
+	}
+
+	if rate := r.FormValue("writeBps"); rate != "" {
+		val, err := strconv.Atoi(rate)
+		if err != nil {
+			w.Write([]byte("Set write bps failed\n"))
+		} else {
+			msg := s.ec.SetWriteBps(val)
+			w.Write([]byte(fmt.Sprintf("Set write bps to %v successfully\n", msg)))
+		}
+	}
+}
+
+// Reload applies the options which can be changed on a live mount, i.e. the cache timeouts and the rate limits.
+func (s *Super) Reload(opt *proto.MountOptions) {
+	inodeExpiration := DefaultInodeExpiration
+	if opt.IcacheTimeout >= 0 {
+		inodeExpiration = time.Duration(opt.IcacheTimeout) * time.Second
+	}
+	s.ic.SetExpiration(inodeExpiration)
+	if opt.LookupValid >= 0 {
+		LookupValidDuration = time.Duration(opt.LookupValid) * time.Second
+	}
+	if opt.AttrValid >= 0 {
+		AttrValidDuration = time.Duration(opt.AttrValid) * time.Second
+	}
+	readRate := s.ec.SetReadRate(int(opt.ReadRate))
+	writeRate := s.ec.SetWriteRate(int(opt.WriteRate))
+	readBps := s.ec.SetReadBps(int(opt.ReadBps))
+	writeBps := s.ec.SetWriteBps(int(opt.WriteBps))
+	log.LogInfof("Reload: icacheExpiration(%v) LookupValidDuration(%v) AttrValidDuration(%v) readRate(%v) writeRate(%v) readBps(%v) writeBps(%v)",
+		inodeExpiration, LookupValidDuration, AttrValidDuration, readRate, writeRate, readBps, writeBps)
 }
 
 func (s *Super) exporterKey(act string) string {
@@ -198,3 +344,11 @@ func (s *Super) handleError(op, msg string) {
 	log.LogError(msg)
 	ump.Alarm(s.umpKey(op), msg)
 }
+
+// writeErrno is returned for the failed writes, EROFS if the volume is frozen read-only meanwhile.
+func (s *Super) writeErrno() fuse.Errno {
+	if s.mw.ReadOnly() {
+		return fuse.Errno(syscall.EROFS)
+	}
+	return fuse.EIO
+}
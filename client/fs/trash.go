@@ -0,0 +1,193 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// trashDir returns the inode of the trash directory under the root of the volume,
+// and creates it if it does not exist.
+func (s *Super) trashDir() (ino uint64, err error) {
+	return s.markedDir(&s.trashIno, proto.TrashDirName, proto.TrashDirXAttr)
+}
+
+// markedDir returns the inode of the directory under the root of the volume marked by the xattr,
+// which is created if it does not exist and cached once marked.
+func (s *Super) markedDir(cached *uint64, name, xattr string) (ino uint64, err error) {
+	if ino = atomic.LoadUint64(cached); ino != 0 {
+		return
+	}
+	ino, _, err = s.mw.Lookup_ll(proto.RootIno, name)
+	if err == syscall.ENOENT {
+		var info *proto.InodeInfo
+		info, err = s.mw.Create_ll(proto.RootIno, name, proto.Mode(os.ModeDir|os.ModeSticky|os.ModePerm), 0, 0, nil)
+		if err == syscall.EEXIST {
+			ino, _, err = s.mw.Lookup_ll(proto.RootIno, name)
+		} else if err == nil {
+			ino = info.Inode
+			s.ndcache.Delete(proto.RootIno, name)
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err = s.mw.XAttrSet_ll(ino, []byte(xattr), []byte("1")); err != nil {
+		return 0, err
+	}
+	atomic.StoreUint64(cached, ino)
+	return
+}
+
+// moveToTrash moves the dentry into the trash instead of removing it.
+// It returns false if the dentry should be removed as usual, which is the case when
+// the trash is disabled, the dentry is a directory or the inode has other links.
+func (d *Dir) moveToTrash(name string) (moved bool, err error) {
+	if d.super.mw.TrashDays() == 0 {
+		return false, nil
+	}
+	trashIno, err := d.super.trashDir()
+	if err != nil || trashIno == d.info.Inode {
+		return false, err
+	}
+	ino, _, err := d.super.mw.Lookup_ll(d.info.Inode, name)
+	if err != nil {
+		return false, err
+	}
+	info, err := d.super.InodeGet(ino)
+	if err != nil {
+		return false, err
+	}
+	if proto.IsDir(info.Mode) || info.Nlink > 1 {
+		return false, nil
+	}
+
+	now := time.Now().Unix()
+	if err = d.super.mw.XAttrSet_ll(ino, []byte(proto.TrashTimeXAttr), []byte(strconv.FormatInt(now, 10))); err != nil {
+		return false, err
+	}
+	entry := proto.TrashEntryName(name, d.info.Inode, now)
+	if err = d.super.mw.Rename_ll(d.info.Inode, name, trashIno, entry); err != nil {
+		d.super.mw.XAttrDel_ll(ino, proto.TrashTimeXAttr)
+		return false, err
+	}
+	d.super.ic.Delete(trashIno)
+	log.LogDebugf("moveToTrash: parent(%v) name(%v) ino(%v) entry(%v)", d.info.Inode, name, ino, entry)
+	return true, nil
+}
+
+// restoreFromTrash moves a trash entry back to its original place.
+func (s *Super) restoreFromTrash(entry string) (err error) {
+	name, parentID, _, ok := proto.ParseTrashEntryName(entry)
+	if !ok {
+		return syscall.EINVAL
+	}
+	trashIno, err := s.trashDir()
+	if err != nil {
+		return
+	}
+	ino, _, err := s.mw.Lookup_ll(trashIno, entry)
+	if err != nil {
+		return
+	}
+	// The deletion time is removed before the entry leaves the trash, otherwise
+	// the restored inode would be purged by the meta partition later.
+	deleteTime, err := s.untrashInode(ino)
+	if err != nil {
+		return
+	}
+	if err = s.mw.Rename_ll(trashIno, entry, parentID, name); err != nil {
+		s.retrashInode(ino, deleteTime)
+		return
+	}
+	s.ndcache.Delete(parentID, name)
+	s.ic.Delete(trashIno)
+	s.ic.Delete(parentID)
+	return
+}
+
+// untrashInode removes the deletion time of an inode leaving the trash,
+// so that it is not purged by the meta partition. It returns the removed deletion time.
+func (s *Super) untrashInode(ino uint64) (deleteTime []byte, err error) {
+	info, err := s.mw.XAttrGet_ll(ino, proto.TrashTimeXAttr)
+	if err != nil {
+		log.LogWarnf("untrashInode: ino(%v) err(%v)", ino, err)
+		return
+	}
+	if deleteTime = info.Get(proto.TrashTimeXAttr); len(deleteTime) == 0 {
+		return
+	}
+	if err = s.mw.XAttrDel_ll(ino, proto.TrashTimeXAttr); err != nil {
+		log.LogWarnf("untrashInode: ino(%v) err(%v)", ino, err)
+	}
+	return
+}
+
+// retrashInode sets the deletion time back if the inode fails to leave the trash.
+func (s *Super) retrashInode(ino uint64, deleteTime []byte) {
+	if len(deleteTime) == 0 {
+		return
+	}
+	if err := s.mw.XAttrSet_ll(ino, []byte(proto.TrashTimeXAttr), deleteTime); err != nil {
+		log.LogWarnf("retrashInode: ino(%v) err(%v)", ino, err)
+	}
+}
+
+func (s *Super) ListTrash(w http.ResponseWriter, r *http.Request) {
+	trashIno, err := s.trashDir()
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf("List trash failed: %v\n", err)))
+		return
+	}
+	children, err := s.mw.ReadDir_ll(trashIno)
+	if err != nil {
+		w.Write([]byte(fmt.Sprintf("List trash failed: %v\n", err)))
+		return
+	}
+	for _, child := range children {
+		name, parentID, deleteTime, ok := proto.ParseTrashEntryName(child.Name)
+		if !ok {
+			continue
+		}
+		w.Write([]byte(fmt.Sprintf("%v\tparent(%v) name(%v) deleted(%v)\n", child.Name, parentID, name,
+			time.Unix(deleteTime, 0).Format(proto.TimeFormat))))
+	}
+}
+
+func (s *Super) RestoreTrash(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		w.Write([]byte(err.Error()))
+		return
+	}
+	entry := r.FormValue("name")
+	if entry == "" {
+		w.Write([]byte("Restore trash failed: name is required\n"))
+		return
+	}
+	if err := s.restoreFromTrash(entry); err != nil {
+		w.Write([]byte(fmt.Sprintf("Restore %v failed: %v\n", entry, err)))
+		return
+	}
+	w.Write([]byte(fmt.Sprintf("Restore %v successfully\n", entry)))
+}
@@ -0,0 +1,169 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"syscall"
+
+	"bazil.org/fuse"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// Flags of the setxattr request, see setxattr(2).
+const (
+	XattrCreate  = 1
+	XattrReplace = 2
+)
+
+// The xattr handlers are shared by files and directories.
+
+func (s *Super) getXattr(ino uint64, req *fuse.GetxattrRequest, resp *fuse.GetxattrResponse) error {
+	name := req.Name
+	info, err := s.mw.XAttrGet_ll(ino, name)
+	if err != nil {
+		log.LogErrorf("GetXattr: ino(%v) name(%v) err(%v)", ino, name, err)
+		return ParseError(err)
+	}
+	value := info.Get(name)
+	if len(value) == 0 {
+		// an empty value is also replied for a missing key
+		exist, err := s.xattrExist(ino, name)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			return fuse.ErrNoXattr
+		}
+	}
+	if req.Position > 0 {
+		if int(req.Position) > len(value) {
+			return fuse.ERANGE
+		}
+		value = value[req.Position:]
+	}
+	resp.Xattr = value
+	log.LogDebugf("TRACE GetXattr: ino(%v) name(%v)", ino, name)
+	return nil
+}
+
+// getDirStat replies the usage of the directory as the value of DirStatXAttr,
+// which is computed by the meta partitions rather than stored in the xattrs.
+func (s *Super) getDirStat(ino uint64, req *fuse.GetxattrRequest, resp *fuse.GetxattrResponse) error {
+	stat, err := s.mw.DirStat(ino)
+	if err != nil {
+		log.LogErrorf("GetXattr: ino(%v) name(%v) err(%v)", ino, req.Name, err)
+		return ParseError(err)
+	}
+	value := []byte(stat.String())
+	if req.Position > 0 {
+		if int(req.Position) > len(value) {
+			return fuse.ERANGE
+		}
+		value = value[req.Position:]
+	}
+	resp.Xattr = value
+	log.LogDebugf("TRACE GetXattr: ino(%v) name(%v) value(%v)", ino, req.Name, stat)
+	return nil
+}
+
+func (s *Super) listXattr(ino uint64, req *fuse.ListxattrRequest, resp *fuse.ListxattrResponse) error {
+	keys, err := s.mw.XAttrsList_ll(ino)
+	if err != nil {
+		log.LogErrorf("ListXattr: ino(%v) err(%v)", ino, err)
+		return ParseError(err)
+	}
+	for _, key := range keys {
+		resp.Append(key)
+	}
+	log.LogDebugf("TRACE Listxattr: ino(%v) keys(%v)", ino, len(keys))
+	return nil
+}
+
+func (s *Super) setXattr(ino uint64, req *fuse.SetxattrRequest) error {
+	name := req.Name
+	if name == proto.DirStatXAttr {
+		return fuse.EPERM
+	}
+	if proto.IsPosixACLXAttr(name) && s.enablePosixACL {
+		return s.setPosixACL(ino, req)
+	}
+	if name == proto.ExpireTTLXAttr {
+		if _, err := proto.ParseExpireTTL(req.Xattr); err != nil {
+			log.LogErrorf("Setxattr: ino(%v) name(%v) value(%v) err(%v)", ino, name, string(req.Xattr), err)
+			return syscall.EINVAL
+		}
+	}
+	var flag int
+	if req.Flags&XattrCreate != 0 {
+		flag |= proto.XAttrCreate
+	}
+	if req.Flags&XattrReplace != 0 {
+		flag |= proto.XAttrReplace
+	}
+	if err := s.mw.XAttrSetFlag_ll(ino, []byte(name), req.Xattr, flag); err != nil {
+		if flag&proto.XAttrCreate != 0 && err == syscall.EEXIST {
+			return fuse.EEXIST
+		}
+		if flag&proto.XAttrReplace != 0 && err == syscall.ENOENT {
+			return fuse.ErrNoXattr
+		}
+		log.LogErrorf("Setxattr: ino(%v) name(%v) err(%v)", ino, name, err)
+		return ParseError(err)
+	}
+	log.LogDebugf("TRACE Setxattr: ino(%v) name(%v)", ino, name)
+	return nil
+}
+
+func (s *Super) removeXattr(ino uint64, req *fuse.RemovexattrRequest) error {
+	name := req.Name
+	exist, err := s.xattrExist(ino, name)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return fuse.ErrNoXattr
+	}
+	if err = s.mw.XAttrDel_ll(ino, name); err != nil {
+		log.LogErrorf("Removexattr: ino(%v) name(%v) err(%v)", ino, name, err)
+		return ParseError(err)
+	}
+	log.LogDebugf("TRACE RemoveXattr: ino(%v) name(%v)", ino, name)
+	return nil
+}
+
+func (s *Super) xattrExist(ino uint64, name string) (bool, error) {
+	keys, err := s.mw.XAttrsList_ll(ino)
+	if err != nil {
+		log.LogErrorf("xattrExist: ino(%v) name(%v) err(%v)", ino, name, err)
+		return false, ParseError(err)
+	}
+	for _, key := range keys {
+		if key == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// modeFollowsACL checks whether the mode bits of the inode follow its S3 ACL, which is the case for the
+// inodes with ACLs in the volumes of the S3 permission priority, so that chmod on them is refused.
+func (s *Super) modeFollowsACL(ino uint64) (bool, error) {
+	if s.mw.PermissionPriority() != proto.PermissionPriorityS3 {
+		return false, nil
+	}
+	return s.xattrExist(ino, proto.XAttrKeyOSSACL)
+}
@@ -44,6 +44,8 @@ import (
 	"bazil.org/fuse/fs"
 	cfs "github.com/chubaofs/chubaofs/client/fs"
 	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/audit"
 	"github.com/chubaofs/chubaofs/util/config"
 	"github.com/chubaofs/chubaofs/util/errors"
 	"github.com/chubaofs/chubaofs/util/exporter"
@@ -69,6 +71,15 @@ const (
 	ControlCommandSetRate      = "/rate/set"
 	ControlCommandGetRate      = "/rate/get"
 	ControlCommandFreeOSMemory = "/debug/freeosmemory"
+	ControlCommandListTrash    = "/trash/list"
+	ControlCommandRestoreTrash = "/trash/restore"
+	ControlCommandReloadConfig = "/conf/reload"
+	ControlCommandOpenFiles    = "/files/open"
+	ControlCommandStreamers    = "/streamers/list"
+	ControlCommandOpStats      = "/ops/stats"
+	ControlCommandInflightOps  = "/ops/inflight"
+	ControlCommandFlushCache   = "/cache/flush"
+	ControlCommandCloneFile    = "/file/clone"
 	Role                       = "Client"
 )
 
@@ -76,6 +87,7 @@ var (
 	configFile       = flag.String("c", "", "FUSE client config file")
 	configVersion    = flag.Bool("v", false, "show version")
 	configForeground = flag.Bool("f", false, "run foreground")
+	configDaemon     = flag.Bool("daemon", false, "supervise the client and remount on crash")
 )
 
 var GlobalMountOptions []proto.MountOption
@@ -101,17 +113,25 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *configDaemon {
+		os.Exit(supervise())
+	}
+
 	/*
 	 * We are in daemon from here.
 	 * Must notify the parent process through SignalOutcome anyway.
 	 */
 
 	cfg, _ := config.LoadConfigFile(*configFile)
-	opt, err := parseMountOption(cfg)
+	opt, err := parseMountOption(GlobalMountOptions, cfg)
 	if err != nil {
 		daemonize.SignalOutcome(err)
 		os.Exit(1)
 	}
+	if err = util.InitTLSFromConfig(cfg, false); err != nil {
+		daemonize.SignalOutcome(err)
+		os.Exit(1)
+	}
 
 	if opt.MaxCPUs > 0 {
 		runtime.GOMAXPROCS(int(opt.MaxCPUs))
@@ -122,13 +142,26 @@ func main() {
 	exporter.Init(ModuleName, cfg)
 
 	level := parseLogLevel(opt.Loglvl)
-	_, err = log.InitLog(opt.Logpath, LoggerPrefix, level, nil)
+	logOpts, err := log.ParseOptions(cfg)
+	if err != nil {
+		daemonize.SignalOutcome(err)
+		os.Exit(1)
+	}
+	_, err = log.InitLogWithOptions(opt.Logpath, LoggerPrefix, level, logOpts)
 	if err != nil {
 		daemonize.SignalOutcome(err)
 		os.Exit(1)
 	}
 	defer log.LogFlush()
 
+	if opt.EnableAudit {
+		if _, err = audit.InitAudit(path.Join(opt.Logpath, LoggerPrefix), LoggerPrefix, opt.Volname, opt.AuditSink); err != nil {
+			daemonize.SignalOutcome(err)
+			os.Exit(1)
+		}
+		defer audit.StopAudit()
+	}
+
 	outputFilePath := path.Join(opt.Logpath, LoggerPrefix, LoggerOutput)
 	outputFile, err := os.OpenFile(outputFilePath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
 	if err != nil {
@@ -176,8 +209,11 @@ func main() {
 	defer fsConn.Close()
 
 	exporter.RegistConsul(super.ClusterName(), ModuleName, cfg)
+	registerReloadSignal(super)
 
-	if err = fs.Serve(fsConn, super); err != nil {
+	server := fs.New(fsConn, nil)
+	super.SetServer(server)
+	if err = server.Serve(super); err != nil {
 		log.LogFlush()
 		syslog.Printf("fs Serve returns err(%v)", err)
 		os.Exit(1)
@@ -242,7 +278,23 @@ func mount(opt *proto.MountOptions) (fsConn *fuse.Conn, super *cfs.Super, err er
 	http.HandleFunc(ControlCommandGetRate, super.GetRate)
 	http.HandleFunc(log.SetLogLevelPath, log.SetLogLevel)
 	http.HandleFunc(ControlCommandFreeOSMemory, freeOSMemory)
+	http.HandleFunc(ControlCommandListTrash, super.ListTrash)
+	http.HandleFunc(ControlCommandRestoreTrash, super.RestoreTrash)
 	http.HandleFunc(log.GetLogPath, log.GetLog)
+	http.HandleFunc(ControlCommandOpenFiles, super.ListOpenFiles)
+	http.HandleFunc(ControlCommandStreamers, super.ListStreamers)
+	http.HandleFunc(ControlCommandOpStats, super.GetOpStats)
+	http.HandleFunc(ControlCommandInflightOps, super.ListInflightOps)
+	http.HandleFunc(ControlCommandFlushCache, super.FlushCache)
+	http.HandleFunc(ControlCommandCloneFile, super.CloneFile)
+	http.HandleFunc(ControlCommandReloadConfig, func(w http.ResponseWriter, r *http.Request) {
+		if err := reloadConfig(super); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("Reload config failed: %v\n", err)))
+			return
+		}
+		w.Write([]byte("Reload config successfully\n"))
+	})
 
 	go func() {
 		if opt.Profport != "" {
@@ -264,9 +316,14 @@ func mount(opt *proto.MountOptions) (fsConn *fuse.Conn, super *cfs.Super, err er
 		return
 	}
 
+	maxReadAhead := uint32(MaxReadAhead)
+	if opt.MaxReadAhead > 0 {
+		maxReadAhead = uint32(opt.MaxReadAhead)
+	}
+
 	options := []fuse.MountOption{
 		fuse.AllowOther(),
-		fuse.MaxReadahead(MaxReadAhead),
+		fuse.MaxReadahead(maxReadAhead),
 		fuse.AsyncRead(),
 		fuse.AutoInvalData(opt.AutoInvalData),
 		fuse.FSName("chubaofs-" + opt.Volname),
@@ -285,6 +342,18 @@ func mount(opt *proto.MountOptions) (fsConn *fuse.Conn, super *cfs.Super, err er
 		options = append(options, fuse.PosixACL())
 	}
 
+	if opt.PermissionCheck == cfs.PermissionCheckClient {
+		options = append(options, fuse.DefaultPermissions())
+	}
+
+	if opt.EnablePosixLock {
+		options = append(options, fuse.LockingPOSIX())
+	}
+
+	if opt.ReaddirPlus {
+		options = append(options, fuse.ReaddirPlus())
+	}
+
 	fsConn, err = fuse.Mount(opt.MountPoint, options...)
 	return
 }
@@ -299,62 +368,124 @@ func registerInterceptedSignal(mnt string) {
 	}()
 }
 
-func parseMountOption(cfg *config.Config) (*proto.MountOptions, error) {
+func registerReloadSignal(super *cfs.Super) {
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, syscall.SIGHUP)
+	go func() {
+		for range sigC {
+			if err := reloadConfig(super); err != nil {
+				syslog.Printf("Reload config failed: %v\n", err)
+			}
+		}
+	}()
+}
+
+// reloadConfig reads the config file again, and applies the log level, the cache timeouts and the rate limits
+// to the live mount. The other options take effect on the next mount.
+func reloadConfig(super *cfs.Super) error {
+	cfg, err := config.LoadConfigFile(*configFile)
+	if err != nil {
+		return err
+	}
+	opts := proto.NewMountOptions()
+	proto.InitMountOptions(opts)
+	opt, err := parseMountOption(opts, cfg)
+	if err != nil {
+		return err
+	}
+	log.SetLevel(parseLogLevel(opt.Loglvl))
+	super.Reload(opt)
+	syslog.Printf("Reload config: logLevel(%v) icacheTimeout(%v) lookupValid(%v) attrValid(%v) readRate(%v) writeRate(%v) readBps(%v) writeBps(%v)\n",
+		opt.Loglvl, opt.IcacheTimeout, opt.LookupValid, opt.AttrValid, opt.ReadRate, opt.WriteRate, opt.ReadBps, opt.WriteBps)
+	return nil
+}
+
+func parseMountOption(opts []proto.MountOption, cfg *config.Config) (*proto.MountOptions, error) {
 	var err error
 	opt := new(proto.MountOptions)
 
-	proto.ParseMountOptions(GlobalMountOptions, cfg)
+	proto.ParseMountOptions(opts, cfg)
 
-	rawmnt := GlobalMountOptions[proto.MountPoint].GetString()
+	rawmnt := opts[proto.MountPoint].GetString()
 	opt.MountPoint, err = filepath.Abs(rawmnt)
 	if err != nil {
 		return nil, errors.Trace(err, "invalide mount point (%v) ", rawmnt)
 	}
 
-	opt.Volname = GlobalMountOptions[proto.VolName].GetString()
-	opt.Owner = GlobalMountOptions[proto.Owner].GetString()
-	opt.Master = GlobalMountOptions[proto.Master].GetString()
-	opt.Logpath = GlobalMountOptions[proto.LogDir].GetString()
-	opt.Loglvl = GlobalMountOptions[proto.LogLevel].GetString()
-	opt.Profport = GlobalMountOptions[proto.ProfPort].GetString()
-	opt.IcacheTimeout = GlobalMountOptions[proto.IcacheTimeout].GetInt64()
-	opt.LookupValid = GlobalMountOptions[proto.LookupValid].GetInt64()
-	opt.AttrValid = GlobalMountOptions[proto.AttrValid].GetInt64()
-	opt.ReadRate = GlobalMountOptions[proto.ReadRate].GetInt64()
-	opt.WriteRate = GlobalMountOptions[proto.WriteRate].GetInt64()
-	opt.EnSyncWrite = GlobalMountOptions[proto.EnSyncWrite].GetInt64()
-	opt.AutoInvalData = GlobalMountOptions[proto.AutoInvalData].GetInt64()
-	opt.UmpDatadir = GlobalMountOptions[proto.WarnLogDir].GetString()
-	opt.Rdonly = GlobalMountOptions[proto.Rdonly].GetBool()
-	opt.WriteCache = GlobalMountOptions[proto.WriteCache].GetBool()
-	opt.KeepCache = GlobalMountOptions[proto.KeepCache].GetBool()
-	opt.FollowerRead = GlobalMountOptions[proto.FollowerRead].GetBool()
-	opt.Authenticate = GlobalMountOptions[proto.Authenticate].GetBool()
+	opt.Volname = opts[proto.VolName].GetString()
+	opt.Owner = opts[proto.Owner].GetString()
+	opt.Master = opts[proto.Master].GetString()
+	opt.Logpath = opts[proto.LogDir].GetString()
+	opt.Loglvl = opts[proto.LogLevel].GetString()
+	opt.Profport = opts[proto.ProfPort].GetString()
+	opt.IcacheTimeout = opts[proto.IcacheTimeout].GetInt64()
+	opt.LookupValid = opts[proto.LookupValid].GetInt64()
+	opt.AttrValid = opts[proto.AttrValid].GetInt64()
+	opt.ReadRate = opts[proto.ReadRate].GetInt64()
+	opt.WriteRate = opts[proto.WriteRate].GetInt64()
+	if iops := opts[proto.ReadIops].GetInt64(); iops > 0 {
+		opt.ReadRate = iops
+	}
+	if iops := opts[proto.WriteIops].GetInt64(); iops > 0 {
+		opt.WriteRate = iops
+	}
+	opt.ReadBps = opts[proto.ReadBps].GetInt64()
+	opt.WriteBps = opts[proto.WriteBps].GetInt64()
+	opt.EnSyncWrite = opts[proto.EnSyncWrite].GetInt64()
+	opt.AutoInvalData = opts[proto.AutoInvalData].GetInt64()
+	opt.UmpDatadir = opts[proto.WarnLogDir].GetString()
+	opt.Rdonly = opts[proto.Rdonly].GetBool()
+	opt.WriteCache = opts[proto.WriteCache].GetBool()
+	opt.KeepCache = opts[proto.KeepCache].GetBool()
+	opt.FollowerRead = opts[proto.FollowerRead].GetBool()
+	opt.Authenticate = opts[proto.Authenticate].GetBool()
 	if opt.Authenticate {
-		opt.TicketMess.ClientKey = GlobalMountOptions[proto.ClientKey].GetString()
-		ticketHostConfig := GlobalMountOptions[proto.TicketHost].GetString()
+		opt.TicketMess.ClientKey = opts[proto.ClientKey].GetString()
+		ticketHostConfig := opts[proto.TicketHost].GetString()
 		ticketHosts := strings.Split(ticketHostConfig, ",")
 		opt.TicketMess.TicketHosts = ticketHosts
-		opt.TicketMess.EnableHTTPS = GlobalMountOptions[proto.EnableHTTPS].GetBool()
+		opt.TicketMess.EnableHTTPS = opts[proto.EnableHTTPS].GetBool()
 		if opt.TicketMess.EnableHTTPS {
-			opt.TicketMess.CertFile = GlobalMountOptions[proto.CertFile].GetString()
+			opt.TicketMess.CertFile = opts[proto.CertFile].GetString()
 		}
 	}
-	opt.TokenKey = GlobalMountOptions[proto.TokenKey].GetString()
-	opt.AccessKey = GlobalMountOptions[proto.AccessKey].GetString()
-	opt.SecretKey = GlobalMountOptions[proto.SecretKey].GetString()
-	opt.DisableDcache = GlobalMountOptions[proto.DisableDcache].GetBool()
-	opt.SubDir = GlobalMountOptions[proto.SubDir].GetString()
-	opt.FsyncOnClose = GlobalMountOptions[proto.FsyncOnClose].GetBool()
-	opt.MaxCPUs = GlobalMountOptions[proto.MaxCPUs].GetInt64()
-	opt.EnableXattr = GlobalMountOptions[proto.EnableXattr].GetBool()
-	opt.NearRead = GlobalMountOptions[proto.NearRead].GetBool()
-	opt.EnablePosixACL = GlobalMountOptions[proto.EnablePosixACL].GetBool()
+	opt.TokenKey = opts[proto.TokenKey].GetString()
+	opt.AccessKey = opts[proto.AccessKey].GetString()
+	opt.SecretKey = opts[proto.SecretKey].GetString()
+	opt.DisableDcache = opts[proto.DisableDcache].GetBool()
+	opt.SubDir = opts[proto.SubDir].GetString()
+	opt.FsyncOnClose = opts[proto.FsyncOnClose].GetBool()
+	opt.MaxCPUs = opts[proto.MaxCPUs].GetInt64()
+	opt.EnableXattr = opts[proto.EnableXattr].GetBool()
+	opt.NearRead = opts[proto.NearRead].GetBool()
+	opt.EnablePosixACL = opts[proto.EnablePosixACL].GetBool()
+	opt.EnablePosixLock = opts[proto.EnablePosixLock].GetBool()
+	opt.ReadCacheSize = opts[proto.ReadCacheSize].GetInt64()
+	opt.EnableAudit = opts[proto.EnableAudit].GetBool()
+	opt.AuditSink = opts[proto.AuditSink].GetString()
+	opt.SlowOpThreshold = opts[proto.SlowOpThreshold].GetInt64()
+	opt.ReaddirPlus = opts[proto.ReaddirPlus].GetBool()
+	opt.NdcacheTimeout = opts[proto.NdcacheTimeout].GetInt64()
+	opt.AsyncRmdir = opts[proto.AsyncRmdir].GetBool()
+	opt.DirectIO = opts[proto.DirectIO].GetBool()
+	opt.MaxReadAhead = opts[proto.MaxReadAhead].GetInt64()
+	opt.PrefetchSize = opts[proto.PrefetchSize].GetInt64()
+	opt.ReadPolicy = opts[proto.ReadPolicy].GetString()
+	opt.HedgeDelay = opts[proto.HedgeDelay].GetInt64()
+	opt.MetaFollowerRead = opts[proto.MetaFollowerRead].GetBool()
+	opt.RootSquash = opts[proto.RootSquash].GetBool()
+	opt.AllSquash = opts[proto.AllSquash].GetBool()
+	opt.AnonUid = opts[proto.AnonUid].GetInt64()
+	opt.AnonGid = opts[proto.AnonGid].GetInt64()
+	opt.UidMap = opts[proto.UidMap].GetString()
+	opt.GidMap = opts[proto.GidMap].GetString()
+	opt.PermissionCheck = opts[proto.PermissionCheck].GetString()
 
 	if opt.MountPoint == "" || opt.Volname == "" || opt.Owner == "" || opt.Master == "" {
 		return nil, errors.New(fmt.Sprintf("invalid config file: lack of mandatory fields, mountPoint(%v), volName(%v), owner(%v), masterAddr(%v)", opt.MountPoint, opt.Volname, opt.Owner, opt.Master))
 	}
 
+	opt.Reported = proto.ReportedMountOptions(opts)
 	return opt, nil
 }
 
@@ -379,28 +510,11 @@ func checkPermission(opt *proto.MountOptions) (err error) {
 
 	// Check user access policy is enabled
 	if opt.AccessKey != "" {
-		var userInfo *proto.UserInfo
-		if userInfo, err = mc.UserAPI().GetAKInfo(opt.AccessKey); err != nil {
-			return
-		}
-		if userInfo.SecretKey != opt.SecretKey {
-			err = proto.ErrNoPermission
-			return
-		}
-		var policy = userInfo.Policy
-		if policy.IsOwn(opt.Volname) {
-			return
-		}
-		if policy.IsAuthorized(opt.Volname, opt.SubDir, proto.POSIXWriteAction) &&
-			policy.IsAuthorized(opt.Volname, opt.SubDir, proto.POSIXReadAction) {
-			return
-		}
-		if policy.IsAuthorized(opt.Volname, opt.SubDir, proto.POSIXReadAction) &&
-			!policy.IsAuthorized(opt.Volname, opt.SubDir, proto.POSIXWriteAction) {
-			opt.Rdonly = true
+		var result *proto.UserAuthorizeResult
+		if result, err = mc.UserAPI().Authorize(opt.AccessKey, opt.SecretKey, opt.Volname, opt.SubDir); err != nil {
 			return
 		}
-		err = proto.ErrNoPermission
+		opt.Rdonly = result.Rdonly || opt.Rdonly
 		return
 	}
 	return
@@ -0,0 +1,211 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+//
+// With -daemon the client is a supervisor which runs the FUSE client as a worker process,
+// and remounts the mount point when the worker crashes or the FUSE connection is broken,
+// instead of leaving a dead mount point.
+//
+
+import (
+	"fmt"
+	syslog "log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/config"
+	sysutil "github.com/chubaofs/chubaofs/util/sys"
+	"github.com/jacobsa/daemonize"
+)
+
+const (
+	// the environment variable and the fd of the pipe to notify the outcome of the mount, see daemonize.Run
+	daemonizeStatusEnv = "DAEMONIZE_STATUS_FD"
+	daemonizeStatusFD  = 3
+
+	mountCheckInterval = 5 * time.Second
+	minRemountInterval = time.Second
+	maxRemountInterval = time.Minute
+)
+
+type mountState int
+
+const (
+	mountStateNone mountState = iota
+	mountStateAlive
+	mountStateDead
+)
+
+// supervise runs the worker until it exits without a dead mount point left, and returns its exit code.
+func supervise() int {
+	cfg, _ := config.LoadConfigFile(*configFile)
+	opt, err := parseMountOption(GlobalMountOptions, cfg)
+	if err != nil {
+		daemonize.SignalOutcome(err)
+		return 1
+	}
+	outputFile, err := os.OpenFile(path.Join(opt.Logpath, LoggerPrefix, LoggerOutput), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
+	if err != nil {
+		daemonize.SignalOutcome(err)
+		return 1
+	}
+	defer outputFile.Close()
+	syslog.SetOutput(outputFile)
+
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, syscall.SIGINT, syscall.SIGTERM)
+
+	var (
+		mounted  bool // the worker has ever mounted the mount point
+		stopping bool
+		interval = minRemountInterval
+	)
+	for first := true; ; first = false {
+		cmd, err := startWorker(first)
+		if err != nil {
+			syslog.Printf("supervisor: start worker failed: %v", err)
+			if first {
+				daemonize.SignalOutcome(err)
+				return 1
+			}
+		} else {
+			syslog.Printf("supervisor: worker(%v) started", cmd.Process.Pid)
+			done := make(chan error, 1)
+			go func() {
+				done <- cmd.Wait()
+			}()
+			// the check may block on a hung mount point, so it is done in the background
+			stateC := make(chan mountState, 1)
+			checking := false
+			ticker := time.NewTicker(mountCheckInterval)
+		wait:
+			for {
+				select {
+				case err = <-done:
+					break wait
+				case sig := <-sigC:
+					syslog.Printf("supervisor: forward signal(%v) to worker(%v)", sig, cmd.Process.Pid)
+					stopping = true
+					cmd.Process.Signal(sig)
+				case <-ticker.C:
+					if !checking {
+						checking = true
+						go func() {
+							stateC <- checkMountPoint(opt.MountPoint)
+						}()
+					}
+				case state := <-stateC:
+					checking = false
+					switch state {
+					case mountStateAlive:
+						mounted = true
+						interval = minRemountInterval
+					case mountStateDead:
+						syslog.Printf("supervisor: mount point(%v) disconnected, kill worker(%v)", opt.MountPoint, cmd.Process.Pid)
+						cmd.Process.Kill()
+					}
+				}
+			}
+			ticker.Stop()
+			syslog.Printf("supervisor: worker(%v) exited: %v", cmd.Process.Pid, err)
+			if stopping {
+				return exitCode(err)
+			}
+			state := checkMountPoint(opt.MountPoint)
+			if state == mountStateNone && (err == nil || !mounted) {
+				// unmounted by the user, or the mount point has never been mounted
+				return exitCode(err)
+			}
+			if state != mountStateNone {
+				if err = sysutil.LazyUnmount(opt.MountPoint); err != nil {
+					syslog.Printf("supervisor: unmount(%v) failed: %v", opt.MountPoint, err)
+				}
+			}
+		}
+		syslog.Printf("supervisor: remount(%v) in %v", opt.MountPoint, interval)
+		select {
+		case sig := <-sigC:
+			syslog.Printf("supervisor: killed due to a received signal (%v)", sig)
+			return 1
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > maxRemountInterval {
+			interval = maxRemountInterval
+		}
+	}
+}
+
+// startWorker starts the client in the foreground without -daemon. The first worker notifies the outcome
+// of the mount to the process which starts the supervisor.
+func startWorker(first bool) (*exec.Cmd, error) {
+	cmdPath, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, len(os.Args))
+	for _, arg := range os.Args[1:] {
+		if name := strings.TrimLeft(arg, "-"); name == "daemon" || strings.HasPrefix(name, "daemon=") {
+			continue
+		}
+		args = append(args, arg)
+	}
+	cmd := exec.Command(cmdPath, args...)
+	cmd.Env = make([]string, 0, len(os.Environ()))
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, daemonizeStatusEnv+"=") {
+			cmd.Env = append(cmd.Env, env)
+		}
+	}
+	if _, ok := os.LookupEnv(daemonizeStatusEnv); ok && first {
+		cmd.ExtraFiles = []*os.File{os.NewFile(daemonizeStatusFD, daemonizeStatusEnv)}
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%v=%v", daemonizeStatusEnv, daemonizeStatusFD))
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// checkMountPoint tells whether the mount point is mounted by comparing its device with the parent's.
+// A mount point whose FUSE server is gone fails with ENOTCONN.
+func checkMountPoint(mnt string) mountState {
+	var st, parent syscall.Stat_t
+	if err := syscall.Stat(mnt, &st); err != nil {
+		if err == syscall.ENOTCONN || err == syscall.ECONNABORTED {
+			return mountStateDead
+		}
+		return mountStateNone
+	}
+	if err := syscall.Stat(path.Dir(mnt), &parent); err != nil || st.Dev == parent.Dev {
+		return mountStateNone
+	}
+	return mountStateAlive
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+	return 1
+}
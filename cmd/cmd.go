@@ -42,6 +42,7 @@ import (
 	"github.com/chubaofs/chubaofs/datanode"
 	"github.com/chubaofs/chubaofs/master"
 	"github.com/chubaofs/chubaofs/metanode"
+	"github.com/chubaofs/chubaofs/replicator"
 	"github.com/chubaofs/chubaofs/util/config"
 	"github.com/chubaofs/chubaofs/util/log"
 	"github.com/chubaofs/chubaofs/util/ump"
@@ -62,6 +63,7 @@ const (
 	RoleAuth    = "authnode"
 	RoleObject  = "objectnode"
 	RoleConsole = "console"
+	RoleReplica = "replicator"
 )
 
 const (
@@ -71,6 +73,7 @@ const (
 	ModuleAuth    = "authNode"
 	ModuleObject  = "objectNode"
 	ModuleConsole = "console"
+	ModuleReplica = "replicator"
 )
 
 const (
@@ -177,6 +180,9 @@ func main() {
 	case RoleConsole:
 		server = console.NewServer()
 		module = ModuleConsole
+	case RoleReplica:
+		server = replicator.NewServer()
+		module = ModuleReplica
 	default:
 		daemonize.SignalOutcome(fmt.Errorf("Fatal: role mismatch: %v", role))
 		os.Exit(1)
@@ -199,7 +205,12 @@ func main() {
 		level = log.ErrorLevel
 	}
 
-	_, err = log.InitLog(logDir, module, level, nil)
+	logOpts, err := log.ParseOptions(cfg)
+	if err != nil {
+		daemonize.SignalOutcome(fmt.Errorf("Fatal: failed to init log - %v", err))
+		os.Exit(1)
+	}
+	_, err = log.InitLogWithOptions(logDir, module, level, logOpts)
 	if err != nil {
 		daemonize.SignalOutcome(fmt.Errorf("Fatal: failed to init log - %v", err))
 		os.Exit(1)
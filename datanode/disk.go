@@ -29,6 +29,7 @@ import (
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util/exporter"
 	"github.com/chubaofs/chubaofs/util/log"
+	"golang.org/x/time/rate"
 	"os"
 )
 
@@ -43,6 +44,7 @@ const ExpiredPartitionPrefix = "expired_"
 type Disk struct {
 	sync.RWMutex
 	Path        string
+	MediaType   string
 	ReadErrCnt  uint64 // number of read errors
 	WriteErrCnt uint64 // number of write errors
 
@@ -57,9 +59,15 @@ type Disk struct {
 	ReservedSpace uint64
 
 	RejectWrite                               bool
+	isSlow                                    bool // marked read-only because of the high latency
+	slowCnt                                   int
+	lastErrCnt                                uint64
+	smart                                     *proto.DiskSmartInfo // nil if SMART is not available
 	partitionMap                              map[uint64]*DataPartition
 	syncTinyDeleteRecordFromLeaderOnEveryDisk chan bool
 	space                                     *SpaceManager
+	writeLimiter                              *rate.Limiter // throttles the client writes
+	repairLimiter                             *rate.Limiter // throttles the repair writes
 }
 
 const (
@@ -68,13 +76,16 @@ const (
 
 type PartitionVisitor func(dp *DataPartition)
 
-func NewDisk(path string, reservedSpace uint64, maxErrCnt int, space *SpaceManager) (d *Disk) {
+func NewDisk(path, mediaType string, reservedSpace uint64, maxErrCnt int, space *SpaceManager) (d *Disk) {
 	d = new(Disk)
 	d.Path = path
+	d.MediaType = mediaType
 	d.ReservedSpace = reservedSpace
 	d.MaxErrCnt = maxErrCnt
 	d.RejectWrite = false
 	d.space = space
+	d.writeLimiter = newWriteLimiter(atomic.LoadUint64(&diskWriteLimitRate))
+	d.repairLimiter = newWriteLimiter(atomic.LoadUint64(&diskRepairLimitRate))
 	d.partitionMap = make(map[uint64]*DataPartition)
 	d.syncTinyDeleteRecordFromLeaderOnEveryDisk = make(chan bool, SyncTinyDeleteRecordFromLeaderOnEveryDisk)
 	d.computeUsage()
@@ -154,7 +165,7 @@ func (d *Disk) incWriteErrCnt() {
 func (d *Disk) startScheduleToUpdateSpaceInfo() {
 	go func() {
 		updateSpaceInfoTicker := time.NewTicker(5 * time.Second)
-		checkStatusTickser := time.NewTicker(time.Minute * 2)
+		checkStatusTickser := time.NewTicker(DiskHealthCheckInterval)
 		defer func() {
 			updateSpaceInfoTicker.Stop()
 			checkStatusTickser.Stop()
@@ -165,7 +176,7 @@ func (d *Disk) startScheduleToUpdateSpaceInfo() {
 				d.computeUsage()
 				d.updateSpaceInfo()
 			case <-checkStatusTickser.C:
-				d.checkDiskStatus()
+				d.checkDiskHealth()
 			}
 		}
 	}()
@@ -219,11 +230,7 @@ func (d *Disk) triggerDiskError(err error) {
 		return
 	}
 	if IsDiskErr(err.Error()) {
-		mesg := fmt.Sprintf("disk path %v error on %v", d.Path, LocalIP)
-		exporter.Warning(mesg)
-		log.LogErrorf(mesg)
-		d.ForceExitRaftStore()
-		d.Status = proto.Unavailable
+		d.offline(fmt.Sprintf("disk path %v error on %v", d.Path, LocalIP))
 	}
 	return
 }
@@ -238,7 +245,7 @@ func (d *Disk) updateSpaceInfo() (err error) {
 		log.LogErrorf(mesg)
 		exporter.Warning(mesg)
 		d.ForceExitRaftStore()
-	} else if d.Available <= 0 {
+	} else if d.Available <= 0 || d.isSlow {
 		d.Status = proto.ReadOnly
 	} else {
 		d.Status = proto.ReadWrite
@@ -0,0 +1,84 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/exporter"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	DefaultDiskSlowThreshold = 10 * time.Second
+	DefaultDiskMaxSlowCnt    = 3
+	DiskHealthCheckInterval  = time.Minute
+)
+
+var (
+	DiskMaxErrCnt     = DefaultDiskMaxErr
+	DiskSlowThreshold = DefaultDiskSlowThreshold
+)
+
+// checkDiskHealth probes the disk and isolates it once it is failing, so that only the partitions
+// on this disk are affected instead of the whole data node.
+// A disk that has reached the maximum number of IO errors within one check interval is taken offline,
+// it is reported to the master in the heartbeat as a bad disk and its partitions are re-replicated.
+// A disk whose probe has been slower than the threshold for several consecutive checks is marked read-only
+// until the data node restarts.
+func (d *Disk) checkDiskHealth() {
+	start := time.Now()
+	d.checkDiskStatus()
+	d.checkLatency(time.Since(start))
+	d.checkErrorRate()
+}
+
+func (d *Disk) checkErrorRate() {
+	errCnt := atomic.LoadUint64(&d.ReadErrCnt) + atomic.LoadUint64(&d.WriteErrCnt)
+	newErrCnt := errCnt - d.lastErrCnt
+	d.lastErrCnt = errCnt
+	if d.MaxErrCnt <= 0 || newErrCnt < uint64(d.MaxErrCnt) || d.Status == proto.Unavailable {
+		return
+	}
+	d.offline(fmt.Sprintf("disk path %v on %v has %v io errors within %v", d.Path, LocalIP, newErrCnt, DiskHealthCheckInterval))
+}
+
+func (d *Disk) checkLatency(cost time.Duration) {
+	if DiskSlowThreshold <= 0 || cost < DiskSlowThreshold {
+		d.slowCnt = 0
+		return
+	}
+	d.slowCnt++
+	log.LogWarnf("action[checkLatency] disk path %v on %v is slow, cost(%v) slowCnt(%v)", d.Path, LocalIP, cost, d.slowCnt)
+	if d.slowCnt < DefaultDiskMaxSlowCnt || d.isSlow {
+		return
+	}
+	d.isSlow = true
+	d.Status = proto.ReadOnly
+	mesg := fmt.Sprintf("disk path %v on %v is slow for %v checks, mark it read-only", d.Path, LocalIP, d.slowCnt)
+	exporter.Warning(mesg)
+	log.LogErrorf("%s", mesg)
+}
+
+// offline takes the disk offline and stops the raft of all the partitions on it.
+func (d *Disk) offline(mesg string) {
+	exporter.Warning(mesg)
+	log.LogErrorf("%s", mesg)
+	d.ForceExitRaftStore()
+	d.Status = proto.Unavailable
+}
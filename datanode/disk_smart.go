@@ -0,0 +1,228 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/exporter"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	DefaultSmartIntervalMin = 10
+	SmartctlCmd             = "smartctl"
+
+	smartAttrReallocatedSectors   = 5
+	smartAttrPowerOnHours         = 9
+	smartAttrTemperature          = 194
+	smartAttrPendingSectors       = 197
+	smartAttrUncorrectableSectors = 198
+
+	// a disk with more reallocated, pending and uncorrectable sectors than it is predicted to fail
+	smartMaxBadSectors = 100
+)
+
+var (
+	SmartEnabled  = true
+	SmartInterval = DefaultSmartIntervalMin * time.Minute
+)
+
+type smartAttribute struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Value  int    `json:"value"`
+	Thresh int    `json:"thresh"`
+	Raw    struct {
+		Value int64 `json:"value"`
+	} `json:"raw"`
+}
+
+// smartctlOutput is the part of the json output of "smartctl -H -A -j" used by the data node.
+type smartctlOutput struct {
+	SmartStatus *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature struct {
+		Current int64 `json:"current"`
+	} `json:"temperature"`
+	PowerOnTime struct {
+		Hours int64 `json:"hours"`
+	} `json:"power_on_time"`
+	AtaSmartAttributes struct {
+		Table []smartAttribute `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NvmeSmartHealthInformationLog *struct {
+		CriticalWarning int64 `json:"critical_warning"`
+		PercentageUsed  int64 `json:"percentage_used"`
+		MediaErrors     int64 `json:"media_errors"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+// smartScheduler collects the SMART attributes of the disk with smartctl periodically.
+// The attributes are exported to the monitor system and reported to the master in the heartbeat,
+// and the master migrates the partitions off the disk once it is predicted to fail.
+func (d *Disk) smartScheduler() {
+	for {
+		d.updateSmartInfo()
+		time.Sleep(SmartInterval)
+	}
+}
+
+func (d *Disk) updateSmartInfo() {
+	device, err := mountDevice(d.Path)
+	if err != nil {
+		log.LogWarnf("action[updateSmartInfo] disk(%v) err(%v)", d.Path, err)
+		return
+	}
+	info, err := readSmartInfo(device)
+	if err != nil {
+		log.LogWarnf("action[updateSmartInfo] disk(%v) device(%v) err(%v)", d.Path, device, err)
+		return
+	}
+	info.Path = d.Path
+	if info.PredictFailure && !d.isPredictedToFail() {
+		mesg := fmt.Sprintf("disk path %v device %v on %v is predicted to fail: %v", d.Path, device, LocalIP, info.Reason)
+		exporter.Warning(mesg)
+		log.LogErrorf("%s", mesg)
+	}
+	d.Lock()
+	d.smart = info
+	d.Unlock()
+	exportSmartInfo(info)
+}
+
+func (d *Disk) smartInfo() (info *proto.DiskSmartInfo) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.smart
+}
+
+func (d *Disk) isPredictedToFail() bool {
+	info := d.smartInfo()
+	return info != nil && info.PredictFailure
+}
+
+func readSmartInfo(device string) (info *proto.DiskSmartInfo, err error) {
+	// the exit status of smartctl is a bit mask which is not zero for an unhealthy disk, so only the output is checked
+	data, _ := exec.Command(SmartctlCmd, "-H", "-A", "-j", device).Output()
+	out := new(smartctlOutput)
+	if err = json.Unmarshal(data, out); err != nil {
+		return
+	}
+	if out.SmartStatus == nil {
+		return nil, fmt.Errorf("SMART is not available on %v", device)
+	}
+	info = &proto.DiskSmartInfo{
+		Device:       device,
+		Healthy:      out.SmartStatus.Passed,
+		Temperature:  out.Temperature.Current,
+		PowerOnHours: out.PowerOnTime.Hours,
+		UpdateTime:   time.Now().Unix(),
+	}
+	reasons := make([]string, 0)
+	if !info.Healthy {
+		reasons = append(reasons, "overall health self-assessment failed")
+	}
+	for _, attr := range out.AtaSmartAttributes.Table {
+		switch attr.ID {
+		case smartAttrReallocatedSectors:
+			info.ReallocatedSectors = attr.Raw.Value
+		case smartAttrPendingSectors:
+			info.PendingSectors = attr.Raw.Value
+		case smartAttrUncorrectableSectors:
+			info.UncorrectableSectors = attr.Raw.Value
+		case smartAttrPowerOnHours:
+			if info.PowerOnHours == 0 {
+				info.PowerOnHours = attr.Raw.Value
+			}
+		case smartAttrTemperature:
+			if info.Temperature == 0 {
+				info.Temperature = attr.Raw.Value
+			}
+		}
+		if attr.Thresh > 0 && attr.Value <= attr.Thresh {
+			reasons = append(reasons, fmt.Sprintf("attribute %v(%v) is below the threshold", attr.ID, attr.Name))
+		}
+	}
+	if nvme := out.NvmeSmartHealthInformationLog; nvme != nil {
+		info.UncorrectableSectors = nvme.MediaErrors
+		if nvme.CriticalWarning != 0 {
+			reasons = append(reasons, fmt.Sprintf("critical warning %v", nvme.CriticalWarning))
+		}
+		if nvme.PercentageUsed >= 100 {
+			reasons = append(reasons, "endurance is used up")
+		}
+	}
+	if badSectors := info.ReallocatedSectors + info.PendingSectors + info.UncorrectableSectors; badSectors >= smartMaxBadSectors {
+		reasons = append(reasons, fmt.Sprintf("%v bad sectors", badSectors))
+	}
+	info.PredictFailure = len(reasons) > 0
+	info.Reason = strings.Join(reasons, ", ")
+	return
+}
+
+// mountDevice returns the device mounted on the longest mount point containing the path.
+func mountDevice(diskPath string) (device string, err error) {
+	fp, err := os.Open("/proc/self/mounts")
+	if err != nil {
+		return
+	}
+	defer fp.Close()
+	diskPath = path.Clean(diskPath)
+	var mountPoint string
+	scanner := bufio.NewScanner(fp)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "/dev/") {
+			continue
+		}
+		if fields[1] != diskPath && fields[1] != "/" && !strings.HasPrefix(diskPath, fields[1]+"/") {
+			continue
+		}
+		if len(fields[1]) > len(mountPoint) {
+			mountPoint, device = fields[1], fields[0]
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+	if device == "" {
+		err = fmt.Errorf("no device is mounted on %v", diskPath)
+	}
+	return
+}
+
+func exportSmartInfo(info *proto.DiskSmartInfo) {
+	labels := map[string]string{"path": info.Path, "device": info.Device}
+	var predictFailure float64
+	if info.PredictFailure {
+		predictFailure = 1
+	}
+	exporter.NewGauge("disk_smart_predict_failure").SetWithLabels(predictFailure, labels)
+	exporter.NewGauge("disk_smart_temperature").SetWithLabels(float64(info.Temperature), labels)
+	exporter.NewGauge("disk_smart_power_on_hours").SetWithLabels(float64(info.PowerOnHours), labels)
+	exporter.NewGauge("disk_smart_reallocated_sectors").SetWithLabels(float64(info.ReallocatedSectors), labels)
+	exporter.NewGauge("disk_smart_pending_sectors").SetWithLabels(float64(info.PendingSectors), labels)
+	exporter.NewGauge("disk_smart_uncorrectable_sectors").SetWithLabels(float64(info.UncorrectableSectors), labels)
+}
@@ -3,6 +3,9 @@ package datanode
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
+
+	"github.com/chubaofs/chubaofs/util"
 	"golang.org/x/time/rate"
 )
 
@@ -11,6 +14,11 @@ var (
 	MaxExtentRepairLimit    = 20000
 	MinExtentRepairLimit    = 5
 	extentRepairLimiteRater = make(chan struct{}, MaxExtentRepairLimit)
+
+	// the write throttles in MB/s set by the master, 0 means unlimited
+	diskWriteLimitRate  uint64 // the client writes on each disk
+	diskRepairLimitRate uint64 // the repair writes on each disk
+	dpWriteLimitRate    uint64 // the client writes on each data partition
 )
 
 func requestDoExtentRepair() (err error) {
@@ -61,3 +69,46 @@ func setLimiter(limiter *rate.Limiter, limitValue uint64) {
 	}
 	limiter.SetLimit(l)
 }
+
+func newWriteLimiter(limitRate uint64) (limiter *rate.Limiter) {
+	limiter = rate.NewLimiter(rate.Inf, util.BlockSize)
+	setWriteLimiter(limiter, limitRate)
+	return
+}
+
+// setWriteLimiter limits the limiter to limitRate MB/s.
+func setWriteLimiter(limiter *rate.Limiter, limitRate uint64) {
+	l := rate.Limit(limitRate * util.MB)
+	if limitRate == 0 {
+		l = rate.Inf
+	}
+	limiter.SetLimit(l)
+}
+
+// writeLimiterWait blocks until the limiter allows to write size bytes.
+func writeLimiterWait(limiter *rate.Limiter, size int) {
+	if limiter == nil || limiter.Limit() == rate.Inf {
+		return
+	}
+	ctx := context.Background()
+	for size > 0 {
+		n := util.Min(size, limiter.Burst())
+		limiter.WaitN(ctx, n)
+		size -= n
+	}
+}
+
+// setWriteLimits applies the write throttles to all the disks and the data partitions.
+func (manager *SpaceManager) setWriteLimits(diskWrite, diskRepair, dpWrite uint64) {
+	atomic.StoreUint64(&diskWriteLimitRate, diskWrite)
+	atomic.StoreUint64(&diskRepairLimitRate, diskRepair)
+	atomic.StoreUint64(&dpWriteLimitRate, dpWrite)
+	for _, d := range manager.GetDisks() {
+		setWriteLimiter(d.writeLimiter, diskWrite)
+		setWriteLimiter(d.repairLimiter, diskRepair)
+	}
+	manager.RangePartitions(func(dp *DataPartition) bool {
+		setWriteLimiter(dp.writeLimiter, dpWrite)
+		return true
+	})
+}
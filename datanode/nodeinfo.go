@@ -43,7 +43,10 @@ func (m *DataNode) updateNodeInfo() {
 	}
 	setLimiter(deleteLimiteRater, clusterInfo.DataNodeDeleteLimitRate)
 	setDoExtentRepair(int(clusterInfo.DataNodeAutoRepairLimitRate))
+	m.space.setWriteLimits(clusterInfo.DataNodeDiskWriteLimitRate, clusterInfo.DataNodeDiskRepairLimitRate,
+		clusterInfo.DataNodeDpWriteLimitRate)
 	log.LogInfof("updateNodeInfo from master:"+
-		"deleteLimite(%v),autoRepairLimit(%v)", clusterInfo.DataNodeDeleteLimitRate,
-		clusterInfo.DataNodeAutoRepairLimitRate)
+		"deleteLimite(%v),autoRepairLimit(%v),diskWriteLimit(%v),diskRepairLimit(%v),dpWriteLimit(%v)",
+		clusterInfo.DataNodeDeleteLimitRate, clusterInfo.DataNodeAutoRepairLimitRate,
+		clusterInfo.DataNodeDiskWriteLimitRate, clusterInfo.DataNodeDiskRepairLimitRate, clusterInfo.DataNodeDpWriteLimitRate)
 }
@@ -24,6 +24,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"hash/crc32"
@@ -39,6 +40,7 @@ import (
 	"github.com/chubaofs/chubaofs/util/exporter"
 	"github.com/chubaofs/chubaofs/util/log"
 	raftProto "github.com/tiglabs/raft/proto"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -59,6 +61,8 @@ type DataPartitionMetadata struct {
 	Hosts                   []string
 	DataPartitionCreateType int
 	LastTruncateID          uint64
+	Encrypted               bool
+	Cache                   bool
 }
 
 type sortedPeers []proto.Peer
@@ -87,6 +91,7 @@ func (md *DataPartitionMetadata) Validate() (err error) {
 type DataPartition struct {
 	clusterID       string
 	volumeID        string
+	volNameLock     sync.RWMutex // guards volumeID and config.VolName, which are changed by renameVols
 	partitionID     uint64
 	partitionStatus int
 	partitionSize   int
@@ -115,6 +120,11 @@ type DataPartition struct {
 	loadExtentHeaderStatus        int
 	DataPartitionCreateType       int
 	isLoadingDataPartition        bool
+	lastAccessTime                int64    // unix time of the last read or write of the clients, used for tiering
+	corruptExtents                []uint64 // extents found corrupt and not repaired by the last scrub
+	lastScrubTime                 int64
+	scrubMutex                    sync.RWMutex
+	writeLimiter                  *rate.Limiter // throttles the client writes on this partition
 }
 
 func CreateDataPartition(dpCfg *dataPartitionCfg, disk *Disk, request *proto.CreateDataPartitionRequest) (dp *DataPartition, err error) {
@@ -156,8 +166,8 @@ func (dp *DataPartition) IsEquareCreateDataPartitionRequst(request *proto.Create
 			return fmt.Errorf("Exsit unavali Partition(%v) partitionHosts(%v) requestHosts(%v)", dp.partitionID, dp.config.Peers, request.Members)
 		}
 	}
-	if dp.config.VolName != request.VolumeId {
-		return fmt.Errorf("Exsit unavali Partition(%v) VolName(%v) requestVolName(%v)", dp.partitionID, dp.config.VolName, request.VolumeId)
+	if volName := dp.VolName(); volName != request.VolumeId {
+		return fmt.Errorf("Exsit unavali Partition(%v) VolName(%v) requestVolName(%v)", dp.partitionID, volName, request.VolumeId)
 	}
 
 	return
@@ -195,6 +205,8 @@ func LoadDataPartition(partitionDir string, disk *Disk) (dp *DataPartition, err
 		PartitionID:   meta.PartitionID,
 		Peers:         meta.Peers,
 		Hosts:         meta.Hosts,
+		Encrypted:     meta.Encrypted,
+		Cache:         meta.Cache,
 		RaftStore:     disk.space.GetRaftStore(),
 		NodeID:        disk.space.GetNodeID(),
 		ClusterID:     disk.space.GetClusterID(),
@@ -243,9 +255,14 @@ func newDataPartition(dpCfg *dataPartitionCfg, disk *Disk) (dp *DataPartition, e
 		snapshot:        make([]*proto.File, 0),
 		partitionStatus: proto.ReadWrite,
 		config:          dpCfg,
+		writeLimiter:    newWriteLimiter(atomic.LoadUint64(&dpWriteLimitRate)),
 	}
 	partition.replicasInit()
-	partition.extentStore, err = storage.NewExtentStore(partition.path, dpCfg.PartitionID, dpCfg.PartitionSize)
+	cipher, err := newExtentCipher(dpCfg, disk)
+	if err != nil {
+		return
+	}
+	partition.extentStore, err = storage.NewExtentStore(partition.path, dpCfg.PartitionID, dpCfg.PartitionSize, cipher)
 	if err != nil {
 		return
 	}
@@ -302,6 +319,19 @@ func (dp *DataPartition) IsRaftLeader() (addr string, ok bool) {
 	return
 }
 
+// VolName returns the name of the volume of the partition.
+func (dp *DataPartition) VolName() string {
+	dp.volNameLock.RLock()
+	defer dp.volNameLock.RUnlock()
+	return dp.volumeID
+}
+
+func (dp *DataPartition) setVolName(name string) {
+	dp.volNameLock.Lock()
+	dp.config.VolName, dp.volumeID = name, name
+	dp.volNameLock.Unlock()
+}
+
 func (dp *DataPartition) Replicas() []string {
 	dp.replicasLock.RLock()
 	defer dp.replicasLock.RUnlock()
@@ -367,6 +397,21 @@ func (dp *DataPartition) Disk() *Disk {
 	return dp.disk
 }
 
+// markAccess records the read or write of the clients, the repair of the replicas is not counted.
+func (dp *DataPartition) markAccess() {
+	atomic.StoreInt64(&dp.lastAccessTime, time.Now().Unix())
+}
+
+// waitWrite throttles the client writes of size bytes by the partition and the disk limits.
+func (dp *DataPartition) waitWrite(size int) {
+	writeLimiterWait(dp.writeLimiter, size)
+	writeLimiterWait(dp.disk.writeLimiter, size)
+}
+
+func (dp *DataPartition) LastAccessTime() int64 {
+	return atomic.LoadInt64(&dp.lastAccessTime)
+}
+
 func (dp *DataPartition) IsRejectWrite() bool {
 	return dp.Disk().RejectWrite
 }
@@ -415,7 +460,7 @@ func (dp *DataPartition) PersistMetadata() (err error) {
 	sort.Sort(sp)
 
 	md := &DataPartitionMetadata{
-		VolumeID:                dp.config.VolName,
+		VolumeID:                dp.VolName(),
 		PartitionID:             dp.config.PartitionID,
 		PartitionSize:           dp.config.PartitionSize,
 		Peers:                   dp.config.Peers,
@@ -423,6 +468,8 @@ func (dp *DataPartition) PersistMetadata() (err error) {
 		DataPartitionCreateType: dp.DataPartitionCreateType,
 		CreateTime:              time.Now().Format(TimeLayout),
 		LastTruncateID:          dp.lastTruncateID,
+		Encrypted:               dp.config.Encrypted,
+		Cache:                   dp.config.Cache,
 	}
 	if metaData, err = json.Marshal(md); err != nil {
 		return
@@ -609,7 +656,7 @@ func (dp *DataPartition) compareReplicas(v1, v2 []string) (equals bool) {
 func (dp *DataPartition) fetchReplicasFromMaster() (isLeader bool, replicas []string, err error) {
 
 	var partition *proto.DataPartitionInfo
-	if partition, err = MasterClient.AdminAPI().GetDataPartition(dp.volumeID, dp.partitionID); err != nil {
+	if partition, err = MasterClient.AdminAPI().GetDataPartition(dp.VolName(), dp.partitionID); err != nil {
 		isLeader = false
 		return
 	}
@@ -630,6 +677,7 @@ func (dp *DataPartition) Load() (response *proto.LoadDataPartitionResponse) {
 	response.PartitionId = uint64(dp.partitionID)
 	response.PartitionStatus = dp.partitionStatus
 	response.Used = uint64(dp.Used())
+	response.AppliedID = dp.GetAppliedID()
 	var err error
 	if dp.loadExtentHeaderStatus != FinishLoadDataPartitionExtentHeader {
 		response.PartitionSnapshot = make([]*proto.File, 0)
@@ -716,7 +764,7 @@ func (dp *DataPartition) doStreamFixTinyDeleteRecord(repairTask *DataPartitionRe
 	var (
 		localTinyDeleteFileSize int64
 		err                     error
-		conn                    *net.TCPConn
+		conn                    net.Conn
 	)
 	if !dp.pushSyncDeleteRecordFromLeaderMesg() {
 		return
@@ -798,10 +846,9 @@ func (dp *DataPartition) ChangeRaftMember(changeType raftProto.ConfChangeType, p
 	return
 }
 
-//
 func (dp *DataPartition) canRemoveSelf() (canRemove bool, err error) {
 	var partition *proto.DataPartitionInfo
-	if partition, err = MasterClient.AdminAPI().GetDataPartition(dp.volumeID, dp.partitionID); err != nil {
+	if partition, err = MasterClient.AdminAPI().GetDataPartition(dp.VolName(), dp.partitionID); err != nil {
 		log.LogErrorf("action[canRemoveSelf] err[%v]", err)
 		return
 	}
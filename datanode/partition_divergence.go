@@ -0,0 +1,66 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"hash/crc32"
+
+	"github.com/chubaofs/chubaofs/storage"
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// repairExtentsFrom overwrites the extents with the data of the replica on the source address, which is
+// chosen by the replica consistency checker of the master as the authoritative one for the extents whose
+// crc diverges across the replicas. Only the blocks within the local extent size are overwritten,
+// the size divergences are left to the extent repair of the partition.
+func (dp *DataPartition) repairExtentsFrom(source string, extents []uint64) {
+	store := dp.ExtentStore()
+	for _, extentID := range extents {
+		if storage.IsTinyExtent(extentID) {
+			continue
+		}
+		ei, err := store.Watermark(extentID)
+		if err != nil {
+			log.LogWarnf("action[repairExtentsFrom] partition(%v) extent(%v) err(%v)", dp.partitionID, extentID, err)
+			continue
+		}
+		blockCount := int((ei.Size + util.BlockSize - 1) / util.BlockSize)
+		for blockNo := 0; blockNo < blockCount; blockNo++ {
+			if err = dp.overwriteBlockFrom(source, extentID, blockNo); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			log.LogErrorf("action[repairExtentsFrom] partition(%v) extent(%v) from(%v) err(%v)", dp.partitionID, extentID, source, err)
+			continue
+		}
+		log.LogWarnf("action[repairExtentsFrom] partition(%v) extent(%v) repaired from(%v)", dp.partitionID, extentID, source)
+	}
+	dp.ReloadSnapshot()
+}
+
+func (dp *DataPartition) overwriteBlockFrom(addr string, extentID uint64, blockNo int) (err error) {
+	var data []byte
+	if data, err = dp.readBlockFrom(addr, extentID, blockNo); err != nil {
+		return
+	}
+	var crc uint32
+	if len(data) == util.BlockSize {
+		crc = crc32.ChecksumIEEE(data)
+	}
+	writeLimiterWait(dp.disk.repairLimiter, len(data))
+	return dp.ExtentStore().Write(extentID, int64(blockNo)*util.BlockSize, int64(len(data)), data, crc, storage.RandomWriteType, true)
+}
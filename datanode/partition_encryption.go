@@ -0,0 +1,60 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/storage"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	GetVolDataKeyRetryTimes    = 10
+	GetVolDataKeyRetryInterval = 3 * time.Second
+)
+
+// volDataKeys caches the data keys of the encrypted volumes, which never change
+// since the key rotation only re-wraps them on the master.
+var volDataKeys sync.Map // key: vol name, value: []byte
+
+func getVolDataKey(volName, nodeAddr string) (dataKey []byte, err error) {
+	if value, ok := volDataKeys.Load(volName); ok {
+		return value.([]byte), nil
+	}
+	for i := 0; i < GetVolDataKeyRetryTimes; i++ {
+		if dataKey, err = MasterClient.AdminAPI().GetVolDataKey(volName, nodeAddr); err == nil {
+			volDataKeys.Store(volName, dataKey)
+			return
+		}
+		log.LogWarnf("action[getVolDataKey] vol(%v) retry(%v) err(%v)", volName, i, err)
+		time.Sleep(GetVolDataKeyRetryInterval)
+	}
+	return nil, fmt.Errorf("get data key of vol(%v) err(%v)", volName, err)
+}
+
+// newExtentCipher returns the cipher of the extents of the data partition, nil if the volume is not encrypted.
+func newExtentCipher(dpCfg *dataPartitionCfg, disk *Disk) (cipher *storage.ExtentCipher, err error) {
+	if !dpCfg.Encrypted {
+		return
+	}
+	var dataKey []byte
+	if dataKey, err = getVolDataKey(dpCfg.VolName, disk.space.dataNode.localServerAddr); err != nil {
+		return
+	}
+	return storage.NewExtentCipher(dataKey, dpCfg.PartitionID)
+}
@@ -254,8 +254,16 @@ func (dp *DataPartition) ApplyRandomWrite(command []byte, raftApplyID uint64) (r
 	return
 }
 
-// RandomWriteSubmit submits the proposal to raft.
+// RandomWriteSubmit submits the proposal to raft. The random writes to the single replica of a cache volume
+// are written to the extent store directly, as there is no other replica to agree with.
 func (dp *DataPartition) RandomWriteSubmit(pkg *repl.Packet) (err error) {
+	if dp.config.Cache && len(dp.config.Peers) == 1 {
+		if err = dp.ExtentStore().Write(pkg.ExtentID, pkg.ExtentOffset, int64(pkg.Size), pkg.Data, pkg.CRC, storage.RandomWriteType, pkg.IsSyncWrite()); err != nil {
+			return
+		}
+		pkg.ResultCode = proto.OpOk
+		return
+	}
 	val, err := MarshalRandWriteRaftLog(pkg.Opcode, pkg.ExtentID, pkg.ExtentOffset, int64(pkg.Size), pkg.Data, pkg.CRC)
 	if err != nil {
 		return
@@ -0,0 +1,194 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/repl"
+	"github.com/chubaofs/chubaofs/storage"
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	DefaultScrubBandwidth    = 10 // MB/s of every disk
+	DefaultScrubIntervalDays = 7
+	MinScrubInterval         = time.Hour
+)
+
+var (
+	ScrubEnabled   = true
+	ScrubBandwidth = DefaultScrubBandwidth
+	ScrubInterval  = DefaultScrubIntervalDays * 24 * time.Hour
+)
+
+// scrubScheduler reads all the normal extents on the disk at the configured bandwidth periodically,
+// and verifies them with the block crcs. The corrupt blocks are repaired with the healthy copies
+// of the other replicas, and the extents that can not be repaired are reported to the master.
+// The tiny extents have no block crc and are not scrubbed.
+func (d *Disk) scrubScheduler() {
+	limiter := rate.NewLimiter(rate.Limit(ScrubBandwidth*util.MB), util.BlockSize)
+	wait := func(size int) {
+		limiter.WaitN(context.Background(), size)
+	}
+	for {
+		start := time.Now()
+		partitions := make([]*DataPartition, 0)
+		d.RLock()
+		for _, dp := range d.partitionMap {
+			partitions = append(partitions, dp)
+		}
+		d.RUnlock()
+		for _, dp := range partitions {
+			dp.scrub(wait)
+		}
+		log.LogInfof("action[scrubScheduler] disk(%v) scrubbed %v partitions in %v", d.Path, len(partitions), time.Since(start))
+		interval := ScrubInterval - time.Since(start)
+		if interval < MinScrubInterval {
+			interval = MinScrubInterval
+		}
+		time.Sleep(interval)
+	}
+}
+
+func (dp *DataPartition) isStopped() bool {
+	select {
+	case <-dp.stopC:
+		return true
+	default:
+		return false
+	}
+}
+
+func (dp *DataPartition) scrub(wait func(size int)) {
+	store := dp.ExtentStore()
+	extents, _, err := store.GetAllWatermarks(storage.NormalExtentFilter())
+	if err != nil {
+		log.LogWarnf("action[scrub] partition(%v) get extents err(%v)", dp.partitionID, err)
+		return
+	}
+	corruptExtents := make([]uint64, 0)
+	for _, ei := range extents {
+		if dp.isStopped() {
+			return
+		}
+		blocks, err := store.ScrubExtent(ei.FileID, wait)
+		if err != nil {
+			log.LogErrorf("action[scrub] partition(%v) extent(%v) err(%v)", dp.partitionID, ei.FileID, err)
+			dp.checkIsDiskError(err)
+			continue
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		log.LogErrorf("action[scrub] partition(%v) extent(%v) corrupt blocks(%v)", dp.partitionID, ei.FileID, blocks)
+		if err = dp.repairCorruptBlocks(ei.FileID, blocks); err != nil {
+			log.LogErrorf("action[scrub] partition(%v) extent(%v) repair err(%v)", dp.partitionID, ei.FileID, err)
+			corruptExtents = append(corruptExtents, ei.FileID)
+			continue
+		}
+		log.LogWarnf("action[scrub] partition(%v) extent(%v) corrupt blocks(%v) repaired", dp.partitionID, ei.FileID, blocks)
+	}
+	dp.scrubMutex.Lock()
+	dp.corruptExtents = corruptExtents
+	dp.lastScrubTime = time.Now().Unix()
+	dp.scrubMutex.Unlock()
+}
+
+// CorruptExtents returns the extents found corrupt and not repaired by the last scrub.
+func (dp *DataPartition) CorruptExtents() []uint64 {
+	dp.scrubMutex.RLock()
+	defer dp.scrubMutex.RUnlock()
+	return dp.corruptExtents
+}
+
+func (dp *DataPartition) repairCorruptBlocks(extentID uint64, blocks []int) (err error) {
+	localAddr := fmt.Sprintf("%v:%v", LocalIP, serverPort)
+	for _, blockNo := range blocks {
+		err = fmt.Errorf("no replica to repair from")
+		for _, addr := range dp.Replicas() {
+			if addr == localAddr {
+				continue
+			}
+			if err = dp.repairBlockFrom(addr, extentID, blockNo); err == nil {
+				break
+			}
+			log.LogWarnf("action[repairCorruptBlocks] partition(%v) extent(%v) block(%v) from(%v) err(%v)",
+				dp.partitionID, extentID, blockNo, addr, err)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (dp *DataPartition) repairBlockFrom(addr string, extentID uint64, blockNo int) (err error) {
+	var data []byte
+	if data, err = dp.readBlockFrom(addr, extentID, blockNo); err != nil {
+		return
+	}
+	return dp.ExtentStore().RepairBlock(extentID, blockNo, data)
+}
+
+// readBlockFrom reads the block of the extent from the replica on addr, the size of the block
+// is decided by the local extent.
+func (dp *DataPartition) readBlockFrom(addr string, extentID uint64, blockNo int) (data []byte, err error) {
+	var (
+		store = dp.ExtentStore()
+		conn  net.Conn
+		ei    *storage.ExtentInfo
+	)
+	if ei, err = store.Watermark(extentID); err != nil {
+		return
+	}
+	offset := int64(blockNo) * util.BlockSize
+	size := util.Min(util.BlockSize, int(int64(ei.Size)-offset))
+	if size <= 0 {
+		return nil, storage.NewParameterMismatchErr("block out of the extent")
+	}
+	if conn, err = gConnPool.GetConnect(addr); err != nil {
+		return
+	}
+	defer func() {
+		gConnPool.PutConnect(conn, err != nil)
+	}()
+	request := repl.NewExtentRepairReadPacket(dp.partitionID, extentID, int(offset), size)
+	if err = request.WriteToConn(conn); err != nil {
+		return
+	}
+	data = make([]byte, 0, size)
+	for len(data) < size {
+		reply := repl.NewPacket()
+		if err = reply.ReadFromConn(conn, proto.ReadDeadlineTime); err != nil {
+			return
+		}
+		if reply.ResultCode != proto.OpOk {
+			return nil, fmt.Errorf("result code(%v) msg(%v)", reply.ResultCode, string(reply.Data[:reply.Size]))
+		}
+		if reply.ReqID != request.ReqID || reply.Size == 0 {
+			return nil, fmt.Errorf("invalid reply(%v) of request(%v)", reply.GetUniqueLogId(), request.GetUniqueLogId())
+		}
+		data = append(data, reply.Data[:reply.Size]...)
+	}
+	return
+}
@@ -0,0 +1,46 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"github.com/chubaofs/chubaofs/repl"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// updateReadOnlyVols replaces the volumes frozen read-only by the ones told by the master with the heartbeat.
+func (s *DataNode) updateReadOnlyVols(names []string) {
+	old, _ := s.readOnlyVols.Load().(map[string]bool)
+	changed := len(old) != len(names)
+	vols := make(map[string]bool, len(names))
+	for _, name := range names {
+		vols[name] = true
+		changed = changed || !old[name]
+	}
+	if changed {
+		log.LogWarnf("updateReadOnlyVols: read only volumes %v", names)
+	}
+	s.readOnlyVols.Store(vols)
+}
+
+func (s *DataNode) isReadOnlyVol(name string) bool {
+	vols, _ := s.readOnlyVols.Load().(map[string]bool)
+	return vols[name]
+}
+
+// isClientWrite tells whether the packet writes the data of a client, which is checked by the leader of
+// the partition only, so that the replicas of the accepted writes are not rejected by the followers.
+func isClientWrite(p *repl.Packet) bool {
+	return p.IsRandomWrite() || (p.IsForwardPkt() && (p.IsWriteOperation() || p.IsCreateExtentOperation()))
+}
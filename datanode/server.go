@@ -23,10 +23,12 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"errors"
 	"os"
+	"os/exec"
 	"syscall"
 
 	"github.com/chubaofs/chubaofs/cmd/common"
@@ -64,14 +66,23 @@ const (
 )
 
 const (
-	ConfigKeyLocalIP       = "localIP"       // string
-	ConfigKeyPort          = "port"          // int
-	ConfigKeyMasterAddr    = "masterAddr"    // array
-	ConfigKeyZone          = "zoneName"      // string
-	ConfigKeyDisks         = "disks"         // array
-	ConfigKeyRaftDir       = "raftDir"       // string
-	ConfigKeyRaftHeartbeat = "raftHeartbeat" // string
-	ConfigKeyRaftReplica   = "raftReplica"   // string
+	ConfigKeyLocalIP           = "localIP"           // string
+	ConfigKeyPort              = "port"              // int
+	ConfigKeyMasterAddr        = "masterAddr"        // array
+	ConfigKeyZone              = "zoneName"          // string
+	ConfigKeyRegion            = "region"            // string
+	ConfigKeyRack              = "rack"              // string
+	ConfigKeyDisks             = "disks"             // array
+	ConfigKeyRaftDir           = "raftDir"           // string
+	ConfigKeyRaftHeartbeat     = "raftHeartbeat"     // string
+	ConfigKeyRaftReplica       = "raftReplica"       // string
+	ConfigKeyEnableScrub       = "enableScrub"       // bool
+	ConfigKeyScrubBandwidth    = "scrubBandwidth"    // int, MB/s of every disk
+	ConfigKeyScrubIntervalDays = "scrubIntervalDays" // int
+	ConfigKeyDiskMaxErrCnt     = "diskMaxErrCnt"     // int, io errors of every disk within one check interval
+	ConfigKeyDiskSlowThreshold = "diskSlowThreshold" // int, ms
+	ConfigKeyEnableSmart       = "enableSmart"       // bool
+	ConfigKeySmartIntervalMin  = "smartIntervalMin"  // int
 )
 
 // DataNode defines the structure of a data node.
@@ -79,6 +90,8 @@ type DataNode struct {
 	space           *SpaceManager
 	port            string
 	zoneName        string
+	region          string
+	rack            string
 	clusterID       string
 	localIP         string
 	localServerAddr string
@@ -87,11 +100,14 @@ type DataNode struct {
 	raftHeartbeat   string
 	raftReplica     string
 	raftStore       raftstore.RaftStore
+	accessTokenKey  []byte
 
 	tcpListener net.Listener
 	stopC       chan bool
 
 	control common.Control
+
+	readOnlyVols atomic.Value // map[string]bool, the volumes frozen read-only on the master
 }
 
 func NewServer() *DataNode {
@@ -195,10 +211,50 @@ func (s *DataNode) parseConfig(cfg *config.Config) (err error) {
 	if s.zoneName == "" {
 		s.zoneName = DefaultZoneName
 	}
+	s.region = cfg.GetString(ConfigKeyRegion)
+	s.rack = cfg.GetString(ConfigKeyRack)
+	if err = util.InitTLSFromConfig(cfg, true); err != nil {
+		return
+	}
+	if s.accessTokenKey, err = proto.AccessTokenKeyFromConfig(cfg); err != nil {
+		return
+	}
+	if s.accessTokenKey != nil {
+		proto.EnableNodeAccessToken(s.accessTokenKey)
+	}
+
+	ScrubEnabled = cfg.GetBoolWithDefault(ConfigKeyEnableScrub, true)
+	if bandwidth := cfg.GetInt64(ConfigKeyScrubBandwidth); bandwidth > 0 {
+		ScrubBandwidth = int(bandwidth)
+	}
+	if days := cfg.GetInt64(ConfigKeyScrubIntervalDays); days > 0 {
+		ScrubInterval = time.Duration(days) * 24 * time.Hour
+	}
+	if cnt := cfg.GetInt64(ConfigKeyDiskMaxErrCnt); cnt > 0 {
+		DiskMaxErrCnt = int(cnt)
+	}
+	if threshold := cfg.GetInt64(ConfigKeyDiskSlowThreshold); threshold > 0 {
+		DiskSlowThreshold = time.Duration(threshold) * time.Millisecond
+	}
+	SmartEnabled = cfg.GetBoolWithDefault(ConfigKeyEnableSmart, true)
+	if SmartEnabled {
+		if _, err = exec.LookPath(SmartctlCmd); err != nil {
+			log.LogWarnf("action[parseConfig] disable SMART monitoring, err(%v)", err)
+			SmartEnabled = false
+			err = nil
+		}
+	}
+	if minutes := cfg.GetInt64(ConfigKeySmartIntervalMin); minutes > 0 {
+		SmartInterval = time.Duration(minutes) * time.Minute
+	}
 
 	log.LogDebugf("action[parseConfig] load masterAddrs(%v).", MasterClient.Nodes())
 	log.LogDebugf("action[parseConfig] load port(%v).", s.port)
 	log.LogDebugf("action[parseConfig] load zoneName(%v).", s.zoneName)
+	log.LogDebugf("action[parseConfig] load region(%v) rack(%v).", s.region, s.rack)
+	log.LogDebugf("action[parseConfig] load scrub enabled(%v) bandwidth(%vMB/s) interval(%v).", ScrubEnabled, ScrubBandwidth, ScrubInterval)
+	log.LogDebugf("action[parseConfig] load disk maxErrCnt(%v) slowThreshold(%v).", DiskMaxErrCnt, DiskSlowThreshold)
+	log.LogDebugf("action[parseConfig] load SMART enabled(%v) interval(%v).", SmartEnabled, SmartInterval)
 	return
 }
 
@@ -217,10 +273,17 @@ func (s *DataNode) startSpaceManager(cfg *config.Config) (err error) {
 	for _, d := range cfg.GetSlice(ConfigKeyDisks) {
 		log.LogDebugf("action[startSpaceManager] load disk raw config(%v).", d)
 
-		// format "PATH:RESET_SIZE
+		// format "PATH:RESET_SIZE[:MEDIA_TYPE]", the media type is hdd by default
 		arr := strings.Split(d.(string), ":")
-		if len(arr) != 2 {
-			return errors.New("Invalid disk configuration. Example: PATH:RESERVE_SIZE")
+		if len(arr) != 2 && len(arr) != 3 {
+			return errors.New("Invalid disk configuration. Example: PATH:RESERVE_SIZE[:MEDIA_TYPE]")
+		}
+		mediaType := proto.MediaTypeHDD
+		if len(arr) == 3 {
+			if mediaType = arr[2]; !proto.IsValidMediaType(mediaType) {
+				return errors.New(fmt.Sprintf("Invalid disk media type %v, only %v and %v are supported",
+					mediaType, proto.MediaTypeSSD, proto.MediaTypeHDD))
+			}
 		}
 		path := arr[0]
 		fileInfo, err := os.Stat(path)
@@ -240,10 +303,10 @@ func (s *DataNode) startSpaceManager(cfg *config.Config) (err error) {
 		}
 
 		wg.Add(1)
-		go func(wg *sync.WaitGroup, path string, reservedSpace uint64) {
+		go func(wg *sync.WaitGroup, path, mediaType string, reservedSpace uint64) {
 			defer wg.Done()
-			s.space.LoadDisk(path, reservedSpace, DefaultDiskMaxErr)
-		}(&wg, path, reservedSpace)
+			s.space.LoadDisk(path, mediaType, reservedSpace, DiskMaxErrCnt)
+		}(&wg, path, mediaType, reservedSpace)
 	}
 	wg.Wait()
 	return nil
@@ -284,7 +347,7 @@ func (s *DataNode) register(cfg *config.Config) {
 
 			// register this data node on the master
 			var nodeID uint64
-			if nodeID, err = MasterClient.NodeAPI().AddDataNode(fmt.Sprintf("%s:%v", LocalIP, s.port), s.zoneName); err != nil {
+			if nodeID, err = MasterClient.NodeAPI().AddDataNodeWithTopology(fmt.Sprintf("%s:%v", LocalIP, s.port), s.region, s.zoneName, s.rack); err != nil {
 				log.LogErrorf("action[registerToMaster] cannot register this node to master[%v] err(%v).",
 					masterAddr, err)
 				timer.Reset(2 * time.Second)
@@ -390,7 +453,11 @@ func (s *DataNode) serveConn(conn net.Conn) {
 	c, _ := conn.(*net.TCPConn)
 	c.SetKeepAlive(true)
 	c.SetNoDelay(true)
-	packetProcessor := repl.NewReplProtocol(c, s.Prepare, s.OperatePacket, s.Post)
+	access := new(proto.ConnAccess)
+	prepare := func(p *repl.Packet) error {
+		return s.Prepare(p, access)
+	}
+	packetProcessor := repl.NewReplProtocol(util.ServerConn(c), prepare, s.OperatePacket, s.Post)
 	packetProcessor.ServerConn()
 }
 
@@ -34,6 +34,7 @@ func (s *DataNode) getDiskAPI(w http.ResponseWriter, r *http.Request) {
 	for _, diskItem := range s.space.GetDisks() {
 		disk := &struct {
 			Path        string `json:"path"`
+			MediaType   string `json:"mediaType"`
 			Total       uint64 `json:"total"`
 			Used        uint64 `json:"used"`
 			Available   uint64 `json:"available"`
@@ -44,6 +45,7 @@ func (s *DataNode) getDiskAPI(w http.ResponseWriter, r *http.Request) {
 			Partitions  int    `json:"partitions"`
 		}{
 			Path:        diskItem.Path,
+			MediaType:   diskItem.MediaType,
 			Total:       diskItem.Total,
 			Used:        diskItem.Used,
 			Available:   diskItem.Available,
@@ -184,7 +186,7 @@ func (s *DataNode) getPartitionAPI(w http.ResponseWriter, r *http.Request) {
 		TinyDeleteRecordSize int64                 `json:"tinyDeleteRecordSize"`
 		RaftStatus           *raft.Status          `json:"raftStatus"`
 	}{
-		VolName:              partition.volumeID,
+		VolName:              partition.VolName(),
 		ID:                   partition.partitionID,
 		Size:                 partition.Size(),
 		Used:                 partition.Used(),
@@ -122,7 +122,7 @@ func (manager *SpaceManager) Stats() *Stats {
 	return manager.stats
 }
 
-func (manager *SpaceManager) LoadDisk(path string, reservedSpace uint64, maxErrCnt int) (err error) {
+func (manager *SpaceManager) LoadDisk(path, mediaType string, reservedSpace uint64, maxErrCnt int) (err error) {
 	var (
 		disk    *Disk
 		visitor PartitionVisitor
@@ -137,11 +137,17 @@ func (manager *SpaceManager) LoadDisk(path string, reservedSpace uint64, maxErrC
 		}
 	}
 	if _, err = manager.GetDisk(path); err != nil {
-		disk = NewDisk(path, reservedSpace, maxErrCnt, manager)
+		disk = NewDisk(path, mediaType, reservedSpace, maxErrCnt, manager)
 		disk.RestorePartition(visitor)
 		manager.putDisk(disk)
 		err = nil
 		go disk.doBackendTask()
+		if ScrubEnabled {
+			go disk.scrubScheduler()
+		}
+		if SmartEnabled {
+			go disk.smartScheduler()
+		}
 	}
 	return
 }
@@ -191,7 +197,9 @@ func (manager *SpaceManager) updateMetrics() {
 		remainingCapacityToCreatePartition, maxCapacityToCreatePartition, partitionCnt)
 }
 
-func (manager *SpaceManager) minPartitionCnt() (d *Disk) {
+// minPartitionCnt chooses the disk of the media type to create a partition,
+// any media type is allowed if the media type is empty.
+func (manager *SpaceManager) minPartitionCnt(mediaType string) (d *Disk) {
 	manager.diskMutex.Lock()
 	defer manager.diskMutex.Unlock()
 	var (
@@ -203,6 +211,9 @@ func (manager *SpaceManager) minPartitionCnt() (d *Disk) {
 		if disk.Available <= 5*util.GB || disk.Status != proto.ReadWrite {
 			continue
 		}
+		if mediaType != "" && disk.MediaType != mediaType {
+			continue
+		}
 		diskWeight := disk.getSelectWeight()
 		if diskWeight < minWeight {
 			minWeight = diskWeight
@@ -266,6 +277,8 @@ func (manager *SpaceManager) CreatePartition(request *proto.CreateDataPartitionR
 		NodeID:        manager.nodeID,
 		ClusterID:     manager.clusterID,
 		PartitionSize: request.PartitionSize,
+		Encrypted:     request.Encrypted,
+		Cache:         request.Cache,
 	}
 	dp = manager.partitions[dpCfg.PartitionID]
 	if dp != nil {
@@ -274,7 +287,13 @@ func (manager *SpaceManager) CreatePartition(request *proto.CreateDataPartitionR
 		}
 		return
 	}
-	disk := manager.minPartitionCnt()
+	disk := manager.minPartitionCnt(request.MediaType)
+	if disk == nil && request.MediaType != "" {
+		// the master moves the replica to the right media later
+		log.LogWarnf("action[CreatePartition] no disk of media type(%v) for partition(%v), choose from all the disks",
+			request.MediaType, request.PartitionId)
+		disk = manager.minPartitionCnt("")
+	}
 	if disk == nil {
 		return nil, ErrNoSpaceToCreatePartition
 	}
@@ -315,12 +334,13 @@ func (s *DataNode) buildHeartBeatResponse(response *proto.DataNodeHeartbeatRespo
 	stat.Unlock()
 
 	response.ZoneName = s.zoneName
+	response.Version = proto.Version
 	response.PartitionReports = make([]*proto.PartitionReport, 0)
 	space := s.space
 	space.RangePartitions(func(partition *DataPartition) bool {
 		leaderAddr, isLeader := partition.IsRaftLeader()
 		vr := &proto.PartitionReport{
-			VolName:         partition.volumeID,
+			VolName:         partition.VolName(),
 			PartitionID:     uint64(partition.partitionID),
 			PartitionStatus: partition.Status(),
 			Total:           uint64(partition.Size()),
@@ -329,6 +349,9 @@ func (s *DataNode) buildHeartBeatResponse(response *proto.DataNodeHeartbeatRespo
 			IsLeader:        isLeader,
 			ExtentCount:     partition.GetExtentCount(),
 			NeedCompare:     true,
+			MediaType:       partition.Disk().MediaType,
+			LastAccessTime:  partition.LastAccessTime(),
+			CorruptExtents:  partition.CorruptExtents(),
 		}
 		log.LogDebugf("action[Heartbeats] dpid(%v), status(%v) total(%v) used(%v) leader(%v) isLeader(%v).", vr.PartitionID, vr.PartitionStatus, vr.Total, vr.Used, leaderAddr, vr.IsLeader)
 		response.PartitionReports = append(response.PartitionReports, vr)
@@ -336,9 +359,16 @@ func (s *DataNode) buildHeartBeatResponse(response *proto.DataNodeHeartbeatRespo
 	})
 
 	disks := space.GetDisks()
+	response.MediaSpaces = make(map[string]uint64)
 	for _, d := range disks {
 		if d.Status == proto.Unavailable {
 			response.BadDisks = append(response.BadDisks, d.Path)
 		}
+		if d.Status == proto.ReadWrite {
+			response.MediaSpaces[d.MediaType] += d.Unallocated
+		}
+		if smart := d.smartInfo(); smart != nil {
+			response.DiskSmart = append(response.DiskSmart, smart)
+		}
 	}
 }
@@ -0,0 +1,44 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// renameVols renames the partitions of the volumes renamed on the master, which sends the former names
+// mapped to the names with the heartbeat.
+func (s *DataNode) renameVols(renamed map[string]string) {
+	proto.SetVolRenames(renamed)
+	if len(renamed) == 0 {
+		return
+	}
+	s.space.RangePartitions(func(dp *DataPartition) bool {
+		oldName := dp.VolName()
+		newName, ok := renamed[oldName]
+		if !ok {
+			return true
+		}
+		dp.setVolName(newName)
+		if err := dp.PersistMetadata(); err != nil {
+			dp.setVolName(oldName)
+			log.LogErrorf("renameVols: dp(%v) rename vol(%v) to (%v) err(%v)", dp.partitionID, oldName, newName, err)
+			return true
+		}
+		log.LogWarnf("renameVols: dp(%v) vol(%v) renamed to (%v)", dp.partitionID, oldName, newName)
+		return true
+	})
+}
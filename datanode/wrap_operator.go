@@ -37,7 +37,7 @@ import (
 	raftProto "github.com/tiglabs/raft/proto"
 )
 
-func (s *DataNode) OperatePacket(p *repl.Packet, c *net.TCPConn) (err error) {
+func (s *DataNode) OperatePacket(p *repl.Packet, c net.Conn) (err error) {
 	sz := p.Size
 	tpObject := exporter.NewTPCnt(p.GetOpMsg())
 	start := time.Now().UnixNano()
@@ -66,6 +66,10 @@ func (s *DataNode) OperatePacket(p *repl.Packet, c *net.TCPConn) (err error) {
 		tpObject.Set(err)
 	}()
 	switch p.Opcode {
+	case proto.OpAuthConn:
+		p.PacketOkReply()
+	case proto.OpHandshake:
+		p.HandshakeReply(proto.DataNodeFeatures)
 	case proto.OpCreateExtent:
 		s.handlePacketToCreateExtent(p)
 	case proto.OpWrite, proto.OpSyncWrite:
@@ -82,6 +86,8 @@ func (s *DataNode) OperatePacket(p *repl.Packet, c *net.TCPConn) (err error) {
 		s.handleMarkDeletePacket(p, c)
 	case proto.OpBatchDeleteExtent:
 		s.handleBatchMarkDeletePacket(p, c)
+	case proto.OpBatchPunchExtent:
+		s.handleBatchPunchExtentPacket(p, c)
 	case proto.OpRandomWrite, proto.OpSyncRandomWrite:
 		s.handleRandomWritePacket(p)
 	case proto.OpNotifyReplicasToRepair:
@@ -106,6 +112,8 @@ func (s *DataNode) OperatePacket(p *repl.Packet, c *net.TCPConn) (err error) {
 		s.handlePacketToRemoveDataPartitionRaftMember(p)
 	case proto.OpDataPartitionTryToLeader:
 		s.handlePacketToDataPartitionTryToLeaderrr(p)
+	case proto.OpRepairDataPartitionExtents:
+		s.handlePacketToRepairDataPartitionExtents(p)
 	case proto.OpGetPartitionSize:
 		s.handlePacketToGetPartitionSize(p)
 	case proto.OpGetMaxExtentIDAndPartitionSize:
@@ -211,6 +219,8 @@ func (s *DataNode) handleHeartbeatPacket(p *repl.Packet) {
 		if task.OpCode == proto.OpDataNodeHeartbeat {
 			marshaled, _ := json.Marshal(task.Request)
 			_ = json.Unmarshal(marshaled, request)
+			s.renameVols(request.RenamedVols)
+			s.updateReadOnlyVols(request.ReadOnlyVols)
 			response.Status = proto.TaskSucceeds
 		} else {
 			response.Status = proto.TaskFailed
@@ -371,6 +381,42 @@ func (s *DataNode) handleBatchMarkDeletePacket(p *repl.Packet, c net.Conn) {
 	return
 }
 
+// Handle OpBatchPunchExtent packet.
+// The ranges of the normal extents which are truncated from the files are released,
+// and the space is reclaimed without removing the extents still referenced by the files.
+func (s *DataNode) handleBatchPunchExtentPacket(p *repl.Packet, c net.Conn) {
+	var (
+		err error
+	)
+	defer func() {
+		if err != nil {
+			log.LogErrorf(fmt.Sprintf("(%v) error(%v) data (%v)", p.GetUniqueLogId(), err, string(p.Data)))
+			p.PackErrorBody(ActionBatchPunchExtent, err.Error())
+		} else {
+			p.PacketOkReply()
+		}
+	}()
+	partition := p.Object.(*DataPartition)
+	var exts []*proto.ExtentKey
+	if err = json.Unmarshal(p.Data, &exts); err != nil {
+		return
+	}
+	store := partition.ExtentStore()
+	for _, ext := range exts {
+		DeleteLimiterWait()
+		released, err := store.PunchHole(ext.ExtentId, int64(ext.ExtentOffset), int64(ext.Size))
+		if err != nil {
+			log.LogWarnf("handleBatchPunchExtentPacket: partition(%v) extent(%v) offset(%v) size(%v) err(%v)",
+				p.PartitionID, ext.ExtentId, ext.ExtentOffset, ext.Size, err)
+			continue
+		}
+		log.LogInfof("handleBatchPunchExtentPacket: partition(%v) extent(%v) offset(%v) size(%v) released(%v) from(%v)",
+			p.PartitionID, ext.ExtentId, ext.ExtentOffset, ext.Size, released, c.RemoteAddr().String())
+	}
+
+	return
+}
+
 // Handle OpWrite packet.
 func (s *DataNode) handleWritePacket(p *repl.Packet) {
 	var err error
@@ -389,6 +435,8 @@ func (s *DataNode) handleWritePacket(p *repl.Packet) {
 		err = storage.BrokenDiskError
 		return
 	}
+	partition.markAccess()
+	partition.waitWrite(int(p.Size))
 	store := partition.ExtentStore()
 	if p.ExtentType == proto.TinyExtentType {
 		err = store.Write(p.ExtentID, p.ExtentOffset, int64(p.Size), p.Data, p.CRC, storage.AppendWriteType, p.IsSyncWrite())
@@ -437,6 +485,8 @@ func (s *DataNode) handleRandomWritePacket(p *repl.Packet) {
 		err = raft.ErrNotLeader
 		return
 	}
+	partition.markAccess()
+	partition.waitWrite(int(p.Size))
 	err = partition.RandomWriteSubmit(p)
 	if err != nil && strings.Contains(err.Error(), raft.ErrNotLeader.Error()) {
 		err = raft.ErrNotLeader
@@ -507,6 +557,9 @@ func (s *DataNode) extentRepairReadPacket(p *repl.Packet, connect net.Conn, isRe
 	needReplySize := p.Size
 	offset := p.ExtentOffset
 	store := partition.ExtentStore()
+	if !isRepairRead {
+		partition.markAccess()
+	}
 
 	for {
 		if needReplySize <= 0 {
@@ -529,6 +582,10 @@ func (s *DataNode) extentRepairReadPacket(p *repl.Packet, connect net.Conn, isRe
 		partition.checkIsDiskError(err)
 		tpObject.Set(err)
 		p.CRC = reply.CRC
+		if err == storage.CrcMismatchError {
+			log.LogErrorf("action[extentRepairReadPacket] partition(%v) extent(%v) offset(%v) size(%v) block crc mismatch",
+				p.PartitionID, p.ExtentID, offset, currReadSize)
+		}
 		if err != nil {
 			return
 		}
@@ -691,7 +748,7 @@ func (s *DataNode) tinyExtentRepairRead(request *repl.Packet, connect net.Conn)
 	return
 }
 
-func (s *DataNode) handlePacketToReadTinyDeleteRecordFile(p *repl.Packet, connect *net.TCPConn) {
+func (s *DataNode) handlePacketToReadTinyDeleteRecordFile(p *repl.Packet, connect net.Conn) {
 	var (
 		err error
 	)
@@ -1004,9 +1061,46 @@ func (s *DataNode) handlePacketToDataPartitionTryToLeaderrr(p *repl.Packet) {
 	return
 }
 
+// Handle OpRepairDataPartitionExtents packet, the extents are repaired in background.
+func (s *DataNode) handlePacketToRepairDataPartitionExtents(p *repl.Packet) {
+	var (
+		err     error
+		reqData []byte
+		req     = &proto.RepairDataPartitionExtentsRequest{}
+	)
+
+	defer func() {
+		if err != nil {
+			p.PackErrorBody(ActionRepairDataPartitionExtents, err.Error())
+		} else {
+			p.PacketOkReply()
+		}
+	}()
+
+	adminTask := &proto.AdminTask{}
+	decode := json.NewDecoder(bytes.NewBuffer(p.Data))
+	decode.UseNumber()
+	if err = decode.Decode(adminTask); err != nil {
+		return
+	}
+	if reqData, err = json.Marshal(adminTask.Request); err != nil {
+		return
+	}
+	p.AddMesgLog(string(reqData))
+	if err = json.Unmarshal(reqData, req); err != nil {
+		return
+	}
+	dp := s.space.Partition(req.PartitionId)
+	if dp == nil {
+		err = fmt.Errorf("partition %v not exsit", req.PartitionId)
+		return
+	}
+	go dp.repairExtentsFrom(req.Source, req.Extents)
+}
+
 func (s *DataNode) forwardToRaftLeader(dp *DataPartition, p *repl.Packet) (ok bool, err error) {
 	var (
-		conn       *net.TCPConn
+		conn       net.Conn
 		leaderAddr string
 	)
 
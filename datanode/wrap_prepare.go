@@ -22,15 +22,19 @@ import (
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/repl"
 	"github.com/chubaofs/chubaofs/storage"
+	"github.com/chubaofs/chubaofs/util/log"
 )
 
-func (s *DataNode) Prepare(p *repl.Packet) (err error) {
+func (s *DataNode) Prepare(p *repl.Packet, access *proto.ConnAccess) (err error) {
 	defer func() {
 		p.SetPacketHasPrepare()
 		if err != nil {
 			p.PackErrorBody(repl.ActionPreparePkt, err.Error())
 		}
 	}()
+	if err = s.checkAccess(p, access); err != nil || p.Opcode == proto.OpAuthConn || p.Opcode == proto.OpHandshake {
+		return
+	}
 	if p.IsMasterCommand() {
 		return
 	}
@@ -54,6 +58,25 @@ func (s *DataNode) Prepare(p *repl.Packet) (err error) {
 	return
 }
 
+// checkAccess authenticates the connection with the OpAuthConn packet,
+// and checks the access of the other packets if the access token key is configured.
+func (s *DataNode) checkAccess(p *repl.Packet, access *proto.ConnAccess) (err error) {
+	if s.accessTokenKey == nil {
+		return
+	}
+	if p.Opcode == proto.OpAuthConn {
+		return access.Authenticate(s.accessTokenKey, p.Data[:p.Size])
+	}
+	var volName string
+	if dp := s.space.Partition(p.PartitionID); dp != nil {
+		volName = dp.VolName()
+	}
+	if err = access.Check(p.Opcode, volName); err != nil {
+		log.LogWarnf("action[checkAccess] op(%v) partition(%v) vol(%v) denied", p.GetOpMsg(), p.PartitionID, volName)
+	}
+	return
+}
+
 func (s *DataNode) checkStoreMode(p *repl.Packet) (err error) {
 	if p.ExtentType == proto.TinyExtentType || p.ExtentType == proto.NormalExtentType {
 		return nil
@@ -80,6 +103,10 @@ func (s *DataNode) checkPartition(p *repl.Packet) (err error) {
 		return
 	}
 	p.Object = dp
+	if isClientWrite(p) && s.isReadOnlyVol(dp.VolName()) {
+		err = proto.ErrVolReadOnly
+		return
+	}
 	if p.IsWriteOperation() || p.IsCreateExtentOperation() {
 		if dp.Available() <= 0 {
 			err = storage.NoSpaceError
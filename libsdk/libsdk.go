@@ -0,0 +1,510 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// The libsdk builds the Go SDK into libcfs.so, a shared library with a C API for the applications not in Go:
+//
+//	go build -buildmode=c-shared -o libcfs.so ./libsdk
+//
+// A client is created by cfs_new_client, configured by cfs_set_client and started by cfs_start_client,
+// the other functions take the id of the client. The functions return a negative errno on failure.
+package main
+
+/*
+#include <stdint.h>
+#include <sys/types.h>
+
+typedef struct {
+	uint64_t ino;
+	uint64_t size;
+	uint64_t atime;
+	uint64_t mtime;
+	uint64_t ctime;
+	uint32_t atime_nsec;
+	uint32_t mtime_nsec;
+	uint32_t ctime_nsec;
+	uint32_t mode;
+	uint32_t nlink;
+	uint32_t uid;
+	uint32_t gid;
+} cfs_stat_info;
+
+typedef struct {
+	uint64_t ino;
+	uint32_t mode;
+	char name[256];
+} cfs_dirent;
+
+typedef struct {
+	uint64_t offset;
+	uint64_t size;
+	uint64_t partition_id;
+	char hosts[256]; // comma separated
+	char zones[256]; // comma separated, the zone of each host
+} cfs_block_location;
+*/
+import "C"
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/fsclient"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	loggerPrefix = "libcfs"
+	maxNameLen   = 255
+	maxHostsLen  = 255
+)
+
+var (
+	clientsMu sync.RWMutex
+	clients   = make(map[int64]*client)
+	nextID    int64
+	logOnce   sync.Once
+)
+
+type client struct {
+	sync.RWMutex
+	cfg      fsclient.Config
+	logDir   string
+	logLevel string
+	fc       *fsclient.Client
+	files    map[int]*fsclient.File
+	nextFd   int
+}
+
+func main() {}
+
+//export cfs_new_client
+func cfs_new_client() C.int64_t {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	nextID++
+	clients[nextID] = &client{files: make(map[int]*fsclient.File)}
+	return C.int64_t(nextID)
+}
+
+// cfs_set_client sets a configuration of the client before it is started, the keys are
+// masterAddr (comma separated), volName, owner, subDir, tokenKey, followerRead, logDir and logLevel.
+//
+//export cfs_set_client
+func cfs_set_client(id C.int64_t, key, val *C.char) C.int {
+	c, ok := getClient(id)
+	if !ok {
+		return statusOf(syscall.EBADF)
+	}
+	c.Lock()
+	defer c.Unlock()
+	if c.fc != nil {
+		return statusOf(syscall.EBUSY)
+	}
+	v := C.GoString(val)
+	switch C.GoString(key) {
+	case "masterAddr":
+		c.cfg.Masters = strings.Split(v, ",")
+	case "volName":
+		c.cfg.Volume = v
+	case "owner":
+		c.cfg.Owner = v
+	case "subDir":
+		c.cfg.SubDir = v
+	case "tokenKey":
+		c.cfg.TokenKey = v
+	case "followerRead":
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return statusOf(syscall.EINVAL)
+		}
+		c.cfg.FollowerRead = b
+	case "logDir":
+		c.logDir = v
+	case "logLevel":
+		c.logLevel = v
+	default:
+		return statusOf(syscall.EINVAL)
+	}
+	return 0
+}
+
+//export cfs_start_client
+func cfs_start_client(id C.int64_t) C.int {
+	c, ok := getClient(id)
+	if !ok {
+		return statusOf(syscall.EBADF)
+	}
+	c.Lock()
+	defer c.Unlock()
+	if c.fc != nil {
+		return statusOf(syscall.EBUSY)
+	}
+	if c.logDir != "" {
+		logOnce.Do(func() {
+			log.InitLog(c.logDir, loggerPrefix, parseLogLevel(c.logLevel), nil)
+		})
+	}
+	fc, err := fsclient.NewClient(&c.cfg)
+	if err != nil {
+		return statusOf(err)
+	}
+	c.fc = fc
+	return 0
+}
+
+//export cfs_close_client
+func cfs_close_client(id C.int64_t) {
+	clientsMu.Lock()
+	c, ok := clients[int64(id)]
+	delete(clients, int64(id))
+	clientsMu.Unlock()
+	if !ok {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	for _, f := range c.files {
+		f.Close()
+	}
+	c.files = nil
+	if c.fc != nil {
+		c.fc.Close()
+	}
+}
+
+// cfs_open opens the file with the flags and the mode of open(2), it returns the fd of the file.
+//
+//export cfs_open
+func cfs_open(id C.int64_t, path *C.char, flags C.int, mode C.mode_t) C.int {
+	c, ok := getStartedClient(id)
+	if !ok {
+		return statusOf(syscall.EBADF)
+	}
+	f, err := c.fc.OpenFile(C.GoString(path), int(flags), os.FileMode(mode).Perm())
+	if err != nil {
+		return statusOf(err)
+	}
+	c.Lock()
+	c.nextFd++
+	fd := c.nextFd
+	c.files[fd] = f
+	c.Unlock()
+	return C.int(fd)
+}
+
+//export cfs_close
+func cfs_close(id C.int64_t, fd C.int) C.int {
+	c, ok := getStartedClient(id)
+	if !ok {
+		return statusOf(syscall.EBADF)
+	}
+	c.Lock()
+	f, ok := c.files[int(fd)]
+	delete(c.files, int(fd))
+	c.Unlock()
+	if !ok {
+		return statusOf(syscall.EBADF)
+	}
+	return statusOf(f.Close())
+}
+
+// cfs_read reads from the offset of the file, or from the offset of the fd if the offset is negative.
+// It returns the number of the bytes read, which is 0 at the end of the file.
+//
+//export cfs_read
+func cfs_read(id C.int64_t, fd C.int, buf unsafe.Pointer, size C.size_t, off C.off_t) C.ssize_t {
+	f, ok := getFile(id, fd)
+	if !ok {
+		return C.ssize_t(statusOf(syscall.EBADF))
+	}
+	b := (*[1 << 30]byte)(buf)[:int(size):int(size)]
+	var (
+		n   int
+		err error
+	)
+	if off < 0 {
+		n, err = f.Read(b)
+	} else {
+		n, err = f.ReadAt(b, int64(off))
+	}
+	if n > 0 || err == nil || err == io.EOF {
+		return C.ssize_t(n)
+	}
+	return C.ssize_t(statusOf(err))
+}
+
+// cfs_write writes to the offset of the file, or to the offset of the fd if the offset is negative.
+// It returns the number of the bytes written.
+//
+//export cfs_write
+func cfs_write(id C.int64_t, fd C.int, buf unsafe.Pointer, size C.size_t, off C.off_t) C.ssize_t {
+	f, ok := getFile(id, fd)
+	if !ok {
+		return C.ssize_t(statusOf(syscall.EBADF))
+	}
+	b := (*[1 << 30]byte)(buf)[:int(size):int(size)]
+	var (
+		n   int
+		err error
+	)
+	if off < 0 {
+		n, err = f.Write(b)
+	} else {
+		n, err = f.WriteAt(b, int64(off))
+	}
+	if err != nil {
+		return C.ssize_t(statusOf(err))
+	}
+	return C.ssize_t(n)
+}
+
+// cfs_flush makes the data written visible to the files opened afterwards, like the hflush of HDFS.
+//
+//export cfs_flush
+func cfs_flush(id C.int64_t, fd C.int) C.int {
+	f, ok := getFile(id, fd)
+	if !ok {
+		return statusOf(syscall.EBADF)
+	}
+	return statusOf(f.Sync())
+}
+
+//export cfs_getattr
+func cfs_getattr(id C.int64_t, path *C.char, stat *C.cfs_stat_info) C.int {
+	c, ok := getStartedClient(id)
+	if !ok {
+		return statusOf(syscall.EBADF)
+	}
+	fi, err := c.fc.Stat(C.GoString(path))
+	if err != nil {
+		return statusOf(err)
+	}
+	info := fi.Sys().(*proto.InodeInfo)
+	stat.ino = C.uint64_t(info.Inode)
+	stat.size = C.uint64_t(info.Size)
+	stat.atime = C.uint64_t(info.AccessTime.Unix())
+	stat.atime_nsec = C.uint32_t(info.AccessTime.Nanosecond())
+	stat.mtime = C.uint64_t(info.ModifyTime.Unix())
+	stat.mtime_nsec = C.uint32_t(info.ModifyTime.Nanosecond())
+	stat.ctime = C.uint64_t(info.CreateTime.Unix())
+	stat.ctime_nsec = C.uint32_t(info.CreateTime.Nanosecond())
+	stat.mode = C.uint32_t(unixMode(info.Mode))
+	stat.nlink = C.uint32_t(info.Nlink)
+	stat.uid = C.uint32_t(info.Uid)
+	stat.gid = C.uint32_t(info.Gid)
+	return 0
+}
+
+//export cfs_mkdirs
+func cfs_mkdirs(id C.int64_t, path *C.char, mode C.mode_t) C.int {
+	c, ok := getStartedClient(id)
+	if !ok {
+		return statusOf(syscall.EBADF)
+	}
+	return statusOf(c.fc.MkdirAll(C.GoString(path), os.FileMode(mode).Perm()))
+}
+
+//export cfs_rename
+func cfs_rename(id C.int64_t, from, to *C.char) C.int {
+	c, ok := getStartedClient(id)
+	if !ok {
+		return statusOf(syscall.EBADF)
+	}
+	return statusOf(c.fc.Rename(C.GoString(from), C.GoString(to)))
+}
+
+// cfs_unlink removes the file or the empty directory.
+//
+//export cfs_unlink
+func cfs_unlink(id C.int64_t, path *C.char) C.int {
+	c, ok := getStartedClient(id)
+	if !ok {
+		return statusOf(syscall.EBADF)
+	}
+	return statusOf(c.fc.Remove(C.GoString(path)))
+}
+
+//export cfs_truncate
+func cfs_truncate(id C.int64_t, path *C.char, size C.off_t) C.int {
+	c, ok := getStartedClient(id)
+	if !ok {
+		return statusOf(syscall.EBADF)
+	}
+	return statusOf(c.fc.Truncate(C.GoString(path), int64(size)))
+}
+
+// cfs_readdir fills the entries of the directory sorted by name, starting from the offset-th entry.
+// It returns the number of the entries filled, which is less than count at the end of the directory.
+//
+//export cfs_readdir
+func cfs_readdir(id C.int64_t, path *C.char, dirents *C.cfs_dirent, count C.int, offset C.int) C.int {
+	c, ok := getStartedClient(id)
+	if !ok {
+		return statusOf(syscall.EBADF)
+	}
+	if count < 0 || offset < 0 {
+		return statusOf(syscall.EINVAL)
+	}
+	entries, err := c.fc.ReadDir(C.GoString(path))
+	if err != nil {
+		return statusOf(err)
+	}
+	if int(offset) >= len(entries) {
+		return 0
+	}
+	entries = entries[offset:]
+	if len(entries) > int(count) {
+		entries = entries[:count]
+	}
+	out := (*[1 << 20]C.cfs_dirent)(unsafe.Pointer(dirents))[:len(entries):len(entries)]
+	for i, entry := range entries {
+		info := entry.Sys().(*proto.InodeInfo)
+		out[i].ino = C.uint64_t(info.Inode)
+		out[i].mode = C.uint32_t(unixMode(info.Mode))
+		copyCString(unsafe.Pointer(&out[i].name[0]), entry.Name(), maxNameLen)
+	}
+	return C.int(len(entries))
+}
+
+// cfs_get_block_locations fills the locations of the data of the file in the range.
+// It returns the number of the blocks, only the first count of which are filled.
+//
+//export cfs_get_block_locations
+func cfs_get_block_locations(id C.int64_t, path *C.char, off, length C.off_t, locs *C.cfs_block_location, count C.int) C.int {
+	c, ok := getStartedClient(id)
+	if !ok {
+		return statusOf(syscall.EBADF)
+	}
+	if count < 0 || off < 0 || length < 0 {
+		return statusOf(syscall.EINVAL)
+	}
+	blocks, err := c.fc.BlockLocations(C.GoString(path), int64(off), int64(length))
+	if err != nil {
+		return statusOf(err)
+	}
+	n := len(blocks)
+	if n > int(count) {
+		n = int(count)
+	}
+	out := (*[1 << 20]C.cfs_block_location)(unsafe.Pointer(locs))[:n:n]
+	for i, block := range blocks[:n] {
+		out[i].offset = C.uint64_t(block.Offset)
+		out[i].size = C.uint64_t(block.Size)
+		out[i].partition_id = C.uint64_t(block.PartitionID)
+		copyCString(unsafe.Pointer(&out[i].hosts[0]), strings.Join(block.Hosts, ","), maxHostsLen)
+		copyCString(unsafe.Pointer(&out[i].zones[0]), strings.Join(block.Zones, ","), maxHostsLen)
+	}
+	return C.int(len(blocks))
+}
+
+func getClient(id C.int64_t) (c *client, ok bool) {
+	clientsMu.RLock()
+	defer clientsMu.RUnlock()
+	c, ok = clients[int64(id)]
+	return
+}
+
+func getStartedClient(id C.int64_t) (c *client, ok bool) {
+	if c, ok = getClient(id); !ok {
+		return
+	}
+	c.RLock()
+	defer c.RUnlock()
+	return c, c.fc != nil
+}
+
+func getFile(id C.int64_t, fd C.int) (f *fsclient.File, ok bool) {
+	c, ok := getStartedClient(id)
+	if !ok {
+		return
+	}
+	c.RLock()
+	defer c.RUnlock()
+	f, ok = c.files[int(fd)]
+	return
+}
+
+// copyCString copies the string to the buffer of maxLen+1 bytes with the terminating NUL, the string is cut if it is too long.
+func copyCString(buf unsafe.Pointer, s string, maxLen int) {
+	if len(s) > maxLen {
+		s = s[:maxLen]
+	}
+	b := (*[1 << 20]byte)(buf)[: len(s)+1 : len(s)+1]
+	copy(b, s)
+	b[len(s)] = 0
+}
+
+// statusOf returns the negative errno of the error, EIO if the error is not an errno.
+func statusOf(err error) C.int {
+	if err == nil {
+		return 0
+	}
+	switch e := err.(type) {
+	case *os.PathError:
+		err = e.Err
+	case *os.LinkError:
+		err = e.Err
+	}
+	if errno, ok := err.(syscall.Errno); ok {
+		return -C.int(errno)
+	}
+	if err == os.ErrClosed {
+		return -C.int(syscall.EBADF)
+	}
+	return -C.int(syscall.EIO)
+}
+
+func parseLogLevel(loglvl string) log.Level {
+	var level log.Level
+	switch strings.ToLower(loglvl) {
+	case "debug":
+		level = log.DebugLevel
+	case "info":
+		level = log.InfoLevel
+	case "warn":
+		level = log.WarnLevel
+	default:
+		level = log.ErrorLevel
+	}
+	return level
+}
+
+// unixMode converts the mode of the inode to the st_mode of stat(2).
+func unixMode(mode uint32) uint32 {
+	osMode := proto.OsMode(mode)
+	unixMode := uint32(osMode.Perm())
+	switch {
+	case osMode.IsDir():
+		unixMode |= syscall.S_IFDIR
+	case osMode&os.ModeSymlink != 0:
+		unixMode |= syscall.S_IFLNK
+	case osMode&os.ModeNamedPipe != 0:
+		unixMode |= syscall.S_IFIFO
+	case osMode&os.ModeSocket != 0:
+		unixMode |= syscall.S_IFSOCK
+	case osMode&os.ModeDevice != 0 && osMode&os.ModeCharDevice != 0:
+		unixMode |= syscall.S_IFCHR
+	case osMode&os.ModeDevice != 0:
+		unixMode |= syscall.S_IFBLK
+	default:
+		unixMode |= syscall.S_IFREG
+	}
+	return unixMode
+}
@@ -0,0 +1,326 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	auditMaskedValue          = "******"
+	maxAuditReplySize         = 64 * 1024
+	defaultAuditQueryLimit    = 100
+	intervalToTrimAuditRecord = time.Hour
+)
+
+// auditedAPIs are the admin APIs changing the cluster, the calls of which are recorded in the audit log.
+// The queries and the APIs called by the nodes and the clients periodically are not recorded.
+var auditedAPIs = map[string]bool{
+	proto.AdminClusterFreeze:             true,
+	proto.AdminClusterUpgrade:            true,
+	proto.AddRaftNode:                    true,
+	proto.RemoveRaftNode:                 true,
+	proto.AdminCreateVol:                 true,
+	proto.AdminDeleteVol:                 true,
+	proto.AdminRestoreVol:                true,
+	proto.AdminUpdateVol:                 true,
+	proto.AdminVolShrink:                 true,
+	proto.AdminVolExpand:                 true,
+	proto.AdminGetVolDataKey:             true,
+	proto.AdminRotateVolKey:              true,
+	proto.AdminVolReadOnly:               true,
+	proto.AdminRenameVol:                 true,
+	proto.AdminVolReplicaNum:             true,
+	proto.AdminLoadMetaPartition:         true,
+	proto.AdminDecommissionMetaPartition: true,
+	proto.AdminCreateMetaPartition:       true,
+	proto.AdminAddMetaReplica:            true,
+	proto.AdminDeleteMetaReplica:         true,
+	proto.AdminCreateDataPartition:       true,
+	proto.AdminLoadDataPartition:         true,
+	proto.AdminDecommissionDataPartition: true,
+	proto.AdminRepairDataPartition:       true,
+	proto.AdminAddDataReplica:            true,
+	proto.AdminDeleteDataReplica:         true,
+	proto.AddMetaNode:                    true,
+	proto.DecommissionMetaNode:           true,
+	proto.TransferMetaNodeLeaders:        true,
+	proto.AdminUpdateMetaNode:            true,
+	proto.AddDataNode:                    true,
+	proto.DecommissionDataNode:           true,
+	proto.TransferDataNodeLeaders:        true,
+	proto.AdminUpdateDataNode:            true,
+	proto.DecommissionDisk:               true,
+	proto.AdminSetMetaNodeThreshold:      true,
+	proto.AdminSetNodeInfo:               true,
+	proto.UserCreate:                     true,
+	proto.UserDelete:                     true,
+	proto.UserUpdate:                     true,
+	proto.UserUpdatePolicy:               true,
+	proto.UserRemovePolicy:               true,
+	proto.UserDeleteVolPolicy:            true,
+	proto.UserTransferVol:                true,
+	proto.UpdateZone:                     true,
+	proto.AdminRepairZonePlacement:       true,
+	proto.AdminSetRebalance:              true,
+	proto.TokenAddURI:                    true,
+	proto.TokenDelURI:                    true,
+	proto.TokenUpdateURI:                 true,
+	proto.QuotaSet:                       true,
+	proto.QuotaDelete:                    true,
+	proto.AdminEvictClient:               true,
+}
+
+// the params whose values are masked in the audit log
+var auditMaskedParams = map[string]bool{
+	volAuthKey: true,
+	tokenKey:   true,
+}
+
+// auditResponseWriter keeps the status and the head of the reply to record the result of the call.
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status int
+	reply  bytes.Buffer
+}
+
+func (w *auditResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	if left := maxAuditReplySize - w.reply.Len(); left > 0 {
+		if len(data) < left {
+			left = len(data)
+		}
+		w.reply.Write(data[:left])
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditResponseWriter) result() (code int32, msg string) {
+	reply := &proto.HTTPReply{}
+	if err := json.Unmarshal(w.reply.Bytes(), reply); err == nil {
+		return reply.Code, reply.Msg
+	}
+	if w.status == 0 || w.status == http.StatusOK {
+		return proto.ErrCodeSuccess, proto.ErrSuc.Error()
+	}
+	return proto.ErrCodeInternalError, strings.TrimSpace(w.reply.String())
+}
+
+// serveAudited serves the request on the leader, and records the call in the audit log if the API is audited.
+func (m *Server) serveAudited(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	if !auditedAPIs[r.URL.Path] {
+		next.ServeHTTP(w, r)
+		return
+	}
+	aw := &auditResponseWriter{ResponseWriter: w}
+	next.ServeHTTP(aw, r)
+	record := &proto.AdminAuditRecord{
+		Time:     time.Now().Unix(),
+		Operator: r.Header.Get(proto.AdminOperator),
+		Addr:     auditClientAddr(r),
+		API:      r.URL.Path,
+		Params:   make(map[string]string),
+	}
+	for key, values := range r.URL.Query() {
+		if auditMaskedParams[key] {
+			record.Params[key] = auditMaskedValue
+			continue
+		}
+		record.Params[key] = strings.Join(values, ",")
+	}
+	record.Code, record.Msg = aw.result()
+	go func() {
+		if err := m.cluster.syncAddAuditRecord(record); err != nil {
+			log.LogErrorf("action[serveAudited] api[%v] operator[%v] addr[%v] err[%v]", record.API, record.Operator, record.Addr, err)
+		}
+	}()
+}
+
+// auditClientAddr returns the address of the client, which is forwarded by the master proxying the request.
+func auditClientAddr(r *http.Request) string {
+	if r.Header.Get(proto.MasterProxiedBy) != "" {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// newAuditRecordID returns an increasing ID of the records, which is the unix nanoseconds of the record.
+func (c *Cluster) newAuditRecordID() uint64 {
+	for {
+		last := atomic.LoadUint64(&c.lastAuditRecordID)
+		id := uint64(time.Now().UnixNano())
+		if id <= last {
+			id = last + 1
+		}
+		if atomic.CompareAndSwapUint64(&c.lastAuditRecordID, last, id) {
+			return id
+		}
+	}
+}
+
+// key=#audit#recordID,value=json.Marshal(record)
+func auditRecordKey(id uint64) string {
+	return auditPrefix + fmt.Sprintf("%020d", id)
+}
+
+func (c *Cluster) syncAddAuditRecord(record *proto.AdminAuditRecord) (err error) {
+	record.ID = c.newAuditRecordID()
+	metadata := new(RaftCmd)
+	metadata.Op = opSyncAddAuditRecord
+	metadata.K = auditRecordKey(record.ID)
+	if metadata.V, err = json.Marshal(record); err != nil {
+		return
+	}
+	return c.submit(metadata)
+}
+
+func (c *Cluster) syncDeleteAuditRecord(id uint64) (err error) {
+	metadata := new(RaftCmd)
+	metadata.Op = opSyncDeleteAuditRecord
+	metadata.K = auditRecordKey(id)
+	return c.submit(metadata)
+}
+
+// loadAuditRecords returns the records in the audit log from the oldest.
+func (c *Cluster) loadAuditRecords() (records []*proto.AdminAuditRecord, err error) {
+	result, err := c.fsm.store.SeekForPrefix([]byte(auditPrefix))
+	if err != nil {
+		err = fmt.Errorf("action[loadAuditRecords],err:%v", err.Error())
+		return
+	}
+	records = make([]*proto.AdminAuditRecord, 0, len(result))
+	for _, value := range result {
+		record := &proto.AdminAuditRecord{}
+		if err = json.Unmarshal(value, record); err != nil {
+			log.LogErrorf("action[loadAuditRecords], unmarshal err:%v", err.Error())
+			return
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return
+}
+
+// trimAuditRecords removes the records older than the retention.
+func (c *Cluster) trimAuditRecords(now time.Time) {
+	if c.cfg.auditRetentionDays <= 0 {
+		return
+	}
+	records, err := c.loadAuditRecords()
+	if err != nil {
+		log.LogErrorf("action[trimAuditRecords] err[%v]", err)
+		return
+	}
+	expiration := now.AddDate(0, 0, -c.cfg.auditRetentionDays).Unix()
+	for _, record := range records {
+		if record.Time >= expiration {
+			continue
+		}
+		if err = c.syncDeleteAuditRecord(record.ID); err != nil {
+			log.LogErrorf("action[trimAuditRecords] record[%v] err[%v]", record.ID, err)
+			return
+		}
+	}
+}
+
+func (c *Cluster) scheduleToTrimAuditRecords() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.trimAuditRecords(time.Now())
+			}
+			time.Sleep(intervalToTrimAuditRecord)
+		}
+	}()
+}
+
+// getAdminAudit queries the audit log of the admin APIs from the newest record.
+func (m *Server) getAdminAudit(w http.ResponseWriter, r *http.Request) {
+	var (
+		api, operator, vol string
+		start, end         int64
+		limit              int
+		records            []*proto.AdminAuditRecord
+		err                error
+	)
+	if api, operator, vol, start, end, limit, err = parseAuditQuery(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if records, err = m.cluster.loadAuditRecords(); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	result := make([]*proto.AdminAuditRecord, 0)
+	for i := len(records) - 1; i >= 0 && len(result) < limit; i-- {
+		record := records[i]
+		if (api != "" && record.API != api) || (operator != "" && record.Operator != operator) ||
+			(vol != "" && record.Params[nameKey] != vol) || (start > 0 && record.Time < start) || (end > 0 && record.Time > end) {
+			continue
+		}
+		result = append(result, record)
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(result))
+}
+
+func parseAuditQuery(r *http.Request) (api, operator, vol string, start, end int64, limit int, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	api = r.FormValue(auditAPIKey)
+	operator = r.FormValue(auditOperatorKey)
+	vol = r.FormValue(nameKey)
+	if value := r.FormValue(startTimeKey); value != "" {
+		if start, err = strconv.ParseInt(value, 10, 64); err != nil {
+			err = unmatchedKey(startTimeKey)
+			return
+		}
+	}
+	if value := r.FormValue(endTimeKey); value != "" {
+		if end, err = strconv.ParseInt(value, 10, 64); err != nil {
+			err = unmatchedKey(endTimeKey)
+			return
+		}
+	}
+	limit = defaultAuditQueryLimit
+	if value := r.FormValue(limitKey); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			err = unmatchedKey(limitKey)
+			return
+		}
+	}
+	return
+}
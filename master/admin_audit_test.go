@@ -0,0 +1,95 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func waitAuditRecord(t *testing.T, api string) *proto.AdminAuditRecord {
+	for i := 0; i < 50; i++ {
+		records, err := server.cluster.loadAuditRecords()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := len(records) - 1; j >= 0; j-- {
+			if records[j].API == api {
+				return records[j]
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("no audit record of api[%v]", api)
+	return nil
+}
+
+func TestAdminAudit(t *testing.T) {
+	process(fmt.Sprintf("%v%v?dpWriteRate=0", hostAddr, proto.AdminSetNodeInfo), t)
+	record := waitAuditRecord(t, proto.AdminSetNodeInfo)
+	if record.Code != proto.ErrCodeSuccess || record.Params[nodeDpWriteRateKey] != "0" || record.Addr != "127.0.0.1" {
+		t.Errorf("unexpected audit record %v", record)
+	}
+
+	// the failed calls are recorded with the auth keys masked
+	reqURL := fmt.Sprintf("%v%v?name=%v&authKey=wrongKey&enable=true", hostAddr, proto.AdminVolReadOnly, commonVolName)
+	resp, err := http.Get(reqURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	record = waitAuditRecord(t, proto.AdminVolReadOnly)
+	if record.Code == proto.ErrCodeSuccess || record.Params[volAuthKey] != auditMaskedValue || record.Params[nameKey] != commonVolName {
+		t.Errorf("unexpected audit record %v", record)
+	}
+
+	reply := process(fmt.Sprintf("%v%v?api=%v&name=%v&limit=1", hostAddr, proto.AdminGetAudit, proto.AdminVolReadOnly, commonVolName), t)
+	if records, ok := reply.Data.([]interface{}); !ok || len(records) != 1 {
+		t.Errorf("expect 1 audit record but got %v", reply.Data)
+	}
+	// the queries are not recorded
+	if auditedAPIs[proto.AdminGetAudit] || auditedAPIs[proto.AdminGetVol] {
+		t.Errorf("expect the queries not audited")
+	}
+}
+
+func TestTrimAuditRecords(t *testing.T) {
+	now := time.Now()
+	old := &proto.AdminAuditRecord{Time: now.AddDate(0, 0, -server.cluster.cfg.auditRetentionDays-1).Unix(), API: "/test/old"}
+	if err := server.cluster.syncAddAuditRecord(old); err != nil {
+		t.Fatal(err)
+	}
+	recent := &proto.AdminAuditRecord{Time: now.Unix(), API: "/test/recent"}
+	if err := server.cluster.syncAddAuditRecord(recent); err != nil {
+		t.Fatal(err)
+	}
+	server.cluster.trimAuditRecords(now)
+	records, err := server.cluster.loadAuditRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var foundOld, foundRecent bool
+	for _, record := range records {
+		foundOld = foundOld || record.ID == old.ID
+		foundRecent = foundRecent || record.ID == recent.ID
+	}
+	if foundOld || !foundRecent {
+		t.Errorf("expect the old record trimmed and the recent one kept, old[%v] recent[%v]", foundOld, foundRecent)
+	}
+}
@@ -115,21 +115,14 @@ func (sender *AdminTaskManager) doSendTasks() {
 	sender.sendTasks(tasks)
 }
 
-func (sender *AdminTaskManager) getConn() (conn *net.TCPConn, err error) {
+func (sender *AdminTaskManager) getConn() (conn net.Conn, err error) {
 	if useConnPool {
 		return sender.connPool.GetConnect(sender.targetAddr)
 	}
-	var connect net.Conn
-	connect, err = net.Dial("tcp", sender.targetAddr)
-	if err == nil {
-		conn = connect.(*net.TCPConn)
-		conn.SetKeepAlive(true)
-		conn.SetNoDelay(true)
-	}
-	return
+	return util.DialTLS(sender.targetAddr, 0)
 }
 
-func (sender *AdminTaskManager) putConn(conn *net.TCPConn, forceClose bool) {
+func (sender *AdminTaskManager) putConn(conn net.Conn, forceClose bool) {
 	if useConnPool {
 		sender.connPool.PutConnect(conn, forceClose)
 	}
@@ -249,6 +242,18 @@ func (sender *AdminTaskManager) AddTask(t *proto.AdminTask) {
 	}
 }
 
+// pendingTaskCount returns the number of the tasks except the heartbeats not yet responded by the node.
+func (sender *AdminTaskManager) pendingTaskCount() (count int) {
+	sender.RLock()
+	defer sender.RUnlock()
+	for _, t := range sender.TaskMap {
+		if !t.IsHeartbeatTask() {
+			count++
+		}
+	}
+	return
+}
+
 func (sender *AdminTaskManager) getToDoTasks() (tasks []*proto.AdminTask) {
 	sender.RLock()
 	defer sender.RUnlock()
@@ -188,6 +188,95 @@ func (m *Server) listZone(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(zoneViews))
 }
 
+func (m *Server) getZonePlacement(w http.ResponseWriter, r *http.Request) {
+	var (
+		violations []*proto.ZonePlacementViolation
+		err        error
+	)
+	if err = r.ParseForm(); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if violations, err = m.cluster.getZonePlacementViolations(r.FormValue(nameKey)); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(violations))
+}
+
+func (m *Server) repairZonePlacement(w http.ResponseWriter, r *http.Request) {
+	var (
+		repaired []*proto.ZonePlacementViolation
+		count    = defaultZonePlacementRepairCount
+		err      error
+	)
+	if err = r.ParseForm(); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if countStr := r.FormValue(countKey); countStr != "" {
+		if count, err = strconv.Atoi(countStr); err != nil || count <= 0 {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(countKey).Error()})
+			return
+		}
+	}
+	if repaired, err = m.cluster.repairZonePlacement(r.FormValue(nameKey), count); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(repaired))
+}
+
+func (m *Server) getRebalance(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.rebalance.view()))
+}
+
+func (m *Server) setRebalance(w http.ResponseWriter, r *http.Request) {
+	var (
+		cfg = m.cluster.rebalance.getConfig()
+		err error
+	)
+	if err = r.ParseForm(); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if value := r.FormValue(enableKey); value != "" {
+		if cfg.enable, err = strconv.ParseBool(value); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(enableKey).Error()})
+			return
+		}
+	}
+	if value := r.FormValue(metaEnableKey); value != "" {
+		if cfg.metaEnable, err = strconv.ParseBool(value); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(metaEnableKey).Error()})
+			return
+		}
+	}
+	if value := r.FormValue(dryRunKey); value != "" {
+		if cfg.dryRun, err = strconv.ParseBool(value); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(dryRunKey).Error()})
+			return
+		}
+	}
+	if value := r.FormValue(maxMigrationsKey); value != "" {
+		if cfg.maxMigrations, err = strconv.Atoi(value); err != nil || cfg.maxMigrations <= 0 {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(maxMigrationsKey).Error()})
+			return
+		}
+	}
+	if value := r.FormValue(thresholdKey); value != "" {
+		if cfg.threshold, err = strconv.ParseFloat(value, 64); err != nil || cfg.threshold <= 0 || cfg.threshold >= 1 {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(thresholdKey).Error()})
+			return
+		}
+	}
+	if err = m.cluster.setRebalance(cfg); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.rebalance.view()))
+}
+
 func (m *Server) clusterStat(w http.ResponseWriter, r *http.Request) {
 	cs := &proto.ClusterStatInfo{
 		DataNodeStatInfo: m.cluster.dataNodeStatInfo,
@@ -239,15 +328,23 @@ func (m *Server) getCluster(w http.ResponseWriter, r *http.Request) {
 func (m *Server) getIPAddr(w http.ResponseWriter, r *http.Request) {
 	m.cluster.loadClusterValue()
 	batchCount := atomic.LoadUint64(&m.cluster.cfg.MetaNodeDeleteBatchCount)
-	limitRate := atomic.LoadUint64(&m.cluster.cfg.DataNodeDeleteLimitRate)
+	limitRate, metaNodeDeleteRate := m.cluster.deleteLimitRates()
 	deleteSleepMs := atomic.LoadUint64(&m.cluster.cfg.MetaNodeDeleteWorkerSleepMs)
 	autoRepairRate := atomic.LoadUint64(&m.cluster.cfg.DataNodeAutoRepairLimitRate)
+	diskWriteRate := atomic.LoadUint64(&m.cluster.cfg.DataNodeDiskWriteLimitRate)
+	diskRepairRate := atomic.LoadUint64(&m.cluster.cfg.DataNodeDiskRepairLimitRate)
+	dpWriteRate := atomic.LoadUint64(&m.cluster.cfg.DataNodeDpWriteLimitRate)
 	cInfo := &proto.ClusterInfo{
 		Cluster:                     m.cluster.Name,
 		MetaNodeDeleteBatchCount:    batchCount,
 		MetaNodeDeleteWorkerSleepMs: deleteSleepMs,
 		DataNodeDeleteLimitRate:     limitRate,
+		MetaNodeDeleteLimitRate:     metaNodeDeleteRate,
 		DataNodeAutoRepairLimitRate: autoRepairRate,
+		DataNodeDiskWriteLimitRate:  diskWriteRate,
+		DataNodeDiskRepairLimitRate: diskRepairRate,
+		DataNodeDpWriteLimitRate:    dpWriteRate,
+		AccessTokenEnabled:          m.cluster.accessTokenKey != nil,
 		Ip:                          strings.Split(r.RemoteAddr, ":")[0],
 	}
 	sendOkReply(w, r, newSuccessHTTPReply(cInfo))
@@ -362,6 +459,42 @@ func (m *Server) loadDataPartition(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
 }
 
+// Get the divergences between the replicas of the data partitions found by the replica consistency checker.
+func (m *Server) getDataPartitionDivergence(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.getReplicaDivergences(r.FormValue(nameKey))))
+}
+
+// Repair the extents of the data partition whose crc diverges across the replicas from the authoritative replica.
+func (m *Server) repairDataPartition(w http.ResponseWriter, r *http.Request) {
+	var (
+		msg         string
+		dp          *DataPartition
+		partitionID uint64
+		err         error
+	)
+
+	if partitionID, err = parseRequestToRepairDataPartition(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if dp, err = m.cluster.getDataPartitionByID(partitionID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrDataPartitionNotExists))
+		return
+	}
+
+	if err = m.cluster.repairDataPartition(dp); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg = fmt.Sprintf(proto.AdminRepairDataPartition+" partitionID :%v  repair data partition successfully", partitionID)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
 func (m *Server) addDataReplica(w http.ResponseWriter, r *http.Request) {
 	var (
 		msg         string
@@ -504,16 +637,19 @@ func (m *Server) decommissionDataPartition(w http.ResponseWriter, r *http.Reques
 
 func (m *Server) diagnoseDataPartition(w http.ResponseWriter, r *http.Request) {
 	var (
-		err               error
-		rstMsg            *proto.DataPartitionDiagnosis
-		inactiveNodes     []string
-		corruptDps        []*DataPartition
-		lackReplicaDps    []*DataPartition
-		corruptDpIDs      []uint64
-		lackReplicaDpIDs  []uint64
-		badDataPartitions []badPartitionView
+		err                error
+		rstMsg             *proto.DataPartitionDiagnosis
+		inactiveNodes      []string
+		corruptDps         []*DataPartition
+		lackReplicaDps     []*DataPartition
+		corruptDpIDs       []uint64
+		lackReplicaDpIDs   []uint64
+		badDataPartitions  []badPartitionView
+		corruptExtentDpIDs []uint64
+		divergentDpIDs     []uint64
 	)
 	corruptDpIDs = make([]uint64, 0)
+	corruptExtentDpIDs = make([]uint64, 0)
 	lackReplicaDpIDs = make([]uint64, 0)
 	if inactiveNodes, corruptDps, err = m.cluster.checkCorruptDataPartitions(); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
@@ -530,22 +666,35 @@ func (m *Server) diagnoseDataPartition(w http.ResponseWriter, r *http.Request) {
 	for _, dp := range lackReplicaDps {
 		lackReplicaDpIDs = append(lackReplicaDpIDs, dp.PartitionID)
 	}
+	for _, dp := range m.cluster.checkCorruptExtentDataPartitions() {
+		corruptExtentDpIDs = append(corruptExtentDpIDs, dp.PartitionID)
+	}
+	divergentDpIDs = make([]uint64, 0)
+	for _, d := range m.cluster.getReplicaDivergences("") {
+		if len(divergentDpIDs) == 0 || divergentDpIDs[len(divergentDpIDs)-1] != d.PartitionID {
+			divergentDpIDs = append(divergentDpIDs, d.PartitionID)
+		}
+	}
 	badDataPartitions = m.cluster.getBadDataPartitionsView()
 	rstMsg = &proto.DataPartitionDiagnosis{
-		InactiveDataNodes:           inactiveNodes,
-		CorruptDataPartitionIDs:     corruptDpIDs,
-		LackReplicaDataPartitionIDs: lackReplicaDpIDs,
-		BadDataPartitionIDs:         badDataPartitions,
+		InactiveDataNodes:             inactiveNodes,
+		CorruptDataPartitionIDs:       corruptDpIDs,
+		LackReplicaDataPartitionIDs:   lackReplicaDpIDs,
+		BadDataPartitionIDs:           badDataPartitions,
+		CorruptExtentDataPartitionIDs: corruptExtentDpIDs,
+		DivergentDataPartitionIDs:     divergentDpIDs,
 	}
 	log.LogInfof("diagnose dataPartition[%v] inactiveNodes:[%v], corruptDpIDs:[%v], lackReplicaDpIDs:[%v]", m.cluster.Name, inactiveNodes, corruptDpIDs, lackReplicaDpIDs)
 	sendOkReply(w, r, newSuccessHTTPReply(rstMsg))
 }
 
-// Mark the volume as deleted, which will then be deleted later.
+// Mark the volume as deleted, which will then be purged after the deletion delay
+// unless it is restored before, or purged at once with force.
 func (m *Server) markDeleteVol(w http.ResponseWriter, r *http.Request) {
 	var (
 		name    string
 		authKey string
+		force   bool
 		err     error
 		msg     string
 	)
@@ -554,7 +703,13 @@ func (m *Server) markDeleteVol(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
-	if err = m.cluster.markDeleteVol(name, authKey); err != nil {
+	if value := r.FormValue(forceKey); value != "" {
+		if force, err = strconv.ParseBool(value); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(forceKey).Error()})
+			return
+		}
+	}
+	if err = m.cluster.markDeleteVol(name, authKey, force); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
@@ -563,26 +718,37 @@ func (m *Server) markDeleteVol(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	msg = fmt.Sprintf("delete vol[%v] successfully,from[%v]", name, r.RemoteAddr)
+	if vol, err := m.cluster.getVol(name); err == nil {
+		msg = fmt.Sprintf("%v,purge at[%v]", msg, time.Unix(vol.getPurgeTime(), 0).Format(proto.TimeFormat))
+	}
 	log.LogWarn(msg)
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
 }
 
 func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 	var (
-		name           string
-		authKey        string
-		err            error
-		msg            string
-		capacity       uint64
-		replicaNum     int
-		followerRead   bool
-		authenticate   bool
-		enableToken    bool
-		zoneName       string
-		description    string
-		dpSelectorName string
-		dpSelectorParm string
-		vol            *Vol
+		name            string
+		authKey         string
+		err             error
+		msg             string
+		capacity        uint64
+		replicaNum      int
+		followerRead    bool
+		authenticate    bool
+		enableToken     bool
+		zoneName        string
+		description     string
+		dpSelectorName  string
+		dpSelectorParm  string
+		trashDays       uint32
+		hotMedia        string
+		coldMedia       string
+		coldDays        uint32
+		caseInsensitive bool
+		enforcePerm     bool
+		smallFileSize   uint32
+		permPriority    string
+		vol             *Vol
 	)
 
 	if name, authKey, description, err = parseRequestToUpdateVol(r); err != nil {
@@ -609,6 +775,36 @@ func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if trashDays, err = parseTrashDaysToUpdateVol(r, vol); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if hotMedia, coldMedia, coldDays, err = parseTieringToUpdateVol(r, vol); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if caseInsensitive, err = parseCaseInsensitiveToUpdateVol(r, vol); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if enforcePerm, err = parseEnforcePermToUpdateVol(r, vol); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if smallFileSize, err = parseSmallFileSizeToUpdateVol(r, vol); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if permPriority, err = parsePermissionPriorityToUpdateVol(r, vol); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
 	newArgs := getVolVarargs(vol)
 
 	newArgs.zoneName = zoneName
@@ -619,6 +815,14 @@ func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 	newArgs.enableToken = enableToken
 	newArgs.dpSelectorName = dpSelectorName
 	newArgs.dpSelectorParm = dpSelectorParm
+	newArgs.trashDays = trashDays
+	newArgs.hotMedia = hotMedia
+	newArgs.coldMedia = coldMedia
+	newArgs.coldDays = coldDays
+	newArgs.caseInsensitive = caseInsensitive
+	newArgs.enforcePerm = enforcePerm
+	newArgs.smallFileSize = smallFileSize
+	newArgs.permPriority = permPriority
 
 	if err = m.cluster.updateVol(name, authKey, newArgs); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
@@ -650,7 +854,12 @@ func (m *Server) volExpand(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
+	if err = vol.checkCapacityChange(); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
 
+	oldCapacity := vol.capacity()
 	newArgs := getVolVarargs(vol)
 	newArgs.capacity = uint64(capacity)
 
@@ -658,6 +867,7 @@ func (m *Server) volExpand(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
+	m.cluster.changeCapacity(vol, volCapacityExpand, oldCapacity, uint64(capacity))
 	msg = fmt.Sprintf("update vol[%v] successfully\n", name)
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
 }
@@ -684,7 +894,17 @@ func (m *Server) volShrink(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
+	if usedSpace := vol.totalUsedSpace(); uint64(capacity)*util.GB < usedSpace {
+		err = fmt.Errorf("shrink capacity[%v] should not be less than the used space[%v]", capacity, usedSpace/util.GB)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = vol.checkCapacityChange(); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
 
+	oldCapacity := vol.capacity()
 	newArgs := getVolVarargs(vol)
 	newArgs.capacity = uint64(capacity)
 
@@ -692,10 +912,106 @@ func (m *Server) volShrink(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
+	m.cluster.changeCapacity(vol, volCapacityShrink, oldCapacity, uint64(capacity))
 	msg = fmt.Sprintf("update vol[%v] successfully\n", name)
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
 }
 
+func (m *Server) getVolCapacityProgress(w http.ResponseWriter, r *http.Request) {
+	var (
+		name     string
+		err      error
+		vol      *Vol
+		progress *proto.VolCapacityProgress
+	)
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	if progress = vol.getCapacityProgress(); progress == nil {
+		err = fmt.Errorf("no capacity change of vol[%v] since the master leader started", name)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(progress))
+}
+
+// getVolDataKey returns the data key of an encrypted volume, only to the data nodes of the cluster.
+func (m *Server) getVolDataKey(w http.ResponseWriter, r *http.Request) {
+	var (
+		name     string
+		nodeAddr string
+		err      error
+		vol      *Vol
+		dataKey  []byte
+	)
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if nodeAddr, err = extractNodeAddr(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if _, err = m.cluster.dataNode(nodeAddr); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrDataNodeNotExists))
+		return
+	}
+	if !m.isRequestFrom(r, nodeAddr) {
+		err = fmt.Errorf("request from [%v] is not from data node[%v]", r.RemoteAddr, nodeAddr)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	if dataKey, err = m.cluster.getVolDataKey(vol); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(&proto.VolDataKey{Name: name, DataKey: dataKey}))
+}
+
+// isRequestFrom checks the request is sent from the host of the address,
+// the requests proxied by the other masters are checked by the forwarded address.
+func (m *Server) isRequestFrom(r *http.Request, addr string) bool {
+	host := strings.Split(addr, ":")[0]
+	remote := strings.Split(r.RemoteAddr, ":")[0]
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		for _, peer := range m.config.peers {
+			if peer.Address == remote {
+				remote = strings.TrimSpace(strings.Split(forwarded, ",")[0])
+				break
+			}
+		}
+	}
+	return remote == host
+}
+
+func (m *Server) rotateVolKey(w http.ResponseWriter, r *http.Request) {
+	var (
+		name    string
+		authKey string
+		err     error
+		msg     string
+	)
+	if name, authKey, err = parseVolNameAndAuthKey(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.rotateVolKey(name, authKey); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg = fmt.Sprintf("rotate data key of vol[%v] successfully", name)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
 func (m *Server) createVol(w http.ResponseWriter, r *http.Request) {
 	var (
 		name         string
@@ -711,20 +1027,28 @@ func (m *Server) createVol(w http.ResponseWriter, r *http.Request) {
 		authenticate bool
 		crossZone    bool
 		enableToken  bool
+		encrypt      bool
+		flat         bool
+		cache        bool
 		zoneName     string
 		description  string
 	)
 
-	if name, owner, zoneName, description, mpCount, dpReplicaNum, size, capacity, followerRead, authenticate, crossZone, enableToken, err = parseRequestToCreateVol(r); err != nil {
+	if name, owner, zoneName, description, mpCount, dpReplicaNum, size, capacity, followerRead, authenticate, crossZone, enableToken, encrypt, flat, cache, err = parseRequestToCreateVol(r); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
-	if !(dpReplicaNum == 2 || dpReplicaNum == 3) {
-		err = fmt.Errorf("replicaNum can only be 2 and 3,received replicaNum is[%v]", dpReplicaNum)
+	if cache && dpReplicaNum != 1 {
+		err = fmt.Errorf("replicaNum of the cache vol can only be 1,received replicaNum is[%v]", dpReplicaNum)
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
-	if vol, err = m.cluster.createVol(name, owner, zoneName, description, mpCount, dpReplicaNum, size, capacity, followerRead, authenticate, crossZone, enableToken); err != nil {
+	if !cache && !(dpReplicaNum == 2 || dpReplicaNum == 3) {
+		err = fmt.Errorf("replicaNum can only be 2 and 3,or 1 with cache=true,received replicaNum is[%v]", dpReplicaNum)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.createVol(name, owner, zoneName, description, mpCount, dpReplicaNum, size, capacity, followerRead, authenticate, crossZone, enableToken, encrypt, flat, cache); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
@@ -791,6 +1115,21 @@ func newSimpleView(vol *Vol) *proto.SimpleVolView {
 		Description:        vol.description,
 		DpSelectorName:     vol.dpSelectorName,
 		DpSelectorParm:     vol.dpSelectorParm,
+		TrashDays:          vol.trashDays,
+		HotMedia:           vol.hotMedia,
+		ColdMedia:          vol.coldMedia,
+		ColdDays:           vol.coldDays,
+		CaseInsensitive:    vol.caseInsensitive,
+		EnforcePerm:        vol.enforcePerm,
+		SmallFileSize:      vol.smallFileSize,
+		DisablePacking:     vol.smallFileSize == 0,
+		Encrypted:          vol.encrypted(),
+		DataKeyID:          vol.dataKeyID,
+		Flat:               vol.flat,
+		PermissionPriority: vol.permissionPriority,
+		ReadOnly:           vol.readOnly,
+		Cache:              vol.cache,
+		PurgeTime:          vol.purgeTime,
 	}
 }
 
@@ -798,14 +1137,16 @@ func (m *Server) addDataNode(w http.ResponseWriter, r *http.Request) {
 	var (
 		nodeAddr string
 		zoneName string
+		region   string
+		rack     string
 		id       uint64
 		err      error
 	)
-	if nodeAddr, zoneName, err = parseRequestForAddNode(r); err != nil {
+	if nodeAddr, zoneName, region, rack, err = parseRequestForAddNode(r); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
-	if id, err = m.cluster.addDataNode(nodeAddr, zoneName); err != nil {
+	if id, err = m.cluster.addDataNode(nodeAddr, zoneName, region, rack); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
@@ -836,6 +1177,8 @@ func (m *Server) getDataNode(w http.ResponseWriter, r *http.Request) {
 		AvailableSpace:            dataNode.AvailableSpace,
 		ID:                        dataNode.ID,
 		ZoneName:                  dataNode.ZoneName,
+		Region:                    dataNode.Region,
+		Rack:                      dataNode.Rack,
 		Addr:                      dataNode.Addr,
 		ReportTime:                dataNode.ReportTime,
 		IsActive:                  dataNode.isActive,
@@ -847,6 +1190,8 @@ func (m *Server) getDataNode(w http.ResponseWriter, r *http.Request) {
 		NodeSetID:                 dataNode.NodeSetID,
 		PersistenceDataPartitions: dataNode.PersistenceDataPartitions,
 		BadDisks:                  dataNode.BadDisks,
+		MediaSpaces:               dataNode.MediaSpaces,
+		DiskSmart:                 dataNode.DiskSmart,
 	}
 
 	sendOkReply(w, r, newSuccessHTTPReply(dataNodeInfo))
@@ -870,6 +1215,15 @@ func (m *Server) decommissionDataNode(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(proto.ErrDataNodeNotExists))
 		return
 	}
+	if graceful, _ := strconv.ParseBool(r.FormValue(gracefulKey)); graceful {
+		if err = m.cluster.decommissionDataNodeGracefully(node); err != nil {
+			sendErrReply(w, r, newErrHTTPReply(err))
+			return
+		}
+		rstMsg = fmt.Sprintf("start to decommission data node [%v] gracefully", offLineAddr)
+		sendOkReply(w, r, newSuccessHTTPReply(rstMsg))
+		return
+	}
 	if err = m.cluster.decommissionDataNode(node); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
@@ -878,6 +1232,23 @@ func (m *Server) decommissionDataNode(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(rstMsg))
 }
 
+func (m *Server) getDataNodeDecommissionProgress(w http.ResponseWriter, r *http.Request) {
+	var (
+		nodeAddr string
+		progress *proto.DecommissionProgress
+		err      error
+	)
+	if nodeAddr, err = parseAndExtractNodeAddr(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if progress, err = m.cluster.getDecommissionProgress(nodeTypeDataNode, nodeAddr); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(progress))
+}
+
 func (m *Server) setNodeInfoHandler(w http.ResponseWriter, r *http.Request) {
 	var (
 		params map[string]interface{}
@@ -922,6 +1293,27 @@ func (m *Server) setNodeInfoHandler(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
+
+	if val, ok := params[clusterDeleteRateKey]; ok {
+		if err = m.cluster.setClusterDeleteLimitRate(val.(uint64)); err != nil {
+			sendErrReply(w, r, newErrHTTPReply(err))
+			return
+		}
+	}
+
+	writeLimitRates := map[string]*uint64{
+		nodeDiskWriteRateKey:  &m.cluster.cfg.DataNodeDiskWriteLimitRate,
+		nodeDiskRepairRateKey: &m.cluster.cfg.DataNodeDiskRepairLimitRate,
+		nodeDpWriteRateKey:    &m.cluster.cfg.DataNodeDpWriteLimitRate,
+	}
+	for key, limitRate := range writeLimitRates {
+		if val, ok := params[key]; ok {
+			if err = m.cluster.setDataNodeWriteLimitRate(limitRate, val.(uint64)); err != nil {
+				sendErrReply(w, r, newErrHTTPReply(err))
+				return
+			}
+		}
+	}
 	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set nodeinfo params %v successfully", params)))
 
 }
@@ -933,6 +1325,10 @@ func (m *Server) getNodeInfoHandler(w http.ResponseWriter, r *http.Request) {
 	resp[nodeMarkDeleteRateKey] = fmt.Sprintf("%v", m.cluster.cfg.DataNodeDeleteLimitRate)
 	resp[nodeDeleteWorkerSleepMs] = fmt.Sprintf("%v", m.cluster.cfg.MetaNodeDeleteWorkerSleepMs)
 	resp[nodeAutoRepairRateKey] = fmt.Sprintf("%v", m.cluster.cfg.DataNodeAutoRepairLimitRate)
+	resp[nodeDiskWriteRateKey] = fmt.Sprintf("%v", m.cluster.cfg.DataNodeDiskWriteLimitRate)
+	resp[nodeDiskRepairRateKey] = fmt.Sprintf("%v", m.cluster.cfg.DataNodeDiskRepairLimitRate)
+	resp[nodeDpWriteRateKey] = fmt.Sprintf("%v", m.cluster.cfg.DataNodeDpWriteLimitRate)
+	resp[clusterDeleteRateKey] = fmt.Sprintf("%v", m.cluster.cfg.ClusterDeleteLimitRate)
 
 	sendOkReply(w, r, newSuccessHTTPReply(resp))
 }
@@ -1029,14 +1425,16 @@ func (m *Server) addMetaNode(w http.ResponseWriter, r *http.Request) {
 	var (
 		nodeAddr string
 		zoneName string
+		region   string
+		rack     string
 		id       uint64
 		err      error
 	)
-	if nodeAddr, zoneName, err = parseRequestForAddNode(r); err != nil {
+	if nodeAddr, zoneName, region, rack, err = parseRequestForAddNode(r); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
-	if id, err = m.cluster.addMetaNode(nodeAddr, zoneName); err != nil {
+	if id, err = m.cluster.addMetaNode(nodeAddr, zoneName, region, rack); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
@@ -1104,6 +1502,8 @@ func (m *Server) getMetaNode(w http.ResponseWriter, r *http.Request) {
 		Addr:                      metaNode.Addr,
 		IsActive:                  metaNode.IsActive,
 		ZoneName:                  metaNode.ZoneName,
+		Region:                    metaNode.Region,
+		Rack:                      metaNode.Rack,
 		MaxMemAvailWeight:         metaNode.MaxMemAvailWeight,
 		Total:                     metaNode.Total,
 		Used:                      metaNode.Used,
@@ -1166,6 +1566,31 @@ func (m *Server) loadMetaPartition(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
 }
 
+func (m *Server) verifyMetaPartition(w http.ResponseWriter, r *http.Request) {
+	var (
+		mp          *MetaPartition
+		partitionID uint64
+		result      *proto.MetaPartitionVerifyResult
+		err         error
+	)
+
+	if partitionID, err = parseRequestToLoadMetaPartition(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if mp, err = m.cluster.getMetaPartitionByID(partitionID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrMetaPartitionNotExists))
+		return
+	}
+
+	if result, err = m.cluster.verifyMetaPartition(mp); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(result))
+}
+
 func (m *Server) decommissionMetaNode(w http.ResponseWriter, r *http.Request) {
 	var (
 		metaNode    *MetaNode
@@ -1183,6 +1608,15 @@ func (m *Server) decommissionMetaNode(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(proto.ErrMetaNodeNotExists))
 		return
 	}
+	if graceful, _ := strconv.ParseBool(r.FormValue(gracefulKey)); graceful {
+		if err = m.cluster.decommissionMetaNodeGracefully(metaNode); err != nil {
+			sendErrReply(w, r, newErrHTTPReply(err))
+			return
+		}
+		rstMsg = fmt.Sprintf("start to decommission meta node [%v] gracefully", offLineAddr)
+		sendOkReply(w, r, newSuccessHTTPReply(rstMsg))
+		return
+	}
 	if err = m.cluster.decommissionMetaNode(metaNode); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
@@ -1191,6 +1625,23 @@ func (m *Server) decommissionMetaNode(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(rstMsg))
 }
 
+func (m *Server) getMetaNodeDecommissionProgress(w http.ResponseWriter, r *http.Request) {
+	var (
+		nodeAddr string
+		progress *proto.DecommissionProgress
+		err      error
+	)
+	if nodeAddr, err = parseAndExtractNodeAddr(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if progress, err = m.cluster.getDecommissionProgress(nodeTypeMetaNode, nodeAddr); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(progress))
+}
+
 func (m *Server) handleMetaNodeTaskResponse(w http.ResponseWriter, r *http.Request) {
 	tr, err := parseRequestToGetTaskResponse(r)
 	if err != nil {
@@ -1275,7 +1726,7 @@ func parseRequestForUpdateMetaNode(r *http.Request) (nodeAddr string, id uint64,
 	return
 }
 
-func parseRequestForAddNode(r *http.Request) (nodeAddr, zoneName string, err error) {
+func parseRequestForAddNode(r *http.Request) (nodeAddr, zoneName, region, rack string, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
 	}
@@ -1285,6 +1736,12 @@ func parseRequestForAddNode(r *http.Request) (nodeAddr, zoneName string, err err
 	if zoneName = r.FormValue(zoneNameKey); zoneName == "" {
 		zoneName = DefaultZoneName
 	}
+	// the region and the rack are the fault domains of the node besides the zone, see topologyPath
+	region, rack = nodeTopology(r.FormValue(regionKey), r.FormValue(rackKey))
+	if strings.Contains(region, topologyPathSeparator) || strings.Contains(rack, topologyPathSeparator) {
+		err = fmt.Errorf("the region[%v] or the rack[%v] contains %v", region, rack, topologyPathSeparator)
+		return
+	}
 	return
 }
 
@@ -1441,6 +1898,45 @@ func parseDefaultInfoToUpdateVol(r *http.Request, vol *Vol) (zoneName string, ca
 	return
 }
 
+func parseTrashDaysToUpdateVol(r *http.Request, vol *Vol) (trashDays uint32, err error) {
+	trashDaysStr := r.FormValue(trashDaysKey)
+	if trashDaysStr == "" {
+		return vol.trashDays, nil
+	}
+	var days uint64
+	if days, err = strconv.ParseUint(trashDaysStr, 10, 32); err != nil {
+		err = unmatchedKey(trashDaysKey)
+		return
+	}
+	return uint32(days), nil
+}
+
+func parseTieringToUpdateVol(r *http.Request, vol *Vol) (hotMedia, coldMedia string, coldDays uint32, err error) {
+	hotMedia, coldMedia, coldDays = vol.hotMedia, vol.coldMedia, vol.coldDays
+	if value, ok := r.Form[hotMediaKey]; ok {
+		hotMedia = value[0]
+	}
+	if value, ok := r.Form[coldMediaKey]; ok {
+		coldMedia = value[0]
+	}
+	if value := r.FormValue(coldDaysKey); value != "" {
+		var days uint64
+		if days, err = strconv.ParseUint(value, 10, 32); err != nil {
+			err = unmatchedKey(coldDaysKey)
+			return
+		}
+		coldDays = uint32(days)
+	}
+	if hotMedia == "" && coldMedia == "" {
+		return
+	}
+	if !proto.IsValidMediaType(hotMedia) || !proto.IsValidMediaType(coldMedia) || hotMedia == coldMedia {
+		err = fmt.Errorf("hotMedia and coldMedia should be different media types of %v and %v", proto.MediaTypeSSD, proto.MediaTypeHDD)
+		return
+	}
+	return
+}
+
 func parseBoolFieldToUpdateVol(r *http.Request, vol *Vol) (followerRead, authenticate bool, err error) {
 	if followerReadStr := r.FormValue(followerReadKey); followerReadStr != "" {
 		if followerRead, err = strconv.ParseBool(followerReadStr); err != nil {
@@ -1461,6 +1957,61 @@ func parseBoolFieldToUpdateVol(r *http.Request, vol *Vol) (followerRead, authent
 	return
 }
 
+func parseCaseInsensitiveToUpdateVol(r *http.Request, vol *Vol) (caseInsensitive bool, err error) {
+	value := r.FormValue(caseInsensitiveKey)
+	if value == "" {
+		return vol.caseInsensitive, nil
+	}
+	if caseInsensitive, err = strconv.ParseBool(value); err != nil {
+		err = unmatchedKey(caseInsensitiveKey)
+	}
+	return
+}
+
+func parseEnforcePermToUpdateVol(r *http.Request, vol *Vol) (enforcePerm bool, err error) {
+	value := r.FormValue(enforcePermKey)
+	if value == "" {
+		return vol.enforcePerm, nil
+	}
+	if enforcePerm, err = strconv.ParseBool(value); err != nil {
+		err = unmatchedKey(enforcePermKey)
+	}
+	return
+}
+
+// The permission priority is not changed if the key is absent, and "none" resets it to the default.
+func parsePermissionPriorityToUpdateVol(r *http.Request, vol *Vol) (priority string, err error) {
+	value := r.FormValue(permissionPriorityKey)
+	if value == "" {
+		return vol.permissionPriority, nil
+	}
+	if priority = value; priority == "none" {
+		priority = proto.PermissionPriorityNone
+	}
+	if !proto.ValidPermissionPriority(priority) {
+		err = unmatchedKey(permissionPriorityKey)
+	}
+	return
+}
+
+func parseSmallFileSizeToUpdateVol(r *http.Request, vol *Vol) (smallFileSize uint32, err error) {
+	value := r.FormValue(smallFileSizeKey)
+	if value == "" {
+		return vol.smallFileSize, nil
+	}
+	var size uint64
+	if size, err = strconv.ParseUint(value, 10, 32); err != nil {
+		err = unmatchedKey(smallFileSizeKey)
+		return
+	}
+	// a tiny extent is written by a single packet
+	if size > util.DefaultTinySizeLimit {
+		err = fmt.Errorf("%v should not be larger than %v", smallFileSizeKey, util.DefaultTinySizeLimit)
+		return
+	}
+	return uint32(size), nil
+}
+
 func parseRequestToSetVolCapacity(r *http.Request) (name, authKey string, capacity int, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
@@ -1477,7 +2028,7 @@ func parseRequestToSetVolCapacity(r *http.Request) (name, authKey string, capaci
 	return
 }
 
-func parseRequestToCreateVol(r *http.Request) (name, owner, zoneName, description string, mpCount, dpReplicaNum, size, capacity int, followerRead, authenticate, crossZone, enableToken bool, err error) {
+func parseRequestToCreateVol(r *http.Request) (name, owner, zoneName, description string, mpCount, dpReplicaNum, size, capacity int, followerRead, authenticate, crossZone, enableToken, encrypt, flat, cache bool, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
 	}
@@ -1526,6 +2077,28 @@ func parseRequestToCreateVol(r *http.Request) (name, owner, zoneName, descriptio
 	zoneName = r.FormValue(zoneNameKey)
 	enableToken = extractEnableToken(r)
 	description = r.FormValue(descriptionKey)
+	if value := r.FormValue(encryptKey); value != "" {
+		if encrypt, err = strconv.ParseBool(value); err != nil {
+			err = unmatchedKey(encryptKey)
+			return
+		}
+	}
+	if value := r.FormValue(flatKey); value != "" {
+		if flat, err = strconv.ParseBool(value); err != nil {
+			err = unmatchedKey(flatKey)
+			return
+		}
+	}
+	if value := r.FormValue(cacheKey); value != "" {
+		if cache, err = strconv.ParseBool(value); err != nil {
+			err = unmatchedKey(cacheKey)
+			return
+		}
+		// the cache vol has a single replica by default
+		if cache && r.FormValue(replicaNumKey) == "" {
+			dpReplicaNum = 1
+		}
+	}
 	return
 }
 
@@ -1565,6 +2138,13 @@ func parseRequestToGetDataPartition(r *http.Request) (ID uint64, volName string,
 	return
 }
 
+func parseRequestToRepairDataPartition(r *http.Request) (ID uint64, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	return extractDataPartitionID(r)
+}
+
 func parseRequestToLoadDataPartition(r *http.Request) (ID uint64, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
@@ -1789,6 +2369,19 @@ func parseAndExtractSetNodeInfoParams(r *http.Request) (params map[string]interf
 		}
 		params[nodeDeleteWorkerSleepMs] = val
 	}
+
+	for _, key := range []string{nodeDiskWriteRateKey, nodeDiskRepairRateKey, nodeDpWriteRateKey, clusterDeleteRateKey} {
+		if value = r.FormValue(key); value != "" {
+			noParams = false
+			var val = uint64(0)
+			val, err = strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				err = unmatchedKey(key)
+				return
+			}
+			params[key] = val
+		}
+	}
 	if noParams {
 		err = keyNotFound(nodeDeleteBatchCountKey)
 		return
@@ -1928,6 +2521,38 @@ func (m *Server) getDataPartitions(w http.ResponseWriter, r *http.Request) {
 	send(w, r, body)
 }
 
+// getDataPartitionLocations returns the locations of the data partitions of the volume given by their ids,
+// the partitions not found are left out.
+func (m *Server) getDataPartitionLocations(w http.ResponseWriter, r *http.Request) {
+	var (
+		name string
+		ids  []uint64
+		vol  *Vol
+		err  error
+	)
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if ids, err = parseDataPartitionIDs(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	locations := make([]*proto.DataPartitionLocation, 0, len(ids))
+	for _, id := range ids {
+		dp, err := vol.getDataPartitionByID(id)
+		if err != nil {
+			continue
+		}
+		locations = append(locations, dp.location(m.cluster))
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(locations))
+}
+
 func (m *Server) getVol(w http.ResponseWriter, r *http.Request) {
 	var (
 		err     error
@@ -1946,6 +2571,10 @@ func (m *Server) getVol(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
 		return
 	}
+	if vol.status() == markDelete {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
 	if !param.skipOwnerValidation && !matchKey(vol.Owner, param.authKey) {
 		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolAuthKeyNotMatch))
 		return
@@ -2103,6 +2732,7 @@ func (m *Server) listVols(w http.ResponseWriter, r *http.Request) {
 			}
 			stat := volStat(vol)
 			volInfo := proto.NewVolInfo(vol.Name, vol.Owner, vol.createTime, vol.status(), stat.TotalSize, stat.UsedSize)
+			volInfo.PurgeTime = vol.getPurgeTime()
 			volsInfo = append(volsInfo, volInfo)
 		}
 	}
@@ -2167,6 +2797,25 @@ func extractName(r *http.Request) (name string, err error) {
 	return
 }
 
+// parseDataPartitionIDs parses the comma separated ids of the data partitions.
+func parseDataPartitionIDs(r *http.Request) (ids []uint64, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	value := r.FormValue(idsKey)
+	if value == "" {
+		return nil, keyNotFound(idsKey)
+	}
+	for _, s := range strings.Split(value, ",") {
+		var id uint64
+		if id, err = strconv.ParseUint(strings.TrimSpace(s), 10, 64); err != nil {
+			return nil, unmatchedKey(idsKey)
+		}
+		ids = append(ids, id)
+	}
+	return
+}
+
 func extractOwner(r *http.Request) (owner string, err error) {
 	if owner = r.FormValue(volOwnerKey); owner == "" {
 		err = keyNotFound(volOwnerKey)
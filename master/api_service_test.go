@@ -102,7 +102,7 @@ func createDefaultMasterServerForTest() *Server {
 	testServer.cluster.checkMetaNodeHeartbeat()
 	time.Sleep(5 * time.Second)
 	testServer.cluster.scheduleToUpdateStatInfo()
-	vol, err := testServer.cluster.createVol(commonVolName, "cfs", testZone2, "", 3, 3, 3, 100, false, false, false, false)
+	vol, err := testServer.cluster.createVol(commonVolName, "cfs", testZone2, "", 3, 3, 3, 100, false, false, false, false, false, false, false)
 	if err != nil {
 		panic(err)
 	}
@@ -359,6 +359,18 @@ func setVolCapacity(capacity uint64, url string, t *testing.T) {
 		t.Errorf("expect capacity is %v, but is %v", capacity, vol.Capacity)
 		return
 	}
+	// the data partitions are adjusted in background, and the next change is rejected until it finishes
+	for i := 0; i < 30; i++ {
+		if progress := vol.getCapacityProgress(); progress != nil && progress.Status == volCapacityFinished {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	reqURL = fmt.Sprintf("%v%v?name=%v", hostAddr, proto.AdminVolCapacityProgress, commonVol.Name)
+	process(reqURL, t)
+	if progress := vol.getCapacityProgress(); progress.Status != volCapacityFinished || progress.NewCapacity != capacity {
+		t.Errorf("expect capacity change to %v finished, but is %v", capacity, progress)
+	}
 }
 
 func buildAuthKey(owner string) string {
@@ -460,6 +472,16 @@ func TestGetDataPartitions(t *testing.T) {
 	process(reqURL, t)
 }
 
+func TestGetDataPartitionLocations(t *testing.T) {
+	partition := commonVol.dataPartitions.partitions[0]
+	reqURL := fmt.Sprintf("%v%v?name=%v&ids=%v,0", hostAddr, proto.ClientDataPartitionLocations, commonVolName, partition.PartitionID)
+	process(reqURL, t)
+	loc := partition.location(server.cluster)
+	if loc.PartitionID != partition.PartitionID || len(loc.Hosts) == 0 || len(loc.Zones) != len(loc.Hosts) {
+		t.Errorf("location of partition[%v]: %+v", partition.PartitionID, loc)
+	}
+}
+
 func TestGetTopo(t *testing.T) {
 	reqURL := fmt.Sprintf("%v%v", hostAddr, proto.GetTopologyView)
 	process(reqURL, t)
@@ -580,6 +602,96 @@ func TestDelToken(t *testing.T) {
 	}
 }
 
+func TestSetAndDeleteQuota(t *testing.T) {
+	var quotaId uint64 = 1
+	reqUrl := fmt.Sprintf("%v%v?name=%v&inode=%v&maxFiles=%v&maxBytes=%v&authKey=%v",
+		hostAddr, proto.QuotaSet, commonVol.Name, quotaId, 100, 1024, buildAuthKey("cfs"))
+	fmt.Println(reqUrl)
+	process(reqUrl, t)
+	quotas := commonVol.getQuotas()
+	if len(quotas) != 1 || quotas[0].MaxFiles != 100 || quotas[0].MaxBytes != 1024 {
+		t.Errorf("set quota failed, quotas[%v]\n", quotas)
+		return
+	}
+
+	reqUrl = fmt.Sprintf("%v%v?name=%v", hostAddr, proto.QuotaList, commonVol.Name)
+	fmt.Println(reqUrl)
+	process(reqUrl, t)
+
+	reqUrl = fmt.Sprintf("%v%v?name=%v&inode=%v&authKey=%v",
+		hostAddr, proto.QuotaDelete, commonVol.Name, quotaId, buildAuthKey("cfs"))
+	fmt.Println(reqUrl)
+	process(reqUrl, t)
+	if quotas = commonVol.getQuotas(); len(quotas) != 0 {
+		t.Errorf("delete quota failed, quotas[%v]\n", quotas)
+	}
+}
+
+func TestNestedQuotaUsage(t *testing.T) {
+	vol := &Vol{
+		quotas: map[uint64]*proto.QuotaInfo{
+			10: {QuotaId: 10, MaxFiles: 5},
+			20: {QuotaId: 20, MaxFiles: 100},
+		},
+		MetaPartitions: map[uint64]*MetaPartition{
+			// the root of quota 20 is in quota 10
+			1: {PartitionID: 1, quotaRoots: []*proto.QuotaRoot{{QuotaId: 10}, {QuotaId: 20, ParentId: 10}},
+				quotaUsage: []*proto.QuotaUsage{{QuotaId: 10, Files: 2}, {QuotaId: 20, Files: 1}}},
+			2: {PartitionID: 2, quotaUsage: []*proto.QuotaUsage{{QuotaId: 20, Files: 3}}},
+		},
+	}
+	index := make(map[uint64]*proto.QuotaInfo)
+	for _, quota := range vol.getQuotas() {
+		index[quota.QuotaId] = quota
+	}
+	if outer := index[10]; outer.UsedFiles != 6 || !outer.Exceeded || outer.ParentId != 0 {
+		t.Errorf("outer quota expect used[6] exceeded, real[%+v]\n", outer)
+	}
+	if inner := index[20]; inner.UsedFiles != 4 || inner.Exceeded || inner.ParentId != 10 {
+		t.Errorf("inner quota expect used[4] parent[10], real[%+v]\n", inner)
+	}
+}
+
+func TestClientSessionAPI(t *testing.T) {
+	var clientID uint64 = 1000
+	reqURL := fmt.Sprintf("%v%v?name=%v&id=%v", hostAddr, proto.ClientSessionRegister, commonVol.Name, clientID)
+	fmt.Println(reqURL)
+	process(reqURL, t)
+
+	reqURL = fmt.Sprintf("%v%v?name=%v&id=%v", hostAddr, proto.ClientSessionHeartbeat, commonVol.Name, clientID)
+	fmt.Println(reqURL)
+	process(reqURL, t)
+
+	reqURL = fmt.Sprintf("%v%v?name=%v", hostAddr, proto.AdminListClients, commonVol.Name)
+	fmt.Println(reqURL)
+	process(reqURL, t)
+	if views := server.cluster.clientSessions.list(commonVol.Name); len(views) != 1 || views[0].ID != clientID {
+		t.Errorf("list client sessions failed, sessions[%v]\n", views)
+		return
+	}
+
+	reqURL = fmt.Sprintf("%v%v?name=%v&id=%v", hostAddr, proto.AdminEvictClient, commonVol.Name, clientID)
+	fmt.Println(reqURL)
+	process(reqURL, t)
+	if views := server.cluster.clientSessions.list(commonVol.Name); len(views) != 0 {
+		t.Errorf("evict client session failed, sessions[%v]\n", views)
+	}
+}
+
+func TestGetVolUsage(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v?name=%v", hostAddr, proto.AdminVolUsage, commonVol.Name)
+	fmt.Println(reqURL)
+	process(reqURL, t)
+	usage := commonVol.getUsage()
+	if usage.MetaPartitions != len(commonVol.MetaPartitions) {
+		t.Errorf("vol usage meta partitions expect[%v],real[%v]\n", len(commonVol.MetaPartitions), usage.MetaPartitions)
+		return
+	}
+	if usage.Dirs != uint64(usage.ReportedPartitions) {
+		t.Errorf("vol usage dirs expect[%v],real[%v]\n", usage.ReportedPartitions, usage.Dirs)
+	}
+}
+
 func TestUpdateToken(t *testing.T) {
 	var tokenType int8
 	for _, token := range commonVol.tokens {
@@ -610,12 +722,144 @@ func TestGetToken(t *testing.T) {
 	}
 }
 
+func TestGetVolAccessToken(t *testing.T) {
+	key := []byte("access-token-key-for-test")
+	server.cluster.accessTokenKey = key
+	defer func() { server.cluster.accessTokenKey = nil }()
+	reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v",
+		hostAddr, proto.ClientVolAccessToken, commonVol.Name, buildAuthKey("cfs"))
+	fmt.Println(reqURL)
+	process(reqURL, t)
+
+	token, err := server.cluster.issueVolAccessToken(commonVol, buildAuthKey("cfs"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.TokenType != proto.ReadWriteToken || token.Verify(key) != nil {
+		t.Errorf("expect a valid read-write token of the owner, but is %v", token)
+	}
+	if token.Verify([]byte("another-access-token-key")) == nil {
+		t.Errorf("expect the token signed with another key is invalid")
+	}
+	if _, err = server.cluster.issueVolAccessToken(commonVol, buildAuthKey("other"), ""); err != proto.ErrVolAuthKeyNotMatch {
+		t.Errorf("expect err[%v], but is %v", proto.ErrVolAuthKeyNotMatch, err)
+	}
+
+	if err = server.cluster.createToken(commonVol, proto.ReadOnlyToken); err != nil {
+		t.Fatal(err)
+	}
+	enableToken := commonVol.enableToken
+	commonVol.enableToken = true
+	defer func() { commonVol.enableToken = enableToken }()
+	for _, volToken := range commonVol.tokens {
+		if volToken.TokenType != proto.ReadOnlyToken {
+			continue
+		}
+		if token, err = server.cluster.issueVolAccessToken(commonVol, "", volToken.Value); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := json.Marshal([]*proto.VolAccessToken{token})
+		access := new(proto.ConnAccess)
+		if err = access.Authenticate(key, data); err != nil {
+			t.Fatal(err)
+		}
+		if err = access.Check(proto.OpStreamRead, commonVol.Name); err != nil {
+			t.Errorf("expect read access of the read-only token, but is %v", err)
+		}
+		if err = access.Check(proto.OpWrite, commonVol.Name); err != proto.ErrNoPermission {
+			t.Errorf("expect no write access of the read-only token, but is %v", err)
+		}
+		if err = access.Check(proto.OpStreamRead, "otherVol"); err != proto.ErrNoPermission {
+			t.Errorf("expect no access to another vol, but is %v", err)
+		}
+		return
+	}
+	t.Errorf("read-only token of vol[%v] not found", commonVol.Name)
+}
+
 func TestClusterStat(t *testing.T) {
 	reqUrl := fmt.Sprintf("%v%v", hostAddr, proto.AdminClusterStat)
 	fmt.Println(reqUrl)
 	process(reqUrl, t)
 }
 
+func TestClusterOverview(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v", hostAddr, proto.AdminClusterOverview)
+	fmt.Println(reqURL)
+	process(reqURL, t)
+
+	ov := server.cluster.overview(server.leaderInfo.addr)
+	var vv *proto.VolOverview
+	for _, v := range ov.Vols {
+		if v.Name == commonVolName {
+			vv = v
+		}
+	}
+	if vv == nil {
+		t.Fatalf("vol[%v] not found in the overview", commonVolName)
+	}
+	if vv.DataPartitions.Total != commonVol.getDataPartitionsCount() || vv.MetaPartitions.Total != len(commonVol.MetaPartitions) {
+		t.Errorf("expect dp[%v] mp[%v] of vol[%v], but is dp[%v] mp[%v]", commonVol.getDataPartitionsCount(),
+			len(commonVol.MetaPartitions), commonVolName, vv.DataPartitions.Total, vv.MetaPartitions.Total)
+	}
+	if ov.DataPartitions.Total < vv.DataPartitions.Total || ov.MetaPartitions.Total < vv.MetaPartitions.Total {
+		t.Errorf("expect the partitions of the cluster include those of vol[%v]", commonVolName)
+	}
+	if len(ov.DataNodes) != server.cluster.dataNodeCount() || len(ov.MetaNodes) != server.cluster.metaNodeCount() {
+		t.Errorf("expect datanodes[%v] metanodes[%v], but is [%v] [%v]", server.cluster.dataNodeCount(),
+			server.cluster.metaNodeCount(), len(ov.DataNodes), len(ov.MetaNodes))
+	}
+}
+
+func TestFaultDomains(t *testing.T) {
+	// register the data node again in another region and rack
+	reqURL := fmt.Sprintf("%v%v?addr=%v&zoneName=%v&region=region1&rack=rack1", hostAddr, proto.AddDataNode, mds1Addr, testZone1)
+	process(reqURL, t)
+	dataNode, err := server.cluster.dataNode(mds1Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.cluster.updateDataNodeTopology(dataNode, "", "")
+	if path := dataNode.GetRackPath(); path != topologyPath("region1", testZone1, "rack1") {
+		t.Errorf("expect dataNode[%v] in rack region1/%v/rack1 but is [%v]", mds1Addr, testZone1, path)
+	}
+
+	reqURL = fmt.Sprintf("%v%v?path=region1/%v", hostAddr, proto.GetFaultDomains, testZone1)
+	reply := process(reqURL, t)
+	data, err := json.Marshal(reply.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var domains []*proto.FaultDomainView
+	if err = json.Unmarshal(data, &domains); err != nil {
+		t.Fatal(err)
+	}
+	if len(domains) != 1 || domains[0].Level != proto.FaultDomainZone || domains[0].DataNodeStat.TotalNodes != 1 {
+		t.Fatalf("expect zone region1/%v with 1 data node but got %v", testZone1, string(data))
+	}
+	rack := domains[0].Children[0]
+	if rack.Name != "rack1" || len(rack.Children) != 1 || rack.Children[0].Name != nodeHost(mds1Addr) ||
+		rack.Children[0].Level != proto.FaultDomainHost {
+		t.Errorf("expect host %v under rack region1/%v/rack1 but got %v", nodeHost(mds1Addr), testZone1, string(data))
+	}
+
+	ov := server.cluster.overview(server.leaderInfo.addr)
+	var dataNodes int
+	for _, region := range ov.FaultDomains {
+		dataNodes += region.DataNodeStat.TotalNodes
+		for _, zone := range region.Children {
+			for _, rack := range zone.Children {
+				if len(rack.Children) != 0 {
+					t.Errorf("expect the fault domains of the overview down to the racks but got hosts under [%v]", rack.Path)
+				}
+			}
+		}
+	}
+	if dataNodes != server.cluster.dataNodeCount() {
+		t.Errorf("expect datanodes[%v] in the fault domains of the overview but is [%v]", server.cluster.dataNodeCount(), dataNodes)
+	}
+}
+
 func TestListVols(t *testing.T) {
 	reqURL := fmt.Sprintf("%v%v?keywords=%v", hostAddr, proto.AdminListVols, commonVolName)
 	fmt.Println(reqURL)
@@ -846,3 +1090,121 @@ func TestListUsersOfVol(t *testing.T) {
 	fmt.Println(reqURL)
 	process(reqURL, t)
 }
+
+func TestAuthorizeUser(t *testing.T) {
+	userInfo, err := server.user.createKey(&proto.UserCreateParam{ID: "authuser", Type: proto.UserTypeNormal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.user.deleteKey("authuser")
+	now := time.Now().Unix()
+	if _, err = server.user.authorize(userInfo.AccessKey, commonVolName, "", now,
+		proto.SignUserAuthorization(userInfo.SecretKey, userInfo.AccessKey, commonVolName, now)); err != proto.ErrNoPermission {
+		t.Errorf("expect err ErrNoPermission without policy, but err is %v", err)
+	}
+	param := &proto.UserPermUpdateParam{UserID: "authuser", Volume: commonVolName, Policy: []string{proto.BuiltinPermissionReadOnly.String()}}
+	if _, err = server.user.updatePolicy(param); err != nil {
+		t.Fatal(err)
+	}
+	reqURL := fmt.Sprintf("%v%v?ak=%v&name=%v&ts=%v&sign=%v", hostAddr, proto.UserAuthorize, userInfo.AccessKey, commonVolName,
+		now, proto.SignUserAuthorization(userInfo.SecretKey, userInfo.AccessKey, commonVolName, now))
+	reply := process(reqURL, t)
+	if reply == nil {
+		return
+	}
+	data, _ := json.Marshal(reply.Data)
+	result := &proto.UserAuthorizeResult{}
+	if err = json.Unmarshal(data, result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Own || !result.Rdonly || result.UserID != "authuser" {
+		t.Errorf("expect read only access of authuser, but is %+v", result)
+	}
+	stale := now - int64(2*proto.UserAuthorizeMaxSkew/time.Second)
+	for _, c := range []struct {
+		sk string
+		ts int64
+	}{{"wrongsecretkey", now}, {userInfo.SecretKey, stale}} {
+		sign := proto.SignUserAuthorization(c.sk, userInfo.AccessKey, commonVolName, c.ts)
+		if _, err = server.user.authorize(userInfo.AccessKey, commonVolName, "", c.ts, sign); err != proto.ErrNoPermission {
+			t.Errorf("expect err ErrNoPermission for ts %v, but err is %v", c.ts, err)
+		}
+	}
+}
+
+func TestZonePlacement(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v", hostAddr, proto.AdminGetZonePlacement)
+	process(reqURL, t)
+	if violation := server.cluster.checkZonePlacement(commonVolName, 1, partitionTypeData, []string{mds1Addr, mds2Addr}); violation == nil {
+		t.Errorf("expect violation of the replicas in zone %v", testZone1)
+	}
+	if violation := server.cluster.checkZonePlacement(commonVolName, 1, partitionTypeData, []string{mds1Addr, mds3Addr}); violation != nil {
+		t.Errorf("expect no violation of the replicas across zones, but is %v", violation)
+	}
+	if violation := server.cluster.checkZonePlacement(commonVolName, 1, partitionTypeMeta, []string{mms3Addr, mms4Addr}); violation == nil {
+		t.Errorf("expect violation of the replicas in zone %v", testZone2)
+	}
+}
+
+func TestSetRebalance(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v?enable=true&metaEnable=true&dryRun=true&maxMigrations=3&threshold=0.2", hostAddr, proto.AdminSetRebalance)
+	process(reqURL, t)
+	cfg := server.cluster.rebalance.getConfig()
+	if !cfg.enable || !cfg.metaEnable || !cfg.dryRun || cfg.maxMigrations != 3 || cfg.threshold != 0.2 {
+		t.Errorf("unexpected rebalance config %+v", cfg)
+		return
+	}
+	server.cluster.checkRebalance()
+	for _, migration := range server.cluster.rebalance.view().Migrations {
+		if !migration.DryRun {
+			t.Errorf("expect dry run migration, but is %v", migration)
+		}
+	}
+	reqURL = fmt.Sprintf("%v%v", hostAddr, proto.AdminGetRebalance)
+	process(reqURL, t)
+	reqURL = fmt.Sprintf("%v%v?enable=false&metaEnable=false", hostAddr, proto.AdminSetRebalance)
+	process(reqURL, t)
+	if cfg = server.cluster.rebalance.getConfig(); cfg.enable || cfg.metaEnable {
+		t.Errorf("expect rebalance disabled")
+	}
+}
+
+func TestGetRaftStatus(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v", hostAddr, proto.AdminGetRaftStatus)
+	fmt.Println(reqURL)
+	resp, err := http.Get(reqURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if leader := resp.Header.Get(proto.MasterLeader); leader != server.leaderInfo.addr {
+		t.Errorf("leader header expect[%v],real[%v]", server.leaderInfo.addr, leader)
+	}
+	process(reqURL, t)
+
+	rs := server.raftStatus()
+	if !rs.IsLeader || rs.LeaderID != server.id || rs.LeaderAddr != server.leaderInfo.addr {
+		t.Errorf("raft status of the leader: %+v", rs)
+	}
+}
+
+func TestClusterUpgrade(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v?enable=true", hostAddr, proto.AdminClusterUpgrade)
+	process(reqURL, t)
+	if !server.cluster.Upgrading {
+		t.Errorf("cluster should be upgrading")
+	}
+	reqURL = fmt.Sprintf("%v%v", hostAddr, proto.AdminUpgradeStatus)
+	process(reqURL, t)
+	status := server.cluster.upgradeStatus()
+	if !status.Upgrading || len(status.DataNodes) == 0 || status.MasterVersion != proto.Version {
+		t.Errorf("upgrade status: %+v", status)
+	}
+	reqURL = fmt.Sprintf("%v%v?addr=%v", hostAddr, proto.TransferDataNodeLeaders, mds1Addr)
+	process(reqURL, t)
+	reqURL = fmt.Sprintf("%v%v?enable=false", hostAddr, proto.AdminClusterUpgrade)
+	process(reqURL, t)
+	if server.cluster.Upgrading {
+		t.Errorf("cluster should not be upgrading")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util/errors"
@@ -259,6 +260,36 @@ func (m *Server) getUsersOfVol(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(users))
 }
 
+func (m *Server) authorizeUser(w http.ResponseWriter, r *http.Request) {
+	var (
+		ak, volName, sign string
+		timestamp         int64
+		result            *proto.UserAuthorizeResult
+		err               error
+	)
+	if ak, err = parseAccessKey(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if volName, err = extractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if timestamp, err = strconv.ParseInt(r.FormValue(timestampKey), 10, 64); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: keyNotFound(timestampKey).Error()})
+		return
+	}
+	if sign = r.FormValue(signKey); sign == "" {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: keyNotFound(signKey).Error()})
+		return
+	}
+	if result, err = m.user.authorize(ak, volName, r.FormValue(subdirKey), timestamp, sign); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(result))
+}
+
 func parseUser(r *http.Request) (userID string, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
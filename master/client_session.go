@@ -0,0 +1,310 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// clientSession is the session of a client mounting a volume.
+type clientSession struct {
+	id            uint64
+	volume        string
+	report        proto.ClientSessionReport
+	registerTime  time.Time
+	lastHeartbeat time.Time
+	opRates       map[string]float64
+}
+
+func (s *clientSession) view(lease time.Duration) *proto.ClientSessionView {
+	return &proto.ClientSessionView{
+		ID:            s.id,
+		Volume:        s.volume,
+		Host:          s.report.Host,
+		Version:       s.report.Version,
+		MountPoint:    s.report.MountPoint,
+		MountOptions:  s.report.MountOptions,
+		MountTime:     s.report.MountTime,
+		RegisterTime:  s.registerTime.Unix(),
+		LastHeartbeat: s.lastHeartbeat.Unix(),
+		Lease:         int64(lease / time.Second),
+		Capabilities:  proto.ClientCapRead | proto.ClientCapWrite,
+		OpRates:       s.opRates,
+	}
+}
+
+// update takes the report of the client, and calculates the op rates since the last report.
+func (s *clientSession) update(report *proto.ClientSessionReport, now time.Time) {
+	if elapsed := now.Sub(s.lastHeartbeat).Seconds(); elapsed > 0 && s.report.Ops != nil && report.Ops != nil {
+		rates := make(map[string]float64, len(report.Ops))
+		for op, count := range report.Ops {
+			if last := s.report.Ops[op]; count > last {
+				rates[op] = float64(count-last) / elapsed
+			}
+		}
+		s.opRates = rates
+	}
+	if report.Host == "" {
+		report.Host = s.report.Host
+	}
+	s.report = *report
+	s.lastHeartbeat = now
+}
+
+// clientSessionManager keeps the sessions of the clients, which expire once the clients stop the heartbeats
+// for the lease. The clients whose sessions expired or were evicted are dead, and they are sent to the meta nodes
+// with the heartbeats for a while, so that the locks and the orphan inodes held by them are released.
+// A dead client can not register again until it is forgotten.
+// The sessions only live in the memory of the master leader, and the clients register them again after
+// the leader changes.
+type clientSessionManager struct {
+	sync.RWMutex
+	lease    time.Duration
+	sessions map[uint64]*clientSession
+	dead     map[uint64]time.Time // the time the client died
+}
+
+func newClientSessionManager() *clientSessionManager {
+	return &clientSessionManager{
+		lease:    defaultClientSessionLease,
+		sessions: make(map[uint64]*clientSession),
+		dead:     make(map[uint64]time.Time),
+	}
+}
+
+func (m *clientSessionManager) register(id uint64, volume string, report *proto.ClientSessionReport) (view *proto.ClientSessionView, err error) {
+	m.Lock()
+	defer m.Unlock()
+	if _, ok := m.dead[id]; ok {
+		return nil, proto.ErrClientSessionExpired
+	}
+	now := time.Now()
+	s, ok := m.sessions[id]
+	if !ok || s.volume != volume {
+		s = &clientSession{id: id, volume: volume, registerTime: now}
+		m.sessions[id] = s
+	}
+	s.update(report, now)
+	return s.view(m.lease), nil
+}
+
+func (m *clientSessionManager) heartbeat(id uint64, volume string, report *proto.ClientSessionReport) (view *proto.ClientSessionView, err error) {
+	m.Lock()
+	defer m.Unlock()
+	if _, ok := m.dead[id]; ok {
+		return nil, proto.ErrClientSessionExpired
+	}
+	s, ok := m.sessions[id]
+	if !ok || s.volume != volume {
+		return nil, proto.ErrClientSessionNotExists
+	}
+	s.update(report, time.Now())
+	return s.view(m.lease), nil
+}
+
+func (m *clientSessionManager) evict(id uint64, volume string) (err error) {
+	m.Lock()
+	defer m.Unlock()
+	s, ok := m.sessions[id]
+	if !ok || s.volume != volume {
+		return proto.ErrClientSessionNotExists
+	}
+	delete(m.sessions, id)
+	m.dead[id] = time.Now()
+	return
+}
+
+func (m *clientSessionManager) list(volume string) (views []*proto.ClientSessionView) {
+	m.RLock()
+	defer m.RUnlock()
+	views = make([]*proto.ClientSessionView, 0)
+	for _, s := range m.sessions {
+		if s.volume == volume {
+			views = append(views, s.view(m.lease))
+		}
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].RegisterTime < views[j].RegisterTime })
+	return
+}
+
+// rename moves the sessions of the renamed volume to its name.
+func (m *clientSessionManager) rename(volume, newVolume string) {
+	m.Lock()
+	defer m.Unlock()
+	for _, s := range m.sessions {
+		if s.volume == volume {
+			s.volume = newVolume
+		}
+	}
+}
+
+// check expires the sessions without the heartbeats for the lease, and forgets the clients dead for long.
+func (m *clientSessionManager) check(now time.Time) {
+	m.Lock()
+	defer m.Unlock()
+	for id, s := range m.sessions {
+		if now.Sub(s.lastHeartbeat) > m.lease {
+			log.LogWarnf("action[checkClientSessions] client[%v] vol[%v] host[%v] expired, last heartbeat[%v]",
+				id, s.volume, s.report.Host, s.lastHeartbeat.Format(proto.TimeFormat))
+			delete(m.sessions, id)
+			m.dead[id] = now
+		}
+	}
+	for id, t := range m.dead {
+		if now.Sub(t) > defaultClientDeadKeepTime {
+			delete(m.dead, id)
+		}
+	}
+}
+
+func (m *clientSessionManager) deadClients() (ids []uint64) {
+	m.RLock()
+	defer m.RUnlock()
+	if len(m.dead) == 0 {
+		return
+	}
+	ids = make([]uint64, 0, len(m.dead))
+	for id := range m.dead {
+		ids = append(ids, id)
+	}
+	return
+}
+
+func (c *Cluster) scheduleToCheckClientSessions() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.clientSessions.check(time.Now())
+			}
+			time.Sleep(defaultIntervalToCheckClientSessions)
+		}
+	}()
+}
+
+func (m *Server) registerClientSession(w http.ResponseWriter, r *http.Request) {
+	name, id, report, err := parseClientSessionReport(r)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	var vol *Vol
+	if vol, err = m.cluster.getVol(name); err != nil || vol.status() == markDelete {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	name = vol.Name
+	if report.Host == "" {
+		report.Host = strings.Split(r.RemoteAddr, ":")[0]
+	}
+	view, err := m.cluster.clientSessions.register(id, name, report)
+	if err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	m.cluster.grantCapabilities(view)
+	log.LogInfof("action[registerClientSession] client[%v] vol[%v] host[%v] version[%v] mountPoint[%v]",
+		id, name, report.Host, report.Version, report.MountPoint)
+	sendOkReply(w, r, newSuccessHTTPReply(view))
+}
+
+func (m *Server) clientSessionHeartbeat(w http.ResponseWriter, r *http.Request) {
+	name, id, report, err := parseClientSessionReport(r)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	view, err := m.cluster.clientSessions.heartbeat(id, m.cluster.volName(name), report)
+	if err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	m.cluster.grantCapabilities(view)
+	sendOkReply(w, r, newSuccessHTTPReply(view))
+}
+
+func (m *Server) listClientSessions(w http.ResponseWriter, r *http.Request) {
+	name, err := parseAndExtractName(r)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	var vol *Vol
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	views := m.cluster.clientSessions.list(vol.Name)
+	for _, view := range views {
+		m.cluster.grantCapabilities(view)
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(views))
+}
+
+func (m *Server) evictClientSession(w http.ResponseWriter, r *http.Request) {
+	name, id, err := parseClientSessionPara(r)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.clientSessions.evict(id, m.cluster.volName(name)); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf("evict client[%v] of vol[%v] successfully,from[%v]", id, name, r.RemoteAddr)
+	log.LogWarn(msg)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+// parseClientSessionReport parses the session of the client, and the report of the client in the body if any.
+func parseClientSessionReport(r *http.Request) (name string, id uint64, report *proto.ClientSessionReport, err error) {
+	if name, id, err = parseClientSessionPara(r); err != nil {
+		return
+	}
+	var body []byte
+	if body, err = ioutil.ReadAll(r.Body); err != nil {
+		return
+	}
+	report = &proto.ClientSessionReport{}
+	if len(body) > 0 {
+		err = json.Unmarshal(body, report)
+	}
+	return
+}
+
+func parseClientSessionPara(r *http.Request) (name string, id uint64, err error) {
+	if name, err = parseAndExtractName(r); err != nil {
+		return
+	}
+	value := r.FormValue(idKey)
+	if value == "" {
+		err = keyNotFound(idKey)
+		return
+	}
+	if id, err = strconv.ParseUint(value, 10, 64); err != nil || id == 0 {
+		err = unmatchedKey(idKey)
+	}
+	return
+}
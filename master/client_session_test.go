@@ -0,0 +1,94 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestClientSessionExpiry(t *testing.T) {
+	m := newClientSessionManager()
+	if _, err := m.register(1, "vol", &proto.ClientSessionReport{Host: "192.168.0.1"}); err != nil {
+		t.Fatalf("register: err(%v)", err)
+	}
+	if _, err := m.register(2, "vol", &proto.ClientSessionReport{Host: "192.168.0.2"}); err != nil {
+		t.Fatalf("register: err(%v)", err)
+	}
+	if _, err := m.heartbeat(1, "other", &proto.ClientSessionReport{}); err != proto.ErrClientSessionNotExists {
+		t.Errorf("heartbeat of another volume: expect err(%v) but got(%v)", proto.ErrClientSessionNotExists, err)
+	}
+	if views := m.list("vol"); len(views) != 2 {
+		t.Errorf("expect 2 sessions but got(%v)", len(views))
+	}
+
+	// the op rates are computed from the counts of two heartbeats
+	m.sessions[1].report.Ops = map[string]uint64{"read": 100}
+	m.sessions[1].lastHeartbeat = time.Now().Add(-10 * time.Second)
+	report := &proto.ClientSessionReport{Version: "v1", Ops: map[string]uint64{"read": 300}}
+	view, err := m.heartbeat(1, "vol", report)
+	if err != nil {
+		t.Fatalf("heartbeat: err(%v)", err)
+	}
+	if rate := view.OpRates["read"]; rate < 19 || rate > 21 {
+		t.Errorf("expect the read rate about 20 but got(%v)", rate)
+	}
+	if view.Host != "192.168.0.1" || view.Version != "v1" {
+		t.Errorf("expect the host kept and the version reported but got(%v)", view)
+	}
+
+	// the session of client 2 expires without the heartbeats
+	m.sessions[2].lastHeartbeat = time.Now().Add(-2 * m.lease)
+	m.check(time.Now())
+	if views := m.list("vol"); len(views) != 1 || views[0].ID != 1 {
+		t.Errorf("expect the session of client 1 only but got(%v)", views)
+	}
+	if dead := m.deadClients(); len(dead) != 1 || dead[0] != 2 {
+		t.Errorf("expect client 2 dead but got(%v)", dead)
+	}
+	if _, err := m.heartbeat(2, "vol", &proto.ClientSessionReport{}); err != proto.ErrClientSessionExpired {
+		t.Errorf("heartbeat of the expired session: expect err(%v) but got(%v)", proto.ErrClientSessionExpired, err)
+	}
+	if _, err := m.register(2, "vol", &proto.ClientSessionReport{Host: "192.168.0.2"}); err != proto.ErrClientSessionExpired {
+		t.Errorf("register of the dead client: expect err(%v) but got(%v)", proto.ErrClientSessionExpired, err)
+	}
+
+	// the dead client registers again once it is forgotten, when client 1 expires too
+	m.check(time.Now().Add(defaultClientDeadKeepTime + time.Second))
+	if dead := m.deadClients(); len(dead) != 1 || dead[0] != 1 {
+		t.Errorf("expect client 1 dead but got(%v)", dead)
+	}
+	if _, err := m.register(2, "vol", &proto.ClientSessionReport{Host: "192.168.0.2"}); err != nil {
+		t.Errorf("register again: err(%v)", err)
+	}
+}
+
+func TestEvictClientSession(t *testing.T) {
+	m := newClientSessionManager()
+	if _, err := m.register(1, "vol", &proto.ClientSessionReport{Host: "192.168.0.1"}); err != nil {
+		t.Fatalf("register: err(%v)", err)
+	}
+	if err := m.evict(1, "other"); err != proto.ErrClientSessionNotExists {
+		t.Errorf("evict from another volume: expect err(%v) but got(%v)", proto.ErrClientSessionNotExists, err)
+	}
+	if err := m.evict(1, "vol"); err != nil {
+		t.Fatalf("evict: err(%v)", err)
+	}
+	if _, err := m.heartbeat(1, "vol", &proto.ClientSessionReport{}); err != proto.ErrClientSessionExpired {
+		t.Errorf("heartbeat of the evicted session: expect err(%v) but got(%v)", proto.ErrClientSessionExpired, err)
+	}
+}
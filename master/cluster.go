@@ -31,6 +31,7 @@ import (
 type Cluster struct {
 	Name                      string
 	vols                      map[string]*Vol
+	volAliases                map[string]string // key: former name of a renamed volume, value: its name
 	dataNodes                 sync.Map
 	metaNodes                 sync.Map
 	dpMutex                   sync.Mutex   // data partition mutex
@@ -50,11 +51,19 @@ type Cluster struct {
 	BadDataPartitionIds       *sync.Map
 	BadMetaPartitionIds       *sync.Map
 	DisableAutoAllocate       bool
+	Upgrading                 bool // the cluster is in a rolling upgrade, see setUpgrading
 	fsm                       *MetadataFsm
 	partition                 raftstore.Partition
 	MasterSecretKey           []byte
 	lastMasterZoneForDataNode string
 	lastMasterZoneForMetaNode string
+	rebalance                 *rebalancer
+	decommissions             sync.Map   // key: decommissionKey, value: *nodeDecommission
+	keyManager                keyManager // nil if the encryption of volumes is not enabled
+	accessTokenKey            []byte     // nil if the access tokens are not enabled
+	transferringLeaders       int32      // 1 while the leaders of a node are being transferred
+	clientSessions            *clientSessionManager
+	lastAuditRecordID         uint64 // the ID of the last record added to the audit log
 }
 
 func newCluster(name string, leaderInfo *LeaderInfo, fsm *MetadataFsm, partition raftstore.Partition, cfg *clusterConfig) (c *Cluster) {
@@ -62,6 +71,7 @@ func newCluster(name string, leaderInfo *LeaderInfo, fsm *MetadataFsm, partition
 	c.Name = name
 	c.leaderInfo = leaderInfo
 	c.vols = make(map[string]*Vol, 0)
+	c.volAliases = make(map[string]string)
 	c.cfg = cfg
 	c.t = newTopology()
 	c.BadDataPartitionIds = new(sync.Map)
@@ -72,6 +82,8 @@ func newCluster(name string, leaderInfo *LeaderInfo, fsm *MetadataFsm, partition
 	c.fsm = fsm
 	c.partition = partition
 	c.idAlloc = newIDAllocator(c.fsm.store, c.partition)
+	c.rebalance = newRebalancer()
+	c.clientSessions = newClientSessionManager()
 	return
 }
 
@@ -88,6 +100,10 @@ func (c *Cluster) scheduleTask() {
 	c.scheduleToCheckMetaPartitionRecoveryProgress()
 	c.scheduleToLoadMetaPartitions()
 	c.scheduleToReduceReplicaNum()
+	c.scheduleToCheckRebalance()
+	c.scheduleToCheckTiering()
+	c.scheduleToCheckClientSessions()
+	c.scheduleToTrimAuditRecords()
 }
 
 func (c *Cluster) masterAddr() (addr string) {
@@ -112,7 +128,7 @@ func (c *Cluster) scheduleToCheckAutoDataPartitionCreation() {
 		// check volumes after switching leader two minutes
 		time.Sleep(2 * time.Minute)
 		for {
-			if c.partition != nil && c.partition.IsRaftLeader() {
+			if c.partition != nil && c.partition.IsRaftLeader() && !c.Upgrading {
 				vols := c.copyVols()
 				for _, vol := range vols {
 					vol.checkAutoDataPartitionCreation(c)
@@ -248,10 +264,12 @@ func (c *Cluster) checkLeaderAddr() {
 
 func (c *Cluster) checkDataNodeHeartbeat() {
 	tasks := make([]*proto.AdminTask, 0)
+	readOnlyVols := c.readOnlyVols()
+	renamedVols := c.renamedVols()
 	c.dataNodes.Range(func(addr, dataNode interface{}) bool {
 		node := dataNode.(*DataNode)
 		node.checkLiveness()
-		task := node.createHeartbeatTask(c.masterAddr())
+		task := node.createHeartbeatTask(c.masterAddr(), readOnlyVols, renamedVols)
 		tasks = append(tasks, task)
 		return true
 	})
@@ -260,16 +278,31 @@ func (c *Cluster) checkDataNodeHeartbeat() {
 
 func (c *Cluster) checkMetaNodeHeartbeat() {
 	tasks := make([]*proto.AdminTask, 0)
+	deadClients := c.clientSessions.deadClients()
+	readOnlyVols := c.readOnlyVols()
+	renamedVols := c.renamedVols()
+	permVols := c.permVols()
+	quotas := c.volQuotas()
 	c.metaNodes.Range(func(addr, metaNode interface{}) bool {
 		node := metaNode.(*MetaNode)
 		node.checkHeartbeat()
-		task := node.createHeartbeatTask(c.masterAddr())
+		task := node.createHeartbeatTask(c.masterAddr(), deadClients, readOnlyVols, renamedVols, permVols, quotas)
 		tasks = append(tasks, task)
 		return true
 	})
 	c.addMetaNodeTasks(tasks)
 }
 
+// permVols returns the names of the volumes enforcing the permission checks of the meta nodes.
+func (c *Cluster) permVols() (names []string) {
+	for name, vol := range c.allVols() {
+		if vol.enforcePerm {
+			names = append(names, name)
+		}
+	}
+	return
+}
+
 func (c *Cluster) scheduleToCheckMetaPartitions() {
 	go func() {
 		for {
@@ -298,7 +331,7 @@ func (c *Cluster) checkMetaPartitions() {
 func (c *Cluster) scheduleToReduceReplicaNum() {
 	go func() {
 		for {
-			if c.partition != nil && c.partition.IsRaftLeader() {
+			if c.partition != nil && c.partition.IsRaftLeader() && !c.Upgrading {
 				c.checkVolReduceReplicaNum()
 			}
 			time.Sleep(5 * time.Minute)
@@ -430,15 +463,17 @@ func (c *Cluster) updateMetaNodeBaseInfo(nodeAddr string, id uint64) (err error)
 	return
 }
 
-func (c *Cluster) addMetaNode(nodeAddr, zoneName string) (id uint64, err error) {
+func (c *Cluster) addMetaNode(nodeAddr, zoneName, region, rack string) (id uint64, err error) {
 	c.mnMutex.Lock()
 	defer c.mnMutex.Unlock()
 	var metaNode *MetaNode
 	if value, ok := c.metaNodes.Load(nodeAddr); ok {
 		metaNode = value.(*MetaNode)
+		c.updateMetaNodeTopology(metaNode, region, rack)
 		return metaNode.ID, nil
 	}
 	metaNode = newMetaNode(nodeAddr, zoneName, c.Name)
+	metaNode.Region, metaNode.Rack = nodeTopology(region, rack)
 	zone, err := c.t.getZone(zoneName)
 	if err != nil {
 		zone = c.t.putZoneIfAbsent(newZone(zoneName))
@@ -473,16 +508,18 @@ errHandler:
 	return
 }
 
-func (c *Cluster) addDataNode(nodeAddr, zoneName string) (id uint64, err error) {
+func (c *Cluster) addDataNode(nodeAddr, zoneName, region, rack string) (id uint64, err error) {
 	c.dnMutex.Lock()
 	defer c.dnMutex.Unlock()
 	var dataNode *DataNode
 	if node, ok := c.dataNodes.Load(nodeAddr); ok {
 		dataNode = node.(*DataNode)
+		c.updateDataNodeTopology(dataNode, region, rack)
 		return dataNode.ID, nil
 	}
 
 	dataNode = newDataNode(nodeAddr, zoneName, c.Name)
+	dataNode.Region, dataNode.Rack = nodeTopology(region, rack)
 	zone, err := c.t.getZone(zoneName)
 	if err != nil {
 		zone = c.t.putZoneIfAbsent(newZone(zoneName))
@@ -568,6 +605,17 @@ func (c *Cluster) checkLackReplicaDataPartitions() (lackReplicaDataPartitions []
 	return
 }
 
+// checkCorruptExtentDataPartitions returns the data partitions which have corrupt extents
+// that the scrubbers failed to repair from the other replicas.
+func (c *Cluster) checkCorruptExtentDataPartitions() (partitions []*DataPartition) {
+	partitions = make([]*DataPartition, 0)
+	vols := c.copyVols()
+	for _, vol := range vols {
+		partitions = append(partitions, vol.dataPartitions.checkCorruptExtentDataPartitions()...)
+	}
+	return
+}
+
 func (c *Cluster) getDataPartitionByID(partitionID uint64) (dp *DataPartition, err error) {
 	vols := c.copyVols()
 	for _, vol := range vols {
@@ -595,6 +643,9 @@ func (c *Cluster) putVol(vol *Vol) {
 	defer c.volMutex.Unlock()
 	if _, ok := c.vols[vol.Name]; !ok {
 		c.vols[vol.Name] = vol
+		for _, alias := range vol.aliases {
+			c.volAliases[alias] = vol.Name
+		}
 	}
 }
 
@@ -602,6 +653,11 @@ func (c *Cluster) getVol(volName string) (vol *Vol, err error) {
 	c.volMutex.RLock()
 	defer c.volMutex.RUnlock()
 	vol, ok := c.vols[volName]
+	if !ok {
+		if name, renamed := c.volAliases[volName]; renamed {
+			vol, ok = c.vols[name]
+		}
+	}
 	if !ok {
 		err = proto.ErrVolNotExists
 	}
@@ -612,10 +668,17 @@ func (c *Cluster) deleteVol(name string) {
 	c.volMutex.Lock()
 	defer c.volMutex.Unlock()
 	delete(c.vols, name)
+	for alias, volName := range c.volAliases {
+		if volName == name {
+			delete(c.volAliases, alias)
+		}
+	}
 	return
 }
 
-func (c *Cluster) markDeleteVol(name, authKey string) (err error) {
+// markDeleteVol marks the volume deleted, which is purged after the deletion delay and can be restored before it.
+// The volume is purged at once if force is set.
+func (c *Cluster) markDeleteVol(name, authKey string, force bool) (err error) {
 	var (
 		vol           *Vol
 		serverAuthKey string
@@ -629,9 +692,17 @@ func (c *Cluster) markDeleteVol(name, authKey string) (err error) {
 		return proto.ErrVolAuthKeyNotMatch
 	}
 
+	vol.Lock()
+	defer vol.Unlock()
+	oldPurgeTime := vol.purgeTime
 	vol.Status = markDelete
+	vol.purgeTime = time.Now().Unix()
+	if !force {
+		vol.purgeTime += int64(c.cfg.volDeletionDelayHours) * 3600
+	}
 	if err = c.syncUpdateVol(vol); err != nil {
 		vol.Status = normal
+		vol.purgeTime = oldPurgeTime
 		return proto.ErrPersistenceByRaft
 	}
 	return
@@ -686,6 +757,10 @@ func (c *Cluster) createDataPartition(volName string, zoneNum int) (dp *DataPart
 	dp = newDataPartition(partitionID, vol.dpReplicaNum, volName, vol.ID)
 	dp.Hosts = targetHosts
 	dp.Peers = targetPeers
+	if hotMedia, _, _, ok := vol.tiering(); ok {
+		dp.mediaType = hotMedia
+		dp.tierTime = time.Now().Unix()
+	}
 	for _, host := range targetHosts {
 		wg.Add(1)
 		go func(host string) {
@@ -743,7 +818,12 @@ errHandler:
 }
 
 func (c *Cluster) syncCreateDataPartitionToDataNode(host string, size uint64, dp *DataPartition, peers []proto.Peer, hosts []string, createType int) (diskPath string, err error) {
-	task := dp.createTaskToCreateDataPartition(host, size, peers, hosts, createType)
+	var encrypted, cache bool
+	if vol, err := c.getVol(dp.VolName); err == nil {
+		encrypted = vol.encrypted()
+		cache = vol.cache
+	}
+	task := dp.createTaskToCreateDataPartition(host, size, peers, hosts, createType, encrypted, cache)
 	dataNode, err := c.dataNode(host)
 	if err != nil {
 		return
@@ -1124,6 +1204,27 @@ errHandler:
 	return
 }
 
+// moveDataReplica moves the replica of the data partition on the offline address to the new address,
+// the new replica is recovered in background like a decommissioned one.
+func (c *Cluster) moveDataReplica(dp *DataPartition, offlineAddr, newAddr string) (err error) {
+	dp.RLock()
+	replica, _ := dp.getReplica(offlineAddr)
+	dp.RUnlock()
+	if err = c.removeDataReplica(dp, offlineAddr, false); err != nil {
+		return
+	}
+	if err = c.addDataReplica(dp, newAddr); err != nil {
+		return
+	}
+	dp.Status = proto.ReadOnly
+	dp.isRecover = true
+	c.putBadDataPartitionIDs(replica, offlineAddr, dp.PartitionID)
+	dp.RLock()
+	c.syncUpdateDataPartition(dp)
+	dp.RUnlock()
+	return
+}
+
 func (c *Cluster) validateDecommissionDataPartition(dp *DataPartition, offlineAddr string) (err error) {
 	dp.RLock()
 	defer dp.RUnlock()
@@ -1132,7 +1233,7 @@ func (c *Cluster) validateDecommissionDataPartition(dp *DataPartition, offlineAd
 		return
 	}
 
-	if err = dp.hasMissingOneReplica(int(vol.dpReplicaNum)); err != nil {
+	if err = dp.hasMissingOneReplica(int(dp.ReplicaNum)); err != nil {
 		return
 	}
 
@@ -1500,18 +1601,26 @@ func (c *Cluster) deleteMetaNodeFromCache(metaNode *MetaNode) {
 
 func (c *Cluster) updateVol(name, authKey string, newArgs *VolVarargs) (err error) {
 	var (
-		vol               *Vol
-		serverAuthKey     string
-		oldDpReplicaNum   uint8
-		oldCapacity       uint64
-		oldFollowerRead   bool
-		oldAuthenticate   bool
-		oldEnableToken    bool
-		oldZoneName       string
-		oldDescription    string
-		oldDpSelectorName string
-		oldDpSelectorParm string
-		volUsedSpace      uint64
+		vol                *Vol
+		serverAuthKey      string
+		oldDpReplicaNum    uint8
+		oldCapacity        uint64
+		oldFollowerRead    bool
+		oldAuthenticate    bool
+		oldEnableToken     bool
+		oldZoneName        string
+		oldDescription     string
+		oldDpSelectorName  string
+		oldDpSelectorParm  string
+		oldTrashDays       uint32
+		oldHotMedia        string
+		oldColdMedia       string
+		oldColdDays        uint32
+		oldCaseInsensitive bool
+		oldEnforcePerm     bool
+		oldSmallFileSize   uint32
+		oldPermPriority    string
+		volUsedSpace       uint64
 	)
 	if vol, err = c.getVol(name); err != nil {
 		log.LogErrorf("action[updateVol] err[%v]", err)
@@ -1564,6 +1673,14 @@ func (c *Cluster) updateVol(name, authKey string, newArgs *VolVarargs) (err erro
 	oldDescription = vol.description
 	oldDpSelectorName = vol.dpSelectorName
 	oldDpSelectorParm = vol.dpSelectorParm
+	oldTrashDays = vol.trashDays
+	oldHotMedia = vol.hotMedia
+	oldColdMedia = vol.coldMedia
+	oldColdDays = vol.coldDays
+	oldCaseInsensitive = vol.caseInsensitive
+	oldEnforcePerm = vol.enforcePerm
+	oldSmallFileSize = vol.smallFileSize
+	oldPermPriority = vol.permissionPriority
 
 	vol.zoneName = newArgs.zoneName
 	vol.Capacity = newArgs.capacity
@@ -1579,6 +1696,14 @@ func (c *Cluster) updateVol(name, authKey string, newArgs *VolVarargs) (err erro
 	}
 	vol.dpSelectorName = newArgs.dpSelectorName
 	vol.dpSelectorParm = newArgs.dpSelectorParm
+	vol.trashDays = newArgs.trashDays
+	vol.hotMedia = newArgs.hotMedia
+	vol.coldMedia = newArgs.coldMedia
+	vol.coldDays = newArgs.coldDays
+	vol.caseInsensitive = newArgs.caseInsensitive
+	vol.enforcePerm = newArgs.enforcePerm
+	vol.smallFileSize = newArgs.smallFileSize
+	vol.permissionPriority = newArgs.permPriority
 
 	if err = c.syncUpdateVol(vol); err != nil {
 		vol.Capacity = oldCapacity
@@ -1590,11 +1715,23 @@ func (c *Cluster) updateVol(name, authKey string, newArgs *VolVarargs) (err erro
 		vol.description = oldDescription
 		vol.dpSelectorName = oldDpSelectorName
 		vol.dpSelectorParm = oldDpSelectorParm
+		vol.trashDays = oldTrashDays
+		vol.hotMedia = oldHotMedia
+		vol.coldMedia = oldColdMedia
+		vol.coldDays = oldColdDays
+		vol.caseInsensitive = oldCaseInsensitive
+		vol.enforcePerm = oldEnforcePerm
+		vol.smallFileSize = oldSmallFileSize
+		vol.permissionPriority = oldPermPriority
 
 		log.LogErrorf("action[updateVol] vol[%v] err[%v]", name, err)
 		err = proto.ErrPersistenceByRaft
 		goto errHandler
 	}
+	if vol.enforcePerm != oldEnforcePerm {
+		// tell the meta nodes at once instead of waiting for the next heartbeats
+		go c.checkMetaNodeHeartbeat()
+	}
 	return
 errHandler:
 	err = fmt.Errorf("action[updateVol], clusterID[%v] name:%v, err:%v ", c.Name, name, err.Error())
@@ -1605,7 +1742,7 @@ errHandler:
 
 // Create a new volume.
 // By default we create 3 meta partitions and 10 data partitions during initialization.
-func (c *Cluster) createVol(name, owner, zoneName, description string, mpCount, dpReplicaNum, size, capacity int, followerRead, authenticate, crossZone, enableToken bool) (vol *Vol, err error) {
+func (c *Cluster) createVol(name, owner, zoneName, description string, mpCount, dpReplicaNum, size, capacity int, followerRead, authenticate, crossZone, enableToken, encrypt, flat, cache bool) (vol *Vol, err error) {
 	var (
 		dataPartitionSize       uint64
 		readWriteDataPartitions int
@@ -1629,7 +1766,7 @@ func (c *Cluster) createVol(name, owner, zoneName, description string, mpCount,
 	} else if !crossZone {
 		zoneName = DefaultZoneName
 	}
-	if vol, err = c.doCreateVol(name, owner, zoneName, description, dataPartitionSize, uint64(capacity), dpReplicaNum, followerRead, authenticate, crossZone, enableToken); err != nil {
+	if vol, err = c.doCreateVol(name, owner, zoneName, description, dataPartitionSize, uint64(capacity), dpReplicaNum, followerRead, authenticate, crossZone, enableToken, encrypt, flat, cache); err != nil {
 		goto errHandler
 	}
 	if err = vol.initMetaPartitions(c, mpCount); err != nil {
@@ -1657,7 +1794,7 @@ errHandler:
 	return
 }
 
-func (c *Cluster) doCreateVol(name, owner, zoneName, description string, dpSize, capacity uint64, dpReplicaNum int, followerRead, authenticate, crossZone, enableToken bool) (vol *Vol, err error) {
+func (c *Cluster) doCreateVol(name, owner, zoneName, description string, dpSize, capacity uint64, dpReplicaNum int, followerRead, authenticate, crossZone, enableToken, encrypt, flat, cache bool) (vol *Vol, err error) {
 	var id uint64
 	c.createVolMutex.Lock()
 	defer c.createVolMutex.Unlock()
@@ -1673,6 +1810,13 @@ func (c *Cluster) doCreateVol(name, owner, zoneName, description string, dpSize,
 	vol = newVol(id, name, owner, zoneName, dpSize, capacity, uint8(dpReplicaNum), defaultReplicaNum, followerRead, authenticate, crossZone, enableToken, createTime, description)
 	// refresh oss secure
 	vol.refreshOSSSecure()
+	vol.flat = flat
+	vol.cache = cache
+	if encrypt {
+		if err = c.generateVolDataKey(vol); err != nil {
+			goto errHandler
+		}
+	}
 	if err = c.syncAddVol(vol); err != nil {
 		goto errHandler
 	}
@@ -1958,6 +2102,66 @@ func (c *Cluster) setDataNodeAutoRepairLimitRate(val uint64) (err error) {
 	return
 }
 
+// setDataNodeWriteLimitRate sets one of the write throttles of the data nodes pointed by limitRate.
+func (c *Cluster) setDataNodeWriteLimitRate(limitRate *uint64, val uint64) (err error) {
+	oldVal := atomic.LoadUint64(limitRate)
+	atomic.StoreUint64(limitRate, val)
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setDataNodeWriteLimitRate] err[%v]", err)
+		atomic.StoreUint64(limitRate, oldVal)
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
+
+func (c *Cluster) setClusterDeleteLimitRate(val uint64) (err error) {
+	oldVal := atomic.LoadUint64(&c.cfg.ClusterDeleteLimitRate)
+	atomic.StoreUint64(&c.cfg.ClusterDeleteLimitRate, val)
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setClusterDeleteLimitRate] err[%v]", err)
+		atomic.StoreUint64(&c.cfg.ClusterDeleteLimitRate, oldVal)
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
+
+// deleteLimitRates divides the cluster-wide deletion rate evenly among the active nodes,
+// and returns the extents that a data node and a meta node may delete per second, 0 means unlimited.
+// The configured limit rate of the data nodes still applies if it is lower.
+func (c *Cluster) deleteLimitRates() (dataNodeRate, metaNodeRate uint64) {
+	dataNodeRate = atomic.LoadUint64(&c.cfg.DataNodeDeleteLimitRate)
+	clusterRate := atomic.LoadUint64(&c.cfg.ClusterDeleteLimitRate)
+	if clusterRate == 0 {
+		return
+	}
+	var dataNodes, metaNodes uint64
+	c.dataNodes.Range(func(addr, node interface{}) bool {
+		if node.(*DataNode).isActive {
+			dataNodes++
+		}
+		return true
+	})
+	c.metaNodes.Range(func(addr, node interface{}) bool {
+		if node.(*MetaNode).IsActive {
+			metaNodes++
+		}
+		return true
+	})
+	share := func(nodes uint64) uint64 {
+		if nodes == 0 || clusterRate <= nodes {
+			return 1
+		}
+		return clusterRate / nodes
+	}
+	if dataNodeShare := share(dataNodes); dataNodeRate == 0 || dataNodeShare < dataNodeRate {
+		dataNodeRate = dataNodeShare
+	}
+	metaNodeRate = share(metaNodes)
+	return
+}
+
 func (c *Cluster) setMetaNodeDeleteWorkerSleepMs(val uint64) (err error) {
 	oldVal := atomic.LoadUint64(&c.cfg.MetaNodeDeleteWorkerSleepMs)
 	atomic.StoreUint64(&c.cfg.MetaNodeDeleteWorkerSleepMs, val)
@@ -1986,6 +2190,7 @@ func (c *Cluster) clearVols() {
 	c.volMutex.Lock()
 	defer c.volMutex.Unlock()
 	c.vols = make(map[string]*Vol, 0)
+	c.volAliases = make(map[string]string)
 }
 
 func (c *Cluster) clearTopology() {
@@ -0,0 +1,174 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func (m *Server) clusterOverview(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.overview(m.leaderInfo.addr)))
+}
+
+// overview aggregates the capacity of the zones, the nodes and the volumes, the health and the raft leaders
+// of the partitions, and the pending repairs of the cluster. The capacity is the one updated by updateStatInfo,
+// and the partitions are the ones reported by the nodes in the last heartbeats.
+func (c *Cluster) overview(leaderAddr string) (ov *proto.ClusterOverview) {
+	ov = &proto.ClusterOverview{
+		Name:             c.Name,
+		LeaderAddr:       leaderAddr,
+		DataNodeStatInfo: c.dataNodeStatInfo,
+		MetaNodeStatInfo: c.metaNodeStatInfo,
+		Zones:            make([]*proto.ZoneOverview, 0),
+		DataNodes:        make([]*proto.NodeOverview, 0),
+		MetaNodes:        make([]*proto.NodeOverview, 0),
+		Vols:             make([]*proto.VolOverview, 0),
+		DataPartitions:   new(proto.PartitionsHealth),
+		MetaPartitions:   new(proto.PartitionsHealth),
+		PendingRepairs: &proto.PendingRepairs{
+			BadDataPartitions: c.getBadDataPartitionsView(),
+			BadMetaPartitions: c.getBadMetaPartitionsView(),
+			OfflineDataNodes:  make([]string, 0),
+			OfflineMetaNodes:  make([]string, 0),
+		},
+		FaultDomains: c.getFaultDomains(proto.FaultDomainRack),
+	}
+	for _, zone := range c.t.getAllZones() {
+		zv := &proto.ZoneOverview{Name: zone.name, Status: zone.getStatusToString()}
+		if zs, ok := c.zoneStatInfos[zone.name]; ok {
+			zv.DataNodeStat, zv.MetaNodeStat = zs.DataNodeStat, zs.MetaNodeStat
+		}
+		ov.Zones = append(ov.Zones, zv)
+	}
+
+	// the raft leaders of the partitions by the address of the nodes
+	leaders := make(map[string]int)
+	for _, vol := range c.copyVols() {
+		vv := &proto.VolOverview{
+			Name:           vol.Name,
+			Owner:          vol.Owner,
+			Status:         vol.Status,
+			Capacity:       vol.Capacity,
+			DataPartitions: new(proto.PartitionsHealth),
+			MetaPartitions: new(proto.PartitionsHealth),
+		}
+		if stat, ok := c.volStatInfo.Load(vol.Name); ok {
+			vs := stat.(*volStatInfo)
+			vv.TotalSize, vv.UsedSize, vv.UsedRatio = vs.TotalSize, vs.UsedSize, vs.UsedRatio
+		}
+		for _, dp := range vol.cloneDataPartitionMap() {
+			dp.RLock()
+			leaderAddr := dp.getLeaderAddr()
+			addPartitionHealth(dp.Status, leaderAddr != "", vv.DataPartitions, ov.DataPartitions)
+			dp.RUnlock()
+			if leaderAddr != "" {
+				leaders[leaderAddr]++
+			}
+		}
+		for _, mp := range vol.cloneMetaPartitionMap() {
+			mp.RLock()
+			mr, err := mp.getMetaReplicaLeader()
+			addPartitionHealth(mp.Status, err == nil, vv.MetaPartitions, ov.MetaPartitions)
+			mp.RUnlock()
+			if err == nil {
+				leaders[mr.Addr]++
+			}
+		}
+		ov.Vols = append(ov.Vols, vv)
+	}
+
+	c.dataNodes.Range(func(key, value interface{}) bool {
+		dataNode := value.(*DataNode)
+		dataNode.RLock()
+		nv := &proto.NodeOverview{
+			ID:             dataNode.ID,
+			Addr:           dataNode.Addr,
+			Zone:           dataNode.ZoneName,
+			Region:         dataNode.Region,
+			Rack:           dataNode.Rack,
+			IsActive:       dataNode.isActive,
+			ToBeOffline:    dataNode.ToBeOffline,
+			Total:          dataNode.Total,
+			Used:           dataNode.Used,
+			UsageRatio:     dataNode.UsageRatio,
+			PartitionCount: int(dataNode.DataPartitionCount),
+			LeaderCount:    leaders[dataNode.Addr],
+			BadDisks:       dataNode.BadDisks,
+		}
+		dataNode.RUnlock()
+		nv.IsWritable = dataNode.isWriteAble()
+		nv.PendingTasks = dataNode.TaskManager.pendingTaskCount()
+		ov.DataNodes = append(ov.DataNodes, nv)
+		ov.PendingRepairs.DataNodePendingTasks += nv.PendingTasks
+		if nv.ToBeOffline {
+			ov.PendingRepairs.OfflineDataNodes = append(ov.PendingRepairs.OfflineDataNodes, nv.Addr)
+		}
+		return true
+	})
+	c.metaNodes.Range(func(key, value interface{}) bool {
+		metaNode := value.(*MetaNode)
+		metaNode.RLock()
+		nv := &proto.NodeOverview{
+			ID:             metaNode.ID,
+			Addr:           metaNode.Addr,
+			Zone:           metaNode.ZoneName,
+			Region:         metaNode.Region,
+			Rack:           metaNode.Rack,
+			IsActive:       metaNode.IsActive,
+			ToBeOffline:    metaNode.ToBeOffline,
+			Total:          metaNode.Total,
+			Used:           metaNode.Used,
+			UsageRatio:     metaNode.Ratio,
+			PartitionCount: metaNode.MetaPartitionCount,
+			LeaderCount:    leaders[metaNode.Addr],
+		}
+		metaNode.RUnlock()
+		nv.IsWritable = metaNode.isWritable()
+		nv.PendingTasks = metaNode.Sender.pendingTaskCount()
+		ov.MetaNodes = append(ov.MetaNodes, nv)
+		ov.PendingRepairs.MetaNodePendingTasks += nv.PendingTasks
+		if nv.ToBeOffline {
+			ov.PendingRepairs.OfflineMetaNodes = append(ov.PendingRepairs.OfflineMetaNodes, nv.Addr)
+		}
+		return true
+	})
+
+	sort.Slice(ov.Zones, func(i, j int) bool { return ov.Zones[i].Name < ov.Zones[j].Name })
+	sort.Slice(ov.Vols, func(i, j int) bool { return ov.Vols[i].Name < ov.Vols[j].Name })
+	sort.Slice(ov.DataNodes, func(i, j int) bool { return ov.DataNodes[i].ID < ov.DataNodes[j].ID })
+	sort.Slice(ov.MetaNodes, func(i, j int) bool { return ov.MetaNodes[i].ID < ov.MetaNodes[j].ID })
+	return
+}
+
+// addPartitionHealth counts a partition of the status into the health of the volume and the cluster.
+func addPartitionHealth(status int8, hasLeader bool, healths ...*proto.PartitionsHealth) {
+	for _, h := range healths {
+		h.Total++
+		switch status {
+		case proto.ReadWrite:
+			h.ReadWrite++
+		case proto.ReadOnly:
+			h.ReadOnly++
+		default:
+			h.Unavailable++
+		}
+		if !hasLeader {
+			h.NoLeader++
+		}
+	}
+}
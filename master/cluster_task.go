@@ -166,6 +166,23 @@ errHandler:
 	return
 }
 
+// moveMetaReplica moves the replica of the meta partition on the offline address to the new address,
+// the new replica is recovered in background like a decommissioned one.
+func (c *Cluster) moveMetaReplica(mp *MetaPartition, offlineAddr, newAddr string) (err error) {
+	if err = c.deleteMetaReplica(mp, offlineAddr, false); err != nil {
+		return
+	}
+	if err = c.addMetaReplica(mp, newAddr); err != nil {
+		return
+	}
+	mp.IsRecover = true
+	c.putBadMetaPartitions(offlineAddr, mp.PartitionID)
+	mp.RLock()
+	c.syncUpdateMetaPartition(mp)
+	mp.RUnlock()
+	return
+}
+
 func (c *Cluster) validateDecommissionMetaPartition(mp *MetaPartition, nodeAddr string) (err error) {
 	mp.RLock()
 	defer mp.RUnlock()
@@ -569,6 +586,7 @@ func (c *Cluster) doLoadDataPartition(dp *DataPartition) {
 
 	dp.getFileCount()
 	dp.validateCRC(c.Name)
+	c.checkReplicaConsistency(dp)
 	dp.checkReplicaSize(c.Name,c.cfg.diffSpaceUsage)
 	dp.setToNormal()
 }
@@ -861,6 +879,8 @@ func (c *Cluster) handleDataNodeHeartbeatResp(nodeAddr string, resp *proto.DataN
 	}
 
 	dataNode.updateNodeMetric(resp)
+	c.autoDecommissionBadDisks(dataNode, resp.BadDisks)
+	c.migrateFailingDisks(dataNode, resp.DiskSmart)
 
 	if err = c.t.putDataNode(dataNode); err != nil {
 		log.LogErrorf("action[handleDataNodeHeartbeatResp] dataNode[%v],zone[%v],node set[%v], err[%v]", dataNode.Addr, dataNode.ZoneName, dataNode.NodeSetID, err)
@@ -923,7 +943,7 @@ func (c *Cluster) updateDataNode(dataNode *DataNode, dps []*proto.PartitionRepor
 			if err != nil {
 				continue
 			}
-			if vol.Status == markDelete {
+			if vol.isPurging() {
 				continue
 			}
 			if dp, err := vol.getDataPartitionByID(vr.PartitionID); err == nil {
@@ -952,7 +972,7 @@ func (c *Cluster) updateMetaNode(metaNode *MetaNode, metaPartitions []*proto.Met
 			if err != nil {
 				continue
 			}
-			if vol.Status == markDelete {
+			if vol.isPurging() {
 				continue
 			}
 			mp, err = vol.metaPartition(mr.PartitionID)
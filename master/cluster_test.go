@@ -1,8 +1,10 @@
 package master
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/chubaofs/chubaofs/proto"
+	"net/http"
 	"testing"
 	"time"
 )
@@ -288,3 +290,66 @@ func TestUpdateInodeIDUpperBound(t *testing.T) {
 	}
 
 }
+
+func TestSetWriteLimits(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v?diskWriteRate=200&diskRepairRate=50&dpWriteRate=100", hostAddr, proto.AdminSetNodeInfo)
+	process(reqURL, t)
+	defer process(fmt.Sprintf("%v%v?diskWriteRate=0&diskRepairRate=0&dpWriteRate=0", hostAddr, proto.AdminSetNodeInfo), t)
+	reply := process(fmt.Sprintf("%v%v", hostAddr, proto.AdminGetIP), t)
+	if reply == nil {
+		return
+	}
+	data, _ := json.Marshal(reply.Data)
+	cInfo := &proto.ClusterInfo{}
+	if err := json.Unmarshal(data, cInfo); err != nil {
+		t.Fatal(err)
+	}
+	if cInfo.DataNodeDiskWriteLimitRate != 200 || cInfo.DataNodeDiskRepairLimitRate != 50 || cInfo.DataNodeDpWriteLimitRate != 100 {
+		t.Errorf("expect write limits [200 50 100] but got [%v %v %v]", cInfo.DataNodeDiskWriteLimitRate,
+			cInfo.DataNodeDiskRepairLimitRate, cInfo.DataNodeDpWriteLimitRate)
+	}
+	reqURL = fmt.Sprintf("%v%v?diskWriteRate=abc", hostAddr, proto.AdminSetNodeInfo)
+	if resp, err := http.Get(reqURL); err == nil {
+		resp.Body.Close()
+	}
+	if server.cluster.cfg.DataNodeDiskWriteLimitRate != 200 {
+		t.Errorf("expect invalid diskWriteRate rejected but got [%v]", server.cluster.cfg.DataNodeDiskWriteLimitRate)
+	}
+}
+
+func TestClusterDeleteLimitRate(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v?clusterDeleteRate=1000", hostAddr, proto.AdminSetNodeInfo)
+	process(reqURL, t)
+	defer process(fmt.Sprintf("%v%v?clusterDeleteRate=0", hostAddr, proto.AdminSetNodeInfo), t)
+	var dataNodes, metaNodes uint64
+	for _, node := range server.cluster.allDataNodes() {
+		if node.Status {
+			dataNodes++
+		}
+	}
+	for _, node := range server.cluster.allMetaNodes() {
+		if node.Status {
+			metaNodes++
+		}
+	}
+	if dataNodes == 0 || metaNodes == 0 {
+		t.Skipf("no active nodes, dataNodes[%v] metaNodes[%v]", dataNodes, metaNodes)
+	}
+	dataNodeRate, metaNodeRate := server.cluster.deleteLimitRates()
+	if metaNodeRate != 1000/metaNodes {
+		t.Errorf("expect meta node delete rate[%v] but got [%v]", 1000/metaNodes, metaNodeRate)
+	}
+	if limitRate := server.cluster.cfg.DataNodeDeleteLimitRate; limitRate == 0 || limitRate > 1000/dataNodes {
+		if dataNodeRate != 1000/dataNodes {
+			t.Errorf("expect data node delete rate[%v] but got [%v]", 1000/dataNodes, dataNodeRate)
+		}
+	} else if dataNodeRate != limitRate {
+		t.Errorf("expect data node delete rate[%v] but got [%v]", limitRate, dataNodeRate)
+	}
+	if err := server.cluster.setClusterDeleteLimitRate(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, metaNodeRate = server.cluster.deleteLimitRates(); metaNodeRate != 0 {
+		t.Errorf("expect unlimited meta node delete rate but got [%v]", metaNodeRate)
+	}
+}
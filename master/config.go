@@ -35,8 +35,14 @@ const (
 	secondsToFreeDataPartitionAfterLoad = "secondsToFreeDataPartitionAfterLoad"
 	nodeSetCapacity                     = "nodeSetCap"
 	cfgMetaNodeReservedMem              = "metaNodeReservedMem"
+	cfgEncryptionKeyFile                = "encryptionKeyFile"
 	heartbeatPortKey                    = "heartbeatPort"
 	replicaPortKey                      = "replicaPort"
+	cfgAutoDecommissionDisk             = "autoDecommissionDisk"
+	cfgMigrateFailingDisk               = "migrateFailingDisk"
+	cfgAutoRepairDivergence             = "autoRepairDivergence"
+	cfgAuditRetentionDays               = "auditRetentionDays"
+	cfgVolDeletionDelayHours            = "volDeletionDelayHours"
 )
 
 //default value
@@ -64,6 +70,8 @@ const (
 	defaultMaxMetaPartitionCountOnEachNode             = 10000
 	defaultReplicaNum                                  = 3
 	defaultDiffSpaceUsage                              = 1024 * 1024 * 1024
+	defaultAuditRetentionDays                          = 90
+	defaultVolDeletionDelayHours                       = 24
 )
 
 // AddrDatabase is a map that stores the address of a given host (e.g., the leader)
@@ -86,11 +94,20 @@ type clusterConfig struct {
 	DataNodeDeleteLimitRate             uint64 //datanode delete limit rate
 	MetaNodeDeleteWorkerSleepMs         uint64 //datanode delete limit rate
 	DataNodeAutoRepairLimitRate         uint64 //datanode autorepair limit rate
+	DataNodeDiskWriteLimitRate          uint64 // MB/s of the client writes on each disk of the data nodes
+	DataNodeDiskRepairLimitRate         uint64 // MB/s of the repair writes on each disk of the data nodes
+	DataNodeDpWriteLimitRate            uint64 // MB/s of the client writes on each data partition
+	ClusterDeleteLimitRate              uint64 // extents deleted by the whole cluster per second, shared by the nodes
 	peers                               []raftstore.PeerAddress
 	peerAddrs                           []string
 	heartbeatPort                       int64
 	replicaPort                         int64
 	diffSpaceUsage                      uint64
+	autoDecommissionDisk                bool // re-replicate the data partitions on the bad disks automatically
+	migrateFailingDisk                  bool // migrate the data partitions off the disks predicted to fail by SMART
+	autoRepairDivergence                bool // repair the diverging extents of the replicas automatically
+	auditRetentionDays                  int  // days to keep the records of the admin API calls, forever if not positive
+	volDeletionDelayHours               int  // hours to keep the volumes marked deleted before the purge, see markDeleteVol
 }
 
 func newClusterConfig() (cfg *clusterConfig) {
@@ -107,6 +124,10 @@ func newClusterConfig() (cfg *clusterConfig) {
 	cfg.MetaNodeThreshold = defaultMetaPartitionMemUsageThreshold
 	cfg.metaNodeReservedMem = defaultMetaNodeReservedMem
 	cfg.diffSpaceUsage = defaultDiffSpaceUsage
+	cfg.autoDecommissionDisk = true
+	cfg.migrateFailingDisk = true
+	cfg.auditRetentionDays = defaultAuditRetentionDays
+	cfg.volDeletionDelayHours = defaultVolDeletionDelayHours
 	return
 }
 
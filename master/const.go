@@ -26,7 +26,9 @@ const (
 	addrKey                 = "addr"
 	diskPathKey             = "disk"
 	nameKey                 = "name"
+	newNameKey              = "newName"
 	idKey                   = "id"
+	idsKey                  = "ids"
 	countKey                = "count"
 	startKey                = "start"
 	enableKey               = "enable"
@@ -42,6 +44,9 @@ const (
 	akKey                   = "ak"
 	keywordsKey             = "keywords"
 	zoneNameKey             = "zoneName"
+	regionKey               = "region"
+	rackKey                 = "rack"
+	pathKey                 = "path"
 	crossZoneKey            = "crossZone"
 	tokenKey                = "token"
 	tokenTypeKey            = "tokenType"
@@ -52,9 +57,40 @@ const (
 	nodeMarkDeleteRateKey   = "markDeleteRate"
 	nodeDeleteWorkerSleepMs = "deleteWorkerSleepMs"
 	nodeAutoRepairRateKey   = "autoRepairRate"
+	nodeDiskWriteRateKey    = "diskWriteRate"
+	nodeDiskRepairRateKey   = "diskRepairRate"
+	nodeDpWriteRateKey      = "dpWriteRate"
+	clusterDeleteRateKey    = "clusterDeleteRate"
+	auditAPIKey             = "api"
+	auditOperatorKey        = "operator"
+	startTimeKey            = "start"
+	endTimeKey              = "end"
+	limitKey                = "limit"
 	descriptionKey          = "description"
 	dpSelectorNameKey       = "dpSelectorName"
 	dpSelectorParmKey       = "dpSelectorParm"
+	inodeKey                = "inode"
+	maxFilesKey             = "maxFiles"
+	maxBytesKey             = "maxBytes"
+	trashDaysKey            = "trashDays"
+	dryRunKey               = "dryRun"
+	metaEnableKey           = "metaEnable"
+	hotMediaKey             = "hotMedia"
+	coldMediaKey            = "coldMedia"
+	coldDaysKey             = "coldDays"
+	gracefulKey             = "graceful"
+	maxMigrationsKey        = "maxMigrations"
+	encryptKey              = "encrypt"
+	flatKey                 = "flat"
+	cacheKey                = "cache"
+	forceKey                = "force"
+	caseInsensitiveKey      = "caseInsensitive"
+	enforcePermKey          = "enforcePerm"
+	permissionPriorityKey   = "permissionPriority"
+	smallFileSizeKey        = "smallFileSize"
+	subdirKey               = "subdir"
+	timestampKey            = "ts"
+	signKey                 = "sign"
 )
 
 const (
@@ -93,9 +129,20 @@ const (
 	maxNumberOfDataPartitionsForExpansion        = 100
 	EmptyCrcValue                         uint32 = 4045511210
 	DefaultZoneName                              = proto.DefaultZoneName
+	DefaultRegionName                            = proto.DefaultRegionName
+	DefaultRackName                              = proto.DefaultRackName
 	retrySendSyncTaskInternal                    = 3 * time.Second
 	defaultRangeOfCountDifferencesAllowed        = 50
 	defaultMinusOfMaxInodeID                     = 1000
+	defaultIntervalToCheckRebalance              = 10 * time.Minute
+	defaultRebalanceMaxMigrations                = 5
+	defaultRebalanceThreshold                    = 0.1
+	defaultIntervalToCheckTiering                = 10 * time.Minute
+	defaultTieringMaxMigrations                  = 5
+	defaultClientSessionLease                    = time.Minute
+	defaultClientDeadKeepTime                    = 30 * time.Minute
+	defaultIntervalToCheckClientSessions         = 10 * time.Second
+	defaultIntervalToWaitRecovery                = 10 * time.Second
 )
 
 const (
@@ -142,6 +189,11 @@ const (
 	OpSyncUpdateToken uint32 = 0x22
 )
 
+const (
+	opSyncAddAuditRecord    uint32 = 0x23
+	opSyncDeleteAuditRecord uint32 = 0x24
+)
+
 const (
 	keySeparator          = "#"
 	idSeparator           = "$" // To seperate ID of server that submits raft changes
@@ -171,4 +223,7 @@ const (
 	userPrefix     = keySeparator + userAcronym + keySeparator
 	volUserPrefix  = keySeparator + volUserAcronym + keySeparator
 	TokenPrefix    = keySeparator + tokenAcronym + keySeparator
+
+	auditAcronym = "audit"
+	auditPrefix  = keySeparator + auditAcronym + keySeparator
 )
@@ -30,6 +30,8 @@ type DataNode struct {
 	AvailableSpace            uint64
 	ID                        uint64
 	ZoneName                  string `json:"Zone"`
+	Region                    string
+	Rack                      string
 	Addr                      string
 	ReportTime                time.Time
 	isActive                  bool
@@ -43,7 +45,10 @@ type DataNode struct {
 	NodeSetID                 uint64
 	PersistenceDataPartitions []uint64
 	BadDisks                  []string
+	MediaSpaces               map[string]uint64 `graphql:"-"` // key: media type, value: remaining capacity to create partition
 	ToBeOffline               bool
+	Version                   string
+	DiskSmart                 []*proto.DiskSmartInfo
 }
 
 func newDataNode(addr, zoneName, clusterID string) (dataNode *DataNode) {
@@ -52,6 +57,8 @@ func newDataNode(addr, zoneName, clusterID string) (dataNode *DataNode) {
 	dataNode.Total = 1
 	dataNode.Addr = addr
 	dataNode.ZoneName = zoneName
+	dataNode.Region = DefaultRegionName
+	dataNode.Rack = DefaultRackName
 	dataNode.TaskManager = newAdminTaskManager(dataNode.Addr, clusterID)
 	return
 }
@@ -89,6 +96,9 @@ func (dataNode *DataNode) updateNodeMetric(resp *proto.DataNodeHeartbeatResponse
 	dataNode.DataPartitionCount = resp.CreatedPartitionCnt
 	dataNode.DataPartitionReports = resp.PartitionReports
 	dataNode.BadDisks = resp.BadDisks
+	dataNode.DiskSmart = resp.DiskSmart
+	dataNode.MediaSpaces = resp.MediaSpaces
+	dataNode.Version = resp.Version
 	if dataNode.Total == 0 {
 		dataNode.UsageRatio = 0.0
 	} else {
@@ -148,10 +158,12 @@ func (dataNode *DataNode) clean() {
 	dataNode.TaskManager.exitCh <- struct{}{}
 }
 
-func (dataNode *DataNode) createHeartbeatTask(masterAddr string) (task *proto.AdminTask) {
+func (dataNode *DataNode) createHeartbeatTask(masterAddr string, readOnlyVols []string, renamedVols map[string]string) (task *proto.AdminTask) {
 	request := &proto.HeartBeatRequest{
-		CurrTime:   time.Now().Unix(),
-		MasterAddr: masterAddr,
+		CurrTime:     time.Now().Unix(),
+		MasterAddr:   masterAddr,
+		ReadOnlyVols: readOnlyVols,
+		RenamedVols:  renamedVols,
 	}
 	task = proto.NewAdminTask(proto.OpDataNodeHeartbeat, dataNode.Addr, request)
 	return
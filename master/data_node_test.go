@@ -22,6 +22,37 @@ func TestDataNode(t *testing.T) {
 	server.cluster.dataNodes.Delete(addr)
 }
 
+func TestDataNodeGracefulDecommission(t *testing.T) {
+	addr := "127.0.0.1:9097"
+	addDataServer(addr, DefaultZoneName)
+	server.cluster.checkDataNodeHeartbeat()
+	time.Sleep(5 * time.Second)
+	reqURL := fmt.Sprintf("%v%v?addr=%v&graceful=true", hostAddr, proto.DecommissionDataNode, addr)
+	process(reqURL, t)
+	var progress *proto.DecommissionProgress
+	for i := 0; i < 30; i++ {
+		var err error
+		if progress, err = server.cluster.getDecommissionProgress(nodeTypeDataNode, addr); err != nil {
+			t.Error(err)
+			return
+		}
+		if progress.Status == decommissionFinished || progress.Status == decommissionFailed {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	if progress.Status != decommissionFinished {
+		t.Errorf("expect decommission finished, but is %v", progress)
+		return
+	}
+	reqURL = fmt.Sprintf("%v%v?addr=%v", hostAddr, proto.DecommissionDataNodeProgress, addr)
+	process(reqURL, t)
+	if _, err := server.cluster.dataNode(addr); err == nil {
+		t.Errorf("decommission datanode [%v] failed", addr)
+	}
+	server.cluster.dataNodes.Delete(addr)
+}
+
 func getDataNodeInfo(addr string, t *testing.T) {
 	reqURL := fmt.Sprintf("%v%v?addr=%v", hostAddr, proto.GetDataNode, addr)
 	fmt.Println(reqURL)
@@ -33,3 +64,51 @@ func decommissionDataNode(addr string, t *testing.T) {
 	fmt.Println(reqURL)
 	process(reqURL, t)
 }
+
+func TestDataNodeAutoDecommissionBadDisk(t *testing.T) {
+	partition := commonVol.dataPartitions.partitions[0]
+	partition.RLock()
+	addr := partition.Hosts[0]
+	partition.RUnlock()
+	dataNode, err := server.cluster.dataNode(addr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	diskPath := "/cfs"
+	server.cluster.autoDecommissionBadDisks(dataNode, []string{diskPath})
+	var progress *proto.DecommissionProgress
+	for i := 0; i < 30; i++ {
+		if progress, err = server.cluster.getDecommissionProgress(nodeTypeDisk, fmt.Sprintf("%v:%v", addr, diskPath)); err != nil {
+			t.Error(err)
+			return
+		}
+		if progress.Status == decommissionFinished || progress.Status == decommissionFailed {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	if progress.Status != decommissionFinished {
+		t.Errorf("expect decommission finished, but is %v", progress)
+		return
+	}
+	partition.RLock()
+	defer partition.RUnlock()
+	if partition.hasHost(addr) {
+		t.Errorf("hosts[%v] should not contains the bad disk of [%v]", partition.Hosts, addr)
+	}
+	partition.isRecover = false
+}
+
+func TestDataNodeMigrateFailingDisk(t *testing.T) {
+	dataNode, err := server.cluster.dataNode(mds1Addr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	diskPath := "/cfs"
+	server.cluster.migrateFailingDisks(dataNode, []*proto.DiskSmartInfo{{Path: diskPath, Healthy: true}})
+	if _, err = server.cluster.getDecommissionProgress(nodeTypeDisk, fmt.Sprintf("%v:%v", mds1Addr, diskPath)); err == nil {
+		t.Errorf("the healthy disk[%v] of [%v] should not be migrated", diskPath, mds1Addr)
+	}
+}
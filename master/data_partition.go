@@ -34,6 +34,10 @@ type DataPartition struct {
 	ReplicaNum     uint8
 	Status         int8
 	isRecover      bool
+	isManual       bool // kept read only regardless of the replicas, such as after shrinking the volume
+	mediaType      string // the media type of the disks the replicas should be on, empty if the volume has no tiering
+	tierTime       int64  // the last time the media type was changed
+	lastAccessTime int64  // the last time the partition was accessed by the clients, reported by the data nodes
 	Replicas       []*DataReplica
 	Hosts          []string // host addresses
 	Peers          []proto.Peer
@@ -49,7 +53,8 @@ type DataPartition struct {
 	lastWarnTime            int64
 	OfflinePeerID           uint64
 	FileInCoreMap           map[string]*FileInCore
-	FilesWithMissingReplica map[string]int64 // key: file name, value: last time when a missing replica is found
+	FilesWithMissingReplica map[string]int64           // key: file name, value: last time when a missing replica is found
+	divergences             []*proto.ReplicaDivergence // found by the last check of the replica consistency
 }
 
 func newDataPartition(ID uint64, replicaNum uint8, volName string, volID uint64) (partition *DataPartition) {
@@ -121,10 +126,10 @@ func (partition *DataPartition) createTaskToRemoveRaftMember(removePeer proto.Pe
 	return
 }
 
-func (partition *DataPartition) createTaskToCreateDataPartition(addr string, dataPartitionSize uint64, peers []proto.Peer, hosts []string, createType int) (task *proto.AdminTask) {
+func (partition *DataPartition) createTaskToCreateDataPartition(addr string, dataPartitionSize uint64, peers []proto.Peer, hosts []string, createType int, encrypted, cache bool) (task *proto.AdminTask) {
 
 	task = proto.NewAdminTask(proto.OpCreateDataPartition, addr, newCreateDataPartitionRequest(
-		partition.VolName, partition.PartitionID, peers, int(dataPartitionSize), hosts, createType))
+		partition.VolName, partition.PartitionID, peers, int(dataPartitionSize), hosts, createType, partition.mediaType, encrypted, cache))
 	partition.resetTaskID(task)
 	return
 }
@@ -472,6 +477,7 @@ func (partition *DataPartition) loadFile(dataNode *DataNode, resp *proto.LoadDat
 	}
 	replica.HasLoadResponse = true
 	replica.Used = resp.Used
+	replica.AppliedID = resp.AppliedID
 }
 
 func (partition *DataPartition) getReplicaIndex(addr string) (index int, err error) {
@@ -526,6 +532,15 @@ func (partition *DataPartition) updateMetric(vr *proto.PartitionReport, dataNode
 	replica.setAlive()
 	replica.IsLeader = vr.IsLeader
 	replica.NeedsToCompare = vr.NeedCompare
+	replica.MediaType = vr.MediaType
+	if len(vr.CorruptExtents) > len(replica.CorruptExtents) {
+		log.LogWarnf("action[updateMetric] vol[%v] partition[%v] replica[%v] corrupt extents%v",
+			partition.VolName, partition.PartitionID, dataNode.Addr, vr.CorruptExtents)
+	}
+	replica.CorruptExtents = vr.CorruptExtents
+	if vr.LastAccessTime > partition.lastAccessTime {
+		partition.lastAccessTime = vr.LastAccessTime
+	}
 	if replica.DiskPath != vr.DiskPath && vr.DiskPath != "" {
 		oldDiskPath := replica.DiskPath
 		replica.DiskPath = vr.DiskPath
@@ -595,6 +610,17 @@ func (partition *DataPartition) containsBadDisk(diskPath string, nodeAddr string
 	return false
 }
 
+func (partition *DataPartition) hasCorruptExtents() bool {
+	partition.RLock()
+	defer partition.RUnlock()
+	for _, replica := range partition.Replicas {
+		if len(replica.CorruptExtents) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (partition *DataPartition) getMinus() (minus float64) {
 	partition.RLock()
 	defer partition.RUnlock()
@@ -680,6 +706,28 @@ func (partition *DataPartition) ToProto(c *Cluster) *proto.DataPartitionInfo {
 		VolID:                   partition.VolID,
 		FileInCoreMap:           fileInCoreMap,
 		OfflinePeerID:           partition.OfflinePeerID,
+		IsManual:                partition.isManual,
+		MediaType:               partition.mediaType,
+		LastAccessTime:          partition.lastAccessTime,
 		FilesWithMissingReplica: partition.FilesWithMissingReplica,
+		Divergences:             partition.divergences,
+	}
+}
+
+func (partition *DataPartition) location(c *Cluster) *proto.DataPartitionLocation {
+	partition.RLock()
+	defer partition.RUnlock()
+	loc := &proto.DataPartitionLocation{
+		PartitionID: partition.PartitionID,
+		LeaderAddr:  partition.getLeaderAddr(),
+		Hosts:       make([]string, len(partition.Hosts)),
+		Zones:       make([]string, len(partition.Hosts)),
+	}
+	copy(loc.Hosts, partition.Hosts)
+	for idx, host := range partition.Hosts {
+		if dataNode, err := c.dataNode(host); err == nil {
+			loc.Zones[idx] = dataNode.ZoneName
+		}
 	}
+	return loc
 }
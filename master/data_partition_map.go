@@ -248,3 +248,15 @@ func (dpMap *DataPartitionMap) checkBadDiskDataPartitions(diskPath, nodeAddr str
 	}
 	return
 }
+
+func (dpMap *DataPartitionMap) checkCorruptExtentDataPartitions() (partitions []*DataPartition) {
+	dpMap.RLock()
+	defer dpMap.RUnlock()
+	partitions = make([]*DataPartition, 0)
+	for _, dp := range dpMap.partitionMap {
+		if dp.hasCorruptExtents() {
+			partitions = append(partitions, dp)
+		}
+	}
+	return
+}
@@ -89,3 +89,50 @@ func loadDataPartitionTest(dp *DataPartition, t *testing.T) {
 	dp.validateCRC(server.cluster.Name)
 	dp.setToNormal()
 }
+
+func TestReplicaConsistency(t *testing.T) {
+	partition := commonVol.dataPartitions.partitions[1]
+	partition.Lock()
+	liveReplicas := partition.liveReplicas(defaultDataPartitionTimeOutSec)
+	if len(liveReplicas) < 2 {
+		partition.Unlock()
+		t.Errorf("partition[%v] has only [%v] live replicas", partition.PartitionID, len(liveReplicas))
+		return
+	}
+	fc := newFileInCore("1025")
+	for i, replica := range liveReplicas {
+		crc := uint32(100)
+		if i == 0 {
+			crc = 200
+		}
+		fc.MetadataArray = append(fc.MetadataArray, newFileMetadata(crc, replica.Addr, i, util.BlockSize))
+	}
+	partition.FileInCoreMap[fc.Name] = fc
+	partition.Unlock()
+	defer func() {
+		partition.Lock()
+		delete(partition.FileInCoreMap, fc.Name)
+		partition.divergences = nil
+		partition.Unlock()
+	}()
+
+	server.cluster.checkReplicaConsistency(partition)
+	var divergence *proto.ReplicaDivergence
+	for _, d := range server.cluster.getReplicaDivergences(commonVolName) {
+		if d.PartitionID == partition.PartitionID && d.ExtentID == 1025 {
+			divergence = d
+		}
+	}
+	if divergence == nil || divergence.Kind != divergenceKindCrc {
+		t.Errorf("expect a crc divergence of extent 1025, but is %v", divergence)
+		return
+	}
+	if len(liveReplicas) > 2 && (divergence.Authority == "" || divergence.Authority == liveReplicas[0].Addr) {
+		t.Errorf("authority[%v] should not be the diverging replica[%v]", divergence.Authority, liveReplicas[0].Addr)
+		return
+	}
+	reqURL := fmt.Sprintf("%v%v?name=%v", hostAddr, proto.AdminDataPartitionDivergence, commonVolName)
+	process(reqURL, t)
+	reqURL = fmt.Sprintf("%v%v?id=%v", hostAddr, proto.AdminRepairDataPartition, partition.PartitionID)
+	process(reqURL, t)
+}
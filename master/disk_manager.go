@@ -16,6 +16,7 @@ package master
 
 import (
 	"fmt"
+	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util"
 	"github.com/chubaofs/chubaofs/util/log"
 	"time"
@@ -95,3 +96,60 @@ func (c *Cluster) decommissionDisk(dataNode *DataNode, badDiskPath string, badPa
 	Warn(c.Name, msg)
 	return
 }
+
+// autoDecommissionBadDisks moves the data partition replicas off the bad disks reported by the data node
+// in background. Only the replicas on the failed disks are re-replicated, the other disks of the node keep serving.
+func (c *Cluster) autoDecommissionBadDisks(dataNode *DataNode, badDisks []string) {
+	if !c.cfg.autoDecommissionDisk {
+		return
+	}
+	c.drainDisks(dataNode, badDisks)
+}
+
+// migrateFailingDisks proactively moves the data partition replicas off the disks which are predicted to fail
+// by the SMART attributes reported by the data node, before the disks really fail.
+func (c *Cluster) migrateFailingDisks(dataNode *DataNode, smart []*proto.DiskSmartInfo) {
+	if !c.cfg.migrateFailingDisk {
+		return
+	}
+	failingDisks := make([]string, 0)
+	for _, info := range smart {
+		if info.PredictFailure {
+			failingDisks = append(failingDisks, info.Path)
+		}
+	}
+	c.drainDisks(dataNode, failingDisks)
+}
+
+func (c *Cluster) drainDisks(dataNode *DataNode, diskPaths []string) {
+	for _, diskPath := range diskPaths {
+		badPartitions := dataNode.badPartitions(diskPath, c)
+		if len(badPartitions) == 0 {
+			continue
+		}
+		d, err := c.startDecommission(nodeTypeDisk, fmt.Sprintf("%v:%v", dataNode.Addr, diskPath))
+		if err != nil {
+			continue
+		}
+		go c.drainBadDisk(dataNode, diskPath, badPartitions, d)
+	}
+}
+
+func (c *Cluster) drainBadDisk(dataNode *DataNode, diskPath string, badPartitions []*DataPartition, d *nodeDecommission) {
+	log.LogWarnf("action[drainBadDisk] Node[%v] disk[%v] begin, partitions[%v]", dataNode.Addr, diskPath, len(badPartitions))
+	ids := make([]uint64, 0, len(badPartitions))
+	for _, dp := range badPartitions {
+		ids = append(ids, dp.PartitionID)
+	}
+	_, err := movePartitions(d, ids, func(i int) error {
+		return c.decommissionDataPartition(dataNode.Addr, badPartitions[i], diskOfflineErr)
+	})
+	d.finish(err)
+	if err != nil {
+		Warn(c.Name, fmt.Sprintf("action[drainBadDisk],clusterID[%v] Node[%v] disk[%v] OffLine failed,err[%v]",
+			c.Name, dataNode.Addr, diskPath, err))
+		return
+	}
+	Warn(c.Name, fmt.Sprintf("action[drainBadDisk],clusterID[%v] Node[%v] disk[%v] OffLine success",
+		c.Name, dataNode.Addr, diskPath))
+}
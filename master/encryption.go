@@ -0,0 +1,195 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const dataKeySize = 32
+
+// keyManager wraps and unwraps the data keys of the encrypted volumes with the master keys.
+// The master keys never leave the key manager, so an external KMS can be plugged in
+// by implementing this interface.
+type keyManager interface {
+	// activeKeyID returns the id of the master key to wrap the new data keys.
+	activeKeyID() string
+	wrap(keyID, volName string, dataKey []byte) (wrapped []byte, err error)
+	unwrap(keyID, volName string, wrapped []byte) (dataKey []byte, err error)
+}
+
+// localKeyManager keeps the master keys in a local file of the master, e.g.
+// {"activeKey":"2","keys":{"1":"<hex of 32 bytes>","2":"<hex of 32 bytes>"}}
+// The old keys must be kept in the file until all the volumes are rotated to the active key.
+type localKeyManager struct {
+	activeKey string
+	keys      map[string]cipher.AEAD
+}
+
+type localKeyFile struct {
+	ActiveKey string            `json:"activeKey"`
+	Keys      map[string]string `json:"keys"`
+}
+
+func newLocalKeyManager(keyFile string) (km *localKeyManager, err error) {
+	var (
+		data []byte
+		kf   = &localKeyFile{}
+	)
+	if data, err = ioutil.ReadFile(keyFile); err != nil {
+		return
+	}
+	if err = json.Unmarshal(data, kf); err != nil {
+		return
+	}
+	km = &localKeyManager{activeKey: kf.ActiveKey, keys: make(map[string]cipher.AEAD)}
+	for id, hexKey := range kf.Keys {
+		var (
+			key   []byte
+			block cipher.Block
+		)
+		if key, err = hex.DecodeString(hexKey); err != nil {
+			return nil, fmt.Errorf("master key[%v] is not hex encoded", id)
+		}
+		if len(key) != dataKeySize {
+			return nil, fmt.Errorf("master key[%v] length[%v] is not %v", id, len(key), dataKeySize)
+		}
+		if block, err = aes.NewCipher(key); err != nil {
+			return
+		}
+		if km.keys[id], err = cipher.NewGCM(block); err != nil {
+			return
+		}
+	}
+	if _, ok := km.keys[km.activeKey]; !ok {
+		return nil, fmt.Errorf("active master key[%v] not found", km.activeKey)
+	}
+	return
+}
+
+func (km *localKeyManager) activeKeyID() string {
+	return km.activeKey
+}
+
+func (km *localKeyManager) getKey(keyID string) (aead cipher.AEAD, err error) {
+	var ok bool
+	if aead, ok = km.keys[keyID]; !ok {
+		err = fmt.Errorf("master key[%v] not found", keyID)
+	}
+	return
+}
+
+// wrap seals the data key with the volume name as the additional data,
+// so that a wrapped data key can not be moved to another volume.
+func (km *localKeyManager) wrap(keyID, volName string, dataKey []byte) (wrapped []byte, err error) {
+	var aead cipher.AEAD
+	if aead, err = km.getKey(keyID); err != nil {
+		return
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return
+	}
+	wrapped = aead.Seal(nonce, nonce, dataKey, []byte(volName))
+	return
+}
+
+func (km *localKeyManager) unwrap(keyID, volName string, wrapped []byte) (dataKey []byte, err error) {
+	var aead cipher.AEAD
+	if aead, err = km.getKey(keyID); err != nil {
+		return
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, fmt.Errorf("wrapped data key of vol[%v] is too short", volName)
+	}
+	nonce := wrapped[:aead.NonceSize()]
+	return aead.Open(nil, nonce, wrapped[aead.NonceSize():], []byte(volName))
+}
+
+// generateVolDataKey generates a new data key for the volume and wraps it with the active master key.
+func (c *Cluster) generateVolDataKey(vol *Vol) (err error) {
+	if c.keyManager == nil {
+		return fmt.Errorf("encryption is not enabled on the master, %v is not configured", cfgEncryptionKeyFile)
+	}
+	dataKey := make([]byte, dataKeySize)
+	if _, err = io.ReadFull(rand.Reader, dataKey); err != nil {
+		return
+	}
+	keyID := c.keyManager.activeKeyID()
+	if vol.wrappedDataKey, err = c.keyManager.wrap(keyID, vol.Name, dataKey); err != nil {
+		return
+	}
+	vol.dataKeyID = keyID
+	return
+}
+
+// getVolDataKey returns the plain data key of the volume to the data nodes.
+func (c *Cluster) getVolDataKey(vol *Vol) (dataKey []byte, err error) {
+	if !vol.encrypted() {
+		return nil, fmt.Errorf("vol[%v] is not encrypted", vol.Name)
+	}
+	if c.keyManager == nil {
+		return nil, fmt.Errorf("encryption is not enabled on the master, %v is not configured", cfgEncryptionKeyFile)
+	}
+	vol.RLock()
+	keyID, wrapped := vol.dataKeyID, vol.wrappedDataKey
+	vol.RUnlock()
+	return c.keyManager.unwrap(keyID, vol.Name, wrapped)
+}
+
+// rotateVolKey re-wraps the data key of the volume with the active master key.
+// The data key itself is not changed, so the extents on the data nodes are not re-encrypted.
+func (c *Cluster) rotateVolKey(name, authKey string) (err error) {
+	var (
+		vol        *Vol
+		dataKey    []byte
+		oldKeyID   string
+		oldWrapped []byte
+	)
+	if vol, err = c.getVol(name); err != nil {
+		return
+	}
+	if !matchKey(vol.Owner, authKey) {
+		return proto.ErrVolAuthKeyNotMatch
+	}
+	if dataKey, err = c.getVolDataKey(vol); err != nil {
+		return
+	}
+	vol.Lock()
+	defer vol.Unlock()
+	oldKeyID, oldWrapped = vol.dataKeyID, vol.wrappedDataKey
+	keyID := c.keyManager.activeKeyID()
+	if vol.wrappedDataKey, err = c.keyManager.wrap(keyID, vol.Name, dataKey); err != nil {
+		vol.wrappedDataKey = oldWrapped
+		return
+	}
+	vol.dataKeyID = keyID
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.dataKeyID, vol.wrappedDataKey = oldKeyID, oldWrapped
+		return
+	}
+	log.LogWarnf("action[rotateVolKey] vol[%v] data key rotated from master key[%v] to [%v]", name, oldKeyID, keyID)
+	return
+}
@@ -0,0 +1,269 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The nodes are placed in the fault domains region/zone/rack/host, which is the topology path of the nodes.
+// The region and the rack are reported by the nodes on the registration, and the host is the ip of the node.
+const topologyPathSeparator = "/"
+
+var faultDomainLevels = []string{proto.FaultDomainRegion, proto.FaultDomainZone, proto.FaultDomainRack, proto.FaultDomainHost}
+
+// nodeTopology fills the default region and rack of the nodes registered without them.
+func nodeTopology(region, rack string) (string, string) {
+	if region == "" {
+		region = DefaultRegionName
+	}
+	if rack == "" {
+		rack = DefaultRackName
+	}
+	return region, rack
+}
+
+func nodeHost(addr string) string {
+	return strings.Split(addr, ":")[0]
+}
+
+func topologyPath(domains ...string) string {
+	return strings.Join(domains, topologyPathSeparator)
+}
+
+// GetRackPath returns the topology path of the rack of the data node.
+func (dataNode *DataNode) GetRackPath() string {
+	dataNode.RLock()
+	defer dataNode.RUnlock()
+	return topologyPath(dataNode.Region, dataNode.ZoneName, dataNode.Rack)
+}
+
+// GetRackPath returns the topology path of the rack of the meta node.
+func (metaNode *MetaNode) GetRackPath() string {
+	metaNode.RLock()
+	defer metaNode.RUnlock()
+	return topologyPath(metaNode.Region, metaNode.ZoneName, metaNode.Rack)
+}
+
+// updateDataNodeTopology moves the registered data node to the region and the rack it reports again.
+func (c *Cluster) updateDataNodeTopology(dataNode *DataNode, region, rack string) {
+	region, rack = nodeTopology(region, rack)
+	dataNode.Lock()
+	oldRegion, oldRack := dataNode.Region, dataNode.Rack
+	if oldRegion == region && oldRack == rack {
+		dataNode.Unlock()
+		return
+	}
+	dataNode.Region, dataNode.Rack = region, rack
+	dataNode.Unlock()
+	if err := c.syncUpdateDataNode(dataNode); err != nil {
+		dataNode.Lock()
+		dataNode.Region, dataNode.Rack = oldRegion, oldRack
+		dataNode.Unlock()
+		log.LogErrorf("action[updateDataNodeTopology] dataNode[%v] region[%v] rack[%v] err[%v]", dataNode.Addr, region, rack, err)
+		return
+	}
+	log.LogWarnf("action[updateDataNodeTopology] dataNode[%v] moved from region[%v] rack[%v] to region[%v] rack[%v]",
+		dataNode.Addr, oldRegion, oldRack, region, rack)
+}
+
+// updateMetaNodeTopology moves the registered meta node to the region and the rack it reports again.
+func (c *Cluster) updateMetaNodeTopology(metaNode *MetaNode, region, rack string) {
+	region, rack = nodeTopology(region, rack)
+	metaNode.Lock()
+	oldRegion, oldRack := metaNode.Region, metaNode.Rack
+	if oldRegion == region && oldRack == rack {
+		metaNode.Unlock()
+		return
+	}
+	metaNode.Region, metaNode.Rack = region, rack
+	metaNode.Unlock()
+	if err := c.syncUpdateMetaNode(metaNode); err != nil {
+		metaNode.Lock()
+		metaNode.Region, metaNode.Rack = oldRegion, oldRack
+		metaNode.Unlock()
+		log.LogErrorf("action[updateMetaNodeTopology] metaNode[%v] region[%v] rack[%v] err[%v]", metaNode.Addr, region, rack, err)
+		return
+	}
+	log.LogWarnf("action[updateMetaNodeTopology] metaNode[%v] moved from region[%v] rack[%v] to region[%v] rack[%v]",
+		metaNode.Addr, oldRegion, oldRack, region, rack)
+}
+
+// usedFaultDomains returns the racks and the hosts of the excluded hosts, which hold the other replicas of the partition.
+func usedFaultDomains(nodes *sync.Map, excludeHosts []string) (racks, hosts map[string]bool) {
+	racks = make(map[string]bool)
+	hosts = make(map[string]bool)
+	for _, addr := range excludeHosts {
+		if value, ok := nodes.Load(addr); ok {
+			if node, ok := value.(Node); ok {
+				racks[node.GetRackPath()] = true
+			}
+		}
+		hosts[nodeHost(addr)] = true
+	}
+	return
+}
+
+// spreadOverFaultDomains picks replicaNum nodes in the order of the carry, the nodes in the unused racks first,
+// then the nodes on the unused hosts, so that a partition survives the failure of a rack or a host if possible.
+func (nodes SortedWeightedNodes) spreadOverFaultDomains(usedRacks, usedHosts map[string]bool, replicaNum int) (selected SortedWeightedNodes) {
+	selected = make(SortedWeightedNodes, 0, replicaNum)
+	picked := make([]bool, len(nodes))
+	for round := 0; round < 3 && len(selected) < replicaNum; round++ {
+		for i, nt := range nodes {
+			if picked[i] {
+				continue
+			}
+			rack, host := nt.Ptr.GetRackPath(), nodeHost(nt.Ptr.GetAddr())
+			if (round == 0 && usedRacks[rack]) || (round <= 1 && usedHosts[host]) {
+				continue
+			}
+			picked[i] = true
+			usedRacks[rack], usedHosts[host] = true, true
+			if selected = append(selected, nt); len(selected) == replicaNum {
+				return
+			}
+		}
+	}
+	return
+}
+
+// getFaultDomains aggregates the capacity of the data nodes and the meta nodes by the fault domains
+// from the regions down to the level.
+func (c *Cluster) getFaultDomains(level string) (regions []*proto.FaultDomainView) {
+	depth := len(faultDomainLevels)
+	for i, l := range faultDomainLevels {
+		if l == level {
+			depth = i + 1
+		}
+	}
+	regions = make([]*proto.FaultDomainView, 0)
+	domains := make(map[string]*proto.FaultDomainView)
+	faultDomainsOf := func(region, zone, rack, addr string) (chain []*proto.FaultDomainView) {
+		names := []string{region, zone, rack, nodeHost(addr)}[:depth]
+		var parent *proto.FaultDomainView
+		for i, name := range names {
+			path := topologyPath(names[:i+1]...)
+			fd, ok := domains[path]
+			if !ok {
+				fd = &proto.FaultDomainView{
+					Name:         name,
+					Level:        faultDomainLevels[i],
+					Path:         path,
+					DataNodeStat: new(proto.ZoneNodesStat),
+					MetaNodeStat: new(proto.ZoneNodesStat),
+				}
+				domains[path] = fd
+				if parent == nil {
+					regions = append(regions, fd)
+				} else {
+					parent.Children = append(parent.Children, fd)
+				}
+			}
+			chain = append(chain, fd)
+			parent = fd
+		}
+		return
+	}
+	c.dataNodes.Range(func(key, value interface{}) bool {
+		dataNode := value.(*DataNode)
+		dataNode.RLock()
+		region, zone, rack, addr := dataNode.Region, dataNode.ZoneName, dataNode.Rack, dataNode.Addr
+		total, used := dataNode.Total, dataNode.Used
+		dataNode.RUnlock()
+		writable := dataNode.isWriteAble()
+		for _, fd := range faultDomainsOf(region, zone, rack, addr) {
+			addFaultDomainStat(fd.DataNodeStat, total, used, writable)
+		}
+		return true
+	})
+	c.metaNodes.Range(func(key, value interface{}) bool {
+		metaNode := value.(*MetaNode)
+		metaNode.RLock()
+		region, zone, rack, addr := metaNode.Region, metaNode.ZoneName, metaNode.Rack, metaNode.Addr
+		total, used := metaNode.Total, metaNode.Used
+		metaNode.RUnlock()
+		writable := metaNode.isWritable()
+		for _, fd := range faultDomainsOf(region, zone, rack, addr) {
+			addFaultDomainStat(fd.MetaNodeStat, total, used, writable)
+		}
+		return true
+	})
+	sortFaultDomains(regions)
+	return
+}
+
+func addFaultDomainStat(stat *proto.ZoneNodesStat, total, used uint64, writable bool) {
+	stat.TotalNodes++
+	if writable {
+		stat.WritableNodes++
+	}
+	stat.Total += float64(total) / float64(util.GB)
+	stat.Used += float64(used) / float64(util.GB)
+}
+
+func fixFaultDomainStat(stat *proto.ZoneNodesStat) {
+	stat.Total = fixedPoint(stat.Total, 2)
+	stat.Used = fixedPoint(stat.Used, 2)
+	stat.Avail = fixedPoint(stat.Total-stat.Used, 2)
+	if stat.Total > 0 {
+		stat.UsedRatio = fixedPoint(stat.Used/stat.Total, 2)
+	}
+}
+
+func sortFaultDomains(domains []*proto.FaultDomainView) {
+	sort.Slice(domains, func(i, j int) bool { return domains[i].Name < domains[j].Name })
+	for _, fd := range domains {
+		fixFaultDomainStat(fd.DataNodeStat)
+		fixFaultDomainStat(fd.MetaNodeStat)
+		sortFaultDomains(fd.Children)
+	}
+}
+
+// findFaultDomain returns the fault domain of the topology path, such as region/zone/rack.
+func findFaultDomain(domains []*proto.FaultDomainView, path string) *proto.FaultDomainView {
+	for _, fd := range domains {
+		if fd.Path == path {
+			return fd
+		}
+		if strings.HasPrefix(path, fd.Path+topologyPathSeparator) {
+			return findFaultDomain(fd.Children, path)
+		}
+	}
+	return nil
+}
+
+func (m *Server) getFaultDomains(w http.ResponseWriter, r *http.Request) {
+	domains := m.cluster.getFaultDomains(proto.FaultDomainHost)
+	path := strings.Trim(r.FormValue(pathKey), topologyPathSeparator)
+	if path == "" {
+		sendOkReply(w, r, newSuccessHTTPReply(domains))
+		return
+	}
+	fd := findFaultDomain(domains, path)
+	if fd == nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: fmt.Sprintf("fault domain[%v] not found", path)})
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply([]*proto.FaultDomainView{fd}))
+}
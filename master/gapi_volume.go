@@ -205,7 +205,7 @@ func (s *VolumeService) createVolume(ctx context.Context, args struct {
 		return nil, fmt.Errorf("[%s] not has permission to create volume for [%s]", uid, args.Owner)
 	}
 
-	vol, err := s.cluster.createVol(args.Name, args.Owner, args.ZoneName, args.Description, int(args.MpCount), int(args.DpReplicaNum), int(args.DataPartitionSize), int(args.Capacity), args.FollowerRead, args.Authenticate, args.CrossZone, args.EnableToken)
+	vol, err := s.cluster.createVol(args.Name, args.Owner, args.ZoneName, args.Description, int(args.MpCount), int(args.DpReplicaNum), int(args.DataPartitionSize), int(args.Capacity), args.FollowerRead, args.Authenticate, args.CrossZone, args.EnableToken, false, false, false)
 	if err != nil {
 		return nil, err
 	}
@@ -256,7 +256,7 @@ func (s *VolumeService) markDeleteVol(ctx context.Context, args struct {
 		return nil, err
 	}
 
-	if err = s.cluster.markDeleteVol(args.Name, args.AuthKey); err != nil {
+	if err = s.cluster.markDeleteVol(args.Name, args.AuthKey, false); err != nil {
 		return nil, err
 	}
 
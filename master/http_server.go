@@ -26,6 +26,7 @@ import (
 	"github.com/gorilla/mux"
 
 	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util"
 	"github.com/chubaofs/chubaofs/util/config"
 	"github.com/chubaofs/chubaofs/util/exporter"
 	"github.com/chubaofs/chubaofs/util/log"
@@ -41,6 +42,13 @@ func (m *Server) startHTTPService(modulename string, cfg *config.Config) {
 		Handler: router,
 	}
 	var serveAPI = func() {
+		if tlsConfig := util.TLSServerConfig(); tlsConfig != nil {
+			server.TLSConfig = tlsConfig
+			if err := server.ListenAndServeTLS("", ""); err != nil {
+				log.LogErrorf("serveAPI: serve https server failed: err(%v)", err)
+			}
+			return
+		}
 		if err := server.ListenAndServe(); err != nil {
 			log.LogErrorf("serveAPI: serve http server failed: err(%v)", err)
 			return
@@ -56,13 +64,17 @@ func (m *Server) registerAPIMiddleware(route *mux.Router) {
 		return http.HandlerFunc(
 			func(w http.ResponseWriter, r *http.Request) {
 				log.LogDebugf("action[interceptor] request, method[%v] path[%v] query[%v]", r.Method, r.URL.Path, r.URL.Query())
-				if mux.CurrentRoute(r).GetName() == proto.AdminGetIP {
+				// the clients learn the leader from the responses of any master
+				if leaderAddr := m.leaderInfo.addr; leaderAddr != "" {
+					w.Header().Set(proto.MasterLeader, leaderAddr)
+				}
+				if name := mux.CurrentRoute(r).GetName(); name == proto.AdminGetIP || name == proto.AdminGetRaftStatus {
 					next.ServeHTTP(w, r)
 					return
 				}
 				if m.partition.IsRaftLeader() {
 					if m.metaReady {
-						next.ServeHTTP(w, r)
+						m.serveAudited(next, w, r)
 						return
 					}
 					log.LogWarnf("action[interceptor] leader meta has not ready")
@@ -74,6 +86,12 @@ func (m *Server) registerAPIMiddleware(route *mux.Router) {
 					http.Error(w, "no leader", http.StatusBadRequest)
 					return
 				}
+				// the masters disagree on the leader during the election, the client retries the other masters
+				if proxiedBy := r.Header.Get(proto.MasterProxiedBy); proxiedBy != "" {
+					log.LogWarnf("action[interceptor] not leader, request[%v] proxied by[%v] leader[%v]", r.URL, proxiedBy, m.leaderInfo.addr)
+					http.Error(w, "not leader", http.StatusBadRequest)
+					return
+				}
 				m.proxy(w, r)
 			})
 	}
@@ -96,12 +114,25 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 		Methods(http.MethodGet).
 		Path(proto.AdminGetIP).
 		HandlerFunc(m.getIPAddr)
+	router.NewRoute().Name(proto.AdminGetRaftStatus).
+		Methods(http.MethodGet).
+		Path(proto.AdminGetRaftStatus).
+		HandlerFunc(m.getRaftStatus)
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.AdminGetCluster).
 		HandlerFunc(m.getCluster)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetAudit).
+		HandlerFunc(m.getAdminAudit)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminClusterFreeze).
 		HandlerFunc(m.setupAutoAllocation)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminClusterUpgrade).
+		HandlerFunc(m.setupUpgrade)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminUpgradeStatus).
+		HandlerFunc(m.getUpgradeStatus)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AddRaftNode).
 		HandlerFunc(m.addRaftNode)
@@ -109,6 +140,7 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 		Path(proto.RemoveRaftNode).
 		HandlerFunc(m.removeRaftNode)
 	router.NewRoute().Methods(http.MethodGet).Path(proto.AdminClusterStat).HandlerFunc(m.clusterStat)
+	router.NewRoute().Methods(http.MethodGet).Path(proto.AdminClusterOverview).HandlerFunc(m.clusterOverview)
 
 	// volume management APIs
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
@@ -120,6 +152,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminDeleteVol).
 		HandlerFunc(m.markDeleteVol)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminRestoreVol).
+		HandlerFunc(m.restoreVol)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminUpdateVol).
 		HandlerFunc(m.updateVol)
@@ -129,15 +164,45 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminVolExpand).
 		HandlerFunc(m.volExpand)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminVolCapacityProgress).
+		HandlerFunc(m.getVolCapacityProgress)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminVolUsage).
+		HandlerFunc(m.getVolUsage)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetVolDataKey).
+		HandlerFunc(m.getVolDataKey)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminRotateVolKey).
+		HandlerFunc(m.rotateVolKey)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminVolReadOnly).
+		HandlerFunc(m.setVolReadOnly)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminRenameVol).
+		HandlerFunc(m.renameVol)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminVolReplicaNum).
+		HandlerFunc(m.setVolReplicaNum)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminVolReplicaNumProgress).
+		HandlerFunc(m.getVolReplicaNumProgress)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.ClientVol).
 		HandlerFunc(m.getVol)
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.ClientVolStat).
 		HandlerFunc(m.getVolStatInfo)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.ClientVolAccessToken).
+		HandlerFunc(m.getVolAccessToken)
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.GetTopologyView).
 		HandlerFunc(m.getTopology)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.GetFaultDomains).
+		HandlerFunc(m.getFaultDomains)
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.AdminListVols).
 		HandlerFunc(m.listVols)
@@ -154,6 +219,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminLoadMetaPartition).
 		HandlerFunc(m.loadMetaPartition)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminVerifyMetaPartition).
+		HandlerFunc(m.verifyMetaPartition)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminDecommissionMetaPartition).
 		HandlerFunc(m.decommissionMetaPartition)
@@ -192,9 +260,18 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminDiagnoseDataPartition).
 		HandlerFunc(m.diagnoseDataPartition)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminDataPartitionDivergence).
+		HandlerFunc(m.getDataPartitionDivergence)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminRepairDataPartition).
+		HandlerFunc(m.repairDataPartition)
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.ClientDataPartitions).
 		HandlerFunc(m.getDataPartitions)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.ClientDataPartitionLocations).
+		HandlerFunc(m.getDataPartitionLocations)
 
 	// meta node management APIs
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
@@ -203,6 +280,12 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.DecommissionMetaNode).
 		HandlerFunc(m.decommissionMetaNode)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.DecommissionMetaNodeProgress).
+		HandlerFunc(m.getMetaNodeDecommissionProgress)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.TransferMetaNodeLeaders).
+		HandlerFunc(m.transferMetaNodeLeaders)
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.GetMetaNode).
 		HandlerFunc(m.getMetaNode)
@@ -232,6 +315,12 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.DecommissionDataNode).
 		HandlerFunc(m.decommissionDataNode)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.DecommissionDataNodeProgress).
+		HandlerFunc(m.getDataNodeDecommissionProgress)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.TransferDataNodeLeaders).
+		HandlerFunc(m.transferDataNodeLeaders)
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.GetDataNode).
 		HandlerFunc(m.getDataNode)
@@ -273,6 +362,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.UserList).
 		HandlerFunc(m.getAllUsers)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.UserAuthorize).
+		HandlerFunc(m.authorizeUser)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.UserTransferVol).
 		HandlerFunc(m.transferUserVol)
@@ -287,6 +379,18 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.GetAllZones).
 		HandlerFunc(m.listZone)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetZonePlacement).
+		HandlerFunc(m.getZonePlacement)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminRepairZonePlacement).
+		HandlerFunc(m.repairZonePlacement)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetRebalance).
+		HandlerFunc(m.getRebalance)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSetRebalance).
+		HandlerFunc(m.setRebalance)
 
 	// APIs for token-based client permissions control
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
@@ -301,6 +405,31 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.TokenUpdateURI).
 		HandlerFunc(m.updateToken)
+
+	// APIs for directory quota
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.QuotaSet).
+		HandlerFunc(m.setQuota)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.QuotaDelete).
+		HandlerFunc(m.deleteQuota)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.QuotaList).
+		HandlerFunc(m.listQuota)
+
+	// APIs for client sessions
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.ClientSessionRegister).
+		HandlerFunc(m.registerClientSession)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.ClientSessionHeartbeat).
+		HandlerFunc(m.clientSessionHeartbeat)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminListClients).
+		HandlerFunc(m.listClientSessions)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminEvictClient).
+		HandlerFunc(m.evictClientSession)
 }
 
 func (m *Server) registerHandler(router *mux.Router, model string, schema *graphql.Schema) {
@@ -347,8 +476,12 @@ func ErrResponse(w http.ResponseWriter, err error) {
 func (m *Server) newReverseProxy() *httputil.ReverseProxy {
 	return &httputil.ReverseProxy{Director: func(request *http.Request) {
 		request.URL.Scheme = "http"
+		if util.TLSEnabled() {
+			request.URL.Scheme = "https"
+		}
 		request.URL.Host = m.leaderInfo.addr
-	}}
+		request.Header.Set(proto.MasterProxiedBy, AddrDatabase[m.id])
+	}, Transport: util.TLSTransport()}
 }
 
 func (m *Server) proxy(w http.ResponseWriter, r *http.Request) {
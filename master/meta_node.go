@@ -43,12 +43,17 @@ type MetaNode struct {
 	sync.RWMutex              `graphql:"-"`
 	ToBeOffline               bool
 	PersistenceMetaPartitions []uint64
+	Version                   string
+	Region                    string
+	Rack                      string
 }
 
 func newMetaNode(addr, zoneName, clusterID string) (node *MetaNode) {
 	return &MetaNode{
 		Addr:     addr,
 		ZoneName: zoneName,
+		Region:   DefaultRegionName,
+		Rack:     DefaultRackName,
 		Sender:   newAdminTaskManager(addr, clusterID),
 		Carry:    rand.Float64(),
 	}
@@ -116,6 +121,7 @@ func (metaNode *MetaNode) updateMetric(resp *proto.MetaNodeHeartbeatResponse, th
 	metaNode.MetaPartitionCount = len(metaNode.metaPartitionInfos)
 	metaNode.Total = resp.Total
 	metaNode.Used = resp.Used
+	metaNode.Version = resp.Version
 	if resp.Total == 0 {
 		metaNode.Ratio = 0
 	} else {
@@ -133,10 +139,16 @@ func (metaNode *MetaNode) reachesThreshold() bool {
 	return float32(float64(metaNode.Used)/float64(metaNode.Total)) > metaNode.Threshold
 }
 
-func (metaNode *MetaNode) createHeartbeatTask(masterAddr string) (task *proto.AdminTask) {
+func (metaNode *MetaNode) createHeartbeatTask(masterAddr string, deadClients []uint64, readOnlyVols []string, renamedVols map[string]string,
+	permVols []string, quotas map[string][]*proto.QuotaInfo) (task *proto.AdminTask) {
 	request := &proto.HeartBeatRequest{
-		CurrTime:   time.Now().Unix(),
-		MasterAddr: masterAddr,
+		CurrTime:     time.Now().Unix(),
+		MasterAddr:   masterAddr,
+		DeadClients:  deadClients,
+		ReadOnlyVols: readOnlyVols,
+		RenamedVols:  renamedVols,
+		PermVols:     permVols,
+		Quotas:       quotas,
 	}
 	task = proto.NewAdminTask(proto.OpMetaNodeHeartbeat, metaNode.Addr, request)
 	return
@@ -28,3 +28,33 @@ func decommissionMetaNode(addr string, t *testing.T) {
 	fmt.Println(reqURL)
 	process(reqURL, t)
 }
+
+func TestMetaNodeGracefulDecommission(t *testing.T) {
+	addr := "127.0.0.1:8107"
+	addMetaServer(addr, testZone2)
+	server.cluster.checkMetaNodeHeartbeat()
+	time.Sleep(5 * time.Second)
+	reqURL := fmt.Sprintf("%v%v?addr=%v&graceful=true", hostAddr, proto.DecommissionMetaNode, addr)
+	process(reqURL, t)
+	var progress *proto.DecommissionProgress
+	for i := 0; i < 30; i++ {
+		var err error
+		if progress, err = server.cluster.getDecommissionProgress(nodeTypeMetaNode, addr); err != nil {
+			t.Error(err)
+			return
+		}
+		if progress.Status == decommissionFinished || progress.Status == decommissionFailed {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	if progress.Status != decommissionFinished {
+		t.Errorf("expect decommission finished, but is %v", progress)
+		return
+	}
+	reqURL = fmt.Sprintf("%v%v?addr=%v", hostAddr, proto.DecommissionMetaNodeProgress, addr)
+	process(reqURL, t)
+	if _, err := server.cluster.metaNode(addr); err == nil {
+		t.Errorf("decommission metanode [%v] failed", addr)
+	}
+}
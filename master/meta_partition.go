@@ -60,6 +60,9 @@ type MetaPartition struct {
 	OfflinePeerID uint64
 	MissNodes     map[string]int64
 	LoadResponse  []*proto.MetaPartitionLoadResponse
+	quotaUsage    []*proto.QuotaUsage
+	quotaRoots    []*proto.QuotaRoot // the quotas rooted in the partition with the quotas enclosing them
+	usage         *proto.DirStat
 	offlineMutex  sync.RWMutex
 	sync.RWMutex
 }
@@ -341,6 +344,11 @@ func (mp *MetaPartition) updateMetaPartition(mgr *proto.MetaPartitionReport, met
 		mp.addReplica(mr)
 	}
 	mr.updateMetric(mgr)
+	if mgr.IsLeader {
+		mp.quotaUsage = mgr.QuotaUsage
+		mp.quotaRoots = mgr.QuotaRoots
+		mp.usage = mgr.Usage
+	}
 	mp.setMaxInodeID()
 	mp.setInodeCount()
 	mp.setDentryCount()
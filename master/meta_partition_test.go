@@ -106,3 +106,34 @@ func decommissionMetaPartition(vol *Vol, id uint64, t *testing.T) {
 		return
 	}
 }
+
+func TestVerifyMetaPartition(t *testing.T) {
+	server.cluster.checkMetaNodeHeartbeat()
+	time.Sleep(5 * time.Second)
+	mp, err := commonVol.metaPartition(commonVol.maxPartitionID())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	result, err := server.cluster.verifyMetaPartition(mp)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !result.Consistent || len(result.Replicas) != len(mp.Replicas) {
+		t.Errorf("expect the replicas consistent, but is %v", result.Mismatches)
+		return
+	}
+	reqURL := fmt.Sprintf("%v%v?id=%v", hostAddr, proto.AdminVerifyMetaPartition, mp.PartitionID)
+	process(reqURL, t)
+
+	diverged := *result.Replicas[len(result.Replicas)-1]
+	diverged.Trees = []*proto.MetaTreeDigest{
+		{Tree: "inode", Count: 123456, Hash: 0x4321},
+		{Tree: "dentry", Count: 123456, Hash: 0x5678},
+	}
+	mismatches := compareMetaTreeDigests(append(result.Replicas[:len(result.Replicas)-1], &diverged))
+	if len(mismatches) != 1 {
+		t.Errorf("expect the inode tree diverges, but is %v", mismatches)
+	}
+}
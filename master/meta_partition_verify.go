@@ -0,0 +1,145 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	metaVerifyPollInterval = time.Second
+	metaVerifyMaxPolls     = 60
+)
+
+func (mr *MetaReplica) createTaskToVerifyMetaPartition(partitionID, verifyID uint64, start bool) (t *proto.AdminTask) {
+	req := &proto.MetaPartitionVerifyRequest{PartitionID: partitionID, VerifyID: verifyID, Start: start}
+	t = proto.NewAdminTask(proto.OpVerifyMetaPartition, mr.Addr, req)
+	resetMetaPartitionTaskID(t, partitionID)
+	return
+}
+
+func (mr *MetaReplica) verifyMetaPartition(partitionID, verifyID uint64, start bool) (resp *proto.MetaPartitionVerifyResponse, err error) {
+	task := mr.createTaskToVerifyMetaPartition(partitionID, verifyID, start)
+	response, err := mr.metaNode.Sender.syncSendAdminTask(task)
+	if err != nil {
+		return
+	}
+	resp = &proto.MetaPartitionVerifyResponse{}
+	if err = json.Unmarshal(response.Data, resp); err != nil {
+		return
+	}
+	resp.Addr = mr.Addr
+	return
+}
+
+// verifyMetaPartition asks the leader to propose the verification through raft, so that each replica
+// takes the digests of its meta trees at the same log index, then collects and compares the digests.
+func (c *Cluster) verifyMetaPartition(mp *MetaPartition) (result *proto.MetaPartitionVerifyResult, err error) {
+	mp.RLock()
+	leader, err := mp.getMetaReplicaLeader()
+	replicas := make([]*MetaReplica, 0, len(mp.Replicas))
+	if err == nil {
+		replicas = append(replicas, leader)
+	}
+	for _, mr := range mp.Replicas {
+		if mr != leader {
+			replicas = append(replicas, mr)
+		}
+	}
+	mp.RUnlock()
+	if err != nil {
+		return
+	}
+
+	verifyID := uint64(time.Now().UnixNano())
+	if _, err = leader.verifyMetaPartition(mp.PartitionID, verifyID, true); err != nil {
+		return
+	}
+	responses := make([]*proto.MetaPartitionVerifyResponse, len(replicas))
+	for i := 0; i < metaVerifyMaxPolls; i++ {
+		done := true
+		for j, mr := range replicas {
+			if responses[j] != nil && responses[j].Done {
+				continue
+			}
+			resp, err1 := mr.verifyMetaPartition(mp.PartitionID, verifyID, false)
+			if err1 != nil {
+				log.LogWarnf("action[verifyMetaPartition] partitionID[%v] addr[%v] err[%v]", mp.PartitionID, mr.Addr, err1)
+				resp = &proto.MetaPartitionVerifyResponse{PartitionID: mp.PartitionID, VerifyID: verifyID, Addr: mr.Addr}
+			}
+			responses[j] = resp
+			done = done && resp.Done
+		}
+		if done {
+			break
+		}
+		time.Sleep(metaVerifyPollInterval)
+	}
+
+	result = &proto.MetaPartitionVerifyResult{
+		PartitionID: mp.PartitionID,
+		VolName:     mp.volName,
+		VerifyID:    verifyID,
+		Replicas:    responses,
+		Mismatches:  compareMetaTreeDigests(responses),
+	}
+	result.Consistent = len(result.Mismatches) == 0
+	if !result.Consistent {
+		Warn(c.Name, fmt.Sprintf("action[verifyMetaPartition] vol[%v] partitionID[%v] replicas diverge: %v",
+			mp.volName, mp.PartitionID, result.Mismatches))
+	}
+	return
+}
+
+// compareMetaTreeDigests compares the digests of the replicas with those of the first one, the leader.
+func compareMetaTreeDigests(responses []*proto.MetaPartitionVerifyResponse) (mismatches []string) {
+	mismatches = make([]string, 0)
+	var base *proto.MetaPartitionVerifyResponse
+	for _, resp := range responses {
+		if !resp.Done {
+			mismatches = append(mismatches, fmt.Sprintf("replica[%v] has not taken the digests", resp.Addr))
+			continue
+		}
+		if base == nil {
+			base = resp
+			continue
+		}
+		if resp.ApplyID != base.ApplyID {
+			mismatches = append(mismatches, fmt.Sprintf("replica[%v] applyID[%v] replica[%v] applyID[%v]",
+				base.Addr, base.ApplyID, resp.Addr, resp.ApplyID))
+		}
+		digests := make(map[string]*proto.MetaTreeDigest, len(resp.Trees))
+		for _, digest := range resp.Trees {
+			digests[digest.Tree] = digest
+		}
+		for _, expect := range base.Trees {
+			actual, ok := digests[expect.Tree]
+			if !ok {
+				mismatches = append(mismatches, fmt.Sprintf("%v: replica[%v] has no digest", expect.Tree, resp.Addr))
+				continue
+			}
+			if actual.Count != expect.Count || actual.Hash != expect.Hash {
+				mismatches = append(mismatches, fmt.Sprintf("%v: replica[%v] count[%v] hash[%x], replica[%v] count[%v] hash[%x]",
+					expect.Tree, base.Addr, expect.Count, expect.Hash, resp.Addr, actual.Count, actual.Hash))
+			}
+		}
+	}
+	return
+}
@@ -39,6 +39,16 @@ type clusterValue struct {
 	MetaNodeDeleteBatchCount    uint64
 	MetaNodeDeleteWorkerSleepMs uint64
 	DataNodeAutoRepairLimitRate uint64
+	DataNodeDiskWriteLimitRate  uint64
+	DataNodeDiskRepairLimitRate uint64
+	DataNodeDpWriteLimitRate    uint64
+	ClusterDeleteLimitRate      uint64
+	RebalanceEnable             bool
+	RebalanceMetaEnable         bool
+	RebalanceDryRun             bool
+	RebalanceMaxMigrations      int
+	RebalanceThreshold          float64
+	Upgrading                   bool
 }
 
 func newClusterValue(c *Cluster) (cv *clusterValue) {
@@ -49,8 +59,16 @@ func newClusterValue(c *Cluster) (cv *clusterValue) {
 		MetaNodeDeleteBatchCount:    c.cfg.MetaNodeDeleteBatchCount,
 		MetaNodeDeleteWorkerSleepMs: c.cfg.MetaNodeDeleteWorkerSleepMs,
 		DataNodeAutoRepairLimitRate: c.cfg.DataNodeAutoRepairLimitRate,
+		DataNodeDiskWriteLimitRate:  c.cfg.DataNodeDiskWriteLimitRate,
+		DataNodeDiskRepairLimitRate: c.cfg.DataNodeDiskRepairLimitRate,
+		DataNodeDpWriteLimitRate:    c.cfg.DataNodeDpWriteLimitRate,
+		ClusterDeleteLimitRate:      c.cfg.ClusterDeleteLimitRate,
 		DisableAutoAllocate:         c.DisableAutoAllocate,
+		Upgrading:                   c.Upgrading,
 	}
+	cfg := c.rebalance.getConfig()
+	cv.RebalanceEnable, cv.RebalanceMetaEnable, cv.RebalanceDryRun = cfg.enable, cfg.metaEnable, cfg.dryRun
+	cv.RebalanceMaxMigrations, cv.RebalanceThreshold = cfg.maxMigrations, cfg.threshold
 	return cv
 }
 
@@ -96,6 +114,9 @@ type dataPartitionValue struct {
 	OfflinePeerID uint64
 	Replicas      []*replicaValue
 	IsRecover     bool
+	IsManual      bool
+	MediaType     string
+	TierTime      int64
 }
 
 type replicaValue struct {
@@ -115,6 +136,9 @@ func newDataPartitionValue(dp *DataPartition) (dpv *dataPartitionValue) {
 		OfflinePeerID: dp.OfflinePeerID,
 		Replicas:      make([]*replicaValue, 0),
 		IsRecover:     dp.isRecover,
+		IsManual:      dp.isManual,
+		MediaType:     dp.mediaType,
+		TierTime:      dp.tierTime,
 	}
 	for _, replica := range dp.Replicas {
 		rv := &replicaValue{Addr: replica.Addr, DiskPath: replica.DiskPath}
@@ -143,6 +167,23 @@ type volValue struct {
 	Description       string
 	DpSelectorName    string
 	DpSelectorParm    string
+	Quotas            []*bsProto.QuotaInfo
+	TrashDays         uint32
+	HotMedia          string
+	ColdMedia         string
+	ColdDays          uint32
+	CaseInsensitive   bool
+	EnforcePerm       bool
+	SmallFileSize     uint32
+	DisablePacking    bool // SmallFileSize of 0 is the default of the volumes created before it
+	DataKeyID         string
+	WrappedDataKey    []byte
+	Flat              bool
+	PermPriority      string
+	ReadOnly          bool
+	Aliases           []string
+	Cache             bool
+	PurgeTime         int64
 }
 
 func (v *volValue) Bytes() (raw []byte, err error) {
@@ -171,6 +212,23 @@ func newVolValue(vol *Vol) (vv *volValue) {
 		Description:       vol.description,
 		DpSelectorName:    vol.dpSelectorName,
 		DpSelectorParm:    vol.dpSelectorParm,
+		Quotas:            vol.getQuotaLimits(),
+		TrashDays:         vol.trashDays,
+		HotMedia:          vol.hotMedia,
+		ColdMedia:         vol.coldMedia,
+		ColdDays:          vol.coldDays,
+		CaseInsensitive:   vol.caseInsensitive,
+		EnforcePerm:       vol.enforcePerm,
+		SmallFileSize:     vol.smallFileSize,
+		DisablePacking:    vol.smallFileSize == 0,
+		DataKeyID:         vol.dataKeyID,
+		WrappedDataKey:    vol.wrappedDataKey,
+		Flat:              vol.flat,
+		PermPriority:      vol.permissionPriority,
+		ReadOnly:          vol.readOnly,
+		Aliases:           vol.aliases,
+		Cache:             vol.cache,
+		PurgeTime:         vol.purgeTime,
 	}
 	return
 }
@@ -188,6 +246,8 @@ type dataNodeValue struct {
 	NodeSetID uint64
 	Addr      string
 	ZoneName  string
+	Region    string
+	Rack      string
 }
 
 func newDataNodeValue(dataNode *DataNode) *dataNodeValue {
@@ -196,6 +256,8 @@ func newDataNodeValue(dataNode *DataNode) *dataNodeValue {
 		NodeSetID: dataNode.NodeSetID,
 		Addr:      dataNode.Addr,
 		ZoneName:  dataNode.ZoneName,
+		Region:    dataNode.Region,
+		Rack:      dataNode.Rack,
 	}
 }
 
@@ -204,6 +266,8 @@ type metaNodeValue struct {
 	NodeSetID uint64
 	Addr      string
 	ZoneName  string
+	Region    string
+	Rack      string
 }
 
 func newMetaNodeValue(metaNode *MetaNode) *metaNodeValue {
@@ -212,6 +276,8 @@ func newMetaNodeValue(metaNode *MetaNode) *metaNodeValue {
 		NodeSetID: metaNode.NodeSetID,
 		Addr:      metaNode.Addr,
 		ZoneName:  metaNode.ZoneName,
+		Region:    metaNode.Region,
+		Rack:      metaNode.Rack,
 	}
 }
 
@@ -550,10 +616,22 @@ func (c *Cluster) loadClusterValue() (err error) {
 		}
 		c.cfg.MetaNodeThreshold = cv.Threshold
 		c.DisableAutoAllocate = cv.DisableAutoAllocate
+		c.Upgrading = cv.Upgrading
 		c.updateMetaNodeDeleteBatchCount(cv.MetaNodeDeleteBatchCount)
 		c.updateMetaNodeDeleteWorkerSleepMs(cv.MetaNodeDeleteWorkerSleepMs)
 		c.updateDataNodeDeleteLimitRate(cv.DataNodeDeleteLimitRate)
 		c.updateDataNodeAutoRepairLimit(cv.DataNodeAutoRepairLimitRate)
+		atomic.StoreUint64(&c.cfg.DataNodeDiskWriteLimitRate, cv.DataNodeDiskWriteLimitRate)
+		atomic.StoreUint64(&c.cfg.DataNodeDiskRepairLimitRate, cv.DataNodeDiskRepairLimitRate)
+		atomic.StoreUint64(&c.cfg.DataNodeDpWriteLimitRate, cv.DataNodeDpWriteLimitRate)
+		atomic.StoreUint64(&c.cfg.ClusterDeleteLimitRate, cv.ClusterDeleteLimitRate)
+		c.rebalance.setConfig(rebalanceConfig{
+			enable:        cv.RebalanceEnable,
+			metaEnable:    cv.RebalanceMetaEnable,
+			dryRun:        cv.RebalanceDryRun,
+			maxMigrations: cv.RebalanceMaxMigrations,
+			threshold:     cv.RebalanceThreshold,
+		})
 		log.LogInfof("action[loadClusterValue], metaNodeThreshold[%v]", cv.Threshold)
 	}
 	return
@@ -606,6 +684,7 @@ func (c *Cluster) loadDataNodes() (err error) {
 		dataNode := newDataNode(dnv.Addr, dnv.ZoneName, c.Name)
 		dataNode.ID = dnv.ID
 		dataNode.NodeSetID = dnv.NodeSetID
+		dataNode.Region, dataNode.Rack = nodeTopology(dnv.Region, dnv.Rack)
 		olddn, ok := c.dataNodes.Load(dataNode.Addr)
 		if ok {
 			if olddn.(*DataNode).ID <= dataNode.ID {
@@ -636,6 +715,7 @@ func (c *Cluster) loadMetaNodes() (err error) {
 		metaNode := newMetaNode(mnv.Addr, mnv.ZoneName, c.Name)
 		metaNode.ID = mnv.ID
 		metaNode.NodeSetID = mnv.NodeSetID
+		metaNode.Region, metaNode.Rack = nodeTopology(mnv.Region, mnv.Rack)
 		oldmn, ok := c.metaNodes.Load(metaNode.Addr)
 		if ok {
 			if oldmn.(*MetaNode).ID <= metaNode.ID {
@@ -735,11 +815,14 @@ func (c *Cluster) loadDataPartitions() (err error) {
 				dpv.Peers[i].ID = dn.(*DataNode).ID
 			}
 		}
-		dp := newDataPartition(dpv.PartitionID, dpv.ReplicaNum, dpv.VolName, dpv.VolID)
+		dp := newDataPartition(dpv.PartitionID, dpv.ReplicaNum, vol.Name, dpv.VolID)
 		dp.Hosts = strings.Split(dpv.Hosts, underlineSeparator)
 		dp.Peers = dpv.Peers
 		dp.OfflinePeerID = dpv.OfflinePeerID
 		dp.isRecover = dpv.IsRecover
+		dp.isManual = dpv.IsManual
+		dp.mediaType = dpv.MediaType
+		dp.tierTime = dpv.TierTime
 		for _, rv := range dpv.Replicas {
 			if !contains(dp.Hosts, rv.Addr) {
 				continue
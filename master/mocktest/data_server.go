@@ -140,6 +140,9 @@ func (mds *MockDataServer) serveConn(rc net.Conn) {
 	case proto.OpDataPartitionTryToLeader:
 		err = mds.handleTryToLeader(conn, req, adminTask)
 		fmt.Printf("data node [%v] try to leader,id[%v],err:%v\n", mds.TcpAddr, adminTask.ID, err)
+	case proto.OpRepairDataPartitionExtents:
+		responseAckOKToMaster(conn, req, nil)
+		fmt.Printf("data node [%v] repair data partition extents,id[%v]\n", mds.TcpAddr, adminTask.ID)
 	default:
 		fmt.Printf("unknown code [%v]\n", req.Opcode)
 	}
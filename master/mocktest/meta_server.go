@@ -135,6 +135,9 @@ func (mms *MockMetaServer) serveConn(rc net.Conn) {
 	case proto.OpMetaPartitionTryToLeader:
 		err = mms.handleTryToLeader(conn, req, adminTask)
 		fmt.Printf("meta node [%v] try to leader,id[%v],err:%v\n", mms.TcpAddr, adminTask.ID, err)
+	case proto.OpVerifyMetaPartition:
+		err = mms.handleVerifyMetaPartition(conn, req, adminTask)
+		fmt.Printf("meta node [%v] verify meta partition,id[%v],err:%v\n", mms.TcpAddr, adminTask.ID, err)
 	default:
 		fmt.Printf("unknown code [%v]\n", req.Opcode)
 	}
@@ -220,6 +223,7 @@ func (mms *MockMetaServer) handleHeartbeats(conn net.Conn, p *proto.Packet, admi
 			Status:      proto.ReadWrite,
 			MaxInodeID:  1,
 			VolName:     partition.VolName,
+			Usage:       &proto.DirStat{Dirs: 1},
 		}
 		mpr.Status = proto.ReadWrite
 		mpr.IsLeader = true
@@ -319,6 +323,37 @@ func (mms *MockMetaServer) handleLoadMetaPartition(conn net.Conn, p *proto.Packe
 	return
 }
 
+func (mms *MockMetaServer) handleVerifyMetaPartition(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
+	var data []byte
+	defer func() {
+		if err != nil {
+			responseAckErrToMaster(conn, p, err)
+		} else {
+			responseAckOKToMaster(conn, p, data)
+		}
+	}()
+	req := &proto.MetaPartitionVerifyRequest{}
+	reqData, err := json.Marshal(adminTask.Request)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(reqData, req); err != nil {
+		return
+	}
+	resp := &proto.MetaPartitionVerifyResponse{
+		PartitionID: req.PartitionID,
+		VerifyID:    req.VerifyID,
+		ApplyID:     100,
+		Done:        true,
+		Trees: []*proto.MetaTreeDigest{
+			{Tree: "inode", Count: 123456, Hash: 0x1234},
+			{Tree: "dentry", Count: 123456, Hash: 0x5678},
+		},
+	}
+	data, err = json.Marshal(resp)
+	return
+}
+
 func (mms *MockMetaServer) handleDecommissionMetaPartition(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
 	responseAckOKToMaster(conn, p, nil)
 	req := &proto.MetaPartitionDecommissionRequest{}
@@ -0,0 +1,281 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	nodeTypeDataNode = "dataNode"
+	nodeTypeMetaNode = "metaNode"
+	nodeTypeDisk     = "disk" // a disk of a data node, the addr is "nodeAddr:diskPath"
+
+	decommissionDraining   = "draining"
+	decommissionRecovering = "recovering"
+	decommissionFinished   = "finished"
+	decommissionFailed     = "failed"
+
+	// decommissionConcurrency is the max number of partitions moved off a node at the same time.
+	decommissionConcurrency = 10
+)
+
+type decommissionKey struct {
+	nodeType string
+	addr     string
+}
+
+// nodeDecommission tracks a graceful decommission of a node. The partition replicas are moved
+// off the node first, and the node is removed from the cluster only after all the new replicas
+// have caught up. The progress is kept in the memory of the master leader only.
+type nodeDecommission struct {
+	sync.RWMutex
+	progress proto.DecommissionProgress
+}
+
+func (d *nodeDecommission) update(fn func(progress *proto.DecommissionProgress)) {
+	d.Lock()
+	defer d.Unlock()
+	fn(&d.progress)
+}
+
+func (d *nodeDecommission) get() (progress *proto.DecommissionProgress) {
+	d.RLock()
+	defer d.RUnlock()
+	progress = new(proto.DecommissionProgress)
+	*progress = d.progress
+	progress.FailedPartitions = append([]uint64(nil), d.progress.FailedPartitions...)
+	return
+}
+
+func (d *nodeDecommission) isRunning() bool {
+	d.RLock()
+	defer d.RUnlock()
+	return d.progress.Status == decommissionDraining || d.progress.Status == decommissionRecovering
+}
+
+func (d *nodeDecommission) finish(err error) {
+	d.update(func(progress *proto.DecommissionProgress) {
+		progress.Status = decommissionFinished
+		if err != nil {
+			progress.Status = decommissionFailed
+			progress.Msg = err.Error()
+		}
+		progress.EndTime = time.Now().Format(proto.TimeFormat)
+	})
+}
+
+func (c *Cluster) startDecommission(nodeType, addr string) (d *nodeDecommission, err error) {
+	key := decommissionKey{nodeType: nodeType, addr: addr}
+	d = &nodeDecommission{progress: proto.DecommissionProgress{
+		Addr:      addr,
+		NodeType:  nodeType,
+		Status:    decommissionDraining,
+		StartTime: time.Now().Format(proto.TimeFormat),
+	}}
+	if old, loaded := c.decommissions.LoadOrStore(key, d); loaded {
+		if old.(*nodeDecommission).isRunning() {
+			return nil, fmt.Errorf("%v[%v] is being decommissioned", nodeType, addr)
+		}
+		c.decommissions.Store(key, d)
+	}
+	return
+}
+
+func (c *Cluster) getDecommissionProgress(nodeType, addr string) (progress *proto.DecommissionProgress, err error) {
+	value, ok := c.decommissions.Load(decommissionKey{nodeType: nodeType, addr: addr})
+	if !ok {
+		return nil, fmt.Errorf("no graceful decommission of %v[%v] since the master leader started", nodeType, addr)
+	}
+	return value.(*nodeDecommission).get(), nil
+}
+
+// waitForRecovery waits until none of the partitions is recovering, or the master is not the leader any more.
+func (c *Cluster) waitForRecovery(d *nodeDecommission, recovering func() int) (err error) {
+	d.update(func(progress *proto.DecommissionProgress) {
+		progress.Status = decommissionRecovering
+	})
+	for {
+		if c.partition != nil && !c.partition.IsRaftLeader() {
+			return fmt.Errorf("the master is not the leader any more")
+		}
+		count := recovering()
+		d.update(func(progress *proto.DecommissionProgress) {
+			progress.Recovered = progress.Migrated - count
+		})
+		if count == 0 {
+			return
+		}
+		time.Sleep(time.Second * defaultIntervalToCheckDataPartition)
+	}
+}
+
+// decommissionDataNodeGracefully drains the data partition replicas off the data node in background.
+func (c *Cluster) decommissionDataNodeGracefully(dataNode *DataNode) (err error) {
+	var d *nodeDecommission
+	if d, err = c.startDecommission(nodeTypeDataNode, dataNode.Addr); err != nil {
+		return
+	}
+	go func() {
+		err := c.drainDataNode(dataNode, d)
+		d.finish(err)
+		if err != nil {
+			dataNode.ToBeOffline = false
+			Warn(c.Name, fmt.Sprintf("action[decommissionDataNodeGracefully],clusterID[%v] Node[%v] OffLine failed,err[%v]",
+				c.Name, dataNode.Addr, err))
+			return
+		}
+		Warn(c.Name, fmt.Sprintf("action[decommissionDataNodeGracefully],clusterID[%v] Node[%v] OffLine success",
+			c.Name, dataNode.Addr))
+	}()
+	return
+}
+
+// movePartitions moves the partitions off the node concurrently and returns the indexes of the moved ones.
+func movePartitions(d *nodeDecommission, ids []uint64, move func(i int) error) (migrated []int, err error) {
+	d.update(func(progress *proto.DecommissionProgress) {
+		progress.Total = len(ids)
+	})
+	var (
+		wg    sync.WaitGroup
+		limit = make(chan struct{}, decommissionConcurrency)
+		mutex sync.Mutex
+	)
+	migrated = make([]int, 0, len(ids))
+	for i := range ids {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+			err := move(i)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err == nil {
+				migrated = append(migrated, i)
+			}
+			d.update(func(progress *proto.DecommissionProgress) {
+				if err != nil {
+					progress.FailedPartitions = append(progress.FailedPartitions, ids[i])
+				} else {
+					progress.Migrated++
+				}
+			})
+		}(i)
+	}
+	wg.Wait()
+	if failed := d.get().FailedPartitions; len(failed) > 0 {
+		return nil, fmt.Errorf("failed to move partitions %v", failed)
+	}
+	return
+}
+
+func (c *Cluster) drainDataNode(dataNode *DataNode, d *nodeDecommission) (err error) {
+	log.LogWarnf("action[drainDataNode] Node[%v] begin", dataNode.Addr)
+	dataNode.ToBeOffline = true
+	dataNode.AvailableSpace = 1
+	partitions := c.getAllDataPartitionByDataNode(dataNode.Addr)
+	ids := make([]uint64, 0, len(partitions))
+	for _, dp := range partitions {
+		ids = append(ids, dp.PartitionID)
+	}
+	var migrated []int
+	if migrated, err = movePartitions(d, ids, func(i int) error {
+		return c.decommissionDataPartition(dataNode.Addr, partitions[i], dataNodeOfflineErr)
+	}); err != nil {
+		return
+	}
+	if err = c.waitForRecovery(d, func() (count int) {
+		for _, i := range migrated {
+			dp := partitions[i]
+			dp.RLock()
+			if dp.isRecover {
+				count++
+			}
+			dp.RUnlock()
+		}
+		return
+	}); err != nil {
+		return
+	}
+	if err = c.syncDeleteDataNode(dataNode); err != nil {
+		return
+	}
+	c.delDataNodeFromCache(dataNode)
+	return
+}
+
+// decommissionMetaNodeGracefully drains the meta partition replicas off the meta node in background.
+func (c *Cluster) decommissionMetaNodeGracefully(metaNode *MetaNode) (err error) {
+	var d *nodeDecommission
+	if d, err = c.startDecommission(nodeTypeMetaNode, metaNode.Addr); err != nil {
+		return
+	}
+	go func() {
+		err := c.drainMetaNode(metaNode, d)
+		d.finish(err)
+		if err != nil {
+			metaNode.ToBeOffline = false
+			Warn(c.Name, fmt.Sprintf("action[decommissionMetaNodeGracefully],clusterID[%v] Node[%v] OffLine failed,err[%v]",
+				c.Name, metaNode.Addr, err))
+			return
+		}
+		Warn(c.Name, fmt.Sprintf("action[decommissionMetaNodeGracefully],clusterID[%v] Node[%v] OffLine success",
+			c.Name, metaNode.Addr))
+	}()
+	return
+}
+
+func (c *Cluster) drainMetaNode(metaNode *MetaNode, d *nodeDecommission) (err error) {
+	log.LogWarnf("action[drainMetaNode] Node[%v] begin", metaNode.Addr)
+	metaNode.ToBeOffline = true
+	metaNode.MaxMemAvailWeight = 1
+	partitions := c.getAllMetaPartitionByMetaNode(metaNode.Addr)
+	ids := make([]uint64, 0, len(partitions))
+	for _, mp := range partitions {
+		ids = append(ids, mp.PartitionID)
+	}
+	var migrated []int
+	if migrated, err = movePartitions(d, ids, func(i int) error {
+		return c.decommissionMetaPartition(metaNode.Addr, partitions[i])
+	}); err != nil {
+		return
+	}
+	if err = c.waitForRecovery(d, func() (count int) {
+		for _, i := range migrated {
+			mp := partitions[i]
+			mp.RLock()
+			if mp.IsRecover {
+				count++
+			}
+			mp.RUnlock()
+		}
+		return
+	}); err != nil {
+		return
+	}
+	if err = c.syncDeleteMetaNode(metaNode); err != nil {
+		return
+	}
+	c.deleteMetaNodeFromCache(metaNode)
+	return
+}
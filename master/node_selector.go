@@ -40,6 +40,7 @@ type Node interface {
 	SelectNodeForWrite()
 	GetID() uint64
 	GetAddr() string
+	GetRackPath() string
 }
 
 // SortedWeightedNodes defines an array sorted by carry
@@ -204,6 +205,8 @@ func getAvailHosts(nodes *sync.Map, excludeHosts []string, replicaNum int, selec
 	}
 	weightedNodes.setNodeCarry(count, replicaNum)
 	sort.Sort(weightedNodes)
+	usedRacks, usedHosts := usedFaultDomains(nodes, excludeHosts)
+	weightedNodes = weightedNodes.spreadOverFaultDomains(usedRacks, usedHosts, replicaNum)
 
 	for i := 0; i < replicaNum; i++ {
 		node := weightedNodes[i].Ptr
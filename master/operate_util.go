@@ -28,7 +28,7 @@ import (
 	"time"
 )
 
-func newCreateDataPartitionRequest(volName string, ID uint64, members []proto.Peer, dataPartitionSize int, hosts []string, createType int) (req *proto.CreateDataPartitionRequest) {
+func newCreateDataPartitionRequest(volName string, ID uint64, members []proto.Peer, dataPartitionSize int, hosts []string, createType int, mediaType string, encrypted, cache bool) (req *proto.CreateDataPartitionRequest) {
 	req = &proto.CreateDataPartitionRequest{
 		PartitionId:   ID,
 		PartitionSize: dataPartitionSize,
@@ -36,6 +36,9 @@ func newCreateDataPartitionRequest(volName string, ID uint64, members []proto.Pe
 		Members:       members,
 		Hosts:         hosts,
 		CreateType:    createType,
+		MediaType:     mediaType,
+		Encrypted:     encrypted,
+		Cache:         cache,
 	}
 	return
 }
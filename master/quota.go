@@ -0,0 +1,269 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// getQuotaLimits returns the limits of the quotas of the volume, which are persisted with the volume.
+func (vol *Vol) getQuotaLimits() (quotas []*proto.QuotaInfo) {
+	vol.quotasLock.RLock()
+	defer vol.quotasLock.RUnlock()
+	quotas = make([]*proto.QuotaInfo, 0, len(vol.quotas))
+	for _, quota := range vol.quotas {
+		quotas = append(quotas, &proto.QuotaInfo{
+			QuotaId:  quota.QuotaId,
+			MaxFiles: quota.MaxFiles,
+			MaxBytes: quota.MaxBytes,
+		})
+	}
+	return
+}
+
+// getQuotas returns the quotas of the volume with the usage reported by the meta partition leaders.
+// The meta partitions count the inodes by the nearest quotas above them, and the partitions holding
+// the roots of the quotas report the quotas enclosing them, so the usage of a quota nested in another
+// is charged to the enclosing quotas as well.
+func (vol *Vol) getQuotas() (quotas []*proto.QuotaInfo) {
+	quotas = vol.getQuotaLimits()
+	if len(quotas) == 0 {
+		return
+	}
+	index := make(map[uint64]*proto.QuotaInfo, len(quotas))
+	for _, quota := range quotas {
+		index[quota.QuotaId] = quota
+	}
+	var usages []*proto.QuotaUsage
+	for _, mp := range vol.cloneMetaPartitionMap() {
+		mp.RLock()
+		usages = append(usages, mp.quotaUsage...)
+		for _, root := range mp.quotaRoots {
+			if quota, ok := index[root.QuotaId]; ok {
+				quota.ParentId = root.ParentId
+			}
+		}
+		mp.RUnlock()
+	}
+	for _, usage := range usages {
+		// the visited quotas stop the loop of the parents reported by the partitions at different times
+		visited := make(map[uint64]bool)
+		for quota, ok := index[usage.QuotaId]; ok && !visited[quota.QuotaId]; quota, ok = index[quota.ParentId] {
+			visited[quota.QuotaId] = true
+			quota.UsedFiles += usage.Files
+			quota.UsedBytes += usage.Bytes
+		}
+	}
+	for _, quota := range quotas {
+		quota.Exceeded = (quota.MaxFiles != 0 && quota.UsedFiles >= quota.MaxFiles) ||
+			(quota.MaxBytes != 0 && quota.UsedBytes >= quota.MaxBytes)
+	}
+	return
+}
+
+func (c *Cluster) setQuota(vol *Vol, quotaId, maxFiles, maxBytes uint64, authKey string) (err error) {
+	var serverAuthKey string
+	if vol.Owner != "" {
+		serverAuthKey = vol.Owner
+	} else {
+		serverAuthKey = vol.Name
+	}
+	if !matchKey(serverAuthKey, authKey) {
+		return proto.ErrVolAuthKeyNotMatch
+	}
+	vol.quotasLock.Lock()
+	old, exist := vol.quotas[quotaId]
+	vol.quotas[quotaId] = &proto.QuotaInfo{QuotaId: quotaId, MaxFiles: maxFiles, MaxBytes: maxBytes}
+	vol.quotasLock.Unlock()
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.quotasLock.Lock()
+		if exist {
+			vol.quotas[quotaId] = old
+		} else {
+			delete(vol.quotas, quotaId)
+		}
+		vol.quotasLock.Unlock()
+		return
+	}
+	// tell the meta nodes at once, which tag the inodes of the directory with the quota
+	go c.checkMetaNodeHeartbeat()
+	return
+}
+
+func (c *Cluster) deleteQuota(vol *Vol, quotaId uint64, authKey string) (err error) {
+	var serverAuthKey string
+	if vol.Owner != "" {
+		serverAuthKey = vol.Owner
+	} else {
+		serverAuthKey = vol.Name
+	}
+	if !matchKey(serverAuthKey, authKey) {
+		return proto.ErrVolAuthKeyNotMatch
+	}
+	vol.quotasLock.Lock()
+	old, exist := vol.quotas[quotaId]
+	if !exist {
+		vol.quotasLock.Unlock()
+		return proto.ErrQuotaNotExists
+	}
+	delete(vol.quotas, quotaId)
+	vol.quotasLock.Unlock()
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.quotasLock.Lock()
+		vol.quotas[quotaId] = old
+		vol.quotasLock.Unlock()
+		return
+	}
+	go c.checkMetaNodeHeartbeat()
+	return
+}
+
+// volQuotas returns the quotas of the volumes with the usage, which are enforced by the meta nodes.
+func (c *Cluster) volQuotas() (quotas map[string][]*proto.QuotaInfo) {
+	for name, vol := range c.allVols() {
+		if volQuotas := vol.getQuotas(); len(volQuotas) > 0 {
+			if quotas == nil {
+				quotas = make(map[string][]*proto.QuotaInfo)
+			}
+			quotas[name] = volQuotas
+		}
+	}
+	return
+}
+
+func (m *Server) setQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		err      error
+		name     string
+		authKey  string
+		quotaId  uint64
+		maxFiles uint64
+		maxBytes uint64
+		vol      *Vol
+		msg      string
+	)
+	if name, quotaId, maxFiles, maxBytes, authKey, err = parseSetQuotaPara(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	if err = m.cluster.setQuota(vol, quotaId, maxFiles, maxBytes, authKey); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg = fmt.Sprintf("set quota of inode[%v] maxFiles[%v] maxBytes[%v] of vol [%v] successed,from[%v]",
+		quotaId, maxFiles, maxBytes, name, r.RemoteAddr)
+	log.LogWarn(msg)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) deleteQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		name    string
+		authKey string
+		quotaId uint64
+		vol     *Vol
+		msg     string
+	)
+	if name, quotaId, authKey, err = parseDeleteQuotaPara(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	if err = m.cluster.deleteQuota(vol, quotaId, authKey); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg = fmt.Sprintf("delete quota of inode[%v] of vol [%v] successed,from[%v]", quotaId, name, r.RemoteAddr)
+	log.LogWarn(msg)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) listQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		name string
+		vol  *Vol
+	)
+	r.ParseForm()
+	if name, err = extractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(vol.getQuotas()))
+}
+
+func parseSetQuotaPara(r *http.Request) (name string, quotaId, maxFiles, maxBytes uint64, authKey string, err error) {
+	if name, quotaId, authKey, err = parseDeleteQuotaPara(r); err != nil {
+		return
+	}
+	if maxFiles, err = extractQuotaLimit(r, maxFilesKey); err != nil {
+		return
+	}
+	if maxBytes, err = extractQuotaLimit(r, maxBytesKey); err != nil {
+		return
+	}
+	if maxFiles == 0 && maxBytes == 0 {
+		err = fmt.Errorf("at least one of %v and %v should be set", maxFilesKey, maxBytesKey)
+	}
+	return
+}
+
+func parseDeleteQuotaPara(r *http.Request) (name string, quotaId uint64, authKey string, err error) {
+	r.ParseForm()
+	if name, err = extractName(r); err != nil {
+		return
+	}
+	var value string
+	if value = r.FormValue(inodeKey); value == "" {
+		err = keyNotFound(inodeKey)
+		return
+	}
+	if quotaId, err = strconv.ParseUint(value, 10, 64); err != nil {
+		return
+	}
+	if quotaId == 0 {
+		err = fmt.Errorf("invalid inode[%v]", value)
+		return
+	}
+	if authKey, err = extractAuthKey(r); err != nil {
+		return
+	}
+	return
+}
+
+func extractQuotaLimit(r *http.Request, key string) (limit uint64, err error) {
+	var value string
+	if value = r.FormValue(key); value == "" {
+		return
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
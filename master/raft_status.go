@@ -0,0 +1,59 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// getRaftStatus replies the raft status of the master itself rather than the leader,
+// so it tells which master each one of the masters follows.
+func (m *Server) getRaftStatus(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.raftStatus()))
+}
+
+func (m *Server) raftStatus() (rs *proto.MasterRaftStatus) {
+	status := m.partition.Status()
+	rs = &proto.MasterRaftStatus{
+		NodeID:     m.id,
+		Addr:       AddrDatabase[m.id],
+		LeaderID:   status.Leader,
+		LeaderAddr: m.leaderInfo.addr,
+		IsLeader:   m.partition.IsRaftLeader(),
+		MetaReady:  m.metaReady,
+		State:      status.State,
+		Term:       status.Term,
+		Index:      status.Index,
+		Commit:     status.Commit,
+		Applied:    status.Applied,
+		Peers:      make([]*proto.MasterPeerStatus, 0, len(status.Replicas)),
+	}
+	for id, replica := range status.Replicas {
+		rs.Peers = append(rs.Peers, &proto.MasterPeerStatus{
+			NodeID:     id,
+			Addr:       AddrDatabase[id],
+			Match:      replica.Match,
+			Commit:     replica.Commit,
+			State:      replica.State,
+			Active:     replica.Active,
+			LastActive: replica.LastActive.Unix(),
+		})
+	}
+	sort.Slice(rs.Peers, func(i, j int) bool { return rs.Peers[i].NodeID < rs.Peers[j].NodeID })
+	return
+}
@@ -0,0 +1,404 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// rebalancer moves the data partition replicas from the data nodes much fuller than the others
+// of the same zone to the emptier ones, and the meta partition replicas from the meta nodes holding
+// much more meta partitions than the others of the same zone to the ones holding less.
+// The replicas are moved like decommissioned ones, and no more moves are started while
+// too many partitions are being recovered.
+type rebalancer struct {
+	sync.RWMutex
+	rebalanceConfig
+	lastCheckTime time.Time
+	migrations    []*proto.RebalanceMigration
+}
+
+type rebalanceConfig struct {
+	enable        bool // balance the data partitions
+	metaEnable    bool // balance the meta partitions
+	dryRun        bool
+	maxMigrations int
+	threshold     float64
+}
+
+func newRebalancer() *rebalancer {
+	return &rebalancer{rebalanceConfig: rebalanceConfig{
+		maxMigrations: defaultRebalanceMaxMigrations,
+		threshold:     defaultRebalanceThreshold,
+	}}
+}
+
+func (r *rebalancer) getConfig() rebalanceConfig {
+	r.RLock()
+	defer r.RUnlock()
+	return r.rebalanceConfig
+}
+
+func (r *rebalancer) setConfig(cfg rebalanceConfig) {
+	r.Lock()
+	defer r.Unlock()
+	if cfg.maxMigrations <= 0 {
+		cfg.maxMigrations = defaultRebalanceMaxMigrations
+	}
+	if cfg.threshold <= 0 {
+		cfg.threshold = defaultRebalanceThreshold
+	}
+	r.rebalanceConfig = cfg
+}
+
+func (r *rebalancer) view() (view *proto.RebalanceView) {
+	r.RLock()
+	defer r.RUnlock()
+	view = &proto.RebalanceView{
+		Enable:        r.enable,
+		MetaEnable:    r.metaEnable,
+		DryRun:        r.dryRun,
+		MaxMigrations: r.maxMigrations,
+		Threshold:     r.threshold,
+		Migrations:    r.migrations,
+	}
+	if !r.lastCheckTime.IsZero() {
+		view.LastCheckTime = r.lastCheckTime.Format(proto.TimeFormat)
+	}
+	return
+}
+
+func (r *rebalancer) setMigrations(migrations []*proto.RebalanceMigration) {
+	r.Lock()
+	defer r.Unlock()
+	r.lastCheckTime = time.Now()
+	r.migrations = migrations
+}
+
+func (c *Cluster) setRebalance(cfg rebalanceConfig) (err error) {
+	oldCfg := c.rebalance.getConfig()
+	c.rebalance.setConfig(cfg)
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setRebalance] err[%v]", err)
+		c.rebalance.setConfig(oldCfg)
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
+
+func (c *Cluster) scheduleToCheckRebalance() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.checkRebalance()
+			}
+			time.Sleep(defaultIntervalToCheckRebalance)
+		}
+	}()
+}
+
+// dataNodeUsage is the snapshot of the space of a data node taken for a rebalance check,
+// it is updated with the moves planned so that a node is not chosen too many times.
+type dataNodeUsage struct {
+	addr     string
+	zone     string
+	total    uint64
+	used     uint64
+	writable bool
+}
+
+func (u *dataNodeUsage) ratio() float64 {
+	return float64(u.used) / float64(u.total)
+}
+
+func (c *Cluster) checkRebalance() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("checkRebalance occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"checkRebalance occurred panic")
+		}
+	}()
+	cfg := c.rebalance.getConfig()
+	var migrations = make([]*proto.RebalanceMigration, 0)
+	if c.Upgrading {
+		c.rebalance.setMigrations(migrations)
+		return
+	}
+	if cfg.enable {
+		migrations = append(migrations, c.rebalanceDataPartitions(cfg)...)
+	}
+	if cfg.metaEnable {
+		migrations = append(migrations, c.rebalanceMetaPartitions(cfg)...)
+	}
+	c.rebalance.setMigrations(migrations)
+}
+
+func (c *Cluster) rebalanceDataPartitions(cfg rebalanceConfig) (migrations []*proto.RebalanceMigration) {
+	budget := cfg.maxMigrations - c.countRecoveringDataPartitions()
+	if budget <= 0 {
+		log.LogInfof("action[rebalanceDataPartitions] too many data partitions are being recovered, skip")
+		return
+	}
+	var zones = make(map[string][]*dataNodeUsage)
+	c.dataNodes.Range(func(key, value interface{}) bool {
+		dataNode := value.(*DataNode)
+		dataNode.RLock()
+		if dataNode.isActive && dataNode.Total > 0 {
+			zones[dataNode.ZoneName] = append(zones[dataNode.ZoneName], &dataNodeUsage{
+				addr:     dataNode.Addr,
+				zone:     dataNode.ZoneName,
+				total:    dataNode.Total,
+				used:     dataNode.Used,
+				writable: dataNode.AvailableSpace > 10*util.GB,
+			})
+		}
+		dataNode.RUnlock()
+		return true
+	})
+	for _, nodes := range zones {
+		if budget <= 0 {
+			break
+		}
+		moved := c.rebalanceZone(nodes, cfg.threshold, budget, cfg.dryRun)
+		budget -= len(moved)
+		migrations = append(migrations, moved...)
+	}
+	return
+}
+
+func (c *Cluster) rebalanceZone(nodes []*dataNodeUsage, threshold float64, budget int, dryRun bool) (migrations []*proto.RebalanceMigration) {
+	var total, used uint64
+	for _, node := range nodes {
+		total += node.total
+		used += node.used
+	}
+	if total == 0 {
+		return
+	}
+	average := float64(used) / float64(total)
+	var hot, cold []*dataNodeUsage
+	for _, node := range nodes {
+		if node.ratio() > average+threshold {
+			hot = append(hot, node)
+		} else if node.ratio() < average && node.writable {
+			cold = append(cold, node)
+		}
+	}
+	if len(hot) == 0 || len(cold) == 0 {
+		return
+	}
+	sort.Slice(hot, func(i, j int) bool { return hot[i].ratio() > hot[j].ratio() })
+	for _, from := range hot {
+		partitions := c.getAllDataPartitionByDataNode(from.addr)
+		sort.Slice(partitions, func(i, j int) bool { return partitions[i].used > partitions[j].used })
+		for _, dp := range partitions {
+			if budget <= 0 || from.ratio() <= average+threshold {
+				break
+			}
+			dp.RLock()
+			size, hosts, recovering := dp.used, append([]string(nil), dp.Hosts...), dp.isRecover
+			dp.RUnlock()
+			if size == 0 || recovering {
+				continue
+			}
+			sort.Slice(cold, func(i, j int) bool { return cold[i].ratio() < cold[j].ratio() })
+			var to *dataNodeUsage
+			for _, node := range cold {
+				if !contains(hosts, node.addr) && node.ratio() < average {
+					to = node
+					break
+				}
+			}
+			if to == nil {
+				continue
+			}
+			migration := &proto.RebalanceMigration{
+				VolName:       dp.VolName,
+				PartitionID:   dp.PartitionID,
+				PartitionType: partitionTypeData,
+				Zone:          from.zone,
+				From:          from.addr,
+				To:            to.addr,
+				FromUsage:     from.ratio(),
+				ToUsage:       to.ratio(),
+				DryRun:        dryRun,
+			}
+			if !dryRun {
+				err := c.validateDecommissionDataPartition(dp, from.addr)
+				if err == nil {
+					err = c.moveDataReplica(dp, from.addr, to.addr)
+				}
+				if err != nil {
+					migration.Msg = err.Error()
+					log.LogErrorf("action[rebalanceZone] move dp[%v] from[%v] to[%v] err[%v]", dp.PartitionID, from.addr, to.addr, err)
+					migrations = append(migrations, migration)
+					continue
+				}
+			}
+			log.LogWarnf("action[rebalanceZone] vol[%v] dp[%v] from[%v] usage[%.2f] to[%v] usage[%.2f] dryRun[%v]",
+				dp.VolName, dp.PartitionID, from.addr, from.ratio(), to.addr, to.ratio(), dryRun)
+			migrations = append(migrations, migration)
+			from.used -= size
+			to.used += size
+			budget--
+		}
+	}
+	return
+}
+
+func (c *Cluster) countRecoveringDataPartitions() (count int) {
+	c.BadDataPartitionIds.Range(func(key, value interface{}) bool {
+		count += len(value.([]uint64))
+		return true
+	})
+	return
+}
+
+// metaNodeLoad is the snapshot of the meta partitions of a meta node taken for a rebalance check,
+// it is updated with the moves planned so that a node is not chosen too many times.
+type metaNodeLoad struct {
+	addr       string
+	zone       string
+	partitions []*MetaPartition
+	count      int
+	writable   bool
+}
+
+func (c *Cluster) rebalanceMetaPartitions(cfg rebalanceConfig) (migrations []*proto.RebalanceMigration) {
+	budget := cfg.maxMigrations - c.countRecoveringMetaPartitions()
+	if budget <= 0 {
+		log.LogInfof("action[rebalanceMetaPartitions] too many meta partitions are being recovered, skip")
+		return
+	}
+	var zones = make(map[string][]*metaNodeLoad)
+	c.metaNodes.Range(func(key, value interface{}) bool {
+		metaNode := value.(*MetaNode)
+		if !metaNode.IsActive || metaNode.ToBeOffline {
+			return true
+		}
+		partitions := c.getAllMetaPartitionByMetaNode(metaNode.Addr)
+		zones[metaNode.ZoneName] = append(zones[metaNode.ZoneName], &metaNodeLoad{
+			addr:       metaNode.Addr,
+			zone:       metaNode.ZoneName,
+			partitions: partitions,
+			count:      len(partitions),
+			writable:   metaNode.isWritable(),
+		})
+		return true
+	})
+	for _, nodes := range zones {
+		if budget <= 0 {
+			break
+		}
+		moved := c.rebalanceMetaZone(nodes, cfg.threshold, budget, cfg.dryRun)
+		budget -= len(moved)
+		migrations = append(migrations, moved...)
+	}
+	return
+}
+
+func (c *Cluster) rebalanceMetaZone(nodes []*metaNodeLoad, threshold float64, budget int, dryRun bool) (migrations []*proto.RebalanceMigration) {
+	var total int
+	for _, node := range nodes {
+		total += node.count
+	}
+	if len(nodes) < 2 || total == 0 {
+		return
+	}
+	average := float64(total) / float64(len(nodes))
+	var hot, cold []*metaNodeLoad
+	for _, node := range nodes {
+		if float64(node.count) > average*(1+threshold) {
+			hot = append(hot, node)
+		} else if float64(node.count) < average && node.writable {
+			cold = append(cold, node)
+		}
+	}
+	if len(hot) == 0 || len(cold) == 0 {
+		return
+	}
+	sort.Slice(hot, func(i, j int) bool { return hot[i].count > hot[j].count })
+	for _, from := range hot {
+		for _, mp := range from.partitions {
+			if budget <= 0 || float64(from.count) <= average*(1+threshold) {
+				break
+			}
+			mp.RLock()
+			hosts, recovering := append([]string(nil), mp.Hosts...), mp.IsRecover
+			mp.RUnlock()
+			if recovering {
+				continue
+			}
+			sort.Slice(cold, func(i, j int) bool { return cold[i].count < cold[j].count })
+			var to *metaNodeLoad
+			for _, node := range cold {
+				if !contains(hosts, node.addr) && float64(node.count) < average {
+					to = node
+					break
+				}
+			}
+			if to == nil {
+				continue
+			}
+			migration := &proto.RebalanceMigration{
+				VolName:       mp.volName,
+				PartitionID:   mp.PartitionID,
+				PartitionType: partitionTypeMeta,
+				Zone:          from.zone,
+				From:          from.addr,
+				To:            to.addr,
+				FromUsage:     float64(from.count) / average,
+				ToUsage:       float64(to.count) / average,
+				DryRun:        dryRun,
+			}
+			if !dryRun {
+				err := c.validateDecommissionMetaPartition(mp, from.addr)
+				if err == nil {
+					err = c.moveMetaReplica(mp, from.addr, to.addr)
+				}
+				if err != nil {
+					migration.Msg = err.Error()
+					log.LogErrorf("action[rebalanceMetaZone] move mp[%v] from[%v] to[%v] err[%v]", mp.PartitionID, from.addr, to.addr, err)
+					migrations = append(migrations, migration)
+					continue
+				}
+			}
+			log.LogWarnf("action[rebalanceMetaZone] vol[%v] mp[%v] from[%v] count[%v] to[%v] count[%v] dryRun[%v]",
+				mp.volName, mp.PartitionID, from.addr, from.count, to.addr, to.count, dryRun)
+			migrations = append(migrations, migration)
+			from.count--
+			to.count++
+			budget--
+		}
+	}
+	return
+}
+
+func (c *Cluster) countRecoveringMetaPartitions() (count int) {
+	c.BadMetaPartitionIds.Range(func(key, value interface{}) bool {
+		count += len(value.([]uint64))
+		return true
+	})
+	return
+}
@@ -0,0 +1,234 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/storage"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	divergenceKindCrc       = "crc"
+	divergenceKindSize      = "size"
+	divergenceKindAppliedID = "appliedID"
+)
+
+// checkReplicaConsistency compares the crc and the size of the normal extents and the applied index across
+// the live replicas, with the files loaded from the replicas by doLoadDataPartition. Only the extents not modified
+// within defaultIntervalToCheckCrc are compared, and the applied indexes only if none of the extents is modified,
+// since the replicas may not have caught up with the recent writes yet.
+// The divergences found replace the ones of the last check.
+func (partition *DataPartition) checkReplicaConsistency() (divergences []*proto.ReplicaDivergence) {
+	partition.Lock()
+	defer partition.Unlock()
+	divergences = make([]*proto.ReplicaDivergence, 0)
+	defer func() {
+		partition.divergences = divergences
+	}()
+	liveReplicas := partition.liveReplicas(defaultDataPartitionTimeOutSec)
+	if len(liveReplicas) < 2 {
+		return
+	}
+	idle := true
+	for _, fc := range partition.FileInCoreMap {
+		if !fc.shouldCheckCrc() {
+			idle = false
+			continue
+		}
+		extentID, err := strconv.ParseUint(fc.Name, 10, 64)
+		if err != nil || storage.IsTinyExtent(extentID) {
+			continue
+		}
+		fms := make([]*FileMetadata, 0, len(liveReplicas))
+		for _, replica := range liveReplicas {
+			if fm, ok := fc.getFileMetaByAddr(replica); ok {
+				fms = append(fms, fm)
+			}
+		}
+		// the missing replicas of the extents are reported by checkExtentFile
+		if len(fms) < 2 {
+			continue
+		}
+		if !hasSameSize(fms) {
+			sizes := make(map[string]uint64, len(fms))
+			for _, fm := range fms {
+				sizes[fm.LocAddr] = uint64(fm.Size)
+			}
+			divergences = append(divergences, partition.newDivergence(divergenceKindSize, extentID, sizes, ""))
+			continue
+		}
+		crcs := make(map[string]uint64, len(fms))
+		for _, fm := range fms {
+			if fm.Crc == EmptyCrcValue || fm.Crc == 0 {
+				crcs = nil
+				break
+			}
+			crcs[fm.LocAddr] = uint64(fm.Crc)
+		}
+		if crcs != nil && !isSameValue(crcs) {
+			divergences = append(divergences, partition.newDivergence(divergenceKindCrc, extentID, crcs, majorityAddr(crcs)))
+		}
+	}
+	if !idle {
+		return
+	}
+	appliedIDs := make(map[string]uint64, len(liveReplicas))
+	for _, replica := range liveReplicas {
+		// the data nodes of the old versions do not report the applied index
+		if replica.AppliedID == 0 {
+			return
+		}
+		appliedIDs[replica.Addr] = replica.AppliedID
+	}
+	if !isSameValue(appliedIDs) {
+		divergences = append(divergences, partition.newDivergence(divergenceKindAppliedID, 0, appliedIDs, ""))
+	}
+	return
+}
+
+func (partition *DataPartition) newDivergence(kind string, extentID uint64, values map[string]uint64, authority string) *proto.ReplicaDivergence {
+	return &proto.ReplicaDivergence{
+		PartitionID: partition.PartitionID,
+		VolName:     partition.VolName,
+		Kind:        kind,
+		ExtentID:    extentID,
+		Values:      values,
+		Authority:   authority,
+		FoundTime:   time.Now().Unix(),
+	}
+}
+
+func (partition *DataPartition) getDivergences() []*proto.ReplicaDivergence {
+	partition.RLock()
+	defer partition.RUnlock()
+	return partition.divergences
+}
+
+func isSameValue(values map[string]uint64) bool {
+	var first *uint64
+	for _, value := range values {
+		if first == nil {
+			v := value
+			first = &v
+		} else if value != *first {
+			return false
+		}
+	}
+	return true
+}
+
+// majorityAddr returns the address of a replica with the value held by the majority of the replicas.
+func majorityAddr(values map[string]uint64) (addr string) {
+	counts := make(map[uint64]int, len(values))
+	for _, value := range values {
+		counts[value]++
+	}
+	for a, value := range values {
+		if counts[value]*2 > len(values) {
+			return a
+		}
+	}
+	return
+}
+
+// checkReplicaConsistency records the divergences between the replicas of the data partition, it is called
+// after the replicas are loaded by the scheduled loading of the data partitions.
+func (c *Cluster) checkReplicaConsistency(dp *DataPartition) {
+	divergences := dp.checkReplicaConsistency()
+	if len(divergences) == 0 {
+		return
+	}
+	Warn(c.Name, fmt.Sprintf("action[checkReplicaConsistency] clusterID[%v] vol[%v] partitionID[%v] has [%v] divergences",
+		c.Name, dp.VolName, dp.PartitionID, len(divergences)))
+	if !c.cfg.autoRepairDivergence {
+		return
+	}
+	if err := c.repairDataPartition(dp); err != nil {
+		log.LogErrorf("action[checkReplicaConsistency] partitionID[%v] repair err[%v]", dp.PartitionID, err)
+	}
+}
+
+type extentRepairKey struct {
+	addr   string
+	source string
+}
+
+// repairDataPartition overwrites the extents whose crc diverges with the data of the authoritative replica,
+// which holds the crc of the majority. The size divergences are left to the extent repair of the data nodes.
+func (c *Cluster) repairDataPartition(dp *DataPartition) (err error) {
+	requests := make(map[extentRepairKey]*proto.RepairDataPartitionExtentsRequest)
+	for _, d := range dp.getDivergences() {
+		if d.Kind != divergenceKindCrc || d.Authority == "" {
+			continue
+		}
+		for addr, crc := range d.Values {
+			if crc == d.Values[d.Authority] {
+				continue
+			}
+			key := extentRepairKey{addr: addr, source: d.Authority}
+			request, ok := requests[key]
+			if !ok {
+				request = &proto.RepairDataPartitionExtentsRequest{PartitionId: dp.PartitionID, Source: d.Authority}
+				requests[key] = request
+			}
+			request.Extents = append(request.Extents, d.ExtentID)
+		}
+	}
+	for key, request := range requests {
+		if e := c.sendRepairDataPartitionExtentsTask(dp, key.addr, request); e != nil {
+			err = e
+			continue
+		}
+		log.LogWarnf("action[repairDataPartition] partitionID[%v] repair extents[%v] of [%v] from [%v]",
+			dp.PartitionID, request.Extents, key.addr, key.source)
+	}
+	return
+}
+
+func (c *Cluster) sendRepairDataPartitionExtentsTask(dp *DataPartition, addr string, request *proto.RepairDataPartitionExtentsRequest) (err error) {
+	dataNode, err := c.dataNode(addr)
+	if err != nil {
+		return
+	}
+	task := proto.NewAdminTask(proto.OpRepairDataPartitionExtents, addr, request)
+	dp.resetTaskID(task)
+	_, err = dataNode.TaskManager.syncSendAdminTask(task)
+	return
+}
+
+func (c *Cluster) getReplicaDivergences(volName string) (divergences []*proto.ReplicaDivergence) {
+	divergences = make([]*proto.ReplicaDivergence, 0)
+	for _, vol := range c.copyVols() {
+		if volName != "" && vol.Name != volName {
+			continue
+		}
+		for _, dp := range vol.cloneDataPartitionMap() {
+			divergences = append(divergences, dp.getDivergences()...)
+		}
+	}
+	sort.Slice(divergences, func(i, j int) bool {
+		if divergences[i].PartitionID != divergences[j].PartitionID {
+			return divergences[i].PartitionID < divergences[j].PartitionID
+		}
+		return divergences[i].ExtentID < divergences[j].ExtentID
+	})
+	return
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/raftstore"
+	"github.com/chubaofs/chubaofs/util"
 	"github.com/chubaofs/chubaofs/util/config"
 	"github.com/chubaofs/chubaofs/util/cryptoutil"
 	"github.com/chubaofs/chubaofs/util/errors"
@@ -94,11 +95,11 @@ func (m *Server) Start(cfg *config.Config) (err error) {
 	m.config = newClusterConfig()
 	gConfig = m.config
 	m.leaderInfo = &LeaderInfo{}
-	m.reverseProxy = m.newReverseProxy()
 	if err = m.checkConfig(cfg); err != nil {
 		log.LogError(errors.Stack(err))
 		return
 	}
+	m.reverseProxy = m.newReverseProxy()
 
 	if m.rocksDBStore, err = raftstore.NewRocksDBStore(m.storeDir, LRUCacheSize, WriteBufferSize); err != nil {
 		return
@@ -116,6 +117,17 @@ func (m *Server) Start(cfg *config.Config) (err error) {
 	if m.cluster.MasterSecretKey, err = cryptoutil.Base64Decode(MasterSecretKey); err != nil {
 		return fmt.Errorf("action[Start] failed %v, err: master service Key invalid = %s", proto.ErrInvalidCfg, MasterSecretKey)
 	}
+	if keyFile := cfg.GetString(cfgEncryptionKeyFile); keyFile != "" {
+		if m.cluster.keyManager, err = newLocalKeyManager(keyFile); err != nil {
+			return fmt.Errorf("action[Start] failed %v, err: load encryption key file[%v] %v", proto.ErrInvalidCfg, keyFile, err)
+		}
+	}
+	if m.cluster.accessTokenKey, err = proto.AccessTokenKeyFromConfig(cfg); err != nil {
+		return fmt.Errorf("action[Start] failed %v, err: %v", proto.ErrInvalidCfg, err)
+	}
+	if m.cluster.accessTokenKey != nil {
+		proto.EnableNodeAccessToken(m.cluster.accessTokenKey)
+	}
 	m.cluster.scheduleTask()
 	m.startHTTPService(ModuleName, cfg)
 	exporter.RegistConsul(m.clusterName, ModuleName, cfg)
@@ -226,6 +238,19 @@ func (m *Server) checkConfig(cfg *config.Config) (err error) {
 			return fmt.Errorf("%v,err:%v", proto.ErrInvalidCfg, err.Error())
 		}
 	}
+	m.config.autoDecommissionDisk = cfg.GetBoolWithDefault(cfgAutoDecommissionDisk, true)
+	m.config.migrateFailingDisk = cfg.GetBoolWithDefault(cfgMigrateFailingDisk, true)
+	m.config.autoRepairDivergence = cfg.GetBool(cfgAutoRepairDivergence)
+	if auditRetentionDays := cfg.GetString(cfgAuditRetentionDays); auditRetentionDays != "" {
+		if m.config.auditRetentionDays, err = strconv.Atoi(auditRetentionDays); err != nil {
+			return fmt.Errorf("%v,err:%v", proto.ErrInvalidCfg, err.Error())
+		}
+	}
+	if volDeletionDelayHours := cfg.GetString(cfgVolDeletionDelayHours); volDeletionDelayHours != "" {
+		if m.config.volDeletionDelayHours, err = strconv.Atoi(volDeletionDelayHours); err != nil {
+			return fmt.Errorf("%v,err:%v", proto.ErrInvalidCfg, err.Error())
+		}
+	}
 	m.tickInterval = int(cfg.GetFloat(cfgTickInterval))
 	m.electionTick = int(cfg.GetFloat(cfgElectionTick))
 	if m.tickInterval <= 300 {
@@ -234,6 +259,9 @@ func (m *Server) checkConfig(cfg *config.Config) (err error) {
 	if m.electionTick <= 3 {
 		m.electionTick = 5
 	}
+	if err = util.InitTLSFromConfig(cfg, true); err != nil {
+		return fmt.Errorf("%v,err:%v", proto.ErrInvalidCfg, err.Error())
+	}
 	return
 }
 
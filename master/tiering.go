@@ -0,0 +1,164 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const secondsPerDay = 24 * 60 * 60
+
+// tiering returns the tiers of the volume, ok is false if the volume has no tiering.
+func (vol *Vol) tiering() (hotMedia, coldMedia string, coldDays uint32, ok bool) {
+	hotMedia, coldMedia, coldDays = vol.hotMedia, vol.coldMedia, vol.coldDays
+	ok = hotMedia != "" && coldMedia != "" && coldDays > 0
+	return
+}
+
+func (c *Cluster) scheduleToCheckTiering() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.checkTiering()
+			}
+			time.Sleep(defaultIntervalToCheckTiering)
+		}
+	}()
+}
+
+// checkTiering moves the data partitions of the volumes with tiering between the tiers.
+// A data partition is demoted to the cold media if it is not read or written for the cold days,
+// and promoted to the hot media once it is accessed again. The replicas are then moved to the
+// data nodes with the disks of the media like decommissioned ones, and no more replicas are
+// moved while too many data partitions are being recovered.
+func (c *Cluster) checkTiering() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("checkTiering occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"checkTiering occurred panic")
+		}
+	}()
+	budget := defaultTieringMaxMigrations - c.countRecoveringDataPartitions()
+	for _, vol := range c.allVols() {
+		hotMedia, coldMedia, coldDays, ok := vol.tiering()
+		if !ok {
+			continue
+		}
+		for _, dp := range vol.cloneDataPartitionMap() {
+			c.updateDataPartitionTier(dp, hotMedia, coldMedia, coldDays)
+			if budget > 0 && c.moveDataPartitionToTier(vol, dp) {
+				budget--
+			}
+		}
+	}
+}
+
+func (c *Cluster) updateDataPartitionTier(dp *DataPartition, hotMedia, coldMedia string, coldDays uint32) {
+	dp.Lock()
+	defer dp.Unlock()
+	now := time.Now().Unix()
+	oldMediaType, oldTierTime := dp.mediaType, dp.tierTime
+	switch dp.mediaType {
+	case hotMedia:
+		// the access time is unknown until the data nodes report it
+		idle := int64(coldDays) * secondsPerDay
+		if dp.lastAccessTime == 0 || now-dp.lastAccessTime <= idle || now-dp.tierTime <= idle {
+			return
+		}
+		dp.mediaType = coldMedia
+	case coldMedia:
+		if dp.lastAccessTime <= dp.tierTime {
+			return
+		}
+		dp.mediaType = hotMedia
+	default:
+		// created before the tiering is set
+		dp.mediaType = hotMedia
+	}
+	dp.tierTime = now
+	if err := c.syncUpdateDataPartition(dp); err != nil {
+		dp.mediaType, dp.tierTime = oldMediaType, oldTierTime
+		log.LogErrorf("action[updateDataPartitionTier] vol[%v] dp[%v] err[%v]", dp.VolName, dp.PartitionID, err)
+		return
+	}
+	log.LogInfof("action[updateDataPartitionTier] vol[%v] dp[%v] media type from[%v] to[%v] lastAccessTime[%v]",
+		dp.VolName, dp.PartitionID, oldMediaType, dp.mediaType, dp.lastAccessTime)
+}
+
+// moveDataPartitionToTier moves one replica of the data partition on a disk of another media type
+// to a data node in the same zone with the space of the media type, returns true if a replica is moved.
+func (c *Cluster) moveDataPartitionToTier(vol *Vol, dp *DataPartition) (moved bool) {
+	dp.RLock()
+	var (
+		mediaType   = dp.mediaType
+		hosts       = append([]string(nil), dp.Hosts...)
+		recovering  = dp.isRecover
+		offlineAddr string
+	)
+	for _, replica := range dp.Replicas {
+		if replica.MediaType != "" && replica.MediaType != mediaType {
+			offlineAddr = replica.Addr
+			break
+		}
+	}
+	dp.RUnlock()
+	if offlineAddr == "" || recovering {
+		return
+	}
+	offlineNode, err := c.dataNode(offlineAddr)
+	if err != nil {
+		return
+	}
+	newAddr := c.chooseDataNodeOfMedia(offlineNode.ZoneName, mediaType, vol.dataPartitionSize, hosts)
+	if newAddr == "" {
+		log.LogDebugf("action[moveDataPartitionToTier] vol[%v] dp[%v] no data node of media type[%v] in zone[%v]",
+			dp.VolName, dp.PartitionID, mediaType, offlineNode.ZoneName)
+		return
+	}
+	if err = c.validateDecommissionDataPartition(dp, offlineAddr); err == nil {
+		err = c.moveDataReplica(dp, offlineAddr, newAddr)
+	}
+	if err != nil {
+		log.LogErrorf("action[moveDataPartitionToTier] vol[%v] dp[%v] from[%v] to[%v] err[%v]",
+			dp.VolName, dp.PartitionID, offlineAddr, newAddr, err)
+		return
+	}
+	log.LogWarnf("action[moveDataPartitionToTier] vol[%v] dp[%v] media type[%v] from[%v] to[%v]",
+		dp.VolName, dp.PartitionID, mediaType, offlineAddr, newAddr)
+	return true
+}
+
+// chooseDataNodeOfMedia chooses the writable data node of the zone with the most space of the media type.
+func (c *Cluster) chooseDataNodeOfMedia(zoneName, mediaType string, size uint64, excludeHosts []string) (addr string) {
+	var maxSpace uint64
+	c.dataNodes.Range(func(key, value interface{}) bool {
+		dataNode := value.(*DataNode)
+		if dataNode.ZoneName != zoneName || dataNode.ToBeOffline || contains(excludeHosts, dataNode.Addr) || !dataNode.isWriteAble() {
+			return true
+		}
+		dataNode.RLock()
+		space := dataNode.MediaSpaces[mediaType]
+		dataNode.RUnlock()
+		if space > size && space > maxSpace {
+			addr, maxSpace = dataNode.Addr, space
+		}
+		return true
+	})
+	return
+}
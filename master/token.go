@@ -25,6 +25,9 @@ import (
 	"time"
 )
 
+// The access tokens are refreshed by the clients before they expire.
+const volAccessTokenTTL = time.Hour
+
 type TokenValue struct {
 	VolName   string
 	Value     string
@@ -122,6 +125,52 @@ func (c *Cluster) updateToken(vol *Vol, tokenType int8, token, authKey string) (
 	return
 }
 
+// issueVolAccessToken signs an access token of the volume for the client. The owner of the volume
+// is granted the read-write access, and the others are granted the access of their volume tokens.
+func (c *Cluster) issueVolAccessToken(vol *Vol, authKey, token string) (accessToken *proto.VolAccessToken, err error) {
+	if c.accessTokenKey == nil {
+		return nil, fmt.Errorf("access token is not enabled on the master, %v is not configured", proto.ConfigKeyAccessTokenKey)
+	}
+	tokenType := int8(proto.ReadWriteToken)
+	if authKey == "" || !matchKey(vol.Owner, authKey) {
+		if !vol.enableToken || token == "" {
+			return nil, proto.ErrVolAuthKeyNotMatch
+		}
+		var tokenObj *proto.Token
+		if tokenObj, err = vol.getToken(token); err != nil {
+			return
+		}
+		tokenType = tokenObj.TokenType
+	}
+	return proto.NewVolAccessToken(c.accessTokenKey, vol.Name, tokenType, volAccessTokenTTL), nil
+}
+
+func (m *Server) getVolAccessToken(w http.ResponseWriter, r *http.Request) {
+	var (
+		err         error
+		name        string
+		authKey     string
+		token       string
+		vol         *Vol
+		accessToken *proto.VolAccessToken
+	)
+	if name, authKey, token, err = parseGetVolAccessTokenPara(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	if accessToken, err = m.cluster.issueVolAccessToken(vol, authKey, token); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	log.LogInfof("action[getVolAccessToken] vol[%v] tokenType[%v] expire[%v] issued to[%v]",
+		name, accessToken.TokenType, accessToken.Expire, r.RemoteAddr)
+	sendOkReply(w, r, newSuccessHTTPReply(accessToken))
+}
+
 func (m *Server) addToken(w http.ResponseWriter, r *http.Request) {
 	var (
 		err       error
@@ -307,6 +356,23 @@ func parseGetTokenPara(r *http.Request) (name string, token string, err error) {
 	return
 }
 
+func parseGetVolAccessTokenPara(r *http.Request) (name, authKey, token string, err error) {
+	r.ParseForm()
+	if name, err = extractName(r); err != nil {
+		return
+	}
+	authKey = r.FormValue(volAuthKey)
+	if r.FormValue(tokenKey) != "" {
+		if token, err = extractTokenValue(r); err != nil {
+			return
+		}
+	}
+	if authKey == "" && token == "" {
+		err = keyNotFound(volAuthKey)
+	}
+	return
+}
+
 func extractTokenValue(r *http.Request) (token string, err error) {
 	if token = r.FormValue(tokenKey); token == "" {
 		err = keyNotFound(tokenKey)
@@ -137,3 +137,45 @@ func TestAllocZones(t *testing.T) {
 		}
 	}
 }
+
+func TestSpreadOverFaultDomains(t *testing.T) {
+	zoneName := "test"
+	nodeSet := newNodeSet(1, 6, zoneName)
+	racks := map[string]string{
+		"192.168.0.1:17310": "rack1",
+		"192.168.0.2:17310": "rack1",
+		"192.168.0.3:17310": "rack2",
+		"192.168.0.4:17310": "rack2",
+		"192.168.0.5:17310": "rack3",
+	}
+	for addr, rack := range racks {
+		dn := createDataNodeForTopo(addr, zoneName, nodeSet)
+		dn.Rack = rack
+		nodeSet.putDataNode(dn)
+	}
+	for i := 0; i < 10; i++ {
+		hosts, _, err := nodeSet.getAvailDataNodeHosts(nil, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		selected := make(map[string]bool)
+		for _, host := range hosts {
+			selected[racks[host]] = true
+		}
+		if len(selected) != 3 {
+			t.Fatalf("expect the replicas %v spread over 3 racks", hosts)
+		}
+	}
+	// the new replica avoids the racks of the other replicas
+	hosts, _, err := nodeSet.getAvailDataNodeHosts([]string{"192.168.0.1:17310", "192.168.0.3:17310"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if racks[hosts[0]] != "rack3" {
+		t.Errorf("expect the new replica in rack3 but is [%v] in [%v]", hosts[0], racks[hosts[0]])
+	}
+	// the replicas share the racks if there are not enough racks
+	if hosts, _, err = nodeSet.getAvailDataNodeHosts(nil, 5); err != nil || len(hosts) != 5 {
+		t.Errorf("expect 5 replicas but got %v, err[%v]", hosts, err)
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync/atomic"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const unknownVersion = "unknown"
+
+func (m *Server) setupUpgrade(w http.ResponseWriter, r *http.Request) {
+	var (
+		status bool
+		err    error
+	)
+	if status, err = parseAndExtractStatus(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.setUpgrading(status); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set Upgrading to %v successfully", status)))
+}
+
+func (m *Server) getUpgradeStatus(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.upgradeStatus()))
+}
+
+func (m *Server) transferDataNodeLeaders(w http.ResponseWriter, r *http.Request) {
+	var (
+		nodeAddr string
+		dataNode *DataNode
+		result   *proto.TransferLeadersResult
+		err      error
+	)
+	if nodeAddr, err = parseAndExtractNodeAddr(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if dataNode, err = m.cluster.dataNode(nodeAddr); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeDataNodeNotExists, Msg: err.Error()})
+		return
+	}
+	if result, err = m.cluster.transferDataNodeLeaders(dataNode); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(result))
+}
+
+func (m *Server) transferMetaNodeLeaders(w http.ResponseWriter, r *http.Request) {
+	var (
+		nodeAddr string
+		metaNode *MetaNode
+		result   *proto.TransferLeadersResult
+		err      error
+	)
+	if nodeAddr, err = parseAndExtractNodeAddr(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if metaNode, err = m.cluster.metaNode(nodeAddr); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeMetaNodeNotExists, Msg: err.Error()})
+		return
+	}
+	if result, err = m.cluster.transferMetaNodeLeaders(metaNode); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(result))
+}
+
+// setUpgrading marks the cluster in a rolling upgrade. The automatic creation of the data partitions,
+// the reduction of the replicas and the rebalance are paused meanwhile, so no replica is moved
+// or created on the nodes being restarted.
+func (c *Cluster) setUpgrading(upgrading bool) (err error) {
+	oldFlag := c.Upgrading
+	c.Upgrading = upgrading
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setUpgrading] err[%v]", err)
+		c.Upgrading = oldFlag
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
+
+// upgradeStatus reports the versions of the nodes, the nodes not reporting a version run a release before it.
+func (c *Cluster) upgradeStatus() (status *proto.UpgradeStatus) {
+	status = &proto.UpgradeStatus{
+		Upgrading:     c.Upgrading,
+		MasterVersion: proto.Version,
+		Versions:      make(map[string]int),
+		DataNodes:     make([]*proto.NodeVersion, 0),
+		MetaNodes:     make([]*proto.NodeVersion, 0),
+	}
+	leaders := c.partitionLeaders()
+	addNode := func(nodes []*proto.NodeVersion, nv *proto.NodeVersion) []*proto.NodeVersion {
+		if nv.Version == "" {
+			nv.Version = unknownVersion
+		}
+		nv.LeaderCount = leaders[nv.Addr]
+		status.Versions[nv.Version]++
+		return append(nodes, nv)
+	}
+	c.dataNodes.Range(func(key, value interface{}) bool {
+		dataNode := value.(*DataNode)
+		dataNode.RLock()
+		nv := &proto.NodeVersion{Addr: dataNode.Addr, Version: dataNode.Version, IsActive: dataNode.isActive}
+		dataNode.RUnlock()
+		status.DataNodes = addNode(status.DataNodes, nv)
+		return true
+	})
+	c.metaNodes.Range(func(key, value interface{}) bool {
+		metaNode := value.(*MetaNode)
+		metaNode.RLock()
+		nv := &proto.NodeVersion{Addr: metaNode.Addr, Version: metaNode.Version, IsActive: metaNode.IsActive}
+		metaNode.RUnlock()
+		status.MetaNodes = addNode(status.MetaNodes, nv)
+		return true
+	})
+	sort.Slice(status.DataNodes, func(i, j int) bool { return status.DataNodes[i].Addr < status.DataNodes[j].Addr })
+	sort.Slice(status.MetaNodes, func(i, j int) bool { return status.MetaNodes[i].Addr < status.MetaNodes[j].Addr })
+	return
+}
+
+// partitionLeaders returns the number of the partitions led by each node.
+func (c *Cluster) partitionLeaders() (leaders map[string]int) {
+	leaders = make(map[string]int)
+	for _, vol := range c.copyVols() {
+		for _, dp := range vol.cloneDataPartitionMap() {
+			if leaderAddr := dp.getLeaderAddrWithLock(); leaderAddr != "" {
+				leaders[leaderAddr]++
+			}
+		}
+		for _, mp := range vol.cloneMetaPartitionMap() {
+			mp.RLock()
+			mr, err := mp.getMetaReplicaLeader()
+			mp.RUnlock()
+			if err == nil {
+				leaders[mr.Addr]++
+			}
+		}
+	}
+	return
+}
+
+// transferDataNodeLeaders asks another active replica of each data partition led by the node to be the leader,
+// so the node can be restarted without interrupting the IO. Only one node is drained at a time.
+func (c *Cluster) transferDataNodeLeaders(dataNode *DataNode) (result *proto.TransferLeadersResult, err error) {
+	if !atomic.CompareAndSwapInt32(&c.transferringLeaders, 0, 1) {
+		return nil, fmt.Errorf("the leaders of another node are being transferred")
+	}
+	defer atomic.StoreInt32(&c.transferringLeaders, 0)
+
+	result = &proto.TransferLeadersResult{Addr: dataNode.Addr, NodeType: nodeTypeDataNode}
+	for _, vol := range c.copyVols() {
+		for _, dp := range vol.cloneDataPartitionMap() {
+			if dp.getLeaderAddrWithLock() != dataNode.Addr {
+				continue
+			}
+			result.Leaders++
+			dp.RLock()
+			hosts := append([]string(nil), dp.Hosts...)
+			dp.RUnlock()
+			var target *DataNode
+			for _, host := range hosts {
+				if node, e := c.dataNode(host); e == nil && host != dataNode.Addr && node.isActive {
+					target = node
+					break
+				}
+			}
+			if target == nil {
+				result.NoTargetPartitions = append(result.NoTargetPartitions, dp.PartitionID)
+				continue
+			}
+			if e := dp.tryToChangeLeader(c, target); e != nil {
+				log.LogWarnf("action[transferDataNodeLeaders] partition[%v] from[%v] to[%v] err[%v]", dp.PartitionID, dataNode.Addr, target.Addr, e)
+				result.FailedPartitions = append(result.FailedPartitions, dp.PartitionID)
+				continue
+			}
+			result.Transferred++
+		}
+	}
+	log.LogInfof("action[transferDataNodeLeaders] node[%v] leaders[%v] transferred[%v]", dataNode.Addr, result.Leaders, result.Transferred)
+	return
+}
+
+// transferMetaNodeLeaders is transferDataNodeLeaders for the meta partitions.
+func (c *Cluster) transferMetaNodeLeaders(metaNode *MetaNode) (result *proto.TransferLeadersResult, err error) {
+	if !atomic.CompareAndSwapInt32(&c.transferringLeaders, 0, 1) {
+		return nil, fmt.Errorf("the leaders of another node are being transferred")
+	}
+	defer atomic.StoreInt32(&c.transferringLeaders, 0)
+
+	result = &proto.TransferLeadersResult{Addr: metaNode.Addr, NodeType: nodeTypeMetaNode}
+	for _, vol := range c.copyVols() {
+		for _, mp := range vol.cloneMetaPartitionMap() {
+			mp.RLock()
+			mr, e := mp.getMetaReplicaLeader()
+			hosts := append([]string(nil), mp.Hosts...)
+			mp.RUnlock()
+			if e != nil || mr.Addr != metaNode.Addr {
+				continue
+			}
+			result.Leaders++
+			var target *MetaNode
+			for _, host := range hosts {
+				if node, e := c.metaNode(host); e == nil && host != metaNode.Addr && node.IsActive {
+					target = node
+					break
+				}
+			}
+			if target == nil {
+				result.NoTargetPartitions = append(result.NoTargetPartitions, mp.PartitionID)
+				continue
+			}
+			if e = mp.tryToChangeLeader(c, target); e != nil {
+				log.LogWarnf("action[transferMetaNodeLeaders] partition[%v] from[%v] to[%v] err[%v]", mp.PartitionID, metaNode.Addr, target.Addr, e)
+				result.FailedPartitions = append(result.FailedPartitions, mp.PartitionID)
+				continue
+			}
+			result.Transferred++
+		}
+	}
+	log.LogInfof("action[transferMetaNodeLeaders] node[%v] leaders[%v] transferred[%v]", metaNode.Addr, result.Leaders, result.Transferred)
+	return
+}
@@ -1,6 +1,7 @@
 package master
 
 import (
+	"crypto/hmac"
 	"crypto/sha1"
 	"encoding/hex"
 	"io"
@@ -237,6 +238,42 @@ func (u *User) getKeyInfo(ak string) (userInfo *proto.UserInfo, err error) {
 	return
 }
 
+// authorize checks the signature made by the secret key of the access key and
+// resolves the permission of the user to the volume without exposing the key.
+func (u *User) authorize(ak, volume, subdir string, timestamp int64, sign string) (result *proto.UserAuthorizeResult, err error) {
+	var userInfo *proto.UserInfo
+	if userInfo, err = u.getKeyInfo(ak); err != nil {
+		return
+	}
+	var skew = time.Since(time.Unix(timestamp, 0))
+	if skew > proto.UserAuthorizeMaxSkew || skew < -proto.UserAuthorizeMaxSkew {
+		err = proto.ErrNoPermission
+		return
+	}
+	var expected = proto.SignUserAuthorization(userInfo.SecretKey, ak, volume, timestamp)
+	if !hmac.Equal([]byte(expected), []byte(sign)) {
+		err = proto.ErrNoPermission
+		return
+	}
+	result = &proto.UserAuthorizeResult{UserID: userInfo.UserID, Volume: volume}
+	var policy = userInfo.Policy
+	var readable = policy.IsAuthorized(volume, subdir, proto.POSIXReadAction)
+	var writable = policy.IsAuthorized(volume, subdir, proto.POSIXWriteAction)
+	switch {
+	case policy.IsOwn(volume):
+		result.Own = true
+	case readable && writable:
+	case readable:
+		result.Rdonly = true
+	default:
+		result, err = nil, proto.ErrNoPermission
+		return
+	}
+	log.LogInfof("action[authorize], accesskey[%v] volume[%v] subdir[%v] own[%v] rdonly[%v]",
+		ak, volume, subdir, result.Own, result.Rdonly)
+	return
+}
+
 func (u *User) getUserInfo(userID string) (userInfo *proto.UserInfo, err error) {
 	if value, exist := u.userStore.Load(userID); exist {
 		userInfo = value.(*proto.UserInfo)
@@ -379,6 +416,58 @@ func (u *User) deleteVolPolicy(volName string) (err error) {
 	return
 }
 
+// renameVolPolicy moves the policies of the users of the renamed volume to its name.
+func (u *User) renameVolPolicy(volName, newVolName string) (err error) {
+	var (
+		volUser  *proto.VolUser
+		userInfo *proto.UserInfo
+		userIDs  []string
+	)
+	if userIDs, err = u.getUsersOfVol(volName); err != nil {
+		return
+	}
+	for _, userID := range userIDs {
+		if userInfo, err = u.getUserInfo(userID); err != nil {
+			if err == proto.ErrUserNotExists {
+				log.LogWarnf("action[renameVolPolicy], userID: %v does not exist", userID)
+				continue
+			}
+			return
+		}
+		userInfo.Mu.Lock()
+		userInfo.Policy.RenameVol(volName, newVolName)
+		if err = u.syncUpdateUserInfo(userInfo); err != nil {
+			err = proto.ErrPersistenceByRaft
+			userInfo.Mu.Unlock()
+			return
+		}
+		userInfo.Mu.Unlock()
+	}
+	//rename volName index
+	u.volUserMutex.Lock()
+	defer u.volUserMutex.Unlock()
+	if value, exist := u.volUser.Load(volName); exist {
+		volUser = value.(*proto.VolUser)
+	} else {
+		return nil
+	}
+	volUser.Mu.Lock()
+	defer volUser.Mu.Unlock()
+	newVolUser := &proto.VolUser{Vol: newVolName, UserIDs: volUser.UserIDs}
+	if err = u.syncAddVolUser(newVolUser); err != nil {
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	u.volUser.Store(newVolName, newVolUser)
+	if err = u.syncDeleteVolUser(volUser); err != nil {
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	u.volUser.Delete(volName)
+	log.LogInfof("action[renameVolPolicy], volName: %v, newVolName: %v", volName, newVolName)
+	return
+}
+
 func (u *User) transferVol(params *proto.UserTransferVolParam) (targetUserInfo *proto.UserInfo, err error) {
 	var userInfo *proto.UserInfo
 	userInfo, err = u.getUserInfo(params.UserSrc)
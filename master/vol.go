@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util"
@@ -26,15 +27,23 @@ import (
 )
 
 type VolVarargs struct {
-	zoneName       string
-	description    string
-	capacity       uint64 //GB
-	dpReplicaNum   uint8
-	followerRead   bool
-	authenticate   bool
-	enableToken    bool
-	dpSelectorName string
-	dpSelectorParm string
+	zoneName        string
+	description     string
+	capacity        uint64 //GB
+	dpReplicaNum    uint8
+	followerRead    bool
+	authenticate    bool
+	enableToken     bool
+	dpSelectorName  string
+	dpSelectorParm  string
+	trashDays       uint32
+	hotMedia        string
+	coldMedia       string
+	coldDays        uint32
+	caseInsensitive bool
+	enforcePerm     bool
+	smallFileSize   uint32
+	permPriority    string
 }
 
 // Vol represents a set of meta partitionMap and data partitionMap
@@ -58,6 +67,8 @@ type Vol struct {
 	enableToken        bool
 	tokens             map[string]*proto.Token
 	tokensLock         sync.RWMutex
+	quotas             map[uint64]*proto.QuotaInfo
+	quotasLock         sync.RWMutex
 	MetaPartitions     map[uint64]*MetaPartition `graphql:"-"`
 	mpsLock            sync.RWMutex
 	dataPartitions     *DataPartitionMap
@@ -69,13 +80,30 @@ type Vol struct {
 	description        string
 	dpSelectorName     string
 	dpSelectorParm     string
+	trashDays          uint32 // days to keep the deleted files in the trash, 0 means disabled
+	hotMedia           string // media type of the data partitions accessed recently
+	coldMedia          string // media type of the data partitions not accessed for coldDays
+	coldDays           uint32 // days without access to move a data partition to the cold media, 0 means no tiering
+	caseInsensitive    bool   // the lookups of the clients which support it ignore the case, e.g. for the SMB gateways
+	enforcePerm        bool   // the meta nodes reject the requests of the clients without the credentials of the users
+	smallFileSize      uint32 // the files up to the size are packed into the tiny extents, 0 means no packing
+	dataKeyID          string // id of the master key which wraps the data key, empty if the volume is not encrypted
+	wrappedDataKey     []byte // data key to encrypt the extents of the volume, wrapped by the master key
+	flat               bool   // the objects are stored by their keys in the root directory without intermediate directories
+	permissionPriority string // which one of the mode bits and the S3 ACLs takes effect, none by default
+	readOnly           bool   // the volume is frozen read-only, the meta nodes and the data nodes reject the writes
+	cache              bool   // the data has a single replica without durability guarantee, for the scratch and cache use
+	capacityProgress   *proto.VolCapacityProgress
+	replicaNumProgress *proto.VolReplicaNumProgress
+	aliases            []string // the former names of the renamed volume, see renameVol
+	purgeTime          int64    // unix time to purge the volume marked deleted, see markDeleteVol
 	sync.RWMutex
 }
 
 func newVol(id uint64, name, owner, zoneName string, dpSize, capacity uint64, dpReplicaNum, mpReplicaNum uint8, followerRead, authenticate, crossZone bool, enableToken bool, createTime int64, description string) (vol *Vol) {
 	vol = &Vol{ID: id, Name: name, MetaPartitions: make(map[uint64]*MetaPartition, 0)}
 	vol.dataPartitions = newDataPartitionMap(name)
-	if dpReplicaNum < defaultReplicaNum {
+	if dpReplicaNum == 0 {
 		dpReplicaNum = defaultReplicaNum
 	}
 	vol.dpReplicaNum = dpReplicaNum
@@ -102,7 +130,9 @@ func newVol(id uint64, name, owner, zoneName string, dpSize, capacity uint64, dp
 	vol.createTime = createTime
 	vol.enableToken = enableToken
 	vol.tokens = make(map[string]*proto.Token, 0)
+	vol.quotas = make(map[uint64]*proto.QuotaInfo, 0)
 	vol.description = description
+	vol.smallFileSize = util.DefaultTinySizeLimit
 	return
 }
 
@@ -127,9 +157,35 @@ func newVolFromVolValue(vv *volValue) (vol *Vol) {
 	vol.Status = vv.Status
 	vol.dpSelectorName = vv.DpSelectorName
 	vol.dpSelectorParm = vv.DpSelectorParm
+	vol.trashDays = vv.TrashDays
+	vol.hotMedia = vv.HotMedia
+	vol.coldMedia = vv.ColdMedia
+	vol.coldDays = vv.ColdDays
+	vol.caseInsensitive = vv.CaseInsensitive
+	vol.enforcePerm = vv.EnforcePerm
+	if vv.DisablePacking {
+		vol.smallFileSize = 0
+	} else if vv.SmallFileSize != 0 {
+		vol.smallFileSize = vv.SmallFileSize
+	}
+	vol.dataKeyID = vv.DataKeyID
+	vol.wrappedDataKey = vv.WrappedDataKey
+	vol.flat = vv.Flat
+	vol.permissionPriority = vv.PermPriority
+	vol.readOnly = vv.ReadOnly
+	vol.aliases = vv.Aliases
+	vol.cache = vv.Cache
+	vol.purgeTime = vv.PurgeTime
+	for _, quota := range vv.Quotas {
+		vol.quotas[quota.QuotaId] = quota
+	}
 	return vol
 }
 
+func (vol *Vol) encrypted() bool {
+	return len(vol.wrappedDataKey) != 0
+}
+
 func (vol *Vol) refreshOSSSecure() (key, secret string) {
 	vol.OSSAccessKey = util.RandomString(16, util.Numeric|util.LowerLetter|util.UpperLetter)
 	vol.OSSSecretKey = util.RandomString(32, util.Numeric|util.LowerLetter|util.UpperLetter)
@@ -287,6 +343,10 @@ func (vol *Vol) checkReplicaNum(c *Cluster) {
 	if !vol.NeedToLowerReplica {
 		return
 	}
+	// the data partitions are being converted by changeReplicaNum
+	if vol.checkReplicaNumChange() != nil {
+		return
+	}
 	var err error
 	dps := vol.cloneDataPartitionMap()
 	for _, dp := range dps {
@@ -460,6 +520,11 @@ func (vol *Vol) updateViewCache(c *Cluster) {
 	view := proto.NewVolView(vol.Name, vol.Status, vol.FollowerRead, vol.createTime)
 	view.SetOwner(vol.Owner)
 	view.SetOSSSecure(vol.OSSAccessKey, vol.OSSSecretKey)
+	view.TrashDays = vol.trashDays
+	view.CaseInsensitive = vol.caseInsensitive
+	view.Flat = vol.flat
+	view.PermissionPriority = vol.permissionPriority
+	view.ReadOnly = vol.readOnly
 	mpViews := vol.getMetaPartitionsView()
 	view.MetaPartitions = mpViews
 	mpViewsReply := newSuccessHTTPReply(mpViews)
@@ -528,7 +593,7 @@ func (vol *Vol) checkStatus(c *Cluster) {
 	vol.updateViewCache(c)
 	vol.Lock()
 	defer vol.Unlock()
-	if vol.Status != markDelete {
+	if vol.Status != markDelete || time.Now().Unix() < vol.purgeTime {
 		return
 	}
 	log.LogInfof("action[volCheckStatus] vol[%v],status[%v]", vol.Name, vol.Status)
@@ -813,14 +878,22 @@ func (vol *Vol) doCreateMetaPartition(c *Cluster, start, end uint64) (mp *MetaPa
 
 func getVolVarargs(vol *Vol) *VolVarargs {
 	return &VolVarargs{
-		zoneName:       vol.zoneName,
-		description:    vol.description,
-		capacity:       vol.Capacity,
-		dpReplicaNum:   vol.dpReplicaNum,
-		followerRead:   vol.FollowerRead,
-		authenticate:   vol.authenticate,
-		enableToken:    vol.enableToken,
-		dpSelectorName: vol.dpSelectorName,
-		dpSelectorParm: vol.dpSelectorParm,
+		zoneName:        vol.zoneName,
+		description:     vol.description,
+		capacity:        vol.Capacity,
+		dpReplicaNum:    vol.dpReplicaNum,
+		followerRead:    vol.FollowerRead,
+		authenticate:    vol.authenticate,
+		enableToken:     vol.enableToken,
+		dpSelectorName:  vol.dpSelectorName,
+		dpSelectorParm:  vol.dpSelectorParm,
+		trashDays:       vol.trashDays,
+		hotMedia:        vol.hotMedia,
+		coldMedia:       vol.coldMedia,
+		coldDays:        vol.coldDays,
+		caseInsensitive: vol.caseInsensitive,
+		enforcePerm:     vol.enforcePerm,
+		smallFileSize:   vol.smallFileSize,
+		permPriority:    vol.permissionPriority,
 	}
 }
@@ -0,0 +1,199 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	volCapacityExpand   = "expand"
+	volCapacityShrink   = "shrink"
+	volCapacityRunning  = "running"
+	volCapacityFinished = "finished"
+)
+
+// checkCapacityChange makes sure only one capacity change of the volume is in progress.
+func (vol *Vol) checkCapacityChange() (err error) {
+	vol.RLock()
+	defer vol.RUnlock()
+	if vol.capacityProgress != nil && vol.capacityProgress.Status == volCapacityRunning {
+		return fmt.Errorf("the capacity change of vol[%v] from %v to %v is in progress",
+			vol.Name, vol.capacityProgress.OldCapacity, vol.capacityProgress.NewCapacity)
+	}
+	return
+}
+
+func (vol *Vol) getCapacityProgress() (progress *proto.VolCapacityProgress) {
+	vol.RLock()
+	defer vol.RUnlock()
+	if vol.capacityProgress == nil {
+		return nil
+	}
+	progress = new(proto.VolCapacityProgress)
+	*progress = *vol.capacityProgress
+	return
+}
+
+func (vol *Vol) updateCapacityProgress(update func(progress *proto.VolCapacityProgress)) {
+	vol.Lock()
+	defer vol.Unlock()
+	update(vol.capacityProgress)
+}
+
+// changeCapacity adjusts the writable data partitions of the volume to the new capacity in background:
+// some data partitions are made writable right away after an expansion, and the empty writable
+// data partitions more than the new capacity needs are set read only after a shrink.
+// The progress is kept in the memory of the master leader only.
+func (c *Cluster) changeCapacity(vol *Vol, action string, oldCapacity, newCapacity uint64) {
+	vol.Lock()
+	vol.capacityProgress = &proto.VolCapacityProgress{
+		Name:        vol.Name,
+		Action:      action,
+		OldCapacity: oldCapacity,
+		NewCapacity: newCapacity,
+		Status:      volCapacityRunning,
+		StartTime:   time.Now().Format(proto.TimeFormat),
+	}
+	vol.Unlock()
+	go func() {
+		var err error
+		if action == volCapacityExpand {
+			err = c.expandDataPartitions(vol, oldCapacity, newCapacity)
+		} else {
+			err = c.shrinkDataPartitions(vol, newCapacity)
+		}
+		vol.updateCapacityProgress(func(progress *proto.VolCapacityProgress) {
+			progress.Status = volCapacityFinished
+			progress.EndTime = time.Now().Format(proto.TimeFormat)
+			if err != nil {
+				progress.Msg = err.Error()
+			}
+		})
+		log.LogInfof("action[changeCapacity] vol[%v] %v from %v to %v finished, err[%v]",
+			vol.Name, action, oldCapacity, newCapacity, err)
+	}()
+}
+
+func (c *Cluster) expandDataPartitions(vol *Vol, oldCapacity, newCapacity uint64) (err error) {
+	count := int(math.Ceil(float64(newCapacity-oldCapacity) * float64(util.GB) * volExpansionRatio / float64(vol.dataPartitionSize)))
+	if count > maxNumberOfDataPartitionsForExpansion {
+		count = maxNumberOfDataPartitionsForExpansion
+	}
+	vol.updateCapacityProgress(func(progress *proto.VolCapacityProgress) {
+		progress.Total = count
+	})
+	var done int
+	// the data partitions set read only by an earlier shrink are reused first
+	for _, dp := range vol.getManualReadOnlyDataPartitions() {
+		if done >= count {
+			return
+		}
+		if err = c.setDataPartitionManual(dp, false); err != nil {
+			return
+		}
+		done++
+		vol.updateCapacityProgress(func(progress *proto.VolCapacityProgress) {
+			progress.Done = done
+		})
+	}
+	for ; done < count; done++ {
+		if c.DisableAutoAllocate {
+			return fmt.Errorf("auto allocation of data partitions is disabled")
+		}
+		if _, err = c.createDataPartition(vol.Name, c.decideZoneNum(vol.crossZone)); err != nil {
+			return
+		}
+		vol.updateCapacityProgress(func(progress *proto.VolCapacityProgress) {
+			progress.Done = done + 1
+		})
+	}
+	return
+}
+
+func (c *Cluster) shrinkDataPartitions(vol *Vol, newCapacity uint64) (err error) {
+	needed := int(math.Ceil(float64(newCapacity) * float64(util.GB) / float64(vol.dataPartitionSize)))
+	if needed < minNumOfRWDataPartitions {
+		needed = minNumOfRWDataPartitions
+	}
+	var writable, empty []*DataPartition
+	for _, dp := range vol.cloneDataPartitionMap() {
+		dp.RLock()
+		if dp.Status == proto.ReadWrite && !dp.isManual {
+			writable = append(writable, dp)
+			if dp.used == 0 {
+				empty = append(empty, dp)
+			}
+		}
+		dp.RUnlock()
+	}
+	count := len(writable) - needed
+	if count > len(empty) {
+		count = len(empty)
+	}
+	if count <= 0 {
+		return
+	}
+	vol.updateCapacityProgress(func(progress *proto.VolCapacityProgress) {
+		progress.Total = count
+	})
+	// the newest data partitions are set read only first
+	sort.Slice(empty, func(i, j int) bool {
+		return empty[i].PartitionID > empty[j].PartitionID
+	})
+	for i := 0; i < count; i++ {
+		if err = c.setDataPartitionManual(empty[i], true); err != nil {
+			return
+		}
+		vol.updateCapacityProgress(func(progress *proto.VolCapacityProgress) {
+			progress.Done = i + 1
+		})
+	}
+	return
+}
+
+func (vol *Vol) getManualReadOnlyDataPartitions() (partitions []*DataPartition) {
+	for _, dp := range vol.cloneDataPartitionMap() {
+		dp.RLock()
+		if dp.isManual {
+			partitions = append(partitions, dp)
+		}
+		dp.RUnlock()
+	}
+	return
+}
+
+func (c *Cluster) setDataPartitionManual(dp *DataPartition, isManual bool) (err error) {
+	dp.Lock()
+	defer dp.Unlock()
+	oldManual := dp.isManual
+	dp.isManual = isManual
+	if err = c.syncUpdateDataPartition(dp); err != nil {
+		dp.isManual = oldManual
+		return
+	}
+	if isManual {
+		dp.Status = proto.ReadOnly
+	}
+	log.LogInfof("action[setDataPartitionManual] vol[%v] dp[%v] isManual[%v]", dp.VolName, dp.PartitionID, isManual)
+	return
+}
@@ -0,0 +1,94 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// isPurging returns true if the volume is marked deleted and its purge time has come,
+// then the meta partitions and the data partitions are being deleted from the nodes.
+func (vol *Vol) isPurging() bool {
+	vol.RLock()
+	defer vol.RUnlock()
+	return vol.Status == markDelete && time.Now().Unix() >= vol.purgeTime
+}
+
+func (vol *Vol) getPurgeTime() int64 {
+	vol.RLock()
+	defer vol.RUnlock()
+	if vol.Status != markDelete {
+		return 0
+	}
+	return vol.purgeTime
+}
+
+// restoreVol restores the volume marked deleted before its purge time.
+func (c *Cluster) restoreVol(name, authKey string) (vol *Vol, err error) {
+	if vol, err = c.getVol(name); err != nil {
+		return nil, proto.ErrVolNotExists
+	}
+	if !matchKey(vol.Owner, authKey) {
+		return nil, proto.ErrVolAuthKeyNotMatch
+	}
+	vol.Lock()
+	defer vol.Unlock()
+	if vol.Status != markDelete {
+		return nil, fmt.Errorf("vol[%v] is not deleted", name)
+	}
+	if time.Now().Unix() >= vol.purgeTime {
+		return nil, fmt.Errorf("vol[%v] is being purged since %v", name, time.Unix(vol.purgeTime, 0).Format(proto.TimeFormat))
+	}
+	oldPurgeTime := vol.purgeTime
+	vol.Status = normal
+	vol.purgeTime = 0
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.Status = markDelete
+		vol.purgeTime = oldPurgeTime
+		return nil, proto.ErrPersistenceByRaft
+	}
+	log.LogWarnf("action[restoreVol] vol[%v] restored", name)
+	return
+}
+
+func (m *Server) restoreVol(w http.ResponseWriter, r *http.Request) {
+	var (
+		name    string
+		authKey string
+		vol     *Vol
+		err     error
+	)
+	if name, authKey, err = parseVolNameAndAuthKey(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.restoreVol(name, authKey); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	// the policies of the volume were removed by the deletion, grant the owner again
+	if err = m.associateVolWithUser(vol.Owner, vol.Name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf("restore vol[%v] successfully,from[%v]", name, r.RemoteAddr)
+	log.LogWarn(msg)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
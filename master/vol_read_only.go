@@ -0,0 +1,92 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// setVolReadOnly freezes the volume read-only or thaws it. The frozen volumes are sent to the meta nodes and
+// the data nodes with the heartbeats, which reject the writes to the volume with OpVolReadOnly, and the write
+// capability of the client sessions of the volume is withdrawn.
+func (c *Cluster) setVolReadOnly(name, authKey string, readOnly bool) (err error) {
+	var vol *Vol
+	if vol, err = c.getVol(name); err != nil {
+		return
+	}
+	if !matchKey(vol.Owner, authKey) {
+		return proto.ErrVolAuthKeyNotMatch
+	}
+	vol.Lock()
+	defer vol.Unlock()
+	if vol.readOnly == readOnly {
+		return
+	}
+	vol.readOnly = readOnly
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.readOnly = !readOnly
+		return
+	}
+	log.LogWarnf("action[setVolReadOnly] vol[%v] readOnly[%v]", name, readOnly)
+	// tell the nodes at once instead of waiting for the next heartbeats
+	go c.checkMetaNodeHeartbeat()
+	go c.checkDataNodeHeartbeat()
+	return
+}
+
+// readOnlyVols returns the names of the volumes frozen read-only.
+func (c *Cluster) readOnlyVols() (names []string) {
+	for name, vol := range c.allVols() {
+		if vol.readOnly {
+			names = append(names, name)
+		}
+	}
+	return
+}
+
+// grantCapabilities withdraws the write capability of the client session if the volume is frozen read-only.
+func (c *Cluster) grantCapabilities(view *proto.ClientSessionView) {
+	if vol, err := c.getVol(view.Volume); err == nil && vol.readOnly {
+		view.Capabilities &^= proto.ClientCapWrite
+	}
+}
+
+func (m *Server) setVolReadOnly(w http.ResponseWriter, r *http.Request) {
+	var (
+		name     string
+		authKey  string
+		readOnly bool
+		err      error
+	)
+	if name, authKey, err = parseVolNameAndAuthKey(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if readOnly, err = parseAndExtractStatus(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.setVolReadOnly(name, authKey, readOnly); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf("set vol[%v] readOnly to %v successfully,from[%v]", name, readOnly, r.RemoteAddr)
+	log.LogWarn(msg)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
@@ -0,0 +1,173 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// renameVol renames the volume, and returns its former name. The former name stays an alias of the volume,
+// so that the mounted clients and the partitions on the nodes which still use it keep working. The aliases are
+// sent to the nodes with the heartbeats to rename their partitions, and the clients learn the new name from
+// their sessions. An alias can not be taken by another volume until the volume is deleted.
+func (c *Cluster) renameVol(name, newName, authKey string) (oldName string, err error) {
+	c.createVolMutex.Lock()
+	defer c.createVolMutex.Unlock()
+	var vol *Vol
+	if vol, err = c.checkRenameVol(name, newName, authKey); err != nil {
+		return
+	}
+	vol.Lock()
+	oldName, oldAliases := vol.Name, vol.aliases
+	if oldName == newName {
+		vol.Unlock()
+		return
+	}
+	aliases := make([]string, 0, len(oldAliases)+1)
+	for _, alias := range oldAliases {
+		if alias != newName {
+			aliases = append(aliases, alias)
+		}
+	}
+	vol.Name, vol.aliases = newName, append(aliases, oldName)
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.Name, vol.aliases = oldName, oldAliases
+		vol.Unlock()
+		return
+	}
+	vol.Unlock()
+
+	c.volMutex.Lock()
+	delete(c.vols, oldName)
+	delete(c.volAliases, newName)
+	c.vols[newName] = vol
+	for _, alias := range vol.aliases {
+		c.volAliases[alias] = newName
+	}
+	c.volMutex.Unlock()
+	c.volStatInfo.Delete(oldName)
+	vol.renamePartitions(newName)
+	vol.updateViewCache(c)
+	c.clientSessions.rename(oldName, newName)
+	log.LogWarnf("action[renameVol] vol[%v] renamed to [%v], aliases%v", oldName, newName, vol.aliases)
+	return
+}
+
+// checkRenameVol checks if the volume can be renamed to the new name, and returns the volume.
+func (c *Cluster) checkRenameVol(name, newName, authKey string) (vol *Vol, err error) {
+	if vol, err = c.getVol(name); err != nil {
+		return
+	}
+	if !matchKey(vol.Owner, authKey) {
+		return nil, proto.ErrVolAuthKeyNotMatch
+	}
+	if other, e := c.getVol(newName); e == nil && other != vol {
+		return nil, proto.ErrDuplicateVol
+	}
+	return
+}
+
+// renamePartitions renames the meta partitions and the data partitions of the volume on the master,
+// the partitions on the nodes are renamed by the heartbeats.
+func (vol *Vol) renamePartitions(name string) {
+	for _, mp := range vol.cloneMetaPartitionMap() {
+		mp.Lock()
+		mp.volName = name
+		mp.Unlock()
+	}
+	for _, dp := range vol.cloneDataPartitionMap() {
+		dp.Lock()
+		dp.VolName = name
+		dp.Unlock()
+	}
+	vol.dataPartitions.Lock()
+	vol.dataPartitions.volName = name
+	vol.dataPartitions.Unlock()
+}
+
+// volName returns the name of the volume the name or the former name refers to.
+func (c *Cluster) volName(name string) string {
+	if vol, err := c.getVol(name); err == nil {
+		return vol.Name
+	}
+	return name
+}
+
+// renamedVols returns the former names of the renamed volumes mapped to their names.
+func (c *Cluster) renamedVols() (renamed map[string]string) {
+	c.volMutex.RLock()
+	defer c.volMutex.RUnlock()
+	if len(c.volAliases) == 0 {
+		return
+	}
+	renamed = make(map[string]string, len(c.volAliases))
+	for alias, name := range c.volAliases {
+		renamed[alias] = name
+	}
+	return
+}
+
+func (m *Server) renameVol(w http.ResponseWriter, r *http.Request) {
+	var (
+		name    string
+		newName string
+		authKey string
+		oldName string
+		err     error
+	)
+	if name, authKey, err = parseVolNameAndAuthKey(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if newName = r.FormValue(newNameKey); newName == "" {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: keyNotFound(newNameKey).Error()})
+		return
+	}
+	if !volNameRegexp.MatchString(newName) {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(newNameKey).Error()})
+		return
+	}
+	var vol *Vol
+	if vol, err = m.cluster.checkRenameVol(name, newName, authKey); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	// The policies are renamed before the volume and renamed back if the volume fails to be renamed,
+	// so that the users never lose the access to the renamed volume.
+	var policyRenamed bool
+	if oldName = vol.Name; oldName != newName {
+		if err = m.user.renameVolPolicy(oldName, newName); err != nil && err != proto.ErrHaveNoPolicy {
+			sendErrReply(w, r, newErrHTTPReply(err))
+			return
+		}
+		policyRenamed = err == nil
+	}
+	if oldName, err = m.cluster.renameVol(name, newName, authKey); err != nil {
+		if policyRenamed {
+			if e := m.user.renameVolPolicy(newName, vol.Name); e != nil {
+				log.LogErrorf("action[renameVol] vol[%v] rename policies back from [%v] err[%v]", vol.Name, newName, e)
+			}
+		}
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf("rename vol[%v] to [%v] successfully,from[%v]", oldName, newName, r.RemoteAddr)
+	log.LogWarn(msg)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
@@ -0,0 +1,257 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// checkReplicaNumChange makes sure only one replica number change of the volume is in progress.
+func (vol *Vol) checkReplicaNumChange() (err error) {
+	vol.RLock()
+	defer vol.RUnlock()
+	if vol.replicaNumProgress != nil && vol.replicaNumProgress.Status == volCapacityRunning {
+		return fmt.Errorf("the replica number change of vol[%v] from %v to %v is in progress",
+			vol.Name, vol.replicaNumProgress.OldReplicaNum, vol.replicaNumProgress.NewReplicaNum)
+	}
+	return
+}
+
+func (vol *Vol) getReplicaNumProgress() (progress *proto.VolReplicaNumProgress) {
+	vol.RLock()
+	defer vol.RUnlock()
+	if vol.replicaNumProgress == nil {
+		return nil
+	}
+	progress = new(proto.VolReplicaNumProgress)
+	*progress = *vol.replicaNumProgress
+	return
+}
+
+func (vol *Vol) updateReplicaNumProgress(update func(progress *proto.VolReplicaNumProgress)) {
+	vol.Lock()
+	defer vol.Unlock()
+	update(vol.replicaNumProgress)
+}
+
+// changeReplicaNum changes the replica number of the data partitions of the volume, and converts the existing
+// data partitions partition by partition in background: a replica is added to each data partition after an
+// increase and recovered like a decommissioned one, and the last replica of each data partition is removed
+// after a decrease. No more data partitions are converted while maxMigrations data partitions of the cluster
+// are being recovered. The progress is kept in the memory of the master leader only, a conversion broken
+// by a leader change is resumed by changing the replica number to the same value again.
+func (c *Cluster) changeReplicaNum(name, authKey string, replicaNum uint8, maxMigrations int) (err error) {
+	var vol *Vol
+	if vol, err = c.getVol(name); err != nil {
+		return
+	}
+	if !matchKey(vol.Owner, authKey) {
+		return proto.ErrVolAuthKeyNotMatch
+	}
+	if vol.cache {
+		return fmt.Errorf("the replica number of the cache vol[%v] can't be changed", name)
+	}
+	if err = vol.checkReplicaNumChange(); err != nil {
+		return
+	}
+	vol.Lock()
+	oldReplicaNum := vol.dpReplicaNum
+	vol.dpReplicaNum = replicaNum
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.dpReplicaNum = oldReplicaNum
+		vol.Unlock()
+		return proto.ErrPersistenceByRaft
+	}
+	vol.replicaNumProgress = &proto.VolReplicaNumProgress{
+		Name:          vol.Name,
+		OldReplicaNum: oldReplicaNum,
+		NewReplicaNum: replicaNum,
+		MaxMigrations: maxMigrations,
+		Status:        volCapacityRunning,
+		StartTime:     time.Now().Format(proto.TimeFormat),
+	}
+	vol.Unlock()
+	go func() {
+		err := c.convertDataPartitionsReplicaNum(vol, replicaNum, maxMigrations)
+		vol.updateReplicaNumProgress(func(progress *proto.VolReplicaNumProgress) {
+			progress.Status = volCapacityFinished
+			progress.EndTime = time.Now().Format(proto.TimeFormat)
+			if err != nil {
+				progress.Msg = err.Error()
+			}
+		})
+		log.LogWarnf("action[changeReplicaNum] vol[%v] replicaNum from %v to %v finished, err[%v]",
+			vol.Name, oldReplicaNum, replicaNum, err)
+	}()
+	return
+}
+
+func (c *Cluster) convertDataPartitionsReplicaNum(vol *Vol, replicaNum uint8, maxMigrations int) (err error) {
+	var partitions []*DataPartition
+	for _, dp := range vol.cloneDataPartitionMap() {
+		dp.RLock()
+		if dp.ReplicaNum != replicaNum || len(dp.Hosts) != int(replicaNum) {
+			partitions = append(partitions, dp)
+		}
+		dp.RUnlock()
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].PartitionID < partitions[j].PartitionID
+	})
+	vol.updateReplicaNumProgress(func(progress *proto.VolReplicaNumProgress) {
+		progress.Total = len(partitions)
+	})
+	for _, dp := range partitions {
+		for c.countRecoveringDataPartitions() >= maxMigrations {
+			if err = c.checkReplicaNumChangeAlive(vol); err != nil {
+				return
+			}
+			time.Sleep(defaultIntervalToWaitRecovery)
+		}
+		if err = c.checkReplicaNumChangeAlive(vol); err != nil {
+			return
+		}
+		if e := c.convertDataPartitionReplicaNum(vol, dp, replicaNum); e != nil {
+			log.LogErrorf("action[convertDataPartitionsReplicaNum] vol[%v] dp[%v] replicaNum[%v] err[%v]",
+				vol.Name, dp.PartitionID, replicaNum, e)
+			vol.updateReplicaNumProgress(func(progress *proto.VolReplicaNumProgress) {
+				progress.Failed++
+				progress.Msg = fmt.Sprintf("dp[%v]: %v", dp.PartitionID, e)
+			})
+			continue
+		}
+		vol.updateReplicaNumProgress(func(progress *proto.VolReplicaNumProgress) {
+			progress.Done++
+		})
+	}
+	return
+}
+
+// checkReplicaNumChangeAlive stops the conversion once the master is not the leader or the volume is deleted.
+func (c *Cluster) checkReplicaNumChangeAlive(vol *Vol) (err error) {
+	if c.partition != nil && !c.partition.IsRaftLeader() {
+		return fmt.Errorf("the master is not the leader any more")
+	}
+	if vol.Status == markDelete {
+		return fmt.Errorf("vol[%v] is deleted", vol.Name)
+	}
+	return
+}
+
+func (c *Cluster) convertDataPartitionReplicaNum(vol *Vol, dp *DataPartition, replicaNum uint8) (err error) {
+	dp.RLock()
+	hosts := make([]string, len(dp.Hosts))
+	copy(hosts, dp.Hosts)
+	isRecover := dp.isRecover
+	dp.RUnlock()
+	if isRecover {
+		return fmt.Errorf("data partition is recovering")
+	}
+	for len(hosts) < int(replicaNum) {
+		var targetHosts []string
+		if targetHosts, _, err = c.chooseTargetDataNodes("", nil, hosts, 1, 1, vol.zoneName); err != nil {
+			return
+		}
+		if err = c.addDataReplica(dp, targetHosts[0]); err != nil {
+			return
+		}
+		dp.Lock()
+		dp.Status = proto.ReadOnly
+		dp.isRecover = true
+		dp.Unlock()
+		c.putBadDataPartitionIDs(nil, targetHosts[0], dp.PartitionID)
+		hosts = append(hosts, targetHosts[0])
+	}
+	for len(hosts) > int(replicaNum) {
+		host := hosts[len(hosts)-1]
+		if err = c.removeDataReplica(dp, host, false); err != nil {
+			return
+		}
+		hosts = hosts[:len(hosts)-1]
+	}
+	dp.Lock()
+	defer dp.Unlock()
+	oldReplicaNum := dp.ReplicaNum
+	dp.ReplicaNum = replicaNum
+	if err = c.syncUpdateDataPartition(dp); err != nil {
+		dp.ReplicaNum = oldReplicaNum
+		return
+	}
+	log.LogInfof("action[convertDataPartitionReplicaNum] vol[%v] dp[%v] replicaNum from %v to %v, hosts%v",
+		vol.Name, dp.PartitionID, oldReplicaNum, replicaNum, dp.Hosts)
+	return
+}
+
+func (m *Server) setVolReplicaNum(w http.ResponseWriter, r *http.Request) {
+	var (
+		name          string
+		authKey       string
+		replicaNum    int
+		maxMigrations = defaultRebalanceMaxMigrations
+		err           error
+	)
+	if name, authKey, err = parseVolNameAndAuthKey(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if replicaNum, err = strconv.Atoi(r.FormValue(replicaNumKey)); err != nil || !(replicaNum == 2 || replicaNum == 3) {
+		err = fmt.Errorf("replicaNum can only be 2 and 3,received replicaNum is[%v]", r.FormValue(replicaNumKey))
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if value := r.FormValue(maxMigrationsKey); value != "" {
+		if maxMigrations, err = strconv.Atoi(value); err != nil || maxMigrations <= 0 {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(maxMigrationsKey).Error()})
+			return
+		}
+	}
+	if err = m.cluster.changeReplicaNum(name, authKey, uint8(replicaNum), maxMigrations); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf("change replicaNum of vol[%v] to [%v] successfully,from[%v]", name, replicaNum, r.RemoteAddr)
+	log.LogWarn(msg)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) getVolReplicaNumProgress(w http.ResponseWriter, r *http.Request) {
+	var (
+		name     string
+		err      error
+		vol      *Vol
+		progress *proto.VolReplicaNumProgress
+	)
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	if progress = vol.getReplicaNumProgress(); progress == nil {
+		err = fmt.Errorf("no replica number change of vol[%v] since the master leader started", name)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(progress))
+}
@@ -1,7 +1,14 @@
 package master
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strings"
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util"
 	"github.com/chubaofs/chubaofs/util/log"
@@ -240,3 +247,447 @@ func TestConcurrentReadWriteDataPartitionMap(t *testing.T) {
 		vol.updateViewCache(server.cluster)
 	}
 }
+
+func TestVolCaseInsensitive(t *testing.T) {
+	vol, err := server.cluster.getVol(commonVolName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	reqURL := fmt.Sprintf("%v%v?name=%v&capacity=10000&authKey=%v&caseInsensitive=true",
+		hostAddr, proto.AdminUpdateVol, commonVolName, buildAuthKey(vol.Owner))
+	process(reqURL, t)
+	if !vol.caseInsensitive {
+		t.Errorf("expect vol[%v] case-insensitive", commonVolName)
+		return
+	}
+	vol.updateViewCache(server.cluster)
+	view := &proto.VolView{}
+	if err = json.Unmarshal(vol.getViewCache(), &proto.HTTPReply{Data: view}); err != nil {
+		t.Error(err)
+		return
+	}
+	if !view.CaseInsensitive {
+		t.Errorf("expect the view of vol[%v] case-insensitive", commonVolName)
+	}
+	reqURL = fmt.Sprintf("%v%v?name=%v&capacity=10000&authKey=%v&caseInsensitive=false",
+		hostAddr, proto.AdminUpdateVol, commonVolName, buildAuthKey(vol.Owner))
+	process(reqURL, t)
+	if vol.caseInsensitive {
+		t.Errorf("expect vol[%v] case-sensitive", commonVolName)
+	}
+}
+
+func TestVolSmallFileSize(t *testing.T) {
+	vol, err := server.cluster.getVol(commonVolName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if vol.smallFileSize != util.DefaultTinySizeLimit {
+		t.Errorf("expect smallFileSize of vol[%v] %v, but is %v", commonVolName, util.DefaultTinySizeLimit, vol.smallFileSize)
+		return
+	}
+	reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v&smallFileSize=0",
+		hostAddr, proto.AdminUpdateVol, commonVolName, buildAuthKey(vol.Owner))
+	process(reqURL, t)
+	if vol.smallFileSize != 0 {
+		t.Errorf("expect packing of vol[%v] disabled", commonVolName)
+		return
+	}
+	if vv := newVolFromVolValue(newVolValue(vol)); vv.smallFileSize != 0 {
+		t.Errorf("expect packing of vol[%v] disabled after reload, but smallFileSize is %v", commonVolName, vv.smallFileSize)
+	}
+	reqURL = fmt.Sprintf("%v%v?name=%v&authKey=%v&smallFileSize=%v",
+		hostAddr, proto.AdminUpdateVol, commonVolName, buildAuthKey(vol.Owner), util.DefaultTinySizeLimit+1)
+	r, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err = parseSmallFileSizeToUpdateVol(r, vol); err == nil {
+		t.Errorf("expect smallFileSize larger than %v rejected", util.DefaultTinySizeLimit)
+	}
+	reqURL = fmt.Sprintf("%v%v?name=%v&authKey=%v&smallFileSize=%v",
+		hostAddr, proto.AdminUpdateVol, commonVolName, buildAuthKey(vol.Owner), util.DefaultTinySizeLimit)
+	process(reqURL, t)
+	if vol.smallFileSize != util.DefaultTinySizeLimit {
+		t.Errorf("expect smallFileSize of vol[%v] %v, but is %v", commonVolName, util.DefaultTinySizeLimit, vol.smallFileSize)
+	}
+}
+
+func TestVolPermissionPriority(t *testing.T) {
+	vol, err := server.cluster.getVol(commonVolName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v&permissionPriority=%v",
+		hostAddr, proto.AdminUpdateVol, commonVolName, buildAuthKey(vol.Owner), proto.PermissionPriorityS3)
+	process(reqURL, t)
+	if vol.permissionPriority != proto.PermissionPriorityS3 {
+		t.Errorf("expect permission priority of vol[%v] %v, but is %v", commonVolName, proto.PermissionPriorityS3, vol.permissionPriority)
+		return
+	}
+	if vv := newVolFromVolValue(newVolValue(vol)); vv.permissionPriority != proto.PermissionPriorityS3 {
+		t.Errorf("expect permission priority of vol[%v] persisted, but is %v", commonVolName, vv.permissionPriority)
+	}
+	reqURL = fmt.Sprintf("%v%v?name=%v&authKey=%v&permissionPriority=unknown",
+		hostAddr, proto.AdminUpdateVol, commonVolName, buildAuthKey(vol.Owner))
+	r, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err = parsePermissionPriorityToUpdateVol(r, vol); err == nil {
+		t.Errorf("expect unknown permission priority rejected")
+	}
+	reqURL = fmt.Sprintf("%v%v?name=%v&authKey=%v&permissionPriority=none",
+		hostAddr, proto.AdminUpdateVol, commonVolName, buildAuthKey(vol.Owner))
+	process(reqURL, t)
+	if vol.permissionPriority != proto.PermissionPriorityNone {
+		t.Errorf("expect permission priority of vol[%v] reset, but is %v", commonVolName, vol.permissionPriority)
+	}
+}
+
+func TestVolTiering(t *testing.T) {
+	vol, err := server.cluster.getVol(commonVolName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	reqURL := fmt.Sprintf("%v%v?name=%v&capacity=10000&authKey=%v&hotMedia=%v&coldMedia=%v&coldDays=1",
+		hostAddr, proto.AdminUpdateVol, commonVolName, buildAuthKey(vol.Owner), proto.MediaTypeSSD, proto.MediaTypeHDD)
+	process(reqURL, t)
+	if _, _, coldDays, ok := vol.tiering(); !ok || coldDays != 1 {
+		t.Errorf("expect tiering of vol[%v] set", commonVolName)
+		return
+	}
+	var dp *DataPartition
+	for _, partition := range vol.cloneDataPartitionMap() {
+		dp = partition
+		break
+	}
+	if dp == nil {
+		t.Errorf("no data partition in vol[%v]", commonVolName)
+		return
+	}
+	server.cluster.updateDataPartitionTier(dp, proto.MediaTypeSSD, proto.MediaTypeHDD, 1)
+	if dp.mediaType != proto.MediaTypeSSD {
+		t.Errorf("expect dp[%v] on %v, but is %v", dp.PartitionID, proto.MediaTypeSSD, dp.mediaType)
+	}
+	// not accessed for 2 days
+	dp.lastAccessTime = time.Now().Unix() - 2*secondsPerDay
+	dp.tierTime = dp.lastAccessTime
+	server.cluster.updateDataPartitionTier(dp, proto.MediaTypeSSD, proto.MediaTypeHDD, 1)
+	if dp.mediaType != proto.MediaTypeHDD {
+		t.Errorf("expect dp[%v] demoted to %v, but is %v", dp.PartitionID, proto.MediaTypeHDD, dp.mediaType)
+	}
+	dp.lastAccessTime = time.Now().Unix() + 1
+	server.cluster.updateDataPartitionTier(dp, proto.MediaTypeSSD, proto.MediaTypeHDD, 1)
+	if dp.mediaType != proto.MediaTypeSSD {
+		t.Errorf("expect dp[%v] promoted to %v, but is %v", dp.PartitionID, proto.MediaTypeSSD, dp.mediaType)
+	}
+	reqURL = fmt.Sprintf("%v%v?name=%v&authKey=%v&hotMedia=&coldMedia=",
+		hostAddr, proto.AdminUpdateVol, commonVolName, buildAuthKey(vol.Owner))
+	process(reqURL, t)
+	if _, _, _, ok := vol.tiering(); ok {
+		t.Errorf("expect tiering of vol[%v] unset", commonVolName)
+	}
+}
+
+func TestVolEncryptionKey(t *testing.T) {
+	keyFile := path.Join(os.TempDir(), "master_encryption_keys.json")
+	writeKeys := func(active string) {
+		data := fmt.Sprintf(`{"activeKey":"%v","keys":{"1":"%v","2":"%v"}}`,
+			active, strings.Repeat("01", dataKeySize), strings.Repeat("02", dataKeySize))
+		if err := ioutil.WriteFile(keyFile, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer os.Remove(keyFile)
+	writeKeys("1")
+	km, err := newLocalKeyManager(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.cluster.keyManager = km
+	defer func() { server.cluster.keyManager = nil }()
+	vol, err := server.cluster.getVol(commonVolName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = server.cluster.generateVolDataKey(vol); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		vol.dataKeyID, vol.wrappedDataKey = "", nil
+		server.cluster.syncUpdateVol(vol)
+	}()
+	dataKey, err := server.cluster.getVolDataKey(vol)
+	if err != nil || len(dataKey) != dataKeySize {
+		t.Fatalf("get data key of vol[%v] err[%v] len[%v]", commonVolName, err, len(dataKey))
+	}
+	// a wrapped data key can not be unwrapped for another volume
+	if _, err = km.unwrap(vol.dataKeyID, "otherVol", vol.wrappedDataKey); err == nil {
+		t.Errorf("expect unwrap data key of vol[%v] as another vol failed", commonVolName)
+	}
+	writeKeys("2")
+	if server.cluster.keyManager, err = newLocalKeyManager(keyFile); err != nil {
+		t.Fatal(err)
+	}
+	reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v", hostAddr, proto.AdminRotateVolKey, commonVolName, buildAuthKey(vol.Owner))
+	process(reqURL, t)
+	if vol.dataKeyID != "2" {
+		t.Errorf("expect data key of vol[%v] wrapped by master key[2], but is [%v]", commonVolName, vol.dataKeyID)
+	}
+	rotatedKey, err := server.cluster.getVolDataKey(vol)
+	if err != nil || !bytes.Equal(rotatedKey, dataKey) {
+		t.Errorf("expect data key of vol[%v] unchanged after rotation, err[%v]", commonVolName, err)
+	}
+}
+
+func TestVolFlatPersisted(t *testing.T) {
+	vol := newVol(1, "flatVol", "cfs", "", util.DefaultDataPartitionSize, 100, defaultReplicaNum,
+		defaultReplicaNum, false, false, false, false, 0, "")
+	vol.flat = true
+	raw, err := newVolValue(vol).Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vv, err := newVolValueFromBytes(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !newVolFromVolValue(vv).flat {
+		t.Errorf("expect flat namespace of vol[%v] persisted", vol.Name)
+	}
+}
+
+func TestVolReadOnly(t *testing.T) {
+	vol, err := server.cluster.getVol(commonVolName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setReadOnly := func(readOnly bool) {
+		reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v&enable=%v", hostAddr, proto.AdminVolReadOnly, commonVolName,
+			buildAuthKey(vol.Owner), readOnly)
+		process(reqURL, t)
+	}
+
+	setReadOnly(true)
+	if !vol.readOnly {
+		t.Fatalf("expect vol[%v] read only", commonVolName)
+	}
+	if names := server.cluster.readOnlyVols(); len(names) != 1 || names[0] != commonVolName {
+		t.Errorf("expect read only vols [%v] but got %v", commonVolName, names)
+	}
+	view := &proto.ClientSessionView{Volume: commonVolName, Capabilities: proto.ClientCapRead | proto.ClientCapWrite}
+	if server.cluster.grantCapabilities(view); view.Capabilities != proto.ClientCapRead {
+		t.Errorf("expect the write capability withdrawn but got capabilities(%v)", view.Capabilities)
+	}
+	raw, err := newVolValue(vol).Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vv, err := newVolValueFromBytes(raw)
+	if err != nil || !newVolFromVolValue(vv).readOnly {
+		t.Errorf("expect read only of vol[%v] persisted, err[%v]", commonVolName, err)
+	}
+
+	setReadOnly(false)
+	if vol.readOnly || len(server.cluster.readOnlyVols()) != 0 {
+		t.Errorf("expect vol[%v] writable", commonVolName)
+	}
+}
+
+func TestRenameVol(t *testing.T) {
+	name, newName := "rename1", "rename2"
+	createVol(name, t)
+	vol, err := server.cluster.getVol(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID := "renameuser"
+	if _, err = server.user.createKey(&proto.UserCreateParam{ID: userID, Type: proto.UserTypeNormal}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = server.user.updatePolicy(&proto.UserPermUpdateParam{UserID: userID, Volume: name,
+		Policy: []string{proto.BuiltinPermissionReadOnly.String()}}); err != nil {
+		t.Fatal(err)
+	}
+	reqURL := fmt.Sprintf("%v%v?name=%v&newName=%v&authKey=%v", hostAddr, proto.AdminRenameVol, name, newName,
+		buildAuthKey(vol.Owner))
+	process(reqURL, t)
+	if vol.Name != newName {
+		t.Fatalf("expect vol[%v] renamed to [%v] but got [%v]", name, newName, vol.Name)
+	}
+	if userIDs, err := server.user.getUsersOfVol(newName); err != nil || !contains(userIDs, userID) {
+		t.Errorf("expect the policy of user[%v] renamed to vol[%v] but got %v, err[%v]", userID, newName, userIDs, err)
+	}
+	for _, volName := range []string{name, newName} {
+		if v, err := server.cluster.getVol(volName); err != nil || v != vol {
+			t.Errorf("expect vol[%v] found by [%v], err[%v]", newName, volName, err)
+		}
+	}
+	if renamed := server.cluster.renamedVols(); renamed[name] != newName {
+		t.Errorf("expect renamed vols %v contains [%v]->[%v]", renamed, name, newName)
+	}
+	for _, mp := range vol.cloneMetaPartitionMap() {
+		if mp.volName != newName {
+			t.Errorf("expect mp[%v] of vol[%v] but got [%v]", mp.PartitionID, newName, mp.volName)
+		}
+	}
+	for _, dp := range vol.cloneDataPartitionMap() {
+		if dp.VolName != newName {
+			t.Errorf("expect dp[%v] of vol[%v] but got [%v]", dp.PartitionID, newName, dp.VolName)
+		}
+	}
+	raw, err := newVolValue(vol).Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vv, err := newVolValueFromBytes(raw)
+	if err != nil || len(vv.Aliases) != 1 || vv.Aliases[0] != name {
+		t.Errorf("expect aliases [%v] of vol[%v] persisted but got %v, err[%v]", name, newName, vv, err)
+	}
+	if _, err = server.cluster.renameVol(newName, commonVolName, buildAuthKey(vol.Owner)); err != proto.ErrDuplicateVol {
+		t.Errorf("expect renaming vol[%v] to [%v] fails with [%v] but got [%v]", newName, commonVolName, proto.ErrDuplicateVol, err)
+	}
+
+	markDeleteVol(newName, t)
+	vol.deleteVolFromStore(server.cluster)
+	if _, err = server.cluster.getVol(name); err != proto.ErrVolNotExists {
+		t.Errorf("expect alias [%v] released with vol[%v] but got err[%v]", name, newName, err)
+	}
+}
+
+func TestVolReplicaNum(t *testing.T) {
+	name := "replicaNum"
+	createVol(name, t)
+	//report the leaders of the data partitions to master
+	server.cluster.checkDataNodeHeartbeat()
+	time.Sleep(5 * time.Second)
+	vol, err := server.cluster.getVol(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changeReplicaNum := func(replicaNum int) {
+		reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v&replicaNum=%v&maxMigrations=1000", hostAddr, proto.AdminVolReplicaNum,
+			name, buildAuthKey(vol.Owner), replicaNum)
+		process(reqURL, t)
+		for i := 0; i < 30 && vol.checkReplicaNumChange() != nil; i++ {
+			time.Sleep(time.Second)
+		}
+		progress := vol.getReplicaNumProgress()
+		if progress == nil || progress.Status != volCapacityFinished || progress.Failed != 0 || progress.Done != progress.Total {
+			t.Fatalf("expect the replicaNum change of vol[%v] to %v finished but got %+v", name, replicaNum, progress)
+		}
+		if vol.dpReplicaNum != uint8(replicaNum) {
+			t.Errorf("expect replicaNum of vol[%v] %v but got %v", name, replicaNum, vol.dpReplicaNum)
+		}
+		for _, dp := range vol.cloneDataPartitionMap() {
+			if dp.ReplicaNum != uint8(replicaNum) || len(dp.Hosts) != replicaNum {
+				t.Errorf("expect dp[%v] with %v replicas but got replicaNum[%v] hosts%v", dp.PartitionID, replicaNum,
+					dp.ReplicaNum, dp.Hosts)
+			}
+		}
+	}
+	changeReplicaNum(2)
+	raw, err := newVolValue(vol).Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vv, err := newVolValueFromBytes(raw); err != nil || newVolFromVolValue(vv).dpReplicaNum != 2 {
+		t.Errorf("expect replicaNum 2 of vol[%v] persisted, err[%v]", name, err)
+	}
+	changeReplicaNum(3)
+	for _, dp := range vol.cloneDataPartitionMap() {
+		dp.isRecover = false
+	}
+	server.cluster.BadDataPartitionIds.Range(func(key, value interface{}) bool {
+		server.cluster.BadDataPartitionIds.Delete(key)
+		return true
+	})
+
+	markDeleteVol(name, t)
+	vol.deleteVolFromStore(server.cluster)
+}
+
+func TestCacheVol(t *testing.T) {
+	name := "cacheVol"
+	reqURL := fmt.Sprintf("%v%v?name=%v&cache=true&capacity=100&owner=cfs&mpCount=2&zoneName=%v", hostAddr, proto.AdminCreateVol, name, testZone2)
+	process(reqURL, t)
+	vol, err := server.cluster.getVol(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !vol.cache || vol.dpReplicaNum != 1 || vol.mpReplicaNum != defaultReplicaNum {
+		t.Errorf("expect cache vol[%v] with 1 data replica and %v meta replicas but got cache[%v] dpReplicaNum[%v] mpReplicaNum[%v]",
+			name, defaultReplicaNum, vol.cache, vol.dpReplicaNum, vol.mpReplicaNum)
+	}
+	if len(vol.cloneDataPartitionMap()) == 0 {
+		t.Errorf("expect data partitions of cache vol[%v] created", name)
+	}
+	for _, dp := range vol.cloneDataPartitionMap() {
+		if dp.ReplicaNum != 1 || len(dp.Hosts) != 1 {
+			t.Errorf("expect dp[%v] with 1 replica but got replicaNum[%v] hosts%v", dp.PartitionID, dp.ReplicaNum, dp.Hosts)
+		}
+	}
+	raw, err := newVolValue(vol).Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vv, err := newVolValueFromBytes(raw); err != nil || !newVolFromVolValue(vv).cache || newVolFromVolValue(vv).dpReplicaNum != 1 {
+		t.Errorf("expect cache of vol[%v] persisted, err[%v]", name, err)
+	}
+	if err = server.cluster.changeReplicaNum(name, buildAuthKey(vol.Owner), 2, defaultRebalanceMaxMigrations); err == nil {
+		t.Errorf("expect the replica number of the cache vol[%v] unchangeable", name)
+	}
+
+	markDeleteVol(name, t)
+	vol.deleteVolFromStore(server.cluster)
+}
+
+func TestRestoreVol(t *testing.T) {
+	name := "restoreVol"
+	createVol(name, t)
+	vol, err := server.cluster.getVol(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	markDeleteVol(name, t)
+	if vol.isPurging() || vol.getPurgeTime() <= time.Now().Unix() {
+		t.Errorf("expect vol[%v] kept until the deletion delay, purgeTime[%v]", name, vol.getPurgeTime())
+	}
+	vol.checkStatus(server.cluster)
+	if _, err = server.cluster.getVol(name); err != nil {
+		t.Fatalf("expect vol[%v] not purged before the purge time, err[%v]", name, err)
+	}
+	reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v", hostAddr, proto.AdminRestoreVol, name, buildAuthKey("cfs"))
+	process(reqURL, t)
+	if vol.status() != normal || vol.getPurgeTime() != 0 {
+		t.Errorf("expect vol[%v] restored, status[%v] purgeTime[%v]", name, vol.status(), vol.getPurgeTime())
+	}
+	userInfo, err := server.user.getUserInfo("cfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contains(userInfo.Policy.OwnVols, name) {
+		t.Errorf("expect vol[%v] owned by cfs again after the restore", name)
+	}
+	if _, err = server.cluster.restoreVol(name, buildAuthKey("cfs")); err == nil {
+		t.Errorf("expect the restore of the normal vol[%v] failed", name)
+	}
+
+	reqURL = fmt.Sprintf("%v%v?name=%v&authKey=%v&force=true", hostAddr, proto.AdminDeleteVol, name, buildAuthKey("cfs"))
+	process(reqURL, t)
+	if !vol.isPurging() {
+		t.Errorf("expect vol[%v] purged at once with force", name)
+	}
+	if _, err = server.cluster.restoreVol(name, buildAuthKey("cfs")); err == nil {
+		t.Errorf("expect the restore of the purging vol[%v] failed", name)
+	}
+	vol.checkStatus(server.cluster)
+	vol.deleteVolFromStore(server.cluster)
+}
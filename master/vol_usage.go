@@ -0,0 +1,58 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"net/http"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func (m *Server) getVolUsage(w http.ResponseWriter, r *http.Request) {
+	var (
+		name string
+		err  error
+		vol  *Vol
+	)
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(vol.getUsage()))
+}
+
+// getUsage sums up the usage of the volume reported by the meta partition leaders in the heartbeats,
+// which is the usage of the root directory without walking the directory tree.
+func (vol *Vol) getUsage() (usage *proto.VolUsage) {
+	usage = &proto.VolUsage{Name: vol.Name}
+	for _, mp := range vol.cloneMetaPartitionMap() {
+		mp.RLock()
+		usage.MetaPartitions++
+		usage.InodeCount += mp.InodeCount
+		usage.DentryCount += mp.DentryCount
+		if mp.usage != nil {
+			usage.ReportedPartitions++
+			usage.Files += mp.usage.Files
+			usage.Dirs += mp.usage.Dirs
+			usage.Bytes += mp.usage.Bytes
+		}
+		mp.RUnlock()
+	}
+	return
+}
@@ -0,0 +1,211 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	partitionTypeData = "data"
+	partitionTypeMeta = "meta"
+
+	defaultZonePlacementRepairCount = 10
+)
+
+// getZonePlacementViolations returns the partitions of the cross zone volumes with all the replicas in one zone,
+// which happens when the partitions are created or decommissioned while only one zone is writable.
+func (c *Cluster) getZonePlacementViolations(volName string) (violations []*proto.ZonePlacementViolation, err error) {
+	var vols = make(map[string]*Vol)
+	if volName != "" {
+		var vol *Vol
+		if vol, err = c.getVol(volName); err != nil {
+			return
+		}
+		vols[volName] = vol
+	} else {
+		vols = c.copyVols()
+	}
+	violations = make([]*proto.ZonePlacementViolation, 0)
+	if c.t.zoneLen() < 2 {
+		return
+	}
+	for _, vol := range vols {
+		if !vol.crossZone || vol.status() == markDelete {
+			continue
+		}
+		for _, dp := range vol.cloneDataPartitionMap() {
+			dp.RLock()
+			hosts := append([]string(nil), dp.Hosts...)
+			dp.RUnlock()
+			if violation := c.checkZonePlacement(vol.Name, dp.PartitionID, partitionTypeData, hosts); violation != nil {
+				violations = append(violations, violation)
+			}
+		}
+		for _, mp := range vol.cloneMetaPartitionMap() {
+			mp.RLock()
+			hosts := append([]string(nil), mp.Hosts...)
+			mp.RUnlock()
+			if violation := c.checkZonePlacement(vol.Name, mp.PartitionID, partitionTypeMeta, hosts); violation != nil {
+				violations = append(violations, violation)
+			}
+		}
+	}
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].VolName != violations[j].VolName {
+			return violations[i].VolName < violations[j].VolName
+		}
+		if violations[i].PartitionType != violations[j].PartitionType {
+			return violations[i].PartitionType < violations[j].PartitionType
+		}
+		return violations[i].PartitionID < violations[j].PartitionID
+	})
+	return
+}
+
+func (c *Cluster) checkZonePlacement(volName string, partitionID uint64, partitionType string, hosts []string) *proto.ZonePlacementViolation {
+	if len(hosts) < 2 {
+		return nil
+	}
+	var zones = make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		zones = append(zones, c.getHostZone(partitionType, host))
+	}
+	for _, zone := range zones {
+		if zone != zones[0] {
+			return nil
+		}
+	}
+	return &proto.ZonePlacementViolation{
+		VolName:       volName,
+		PartitionID:   partitionID,
+		PartitionType: partitionType,
+		Hosts:         hosts,
+		Zones:         zones,
+	}
+}
+
+func (c *Cluster) getHostZone(partitionType, host string) string {
+	if partitionType == partitionTypeData {
+		if dataNode, err := c.dataNode(host); err == nil {
+			return dataNode.ZoneName
+		}
+	} else {
+		if metaNode, err := c.metaNode(host); err == nil {
+			return metaNode.ZoneName
+		}
+	}
+	return ""
+}
+
+// repairZonePlacement moves one replica of each of the violating partitions of the volume to another zone,
+// at most count partitions are repaired at a time since the moved replicas have to be recovered.
+func (c *Cluster) repairZonePlacement(volName string, count int) (repaired []*proto.ZonePlacementViolation, err error) {
+	var violations []*proto.ZonePlacementViolation
+	if violations, err = c.getZonePlacementViolations(volName); err != nil {
+		return
+	}
+	if len(violations) > count {
+		violations = violations[:count]
+	}
+	for _, violation := range violations {
+		var vol *Vol
+		if vol, err = c.getVol(violation.VolName); err != nil {
+			return
+		}
+		if violation.PartitionType == partitionTypeData {
+			err = c.repairDataPartitionZone(vol, violation)
+		} else {
+			err = c.repairMetaPartitionZone(vol, violation)
+		}
+		if err != nil {
+			violation.Msg = err.Error()
+			log.LogErrorf("action[repairZonePlacement] vol[%v] %v partition[%v] err[%v]",
+				violation.VolName, violation.PartitionType, violation.PartitionID, err)
+		}
+	}
+	return violations, nil
+}
+
+func (c *Cluster) repairDataPartitionZone(vol *Vol, violation *proto.ZonePlacementViolation) (err error) {
+	var (
+		dp          *DataPartition
+		targetHosts []string
+	)
+	if dp, err = vol.getDataPartitionByID(violation.PartitionID); err != nil {
+		return
+	}
+	// move a follower, so the partition keeps serving with the leader
+	dp.RLock()
+	offlineAddr := dp.Hosts[len(dp.Hosts)-1]
+	for _, r := range dp.Replicas {
+		if !r.IsLeader && dp.hasHost(r.Addr) {
+			offlineAddr = r.Addr
+			break
+		}
+	}
+	hosts := append([]string(nil), dp.Hosts...)
+	dp.RUnlock()
+	if err = c.validateDecommissionDataPartition(dp, offlineAddr); err != nil {
+		return
+	}
+	if targetHosts, _, err = c.chooseTargetDataNodes(violation.Zones[0], nil, hosts, 1, 1, ""); err != nil {
+		return
+	}
+	if err = c.moveDataReplica(dp, offlineAddr, targetHosts[0]); err != nil {
+		return
+	}
+	violation.NewHost = targetHosts[0]
+	Warn(c.Name, fmt.Sprintf("action[repairDataPartitionZone] clusterID[%v] vol[%v] data partition[%v] "+
+		"moved addr[%v] in zone[%v] to addr[%v]", c.Name, vol.Name, dp.PartitionID, offlineAddr, violation.Zones[0], targetHosts[0]))
+	return
+}
+
+func (c *Cluster) repairMetaPartitionZone(vol *Vol, violation *proto.ZonePlacementViolation) (err error) {
+	var (
+		mp       *MetaPartition
+		newHosts []string
+	)
+	if mp, err = vol.metaPartition(violation.PartitionID); err != nil {
+		return
+	}
+	mp.RLock()
+	offlineAddr := mp.Hosts[len(mp.Hosts)-1]
+	for _, mr := range mp.Replicas {
+		if !mr.IsLeader && contains(mp.Hosts, mr.Addr) {
+			offlineAddr = mr.Addr
+			break
+		}
+	}
+	hosts := append([]string(nil), mp.Hosts...)
+	mp.RUnlock()
+	if err = c.validateDecommissionMetaPartition(mp, offlineAddr); err != nil {
+		return
+	}
+	if newHosts, _, err = c.chooseTargetMetaHosts(violation.Zones[0], nil, hosts, 1, false, ""); err != nil {
+		return
+	}
+	if err = c.moveMetaReplica(mp, offlineAddr, newHosts[0]); err != nil {
+		return
+	}
+	violation.NewHost = newHosts[0]
+	Warn(c.Name, fmt.Sprintf("action[repairMetaPartitionZone] clusterID[%v] vol[%v] meta partition[%v] "+
+		"moved addr[%v] in zone[%v] to addr[%v]", c.Name, vol.Name, mp.PartitionID, offlineAddr, violation.Zones[0], newHosts[0]))
+	return
+}
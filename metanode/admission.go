@@ -0,0 +1,236 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"net"
+	"runtime"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/chubaofs/chubaofs/cmd/common"
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/exporter"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The classes of the requests in the admission control.
+const (
+	admitForeground = iota // the requests of the clients
+	admitBackground        // the directory walks, the checks and the deletions, which are shed first
+	admitControl           // the requests of the master, which are never shed
+)
+
+const (
+	defaultMaxForegroundRequests = 4096
+	defaultMaxBackgroundRequests = 64
+	defaultOverloadCPURatio      = 0.9
+	defaultOverloadSubmitLatency = time.Second
+
+	// the foreground requests wait for the slots in the queue at most for this long
+	admitQueueTimeout = 100 * time.Millisecond
+	// the background work is delayed at most for this long each time while the meta node is overloaded
+	maxBackgroundDelay    = 10 * time.Second
+	backgroundDelayStep   = 100 * time.Millisecond
+	overloadCheckInterval = time.Second
+
+	MetricAdmissionShed      = "admission_shed"
+	MetricAdmissionOverload  = "admission_overload"
+	MetricAdmissionCPURatio  = "admission_cpu_ratio"
+	MetricAdmissionSubmitLat = "admission_submit_latency_ms"
+)
+
+// The message of the busy replies, the clients retry the requests replied with OpAgain.
+const admitBusyMsg = "meta node is busy"
+
+// admission limits the requests being served by their classes, and sheds the background ones
+// once the meta node is overloaded, which is detected by the CPU usage of the process and
+// the latency of the raft submits, i.e. the stall of the writes.
+type admission struct {
+	slots         [admitControl]chan struct{}
+	cpuRatio      float64
+	submitLatency time.Duration
+
+	overloaded int32
+	submitNs   int64 // the moving average of the latency of the raft submits
+	lastCPU    time.Duration
+	lastCheck  time.Time
+}
+
+// AdmissionConfig defines the limits of the admission control, the defaults are used for the zero values.
+type AdmissionConfig struct {
+	MaxForegroundRequests int
+	MaxBackgroundRequests int
+	OverloadCPURatio      float64
+	OverloadSubmitLatency time.Duration
+}
+
+func newAdmission(conf AdmissionConfig) *admission {
+	if conf.MaxForegroundRequests <= 0 {
+		conf.MaxForegroundRequests = defaultMaxForegroundRequests
+	}
+	if conf.MaxBackgroundRequests <= 0 {
+		conf.MaxBackgroundRequests = defaultMaxBackgroundRequests
+	}
+	if conf.OverloadCPURatio <= 0 {
+		conf.OverloadCPURatio = defaultOverloadCPURatio
+	}
+	if conf.OverloadSubmitLatency <= 0 {
+		conf.OverloadSubmitLatency = defaultOverloadSubmitLatency
+	}
+	a := &admission{
+		cpuRatio:      conf.OverloadCPURatio,
+		submitLatency: conf.OverloadSubmitLatency,
+		lastCPU:       processCPUTime(),
+		lastCheck:     time.Now(),
+	}
+	a.slots[admitForeground] = make(chan struct{}, conf.MaxForegroundRequests)
+	a.slots[admitBackground] = make(chan struct{}, conf.MaxBackgroundRequests)
+	return a
+}
+
+func admitClass(opcode uint8) int {
+	switch opcode {
+	case proto.OpCreateMetaPartition, proto.OpMetaNodeHeartbeat, proto.OpDeleteMetaPartition,
+		proto.OpUpdateMetaPartition, proto.OpDecommissionMetaPartition, proto.OpAddMetaPartitionRaftMember,
+		proto.OpRemoveMetaPartitionRaftMember, proto.OpMetaPartitionTryToLeader:
+		return admitControl
+	case proto.OpMetaGetDirStat, proto.OpLoadMetaPartition, proto.OpMetaFreeInodesOnRaftFollower,
+		proto.OpVerifyMetaPartition, proto.OpMetaReadChanges:
+		return admitBackground
+	}
+	return admitForeground
+}
+
+// admit takes a slot of the class of the operation, it returns false if the request is to be shed.
+// The foreground requests wait in the queue for a while, and the background ones are shed at once.
+func (a *admission) admit(opcode uint8) (release func(), ok bool) {
+	class := admitClass(opcode)
+	if a == nil || class == admitControl {
+		return func() {}, true
+	}
+	slots := a.slots[class]
+	release = func() { <-slots }
+	if class == admitBackground {
+		if a.isOverloaded() {
+			return nil, false
+		}
+		select {
+		case slots <- struct{}{}:
+			return release, true
+		default:
+			return nil, false
+		}
+	}
+	select {
+	case slots <- struct{}{}:
+		return release, true
+	default:
+	}
+	timer := time.NewTimer(admitQueueTimeout)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return release, true
+	case <-timer.C:
+		return nil, false
+	}
+}
+
+func (a *admission) isOverloaded() bool {
+	return a != nil && atomic.LoadInt32(&a.overloaded) == 1
+}
+
+// observeSubmit records the latency of a raft submit.
+func (a *admission) observeSubmit(latency time.Duration) {
+	if a == nil {
+		return
+	}
+	// an exponential moving average weighting the latest submit by 1/8
+	for {
+		old := atomic.LoadInt64(&a.submitNs)
+		avg := old + (int64(latency)-old)/8
+		if atomic.CompareAndSwapInt64(&a.submitNs, old, avg) {
+			return
+		}
+	}
+}
+
+// delayBackground blocks the background work while the meta node is overloaded, at most for maxBackgroundDelay.
+func (a *admission) delayBackground(stopC chan bool) {
+	for waited := time.Duration(0); a.isOverloaded() && waited < maxBackgroundDelay; waited += backgroundDelayStep {
+		select {
+		case <-stopC:
+			return
+		case <-time.After(backgroundDelayStep):
+		}
+	}
+}
+
+// check updates the overload state by the CPU usage since the last check and the latency of the raft submits.
+func (a *admission) check() {
+	now, cpu := time.Now(), processCPUTime()
+	var ratio float64
+	if elapsed := now.Sub(a.lastCheck); elapsed > 0 {
+		ratio = float64(cpu-a.lastCPU) / float64(elapsed) / float64(runtime.NumCPU())
+	}
+	a.lastCPU, a.lastCheck = cpu, now
+	submit := time.Duration(atomic.LoadInt64(&a.submitNs))
+
+	var overloaded int32
+	if ratio >= a.cpuRatio || submit >= a.submitLatency {
+		overloaded = 1
+	}
+	if old := atomic.SwapInt32(&a.overloaded, overloaded); old != overloaded {
+		log.LogWarnf("admission: overloaded(%v) cpu ratio(%.2f) submit latency(%v)", overloaded == 1, ratio, submit)
+	}
+	exporter.NewGauge(MetricAdmissionOverload).Set(float64(overloaded))
+	exporter.NewGauge(MetricAdmissionCPURatio).Set(ratio)
+	exporter.NewGauge(MetricAdmissionSubmitLat).Set(float64(submit) / float64(time.Millisecond))
+}
+
+// checkOverload checks the overload of the meta node periodically until the manager stops.
+func (m *metadataManager) checkOverload() {
+	ticker := time.NewTicker(overloadCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if state := atomic.LoadUint32(&m.state); state == common.StateShutdown || state == common.StateStopped {
+			return
+		}
+		m.admission.check()
+	}
+}
+
+// shed replies the request with OpAgain, which the clients retry later.
+func (m *metadataManager) shed(conn net.Conn, p *Packet) error {
+	exporter.NewCounter(MetricAdmissionShed).AddWithLabels(1, map[string]string{"op": p.GetOpMsg()})
+	p.PacketErrorWithBody(proto.OpAgain, []byte(admitBusyMsg))
+	return m.respondToClient(conn, p)
+}
+
+func processCPUTime() time.Duration {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+}
+
+func (mp *metaPartition) delayBackground() {
+	if mp.manager != nil {
+		mp.manager.admission.delayBackground(mp.stopC)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestAdmission(t *testing.T) {
+	a := newAdmission(AdmissionConfig{MaxForegroundRequests: 1, MaxBackgroundRequests: 1})
+
+	release, ok := a.admit(proto.OpMetaLookup)
+	if !ok {
+		t.Fatalf("the first foreground request is shed")
+	}
+	start := time.Now()
+	if _, ok = a.admit(proto.OpMetaInodeGet); ok {
+		t.Fatalf("the foreground request beyond the limit is admitted")
+	}
+	if elapsed := time.Since(start); elapsed < admitQueueTimeout {
+		t.Fatalf("the foreground request is shed without waiting in the queue: %v", elapsed)
+	}
+	if _, ok = a.admit(proto.OpMetaNodeHeartbeat); !ok {
+		t.Fatalf("the control request is shed")
+	}
+	release()
+	if release, ok = a.admit(proto.OpMetaInodeGet); !ok {
+		t.Fatalf("the foreground request is shed after the slot is released")
+	}
+	release()
+
+	if release, ok = a.admit(proto.OpMetaGetDirStat); !ok {
+		t.Fatalf("the background request is shed without overload")
+	}
+	release()
+	atomic.StoreInt32(&a.overloaded, 1)
+	if _, ok = a.admit(proto.OpMetaGetDirStat); ok {
+		t.Fatalf("the background request is admitted while overloaded")
+	}
+	if release, ok = a.admit(proto.OpMetaLookup); !ok {
+		t.Fatalf("the foreground request is shed while overloaded")
+	}
+	release()
+}
+
+func TestAdmission_SubmitLatency(t *testing.T) {
+	a := newAdmission(AdmissionConfig{OverloadCPURatio: 1000, OverloadSubmitLatency: 10 * time.Millisecond})
+	for i := 0; i < 32; i++ {
+		a.observeSubmit(100 * time.Millisecond)
+	}
+	a.check()
+	if !a.isOverloaded() {
+		t.Fatalf("not overloaded with the submit latency(%v)", time.Duration(a.submitNs))
+	}
+	for i := 0; i < 64; i++ {
+		a.observeSubmit(time.Millisecond)
+	}
+	a.check()
+	if a.isOverloaded() {
+		t.Fatalf("still overloaded with the submit latency(%v)", time.Duration(a.submitNs))
+	}
+}
@@ -59,6 +59,8 @@ func (m *MetaNode) registerAPIHandler() (err error) {
 	http.HandleFunc("/getDirectory", m.getDirectoryHandler)
 	http.HandleFunc("/getAllDentry", m.getAllDentriesHandler)
 	http.HandleFunc("/getParams", m.getParamsHandler)
+	// long poll the events of the volume
+	http.HandleFunc("/getEvents", m.getEventsHandler)
 	return
 }
 
@@ -67,6 +69,7 @@ func (m *MetaNode) getParamsHandler(w http.ResponseWriter,
 	resp := NewAPIResponse(http.StatusOK, http.StatusText(http.StatusOK))
 	params := make(map[string]interface{})
 	params[metaNodeDeleteBatchCountKey] = DeleteBatchCount()
+	params[metaNodeMultipartExpirationKey] = MultipartExpiration().String()
 	resp.Data = params
 	data, _ := resp.Marshal()
 	if _, err := w.Write(data); err != nil {
@@ -413,3 +416,60 @@ func (m *MetaNode) getDirectoryHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	return
 }
+
+func (m *MetaNode) getEventsHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[getEventsHandler] response %s", err)
+		}
+	}()
+	pid, err := strconv.ParseUint(r.FormValue("pid"), 10, 64)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	req := &proto.ReadEventsRequest{PartitionId: pid}
+	if value := r.FormValue("from"); value != "" {
+		if req.From, err = strconv.ParseUint(value, 10, 64); err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+	}
+	if value := r.FormValue("limit"); value != "" {
+		if req.Limit, err = strconv.Atoi(value); err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+	}
+	if value := r.FormValue("wait"); value != "" {
+		if req.Wait, err = strconv.ParseInt(value, 10, 64); err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+		if req.Wait > maxPollEventsWait {
+			req.Wait = maxPollEventsWait
+		}
+	}
+
+	mp, err := m.metadataManager.GetPartition(pid)
+	if err != nil {
+		resp.Code = http.StatusNotFound
+		resp.Msg = err.Error()
+		return
+	}
+	p := &Packet{}
+	if err = mp.ReadEvents(req, p); err != nil {
+		resp.Code = http.StatusInternalServerError
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Code = http.StatusSeeOther
+	resp.Msg = p.GetResultMsg()
+	if len(p.Data) > 0 {
+		resp.Data = json.RawMessage(p.Data)
+	}
+	return
+}
@@ -0,0 +1,81 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"sync"
+
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// deadClientSet keeps the clients whose sessions expired or were evicted on the master,
+// which are sent to the meta nodes with the heartbeats.
+type deadClientSet struct {
+	sync.RWMutex
+	clients map[uint64]bool
+}
+
+func newDeadClientSet() *deadClientSet {
+	return &deadClientSet{clients: make(map[uint64]bool)}
+}
+
+// update replaces the dead clients by the ones from the master, and returns the ones newly dead.
+func (s *deadClientSet) update(ids []uint64) (added map[uint64]bool) {
+	clients := make(map[uint64]bool, len(ids))
+	added = make(map[uint64]bool)
+	s.Lock()
+	defer s.Unlock()
+	for _, id := range ids {
+		clients[id] = true
+		if !s.clients[id] {
+			added[id] = true
+		}
+	}
+	s.clients = clients
+	return
+}
+
+func (s *deadClientSet) has(id uint64) bool {
+	if s == nil {
+		return false
+	}
+	s.RLock()
+	defer s.RUnlock()
+	return s.clients[id]
+}
+
+// updateDeadClients updates the dead clients told by the master, and releases the locks held by the ones newly dead.
+// The orphan inodes held by the dead clients are released by the delete workers of the partitions.
+func (m *metadataManager) updateDeadClients(ids []uint64) {
+	added := m.deadClients.update(ids)
+	if len(added) == 0 {
+		return
+	}
+	m.Range(func(id uint64, partition MetaPartition) bool {
+		mp, ok := partition.(*metaPartition)
+		if !ok {
+			return true
+		}
+		if released := mp.lockTable.ReleaseClients(added); released > 0 {
+			log.LogWarnf("updateDeadClients: partition(%v) released(%v) locks of the dead clients", id, released)
+		}
+		return true
+	})
+}
+
+// isDeadClient tells whether the session of the client expired or was evicted on the master.
+func (mp *metaPartition) isDeadClient(clientID uint64) bool {
+	return mp.manager != nil && mp.manager.deadClients.has(clientID)
+}
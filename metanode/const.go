@@ -31,6 +31,10 @@ type (
 	CreateInoReq = proto.CreateInodeRequest
 	// MetaNode -> Client create Inode response
 	CreateInoResp = proto.CreateInodeResponse
+	// Client -> MetaNode clone Inode request
+	CloneInoReq = proto.CloneInodeRequest
+	// MetaNode -> Client clone Inode response
+	CloneInoResp = proto.CloneInodeResponse
 	// Client -> MetaNode create Link Request
 	LinkInodeReq = proto.LinkInodeRequest
 	// MetaNode -> Client create Link Response
@@ -75,6 +79,8 @@ type (
 	UpdatePartitionResp = proto.UpdateMetaPartitionResponse
 	// Client -> MetaNode
 	ExtentsTruncateReq = proto.TruncateRequest
+	// Client -> MetaNode
+	PunchHoleReq = proto.PunchHoleRequest
 
 	// Client -> MetaNode
 	EvictInodeReq = proto.EvictInodeRequest
@@ -117,6 +123,18 @@ const (
 	opFSMDeleteDentryBatch
 	opFSMUnlinkInodeBatch
 	opFSMEvictInodeBatch
+
+	opFSMTxBegin
+	opFSMTxUpdate
+	opFSMTxRemove
+
+	opFSMBatch // the operations proposed in a batch, see submitBatcher
+	opFSMCloneInode
+	opFSMPunchHole
+	opFSMVerify    // the marker to take the digests of the meta trees, see VerifyReplica
+	opFSMRenameVol // rename the volume of the partition, see renameVols
+	opFSMSetXAttrFlag
+	opFSMSetQuota // tag the inode with its directory quota, see fsmSetQuota
 )
 
 var (
@@ -137,18 +155,35 @@ const (
 
 // Configuration keys
 const (
-	cfgLocalIP           = "localIP"
-	cfgListen            = "listen"
-	cfgMetadataDir       = "metadataDir"
-	cfgRaftDir           = "raftDir"
-	cfgMasterAddrs       = "masterAddrs" // will be deprecated
-	cfgRaftHeartbeatPort = "raftHeartbeatPort"
-	cfgRaftReplicaPort   = "raftReplicaPort"
-	cfgDeleteBatchCount  = "deleteBatchCount"
-	cfgTotalMem          = "totalMem"
-	cfgZoneName          = "zoneName"
-
-	metaNodeDeleteBatchCountKey = "batchCount"
+	cfgLocalIP             = "localIP"
+	cfgListen              = "listen"
+	cfgMetadataDir         = "metadataDir"
+	cfgRaftDir             = "raftDir"
+	cfgMasterAddrs         = "masterAddrs" // will be deprecated
+	cfgRaftHeartbeatPort   = "raftHeartbeatPort"
+	cfgRaftReplicaPort     = "raftReplicaPort"
+	cfgDeleteBatchCount    = "deleteBatchCount"
+	cfgMultipartExpiration = "multipartExpiration" // in hours
+	cfgPendingDeleteRate   = "pendingDeleteRate"   // in entries per second of a partition
+	cfgChangeJournalSize   = "changeJournalSize"   // in changes kept per partition, 0 disables the journal
+	cfgTotalMem            = "totalMem"
+	cfgZoneName            = "zoneName"
+	cfgRegion              = "region"
+	cfgRack                = "rack"
+
+	// the admission control, see AdmissionConfig
+	cfgMaxForegroundRequests = "maxForegroundRequests"
+	cfgMaxBackgroundRequests = "maxBackgroundRequests"
+	cfgOverloadCPURatio      = "overloadCPURatio"
+	cfgOverloadSubmitLatency = "overloadSubmitLatency" // in ms
+
+	// the batching and the pipelining of the raft proposals, see SubmitBatchConfig
+	cfgSubmitBatchSize     = "submitBatchSize"
+	cfgSubmitPipelineDepth = "submitPipelineDepth"
+	cfgRaftMaxInflightMsgs = "raftMaxInflightMsgs"
+
+	metaNodeDeleteBatchCountKey    = "batchCount"
+	metaNodeMultipartExpirationKey = "multipartExpiration"
 )
 
 const (
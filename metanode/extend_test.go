@@ -20,9 +20,35 @@ import (
 	"testing"
 	"time"
 
+	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util"
 )
 
+func TestFsmSetXAttrFlag(t *testing.T) {
+	mp := &metaPartition{extendTree: NewBtree()}
+	set := func(value string, flag int) uint8 {
+		extend := NewExtend(1)
+		extend.Put([]byte("user.k"), []byte(value))
+		return mp.fsmSetXAttrFlag(extend, flag)
+	}
+	if status := set("a", proto.XAttrReplace); status != proto.OpNotExistErr {
+		t.Fatalf("replace absent expect(%v) actual(%v)", proto.OpNotExistErr, status)
+	}
+	if status := set("a", proto.XAttrCreate); status != proto.OpOk {
+		t.Fatalf("create absent expect(%v) actual(%v)", proto.OpOk, status)
+	}
+	if status := set("b", proto.XAttrCreate); status != proto.OpExistErr {
+		t.Fatalf("create existing expect(%v) actual(%v)", proto.OpExistErr, status)
+	}
+	if status := set("c", proto.XAttrReplace); status != proto.OpOk {
+		t.Fatalf("replace existing expect(%v) actual(%v)", proto.OpOk, status)
+	}
+	value, _ := mp.extendTree.Get(NewExtend(1)).(*Extend).Get([]byte("user.k"))
+	if string(value) != "c" {
+		t.Errorf("value expect(c) actual(%s)", value)
+	}
+}
+
 func TestExtend_Bytes(t *testing.T) {
 	var err error
 	const numSamples = 100
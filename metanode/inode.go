@@ -28,6 +28,7 @@ import (
 
 const (
 	DeleteMarkFlag = 1 << 0
+	QuotaFlag      = 1 << 1 // set in the marshaled flag only, when the quota id follows the reserved space
 )
 
 // Inode wraps necessary properties of `Inode` information in the file system.
@@ -64,6 +65,7 @@ type Inode struct {
 	NLink      uint32 // NodeLink counts
 	Flag       int32
 	Reserved   uint64 // reserved space
+	QuotaId    uint64 // root inode of the directory quota the inode belongs to
 	//Extents    *ExtentsTree
 	Extents *SortedExtents
 }
@@ -90,6 +92,7 @@ func (i *Inode) String() string {
 	buff.WriteString(fmt.Sprintf("NLink[%d]", i.NLink))
 	buff.WriteString(fmt.Sprintf("Flag[%d]", i.Flag))
 	buff.WriteString(fmt.Sprintf("Reserved[%d]", i.Reserved))
+	buff.WriteString(fmt.Sprintf("QuotaId[%d]", i.QuotaId))
 	buff.WriteString(fmt.Sprintf("Extents[%s]", i.Extents))
 	buff.WriteString("}")
 	return buff.String()
@@ -140,6 +143,7 @@ func (i *Inode) Copy() BtreeItem {
 	newIno.NLink = i.NLink
 	newIno.Flag = i.Flag
 	newIno.Reserved = i.Reserved
+	newIno.QuotaId = i.QuotaId
 	newIno.Extents = i.Extents.Clone()
 	i.RUnlock()
 	return newIno
@@ -308,12 +312,21 @@ func (i *Inode) MarshalValue() (val []byte) {
 	if err = binary.Write(buff, binary.BigEndian, &i.NLink); err != nil {
 		panic(err)
 	}
-	if err = binary.Write(buff, binary.BigEndian, &i.Flag); err != nil {
+	flag := i.Flag
+	if i.QuotaId > 0 {
+		flag |= QuotaFlag
+	}
+	if err = binary.Write(buff, binary.BigEndian, &flag); err != nil {
 		panic(err)
 	}
 	if err = binary.Write(buff, binary.BigEndian, &i.Reserved); err != nil {
 		panic(err)
 	}
+	if i.QuotaId > 0 {
+		if err = binary.Write(buff, binary.BigEndian, &i.QuotaId); err != nil {
+			panic(err)
+		}
+	}
 	// marshal ExtentsKey
 	extData, err := i.Extents.MarshalBinary()
 	if err != nil {
@@ -376,6 +389,12 @@ func (i *Inode) UnmarshalValue(val []byte) (err error) {
 	if err = binary.Read(buff, binary.BigEndian, &i.Reserved); err != nil {
 		return
 	}
+	if i.Flag&QuotaFlag == QuotaFlag {
+		i.Flag &^= QuotaFlag
+		if err = binary.Read(buff, binary.BigEndian, &i.QuotaId); err != nil {
+			return
+		}
+	}
 	if buff.Len() == 0 {
 		return
 	}
@@ -406,8 +425,9 @@ func (i *Inode) AppendExtents(eks []proto.ExtentKey, ct int64) (delExtents []pro
 	return
 }
 
-func (i *Inode) ExtentsTruncate(length uint64, ct int64) (delExtents []proto.ExtentKey) {
+func (i *Inode) ExtentsTruncate(length uint64, ct int64) (delExtents, holes []proto.ExtentKey) {
 	i.Lock()
+	holes = i.Extents.TruncatedHoles(length)
 	delExtents = i.Extents.Truncate(length)
 	i.Size = length
 	i.ModifyTime = ct
@@ -416,6 +436,16 @@ func (i *Inode) ExtentsTruncate(length uint64, ct int64) (delExtents []proto.Ext
 	return
 }
 
+// PunchHole removes the range from the extents of the inode, the size of the inode is kept.
+func (i *Inode) PunchHole(offset, size uint64, ct int64) (delExtents, holes []proto.ExtentKey) {
+	i.Lock()
+	delExtents, holes = i.Extents.PunchHole(offset, size)
+	i.ModifyTime = ct
+	i.Generation++
+	i.Unlock()
+	return
+}
+
 // IncNLink increases the nLink value by one.
 func (i *Inode) IncNLink() {
 	i.Lock()
@@ -442,6 +472,13 @@ func (i *Inode) GetNLink() uint32 {
 	return i.NLink
 }
 
+// GetSize returns the size of the inode.
+func (i *Inode) GetSize() uint64 {
+	i.RLock()
+	defer i.RUnlock()
+	return i.Size
+}
+
 func (i *Inode) IsTempFile() bool {
 	i.RLock()
 	ok := i.NLink == 0
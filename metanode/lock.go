@@ -0,0 +1,143 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// LockGracePeriod is how long a new leader of the partition only grants the locks reclaimed
+// by their holders, which reclaim their locks every proto.LockReclaimInterval.
+const LockGracePeriod = 3 * proto.LockReclaimInterval
+
+var ErrLockGrace = errors.New("in the grace period of the locks")
+
+// LockTable keeps the advisory file locks of the inodes in a meta partition.
+// Locks only live in the memory of the partition leader and are not replicated
+// through raft, so they are dropped when the leadership changes. The new leader
+// starts a grace period, in which the clients reclaim the locks they hold before
+// any new lock is granted, so that no lock is granted twice.
+type LockTable struct {
+	sync.Mutex
+	locks    map[uint64][]*proto.FileLock
+	graceEnd time.Time
+}
+
+// NewLockTable returns a new empty lock table.
+func NewLockTable() *LockTable {
+	return &LockTable{
+		locks: make(map[uint64][]*proto.FileLock),
+	}
+}
+
+func lockConflict(held, lk *proto.FileLock) bool {
+	if held.Flock != lk.Flock || held.SameOwner(lk) || !held.Overlaps(lk) {
+		return false
+	}
+	return held.Type == proto.FileLockWrite || lk.Type == proto.FileLockWrite
+}
+
+// Get returns the first held lock conflicting with the given one, or nil.
+func (t *LockTable) Get(ino uint64, lk *proto.FileLock) *proto.FileLock {
+	t.Lock()
+	defer t.Unlock()
+	return t.conflict(ino, lk)
+}
+
+func (t *LockTable) conflict(ino uint64, lk *proto.FileLock) *proto.FileLock {
+	if lk.Type == proto.FileLockUnlock {
+		return nil
+	}
+	for _, held := range t.locks[ino] {
+		if lockConflict(held, lk) {
+			c := *held
+			return &c
+		}
+	}
+	return nil
+}
+
+// InGrace tells whether the table is in the grace period, in which the conflicts are unknown.
+func (t *LockTable) InGrace() bool {
+	t.Lock()
+	defer t.Unlock()
+	return time.Now().Before(t.graceEnd)
+}
+
+// Acquire is Set checking the grace period, in which only the locks reclaimed and
+// the unlocks are taken, the others fail with ErrLockGrace.
+func (t *LockTable) Acquire(ino uint64, lk *proto.FileLock, reclaim bool) (*proto.FileLock, error) {
+	t.Lock()
+	defer t.Unlock()
+	if !reclaim && lk.Type != proto.FileLockUnlock && time.Now().Before(t.graceEnd) {
+		return nil, ErrLockGrace
+	}
+	return t.set(ino, lk), nil
+}
+
+// Set acquires or releases a lock. It returns the conflicting lock without
+// changing the table if the lock cannot be taken.
+func (t *LockTable) Set(ino uint64, lk *proto.FileLock) *proto.FileLock {
+	t.Lock()
+	defer t.Unlock()
+	return t.set(ino, lk)
+}
+
+func (t *LockTable) set(ino uint64, lk *proto.FileLock) *proto.FileLock {
+	if c := t.conflict(ino, lk); c != nil {
+		return c
+	}
+	result := proto.MergeFileLock(t.locks[ino], lk)
+	if len(result) == 0 {
+		delete(t.locks, ino)
+	} else {
+		t.locks[ino] = result
+	}
+	return nil
+}
+
+// ReleaseClients drops the locks held by the clients, and returns the number of the locks dropped.
+func (t *LockTable) ReleaseClients(clients map[uint64]bool) (released int) {
+	t.Lock()
+	defer t.Unlock()
+	for ino, held := range t.locks {
+		kept := held[:0]
+		for _, lk := range held {
+			if clients[lk.Client] {
+				released++
+				continue
+			}
+			kept = append(kept, lk)
+		}
+		if len(kept) == 0 {
+			delete(t.locks, ino)
+		} else {
+			t.locks[ino] = kept
+		}
+	}
+	return
+}
+
+// Reset drops all the locks in the table, and starts the grace period.
+func (t *LockTable) Reset() {
+	t.Lock()
+	defer t.Unlock()
+	t.locks = make(map[uint64][]*proto.FileLock)
+	t.graceEnd = time.Now().Add(LockGracePeriod)
+}
@@ -0,0 +1,114 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestLockTable_Conflict(t *testing.T) {
+	table := NewLockTable()
+	a := &proto.FileLock{Client: 1, Owner: 1, Start: 0, End: 99, Type: proto.FileLockWrite}
+	if c := table.Set(1, a); c != nil {
+		t.Fatalf("unexpected conflict: %v", c)
+	}
+	b := &proto.FileLock{Client: 2, Owner: 1, Start: 50, End: 60, Type: proto.FileLockRead}
+	if c := table.Set(1, b); c == nil || c.Client != 1 {
+		t.Fatalf("expect conflict with client 1, got %v", c)
+	}
+	// flock and fcntl locks do not conflict with each other
+	b.Flock = true
+	if c := table.Set(1, b); c != nil {
+		t.Fatalf("unexpected conflict between flock and fcntl locks: %v", c)
+	}
+	// other inodes are not affected
+	if c := table.Get(2, &proto.FileLock{Client: 2, Owner: 1, End: 99, Type: proto.FileLockWrite}); c != nil {
+		t.Fatalf("unexpected conflict on other inode: %v", c)
+	}
+}
+
+func TestLockTable_SplitOnUnlock(t *testing.T) {
+	table := NewLockTable()
+	table.Set(1, &proto.FileLock{Client: 1, Owner: 1, Start: 0, End: 99, Type: proto.FileLockWrite})
+	table.Set(1, &proto.FileLock{Client: 1, Owner: 1, Start: 40, End: 59, Type: proto.FileLockUnlock})
+
+	other := &proto.FileLock{Client: 2, Owner: 1, Start: 40, End: 59, Type: proto.FileLockWrite}
+	if c := table.Get(1, other); c != nil {
+		t.Fatalf("unlocked range still conflicts: %v", c)
+	}
+	other.Start, other.End = 30, 45
+	if c := table.Get(1, other); c == nil || c.End != 39 {
+		t.Fatalf("expect conflict with left part, got %v", c)
+	}
+	other.Start, other.End = 55, 65
+	if c := table.Get(1, other); c == nil || c.Start != 60 {
+		t.Fatalf("expect conflict with right part, got %v", c)
+	}
+
+	table.Set(1, &proto.FileLock{Client: 1, Owner: 1, Start: 0, End: ^uint64(0), Type: proto.FileLockUnlock})
+	if len(table.locks) != 0 {
+		t.Fatalf("expect empty lock table, got %v", table.locks)
+	}
+}
+
+func TestLockTable_ReleaseClients(t *testing.T) {
+	table := NewLockTable()
+	table.Set(1, &proto.FileLock{Client: 1, Owner: 1, Start: 0, End: 99, Type: proto.FileLockWrite})
+	table.Set(1, &proto.FileLock{Client: 2, Owner: 1, Start: 100, End: 199, Type: proto.FileLockWrite})
+	table.Set(2, &proto.FileLock{Client: 1, Owner: 1, Start: 0, End: 99, Type: proto.FileLockRead})
+
+	if released := table.ReleaseClients(map[uint64]bool{1: true}); released != 2 {
+		t.Fatalf("expect 2 locks released, got %v", released)
+	}
+	other := &proto.FileLock{Client: 3, Owner: 1, Start: 0, End: 99, Type: proto.FileLockWrite}
+	if c := table.Get(1, other); c != nil {
+		t.Fatalf("lock of the dead client still conflicts: %v", c)
+	}
+	if c := table.Get(2, other); c != nil {
+		t.Fatalf("lock of the dead client still conflicts: %v", c)
+	}
+	other.Start, other.End = 150, 160
+	if c := table.Get(1, other); c == nil || c.Client != 2 {
+		t.Fatalf("expect conflict with client 2, got %v", c)
+	}
+}
+
+func TestLockTable_Grace(t *testing.T) {
+	table := NewLockTable()
+	table.Reset()
+	if !table.InGrace() {
+		t.Fatalf("expect the grace period after reset")
+	}
+	held := &proto.FileLock{Client: 1, Owner: 1, Start: 0, End: 99, Type: proto.FileLockWrite}
+	other := &proto.FileLock{Client: 2, Owner: 1, Start: 0, End: 99, Type: proto.FileLockWrite}
+	if _, err := table.Acquire(1, other, false); err != ErrLockGrace {
+		t.Fatalf("expect new lock rejected in the grace period, got %v", err)
+	}
+	if c, err := table.Acquire(1, held, true); err != nil || c != nil {
+		t.Fatalf("expect lock reclaimed in the grace period, got conflict(%v) err(%v)", c, err)
+	}
+	unlock := &proto.FileLock{Client: 3, Owner: 1, Start: 0, End: 99, Type: proto.FileLockUnlock}
+	if _, err := table.Acquire(2, unlock, false); err != nil {
+		t.Fatalf("expect unlock taken in the grace period, got %v", err)
+	}
+
+	table.graceEnd = time.Now().Add(-time.Second)
+	if c, err := table.Acquire(1, other, false); err != nil || c == nil || c.Client != 1 {
+		t.Fatalf("expect conflict with the reclaimed lock, got conflict(%v) err(%v)", c, err)
+	}
+}
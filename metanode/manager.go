@@ -50,10 +50,12 @@ type MetadataManager interface {
 
 // MetadataManagerConfig defines the configures in the metadata manager.
 type MetadataManagerConfig struct {
-	NodeID    uint64
-	RootDir   string
-	ZoneName  string
-	RaftStore raftstore.RaftStore
+	NodeID      uint64
+	RootDir     string
+	ZoneName    string
+	RaftStore   raftstore.RaftStore
+	Admission   AdmissionConfig
+	SubmitBatch SubmitBatchConfig
 }
 
 type metadataManager struct {
@@ -67,6 +69,12 @@ type metadataManager struct {
 	partitions         map[uint64]MetaPartition // Key: metaRangeId, Val: metaPartition
 	metaNode           *MetaNode
 	flDeleteBatchCount atomic.Value
+	readOnlyVols       atomic.Value // map[string]bool, the volumes frozen read-only on the master
+	permVols           atomic.Value // map[string]bool, the volumes enforcing the permission checks
+	quotas             atomic.Value // map[string]map[uint64]*proto.QuotaInfo, the directory quotas of the volumes
+	admission          *admission
+	submitBatch        SubmitBatchConfig
+	deadClients        *deadClientSet
 }
 
 // HandleMetadataOperation handles the metadata operations.
@@ -75,6 +83,12 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet,
 	metric := exporter.NewTPCnt(p.GetOpMsg())
 	defer metric.Set(err)
 
+	release, ok := m.admission.admit(p.Opcode)
+	if !ok {
+		return m.shed(conn, p)
+	}
+	defer release()
+
 	switch p.Opcode {
 	case proto.OpMetaCreateInode:
 		err = m.opCreateInode(conn, p, remoteAddr)
@@ -102,6 +116,10 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet,
 		err = m.opBatchDeleteDentry(conn, p, remoteAddr)
 	case proto.OpMetaUpdateDentry:
 		err = m.opUpdateDentry(conn, p, remoteAddr)
+	case proto.OpMetaReadDirPlus:
+		err = m.opReadDirPlus(conn, p, remoteAddr)
+	case proto.OpMetaCloneInode:
+		err = m.opCloneInode(conn, p, remoteAddr)
 	case proto.OpMetaReadDir:
 		err = m.opReadDir(conn, p, remoteAddr)
 	case proto.OpCreateMetaPartition:
@@ -116,6 +134,8 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet,
 		err = m.opMetaExtentsDel(conn, p, remoteAddr)
 	case proto.OpMetaTruncate:
 		err = m.opMetaExtentsTruncate(conn, p, remoteAddr)
+	case proto.OpMetaPunchHole:
+		err = m.opMetaPunchHole(conn, p, remoteAddr)
 	case proto.OpMetaLookup:
 		err = m.opMetaLookup(conn, p, remoteAddr)
 	case proto.OpDeleteMetaPartition:
@@ -132,6 +152,8 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet,
 		err = m.opRemoveMetaPartitionRaftMember(conn, p, remoteAddr)
 	case proto.OpMetaPartitionTryToLeader:
 		err = m.opMetaPartitionTryToLeader(conn, p, remoteAddr)
+	case proto.OpVerifyMetaPartition:
+		err = m.opVerifyMetaPartition(conn, p, remoteAddr)
 	case proto.OpMetaBatchInodeGet:
 		err = m.opMetaBatchInodeGet(conn, p, remoteAddr)
 	case proto.OpMetaDeleteInode:
@@ -151,6 +173,25 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet,
 		err = m.opMetaRemoveXAttr(conn, p, remoteAddr)
 	case proto.OpMetaListXAttr:
 		err = m.opMetaListXAttr(conn, p, remoteAddr)
+	// operations for file locks
+	case proto.OpMetaSetLock:
+		err = m.opMetaSetLock(conn, p, remoteAddr)
+	case proto.OpMetaGetLock:
+		err = m.opMetaGetLock(conn, p, remoteAddr)
+	case proto.OpMetaGetDirStat:
+		err = m.opMetaGetDirStat(conn, p, remoteAddr)
+	case proto.OpMetaReadChanges:
+		err = m.opMetaReadChanges(conn, p, remoteAddr)
+	case proto.OpMetaReadEvents:
+		err = m.opMetaReadEvents(conn, p, remoteAddr)
+	case proto.OpMetaTxBegin:
+		err = m.opMetaTxBegin(conn, p, remoteAddr)
+	case proto.OpMetaTxUpdate:
+		err = m.opMetaTxUpdate(conn, p, remoteAddr)
+	case proto.OpMetaTxFinish:
+		err = m.opMetaTxFinish(conn, p, remoteAddr)
+	case proto.OpMetaQuotaTag:
+		err = m.opMetaQuotaTag(conn, p, remoteAddr)
 	// operations for multipart session
 	case proto.OpCreateMultipart:
 		err = m.opCreateMultipart(conn, p, remoteAddr)
@@ -201,7 +242,11 @@ func (m *metadataManager) Stop() {
 // onStart creates the connection pool and loads the partitions.
 func (m *metadataManager) onStart() (err error) {
 	m.connPool = util.NewConnectPool()
-	err = m.loadPartitions()
+	if err = m.loadPartitions(); err != nil {
+		return
+	}
+	go m.statMetrics()
+	go m.checkOverload()
 	return
 }
 
@@ -447,12 +492,15 @@ func (m *metadataManager) MarshalJSON() (data []byte, err error) {
 // NewMetadataManager returns a new metadata manager.
 func NewMetadataManager(conf MetadataManagerConfig, metaNode *MetaNode) MetadataManager {
 	return &metadataManager{
-		nodeId:     conf.NodeID,
-		zoneName:   conf.ZoneName,
-		rootDir:    conf.RootDir,
-		raftStore:  conf.RaftStore,
-		partitions: make(map[uint64]MetaPartition),
-		metaNode:   metaNode,
+		nodeId:      conf.NodeID,
+		zoneName:    conf.ZoneName,
+		rootDir:     conf.RootDir,
+		raftStore:   conf.RaftStore,
+		partitions:  make(map[uint64]MetaPartition),
+		metaNode:    metaNode,
+		admission:   newAdmission(conf.Admission),
+		submitBatch: conf.SubmitBatch,
+		deadClients: newDeadClientSet(),
 	}
 }
 
@@ -51,6 +51,11 @@ func (m *metadataManager) opMasterHeartbeat(conn net.Conn, p *Packet,
 		resp.Result = err.Error()
 		goto end
 	}
+	m.updateDeadClients(req.DeadClients)
+	m.renameVols(req.RenamedVols)
+	m.updateReadOnlyVols(req.ReadOnlyVols)
+	m.updatePermVols(req.PermVols)
+	m.updateQuotas(req.Quotas)
 
 	// collect memory info
 	resp.Total = configTotalMem
@@ -76,6 +81,10 @@ func (m *metadataManager) opMasterHeartbeat(conn net.Conn, p *Packet,
 			mpr.Status = proto.Unavailable
 		}
 		mpr.IsLeader = isLeader
+		if isLeader {
+			mpr.Usage, mpr.QuotaUsage = partition.GetUsage()
+			mpr.QuotaRoots = partition.GetQuotaRoots()
+		}
 		if mConf.Cursor >= mConf.End {
 			mpr.Status = proto.ReadOnly
 		}
@@ -86,6 +95,7 @@ func (m *metadataManager) opMasterHeartbeat(conn net.Conn, p *Packet,
 		return true
 	})
 	resp.ZoneName = m.zoneName
+	resp.Version = proto.Version
 	resp.Status = proto.TaskSucceeds
 end:
 	adminTask.Request = nil
@@ -153,6 +163,9 @@ func (m *metadataManager) opCreateInode(conn net.Conn, p *Packet,
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
 	err = mp.CreateInode(req, p)
 	// reply the operation result to the client through TCP
 	m.respondToClient(conn, p)
@@ -161,6 +174,35 @@ func (m *metadataManager) opCreateInode(conn net.Conn, p *Packet,
 	return
 }
 
+func (m *metadataManager) opCloneInode(conn net.Conn, p *Packet,
+	remoteAddr string) (err error) {
+	req := &CloneInoReq{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
+	err = mp.CloneInode(req, p)
+	m.respondToClient(conn, p)
+	log.LogDebugf("%s [opCloneInode] req: %d - %v, resp: %v, body: %s",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
 func (m *metadataManager) opMetaLinkInode(conn net.Conn, p *Packet,
 	remoteAddr string) (err error) {
 	req := &LinkInodeReq{}
@@ -180,6 +222,9 @@ func (m *metadataManager) opMetaLinkInode(conn net.Conn, p *Packet,
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
 	err = mp.CreateInodeLink(req, p)
 	m.respondToClient(conn, p)
 	log.LogDebugf("%s [opMetaLinkInode] req: %d - %v, resp: %v, body: %s",
@@ -221,9 +266,15 @@ func (m *metadataManager) opCreateDentry(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
+	if m.rejectNoCred(conn, mp, p, req.Cred) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
 	err = mp.CreateDentry(req, p)
 	m.respondToClient(conn, p)
 	log.LogDebugf("%s [opCreateDentry] req: %d - %v, resp: %v, body: %s",
@@ -248,9 +299,15 @@ func (m *metadataManager) opDeleteDentry(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
+	if m.rejectNoCred(conn, mp, p, req.Cred) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
 	err = mp.DeleteDentry(req, p)
 	m.respondToClient(conn, p)
 	log.LogDebugf("%s [opDeleteDentry] req: %d - %v, resp: %v, body: %s",
@@ -278,6 +335,9 @@ func (m *metadataManager) opBatchDeleteDentry(conn net.Conn, p *Packet,
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
 	err = mp.DeleteDentryBatch(req, p)
 	m.respondToClient(conn, p)
 	log.LogDebugf("%s [opDeleteDentry] req: %d - %v, resp: %v, body: %s",
@@ -301,9 +361,15 @@ func (m *metadataManager) opUpdateDentry(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
+	if m.rejectNoCred(conn, mp, p, req.Cred) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
 	err = mp.UpdateDentry(req, p)
 	m.respondToClient(conn, p)
 	log.LogDebugf("%s [opUpdateDentry] req: %d - %v; resp: %v, body: %s",
@@ -330,6 +396,9 @@ func (m *metadataManager) opMetaUnlinkInode(conn net.Conn, p *Packet,
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
 	err = mp.UnlinkInode(req, p)
 	m.respondToClient(conn, p)
 	log.LogDebugf("%s [opDeleteInode] req: %d - %v, resp: %v, body: %s",
@@ -356,6 +425,9 @@ func (m *metadataManager) opMetaBatchUnlinkInode(conn net.Conn, p *Packet,
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
 	err = mp.UnlinkInodeBatch(req, p)
 	m.respondToClient(conn, p)
 	log.LogDebugf("%s [opDeleteInode] req: %d - %v, resp: %v, body: %s",
@@ -380,7 +452,10 @@ func (m *metadataManager) opReadDir(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
-	if !m.serveProxy(conn, mp, p) {
+	if m.rejectNoCred(conn, mp, p, req.Cred) {
+		return
+	}
+	if !m.serveReadProxy(conn, mp, p, req.FollowerRead) {
 		return
 	}
 	err = mp.ReadDir(req, p)
@@ -390,6 +465,35 @@ func (m *metadataManager) opReadDir(conn net.Conn, p *Packet,
 	return
 }
 
+func (m *metadataManager) opReadDirPlus(conn net.Conn, p *Packet,
+	remoteAddr string) (err error) {
+	req := &proto.ReadDirRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
+		return
+	}
+	if m.rejectNoCred(conn, mp, p, req.Cred) {
+		return
+	}
+	if !m.serveReadProxy(conn, mp, p, req.FollowerRead) {
+		return
+	}
+	err = mp.ReadDirPlus(req, p)
+	m.respondToClient(conn, p)
+	log.LogDebugf("%s [%v]req: %v , resp: %v, body: %s", remoteAddr,
+		p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
 func (m *metadataManager) opMetaInodeGet(conn net.Conn, p *Packet,
 	remoteAddr string) (err error) {
 	req := &InodeGetReq{}
@@ -406,7 +510,7 @@ func (m *metadataManager) opMetaInodeGet(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
-	if !m.serveProxy(conn, mp, p) {
+	if !m.serveReadProxy(conn, mp, p, req.FollowerRead) {
 		return
 	}
 	if err = mp.InodeGet(req, p); err != nil {
@@ -494,10 +598,16 @@ func (m *metadataManager) opSetAttr(conn net.Conn, p *Packet,
 		return
 	}
 
+	if m.rejectNoCred(conn, mp, p, req.Cred) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
-	if err = mp.SetAttr(p.Data, p); err != nil {
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
+	if err = mp.SetAttr(req, p.Data, p); err != nil {
 		err = errors.NewErrorf("[opSetAttr] req: %v, error: %s", req, err.Error())
 	}
 	m.respondToClient(conn, p)
@@ -523,7 +633,10 @@ func (m *metadataManager) opMetaLookup(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	if !m.serveProxy(conn, mp, p) {
+	if m.rejectNoCred(conn, mp, p, req.Cred) {
+		return
+	}
+	if !m.serveReadProxy(conn, mp, p, req.FollowerRead) {
 		return
 	}
 	err = mp.Lookup(req, p)
@@ -552,6 +665,9 @@ func (m *metadataManager) opMetaExtentsAdd(conn net.Conn, p *Packet,
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
 	err = mp.ExtentAppend(req, p)
 	m.respondToClient(conn, p)
 	if err != nil {
@@ -614,6 +730,9 @@ func (m *metadataManager) opMetaExtentsTruncate(conn net.Conn, p *Packet,
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
 	mp.ExtentsTruncate(req, p)
 	m.respondToClient(conn, p)
 	log.LogDebugf("%s [OpMetaTruncate] req: %d - %v, resp body: %v, "+
@@ -621,6 +740,35 @@ func (m *metadataManager) opMetaExtentsTruncate(conn net.Conn, p *Packet,
 	return
 }
 
+func (m *metadataManager) opMetaPunchHole(conn net.Conn, p *Packet,
+	remoteAddr string) (err error) {
+	req := &PunchHoleReq{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
+	mp.PunchHole(req, p)
+	m.respondToClient(conn, p)
+	log.LogDebugf("%s [OpMetaPunchHole] req: %d - %v, resp body: %v, "+
+		"resp body: %s", remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
 // Delete a meta partition.
 func (m *metadataManager) opDeleteMetaPartition(conn net.Conn,
 	p *Packet, remoteAddr string) (err error) {
@@ -731,6 +879,46 @@ func (m *metadataManager) opLoadMetaPartition(conn net.Conn, p *Packet,
 	return
 }
 
+func (m *metadataManager) opVerifyMetaPartition(conn net.Conn, p *Packet,
+	remoteAddr string) (err error) {
+	req := &proto.MetaPartitionVerifyRequest{}
+	adminTask := &proto.AdminTask{
+		Request: req,
+	}
+	decode := json.NewDecoder(bytes.NewBuffer(p.Data))
+	decode.UseNumber()
+	if err = decode.Decode(adminTask); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	resp, err := mp.VerifyReplica(req)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		return
+	}
+	p.PacketOkWithBody(data)
+	m.respondToClient(conn, p)
+	log.LogInfof("%s [opVerifyMetaPartition] req[%v], response body[%s]", remoteAddr, req, data)
+	return
+}
+
 func (m *metadataManager) opDecommissionMetaPartition(conn net.Conn,
 	p *Packet, remoteAddr string) (err error) {
 	var reqData []byte
@@ -929,7 +1117,7 @@ func (m *metadataManager) opMetaBatchInodeGet(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	if !m.serveProxy(conn, mp, p) {
+	if !m.serveReadProxy(conn, mp, p, req.FollowerRead) {
 		return
 	}
 	err = mp.InodeGetBatch(req, p)
@@ -976,6 +1164,9 @@ func (m *metadataManager) opMetaDeleteInode(conn net.Conn, p *Packet,
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
 	err = mp.DeleteInode(req, p)
 	_ = m.respondToClient(conn, p)
 	log.LogDebugf("%s [opMetaDeleteInode] req: %d - %v, resp: %v, body: %s",
@@ -1030,6 +1221,10 @@ func (m *metadataManager) opMetaSetXAttr(conn net.Conn, p *Packet, remoteAddr st
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
+	// the holds of the orphan inodes are renewed on the read only volumes
+	if !proto.IsOrphanHoldXAttr(req.Key) && m.rejectReadOnly(conn, mp, p) {
+		return
+	}
 	err = mp.SetXAttr(req, p)
 	_ = m.respondToClient(conn, p)
 	log.LogDebugf("%s [opMetaSetXAttr] req: %d - %v, resp: %v, body: %s",
@@ -1105,6 +1300,10 @@ func (m *metadataManager) opMetaRemoveXAttr(conn net.Conn, p *Packet, remoteAddr
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
+	// the holds of the orphan inodes are renewed on the read only volumes
+	if !proto.IsOrphanHoldXAttr(req.Key) && m.rejectReadOnly(conn, mp, p) {
+		return
+	}
 	err = mp.RemoveXAttr(req, p)
 	_ = m.respondToClient(conn, p)
 	log.LogDebugf("%s [opMetaGetXAttr] req: %d - %v, resp: %v, body: %s",
@@ -1155,6 +1354,9 @@ func (m *metadataManager) opMetaBatchExtentsAdd(conn net.Conn, p *Packet, remote
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
 	err = mp.BatchExtentAppend(req, p)
 	_ = m.respondToClient(conn, p)
 	log.LogDebugf("%s [opMetaBatchExtentsAdd] req: %d - %v, resp: %v, body: %s",
@@ -1180,6 +1382,9 @@ func (m *metadataManager) opCreateMultipart(conn net.Conn, p *Packet, remote str
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
 	err = mp.CreateMultipart(req, p)
 	_ = m.respondToClient(conn, p)
 	return
@@ -1204,6 +1409,9 @@ func (m *metadataManager) opRemoveMultipart(conn net.Conn, p *Packet, remote str
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
 	err = mp.RemoveMultipart(req, p)
 	_ = m.respondToClient(conn, p)
 	return
@@ -1250,6 +1458,9 @@ func (m *metadataManager) opAppendMultipart(conn net.Conn, p *Packet, remote str
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
 	err = mp.AppendMultipart(req, p)
 	return
 }
@@ -1276,3 +1487,231 @@ func (m *metadataManager) opListMultipart(conn net.Conn, p *Packet, remote strin
 	_ = m.respondToClient(conn, p)
 	return
 }
+
+func (m *metadataManager) opMetaSetLock(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.SetLockRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionId)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.SetLock(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaSetLock] req: %d - %v, resp: %v, body: %s",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+func (m *metadataManager) opMetaGetLock(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.GetLockRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionId)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.GetLock(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaGetLock] req: %d - %v, resp: %v, body: %s",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+func (m *metadataManager) opMetaGetDirStat(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.GetDirStatRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionId)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.GetDirStat(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaGetDirStat] req: %d - %v, resp: %v, body: %s",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+func (m *metadataManager) opMetaReadChanges(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.ReadChangesRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionId)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.ReadChanges(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaReadChanges] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(), req, p.GetResultMsg())
+	return
+}
+
+func (m *metadataManager) opMetaReadEvents(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.ReadEventsRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionId)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	if req.Wait > maxReadEventsWait {
+		req.Wait = maxReadEventsWait
+	}
+	err = mp.ReadEvents(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaReadEvents] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(), req, p.GetResultMsg())
+	return
+}
+
+func (m *metadataManager) opMetaTxBegin(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.TxBeginRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	if m.rejectReadOnly(conn, mp, p) {
+		return
+	}
+	err = mp.TxBeginOp(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaTxBegin] req: %d - %v, resp: %v, body: %s",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+// opMetaTxUpdate and opMetaTxFinish are served on the read only volumes as well,
+// so that the transactions begun before are completed or reverted.
+func (m *metadataManager) opMetaTxUpdate(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.TxUpdateRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.TxUpdateOp(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaTxUpdate] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(), req, p.GetResultMsg())
+	return
+}
+
+func (m *metadataManager) opMetaTxFinish(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.TxFinishRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.TxFinishOp(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaTxFinish] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(), req, p.GetResultMsg())
+	return
+}
+
+func (m *metadataManager) opMetaQuotaTag(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.QuotaTagRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.QuotaTag(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaQuotaTag] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(), req, p.GetResultMsg())
+	return
+}
@@ -26,12 +26,21 @@ const (
 	NoClosedConnect    = false
 )
 
+// serveReadProxy is serveProxy for the reads, which are served by a follower
+// instead if the client allows it and the follower is not far behind the leader.
+func (m *metadataManager) serveReadProxy(conn net.Conn, mp MetaPartition, p *Packet, followerRead bool) (ok bool) {
+	if followerRead && mp.IsFollowerReadable() {
+		return true
+	}
+	return m.serveProxy(conn, mp, p)
+}
+
 // The proxy is used during the leader change. When a leader of a partition changes, the proxy forwards the request to
 // the new leader.
 func (m *metadataManager) serveProxy(conn net.Conn, mp MetaPartition,
 	p *Packet) (ok bool) {
 	var (
-		mConn      *net.TCPConn
+		mConn      net.Conn
 		leaderAddr string
 		err        error
 		reqID      = p.ReqID
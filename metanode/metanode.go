@@ -55,6 +55,12 @@ type MetaNode struct {
 	raftHeartbeatPort string
 	raftReplicatePort string
 	zoneName          string
+	region            string
+	rack              string
+	accessTokenKey    []byte
+	admission         AdmissionConfig
+	submitBatch       SubmitBatchConfig
+	raftMaxInflight   int
 	httpStopC         chan uint8
 
 	control common.Control
@@ -170,6 +176,8 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 	m.raftHeartbeatPort = cfg.GetString(cfgRaftHeartbeatPort)
 	m.raftReplicatePort = cfg.GetString(cfgRaftReplicaPort)
 	m.zoneName = cfg.GetString(cfgZoneName)
+	m.region = cfg.GetString(cfgRegion)
+	m.rack = cfg.GetString(cfgRack)
 	configTotalMem, _ = strconv.ParseUint(cfg.GetString(cfgTotalMem), 10, 64)
 
 	if configTotalMem == 0 {
@@ -180,6 +188,26 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 	if deleteBatchCount > 1 {
 		updateDeleteBatchCount(uint64(deleteBatchCount))
 	}
+	if multipartExpiration := cfg.GetInt64(cfgMultipartExpiration); multipartExpiration > 0 {
+		updateMultipartExpiration(time.Duration(multipartExpiration) * time.Hour)
+	}
+	if pendingDeleteRate := cfg.GetInt64(cfgPendingDeleteRate); pendingDeleteRate > 0 {
+		updatePendingDeleteRate(uint64(pendingDeleteRate))
+	}
+	if changeJournalSize := cfg.GetInt64(cfgChangeJournalSize); changeJournalSize > 0 {
+		updateChangeJournalSize(uint64(changeJournalSize))
+	}
+	m.admission = AdmissionConfig{
+		MaxForegroundRequests: int(cfg.GetInt64(cfgMaxForegroundRequests)),
+		MaxBackgroundRequests: int(cfg.GetInt64(cfgMaxBackgroundRequests)),
+		OverloadCPURatio:      cfg.GetFloat(cfgOverloadCPURatio),
+		OverloadSubmitLatency: time.Duration(cfg.GetInt64(cfgOverloadSubmitLatency)) * time.Millisecond,
+	}
+	m.submitBatch = SubmitBatchConfig{
+		MaxOps:        int(cfg.GetInt64(cfgSubmitBatchSize)),
+		PipelineDepth: int(cfg.GetInt64(cfgSubmitPipelineDepth)),
+	}
+	m.raftMaxInflight = int(cfg.GetInt64(cfgRaftMaxInflightMsgs))
 
 	total, _, err := util.GetMemInfo()
 	if err == nil && configTotalMem > total-util.GB {
@@ -220,6 +248,17 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 	log.LogInfof("[parseConfig] load raftHeartbeatPort[%v].", m.raftHeartbeatPort)
 	log.LogInfof("[parseConfig] load raftReplicatePort[%v].", m.raftReplicatePort)
 	log.LogInfof("[parseConfig] load zoneName[%v].", m.zoneName)
+	log.LogInfof("[parseConfig] load region[%v] rack[%v].", m.region, m.rack)
+
+	if err = util.InitTLSFromConfig(cfg, true); err != nil {
+		return
+	}
+	if m.accessTokenKey, err = proto.AccessTokenKeyFromConfig(cfg); err != nil {
+		return
+	}
+	if m.accessTokenKey != nil {
+		proto.EnableNodeAccessToken(m.accessTokenKey)
+	}
 
 	addrs := cfg.GetSlice(proto.MasterAddr)
 	masters := make([]string, 0, len(addrs))
@@ -257,10 +296,12 @@ func (m *MetaNode) startMetaManager() (err error) {
 	}
 	// load metadataManager
 	conf := MetadataManagerConfig{
-		NodeID:    m.nodeId,
-		RootDir:   m.metadataDir,
-		RaftStore: m.raftStore,
-		ZoneName:  m.zoneName,
+		NodeID:      m.nodeId,
+		RootDir:     m.metadataDir,
+		RaftStore:   m.raftStore,
+		ZoneName:    m.zoneName,
+		Admission:   m.admission,
+		SubmitBatch: m.submitBatch,
 	}
 	m.metadataManager = NewMetadataManager(conf, m)
 	if err = m.metadataManager.Start(); err == nil {
@@ -293,7 +334,7 @@ func (m *MetaNode) register() (err error) {
 			step++
 		}
 		var nodeID uint64
-		if nodeID, err = masterClient.NodeAPI().AddMetaNode(nodeAddress, m.zoneName); err != nil {
+		if nodeID, err = masterClient.NodeAPI().AddMetaNodeWithTopology(nodeAddress, m.region, m.zoneName, m.rack); err != nil {
 			log.LogErrorf("register: register to master fail: address(%v) err(%s)", nodeAddress, err)
 			time.Sleep(3 * time.Second)
 			continue
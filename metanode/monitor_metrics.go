@@ -0,0 +1,88 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/cmd/common"
+	"github.com/chubaofs/chubaofs/util/exporter"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// metrics
+const (
+	StatPeriod              = time.Minute
+	MetricMetaPartitionKeys = "mp_keys"
+	MetricMetaPartitionsCnt = "mp_count"
+)
+
+// The types of the trees of a meta partition in the labels of the metrics.
+const (
+	treeTypeInode     = "inode"
+	treeTypeDentry    = "dentry"
+	treeTypeExtend    = "extend"
+	treeTypeMultipart = "multipart"
+)
+
+// statMetrics exports the number of the keys of each tree of the meta partitions periodically.
+// The trees of the meta partitions are in-memory btrees, so the keys are exact rather than estimated.
+func (m *metadataManager) statMetrics() {
+	ticker := time.NewTicker(StatPeriod)
+	defer func() {
+		ticker.Stop()
+		if err := recover(); err != nil {
+			log.LogErrorf("statMetrics panic,err[%v]", err)
+		}
+	}()
+	for range ticker.C {
+		if state := atomic.LoadUint32(&m.state); state == common.StateShutdown || state == common.StateStopped {
+			return
+		}
+		m.doStat()
+	}
+}
+
+func (m *metadataManager) doStat() {
+	var count int
+	m.Range(func(id uint64, p MetaPartition) bool {
+		mp, ok := p.(*metaPartition)
+		if !ok {
+			return true
+		}
+		count++
+		labels := map[string]string{
+			"vol":       mp.config.VolName,
+			"partition": strconv.FormatUint(id, 10),
+		}
+		setTreeKeys(labels, treeTypeInode, mp.getInodeTree().Len())
+		setTreeKeys(labels, treeTypeDentry, mp.getDentryTree().Len())
+		setTreeKeys(labels, treeTypeExtend, mp.extendTree.Len())
+		setTreeKeys(labels, treeTypeMultipart, mp.multipartTree.Len())
+		return true
+	})
+	exporter.NewGauge(MetricMetaPartitionsCnt).Set(float64(count))
+}
+
+func setTreeKeys(partitionLabels map[string]string, treeType string, keys int) {
+	labels := make(map[string]string, len(partitionLabels)+1)
+	for k, v := range partitionLabels {
+		labels[k] = v
+	}
+	labels["tree"] = treeType
+	exporter.NewGauge(MetricMetaPartitionKeys).SetWithLabels(float64(keys), labels)
+}
@@ -15,11 +15,13 @@
 package metanode
 
 import (
+	"encoding/json"
 	"math/rand"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util"
 )
 
@@ -168,3 +170,40 @@ func TestMultipartExtend_Bytes(t *testing.T) {
 		t.Fatalf("result mismatch:\n\tme1:%v\n\tme2:%v", me, me2)
 	}
 }
+
+func TestListMultipart_PrefixAndMarker(t *testing.T) {
+	mp := &metaPartition{multipartTree: NewBtree()}
+	for _, key := range []string{"a/1", "b/1", "b/2", "b/3", "c/1"} {
+		mp.multipartTree.ReplaceOrInsert(&Multipart{id: "id-" + key, key: key, initTime: time.Now()}, true)
+	}
+	var list = func(req *proto.ListMultipartRequest) (keys []string) {
+		p := &Packet{}
+		if err := mp.ListMultipart(req, p); err != nil {
+			t.Fatalf("list multipart fail: err(%v)", err)
+		}
+		resp := &proto.ListMultipartResponse{}
+		if err := json.Unmarshal(p.Data, resp); err != nil {
+			t.Fatalf("unmarshal response fail: err(%v)", err)
+		}
+		for _, info := range resp.Multiparts {
+			keys = append(keys, info.Path)
+		}
+		return
+	}
+	var samples = []struct {
+		req    *proto.ListMultipartRequest
+		expect []string
+	}{
+		{&proto.ListMultipartRequest{Max: 10}, []string{"a/1", "b/1", "b/2", "b/3", "c/1"}},
+		{&proto.ListMultipartRequest{Max: 10, Prefix: "b/"}, []string{"b/1", "b/2", "b/3"}},
+		{&proto.ListMultipartRequest{Max: 2, Prefix: "b/"}, []string{"b/1", "b/2"}},
+		{&proto.ListMultipartRequest{Max: 10, Prefix: "b/", Marker: "b/2", MultipartIdMarker: "id-b/2"}, []string{"b/2", "b/3"}},
+		{&proto.ListMultipartRequest{Max: 10, Prefix: "b/", Marker: "a/1"}, []string{"b/1", "b/2", "b/3"}},
+		{&proto.ListMultipartRequest{Max: 10, Prefix: "d/"}, nil},
+	}
+	for i, sample := range samples {
+		if keys := list(sample.req); !reflect.DeepEqual(keys, sample.expect) {
+			t.Fatalf("sample(%v) result mismatch: expect(%v) actual(%v)", i, sample.expect, keys)
+		}
+	}
+}
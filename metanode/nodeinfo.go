@@ -1,25 +1,35 @@
 package metanode
 
 import (
+	"context"
 	"sync/atomic"
 	"time"
 
 	"github.com/chubaofs/chubaofs/util/log"
+	"golang.org/x/time/rate"
 )
 
 const (
 	UpdateNodeInfoTicket     = 1 * time.Minute
 	DefaultDeleteBatchCounts = 128
+	DefaultPendingDeleteRate = 1000
 )
 
 type NodeInfo struct {
-	deleteBatchCount uint64
+	deleteBatchCount    uint64
+	multipartExpiration int64
+	pendingDeleteRate   uint64
+	changeJournalSize   uint64
 }
 
 var (
 	nodeInfo                   = &NodeInfo{}
 	nodeInfoStopC              = make(chan struct{}, 0)
 	deleteWorkerSleepMs uint64 = 0
+
+	// deleteExtentLimiter limits the extents deleted by the meta node per second to its share
+	// of the cluster-wide deletion rate distributed by the master.
+	deleteExtentLimiter = rate.NewLimiter(rate.Inf, DefaultDeleteBatchCounts)
 )
 
 func DeleteBatchCount() uint64 {
@@ -34,6 +44,39 @@ func updateDeleteBatchCount(val uint64) {
 	atomic.StoreUint64(&nodeInfo.deleteBatchCount, val)
 }
 
+// MultipartExpiration returns the expiration of the multipart uploads, zero means never expire.
+func MultipartExpiration() time.Duration {
+	return time.Duration(atomic.LoadInt64(&nodeInfo.multipartExpiration))
+}
+
+func updateMultipartExpiration(val time.Duration) {
+	atomic.StoreInt64(&nodeInfo.multipartExpiration, int64(val))
+}
+
+// PendingDeleteRate returns the max number of the entries of the pending delete directories
+// deleted by a partition per second.
+func PendingDeleteRate() uint64 {
+	val := atomic.LoadUint64(&nodeInfo.pendingDeleteRate)
+	if val == 0 {
+		val = DefaultPendingDeleteRate
+	}
+	return val
+}
+
+func updatePendingDeleteRate(val uint64) {
+	atomic.StoreUint64(&nodeInfo.pendingDeleteRate, val)
+}
+
+// ChangeJournalSize returns the number of the changes kept by the change journal of a partition,
+// zero means the journal is disabled.
+func ChangeJournalSize() int {
+	return int(atomic.LoadUint64(&nodeInfo.changeJournalSize))
+}
+
+func updateChangeJournalSize(val uint64) {
+	atomic.StoreUint64(&nodeInfo.changeJournalSize, val)
+}
+
 func updateDeleteWorkerSleepMs(val uint64) {
 	atomic.StoreUint64(&deleteWorkerSleepMs, val)
 }
@@ -45,6 +88,30 @@ func DeleteWorkerSleepMs() {
 	}
 }
 
+func updateDeleteExtentLimitRate(val uint64) {
+	l := rate.Limit(val)
+	if val == 0 {
+		l = rate.Inf
+	}
+	deleteExtentLimiter.SetLimit(l)
+}
+
+// deleteExtentLimiterWait blocks until n extents are allowed to be deleted.
+func deleteExtentLimiterWait(n int) {
+	if deleteExtentLimiter.Limit() == rate.Inf {
+		return
+	}
+	ctx := context.Background()
+	for n > 0 {
+		cnt := n
+		if cnt > deleteExtentLimiter.Burst() {
+			cnt = deleteExtentLimiter.Burst()
+		}
+		deleteExtentLimiter.WaitN(ctx, cnt)
+		n -= cnt
+	}
+}
+
 func (m *MetaNode) startUpdateNodeInfo() {
 	ticker := time.NewTicker(UpdateNodeInfoTicket)
 	defer ticker.Stop()
@@ -72,4 +139,5 @@ func (m *MetaNode) updateNodeInfo() {
 	}
 	updateDeleteBatchCount(clusterInfo.MetaNodeDeleteBatchCount)
 	updateDeleteWorkerSleepMs(clusterInfo.MetaNodeDeleteWorkerSleepMs)
+	updateDeleteExtentLimitRate(clusterInfo.MetaNodeDeleteLimitRate)
 }
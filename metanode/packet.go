@@ -22,6 +22,7 @@ import (
 
 type Packet struct {
 	proto.Packet
+	fromNode bool // sent by a cluster node authenticated by a node token
 }
 
 // NewPacketToDeleteExtent returns a new packet to delete the extent.
@@ -62,6 +63,23 @@ func NewPacketToBatchDeleteExtent(dp *DataPartition, exts []*proto.ExtentKey) *P
 	return p
 }
 
+// NewPacketToBatchPunchExtent returns a new packet to punch holes in the extents.
+func NewPacketToBatchPunchExtent(dp *DataPartition, exts []*proto.ExtentKey) *Packet {
+	p := new(Packet)
+	p.Magic = proto.ProtoMagic
+	p.Opcode = proto.OpBatchPunchExtent
+	p.ExtentType = proto.NormalExtentType
+	p.PartitionID = uint64(dp.PartitionID)
+	p.Data, _ = json.Marshal(exts)
+	p.Size = uint32(len(p.Data))
+	p.ReqID = proto.GenerateRequestID()
+	p.RemainingFollowers = uint8(len(dp.Hosts) - 1)
+	p.Arg = ([]byte)(dp.GetAllAddrs())
+	p.ArgLen = uint32(len(p.Arg))
+
+	return p
+}
+
 // NewPacketToDeleteExtent returns a new packet to delete the extent.
 func NewPacketToFreeInodeOnRaftFollower(partitionID uint64, freeInodes []byte) *Packet {
 	p := new(Packet)
@@ -115,10 +115,14 @@ type OpInode interface {
 	CreateInodeLink(req *LinkInodeReq, p *Packet) (err error)
 	EvictInode(req *EvictInodeReq, p *Packet) (err error)
 	EvictInodeBatch(req *BatchEvictInodeReq, p *Packet) (err error)
-	SetAttr(reqData []byte, p *Packet) (err error)
+	SetAttr(req *SetattrRequest, reqData []byte, p *Packet) (err error)
 	GetInodeTree() *BTree
+	GetUsage() (usage *proto.DirStat, quotaUsage []*proto.QuotaUsage)
+	GetQuotaRoots() (roots []*proto.QuotaRoot)
+	QuotaTag(req *proto.QuotaTagRequest, p *Packet) (err error)
 	DeleteInode(req *proto.DeleteInodeRequest, p *Packet) (err error)
 	DeleteInodeBatch(req *proto.DeleteInodeBatchRequest, p *Packet) (err error)
+	CloneInode(req *CloneInoReq, p *Packet) (err error)
 }
 
 type OpExtend interface {
@@ -136,7 +140,9 @@ type OpDentry interface {
 	DeleteDentryBatch(req *BatchDeleteDentryReq, p *Packet) (err error)
 	UpdateDentry(req *UpdateDentryReq, p *Packet) (err error)
 	ReadDir(req *ReadDirReq, p *Packet) (err error)
+	ReadDirPlus(req *ReadDirReq, p *Packet) (err error)
 	Lookup(req *LookupReq, p *Packet) (err error)
+	GetDirStat(req *proto.GetDirStatRequest, p *Packet) (err error)
 	GetDentryTree() *BTree
 }
 
@@ -145,9 +151,16 @@ type OpExtent interface {
 	ExtentAppend(req *proto.AppendExtentKeyRequest, p *Packet) (err error)
 	ExtentsList(req *proto.GetExtentsRequest, p *Packet) (err error)
 	ExtentsTruncate(req *ExtentsTruncateReq, p *Packet) (err error)
+	PunchHole(req *PunchHoleReq, p *Packet) (err error)
 	BatchExtentAppend(req *proto.AppendExtentKeysRequest, p *Packet) (err error)
 }
 
+// OpLock defines the interface for the file lock operations.
+type OpLock interface {
+	SetLock(req *proto.SetLockRequest, p *Packet) (err error)
+	GetLock(req *proto.GetLockRequest, p *Packet) (err error)
+}
+
 type OpMultipart interface {
 	GetMultipart(req *proto.GetMultipartRequest, p *Packet) (err error)
 	CreateMultipart(req *proto.CreateMultipartRequest, p *Packet) (err error)
@@ -156,6 +169,13 @@ type OpMultipart interface {
 	ListMultipart(req *proto.ListMultipartRequest, p *Packet) (err error)
 }
 
+// OpTransaction defines the interface for the transactions coordinated by the clients.
+type OpTransaction interface {
+	TxBeginOp(req *proto.TxBeginRequest, p *Packet) (err error)
+	TxUpdateOp(req *proto.TxUpdateRequest, p *Packet) (err error)
+	TxFinishOp(req *proto.TxFinishRequest, p *Packet) (err error)
+}
+
 // OpMeta defines the interface for the metadata operations.
 type OpMeta interface {
 	OpInode
@@ -164,11 +184,14 @@ type OpMeta interface {
 	OpPartition
 	OpExtend
 	OpMultipart
+	OpLock
+	OpTransaction
 }
 
 // OpPartition defines the interface for the partition operations.
 type OpPartition interface {
 	IsLeader() (leaderAddr string, isLeader bool)
+	IsFollowerReadable() bool
 	GetCursor() uint64
 	GetBaseConfig() MetaPartitionConfig
 	ResponseLoadMetaPartition(p *Packet) (err error)
@@ -181,6 +204,9 @@ type OpPartition interface {
 	TryToLeader(groupID uint64) error
 	CanRemoveRaftMember(peer proto.Peer) error
 	IsEquareCreateMetaPartitionRequst(request *proto.CreateMetaPartitionRequest) (err error)
+	VerifyReplica(req *proto.MetaPartitionVerifyRequest) (resp *proto.MetaPartitionVerifyResponse, err error)
+	ReadChanges(req *proto.ReadChangesRequest, p *Packet) (err error)
+	ReadEvents(req *proto.ReadEventsRequest, p *Packet) (err error)
 }
 
 // MetaPartition defines the interface for the meta partition operations.
@@ -204,9 +230,13 @@ type metaPartition struct {
 	size                   uint64 // For partition all file size
 	applyID                uint64 // Inode/Dentry max applyID, this index will be update after restoring from the dumped data.
 	dentryTree             *BTree
-	inodeTree              *BTree // btree for inodes
-	extendTree             *BTree // btree for inode extend (XAttr) management
-	multipartTree          *BTree // collection for multipart management
+	inodeTree              *BTree     // btree for inodes
+	extendTree             *BTree     // btree for inode extend (XAttr) management
+	multipartTree          *BTree     // collection for multipart management
+	txTree                 *BTree     // collection for transaction management
+	lockTable              *LockTable // advisory file locks, only valid on the leader
+	dirStats               *dirStatCache
+	remoteViews            *remoteViews // the partitions of the volume cached for serving the requests
 	raftPartition          raftstore.Partition
 	stopC                  chan bool
 	storeChan              chan *storeMsg
@@ -214,9 +244,18 @@ type metaPartition struct {
 	delInodeFp             *os.File
 	freeList               *freeList // free inode list
 	extDelCh               chan []proto.ExtentKey
+	extPunchCh             chan []proto.ExtentKey // the released ranges of the extents to punch holes
 	extReset               chan struct{}
+	quotaTagCh             chan *quotaTagTask // the inodes to tag with their directory quotas, see tagQuota
 	vol                    *Vol
 	manager                *metadataManager
+	batcher                *submitBatcher // nil if the batching of the proposals is disabled
+	shared                 sharedInodes
+	usage                  partitionUsage // the usage in total and by the directory quotas
+	trash                  trashIndex     // the trash directories and the inodes in the trash
+	verifyResult           atomic.Value   // *proto.MetaPartitionVerifyResponse, the last verification
+	changes                *changeJournal // nil if the change journal is disabled
+	dedup                  *requestDedup  // the replies of the requests retried by the clients
 	isLoadingMetaPartition bool
 }
 
@@ -282,6 +321,9 @@ func (mp *metaPartition) onStart() (err error) {
 			mp.config.PartitionId, err.Error())
 		return
 	}
+	if size := ChangeJournalSize(); size > 0 {
+		mp.changes = newChangeJournal(size, mp.applyID)
+	}
 	mp.startSchedule(mp.applyID)
 	if err = mp.startFreeList(); err != nil {
 		err = errors.NewErrorf("[onStart] start free list id=%d: %s",
@@ -293,6 +335,9 @@ func (mp *metaPartition) onStart() (err error) {
 			mp.config.PartitionId, err.Error())
 		return
 	}
+	if mp.batcher != nil {
+		go mp.batcher.run()
+	}
 	return
 }
 
@@ -380,13 +425,23 @@ func NewMetaPartition(conf *MetaPartitionConfig, manager *metadataManager) MetaP
 		inodeTree:     NewBtree(),
 		extendTree:    NewBtree(),
 		multipartTree: NewBtree(),
+		txTree:        NewBtree(),
+		lockTable:     NewLockTable(),
+		dirStats:      newDirStatCache(),
+		remoteViews:   new(remoteViews),
 		stopC:         make(chan bool),
 		storeChan:     make(chan *storeMsg, 100),
 		freeList:      newFreeList(),
 		extDelCh:      make(chan []proto.ExtentKey, 10000),
+		extPunchCh:    make(chan []proto.ExtentKey, 10000),
 		extReset:      make(chan struct{}),
+		quotaTagCh:    make(chan *quotaTagTask, quotaTagQueueSize),
 		vol:           NewVol(),
 		manager:       manager,
+		dedup:         newRequestDedup(),
+	}
+	if manager != nil {
+		mp.batcher = newSubmitBatcher(mp, manager.submitBatch)
 	}
 	return mp
 }
@@ -446,6 +501,9 @@ func (mp *metaPartition) LoadSnapshot(snapshotPath string) (err error) {
 	if err = mp.loadMultipart(snapshotPath); err != nil {
 		return
 	}
+	if err = mp.loadTransaction(snapshotPath); err != nil {
+		return
+	}
 	err = mp.loadApplyID(snapshotPath)
 	return
 }
@@ -467,6 +525,9 @@ func (mp *metaPartition) load() (err error) {
 	if err = mp.loadMultipart(snapshotPath); err != nil {
 		return
 	}
+	if err = mp.loadTransaction(snapshotPath); err != nil {
+		return
+	}
 	err = mp.loadApplyID(snapshotPath)
 	return
 }
@@ -494,6 +555,7 @@ func (mp *metaPartition) store(sm *storeMsg) (err error) {
 		mp.storeDentry,
 		mp.storeExtend,
 		mp.storeMultipart,
+		mp.storeTransaction,
 	}
 	for _, storeFunc := range storeFuncs {
 		var crc uint32
@@ -658,7 +720,7 @@ func (mp *metaPartition) Reset() (err error) {
 	mp.applyID = 0
 
 	// remove files
-	filenames := []string{applyIDFile, dentryFile, inodeFile, extendFile, multipartFile}
+	filenames := []string{applyIDFile, dentryFile, inodeFile, extendFile, multipartFile, transactionFile}
 	for _, filename := range filenames {
 		filepath := path.Join(mp.config.RootDir, filename)
 		if err = os.Remove(filepath); err != nil {
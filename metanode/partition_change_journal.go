@@ -0,0 +1,251 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// defaultReadChangesLimit is the number of the changes returned by a read if the limit is not given.
+const defaultReadChangesLimit = 1024
+
+// changeJournal keeps the latest changes applied by a partition in memory, for the agents tailing the
+// metadata, such as the replication of the volume to a remote cluster. The journal is not persisted,
+// after a restart or a snapshot the changes before the applied index are gone and the readers have to
+// rescan the metadata, which they learn from the trimmed index.
+type changeJournal struct {
+	sync.RWMutex
+	size    int
+	changes []*proto.MetaChange
+	trimmed uint64        // the changes at and below the index are not in the journal
+	updateC chan struct{} // closed and renewed once the journal is updated, for the readers waiting
+}
+
+func newChangeJournal(size int, applyID uint64) *changeJournal {
+	return &changeJournal{size: size, trimmed: applyID, updateC: make(chan struct{})}
+}
+
+// updated returns the channel closed once the journal is updated.
+func (j *changeJournal) updated() <-chan struct{} {
+	j.RLock()
+	defer j.RUnlock()
+	return j.updateC
+}
+
+func (j *changeJournal) notify() {
+	close(j.updateC)
+	j.updateC = make(chan struct{})
+}
+
+func (j *changeJournal) append(changes ...*proto.MetaChange) {
+	j.Lock()
+	defer j.Unlock()
+	j.changes = append(j.changes, changes...)
+	if drop := len(j.changes) - j.size; drop > 0 {
+		j.trimmed = j.changes[drop-1].Index
+		j.changes = append(j.changes[:0:0], j.changes[drop:]...)
+	}
+	j.notify()
+}
+
+// reset drops the changes, as the partition is restored from a snapshot at the index.
+func (j *changeJournal) reset(index uint64) {
+	j.Lock()
+	defer j.Unlock()
+	j.changes = nil
+	j.trimmed = index
+	j.notify()
+}
+
+// read returns the changes after the index from. The changes of the same index are not split
+// by the limit, so that the reader may continue from the index of the last change.
+func (j *changeJournal) read(from uint64, limit int) (changes []*proto.MetaChange, truncated bool) {
+	j.RLock()
+	defer j.RUnlock()
+	if from < j.trimmed {
+		return nil, true
+	}
+	start := sort.Search(len(j.changes), func(i int) bool { return j.changes[i].Index > from })
+	end := start
+	for end < len(j.changes) && (end-start < limit || j.changes[end].Index == j.changes[end-1].Index) {
+		end++
+	}
+	changes = make([]*proto.MetaChange, end-start)
+	copy(changes, j.changes[start:end])
+	return
+}
+
+// ReadChanges returns the changes of the metadata applied after req.From.
+func (mp *metaPartition) ReadChanges(req *proto.ReadChangesRequest, p *Packet) (err error) {
+	if mp.changes == nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte("the change journal is disabled"))
+		return
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultReadChangesLimit
+	}
+	resp := &proto.ReadChangesResponse{ApplyID: atomic.LoadUint64(&mp.applyID)}
+	resp.Changes, resp.Truncated = mp.changes.read(req.From, limit)
+	var encoded []byte
+	if encoded, err = json.Marshal(resp); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(encoded)
+	return
+}
+
+// recordChange appends the changes made by the applied operation to the journal. The value of the
+// item is decoded again, only if the journal is enabled.
+func (mp *metaPartition) recordChange(msg *MetaItem, resp interface{}, index uint64) {
+	if mp.changes == nil {
+		return
+	}
+	now := time.Now().Unix()
+	var changes []*proto.MetaChange
+	inodeChange := func(op string, status uint8) {
+		if status != proto.OpOk {
+			return
+		}
+		ino := NewInode(0, 0)
+		if err := ino.Unmarshal(msg.V); err != nil {
+			return
+		}
+		changes = append(changes, &proto.MetaChange{Index: index, Op: op, Inode: ino.Inode, Type: ino.Type, Time: now})
+	}
+	inodeStatus := func() uint8 {
+		if r, ok := resp.(*InodeResponse); ok {
+			return r.Status
+		}
+		return proto.OpErr
+	}
+	inodeBatchChange := func(op string, results []*InodeResponse) {
+		inodes, err := InodeBatchUnmarshal(msg.V)
+		if err != nil || len(inodes) != len(results) {
+			return
+		}
+		for i, ino := range inodes {
+			if results[i].Status == proto.OpOk {
+				changes = append(changes, &proto.MetaChange{Index: index, Op: op, Inode: ino.Inode, Time: now})
+			}
+		}
+	}
+	dentryChange := func(op string, status uint8, old *Dentry) {
+		if status != proto.OpOk {
+			return
+		}
+		den := &Dentry{}
+		if err := den.Unmarshal(msg.V); err != nil {
+			return
+		}
+		change := &proto.MetaChange{Index: index, Op: op, Inode: den.Inode, ParentID: den.ParentId,
+			Name: den.Name, Type: den.Type, Time: now}
+		if old != nil {
+			if op == proto.ChangeUpdateDentry {
+				change.OldInode = old.Inode
+			} else {
+				change.Inode, change.Type = old.Inode, old.Type
+			}
+		}
+		changes = append(changes, change)
+	}
+	xattrChange := func(op string) {
+		if extend, err := NewExtendFromBytes(msg.V); err == nil {
+			changes = append(changes, &proto.MetaChange{Index: index, Op: op, Inode: extend.inode, Time: now})
+		}
+	}
+
+	switch msg.Op {
+	case opFSMCreateInode:
+		status, _ := resp.(uint8)
+		inodeChange(proto.ChangeCreateInode, status)
+	case opFSMCloneInode:
+		status, _ := resp.(uint8)
+		if status == proto.OpOk && len(msg.V) > 8 {
+			ino := NewInode(0, 0)
+			if err := ino.Unmarshal(msg.V[8:]); err == nil {
+				changes = append(changes, &proto.MetaChange{Index: index, Op: proto.ChangeCreateInode,
+					Inode: ino.Inode, Type: ino.Type, Time: now})
+			}
+		}
+	case opFSMCreateLinkInode:
+		inodeChange(proto.ChangeLinkInode, inodeStatus())
+	case opFSMUnlinkInode:
+		inodeChange(proto.ChangeUnlinkInode, inodeStatus())
+	case opFSMEvictInode:
+		inodeChange(proto.ChangeEvictInode, inodeStatus())
+	case opFSMExtentTruncate:
+		inodeChange(proto.ChangeWrite, inodeStatus())
+	case opFSMUnlinkInodeBatch:
+		results, _ := resp.([]*InodeResponse)
+		inodeBatchChange(proto.ChangeUnlinkInode, results)
+	case opFSMEvictInodeBatch:
+		results, _ := resp.([]*InodeResponse)
+		inodeBatchChange(proto.ChangeEvictInode, results)
+	case opFSMExtentsAdd:
+		status, _ := resp.(uint8)
+		inodeChange(proto.ChangeWrite, status)
+	case opFSMPunchHole:
+		if status, _ := resp.(uint8); status == proto.OpOk && len(msg.V) >= 8 {
+			changes = append(changes, &proto.MetaChange{Index: index, Op: proto.ChangeWrite,
+				Inode: binary.BigEndian.Uint64(msg.V), Time: now})
+		}
+	case opFSMSetAttr:
+		req := &SetattrRequest{}
+		if err := json.Unmarshal(msg.V, req); err == nil {
+			changes = append(changes, &proto.MetaChange{Index: index, Op: proto.ChangeSetAttr, Inode: req.Inode, Time: now})
+		}
+	case opFSMSetXAttr:
+		xattrChange(proto.ChangeSetXAttr)
+	case opFSMSetXAttrFlag:
+		if status, _ := resp.(uint8); status == proto.OpOk && len(msg.V) > 4 {
+			if extend, err := NewExtendFromBytes(msg.V[4:]); err == nil {
+				changes = append(changes, &proto.MetaChange{Index: index, Op: proto.ChangeSetXAttr, Inode: extend.inode, Time: now})
+			}
+		}
+	case opFSMRemoveXAttr:
+		xattrChange(proto.ChangeRemoveXAttr)
+	case opFSMCreateDentry:
+		status, _ := resp.(uint8)
+		dentryChange(proto.ChangeCreateDentry, status, nil)
+	case opFSMDeleteDentry:
+		if r, ok := resp.(*DentryResponse); ok {
+			dentryChange(proto.ChangeDeleteDentry, r.Status, r.Msg)
+		}
+	case opFSMUpdateDentry:
+		if r, ok := resp.(*DentryResponse); ok {
+			dentryChange(proto.ChangeUpdateDentry, r.Status, r.Msg)
+		}
+	case opFSMDeleteDentryBatch:
+		results, _ := resp.([]*DentryResponse)
+		for _, r := range results {
+			if r.Status == proto.OpOk && r.Msg != nil {
+				changes = append(changes, &proto.MetaChange{Index: index, Op: proto.ChangeDeleteDentry, Inode: r.Msg.Inode,
+					ParentID: r.Msg.ParentId, Name: r.Msg.Name, Type: r.Msg.Type, Time: now})
+			}
+		}
+	}
+	if len(changes) > 0 {
+		mp.changes.append(changes...)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestChangeJournal(t *testing.T) {
+	mp := &metaPartition{
+		config:     &MetaPartitionConfig{PartitionId: 1},
+		inodeTree:  NewBtree(),
+		dentryTree: NewBtree(),
+		changes:    newChangeJournal(4, 10),
+	}
+	mp.inodeTree.ReplaceOrInsert(NewInode(1, proto.Mode(os.ModeDir|0755)), true)
+	apply := func(op uint32, dentry *Dentry, index uint64) {
+		data, err := dentry.Marshal()
+		if err != nil {
+			t.Fatalf("marshal dentry: %v", err)
+		}
+		cmd, err := NewMetaItem(op, nil, data).MarshalJson()
+		if err != nil {
+			t.Fatalf("marshal command: %v", err)
+		}
+		if _, err = mp.Apply(cmd, index); err != nil {
+			t.Fatalf("apply: %v", err)
+		}
+	}
+	apply(opFSMCreateDentry, &Dentry{ParentId: 1, Name: "a", Inode: 100, Type: proto.Mode(0644)}, 11)
+	apply(opFSMCreateDentry, &Dentry{ParentId: 1, Name: "b", Inode: 101, Type: proto.Mode(0644)}, 12)
+	apply(opFSMUpdateDentry, &Dentry{ParentId: 1, Name: "b", Inode: 102}, 13)
+	apply(opFSMDeleteDentry, &Dentry{ParentId: 1, Name: "a"}, 14)
+	apply(opFSMDeleteDentry, &Dentry{ParentId: 1, Name: "c"}, 15)
+
+	changes, truncated := mp.changes.read(10, 10)
+	if truncated || len(changes) != 4 {
+		t.Fatalf("read changes expect(4) actual(%v) truncated(%v)", len(changes), truncated)
+	}
+	if c := changes[2]; c.Op != proto.ChangeUpdateDentry || c.Inode != 102 || c.OldInode != 101 {
+		t.Fatalf("update dentry change(%v)", c)
+	}
+	if c := changes[3]; c.Op != proto.ChangeDeleteDentry || c.Inode != 100 || c.Name != "a" || c.Index != 14 {
+		t.Fatalf("delete dentry change(%v)", c)
+	}
+	if changes, _ = mp.changes.read(12, 10); len(changes) != 2 || changes[0].Index != 13 {
+		t.Fatalf("read changes after 12: %v", changes)
+	}
+
+	mp.changes.append(&proto.MetaChange{Index: 16, Op: proto.ChangeWrite, Inode: 100},
+		&proto.MetaChange{Index: 16, Op: proto.ChangeWrite, Inode: 102})
+	if changes, _ = mp.changes.read(14, 1); len(changes) != 2 {
+		t.Fatalf("the changes of the same index are split: %v", changes)
+	}
+	if _, truncated = mp.changes.read(10, 10); !truncated {
+		t.Fatalf("the trimmed changes are not reported")
+	}
+	if changes, truncated = mp.changes.read(12, 10); truncated || len(changes) != 4 {
+		t.Fatalf("read changes after 12 expect(4) actual(%v) truncated(%v)", len(changes), truncated)
+	}
+}
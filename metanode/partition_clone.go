@@ -0,0 +1,240 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/storage"
+)
+
+// CloneInode creates an inode sharing the extents of the file, so a file is copied without copying the data.
+// The extents are copied when the clone is applied, and both inodes are marked with SharedExtentsXAttr,
+// so the clients write them copy-on-write and the extents are only deleted once no inode refers to them.
+func (mp *metaPartition) CloneInode(req *CloneInoReq, p *Packet) (err error) {
+	inoID, err := mp.nextInodeID()
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpInodeFullErr, []byte(err.Error()))
+		return
+	}
+	ino := NewInode(inoID, 0)
+	ino.Uid = req.Uid
+	ino.Gid = req.Gid
+	data, err := ino.Marshal()
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	val := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint64(val, req.Inode)
+	val = append(val, data...)
+	resp, err := mp.submit(opFSMCloneInode, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	var (
+		status = resp.(uint8)
+		reply  []byte
+	)
+	if status == proto.OpOk {
+		status = proto.OpNotExistErr
+		if item := mp.inodeTree.Get(ino); item != nil {
+			resp := &CloneInoResp{Info: &proto.InodeInfo{}}
+			if replyInfo(resp.Info, item.(*Inode)) {
+				status = proto.OpOk
+				if reply, err = json.Marshal(resp); err != nil {
+					status = proto.OpErr
+					reply = []byte(err.Error())
+				}
+			}
+		}
+	}
+	p.PacketErrorWithBody(status, reply)
+	return
+}
+
+// fsmCloneInode creates the inode with the type, the size and the extents of the source file.
+func (mp *metaPartition) fsmCloneInode(src uint64, ino *Inode) (status uint8) {
+	item := mp.inodeTree.CopyGet(NewInode(src, 0))
+	if item == nil {
+		return proto.OpNotExistErr
+	}
+	srcIno := item.(*Inode)
+	if srcIno.ShouldDelete() {
+		return proto.OpNotExistErr
+	}
+	if !proto.IsRegular(srcIno.Type) {
+		return proto.OpArgMismatchErr
+	}
+	srcIno.DoReadFunc(func() {
+		ino.Type = srcIno.Type
+		ino.Size = srcIno.Size
+		ino.Extents = srcIno.Extents.Clone()
+	})
+	if status = mp.fsmCreateInode(ino); status != proto.OpOk {
+		return
+	}
+	for _, inode := range []uint64{src, ino.Inode} {
+		extend := NewExtend(inode)
+		extend.Put([]byte(proto.SharedExtentsXAttr), []byte("1"))
+		mp.fsmSetXAttr(extend)
+	}
+	// the generation tells the clients caching the extents of the source that it is changed
+	srcIno.Lock()
+	srcIno.Generation++
+	srcIno.Unlock()
+	mp.addSharedInodes(srcIno, ino)
+	return
+}
+
+// sharedInodes tracks the inodes of the partition marked with SharedExtentsXAttr, and counts
+// the references of them to each extent, so that an extent referred by any of them is not deleted.
+// They are loaded from the trees on the first use, and kept up to date by the operations changing
+// the extents of the inodes, the clones, the evictions and the deletions of them.
+type sharedInodes struct {
+	sync.Mutex
+	inodes  map[uint64][]sharedExtentKey // the extents referred by each shared inode, none once it is being deleted
+	extents map[sharedExtentKey]int      // the number of the references of the shared inodes to each extent
+}
+
+// sharedExtentKey identifies an extent, or a range of a tiny extent which is shared by the files as well.
+type sharedExtentKey struct {
+	partitionID uint64
+	extentID    uint64
+	offset      uint64
+}
+
+func newSharedExtentKey(ek *proto.ExtentKey) (key sharedExtentKey) {
+	key.partitionID, key.extentID = ek.PartitionId, ek.ExtentId
+	if storage.IsTinyExtent(ek.ExtentId) {
+		key.offset = ek.ExtentOffset
+	}
+	return
+}
+
+// sharedExtentKeys returns the extents referred by the inode, none if it is being deleted.
+func sharedExtentKeys(inode *Inode) (keys []sharedExtentKey) {
+	if inode.ShouldDelete() {
+		return nil
+	}
+	inode.DoReadFunc(func() {
+		inode.Extents.Range(func(ek proto.ExtentKey) bool {
+			keys = append(keys, newSharedExtentKey(&ek))
+			return true
+		})
+	})
+	return
+}
+
+// set replaces the references of the inode with the keys, with the lock held.
+func (s *sharedInodes) set(ino uint64, keys []sharedExtentKey) {
+	for _, key := range s.inodes[ino] {
+		if s.extents[key]--; s.extents[key] <= 0 {
+			delete(s.extents, key)
+		}
+	}
+	s.inodes[ino] = keys
+	for _, key := range keys {
+		s.extents[key]++
+	}
+}
+
+// reset drops the loaded inodes, e.g. after the trees are replaced by a snapshot.
+func (s *sharedInodes) reset() {
+	s.Lock()
+	s.inodes, s.extents = nil, nil
+	s.Unlock()
+}
+
+// loadSharedInodes loads the shared inodes from the trees if they are not loaded, with the lock held.
+func (mp *metaPartition) loadSharedInodes() {
+	if mp.shared.inodes != nil {
+		return
+	}
+	mp.shared.inodes = make(map[uint64][]sharedExtentKey)
+	mp.shared.extents = make(map[sharedExtentKey]int)
+	mp.extendTree.GetTree().Ascend(func(i BtreeItem) bool {
+		extend := i.(*Extend)
+		if _, ok := extend.Get([]byte(proto.SharedExtentsXAttr)); !ok {
+			return true
+		}
+		if item := mp.inodeTree.Get(NewInode(extend.inode, 0)); item != nil {
+			mp.shared.set(extend.inode, sharedExtentKeys(item.(*Inode)))
+		}
+		return true
+	})
+}
+
+// addSharedInodes counts the references of the inodes just marked shared.
+func (mp *metaPartition) addSharedInodes(inodes ...*Inode) {
+	mp.shared.Lock()
+	defer mp.shared.Unlock()
+	if mp.shared.inodes == nil {
+		// loaded from the trees later, which have the marks and the extents of these inodes
+		return
+	}
+	for _, inode := range inodes {
+		mp.shared.set(inode.Inode, sharedExtentKeys(inode))
+	}
+}
+
+// updateSharedInode counts the references of the inode again if it is shared,
+// after the extents of it are changed or it is marked to be deleted.
+func (mp *metaPartition) updateSharedInode(inode *Inode) {
+	mp.shared.Lock()
+	defer mp.shared.Unlock()
+	if _, ok := mp.shared.inodes[inode.Inode]; ok {
+		mp.shared.set(inode.Inode, sharedExtentKeys(inode))
+	}
+}
+
+// removeSharedInode drops the references of the inode deleted.
+func (mp *metaPartition) removeSharedInode(ino uint64) {
+	mp.shared.Lock()
+	defer mp.shared.Unlock()
+	if _, ok := mp.shared.inodes[ino]; ok {
+		mp.shared.set(ino, nil)
+		delete(mp.shared.inodes, ino)
+	}
+}
+
+func (mp *metaPartition) isSharedInode(ino uint64) bool {
+	mp.shared.Lock()
+	defer mp.shared.Unlock()
+	mp.loadSharedInodes()
+	_, ok := mp.shared.inodes[ino]
+	return ok
+}
+
+// excludeSharedExtents returns the extents to be deleted which are not referred by the shared inodes.
+func (mp *metaPartition) excludeSharedExtents(eks []*proto.ExtentKey) []*proto.ExtentKey {
+	mp.shared.Lock()
+	defer mp.shared.Unlock()
+	mp.loadSharedInodes()
+	if len(mp.shared.extents) == 0 {
+		return eks
+	}
+	result := make([]*proto.ExtentKey, 0, len(eks))
+	for _, ek := range eks {
+		if mp.shared.extents[newSharedExtentKey(ek)] == 0 {
+			result = append(result, ek)
+		}
+	}
+	return result
+}
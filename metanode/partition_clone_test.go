@@ -0,0 +1,110 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestCloneInodeSharedExtents(t *testing.T) {
+	mp := &metaPartition{
+		config:     &MetaPartitionConfig{PartitionId: 1},
+		inodeTree:  NewBtree(),
+		extendTree: NewBtree(),
+	}
+	src := NewInode(10, proto.Mode(0644))
+	src.Size = 8192
+	src.Extents.Append(proto.ExtentKey{FileOffset: 0, PartitionId: 1, ExtentId: 1, ExtentOffset: 100, Size: 4096})
+	src.Extents.Append(proto.ExtentKey{FileOffset: 4096, PartitionId: 1, ExtentId: 1025, Size: 4096})
+	mp.inodeTree.ReplaceOrInsert(src, true)
+	mp.inodeTree.ReplaceOrInsert(NewInode(20, proto.Mode(os.ModeDir|0755)), true)
+
+	clone := func(src, ino uint64) interface{} {
+		data, err := NewInode(ino, 0).Marshal()
+		if err != nil {
+			t.Fatalf("marshal inode: %v", err)
+		}
+		val := make([]byte, 8, 8+len(data))
+		binary.BigEndian.PutUint64(val, src)
+		cmd, err := NewMetaItem(opFSMCloneInode, nil, append(val, data...)).MarshalJson()
+		if err != nil {
+			t.Fatalf("marshal command: %v", err)
+		}
+		resp, err := mp.Apply(cmd, 100)
+		if err != nil {
+			t.Fatalf("apply clone: %v", err)
+		}
+		return resp
+	}
+	// loaded before the clone, so the references of the clone are counted on the way
+	if mp.isSharedInode(10) {
+		t.Fatalf("inode(10) should not be shared before the clone")
+	}
+	generation := src.Generation
+	if status := clone(20, 21); status != proto.OpArgMismatchErr {
+		t.Fatalf("clone a directory expect(%v) actual(%v)", proto.OpArgMismatchErr, status)
+	}
+	if status := clone(10, 11); status != proto.OpOk {
+		t.Fatalf("clone the file expect(%v) actual(%v)", proto.OpOk, status)
+	}
+	if mp.config.Cursor != 21 {
+		t.Fatalf("cursor expect(21) actual(%v)", mp.config.Cursor)
+	}
+	if src.Generation != generation+1 {
+		t.Fatalf("generation of the source expect(%v) actual(%v)", generation+1, src.Generation)
+	}
+
+	ino := mp.inodeTree.Get(NewInode(11, 0)).(*Inode)
+	if !proto.IsRegular(ino.Type) || ino.Size != src.Size || ino.Extents.Size() != src.Extents.Size() {
+		t.Fatalf("cloned inode(%v) source inode(%v)", ino, src)
+	}
+	for _, inode := range []uint64{10, 11} {
+		if !mp.isSharedInode(inode) {
+			t.Fatalf("inode(%v) should be shared", inode)
+		}
+	}
+
+	eks := []*proto.ExtentKey{
+		{PartitionId: 1, ExtentId: 1, ExtentOffset: 100, Size: 4096},
+		{PartitionId: 1, ExtentId: 1, ExtentOffset: 4196, Size: 4096},
+		{PartitionId: 1, ExtentId: 1025, Size: 8192},
+		{PartitionId: 2, ExtentId: 1025, Size: 4096},
+	}
+	check := func(expect int) {
+		if result := mp.excludeSharedExtents(eks); len(result) != expect {
+			t.Fatalf("extents to delete expect(%v) actual(%v)", expect, result)
+		}
+	}
+	check(2)
+	// the clone truncated still refers the first extent through the source
+	ino.ExtentsTruncate(0, 0)
+	mp.updateSharedInode(ino)
+	check(2)
+	src.SetDeleteMark()
+	mp.updateSharedInode(src)
+	check(len(eks))
+
+	// reloaded from the trees after a reset
+	mp.shared.reset()
+	check(len(eks))
+	mp.removeSharedInode(10)
+	if mp.isSharedInode(10) || !mp.isSharedInode(11) {
+		t.Fatalf("inode(10) should be removed from the shared inodes only")
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// The replies of the requests are kept for the window after the requests are done, which is longer than
+// the time the clients retry a request for.
+const requestDedupWindow = time.Minute
+
+type requestKey struct {
+	clientID uint64
+	reqID    int64
+}
+
+type requestRecord struct {
+	done   bool
+	result uint8
+	reply  []byte
+	expire time.Time
+}
+
+// requestDedup deduplicates the requests retried by the clients after timeouts, so that a retried request
+// which has been done gets the reply of the first try instead of being done again, such as a spurious
+// EEXIST of creating a dentry or unlinking an inode twice. The requests are told apart by the client id in
+// the request and the id of the packet, which is kept by the retries of the client. The replies are kept in
+// the memory of the leader only, so the requests retried after the leader changes are done again as before.
+type requestDedup struct {
+	sync.Mutex
+	records   map[requestKey]*requestRecord
+	lastPurge time.Time
+}
+
+func newRequestDedup() *requestDedup {
+	return &requestDedup{records: make(map[requestKey]*requestRecord), lastPurge: time.Now()}
+}
+
+// begin starts the request, and returns true if the request is a retry which has been replied by the packet.
+// A retry of a request still being done is replied with OpAgain, which the client retries later.
+func (d *requestDedup) begin(clientID uint64, p *Packet) bool {
+	if d == nil || clientID == 0 {
+		return false
+	}
+	key := requestKey{clientID: clientID, reqID: p.ReqID}
+	now := time.Now()
+	d.Lock()
+	defer d.Unlock()
+	if now.Sub(d.lastPurge) > requestDedupWindow {
+		for k, r := range d.records {
+			if r.done && now.After(r.expire) {
+				delete(d.records, k)
+			}
+		}
+		d.lastPurge = now
+	}
+	if r, ok := d.records[key]; ok {
+		if !r.done {
+			p.PacketErrorWithBody(proto.OpAgain, []byte("request in progress"))
+		} else {
+			p.PacketErrorWithBody(r.result, r.reply)
+		}
+		return true
+	}
+	d.records[key] = &requestRecord{}
+	return false
+}
+
+// end records the reply of the request begun. The request failing to be done, which the client retries,
+// is forgotten so that the retries do it again.
+func (d *requestDedup) end(clientID uint64, p *Packet) {
+	if d == nil || clientID == 0 {
+		return
+	}
+	key := requestKey{clientID: clientID, reqID: p.ReqID}
+	d.Lock()
+	defer d.Unlock()
+	if p.ShouldRetry() {
+		delete(d.records, key)
+		return
+	}
+	d.records[key] = &requestRecord{
+		done:   true,
+		result: p.ResultCode,
+		reply:  p.Data,
+		expire: time.Now().Add(requestDedupWindow),
+	}
+}
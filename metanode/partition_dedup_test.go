@@ -0,0 +1,64 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestRequestDedup(t *testing.T) {
+	d := newRequestDedup()
+	newPacket := func(reqID int64) *Packet {
+		p := &Packet{}
+		p.ReqID = reqID
+		return p
+	}
+
+	// the requests without the client id are never deduplicated
+	if d.begin(0, newPacket(1)) || d.begin(0, newPacket(1)) {
+		t.Fatalf("request without client id deduplicated")
+	}
+
+	p := newPacket(1)
+	if d.begin(7, p) {
+		t.Fatalf("first try deduplicated")
+	}
+	retry := newPacket(1)
+	if !d.begin(7, retry) || retry.ResultCode != proto.OpAgain {
+		t.Fatalf("retry in progress expect(%v) actual(%v)", proto.OpAgain, retry.ResultCode)
+	}
+	p.PacketOkWithBody([]byte("reply"))
+	d.end(7, p)
+
+	retry = newPacket(1)
+	if !d.begin(7, retry) || retry.ResultCode != proto.OpOk || !bytes.Equal(retry.Data, []byte("reply")) {
+		t.Fatalf("retry done expect the first reply: result(%v) data(%s)", retry.ResultCode, retry.Data)
+	}
+	if d.begin(8, newPacket(1)) {
+		t.Fatalf("request of another client deduplicated")
+	}
+
+	// the request failing to be done is done again by the retry
+	p = newPacket(2)
+	d.begin(7, p)
+	p.PacketErrorWithBody(proto.OpAgain, nil)
+	d.end(7, p)
+	if d.begin(7, newPacket(2)) {
+		t.Fatalf("retry of the failed request deduplicated")
+	}
+}
@@ -349,6 +349,7 @@ func (mp *metaPartition) checkBatchDeleteExtents(allExtents map[uint64][]*proto.
 			mp.extDelCh <- needDeleteExtents
 		}
 		DeleteWorkerSleepMs()
+		mp.delayBackground()
 	}
 	return
 }
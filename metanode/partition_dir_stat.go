@@ -0,0 +1,192 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	// DirStatTTL is how long the summary of a directory is served before it is computed again.
+	DirStatTTL = 5 * time.Minute
+	// dirStatBatchSize is the max number of inodes got from a partition in a request.
+	dirStatBatchSize = 1000
+)
+
+// dirStatCache keeps the summaries of the directories computed by the partition.
+// The summaries only live in the memory and are computed lazily on the requests,
+// so a summary may be DirStatTTL behind the changes of the directory.
+type dirStatCache struct {
+	sync.Mutex
+	summaries map[uint64]*proto.DirSummary
+}
+
+func newDirStatCache() *dirStatCache {
+	return &dirStatCache{
+		summaries: make(map[uint64]*proto.DirSummary),
+	}
+}
+
+func (c *dirStatCache) get(ino uint64, now time.Time) *proto.DirSummary {
+	c.Lock()
+	defer c.Unlock()
+	summary, ok := c.summaries[ino]
+	if !ok || now.Sub(time.Unix(summary.StatTime, 0)) > DirStatTTL {
+		return nil
+	}
+	return summary
+}
+
+func (c *dirStatCache) put(summary *proto.DirSummary) {
+	c.Lock()
+	c.summaries[summary.Inode] = summary
+	c.Unlock()
+}
+
+// evict removes the expired summaries.
+func (c *dirStatCache) evict(now time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	for ino, summary := range c.summaries {
+		if now.Sub(time.Unix(summary.StatTime, 0)) > DirStatTTL {
+			delete(c.summaries, ino)
+		}
+	}
+}
+
+func (mp *metaPartition) dirStatWorker() {
+	t := time.NewTicker(DirStatTTL)
+	defer t.Stop()
+	for {
+		select {
+		case <-mp.stopC:
+			log.LogDebugf("[metaPartition] dirStatWorker stop partition: %v", mp.config.PartitionId)
+			return
+		case now := <-t.C:
+			mp.dirStats.evict(now)
+		}
+	}
+}
+
+// GetDirStat returns the summaries of the directories held by the partition,
+// the directories which do not exist are left out of the response.
+func (mp *metaPartition) GetDirStat(req *proto.GetDirStatRequest, p *Packet) (err error) {
+	response := &proto.GetDirStatResponse{
+		Summaries: make([]*proto.DirSummary, 0, len(req.Inodes)),
+	}
+	now := time.Now()
+	for _, ino := range req.Inodes {
+		summary := mp.dirStats.get(ino, now)
+		if summary == nil {
+			if mp.inodeTree.Get(NewInode(ino, 0)) == nil {
+				continue
+			}
+			if summary, err = mp.summarizeDir(ino, now); err != nil {
+				p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+				return
+			}
+			mp.dirStats.put(summary)
+		}
+		response.Summaries = append(response.Summaries, summary)
+	}
+	var encoded []byte
+	if encoded, err = json.Marshal(response); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(encoded)
+	return
+}
+
+// summarizeDir counts the direct children of the directory. The dentries of a directory
+// live in the partition holding the directory, while the inodes of the files may live
+// in other partitions, whose sizes are got through the leaders of those partitions.
+func (mp *metaPartition) summarizeDir(dir uint64, now time.Time) (summary *proto.DirSummary, err error) {
+	summary = &proto.DirSummary{
+		Inode:    dir,
+		SubDirs:  make([]uint64, 0),
+		StatTime: now.Unix(),
+	}
+	var files []uint64
+	begin := &Dentry{ParentId: dir}
+	end := &Dentry{ParentId: dir + 1}
+	mp.dentryTree.GetTree().AscendRange(begin, end, func(i BtreeItem) bool {
+		den := i.(*Dentry)
+		if proto.IsDir(den.Type) {
+			summary.Stat.Dirs++
+			summary.SubDirs = append(summary.SubDirs, den.Inode)
+			return true
+		}
+		summary.Stat.Files++
+		if proto.IsRegular(den.Type) {
+			files = append(files, den.Inode)
+		}
+		return true
+	})
+
+	var remote []uint64
+	for _, ino := range files {
+		item := mp.inodeTree.Get(NewInode(ino, 0))
+		if item == nil {
+			remote = append(remote, ino)
+			continue
+		}
+		inode := item.(*Inode)
+		inode.RLock()
+		summary.Stat.Bytes += inode.Size
+		inode.RUnlock()
+	}
+	if len(remote) == 0 {
+		return
+	}
+
+	var views []*proto.MetaPartitionView
+	if views, err = mp.remoteViews.get(mp.config.VolName); err != nil {
+		return
+	}
+	var batches = make(map[*proto.MetaPartitionView][]uint64)
+	for _, ino := range remote {
+		view, e := findPartitionView(views, ino)
+		if e != nil {
+			log.LogWarnf("summarizeDir: partition(%v) dir(%v) ino(%v) err(%v)", mp.config.PartitionId, dir, ino, e)
+			continue
+		}
+		batches[view] = append(batches[view], ino)
+	}
+	for view, inodes := range batches {
+		for len(inodes) > 0 {
+			n := len(inodes)
+			if n > dirStatBatchSize {
+				n = dirStatBatchSize
+			}
+			var infos []*proto.InodeInfo
+			if infos, err = mp.batchGetRemoteInodes(view, inodes[:n]); err != nil {
+				log.LogWarnf("summarizeDir: get inodes fail: partition(%v) dir(%v) target(%v) err(%v)",
+					mp.config.PartitionId, dir, view.PartitionID, err)
+				return
+			}
+			inodes = inodes[n:]
+			for _, info := range infos {
+				summary.Stat.Bytes += info.Size
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,77 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestGetDirStat(t *testing.T) {
+	mp := &metaPartition{
+		config:     &MetaPartitionConfig{PartitionId: 1, Start: 1, End: 100},
+		inodeTree:  NewBtree(),
+		dentryTree: NewBtree(),
+		dirStats:   newDirStatCache(),
+	}
+	dirMode, fileMode := uint32(os.ModeDir|0755), uint32(0644)
+	for _, ino := range []*Inode{NewInode(1, dirMode), NewInode(2, fileMode), NewInode(3, fileMode), NewInode(4, dirMode)} {
+		ino.Size = ino.Inode * 10
+		mp.inodeTree.ReplaceOrInsert(ino, true)
+	}
+	for _, den := range []*Dentry{
+		{ParentId: 1, Name: "a", Inode: 2, Type: fileMode},
+		{ParentId: 1, Name: "b", Inode: 3, Type: fileMode},
+		{ParentId: 1, Name: "c", Inode: 4, Type: dirMode},
+		{ParentId: 4, Name: "d", Inode: 2, Type: fileMode},
+	} {
+		mp.dentryTree.ReplaceOrInsert(den, true)
+	}
+
+	p := &Packet{}
+	if err := mp.GetDirStat(&proto.GetDirStatRequest{Inodes: []uint64{1, 4, 5}}, p); err != nil {
+		t.Fatalf("get dir stat fail: err(%v)", err)
+	}
+	resp := new(proto.GetDirStatResponse)
+	if err := json.Unmarshal(p.Data, resp); err != nil {
+		t.Fatalf("unmarshal response fail: err(%v)", err)
+	}
+	if len(resp.Summaries) != 2 {
+		t.Fatalf("summaries of the existing dirs expect(2) actual(%v)", len(resp.Summaries))
+	}
+	expects := []proto.DirStat{{Files: 2, Dirs: 1, Bytes: 50}, {Files: 1, Bytes: 20}}
+	for i, summary := range resp.Summaries {
+		if summary.Stat != expects[i] {
+			t.Fatalf("dir(%v) stat mismatch: expect(%v) actual(%v)", summary.Inode, expects[i], summary.Stat)
+		}
+	}
+	if !reflect.DeepEqual(resp.Summaries[0].SubDirs, []uint64{4}) {
+		t.Fatalf("dir(1) subdirs mismatch: actual(%v)", resp.Summaries[0].SubDirs)
+	}
+
+	// the summary is reused until it expires
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 4, Name: "e", Inode: 3, Type: fileMode}, true)
+	p = &Packet{}
+	if err := mp.GetDirStat(&proto.GetDirStatRequest{Inodes: []uint64{4}}, p); err != nil {
+		t.Fatalf("get dir stat fail: err(%v)", err)
+	}
+	if err := json.Unmarshal(p.Data, resp); err != nil || resp.Summaries[0].Stat.Files != 1 {
+		t.Fatalf("cached summary expected: resp(%v) err(%v)", resp.Summaries[0], err)
+	}
+}
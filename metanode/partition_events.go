@@ -0,0 +1,172 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+const (
+	// the changes are turned into the events once they are older than this, so that the dentry changes
+	// of a rename are paired and the writes to a file are coalesced. Unit: second
+	eventSettleTime = 2
+	// the longest wait of a read of the events over the packets, which must be within the read deadline
+	maxReadEventsWait = 3
+	// the longest wait of a read of the events over HTTP, see getEventsHandler
+	maxPollEventsWait = 60
+)
+
+// events turns the changes after the index from into the events of the volume. It returns the index
+// the reader continues from, which stops at the changes not settled yet.
+//
+// The events are derived from the changes of a single partition only: a dentry created and then deleted
+// for the same inode within the settle time is reported as a rename, so is a dentry updated. A write is
+// reported as write-close if no other write to the file follows within the settle time.
+// The renames across the partitions are reported as a create and a delete.
+func (j *changeJournal) events(from uint64, limit int, now int64) (events []*proto.VolumeEvent, cursor uint64, truncated bool) {
+	j.RLock()
+	defer j.RUnlock()
+	cursor = from
+	if from < j.trimmed {
+		return nil, cursor, true
+	}
+	start := sort.Search(len(j.changes), func(i int) bool { return j.changes[i].Index > from })
+	if start == len(j.changes) {
+		return
+	}
+	// look back for the dentries created before the index from, which are paired with the deletions after it
+	lookback := start
+	for lookback > 0 && j.changes[start].Time-j.changes[lookback-1].Time <= eventSettleTime {
+		lookback--
+	}
+	window := j.changes[lookback:]
+
+	renames := make(map[*proto.MetaChange]*proto.MetaChange) // the created dentry to the deleted one
+	renamed := make(map[*proto.MetaChange]bool)
+	created := make(map[uint64]*proto.MetaChange)
+	for _, c := range window {
+		switch c.Op {
+		case proto.ChangeCreateDentry, proto.ChangeUpdateDentry:
+			created[c.Inode] = c
+		case proto.ChangeDeleteDentry:
+			cr, ok := created[c.Inode]
+			if !ok || c.Time-cr.Time > eventSettleTime || (cr.ParentID == c.ParentID && cr.Name == c.Name) {
+				continue
+			}
+			renames[cr] = c
+			renamed[c] = true
+			delete(created, c.Inode)
+		}
+	}
+	writing := make(map[*proto.MetaChange]bool) // the writes followed by the others to the same file
+	nextWrite := make(map[uint64]int64)
+	for i := len(window) - 1; i >= 0; i-- {
+		c := window[i]
+		if c.Op != proto.ChangeWrite {
+			continue
+		}
+		if t, ok := nextWrite[c.Inode]; ok && t-c.Time <= eventSettleTime {
+			writing[c] = true
+		}
+		nextWrite[c.Inode] = c.Time
+	}
+
+	for i := start; i < len(j.changes); i++ {
+		c := j.changes[i]
+		if c.Time > now-eventSettleTime {
+			break
+		}
+		if len(events) >= limit && c.Index != cursor {
+			break
+		}
+		cursor = c.Index
+		event := &proto.VolumeEvent{Index: c.Index, Inode: c.Inode, Mode: c.Type, ParentID: c.ParentID, Name: c.Name, Time: c.Time}
+		switch c.Op {
+		case proto.ChangeCreateDentry, proto.ChangeUpdateDentry:
+			event.Type = proto.EventCreate
+			if del, ok := renames[c]; ok {
+				event.Type = proto.EventRename
+				event.OldParentID, event.OldName = del.ParentID, del.Name
+			}
+		case proto.ChangeDeleteDentry:
+			if renamed[c] {
+				continue
+			}
+			event.Type = proto.EventDelete
+		case proto.ChangeWrite:
+			if writing[c] {
+				continue
+			}
+			event.Type = proto.EventWriteClose
+		default:
+			continue
+		}
+		events = append(events, event)
+	}
+	return
+}
+
+// readEvents reads the events after req.From, and waits for them at most for the duration wait.
+func (mp *metaPartition) readEvents(req *proto.ReadEventsRequest, wait time.Duration) (resp *proto.ReadEventsResponse) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultReadChangesLimit
+	}
+	deadline := time.Now().Add(wait)
+	resp = &proto.ReadEventsResponse{}
+	for {
+		updated := mp.changes.updated()
+		now := time.Now()
+		resp.Events, resp.Cursor, resp.Truncated = mp.changes.events(req.From, limit, now.Unix())
+		if len(resp.Events) > 0 || resp.Truncated || !now.Before(deadline) {
+			return
+		}
+		// the changes settle as time goes by, so check again in a second even if nothing is applied
+		timeout := deadline.Sub(now)
+		if timeout > time.Second {
+			timeout = time.Second
+		}
+		timer := time.NewTimer(timeout)
+		select {
+		case <-updated:
+		case <-timer.C:
+		case <-mp.stopC:
+			timer.Stop()
+			return
+		}
+		timer.Stop()
+	}
+}
+
+// ReadEvents returns the events of the volume derived from the changes applied after req.From,
+// the caller bounds req.Wait.
+func (mp *metaPartition) ReadEvents(req *proto.ReadEventsRequest, p *Packet) (err error) {
+	if mp.changes == nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte("the change journal is disabled"))
+		return
+	}
+	resp := mp.readEvents(req, time.Duration(req.Wait)*time.Second)
+	var encoded []byte
+	if encoded, err = json.Marshal(resp); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(encoded)
+	return
+}
@@ -0,0 +1,90 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestChangeJournalEvents(t *testing.T) {
+	j := newChangeJournal(100, 0)
+	j.append(
+		&proto.MetaChange{Index: 1, Op: proto.ChangeCreateInode, Inode: 100, Time: 100},
+		&proto.MetaChange{Index: 2, Op: proto.ChangeCreateDentry, Inode: 100, ParentID: 1, Name: "a", Time: 100},
+		&proto.MetaChange{Index: 3, Op: proto.ChangeWrite, Inode: 100, Time: 101},
+		&proto.MetaChange{Index: 4, Op: proto.ChangeWrite, Inode: 100, Time: 102},
+		// rename a to b
+		&proto.MetaChange{Index: 5, Op: proto.ChangeCreateDentry, Inode: 100, ParentID: 1, Name: "b", Time: 103},
+		&proto.MetaChange{Index: 6, Op: proto.ChangeDeleteDentry, Inode: 100, ParentID: 1, Name: "a", Time: 103},
+		&proto.MetaChange{Index: 7, Op: proto.ChangeDeleteDentry, Inode: 100, ParentID: 1, Name: "b", Time: 110},
+		&proto.MetaChange{Index: 8, Op: proto.ChangeWrite, Inode: 101, Time: 120},
+	)
+
+	events, cursor, truncated := j.events(0, 100, 115)
+	if truncated || cursor != 7 {
+		t.Fatalf("events: cursor(%v) truncated(%v)", cursor, truncated)
+	}
+	expected := []proto.VolumeEvent{
+		{Index: 2, Type: proto.EventCreate, Inode: 100, ParentID: 1, Name: "a"},
+		{Index: 4, Type: proto.EventWriteClose, Inode: 100},
+		{Index: 5, Type: proto.EventRename, Inode: 100, ParentID: 1, Name: "b", OldParentID: 1, OldName: "a"},
+		{Index: 7, Type: proto.EventDelete, Inode: 100, ParentID: 1, Name: "b"},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("events expect(%v) actual(%v)", len(expected), len(events))
+	}
+	for i, e := range events {
+		e.Time = 0
+		if *e != expected[i] {
+			t.Fatalf("event %v expect(%v) actual(%v)", i, expected[i], *e)
+		}
+	}
+
+	// the rename is paired with the dentry created before the index read from
+	if events, cursor, _ = j.events(5, 1, 115); len(events) != 1 || events[0].Index != 7 || cursor != 7 {
+		t.Fatalf("events after 5: cursor(%v) %v", cursor, events)
+	}
+	if events, cursor, _ = j.events(0, 1, 115); len(events) != 1 || cursor != 2 {
+		t.Fatalf("events limited: cursor(%v) %v", cursor, events)
+	}
+	// the write is not settled yet
+	if events, cursor, _ = j.events(7, 100, 121); len(events) != 0 || cursor != 7 {
+		t.Fatalf("unsettled events: cursor(%v) %v", cursor, events)
+	}
+	j.reset(10)
+	if _, _, truncated = j.events(7, 100, 121); !truncated {
+		t.Fatalf("the trimmed changes are not reported")
+	}
+}
+
+func TestReadEventsWait(t *testing.T) {
+	mp := &metaPartition{changes: newChangeJournal(100, 0), stopC: make(chan bool)}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		mp.changes.append(&proto.MetaChange{Index: 1, Op: proto.ChangeCreateDentry, Inode: 100, ParentID: 1,
+			Name: "a", Time: time.Now().Unix() - eventSettleTime})
+	}()
+	start := time.Now()
+	resp := mp.readEvents(&proto.ReadEventsRequest{}, 5*time.Second)
+	if len(resp.Events) != 1 || resp.Cursor != 1 {
+		t.Fatalf("read events: %v", resp)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Fatalf("the waiting read is not woken up by the update")
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"sync/atomic"
+)
+
+// maxFollowerReadLag is the max raft log entries a follower may have not applied yet to serve the reads.
+const maxFollowerReadLag = 16
+
+// IsFollowerReadable returns true if the reads may be served by this replica.
+// A follower knows the commit index from the appends and heartbeats of the leader,
+// and turns into a candidate after the election timeout without them, so the reads
+// it serves miss at most the mutations of the last heartbeat interval, the last
+// maxFollowerReadLag entries, and those of the election timeout if it is partitioned.
+func (mp *metaPartition) IsFollowerReadable() bool {
+	if _, ok := mp.IsLeader(); ok {
+		return true
+	}
+	if mp.raftPartition == nil {
+		return false
+	}
+	status := mp.raftPartition.Status()
+	if status == nil || status.Leader == 0 || status.State != "StateFollower" || status.RestoringSnapshot {
+		return false
+	}
+	return atomic.LoadUint64(&mp.applyID)+maxFollowerReadLag >= status.Commit
+}
@@ -47,6 +47,14 @@ func (mp *metaPartition) startFreeList() (err error) {
 	// start vol update ticket
 	go mp.updateVolWorker()
 	go mp.deleteWorker()
+	go mp.trashWorker()
+	go mp.pendingDeleteWorker()
+	go mp.multipartGCWorker()
+	go mp.txRecoverWorker()
+	go mp.ttlWorker()
+	go mp.dirStatWorker()
+	go mp.punchHoleWorker()
+	go mp.quotaTagWorker()
 	mp.startToDeleteExtents()
 	return
 }
@@ -120,6 +128,7 @@ func (mp *metaPartition) deleteWorker() {
 
 		//add sleep time value
 		DeleteWorkerSleepMs()
+		mp.delayBackground()
 
 		isForceDeleted := sleepCnt%MaxSleepCnt == 0
 		if !isForceDeleted && mp.freeList.Len() < MinDeleteBatchCounts {
@@ -139,7 +148,11 @@ func (mp *metaPartition) deleteWorker() {
 
 			//check inode nlink == 0 and deletMarkFlag unset
 			if inode, ok := mp.inodeTree.CopyGet(&Inode{Inode: ino}).(*Inode); ok {
-				if inode.ShouldDelayDelete() {
+				if isRelinked(inode) {
+					log.LogDebugf("[metaPartition] deleteWorker skip inode: %v as it is linked again", inode)
+					continue
+				}
+				if mp.shouldDelayDelete(inode) {
 					log.LogDebugf("[metaPartition] deleteWorker delay to remove inode: %v as NLink is 0", inode)
 					delayDeleteInos = append(delayDeleteInos, ino)
 					continue
@@ -275,7 +288,7 @@ func (mp *metaPartition) syncToRaftFollowersFreeInode(hasDeleteInodes []byte) (e
 }
 
 func (mp *metaPartition) notifyRaftFollowerToFreeInodes(wg *sync.WaitGroup, target string, hasDeleteInodes []byte) (err error) {
-	var conn *net.TCPConn
+	var conn net.Conn
 	conn, err = mp.config.ConnPool.GetConnect(target)
 	defer func() {
 		wg.Done()
@@ -306,6 +319,10 @@ func (mp *metaPartition) notifyRaftFollowerToFreeInodes(wg *sync.WaitGroup, targ
 }
 
 func (mp *metaPartition) doDeleteMarkedInodes(ext *proto.ExtentKey) (err error) {
+	// the extent is still referred by a cloned file
+	if len(mp.excludeSharedExtents([]*proto.ExtentKey{ext})) == 0 {
+		return
+	}
 	// get the data node view
 	dp := mp.vol.GetPartition(ext.PartitionId)
 	if dp == nil {
@@ -313,6 +330,7 @@ func (mp *metaPartition) doDeleteMarkedInodes(ext *proto.ExtentKey) (err error)
 			ext.PartitionId)
 		return
 	}
+	deleteExtentLimiterWait(1)
 	// delete the data node
 	conn, err := mp.config.ConnPool.GetConnect(dp.Hosts[0])
 
@@ -349,6 +367,9 @@ func (mp *metaPartition) doDeleteMarkedInodes(ext *proto.ExtentKey) (err error)
 }
 
 func (mp *metaPartition) doBatchDeleteExtentsByPartition(partitionID uint64, exts []*proto.ExtentKey) (err error) {
+	if exts = mp.excludeSharedExtents(exts); len(exts) == 0 {
+		return
+	}
 	// get the data node view
 	dp := mp.vol.GetPartition(partitionID)
 	if dp == nil {
@@ -362,6 +383,7 @@ func (mp *metaPartition) doBatchDeleteExtentsByPartition(partitionID uint64, ext
 			return
 		}
 	}
+	deleteExtentLimiterWait(len(exts))
 
 	// delete the data node
 	conn, err := mp.config.ConnPool.GetConnect(dp.Hosts[0])
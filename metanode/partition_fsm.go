@@ -45,7 +45,14 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 	if err = msg.UnmarshalJson(command); err != nil {
 		return
 	}
+	if msg.Op == opFSMBatch {
+		return mp.applyBatch(msg.V, index)
+	}
+	return mp.applyItem(msg, index)
+}
 
+// applyItem applies a single operational command.
+func (mp *metaPartition) applyItem(msg *MetaItem, index uint64) (resp interface{}, err error) {
 	switch msg.Op {
 	case opFSMCreateInode:
 		ino := NewInode(0, 0)
@@ -140,6 +147,7 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		dentryTree := mp.getDentryTree()
 		extendTree := mp.extendTree.GetTree()
 		multipartTree := mp.multipartTree.GetTree()
+		txTree := mp.txTree.GetTree()
 		msg := &storeMsg{
 			command:       opFSMStoreTick,
 			applyIndex:    index,
@@ -147,6 +155,7 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			dentryTree:    dentryTree,
 			extendTree:    extendTree,
 			multipartTree: multipartTree,
+			txTree:        txTree,
 		}
 		mp.storeChan <- msg
 	case opFSMInternalDeleteInode:
@@ -163,6 +172,15 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			return
 		}
 		err = mp.fsmSetXAttr(extend)
+	case opFSMSetXAttrFlag:
+		var extend *Extend
+		if len(msg.V) < 4 {
+			return nil, fmt.Errorf("set xattr: bad value length(%v)", len(msg.V))
+		}
+		if extend, err = NewExtendFromBytes(msg.V[4:]); err != nil {
+			return
+		}
+		resp = mp.fsmSetXAttrFlag(extend, int(binary.BigEndian.Uint32(msg.V)))
 	case opFSMRemoveXAttr:
 		var extend *Extend
 		if extend, err = NewExtendFromBytes(msg.V); err != nil {
@@ -181,6 +199,53 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		var multipart *Multipart
 		multipart = MultipartFromBytes(msg.V)
 		resp = mp.fsmAppendMultipart(multipart)
+	case opFSMTxBegin, opFSMTxUpdate, opFSMTxRemove:
+		var tx *Transaction
+		if tx, err = TransactionFromBytes(msg.V); err != nil {
+			return
+		}
+		switch msg.Op {
+		case opFSMTxBegin:
+			resp = mp.fsmTxBegin(tx)
+		case opFSMTxUpdate:
+			resp = mp.fsmTxUpdate(tx)
+		default:
+			resp = mp.fsmTxRemove(tx)
+		}
+	case opFSMCloneInode:
+		if len(msg.V) < 8 {
+			return nil, fmt.Errorf("clone inode: bad value length(%v)", len(msg.V))
+		}
+		ino := NewInode(0, 0)
+		if err = ino.Unmarshal(msg.V[8:]); err != nil {
+			return
+		}
+		if mp.config.Cursor < ino.Inode {
+			mp.config.Cursor = ino.Inode
+		}
+		resp = mp.fsmCloneInode(binary.BigEndian.Uint64(msg.V[:8]), ino)
+	case opFSMPunchHole:
+		if len(msg.V) < 32 {
+			return nil, fmt.Errorf("punch hole: bad value length(%v)", len(msg.V))
+		}
+		resp = mp.fsmPunchHole(binary.BigEndian.Uint64(msg.V), binary.BigEndian.Uint64(msg.V[8:]),
+			binary.BigEndian.Uint64(msg.V[16:]), int64(binary.BigEndian.Uint64(msg.V[24:])))
+	case opFSMSetQuota:
+		if len(msg.V) < 16 {
+			return nil, fmt.Errorf("set quota: bad value length(%v)", len(msg.V))
+		}
+		resp = mp.fsmSetQuota(binary.BigEndian.Uint64(msg.V), binary.BigEndian.Uint64(msg.V[8:]))
+	case opFSMVerify:
+		if len(msg.V) < 8 {
+			return nil, fmt.Errorf("verify: bad value length(%v)", len(msg.V))
+		}
+		mp.fsmVerify(binary.BigEndian.Uint64(msg.V), index)
+	case opFSMRenameVol:
+		req := &renameVolReq{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp, err = mp.fsmRenameVol(req)
 	case opFSMSyncCursor:
 		var cursor uint64
 		cursor = binary.BigEndian.Uint64(msg.V)
@@ -188,6 +253,9 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			mp.config.Cursor = cursor
 		}
 	}
+	if err == nil {
+		mp.recordChange(msg, resp, index)
+	}
 
 	return
 }
@@ -249,15 +317,23 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 		dentryTree    = NewBtree()
 		extendTree    = NewBtree()
 		multipartTree = NewBtree()
+		txTree        = NewBtree()
 	)
 	defer func() {
 		if err == io.EOF {
 			mp.applyID = appIndexID
 			mp.inodeTree = inodeTree
+			mp.usage.reset(inodeTree)
 			mp.dentryTree = dentryTree
 			mp.extendTree = extendTree
+			mp.trash.reset(extendTree)
+			mp.shared.reset()
 			mp.multipartTree = multipartTree
+			mp.txTree = txTree
 			mp.config.Cursor = cursor
+			if mp.changes != nil {
+				mp.changes.reset(appIndexID)
+			}
 			err = nil
 			// store message
 			mp.storeChan <- &storeMsg{
@@ -267,6 +343,7 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 				dentryTree:    mp.dentryTree,
 				extendTree:    mp.extendTree,
 				multipartTree: mp.multipartTree,
+				txTree:        mp.txTree,
 			}
 			mp.extReset <- struct{}{}
 			log.LogDebugf("ApplySnapshot: finish with EOF: partitionID(%v) applyID(%v)", mp.config.PartitionId, mp.applyID)
@@ -323,6 +400,13 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 			var multipart = MultipartFromBytes(snap.V)
 			multipartTree.ReplaceOrInsert(multipart, true)
 			log.LogDebugf("ApplySnapshot: create multipart: partitionID(%v) multipart(%v)", mp.config.PartitionId, multipart)
+		case opFSMTxBegin:
+			var tx *Transaction
+			if tx, err = TransactionFromBytes(snap.V); err != nil {
+				return
+			}
+			txTree.ReplaceOrInsert(tx, true)
+			log.LogDebugf("ApplySnapshot: create transaction: partitionID(%v) tx(%v)", mp.config.PartitionId, tx)
 		case opExtentFileSnapshot:
 			fileName := string(snap.K)
 			fileName = path.Join(mp.config.RootDir, fileName)
@@ -371,6 +455,9 @@ func (mp *metaPartition) HandleLeaderChange(leader uint64) {
 	mp.storeChan <- &storeMsg{
 		command: startStoreTick,
 	}
+	// locks granted by the previous leader are unknown here, and are reclaimed by the clients in the grace period
+	mp.lockTable.Reset()
+	mp.retagQuotas()
 	log.LogDebugf("[metaPartition] pid: %v HandleLeaderChange become leader conn %v, nodeId: %v, leader: %v", mp.config.PartitionId, serverPort, mp.config.NodeId, leader)
 	if mp.config.Start == 0 && mp.config.Cursor == 0 {
 		id, err := mp.nextInodeID()
@@ -384,6 +471,20 @@ func (mp *metaPartition) HandleLeaderChange(leader uint64) {
 
 // Put puts the given key-value pair (operation key and operation request) into the raft store.
 func (mp *metaPartition) submit(op uint32, data []byte) (resp interface{}, err error) {
+	start := time.Now()
+	if mp.batcher != nil && batchable(op) {
+		resp, err = mp.batcher.submit(op, data)
+	} else {
+		resp, err = mp.submitItem(op, data)
+	}
+	if mp.manager != nil {
+		mp.manager.admission.observeSubmit(time.Since(start))
+	}
+	return
+}
+
+// submitItem proposes a single operation to the raft store.
+func (mp *metaPartition) submitItem(op uint32, data []byte) (resp interface{}, err error) {
 	snap := NewMetaItem(0, nil, nil)
 	snap.Op = op
 	if data != nil {
@@ -0,0 +1,244 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	defaultSubmitPipelineDepth = 8
+	// the size of the queue of the operations waiting to be batched of each partition
+	submitQueueSize = 1024
+)
+
+// SubmitBatchConfig defines the batching of the raft proposals of the meta partitions.
+// The batching is disabled if MaxOps is not greater than 1, as the meta nodes of the earlier
+// versions can not apply the batches, it must be enabled after all meta nodes are upgraded.
+type SubmitBatchConfig struct {
+	MaxOps        int // the max number of the operations in a proposal
+	PipelineDepth int // the max number of the proposals being submitted of a partition at the same time
+}
+
+// batchSubmit is an operation waiting to be proposed in a batch.
+type batchSubmit struct {
+	op   uint32
+	data []byte
+	resp interface{}
+	err  error
+	done chan struct{}
+}
+
+// batchResult is the result of an operation applied in a batch.
+type batchResult struct {
+	resp interface{}
+	err  error
+}
+
+// submitBatcher merges the operations submitted concurrently to a meta partition into one raft proposal.
+// An operation is proposed at once if there are free pipeline slots, otherwise it waits in the queue
+// and is proposed with the other waiting ones when a proposal in flight is done, so the batches
+// grow with the load and the latency is not raised while the partition is idle.
+type submitBatcher struct {
+	mp       *metaPartition
+	maxOps   int
+	reqC     chan *batchSubmit
+	inflight chan struct{}
+}
+
+func newSubmitBatcher(mp *metaPartition, conf SubmitBatchConfig) *submitBatcher {
+	if conf.MaxOps <= 1 {
+		return nil
+	}
+	if conf.PipelineDepth <= 0 {
+		conf.PipelineDepth = defaultSubmitPipelineDepth
+	}
+	return &submitBatcher{
+		mp:       mp,
+		maxOps:   conf.MaxOps,
+		reqC:     make(chan *batchSubmit, submitQueueSize),
+		inflight: make(chan struct{}, conf.PipelineDepth),
+	}
+}
+
+// batchable returns if the operation can be applied in a batch, the operations depending on
+// the index of the raft log or changing the partition itself are always proposed alone.
+func batchable(op uint32) bool {
+	switch op {
+	case opFSMCreateInode, opFSMUnlinkInode, opFSMUnlinkInodeBatch, opFSMExtentTruncate,
+		opFSMCreateLinkInode, opFSMEvictInode, opFSMEvictInodeBatch, opFSMSetAttr,
+		opFSMCreateDentry, opFSMDeleteDentry, opFSMDeleteDentryBatch, opFSMUpdateDentry,
+		opFSMExtentsAdd, opFSMSetXAttr, opFSMSetXAttrFlag, opFSMRemoveXAttr,
+		opFSMCreateMultipart, opFSMRemoveMultipart, opFSMAppendMultipart, opFSMCloneInode, opFSMPunchHole,
+		opFSMSetQuota:
+		return true
+	}
+	return false
+}
+
+func (b *submitBatcher) submit(op uint32, data []byte) (resp interface{}, err error) {
+	req := &batchSubmit{op: op, data: data, done: make(chan struct{})}
+	select {
+	case b.reqC <- req:
+	case <-b.mp.stopC:
+		return nil, ErrNotALeader
+	}
+	select {
+	case <-req.done:
+		return req.resp, req.err
+	case <-b.mp.stopC:
+		return nil, ErrNotALeader
+	}
+}
+
+// run proposes the queued operations until the partition stops.
+func (b *submitBatcher) run() {
+	for {
+		var req *batchSubmit
+		select {
+		case <-b.mp.stopC:
+			return
+		case req = <-b.reqC:
+		}
+		// wait for a free pipeline slot, the operations queued meanwhile join the batch
+		select {
+		case <-b.mp.stopC:
+			return
+		case b.inflight <- struct{}{}:
+		}
+		batch := []*batchSubmit{req}
+	drain:
+		for len(batch) < b.maxOps {
+			select {
+			case req = <-b.reqC:
+				batch = append(batch, req)
+			default:
+				break drain
+			}
+		}
+		go b.propose(batch)
+	}
+}
+
+func (b *submitBatcher) propose(batch []*batchSubmit) {
+	defer func() { <-b.inflight }()
+	var (
+		resp interface{}
+		err  error
+	)
+	if len(batch) == 1 {
+		resp, err = b.mp.submitItem(batch[0].op, batch[0].data)
+		batch[0].resp, batch[0].err = resp, err
+		close(batch[0].done)
+		return
+	}
+	items := make([]*MetaItem, 0, len(batch))
+	for _, req := range batch {
+		items = append(items, NewMetaItem(req.op, nil, req.data))
+	}
+	var data []byte
+	if data, err = marshalBatch(items); err == nil {
+		resp, err = b.mp.submitItem(opFSMBatch, data)
+	}
+	results, ok := resp.([]*batchResult)
+	if err == nil && (!ok || len(results) != len(batch)) {
+		err = fmt.Errorf("unexpected response of the batch: %T", resp)
+	}
+	for i, req := range batch {
+		if err != nil {
+			req.err = err
+		} else {
+			req.resp, req.err = results[i].resp, results[i].err
+		}
+		close(req.done)
+	}
+	log.LogDebugf("submitBatcher: partitionID(%v) ops(%v) err(%v)", b.mp.config.PartitionId, len(batch), err)
+}
+
+// marshalBatch marshals the operations of a batch.
+// Binary frame structure:
+//
+//	+-------+-------+------+-----+
+//	| Count |  Len  | Item | ... |
+//	+-------+-------+------+-----+
+//	|   4   |   4   | Len  | ... |
+//	+-------+-------+------+-----+
+func marshalBatch(items []*MetaItem) (data []byte, err error) {
+	buff := bytes.NewBuffer(make([]byte, 0))
+	if err = binary.Write(buff, binary.BigEndian, uint32(len(items))); err != nil {
+		return
+	}
+	for _, item := range items {
+		var raw []byte
+		if raw, err = item.MarshalBinary(); err != nil {
+			return
+		}
+		if err = binary.Write(buff, binary.BigEndian, uint32(len(raw))); err != nil {
+			return
+		}
+		if _, err = buff.Write(raw); err != nil {
+			return
+		}
+	}
+	return buff.Bytes(), nil
+}
+
+// unmarshalBatch unmarshals the operations of a batch.
+func unmarshalBatch(data []byte) (items []*MetaItem, err error) {
+	var count uint32
+	buff := bytes.NewBuffer(data)
+	if err = binary.Read(buff, binary.BigEndian, &count); err != nil {
+		return
+	}
+	items = make([]*MetaItem, 0, count)
+	for i := uint32(0); i < count; i++ {
+		var size uint32
+		if err = binary.Read(buff, binary.BigEndian, &size); err != nil {
+			return
+		}
+		if int(size) > buff.Len() {
+			return nil, fmt.Errorf("item %v of the batch is truncated", i)
+		}
+		item := &MetaItem{}
+		if err = item.UnmarshalBinary(buff.Next(int(size))); err != nil {
+			return
+		}
+		items = append(items, item)
+	}
+	return
+}
+
+// applyBatch applies the operations of a batch in order, the result of each one is returned
+// to its submitter, so the failure of an operation does not fail the others.
+func (mp *metaPartition) applyBatch(data []byte, index uint64) (resp interface{}, err error) {
+	items, err := unmarshalBatch(data)
+	if err != nil {
+		return
+	}
+	results := make([]*batchResult, 0, len(items))
+	for _, item := range items {
+		result := &batchResult{}
+		if result.resp, result.err = mp.applyItem(item, index); result.err != nil {
+			log.LogWarnf("applyBatch: partitionID(%v) index(%v) op(%v) err(%v)",
+				mp.config.PartitionId, index, item.Op, result.err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestApplyBatch(t *testing.T) {
+	mp := &metaPartition{
+		config:     &MetaPartitionConfig{PartitionId: 1},
+		inodeTree:  NewBtree(),
+		dentryTree: NewBtree(),
+	}
+	mp.inodeTree.ReplaceOrInsert(NewInode(1, proto.Mode(os.ModeDir|0755)), true)
+
+	var items []*MetaItem
+	for _, dentry := range []*Dentry{
+		{ParentId: 1, Name: "a", Inode: 10},
+		{ParentId: 1, Name: "a", Inode: 11},
+		{ParentId: 2, Name: "b", Inode: 12},
+	} {
+		data, err := dentry.Marshal()
+		if err != nil {
+			t.Fatalf("marshal dentry: %v", err)
+		}
+		items = append(items, NewMetaItem(opFSMCreateDentry, nil, data))
+	}
+	items = append(items, NewMetaItem(opFSMCreateInode, nil, []byte("bad inode")))
+
+	data, err := marshalBatch(items)
+	if err != nil {
+		t.Fatalf("marshal batch: %v", err)
+	}
+	cmd, err := NewMetaItem(opFSMBatch, nil, data).MarshalJson()
+	if err != nil {
+		t.Fatalf("marshal command: %v", err)
+	}
+	resp, err := mp.Apply(cmd, 100)
+	if err != nil {
+		t.Fatalf("apply batch: %v", err)
+	}
+	results, ok := resp.([]*batchResult)
+	if !ok || len(results) != len(items) {
+		t.Fatalf("results of the batch expect(%v) actual(%v)", len(items), resp)
+	}
+	for i, expect := range []uint8{proto.OpOk, proto.OpExistErr, proto.OpNotExistErr} {
+		if results[i].err != nil || results[i].resp.(uint8) != expect {
+			t.Fatalf("result(%v) expect(%v) actual(%v) err(%v)", i, expect, results[i].resp, results[i].err)
+		}
+	}
+	if results[3].err == nil {
+		t.Fatalf("result of the bad inode should fail")
+	}
+	if mp.applyID != 100 {
+		t.Fatalf("apply id expect(100) actual(%v)", mp.applyID)
+	}
+	if dentry, status := mp.getDentry(&Dentry{ParentId: 1, Name: "a"}); status != proto.OpOk || dentry.Inode != 10 {
+		t.Fatalf("dentry of the batch status(%v) dentry(%v)", status, dentry)
+	}
+}
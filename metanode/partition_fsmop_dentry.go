@@ -89,6 +89,24 @@ func (mp *metaPartition) getDentry(dentry *Dentry) (*Dentry, uint8) {
 	return dentry, status
 }
 
+// getDentryCaseInsensitive returns the first dentry of the parent in the order of the names which matches
+// the name ignoring the case. All the dentries of the parent are walked through in the worst case.
+func (mp *metaPartition) getDentryCaseInsensitive(parentID uint64, name string) (*Dentry, uint8) {
+	var dentry *Dentry
+	mp.dentryTree.AscendRange(&Dentry{ParentId: parentID}, &Dentry{ParentId: parentID + 1}, func(i BtreeItem) bool {
+		d := i.(*Dentry)
+		if strings.EqualFold(d.Name, name) {
+			dentry = d
+			return false
+		}
+		return true
+	})
+	if dentry == nil {
+		return nil, proto.OpNotExistErr
+	}
+	return dentry, proto.OpOk
+}
+
 // Delete dentry from the dentry tree.
 func (mp *metaPartition) fsmDeleteDentry(dentry *Dentry, checkInode bool) (
 	resp *DentryResponse) {
@@ -162,18 +180,29 @@ func (mp *metaPartition) readDir(req *ReadDirReq) (resp *ReadDirResp) {
 	resp = &ReadDirResp{}
 	begDentry := &Dentry{
 		ParentId: req.ParentID,
+		Name:     req.Marker,
+	}
+	if req.Prefix > req.Marker {
+		begDentry.Name = req.Prefix
 	}
 	endDentry := &Dentry{
 		ParentId: req.ParentID + 1,
 	}
 	mp.dentryTree.AscendRange(begDentry, endDentry, func(i BtreeItem) bool {
 		d := i.(*Dentry)
+		if req.Marker != "" && d.Name == req.Marker {
+			return true
+		}
+		// the dentries are in the order of the names, so none after has the prefix
+		if !strings.HasPrefix(d.Name, req.Prefix) {
+			return false
+		}
 		resp.Children = append(resp.Children, proto.Dentry{
 			Inode: d.Inode,
 			Type:  d.Type,
 			Name:  d.Name,
 		})
-		return true
+		return req.Limit == 0 || uint64(len(resp.Children)) < req.Limit
 	})
 	return
 }
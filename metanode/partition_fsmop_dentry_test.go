@@ -0,0 +1,173 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestReadDir_Pagination(t *testing.T) {
+	mp := &metaPartition{dentryTree: NewBtree()}
+	for i := 0; i < 10; i++ {
+		mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: fmt.Sprintf("f%02d", i), Inode: uint64(i + 10)}, true)
+	}
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 2, Name: "other", Inode: 100}, true)
+
+	if resp := mp.readDir(&ReadDirReq{ParentID: 1}); len(resp.Children) != 10 {
+		t.Fatalf("read all dentries expect(10) actual(%v)", len(resp.Children))
+	}
+	var names []string
+	var marker string
+	for {
+		resp := mp.readDir(&ReadDirReq{ParentID: 1, Marker: marker, Limit: 4})
+		for _, child := range resp.Children {
+			names = append(names, child.Name)
+		}
+		if len(resp.Children) < 4 {
+			break
+		}
+		marker = resp.Children[len(resp.Children)-1].Name
+	}
+	if len(names) != 10 {
+		t.Fatalf("read dentries in pages expect(10) actual(%v): %v", len(names), names)
+	}
+	for i, name := range names {
+		if expect := fmt.Sprintf("f%02d", i); name != expect {
+			t.Fatalf("dentry(%v) expect(%v) actual(%v)", i, expect, name)
+		}
+	}
+}
+
+func TestReadDirPlus_LocalInodes(t *testing.T) {
+	mp := &metaPartition{dentryTree: NewBtree(), inodeTree: NewBtree()}
+	for i := 0; i < 3; i++ {
+		mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: fmt.Sprintf("f%02d", i), Inode: uint64(i + 10)}, true)
+		mp.inodeTree.ReplaceOrInsert(NewInode(uint64(i+10), 0644), true)
+	}
+
+	children := mp.readDir(&ReadDirReq{ParentID: 1}).Children
+	infos := mp.getChildInodes(children)
+	if len(infos) != len(children) {
+		t.Fatalf("inodes of dentries expect(%v) actual(%v)", len(children), len(infos))
+	}
+	for i, info := range infos {
+		if info.Inode != children[i].Inode {
+			t.Fatalf("inode(%v) expect(%v) actual(%v)", i, children[i].Inode, info.Inode)
+		}
+	}
+}
+
+func TestGetDentryCaseInsensitive(t *testing.T) {
+	mp := &metaPartition{dentryTree: NewBtree()}
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: "Readme.TXT", Inode: 10}, true)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 2, Name: "readme.txt", Inode: 20}, true)
+
+	if _, status := mp.getDentry(&Dentry{ParentId: 1, Name: "README.txt"}); status != proto.OpNotExistErr {
+		t.Fatalf("exact lookup status expect(%v) actual(%v)", proto.OpNotExistErr, status)
+	}
+	dentry, status := mp.getDentryCaseInsensitive(1, "README.txt")
+	if status != proto.OpOk || dentry.Inode != 10 || dentry.Name != "Readme.TXT" {
+		t.Fatalf("case-insensitive lookup status(%v) dentry(%v)", status, dentry)
+	}
+	if _, status = mp.getDentryCaseInsensitive(3, "readme.txt"); status != proto.OpNotExistErr {
+		t.Fatalf("case-insensitive lookup of another parent status(%v)", status)
+	}
+}
+
+func TestReadDir_Prefix(t *testing.T) {
+	mp := &metaPartition{dentryTree: NewBtree()}
+	for i, name := range []string{"a", "b-1", "b-2", "b-3", "c"} {
+		mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: name, Inode: uint64(i + 10)}, true)
+	}
+	var cases = []struct {
+		prefix, marker string
+		limit          uint64
+		expect         []string
+	}{
+		{"b-", "", 0, []string{"b-1", "b-2", "b-3"}},
+		{"b-", "", 2, []string{"b-1", "b-2"}},
+		{"b-", "b-1", 0, []string{"b-2", "b-3"}},
+		{"b-", "a", 0, []string{"b-1", "b-2", "b-3"}},
+		{"b-", "b-3", 0, nil},
+		{"d", "", 0, nil},
+	}
+	for _, c := range cases {
+		var names []string
+		for _, child := range mp.readDir(&ReadDirReq{ParentID: 1, Prefix: c.prefix, Marker: c.marker, Limit: c.limit}).Children {
+			names = append(names, child.Name)
+		}
+		if fmt.Sprint(names) != fmt.Sprint(c.expect) {
+			t.Fatalf("read dentries prefix(%v) marker(%v) limit(%v): expect(%v) actual(%v)",
+				c.prefix, c.marker, c.limit, c.expect, names)
+		}
+	}
+}
+
+func TestRenameDirInPartition(t *testing.T) {
+	mp := &metaPartition{dentryTree: NewBtree(), inodeTree: NewBtree()}
+	dirMode := proto.Mode(os.ModeDir | 0755)
+	mp.inodeTree.ReplaceOrInsert(NewInode(1, dirMode), true)
+	mp.inodeTree.ReplaceOrInsert(NewInode(10, dirMode), true)
+	if status := mp.fsmCreateDentry(&Dentry{ParentId: 1, Name: "dir1", Inode: 10, Type: dirMode}, false); status != proto.OpOk {
+		t.Fatalf("create dentry dir1 status(%v)", status)
+	}
+
+	// the rename links the inode, creates the new dentry, deletes the old one and unlinks the inode
+	if resp := mp.fsmCreateLinkInode(NewInode(10, 0)); resp.Status != proto.OpOk {
+		t.Fatalf("link dir status(%v)", resp.Status)
+	}
+	if status := mp.fsmCreateDentry(&Dentry{ParentId: 1, Name: "dir2", Inode: 10, Type: dirMode}, false); status != proto.OpOk {
+		t.Fatalf("create dentry dir2 status(%v)", status)
+	}
+	if resp := mp.fsmDeleteDentry(&Dentry{ParentId: 1, Name: "dir1", Inode: 10}, true); resp.Status != proto.OpOk {
+		t.Fatalf("delete dentry dir1 status(%v)", resp.Status)
+	}
+	if resp := mp.fsmUnlinkInode(NewInode(10, 0)); resp.Status != proto.OpOk {
+		t.Fatalf("unlink dir status(%v)", resp.Status)
+	}
+
+	if _, status := mp.getDentry(&Dentry{ParentId: 1, Name: "dir2"}); status != proto.OpOk {
+		t.Errorf("dentry dir2 status(%v)", status)
+	}
+	if _, status := mp.getDentry(&Dentry{ParentId: 1, Name: "dir1"}); status != proto.OpNotExistErr {
+		t.Errorf("dentry dir1 expect removed, status(%v)", status)
+	}
+	item := mp.inodeTree.Get(NewInode(10, 0))
+	if item == nil {
+		t.Fatalf("dir inode removed by the rename")
+	}
+	if nlink := item.(*Inode).GetNLink(); nlink != 2 {
+		t.Errorf("dir nlink expect(2) actual(%v)", nlink)
+	}
+}
+
+func TestHardLinkDirRefused(t *testing.T) {
+	mp := &metaPartition{inodeTree: NewBtree()}
+	mp.inodeTree.ReplaceOrInsert(NewInode(10, proto.Mode(os.ModeDir|0755)), true)
+	p := &Packet{}
+	if err := mp.CreateInodeLink(&LinkInodeReq{Inode: 10, HardLink: true}, p); err != nil {
+		t.Fatalf("hard link dir err(%v)", err)
+	}
+	if p.ResultCode != proto.OpNotPerm {
+		t.Fatalf("hard link dir expect(%v) actual(%v)", proto.OpNotPerm, p.ResultCode)
+	}
+	if nlink := mp.inodeTree.Get(NewInode(10, 0)).(*Inode).GetNLink(); nlink != 2 {
+		t.Errorf("dir nlink expect(2) actual(%v)", nlink)
+	}
+}
@@ -14,6 +14,8 @@
 
 package metanode
 
+import "github.com/chubaofs/chubaofs/proto"
+
 type ExtendOpResult struct {
 	Status uint8
 	Extend *Extend
@@ -29,6 +31,39 @@ func (mp *metaPartition) fsmSetXAttr(extend *Extend) (err error) {
 		e = treeItem.(*Extend)
 	}
 	e.Merge(extend, true)
+	mp.trash.update(e)
+	return
+}
+
+// fsmSetXAttrFlag sets the xattrs of the extend if none of the keys exists with XAttrCreate,
+// or if all of them exist with XAttrReplace.
+func (mp *metaPartition) fsmSetXAttrFlag(extend *Extend, flag int) (status uint8) {
+	var e *Extend
+	if treeItem := mp.extendTree.CopyGet(extend); treeItem != nil {
+		e = treeItem.(*Extend)
+	}
+	status = proto.OpOk
+	extend.Range(func(key, value []byte) bool {
+		var exist bool
+		if e != nil {
+			_, exist = e.Get(key)
+		}
+		if exist && flag&proto.XAttrCreate != 0 {
+			status = proto.OpExistErr
+		} else if !exist && flag&proto.XAttrReplace != 0 {
+			status = proto.OpNotExistErr
+		}
+		return status == proto.OpOk
+	})
+	if status != proto.OpOk {
+		return
+	}
+	if e == nil {
+		e = NewExtend(extend.inode)
+		mp.extendTree.ReplaceOrInsert(e, true)
+	}
+	e.Merge(extend, true)
+	mp.trash.update(e)
 	return
 }
 
@@ -42,5 +77,6 @@ func (mp *metaPartition) fsmRemoveXAttr(extend *Extend) (err error) {
 		e.Remove(key)
 		return true
 	})
+	mp.trash.update(e)
 	return
 }
@@ -38,7 +38,9 @@ func (mp *metaPartition) fsmCreateInode(ino *Inode) (status uint8) {
 	status = proto.OpOk
 	if _, ok := mp.inodeTree.ReplaceOrInsert(ino, false); !ok {
 		status = proto.OpExistErr
+		return
 	}
+	mp.usage.addInode(ino)
 	return
 }
 
@@ -125,6 +127,7 @@ func (mp *metaPartition) fsmUnlinkInode(ino *Inode) (resp *InodeResponse) {
 
 	if inode.IsEmptyDir() {
 		mp.inodeTree.Delete(inode)
+		mp.usage.removeInode(inode)
 	}
 
 	inode.DecNLink()
@@ -194,9 +197,13 @@ func (mp *metaPartition) internalDeleteBatch(val []byte) error {
 }
 
 func (mp *metaPartition) internalDeleteInode(ino *Inode) {
-	mp.inodeTree.Delete(ino)
+	if item := mp.inodeTree.Delete(ino); item != nil {
+		mp.usage.removeInode(item.(*Inode))
+	}
 	mp.freeList.Remove(ino.Inode)
 	mp.extendTree.Delete(&Extend{inode: ino.Inode}) // Also delete extend attribute.
+	mp.trash.remove(ino.Inode)
+	mp.removeSharedInode(ino.Inode)
 	return
 }
 
@@ -213,7 +220,10 @@ func (mp *metaPartition) fsmAppendExtents(ino *Inode) (status uint8) {
 		return
 	}
 	eks := ino.Extents.CopyExtents()
+	oldSize := ino2.GetSize()
 	delExtents := ino2.AppendExtents(eks, ino.ModifyTime)
+	mp.usage.resize(ino2, oldSize)
+	mp.updateSharedInode(ino2)
 	log.LogInfof("fsmAppendExtents inode(%v) exts(%v)", ino2.Inode, delExtents)
 	mp.extDelCh <- delExtents
 	return
@@ -238,11 +248,15 @@ func (mp *metaPartition) fsmExtentsTruncate(ino *Inode) (resp *InodeResponse) {
 		return
 	}
 
-	delExtents := i.ExtentsTruncate(ino.Size, ino.ModifyTime)
+	oldSize := i.GetSize()
+	delExtents, holes := i.ExtentsTruncate(ino.Size, ino.ModifyTime)
+	mp.usage.resize(i, oldSize)
+	mp.updateSharedInode(i)
 
 	// now we should delete the extent
-	log.LogInfof("fsmExtentsTruncate inode(%v) exts(%v)", i.Inode, delExtents)
+	log.LogInfof("fsmExtentsTruncate inode(%v) exts(%v) holes(%v)", i.Inode, delExtents, holes)
 	mp.extDelCh <- delExtents
+	mp.punchHoles(holes)
 	return
 }
 
@@ -261,13 +275,16 @@ func (mp *metaPartition) fsmEvictInode(ino *Inode) (resp *InodeResponse) {
 	}
 	if proto.IsDir(i.Type) {
 		if i.IsEmptyDir() {
+			mp.usage.removeInode(i)
 			i.SetDeleteMark()
 		}
 		return
 	}
 
 	if i.IsTempFile() {
+		mp.usage.removeInode(i)
 		i.SetDeleteMark()
+		mp.updateSharedInode(i)
 		mp.freeList.Push(i.Inode)
 	}
 	return
@@ -0,0 +1,54 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import "github.com/chubaofs/chubaofs/proto"
+
+func (mp *metaPartition) fsmTxBegin(tx *Transaction) (status uint8) {
+	_, ok := mp.txTree.ReplaceOrInsert(tx, false)
+	if !ok {
+		return proto.OpExistErr
+	}
+	return proto.OpOk
+}
+
+// fsmTxUpdate moves the transaction to the state of the given one, and replaces the payload
+// if the given one has it. The update time is carried by the request so that all the replicas apply the same value.
+// The stored record is replaced instead of modified, so the readers need no lock on it.
+func (mp *metaPartition) fsmTxUpdate(tx *Transaction) (status uint8) {
+	item := mp.txTree.Get(tx)
+	if item == nil {
+		return proto.OpNotExistErr
+	}
+	stored := item.(*Transaction)
+	if !stored.state.canMoveTo(tx.state) {
+		return proto.OpArgMismatchErr
+	}
+	updated := stored.Copy().(*Transaction)
+	updated.state = tx.state
+	updated.updateTime = tx.updateTime
+	if len(tx.payload) > 0 {
+		updated.payload = tx.payload
+	}
+	mp.txTree.ReplaceOrInsert(updated, true)
+	return proto.OpOk
+}
+
+func (mp *metaPartition) fsmTxRemove(tx *Transaction) (status uint8) {
+	if mp.txTree.Delete(tx) == nil {
+		return proto.OpNotExistErr
+	}
+	return proto.OpOk
+}
@@ -0,0 +1,87 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	MultipartGCInterval = 30 * time.Minute
+)
+
+// multipartGCWorker aborts the multipart uploads which are initiated longer than
+// the configured expiration ago and never completed or aborted by the client.
+// The inodes of the uploaded parts may live in other partitions, so they are released
+// through the leaders of those partitions just like an abort issued by the object node.
+func (mp *metaPartition) multipartGCWorker() {
+	t := time.NewTicker(MultipartGCInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-mp.stopC:
+			log.LogDebugf("[metaPartition] multipartGCWorker stop partition: %v", mp.config.PartitionId)
+			return
+		case <-t.C:
+			if _, isLeader := mp.IsLeader(); !isLeader {
+				continue
+			}
+			expiration := MultipartExpiration()
+			if expiration == 0 {
+				continue
+			}
+			mp.expireMultiparts(time.Now().Add(-expiration))
+		}
+	}
+}
+
+func (mp *metaPartition) expireMultiparts(expireTime time.Time) {
+	var expired = make([]*Multipart, 0)
+	mp.multipartTree.GetTree().Ascend(func(i BtreeItem) bool {
+		multipart := i.(*Multipart)
+		if multipart.initTime.Before(expireTime) {
+			expired = append(expired, multipart.Copy().(*Multipart))
+		}
+		return true
+	})
+	if len(expired) == 0 {
+		return
+	}
+
+	volName := mp.config.VolName
+	views, err := masterClient.ClientAPI().GetMetaPartitions(volName)
+	if err != nil {
+		log.LogErrorf("expireMultiparts: get meta partitions fail: volume(%v) err(%v)", volName, err)
+		return
+	}
+	for _, multipart := range expired {
+		for _, part := range multipart.Parts() {
+			if err = mp.releaseRemoteInode(views, part.Inode); err != nil {
+				log.LogWarnf("expireMultiparts: release part inode fail: partition(%v) path(%v) multipartID(%v) partID(%v) inode(%v) err(%v)",
+					mp.config.PartitionId, multipart.key, multipart.id, part.ID, part.Inode, err)
+			}
+		}
+		var status interface{}
+		if status, err = mp.putMultipart(opFSMRemoveMultipart, &Multipart{id: multipart.id, key: multipart.key}); err != nil {
+			log.LogWarnf("expireMultiparts: remove multipart fail: partition(%v) path(%v) multipartID(%v) err(%v)",
+				mp.config.PartitionId, multipart.key, multipart.id, err)
+			return
+		}
+		log.LogInfof("expireMultiparts: abort multipart: partition(%v) path(%v) multipartID(%v) initTime(%v) status(%v)",
+			mp.config.PartitionId, multipart.key, multipart.id, multipart.initTime, status)
+	}
+}
@@ -19,15 +19,28 @@ import (
 	"fmt"
 
 	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
 )
 
 // CreateDentry returns a new dentry.
 func (mp *metaPartition) CreateDentry(req *CreateDentryReq, p *Packet) (err error) {
+	if mp.dedup.begin(req.ClientID, p) {
+		return
+	}
+	defer mp.dedup.end(req.ClientID, p)
 	if req.ParentID == req.Inode {
 		err = fmt.Errorf("parentId is equal inodeId")
 		p.PacketErrorWithBody(proto.OpExistErr, []byte(err.Error()))
 		return
 	}
+	if status := mp.checkAccess(req.Cred, req.ParentID, proto.PermWrite|proto.PermExec); status != proto.OpOk {
+		p.PacketErrorWithBody(status, nil)
+		return
+	}
+	if status := mp.checkQuotaToCreate(req.ParentID); status != proto.OpOk {
+		p.PacketErrorWithBody(status, nil)
+		return
+	}
 
 	dentry := &Dentry{
 		ParentId: req.ParentID,
@@ -45,11 +58,22 @@ func (mp *metaPartition) CreateDentry(req *CreateDentryReq, p *Packet) (err erro
 		return
 	}
 	p.ResultCode = resp.(uint8)
+	if p.ResultCode == proto.OpOk {
+		mp.tagChild(req.ParentID, req.Inode)
+	}
 	return
 }
 
 // DeleteDentry deletes a dentry.
 func (mp *metaPartition) DeleteDentry(req *DeleteDentryReq, p *Packet) (err error) {
+	if mp.dedup.begin(req.ClientID, p) {
+		return
+	}
+	defer mp.dedup.end(req.ClientID, p)
+	if status := mp.checkRemove(req.Cred, req.ParentID, req.Name); status != proto.OpOk {
+		p.PacketErrorWithBody(status, nil)
+		return
+	}
 	dentry := &Dentry{
 		ParentId: req.ParentID,
 		Name:     req.Name,
@@ -144,11 +168,19 @@ func (mp *metaPartition) DeleteDentryBatch(req *BatchDeleteDentryReq, p *Packet)
 
 // UpdateDentry updates a dentry.
 func (mp *metaPartition) UpdateDentry(req *UpdateDentryReq, p *Packet) (err error) {
+	if mp.dedup.begin(req.ClientID, p) {
+		return
+	}
+	defer mp.dedup.end(req.ClientID, p)
 	if req.ParentID == req.Inode {
 		err = fmt.Errorf("parentId is equal inodeId")
 		p.PacketErrorWithBody(proto.OpExistErr, []byte(err.Error()))
 		return
 	}
+	if status := mp.checkRemove(req.Cred, req.ParentID, req.Name); status != proto.OpOk {
+		p.PacketErrorWithBody(status, nil)
+		return
+	}
 
 	dentry := &Dentry{
 		ParentId: req.ParentID,
@@ -168,6 +200,7 @@ func (mp *metaPartition) UpdateDentry(req *UpdateDentryReq, p *Packet) (err erro
 	msg := resp.(*DentryResponse)
 	p.ResultCode = msg.Status
 	if msg.Status == proto.OpOk {
+		mp.tagChild(req.ParentID, req.Inode)
 		var reply []byte
 		m := &UpdateDentryResp{
 			Inode: msg.Msg.Inode,
@@ -180,6 +213,10 @@ func (mp *metaPartition) UpdateDentry(req *UpdateDentryReq, p *Packet) (err erro
 
 // ReadDir reads the directory based on the given request.
 func (mp *metaPartition) ReadDir(req *ReadDirReq, p *Packet) (err error) {
+	if status := mp.checkAccess(req.Cred, req.ParentID, proto.PermRead); status != proto.OpOk {
+		p.PacketErrorWithBody(status, nil)
+		return
+	}
 	resp := mp.readDir(req)
 	reply, err := json.Marshal(resp)
 	if err != nil {
@@ -190,19 +227,92 @@ func (mp *metaPartition) ReadDir(req *ReadDirReq, p *Packet) (err error) {
 	return
 }
 
+// ReadDirPlus reads the directory like ReadDir, and replies the inodes of the dentries along with them.
+// The inodes held by other partitions are got through the leaders of those partitions.
+func (mp *metaPartition) ReadDirPlus(req *ReadDirReq, p *Packet) (err error) {
+	if status := mp.checkAccess(req.Cred, req.ParentID, proto.PermRead); status != proto.OpOk {
+		p.PacketErrorWithBody(status, nil)
+		return
+	}
+	resp := &proto.ReadDirPlusResponse{
+		Children: mp.readDir(req).Children,
+	}
+	resp.Infos = mp.getChildInodes(resp.Children)
+	reply, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+func (mp *metaPartition) getChildInodes(children []proto.Dentry) (infos []*proto.InodeInfo) {
+	infos = make([]*proto.InodeInfo, 0, len(children))
+	var remote []uint64
+	ino := NewInode(0, 0)
+	for _, child := range children {
+		ino.Inode = child.Inode
+		item := mp.inodeTree.Get(ino)
+		if item == nil {
+			remote = append(remote, child.Inode)
+			continue
+		}
+		info := &proto.InodeInfo{}
+		if replyInfo(info, item.(*Inode)) {
+			infos = append(infos, info)
+		}
+	}
+	if len(remote) == 0 {
+		return
+	}
+
+	views, err := mp.remoteViews.get(mp.config.VolName)
+	if err != nil {
+		log.LogWarnf("getChildInodes: get meta partitions fail: partition(%v) err(%v)", mp.config.PartitionId, err)
+		return
+	}
+	var batches = make(map[*proto.MetaPartitionView][]uint64)
+	for _, ino := range remote {
+		if view, e := findPartitionView(views, ino); e == nil {
+			batches[view] = append(batches[view], ino)
+		}
+	}
+	for view, inodes := range batches {
+		remoteInfos, e := mp.batchGetRemoteInodes(view, inodes)
+		if e != nil {
+			log.LogWarnf("getChildInodes: get inodes fail: partition(%v) target(%v) err(%v)",
+				mp.config.PartitionId, view.PartitionID, e)
+			continue
+		}
+		infos = append(infos, remoteInfos...)
+	}
+	return
+}
+
 // Lookup looks up the given dentry from the request.
 func (mp *metaPartition) Lookup(req *LookupReq, p *Packet) (err error) {
+	if status := mp.checkAccess(req.Cred, req.ParentID, proto.PermExec); status != proto.OpOk {
+		p.PacketErrorWithBody(status, nil)
+		return
+	}
 	dentry := &Dentry{
 		ParentId: req.ParentID,
 		Name:     req.Name,
 	}
 	dentry, status := mp.getDentry(dentry)
+	if status == proto.OpNotExistErr && req.CaseInsensitive {
+		dentry, status = mp.getDentryCaseInsensitive(req.ParentID, req.Name)
+	}
 	var reply []byte
 	if status == proto.OpOk {
 		resp := &LookupResp{
 			Inode: dentry.Inode,
 			Mode:  dentry.Type,
 		}
+		if dentry.Name != req.Name {
+			resp.Name = dentry.Name
+		}
 		reply, err = json.Marshal(resp)
 		if err != nil {
 			status = proto.OpErr
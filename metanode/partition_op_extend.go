@@ -15,6 +15,7 @@
 package metanode
 
 import (
+	"encoding/binary"
 	"encoding/json"
 
 	"github.com/chubaofs/chubaofs/proto"
@@ -23,6 +24,9 @@ import (
 func (mp *metaPartition) SetXAttr(req *proto.SetXAttrRequest, p *Packet) (err error) {
 	var extend = NewExtend(req.Inode)
 	extend.Put([]byte(req.Key), []byte(req.Value))
+	if req.Flag != 0 {
+		return mp.setXAttrFlag(extend, req.Flag, p)
+	}
 	if _, err = mp.putExtend(opFSMSetXAttr, extend); err != nil {
 		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
 		return
@@ -31,6 +35,30 @@ func (mp *metaPartition) SetXAttr(req *proto.SetXAttrRequest, p *Packet) (err er
 	return
 }
 
+// setXAttrFlag sets the xattr with the create or replace flag, the existence of the key
+// is checked in the apply so that it is atomic with the set.
+func (mp *metaPartition) setXAttrFlag(extend *Extend, flag int, p *Packet) (err error) {
+	var marshaled []byte
+	if marshaled, err = extend.Bytes(); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	val := make([]byte, 4+len(marshaled))
+	binary.BigEndian.PutUint32(val, uint32(flag))
+	copy(val[4:], marshaled)
+	resp, err := mp.submit(opFSMSetXAttrFlag, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	if status := resp.(uint8); status != proto.OpOk {
+		p.PacketErrorWithBody(status, nil)
+		return
+	}
+	p.PacketOkReply()
+	return
+}
+
 func (mp *metaPartition) GetXAttr(req *proto.GetXAttrRequest, p *Packet) (err error) {
 	var response = &proto.GetXAttrResponse{
 		VolName:     req.VolName,
@@ -23,8 +23,12 @@ import (
 
 // ExtentAppend appends an extent.
 func (mp *metaPartition) ExtentAppend(req *proto.AppendExtentKeyRequest, p *Packet) (err error) {
-	ino := NewInode(req.Inode, 0)
 	ext := req.Extent
+	if status := mp.checkQuotaToGrow(req.Inode, ext.FileOffset+uint64(ext.Size)); status != proto.OpOk {
+		p.PacketErrorWithBody(status, nil)
+		return
+	}
+	ino := NewInode(req.Inode, 0)
 	ino.Extents.Append(ext)
 	val, err := ino.Marshal()
 	if err != nil {
@@ -59,6 +63,7 @@ func (mp *metaPartition) ExtentsList(req *proto.GetExtentsRequest, p *Packet) (e
 				return true
 			})
 		})
+		resp.Shared = mp.isSharedInode(ino.Inode)
 		reply, err = json.Marshal(resp)
 		if err != nil {
 			status = proto.OpErr
@@ -94,6 +99,10 @@ func (mp *metaPartition) BatchExtentAppend(req *proto.AppendExtentKeysRequest, p
 	for _, extent := range extents {
 		ino.Extents.Append(extent)
 	}
+	if status := mp.checkQuotaToGrow(req.Inode, ino.Extents.Size()); status != proto.OpOk {
+		p.PacketErrorWithBody(status, nil)
+		return
+	}
 	val, err := ino.Marshal()
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
@@ -34,6 +34,7 @@ func replyInfo(info *proto.InodeInfo, ino *Inode) bool {
 	info.Uid = ino.Uid
 	info.Gid = ino.Gid
 	info.Generation = ino.Generation
+	info.QuotaId = ino.QuotaId
 	if length := len(ino.LinkTarget); length > 0 {
 		info.Target = make([]byte, length)
 		copy(info.Target, ino.LinkTarget)
@@ -47,6 +48,10 @@ func replyInfo(info *proto.InodeInfo, ino *Inode) bool {
 
 // CreateInode returns a new inode.
 func (mp *metaPartition) CreateInode(req *CreateInoReq, p *Packet) (err error) {
+	if mp.dedup.begin(req.ClientID, p) {
+		return
+	}
+	defer mp.dedup.end(req.ClientID, p)
 	inoID, err := mp.nextInodeID()
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpInodeFullErr, []byte(err.Error()))
@@ -89,6 +94,10 @@ func (mp *metaPartition) CreateInode(req *CreateInoReq, p *Packet) (err error) {
 
 // DeleteInode deletes an inode.
 func (mp *metaPartition) UnlinkInode(req *UnlinkInoReq, p *Packet) (err error) {
+	if mp.dedup.begin(req.ClientID, p) {
+		return
+	}
+	defer mp.dedup.end(req.ClientID, p)
 	ino := NewInode(req.Inode, 0)
 	val, err := ino.Marshal()
 	if err != nil {
@@ -170,6 +179,12 @@ func (mp *metaPartition) UnlinkInodeBatch(req *BatchUnlinkInoReq, p *Packet) (er
 
 // InodeGet executes the inodeGet command from the client.
 func (mp *metaPartition) InodeGet(req *InodeGetReq, p *Packet) (err error) {
+	if req.Mask != 0 {
+		if status := mp.checkAccess(req.Cred, req.Inode, req.Mask); status != proto.OpOk {
+			p.PacketErrorWithBody(status, nil)
+			return
+		}
+	}
 	ino := NewInode(req.Inode, 0)
 	retMsg := mp.getInode(ino)
 	ino = retMsg.Msg
@@ -179,7 +194,8 @@ func (mp *metaPartition) InodeGet(req *InodeGetReq, p *Packet) (err error) {
 	)
 	if retMsg.Status == proto.OpOk {
 		resp := &proto.InodeGetResponse{
-			Info: &proto.InodeInfo{},
+			Info:   &proto.InodeInfo{},
+			Shared: mp.isSharedInode(req.Inode),
 		}
 		if replyInfo(resp.Info, retMsg.Msg) {
 			status = proto.OpOk
@@ -219,6 +235,18 @@ func (mp *metaPartition) InodeGetBatch(req *InodeGetReqBatch, p *Packet) (err er
 
 // CreateInodeLink creates an inode link (e.g., soft link).
 func (mp *metaPartition) CreateInodeLink(req *LinkInodeReq, p *Packet) (err error) {
+	// The directories are linked by the renames only. The check is made before the proposal,
+	// so that applying the logs does not depend on the kind of the link.
+	if req.HardLink {
+		if item := mp.inodeTree.Get(NewInode(req.Inode, 0)); item != nil && proto.IsDir(item.(*Inode).Type) {
+			p.PacketErrorWithBody(proto.OpNotPerm, []byte("hard link to directory"))
+			return
+		}
+	}
+	if mp.dedup.begin(req.ClientID, p) {
+		return
+	}
+	defer mp.dedup.end(req.ClientID, p)
 	ino := NewInode(req.Inode, 0)
 	val, err := ino.Marshal()
 	if err != nil {
@@ -231,9 +259,10 @@ func (mp *metaPartition) CreateInodeLink(req *LinkInodeReq, p *Packet) (err erro
 		return
 	}
 	retMsg := resp.(*InodeResponse)
-	status := proto.OpNotExistErr
+	status := retMsg.Status
 	var reply []byte
 	if retMsg.Status == proto.OpOk {
+		status = proto.OpNotExistErr
 		resp := &LinkInodeResp{
 			Info: &proto.InodeInfo{},
 		}
@@ -253,6 +282,12 @@ func (mp *metaPartition) CreateInodeLink(req *LinkInodeReq, p *Packet) (err erro
 
 // EvictInode evicts an inode.
 func (mp *metaPartition) EvictInode(req *EvictInodeReq, p *Packet) (err error) {
+	// the client drops its own hold before evicting, so the live holds left are of the others
+	// still holding the orphan inode open, and it is deleted after all of them expire
+	if held, _ := mp.orphanHeld(req.Inode, time.Now().Unix()); held {
+		p.PacketOkReply()
+		return
+	}
 	ino := NewInode(req.Inode, 0)
 	val, err := ino.Marshal()
 	if err != nil {
@@ -305,7 +340,11 @@ func (mp *metaPartition) EvictInodeBatch(req *BatchEvictInodeReq, p *Packet) (er
 }
 
 // SetAttr set the inode attributes.
-func (mp *metaPartition) SetAttr(reqData []byte, p *Packet) (err error) {
+func (mp *metaPartition) SetAttr(req *SetattrRequest, reqData []byte, p *Packet) (err error) {
+	if status := mp.checkSetAttr(req); status != proto.OpOk {
+		p.PacketErrorWithBody(status, nil)
+		return
+	}
 	_, err = mp.submit(opFSMSetAttr, reqData)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
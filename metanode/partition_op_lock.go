@@ -0,0 +1,65 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// SetLock acquires or releases an advisory lock on an inode.
+// A conflicting lock is replied with OpExistErr and the conflicting lock as body.
+func (mp *metaPartition) SetLock(req *proto.SetLockRequest, p *Packet) (err error) {
+	if mp.inodeTree.Get(NewInode(req.Inode, 0)) == nil {
+		p.PacketErrorWithBody(proto.OpNotExistErr, nil)
+		return
+	}
+	// the client retries in the grace period as if the lock conflicts
+	conflict, graceErr := mp.lockTable.Acquire(req.Inode, &req.Lock, req.Reclaim)
+	if graceErr != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(graceErr.Error()))
+		return
+	}
+	if conflict == nil {
+		p.PacketOkReply()
+		return
+	}
+	var encoded []byte
+	if encoded, err = json.Marshal(&proto.GetLockResponse{Lock: conflict}); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketErrorWithBody(proto.OpExistErr, encoded)
+	return
+}
+
+// GetLock returns the lock which conflicts with the requested one, if any.
+func (mp *metaPartition) GetLock(req *proto.GetLockRequest, p *Packet) (err error) {
+	if mp.lockTable.InGrace() {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(ErrLockGrace.Error()))
+		return
+	}
+	response := &proto.GetLockResponse{
+		Lock: mp.lockTable.Get(req.Inode, &req.Lock),
+	}
+	var encoded []byte
+	if encoded, err = json.Marshal(response); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(encoded)
+	return
+}
@@ -161,17 +161,20 @@ func (mp *metaPartition) ListMultipart(req *proto.ListMultipartRequest, p *Packe
 	var matches = make([]*Multipart, 0, max)
 	var walkTreeFunc = func(i BtreeItem) bool {
 		multipart := i.(*Multipart)
-		// prefix is enabled
+		// prefix is enabled, all keys with the prefix are already visited since the tree is sorted by key
 		if len(prefix) > 0 && !strings.HasPrefix(multipart.key, prefix) {
-			// skip and continue
-			return true
+			return false
 		}
 		matches = append(matches, multipart)
 		return !(len(matches) >= max)
 	}
-	if len(keyMarker) > 0 {
+	// start from the larger one of the prefix and the marker
+	switch {
+	case len(keyMarker) > 0 && keyMarker >= prefix:
 		mp.multipartTree.AscendGreaterOrEqual(&Multipart{key: keyMarker, id: multipartIdMarker}, walkTreeFunc)
-	} else {
+	case len(prefix) > 0:
+		mp.multipartTree.AscendGreaterOrEqual(&Multipart{key: prefix}, walkTreeFunc)
+	default:
 		mp.multipartTree.Ascend(walkTreeFunc)
 	}
 	multipartInfos := make([]*proto.MultipartInfo, len(matches))
@@ -0,0 +1,73 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// orphanHeld tells whether a client still holds the orphan inode, and whether the inode is
+// tracked by the holds at all, i.e. it has ever been held by a client.
+// The holds of the dead clients are taken as expired.
+func (mp *metaPartition) orphanHeld(ino uint64, now int64) (held, tracked bool) {
+	item := mp.extendTree.Get(NewExtend(ino))
+	if item == nil {
+		return
+	}
+	item.(*Extend).Range(func(key, value []byte) bool {
+		if !proto.IsOrphanHoldXAttr(string(key)) {
+			return true
+		}
+		tracked = true
+		if clientID, err := proto.OrphanHoldClient(string(key)); err == nil && mp.isDeadClient(clientID) {
+			return true
+		}
+		expire, err := proto.ParseOrphanHold(value)
+		if err != nil {
+			log.LogWarnf("orphanHeld: partition(%v) ino(%v) hold(%v) err(%v)", mp.config.PartitionId, ino, string(key), err)
+			return true
+		}
+		if expire > now {
+			held = true
+			return false
+		}
+		return true
+	})
+	return
+}
+
+// shouldDelayDelete tells whether the deletion of the freed inode should be delayed.
+// The orphan inode held by the clients is kept until all the holds expire, and the one never
+// held is kept for the delay after it was last accessed.
+func (mp *metaPartition) shouldDelayDelete(inode *Inode) bool {
+	if inode.ShouldDelete() {
+		return false
+	}
+	if held, tracked := mp.orphanHeld(inode.Inode, time.Now().Unix()); tracked {
+		return held
+	}
+	return inode.ShouldDelayDelete()
+}
+
+// isRelinked tells whether the freed inode has been linked again, e.g. a temporary file
+// linked into the namespace by linkat, so it must not be deleted.
+func isRelinked(inode *Inode) bool {
+	inode.RLock()
+	defer inode.RUnlock()
+	return inode.Flag&DeleteMarkFlag != DeleteMarkFlag && inode.NLink > 0 && !proto.IsDir(inode.Type)
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestShouldDelayDeleteOrphan(t *testing.T) {
+	now := time.Now().Unix()
+	unheld := NewInode(2, 0644)
+	unheld.AccessTime = now
+	held := NewInode(3, 0644)
+	expired := NewInode(4, 0644)
+	expired.AccessTime = now
+	marked := NewInode(5, 0644)
+	marked.SetDeleteMark()
+	for _, inode := range []*Inode{unheld, held, expired, marked} {
+		inode.NLink = 0
+	}
+	mp := newPermissionTestPartition(unheld, held, expired, marked)
+
+	hold := func(ino uint64, expire int64) {
+		extend := NewExtend(ino)
+		extend.Put([]byte(proto.OrphanHoldXAttr(ino)), []byte(strconv.FormatInt(expire, 10)))
+		mp.extendTree.ReplaceOrInsert(extend, true)
+	}
+	hold(3, now+60)
+	hold(4, now-1)
+	hold(5, now+60)
+
+	cases := []struct {
+		inode  *Inode
+		expect bool
+	}{
+		{unheld, true},
+		{held, true},
+		{expired, false},
+		{marked, false},
+	}
+	for i, c := range cases {
+		if got := mp.shouldDelayDelete(c.inode); got != c.expect {
+			t.Errorf("case %v: ino(%v) expect delay(%v) but got(%v)", i, c.inode.Inode, c.expect, got)
+		}
+	}
+
+	if held, tracked := mp.orphanHeld(unheld.Inode, now); held || tracked {
+		t.Errorf("unheld inode: held(%v) tracked(%v)", held, tracked)
+	}
+}
+
+func TestOrphanHeldByDeadClient(t *testing.T) {
+	now := time.Now().Unix()
+	orphan := NewInode(2, 0644)
+	orphan.NLink = 0
+	mp := newPermissionTestPartition(orphan)
+	mp.manager = &metadataManager{deadClients: newDeadClientSet()}
+	extend := NewExtend(orphan.Inode)
+	extend.Put([]byte(proto.OrphanHoldXAttr(1)), []byte(strconv.FormatInt(now+60, 10)))
+	mp.extendTree.ReplaceOrInsert(extend, true)
+
+	if held, _ := mp.orphanHeld(orphan.Inode, now); !held {
+		t.Fatalf("orphan inode is held by client 1")
+	}
+	if added := mp.manager.deadClients.update([]uint64{1}); !added[1] {
+		t.Fatalf("client 1 is newly dead")
+	}
+	if held, tracked := mp.orphanHeld(orphan.Inode, now); held || !tracked {
+		t.Errorf("hold of the dead client: held(%v) tracked(%v)", held, tracked)
+	}
+	if mp.shouldDelayDelete(orphan) {
+		t.Errorf("orphan inode held by the dead client is deleted")
+	}
+}
+
+func TestIsRelinked(t *testing.T) {
+	orphan := NewInode(2, 0644)
+	orphan.NLink = 0
+	linked := NewInode(3, 0644)
+	if isRelinked(orphan) {
+		t.Errorf("orphan inode is not relinked")
+	}
+	if !isRelinked(linked) {
+		t.Errorf("linked inode is relinked")
+	}
+	linked.SetDeleteMark()
+	if isRelinked(linked) {
+		t.Errorf("marked inode is not relinked")
+	}
+}
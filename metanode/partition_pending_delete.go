@@ -0,0 +1,194 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+	"golang.org/x/time/rate"
+)
+
+const (
+	PendingDeleteInterval = time.Minute
+	// pendingDeleteBatchSize is the max number of dentries of a directory collected at a time.
+	pendingDeleteBatchSize = 1000
+)
+
+// pendingDeleteWorker deletes the directories removed in background. The client renames such
+// a directory into the pending delete directory, and it is deleted in two steps: the partition
+// holding the pending delete directory marks the directories under it, then the partition holding
+// a marked directory, which holds its dentries as well, deletes the dentries and releases the
+// directory once it is empty. The subdirectories are marked before their dentries are deleted,
+// so they are deleted the same way by their own partitions.
+func (mp *metaPartition) pendingDeleteWorker() {
+	t := time.NewTicker(PendingDeleteInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-mp.stopC:
+			log.LogDebugf("[metaPartition] pendingDeleteWorker stop partition: %v", mp.config.PartitionId)
+			return
+		case <-t.C:
+			if _, isLeader := mp.IsLeader(); !isLeader {
+				continue
+			}
+			mp.deletePending()
+		}
+	}
+}
+
+func (mp *metaPartition) deletePending() {
+	var (
+		pendingDirs []uint64
+		markedDirs  []uint64
+	)
+	mp.extendTree.GetTree().Ascend(func(i BtreeItem) bool {
+		extend := i.(*Extend)
+		if _, ok := extend.Get([]byte(proto.PendingDeleteDirXAttr)); ok {
+			pendingDirs = append(pendingDirs, extend.inode)
+		}
+		if _, ok := extend.Get([]byte(proto.PendingDeleteXAttr)); ok {
+			markedDirs = append(markedDirs, extend.inode)
+		}
+		return true
+	})
+	if len(pendingDirs) == 0 && len(markedDirs) == 0 {
+		return
+	}
+
+	views, err := mp.remoteViews.get(mp.config.VolName)
+	if err != nil {
+		log.LogErrorf("deletePending: get meta partitions fail: volume(%v) err(%v)", mp.config.VolName, err)
+		return
+	}
+	for _, dir := range pendingDirs {
+		mp.markPendingEntries(views, dir)
+	}
+	limit := PendingDeleteRate()
+	limiter := rate.NewLimiter(rate.Limit(limit), int(limit))
+	for _, dir := range markedDirs {
+		if err = mp.deleteMarkedDir(views, dir, limiter); err != nil {
+			log.LogWarnf("deletePending: partition(%v) dir(%v) err(%v)", mp.config.PartitionId, dir, err)
+			return
+		}
+	}
+}
+
+// markPendingEntries marks the directories under the pending delete directory to be deleted,
+// and removes the entries of the directories already deleted.
+func (mp *metaPartition) markPendingEntries(views []*proto.MetaPartitionView, dir uint64) {
+	entries := mp.childDentries(dir, 0)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	for _, den := range entries {
+		if !proto.IsDir(den.Type) {
+			mp.deletePendingDentry(views, den)
+			continue
+		}
+		view, err := findPartitionView(views, den.Inode)
+		if err != nil {
+			log.LogWarnf("markPendingEntries: partition(%v) entry(%v) err(%v)", mp.config.PartitionId, den, err)
+			continue
+		}
+		infos, err := mp.batchGetRemoteInodes(view, []uint64{den.Inode})
+		if err != nil {
+			log.LogWarnf("markPendingEntries: get inode fail: partition(%v) entry(%v) err(%v)", mp.config.PartitionId, den, err)
+			continue
+		}
+		if len(infos) == 0 {
+			mp.deletePendingDentry(views, den)
+			continue
+		}
+		if err = mp.setRemoteXAttr(views, den.Inode, proto.PendingDeleteXAttr, now); err != nil {
+			log.LogWarnf("markPendingEntries: mark dir fail: partition(%v) entry(%v) err(%v)", mp.config.PartitionId, den, err)
+		}
+	}
+}
+
+// deleteMarkedDir deletes the dentries of the marked directory at the limited rate,
+// and releases the directory once it is empty.
+func (mp *metaPartition) deleteMarkedDir(views []*proto.MetaPartitionView, dir uint64, limiter *rate.Limiter) (err error) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	for {
+		children := mp.childDentries(dir, pendingDeleteBatchSize)
+		if len(children) == 0 {
+			break
+		}
+		for _, den := range children {
+			mp.delayBackground()
+			limiter.Wait(context.Background())
+			if proto.IsDir(den.Type) {
+				if err = mp.setRemoteXAttr(views, den.Inode, proto.PendingDeleteXAttr, now); err != nil {
+					return
+				}
+			}
+			if err = mp.deletePendingDentry(views, den); err != nil {
+				return
+			}
+		}
+	}
+
+	// Remove the mark first, so that the directory is never unlinked twice.
+	extend := NewExtend(dir)
+	extend.Put([]byte(proto.PendingDeleteXAttr), nil)
+	if _, err = mp.putExtend(opFSMRemoveXAttr, extend); err != nil {
+		return
+	}
+	val, err := NewInode(dir, 0).Marshal()
+	if err != nil {
+		return
+	}
+	if _, err = mp.submit(opFSMUnlinkInode, val); err != nil {
+		return
+	}
+	log.LogInfof("deleteMarkedDir: delete dir: partition(%v) dir(%v)", mp.config.PartitionId, dir)
+	return
+}
+
+// deletePendingDentry deletes the dentry and releases the inode if it is not a directory,
+// a directory is released by its own partition.
+func (mp *metaPartition) deletePendingDentry(views []*proto.MetaPartitionView, den *Dentry) (err error) {
+	val, err := (&Dentry{ParentId: den.ParentId, Name: den.Name}).Marshal()
+	if err != nil {
+		return
+	}
+	r, err := mp.submit(opFSMDeleteDentry, val)
+	if err != nil {
+		return
+	}
+	resp := r.(*DentryResponse)
+	if resp.Status != proto.OpOk || proto.IsDir(resp.Msg.Type) {
+		return
+	}
+	if e := mp.releaseRemoteInode(views, resp.Msg.Inode); e != nil {
+		log.LogWarnf("deletePendingDentry: release inode fail: partition(%v) dentry(%v) ino(%v) err(%v)",
+			mp.config.PartitionId, den, resp.Msg.Inode, e)
+	}
+	return
+}
+
+// childDentries returns at most limit dentries of the directory, all of them if limit is zero.
+func (mp *metaPartition) childDentries(dir uint64, limit int) (children []*Dentry) {
+	begin := &Dentry{ParentId: dir}
+	end := &Dentry{ParentId: dir + 1}
+	mp.dentryTree.GetTree().AscendRange(begin, end, func(i BtreeItem) bool {
+		children = append(children, i.(*Dentry))
+		return limit == 0 || len(children) < limit
+	})
+	return
+}
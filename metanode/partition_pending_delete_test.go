@@ -0,0 +1,45 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestChildDentries(t *testing.T) {
+	mp := &metaPartition{dentryTree: NewBtree()}
+	for i := 0; i < 5; i++ {
+		mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 2, Name: fmt.Sprintf("f%v", i), Inode: uint64(i + 10)}, true)
+	}
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: "other", Inode: 100}, true)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 3, Name: "other", Inode: 101}, true)
+
+	if children := mp.childDentries(2, 0); len(children) != 5 {
+		t.Fatalf("all children expect(5) actual(%v)", len(children))
+	}
+	children := mp.childDentries(2, 3)
+	if len(children) != 3 {
+		t.Fatalf("limited children expect(3) actual(%v)", len(children))
+	}
+	for _, child := range children {
+		if child.ParentId != 2 {
+			t.Fatalf("child of another dir: %v", child)
+		}
+	}
+	if children = mp.childDentries(4, 0); len(children) != 0 {
+		t.Fatalf("children of empty dir: %v", children)
+	}
+}
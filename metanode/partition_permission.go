@@ -0,0 +1,172 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"os"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The permissions are checked for the requests carrying the credentials of the users, which are sent by
+// the clients mounted with the server side permission checks. The checks are made by the partition
+// holding the dentries of the parent directory or the inode, so that the inodes to check are local.
+// The volumes enforcing the permissions reject the requests without credentials, see rejectNoCred.
+// The credentials are asserted by the clients, so the checks are not a security boundary against a
+// client holding a token of the volume, which may claim to be any user including root.
+
+// permitted checks the permissions of the user on the inode by its access ACL if it has one, or by its
+// mode bits in the order of the owner, the group and the others as the local file systems do. The root
+// user is permitted anything but executing a file without any execute bit.
+func (i *Inode) permitted(cred *proto.Credential, mask uint32, acl proto.PosixACL) bool {
+	i.RLock()
+	mode, uid, gid := i.Type, i.Uid, i.Gid
+	i.RUnlock()
+	if cred.Uid == 0 {
+		return mask&proto.PermExec == 0 || proto.IsDir(mode) || mode&0111 != 0
+	}
+	if acl != nil {
+		return acl.Permitted(cred, uid, gid, mask)
+	}
+	var perm uint32
+	switch {
+	case cred.Uid == uid:
+		perm = mode >> 6
+	case cred.InGroup(gid):
+		perm = mode >> 3
+	default:
+		perm = mode
+	}
+	return perm&mask == mask
+}
+
+func (mp *metaPartition) getLocalInode(ino uint64) *Inode {
+	item := mp.inodeTree.Get(NewInode(ino, 0))
+	if item == nil {
+		return nil
+	}
+	return item.(*Inode)
+}
+
+// getAccessACL returns the access ACL of the inode, nil if it has none or the ACL is malformed.
+func (mp *metaPartition) getAccessACL(ino uint64) proto.PosixACL {
+	item := mp.extendTree.Get(NewExtend(ino))
+	if item == nil {
+		return nil
+	}
+	value, exist := item.(*Extend).Get([]byte(proto.XAttrPosixACLAccess))
+	if !exist {
+		return nil
+	}
+	acl, err := proto.ParsePosixACL(value)
+	if err != nil {
+		log.LogWarnf("getAccessACL: partition(%v) ino(%v) err(%v)", mp.config.PartitionId, ino, err)
+		return nil
+	}
+	return acl
+}
+
+// checkAccess checks the permissions of the user on the inode, OpOk if the request carries no credential.
+func (mp *metaPartition) checkAccess(cred *proto.Credential, ino uint64, mask uint32) uint8 {
+	if cred == nil {
+		return proto.OpOk
+	}
+	inode := mp.getLocalInode(ino)
+	if inode == nil {
+		return proto.OpNotExistErr
+	}
+	if !inode.permitted(cred, mask, mp.getAccessACL(ino)) {
+		log.LogDebugf("checkAccess: access denied: partition(%v) ino(%v) uid(%v) gid(%v) mask(%v)",
+			mp.config.PartitionId, ino, cred.Uid, cred.Gid, mask)
+		return proto.OpAccessDenied
+	}
+	return proto.OpOk
+}
+
+// checkRemove checks whether the user may remove or replace the dentry of the parent directory, which
+// requires the write and execute permissions on the directory. Only the owners of the directory and the
+// child may do it if the sticky bit of the directory is set.
+func (mp *metaPartition) checkRemove(cred *proto.Credential, parentID uint64, name string) uint8 {
+	if status := mp.checkAccess(cred, parentID, proto.PermWrite|proto.PermExec); status != proto.OpOk || cred == nil {
+		return status
+	}
+	parent := mp.getLocalInode(parentID)
+	parent.RLock()
+	mode, owner := parent.Type, parent.Uid
+	parent.RUnlock()
+	if os.FileMode(mode)&os.ModeSticky == 0 || cred.Uid == 0 || cred.Uid == owner {
+		return proto.OpOk
+	}
+	dentry, status := mp.getDentry(&Dentry{ParentId: parentID, Name: name})
+	if status != proto.OpOk {
+		return status
+	}
+	var childOwner uint32
+	if child := mp.getLocalInode(dentry.Inode); child != nil {
+		child.RLock()
+		childOwner = child.Uid
+		child.RUnlock()
+	} else {
+		views, err := mp.remoteViews.get(mp.config.VolName)
+		if err != nil {
+			return proto.OpAgain
+		}
+		view, err := findPartitionView(views, dentry.Inode)
+		if err != nil {
+			return proto.OpAgain
+		}
+		infos, err := mp.batchGetRemoteInodes(view, []uint64{dentry.Inode})
+		if err != nil || len(infos) == 0 {
+			return proto.OpAgain
+		}
+		childOwner = infos[0].Uid
+	}
+	if cred.Uid != childOwner {
+		return proto.OpAccessDenied
+	}
+	return proto.OpOk
+}
+
+// checkSetAttr checks whether the user may change the attributes of the inode as chmod(2), chown(2) and
+// utimes(2) do: the mode by the owner, the owner by root only, the group by the owner to one of the
+// groups of the owner, and the times by the owner or the users with the write permission.
+func (mp *metaPartition) checkSetAttr(req *SetattrRequest) uint8 {
+	var cred = req.Cred
+	if cred == nil || cred.Uid == 0 {
+		return proto.OpOk
+	}
+	inode := mp.getLocalInode(req.Inode)
+	if inode == nil {
+		return proto.OpNotExistErr
+	}
+	inode.RLock()
+	uid, gid := inode.Uid, inode.Gid
+	inode.RUnlock()
+	isOwner := cred.Uid == uid
+	if req.Valid&proto.AttrMode != 0 && !isOwner {
+		return proto.OpNotPerm
+	}
+	if req.Valid&proto.AttrUid != 0 && req.Uid != uid {
+		return proto.OpNotPerm
+	}
+	if req.Valid&proto.AttrGid != 0 && req.Gid != gid && (!isOwner || !cred.InGroup(req.Gid)) {
+		return proto.OpNotPerm
+	}
+	if req.Valid&(proto.AttrAccessTime|proto.AttrModifyTime) != 0 && !isOwner && !inode.permitted(cred, proto.PermWrite, mp.getAccessACL(req.Inode)) {
+		return proto.OpAccessDenied
+	}
+	return proto.OpOk
+}
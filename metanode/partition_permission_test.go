@@ -0,0 +1,159 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func newPermissionTestPartition(inodes ...*Inode) *metaPartition {
+	mp := &metaPartition{
+		config:     &MetaPartitionConfig{PartitionId: 1, Start: 1, End: 100},
+		inodeTree:  NewBtree(),
+		dentryTree: NewBtree(),
+		extendTree: NewBtree(),
+	}
+	for _, ino := range inodes {
+		mp.inodeTree.ReplaceOrInsert(ino, true)
+	}
+	return mp
+}
+
+func newOwnedInode(ino uint64, mode, uid, gid uint32) *Inode {
+	inode := NewInode(ino, mode)
+	inode.Uid, inode.Gid = uid, gid
+	return inode
+}
+
+func TestCheckAccess(t *testing.T) {
+	mp := newPermissionTestPartition(
+		newOwnedInode(1, uint32(os.ModeDir|0750), 100, 200),
+		newOwnedInode(2, 0640, 100, 200),
+	)
+	owner := &proto.Credential{Uid: 100, Gid: 100}
+	member := &proto.Credential{Uid: 101, Gid: 101, Gids: []uint32{200}}
+	other := &proto.Credential{Uid: 102, Gid: 102}
+	root := &proto.Credential{}
+	cases := []struct {
+		cred   *proto.Credential
+		ino    uint64
+		mask   uint32
+		expect uint8
+	}{
+		{nil, 2, proto.PermWrite, proto.OpOk},
+		{owner, 2, proto.PermRead | proto.PermWrite, proto.OpOk},
+		{owner, 2, proto.PermExec, proto.OpAccessDenied},
+		{member, 2, proto.PermRead, proto.OpOk},
+		{member, 2, proto.PermWrite, proto.OpAccessDenied},
+		{member, 1, proto.PermRead | proto.PermExec, proto.OpOk},
+		{other, 1, proto.PermExec, proto.OpAccessDenied},
+		{root, 2, proto.PermRead | proto.PermWrite, proto.OpOk},
+		{root, 2, proto.PermExec, proto.OpAccessDenied},
+		{root, 1, proto.PermExec, proto.OpOk},
+		{owner, 3, proto.PermRead, proto.OpNotExistErr},
+	}
+	for i, c := range cases {
+		if status := mp.checkAccess(c.cred, c.ino, c.mask); status != c.expect {
+			t.Fatalf("case(%v) expect(%v) actual(%v)", i, c.expect, status)
+		}
+	}
+}
+
+func TestCheckAccessPosixACL(t *testing.T) {
+	mp := newPermissionTestPartition(newOwnedInode(1, 0640, 100, 200))
+	acl := proto.PosixACL{
+		{Tag: proto.ACLUserObj, Perm: 6},
+		{Tag: proto.ACLUser, Perm: 7, ID: 101},
+		{Tag: proto.ACLGroupObj, Perm: 4},
+		{Tag: proto.ACLGroup, Perm: 6, ID: 300},
+		{Tag: proto.ACLMask, Perm: 6},
+		{Tag: proto.ACLOther, Perm: 0},
+	}
+	parsed, err := proto.ParsePosixACL(acl.Bytes())
+	if err != nil {
+		t.Fatalf("parse posix acl fail: err(%v)", err)
+	}
+	if parsed.Mode() != 0660 {
+		t.Fatalf("mode of the acl expect(0660) actual(%o)", parsed.Mode())
+	}
+	extend := NewExtend(1)
+	extend.Put([]byte(proto.XAttrPosixACLAccess), acl.Bytes())
+	mp.extendTree.ReplaceOrInsert(extend, true)
+
+	cases := []struct {
+		cred   *proto.Credential
+		mask   uint32
+		expect uint8
+	}{
+		{&proto.Credential{Uid: 101, Gid: 101}, proto.PermRead | proto.PermWrite, proto.OpOk},
+		{&proto.Credential{Uid: 101, Gid: 101}, proto.PermExec, proto.OpAccessDenied}, // masked
+		{&proto.Credential{Uid: 102, Gid: 300}, proto.PermWrite, proto.OpOk},
+		{&proto.Credential{Uid: 102, Gid: 200}, proto.PermWrite, proto.OpAccessDenied},
+		{&proto.Credential{Uid: 102, Gid: 200, Gids: []uint32{300}}, proto.PermWrite, proto.OpOk},
+		{&proto.Credential{Uid: 103, Gid: 103}, proto.PermRead, proto.OpAccessDenied},
+	}
+	for i, c := range cases {
+		if status := mp.checkAccess(c.cred, 1, c.mask); status != c.expect {
+			t.Fatalf("case(%v) expect(%v) actual(%v)", i, c.expect, status)
+		}
+	}
+
+	access, mode := acl.Inherit(0755)
+	if mode != 0640 || access[1].Perm != 7 || access[4].Perm != 4 {
+		t.Fatalf("inherited acl mismatch: mode(%o) acl(%v)", mode, access)
+	}
+}
+
+func TestCheckRemoveSticky(t *testing.T) {
+	mp := newPermissionTestPartition(
+		newOwnedInode(1, uint32(os.ModeDir|os.ModeSticky|0777), 0, 0),
+		newOwnedInode(2, 0644, 100, 100),
+	)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: "a", Inode: 2}, true)
+
+	if status := mp.checkRemove(&proto.Credential{Uid: 100, Gid: 100}, 1, "a"); status != proto.OpOk {
+		t.Fatalf("owner of the child expect(%v) actual(%v)", proto.OpOk, status)
+	}
+	if status := mp.checkRemove(&proto.Credential{Uid: 101, Gid: 101}, 1, "a"); status != proto.OpAccessDenied {
+		t.Fatalf("other user expect(%v) actual(%v)", proto.OpAccessDenied, status)
+	}
+}
+
+func TestCheckSetAttr(t *testing.T) {
+	mp := newPermissionTestPartition(newOwnedInode(1, 0664, 100, 200))
+	owner := &proto.Credential{Uid: 100, Gid: 100, Gids: []uint32{300}}
+	member := &proto.Credential{Uid: 101, Gid: 200}
+	cases := []struct {
+		req    *SetattrRequest
+		expect uint8
+	}{
+		{&SetattrRequest{Inode: 1, Valid: proto.AttrMode, Mode: 0600, Cred: owner}, proto.OpOk},
+		{&SetattrRequest{Inode: 1, Valid: proto.AttrMode, Mode: 0600, Cred: member}, proto.OpNotPerm},
+		{&SetattrRequest{Inode: 1, Valid: proto.AttrUid, Uid: 101, Cred: owner}, proto.OpNotPerm},
+		{&SetattrRequest{Inode: 1, Valid: proto.AttrGid, Gid: 300, Cred: owner}, proto.OpOk},
+		{&SetattrRequest{Inode: 1, Valid: proto.AttrGid, Gid: 400, Cred: owner}, proto.OpNotPerm},
+		{&SetattrRequest{Inode: 1, Valid: proto.AttrModifyTime, Cred: member}, proto.OpOk},
+		{&SetattrRequest{Inode: 1, Valid: proto.AttrModifyTime, Cred: &proto.Credential{Uid: 102, Gid: 102}}, proto.OpAccessDenied},
+		{&SetattrRequest{Inode: 1, Valid: proto.AttrUid, Uid: 101, Cred: &proto.Credential{}}, proto.OpOk},
+	}
+	for i, c := range cases {
+		if status := mp.checkSetAttr(c.req); status != c.expect {
+			t.Fatalf("case(%v) expect(%v) actual(%v)", i, c.expect, status)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/errors"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	PunchHoleInterval    = time.Minute
+	PunchHoleBatchCount  = 1000
+	MaxPendingPunchHoles = 100000
+)
+
+// PunchHole removes the range of the file from its extents for fallocate with FALLOC_FL_PUNCH_HOLE,
+// the extents in the range are deleted and the ranges of the extents partially in it are punched.
+func (mp *metaPartition) PunchHole(req *PunchHoleReq, p *Packet) (err error) {
+	val := make([]byte, 32)
+	binary.BigEndian.PutUint64(val, req.Inode)
+	binary.BigEndian.PutUint64(val[8:], req.Offset)
+	binary.BigEndian.PutUint64(val[16:], req.Size)
+	binary.BigEndian.PutUint64(val[24:], uint64(Now.GetCurrentTime().Unix()))
+	resp, err := mp.submit(opFSMPunchHole, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.PacketErrorWithBody(resp.(uint8), nil)
+	return
+}
+
+func (mp *metaPartition) fsmPunchHole(inode, offset, size uint64, mt int64) (status uint8) {
+	item := mp.inodeTree.CopyGet(NewInode(inode, 0))
+	if item == nil {
+		return proto.OpNotExistErr
+	}
+	ino := item.(*Inode)
+	if ino.ShouldDelete() {
+		return proto.OpNotExistErr
+	}
+	if !proto.IsRegular(ino.Type) {
+		return proto.OpArgMismatchErr
+	}
+	delExtents, holes := ino.PunchHole(offset, size, mt)
+	mp.updateSharedInode(ino)
+	log.LogInfof("fsmPunchHole inode(%v) offset(%v) size(%v) exts(%v) holes(%v)", inode, offset, size, delExtents, holes)
+	mp.extDelCh <- delExtents
+	mp.punchHoles(holes)
+	return proto.OpOk
+}
+
+// punchHoles queues the ranges of the extents released by truncating the files or punching holes in them.
+// The queue is not persisted, the ranges lost on restart or leader change only leave the space unreclaimed,
+// and the space is reclaimed when the extents are deleted as a whole.
+func (mp *metaPartition) punchHoles(holes []proto.ExtentKey) {
+	if len(holes) == 0 {
+		return
+	}
+	select {
+	case mp.extPunchCh <- holes:
+	default:
+		log.LogWarnf("punchHoles: queue is full, partition(%v) drop holes(%v)", mp.config.PartitionId, holes)
+	}
+}
+
+// punchHoleWorker sends the queued ranges to the data partitions in batches, so that the data nodes
+// punch holes in the extents to reclaim the space of them. Only the leader sends them,
+// the followers apply the same truncations and drop their queues.
+func (mp *metaPartition) punchHoleWorker() {
+	t := time.NewTicker(PunchHoleInterval)
+	defer t.Stop()
+	var (
+		pending      = make(map[uint64][]*proto.ExtentKey)
+		pendingCount int
+	)
+	for {
+		select {
+		case <-mp.stopC:
+			log.LogDebugf("[metaPartition] punchHoleWorker stop partition: %v", mp.config.PartitionId)
+			return
+		case holes := <-mp.extPunchCh:
+			if pendingCount >= MaxPendingPunchHoles {
+				log.LogWarnf("punchHoleWorker: too many pending holes, partition(%v) drop holes(%v)", mp.config.PartitionId, holes)
+				continue
+			}
+			for i := range holes {
+				hole := holes[i]
+				pending[hole.PartitionId] = append(pending[hole.PartitionId], &hole)
+				pendingCount++
+			}
+		case <-t.C:
+			if _, isLeader := mp.IsLeader(); !isLeader {
+				pending, pendingCount = make(map[uint64][]*proto.ExtentKey), 0
+				continue
+			}
+			for partitionID, eks := range pending {
+				for len(eks) > 0 {
+					n := len(eks)
+					if n > PunchHoleBatchCount {
+						n = PunchHoleBatchCount
+					}
+					if err := mp.doBatchPunchExtentsByPartition(partitionID, eks[:n]); err != nil {
+						log.LogWarnf("punchHoleWorker: partition(%v) dataPartition(%v) err(%v)", mp.config.PartitionId, partitionID, err)
+						break
+					}
+					eks = eks[n:]
+					pendingCount -= n
+				}
+				if len(eks) == 0 {
+					delete(pending, partitionID)
+				} else {
+					pending[partitionID] = eks
+				}
+			}
+		}
+	}
+}
+
+func (mp *metaPartition) doBatchPunchExtentsByPartition(partitionID uint64, exts []*proto.ExtentKey) (err error) {
+	if exts = mp.excludeSharedExtents(exts); len(exts) == 0 {
+		return
+	}
+	dp := mp.vol.GetPartition(partitionID)
+	if dp == nil {
+		return errors.NewErrorf("unknown dataPartitionID=%d in vol", partitionID)
+	}
+	conn, err := mp.config.ConnPool.GetConnect(dp.Hosts[0])
+	defer func() {
+		if err != nil {
+			mp.config.ConnPool.PutConnect(conn, ForceClosedConnect)
+		} else {
+			mp.config.ConnPool.PutConnect(conn, NoClosedConnect)
+		}
+	}()
+	if err != nil {
+		return errors.NewErrorf("get conn from pool %s, extents partitionId=%d", err.Error(), partitionID)
+	}
+	p := NewPacketToBatchPunchExtent(dp, exts)
+	if err = p.WriteToConn(conn); err != nil {
+		return errors.NewErrorf("write to dataNode %s, %s", p.GetUniqueLogId(), err.Error())
+	}
+	if err = p.ReadFromConn(conn, proto.BatchDeleteExtentReadDeadLineTime); err != nil {
+		return errors.NewErrorf("read response from dataNode %s, %s", p.GetUniqueLogId(), err.Error())
+	}
+	if p.ResultCode != proto.OpOk {
+		err = errors.NewErrorf("[doBatchPunchExtentsByPartition] %s response: %s", p.GetUniqueLogId(), p.GetResultMsg())
+	}
+	return
+}
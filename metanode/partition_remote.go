@@ -0,0 +1,207 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// The background tasks of a partition sometimes have to operate the metadata held by
+// other partitions of the volume, such as the part inodes of an expired multipart upload.
+// The requests are sent to the leaders of those partitions like the client does.
+
+// remoteCred is sent with the requests checked against the permissions, which the partition
+// makes as root on behalf of the requests already permitted, or of the background tasks.
+var remoteCred = &proto.Credential{}
+
+// RemoteViewsTTL is how long the meta partitions of the volume are cached for the requests
+// served with the help of other partitions, e.g. the sizes of the files in a directory.
+const RemoteViewsTTL = 5 * time.Minute
+
+type remoteViews struct {
+	sync.Mutex
+	views      []*proto.MetaPartitionView
+	updateTime time.Time
+}
+
+// get returns the meta partitions of the volume, which are got from the master once they expire.
+func (v *remoteViews) get(volName string) (views []*proto.MetaPartitionView, err error) {
+	v.Lock()
+	defer v.Unlock()
+	if v.views != nil && time.Since(v.updateTime) <= RemoteViewsTTL {
+		return v.views, nil
+	}
+	if views, err = masterClient.ClientAPI().GetMetaPartitions(volName); err != nil {
+		return
+	}
+	v.views, v.updateTime = views, time.Now()
+	return
+}
+
+func findPartitionView(views []*proto.MetaPartitionView, ino uint64) (view *proto.MetaPartitionView, err error) {
+	for _, v := range views {
+		if v.Start <= ino && ino <= v.End {
+			view = v
+			break
+		}
+	}
+	if view == nil || view.LeaderAddr == "" {
+		return nil, fmt.Errorf("no leader of meta partition found for inode(%v)", ino)
+	}
+	return
+}
+
+// releaseRemoteInode unlinks and evicts the inode on the leader of the partition it belongs to.
+func (mp *metaPartition) releaseRemoteInode(views []*proto.MetaPartitionView, ino uint64) (err error) {
+	var view *proto.MetaPartitionView
+	if view, err = findPartitionView(views, ino); err != nil {
+		return
+	}
+	if _, err = mp.sendRemoteRequest(view.LeaderAddr, proto.OpMetaUnlinkInode, &proto.UnlinkInodeRequest{
+		VolName:     mp.config.VolName,
+		PartitionID: view.PartitionID,
+		Inode:       ino,
+	}); err != nil {
+		return
+	}
+	_, err = mp.sendRemoteRequest(view.LeaderAddr, proto.OpMetaEvictInode, &proto.EvictInodeRequest{
+		VolName:     mp.config.VolName,
+		PartitionID: view.PartitionID,
+		Inode:       ino,
+	})
+	return
+}
+
+// lookupRemoteDentry returns the inode the dentry points to, or zero if the dentry does not exist.
+func (mp *metaPartition) lookupRemoteDentry(views []*proto.MetaPartitionView, parentID uint64, name string) (ino uint64, err error) {
+	var view *proto.MetaPartitionView
+	if view, err = findPartitionView(views, parentID); err != nil {
+		return
+	}
+	var packet *proto.Packet
+	if packet, err = mp.sendRemoteRequest(view.LeaderAddr, proto.OpMetaLookup, &proto.LookupRequest{
+		VolName:     mp.config.VolName,
+		PartitionID: view.PartitionID,
+		ParentID:    parentID,
+		Name:        name,
+		Cred:        remoteCred,
+	}); err != nil || packet.ResultCode == proto.OpNotExistErr {
+		return
+	}
+	resp := new(proto.LookupResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		return
+	}
+	return resp.Inode, nil
+}
+
+func (mp *metaPartition) deleteRemoteDentry(views []*proto.MetaPartitionView, parentID uint64, name string) (err error) {
+	var view *proto.MetaPartitionView
+	if view, err = findPartitionView(views, parentID); err != nil {
+		return
+	}
+	_, err = mp.sendRemoteRequest(view.LeaderAddr, proto.OpMetaDeleteDentry, &proto.DeleteDentryRequest{
+		VolName:     mp.config.VolName,
+		PartitionID: view.PartitionID,
+		ParentID:    parentID,
+		Name:        name,
+		Cred:        remoteCred,
+	})
+	return
+}
+
+// updateRemoteDentry points the dentry to the inode.
+func (mp *metaPartition) updateRemoteDentry(views []*proto.MetaPartitionView, parentID uint64, name string, ino uint64) (err error) {
+	var view *proto.MetaPartitionView
+	if view, err = findPartitionView(views, parentID); err != nil {
+		return
+	}
+	_, err = mp.sendRemoteRequest(view.LeaderAddr, proto.OpMetaUpdateDentry, &proto.UpdateDentryRequest{
+		VolName:     mp.config.VolName,
+		PartitionID: view.PartitionID,
+		ParentID:    parentID,
+		Name:        name,
+		Inode:       ino,
+		Cred:        remoteCred,
+	})
+	return
+}
+
+func (mp *metaPartition) setRemoteXAttr(views []*proto.MetaPartitionView, ino uint64, key, value string) (err error) {
+	var view *proto.MetaPartitionView
+	if view, err = findPartitionView(views, ino); err != nil {
+		return
+	}
+	_, err = mp.sendRemoteRequest(view.LeaderAddr, proto.OpMetaSetXAttr, &proto.SetXAttrRequest{
+		VolName:     mp.config.VolName,
+		PartitionId: view.PartitionID,
+		Inode:       ino,
+		Key:         key,
+		Value:       value,
+	})
+	return
+}
+
+func (mp *metaPartition) batchGetRemoteInodes(view *proto.MetaPartitionView, inodes []uint64) (infos []*proto.InodeInfo, err error) {
+	var packet *proto.Packet
+	if packet, err = mp.sendRemoteRequest(view.LeaderAddr, proto.OpMetaBatchInodeGet, &proto.BatchInodeGetRequest{
+		VolName:     mp.config.VolName,
+		PartitionID: view.PartitionID,
+		Inodes:      inodes,
+	}); err != nil {
+		return
+	}
+	resp := new(proto.BatchInodeGetResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		return
+	}
+	return resp.Infos, nil
+}
+
+// sendRemoteRequest sends the request to the target meta node, a not exist result is not taken as an error.
+func (mp *metaPartition) sendRemoteRequest(target string, opcode uint8, req interface{}) (packet *proto.Packet, err error) {
+	var conn net.Conn
+	conn, err = mp.config.ConnPool.GetConnect(target)
+	defer func() {
+		if err != nil {
+			mp.config.ConnPool.PutConnect(conn, ForceClosedConnect)
+		} else {
+			mp.config.ConnPool.PutConnect(conn, NoClosedConnect)
+		}
+	}()
+	if err != nil {
+		return
+	}
+	packet = proto.NewPacketReqID()
+	packet.Opcode = opcode
+	if err = packet.MarshalData(req); err != nil {
+		return
+	}
+	if err = packet.WriteToConn(conn); err != nil {
+		return
+	}
+	if err = packet.ReadFromConn(conn, proto.ReadDeadlineTime); err != nil {
+		return
+	}
+	if packet.ResultCode != proto.OpOk && packet.ResultCode != proto.OpNotExistErr {
+		err = fmt.Errorf("request(%v) error(%v)", packet.GetUniqueLogId(), packet.GetResultMsg())
+	}
+	return
+}
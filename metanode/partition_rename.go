@@ -0,0 +1,123 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// TxTypeRename is the transaction of a rename across meta partitions, coordinated by the client
+// on the meta partition holding the renamed inode. The client goes like this:
+//
+//	begin      record the intent of the rename
+//	ilink      link the renamed inode once more for the destination dentry
+//	prepare    the link is taken
+//	dcreate    or dupdate, point the destination dentry to the inode
+//	commit     the rename takes effect, with the inode overwritten recorded in the intent
+//	ddelete    delete the source dentry
+//	finish     remove the record, then unlink the inode for the source dentry
+//	           and release the inode overwritten
+//
+// If the client fails in the middle, the recovery of the meta partition rolls back the renames
+// not committed, and completes the committed ones, with renameTxHandler.
+const TxTypeRename = TxType(proto.TxTypeRename)
+
+func init() {
+	RegisterTxHandler(TxTypeRename, renameTxHandler{})
+}
+
+type renameTxHandler struct{}
+
+// Commit deletes the source dentry if it still points to the renamed inode.
+func (renameTxHandler) Commit(mp *metaPartition, tx *Transaction) (err error) {
+	var intent *proto.RenameIntent
+	if intent, err = proto.UnmarshalRenameIntent(tx.payload); err != nil {
+		return
+	}
+	var views []*proto.MetaPartitionView
+	if views, err = mp.remoteViews.get(mp.config.VolName); err != nil {
+		return
+	}
+	var srcIno uint64
+	if srcIno, err = mp.lookupRemoteDentry(views, intent.SrcParentID, intent.SrcName); err != nil {
+		return
+	}
+	if srcIno != intent.Inode {
+		return
+	}
+	return mp.deleteRemoteDentry(views, intent.SrcParentID, intent.SrcName)
+}
+
+// Rollback points the destination dentry back to the inode overwritten, or deletes it,
+// if it points to the renamed inode. The destination dentry is only changed once prepared.
+func (renameTxHandler) Rollback(mp *metaPartition, tx *Transaction) (err error) {
+	if tx.state == TxStateBegin {
+		return
+	}
+	var intent *proto.RenameIntent
+	if intent, err = proto.UnmarshalRenameIntent(tx.payload); err != nil {
+		return
+	}
+	var views []*proto.MetaPartitionView
+	if views, err = mp.remoteViews.get(mp.config.VolName); err != nil {
+		return
+	}
+	var dstIno uint64
+	if dstIno, err = mp.lookupRemoteDentry(views, intent.DstParentID, intent.DstName); err != nil {
+		return
+	}
+	if dstIno != intent.Inode {
+		return
+	}
+	if intent.OldInode != 0 {
+		return mp.updateRemoteDentry(views, intent.DstParentID, intent.DstName, intent.OldInode)
+	}
+	return mp.deleteRemoteDentry(views, intent.DstParentID, intent.DstName)
+}
+
+// Release unlinks the renamed inode for the source dentry once committed, or for the destination
+// dentry once rolled back, and releases the inode overwritten by a committed rename. The link may
+// not be taken yet in the begin state, so nothing is released then, an extra link is only a leak.
+func (renameTxHandler) Release(mp *metaPartition, tx *Transaction) {
+	if tx.state == TxStateBegin {
+		return
+	}
+	intent, err := proto.UnmarshalRenameIntent(tx.payload)
+	if err != nil {
+		log.LogWarnf("renameTxHandler: bad intent: partition(%v) tx(%v) err(%v)", mp.config.PartitionId, tx, err)
+		return
+	}
+	var val []byte
+	if val, err = NewInode(intent.Inode, 0).Marshal(); err != nil {
+		return
+	}
+	if _, err = mp.submit(opFSMUnlinkInode, val); err != nil {
+		log.LogWarnf("renameTxHandler: unlink renamed inode fail: partition(%v) ino(%v) err(%v)",
+			mp.config.PartitionId, intent.Inode, err)
+		return
+	}
+	if tx.state == TxStateCommitted && intent.OldInode != 0 {
+		var views []*proto.MetaPartitionView
+		if views, err = mp.remoteViews.get(mp.config.VolName); err == nil {
+			err = mp.releaseRemoteInode(views, intent.OldInode)
+		}
+		if err != nil {
+			log.LogWarnf("renameTxHandler: release overwritten inode fail: partition(%v) ino(%v) err(%v)",
+				mp.config.PartitionId, intent.OldInode, err)
+		}
+	}
+	log.LogInfof("renameTxHandler: partition(%v) tx(%v) intent(%v)", mp.config.PartitionId, tx, intent)
+}
@@ -31,6 +31,7 @@ type storeMsg struct {
 	dentryTree    *BTree
 	extendTree    *BTree
 	multipartTree *BTree
+	txTree        *BTree
 }
 
 func (mp *metaPartition) startSchedule(curIndex uint64) {
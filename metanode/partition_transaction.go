@@ -0,0 +1,233 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	TxRecoverInterval = time.Minute
+	// TxTimeout is how long a transaction may stay unchanged before it is taken
+	// as abandoned by its coordinator and resolved by the recovery.
+	TxTimeout = 5 * time.Minute
+)
+
+// A multi-step operation built on the transactions goes like this:
+//
+//	tx, err := mp.TxBegin(txType, payload)  // record the operation
+//	... prepare the participants ...
+//	err = mp.TxPrepare(tx.ID())             // or mp.TxRollback(tx.ID()) if any of them fails
+//	err = mp.TxCommit(tx.ID())              // the operation takes effect from here
+//	... apply the operation to the participants ...
+//	err = mp.TxFinish(tx.ID())              // remove the record
+//	... release what is held for the operation ...
+//
+// If the coordinator fails before the transaction is finished, the recovery of the partition
+// leader rolls back the transactions which are not committed yet, and redoes the committed ones,
+// through the handler registered for the type of the transaction. The clients coordinate their
+// transactions the same way through OpMetaTxBegin, OpMetaTxUpdate and OpMetaTxFinish.
+
+// TxBegin records a new transaction of the type.
+func (mp *metaPartition) TxBegin(txType TxType, payload []byte) (tx *Transaction, err error) {
+	now := time.Now().Unix()
+	tx = &Transaction{
+		id:         fmt.Sprintf("%d_%d_%d", mp.config.PartitionId, time.Now().UnixNano(), rand.Uint32()),
+		txType:     txType,
+		state:      TxStateBegin,
+		payload:    payload,
+		createTime: now,
+		updateTime: now,
+	}
+	if err = mp.submitTx(opFSMTxBegin, tx); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// TxPrepare marks the transaction as prepared.
+func (mp *metaPartition) TxPrepare(id string) error {
+	return mp.updateTx(id, TxStatePrepared, nil)
+}
+
+// TxCommit marks the transaction as committed.
+func (mp *metaPartition) TxCommit(id string) error {
+	return mp.updateTx(id, TxStateCommitted, nil)
+}
+
+// TxRollback marks the transaction as rolled back.
+func (mp *metaPartition) TxRollback(id string) error {
+	return mp.updateTx(id, TxStateRolledBack, nil)
+}
+
+// TxFinish removes the record of a committed or rolled back transaction.
+func (mp *metaPartition) TxFinish(id string) error {
+	return mp.submitTx(opFSMTxRemove, &Transaction{id: id})
+}
+
+// GetTx returns a copy of the transaction, or nil if not exist.
+func (mp *metaPartition) GetTx(id string) *Transaction {
+	item := mp.txTree.Get(&Transaction{id: id})
+	if item == nil {
+		return nil
+	}
+	return item.(*Transaction).Copy().(*Transaction)
+}
+
+// updateTx moves the transaction to the state, the payload is kept if the given one is empty.
+func (mp *metaPartition) updateTx(id string, state TxState, payload []byte) error {
+	return mp.submitTx(opFSMTxUpdate, &Transaction{id: id, state: state, payload: payload, updateTime: time.Now().Unix()})
+}
+
+func (mp *metaPartition) submitTx(op uint32, tx *Transaction) (err error) {
+	var status uint8
+	if status, err = mp.submitTxStatus(op, tx); err != nil {
+		return
+	}
+	if status != proto.OpOk {
+		return fmt.Errorf("transaction(%v) op(%v) state(%v) fail: status(%v)", tx.id, op, tx.state, status)
+	}
+	return nil
+}
+
+func (mp *metaPartition) submitTxStatus(op uint32, tx *Transaction) (status uint8, err error) {
+	var raw []byte
+	if raw, err = tx.Bytes(); err != nil {
+		return
+	}
+	var resp interface{}
+	if resp, err = mp.submit(op, raw); err != nil {
+		return
+	}
+	return resp.(uint8), nil
+}
+
+// TxBeginOp begins a transaction coordinated by the client, and replies the id of it.
+func (mp *metaPartition) TxBeginOp(req *proto.TxBeginRequest, p *Packet) (err error) {
+	if _, ok := getTxHandler(TxType(req.Type)); !ok {
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(fmt.Sprintf("unknown transaction type(%v)", req.Type)))
+		return
+	}
+	var tx *Transaction
+	if tx, err = mp.TxBegin(TxType(req.Type), req.Payload); err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	var reply []byte
+	if reply, err = json.Marshal(&proto.TxBeginResponse{TxID: tx.id}); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+// TxUpdateOp moves the transaction coordinated by the client to the state of the request.
+func (mp *metaPartition) TxUpdateOp(req *proto.TxUpdateRequest, p *Packet) (err error) {
+	tx := &Transaction{id: req.TxID, state: TxState(req.State), payload: req.Payload, updateTime: time.Now().Unix()}
+	var status uint8
+	if status, err = mp.submitTxStatus(opFSMTxUpdate, tx); err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.PacketErrorWithBody(status, nil)
+	return
+}
+
+// TxFinishOp removes the record of the transaction coordinated by the client,
+// a transaction already removed is taken as finished.
+func (mp *metaPartition) TxFinishOp(req *proto.TxFinishRequest, p *Packet) (err error) {
+	var status uint8
+	if status, err = mp.submitTxStatus(opFSMTxRemove, &Transaction{id: req.TxID}); err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	if status == proto.OpNotExistErr {
+		status = proto.OpOk
+	}
+	p.PacketErrorWithBody(status, nil)
+	return
+}
+
+// txRecoverWorker resolves the transactions abandoned by the coordinators.
+func (mp *metaPartition) txRecoverWorker() {
+	t := time.NewTicker(TxRecoverInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-mp.stopC:
+			log.LogDebugf("[metaPartition] txRecoverWorker stop partition: %v", mp.config.PartitionId)
+			return
+		case <-t.C:
+			if _, isLeader := mp.IsLeader(); !isLeader {
+				continue
+			}
+			mp.recoverTxs(time.Now().Add(-TxTimeout).Unix())
+		}
+	}
+}
+
+func (mp *metaPartition) recoverTxs(expireTime int64) {
+	var abandoned = make([]*Transaction, 0)
+	mp.txTree.GetTree().Ascend(func(i BtreeItem) bool {
+		tx := i.(*Transaction)
+		if tx.updateTime < expireTime {
+			abandoned = append(abandoned, tx.Copy().(*Transaction))
+		}
+		return true
+	})
+	for _, tx := range abandoned {
+		if err := mp.recoverTx(tx); err != nil {
+			log.LogWarnf("recoverTxs: recover transaction fail: partition(%v) tx(%v) err(%v)",
+				mp.config.PartitionId, tx, err)
+			continue
+		}
+		log.LogInfof("recoverTxs: transaction recovered: partition(%v) tx(%v)", mp.config.PartitionId, tx)
+	}
+}
+
+func (mp *metaPartition) recoverTx(tx *Transaction) (err error) {
+	handler, ok := getTxHandler(tx.txType)
+	if !ok {
+		return fmt.Errorf("no handler registered for transaction type(%v)", tx.txType)
+	}
+	switch tx.state {
+	case TxStateCommitted:
+		if err = handler.Commit(mp, tx); err != nil {
+			return
+		}
+	default:
+		// not committed yet, so it is presumed to be aborted
+		if err = handler.Rollback(mp, tx); err != nil {
+			return
+		}
+		if tx.state != TxStateRolledBack {
+			if err = mp.TxRollback(tx.id); err != nil {
+				return
+			}
+		}
+	}
+	if err = mp.TxFinish(tx.id); err != nil {
+		return
+	}
+	handler.Release(mp, tx)
+	return
+}
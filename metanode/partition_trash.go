@@ -0,0 +1,199 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	TrashPurgeInterval = 10 * time.Minute
+	secondsPerDay      = 24 * 60 * 60
+)
+
+// trashIndex tracks the trash directories and the inodes moved into the trash held by
+// the partition. It follows the extend tree in the apply path, so the trash worker
+// does not scan the tree.
+type trashIndex struct {
+	sync.Mutex
+	dirs   map[uint64]struct{}
+	inodes map[uint64]int64 // the deletion time of the inodes in the trash
+}
+
+// update indexes the inode by its extended attributes.
+func (t *trashIndex) update(extend *Extend) {
+	_, isDir := extend.Get([]byte(proto.TrashDirXAttr))
+	var deleteTime int64
+	value, isTrashed := extend.Get([]byte(proto.TrashTimeXAttr))
+	if isTrashed {
+		deleteTime, _ = strconv.ParseInt(string(value), 10, 64)
+	}
+	t.Lock()
+	defer t.Unlock()
+	if isDir {
+		if t.dirs == nil {
+			t.dirs = make(map[uint64]struct{})
+		}
+		t.dirs[extend.inode] = struct{}{}
+	} else {
+		delete(t.dirs, extend.inode)
+	}
+	if isTrashed {
+		if t.inodes == nil {
+			t.inodes = make(map[uint64]int64)
+		}
+		t.inodes[extend.inode] = deleteTime
+	} else {
+		delete(t.inodes, extend.inode)
+	}
+}
+
+func (t *trashIndex) remove(ino uint64) {
+	t.Lock()
+	delete(t.dirs, ino)
+	delete(t.inodes, ino)
+	t.Unlock()
+}
+
+// reset rebuilds the index from the extend tree, e.g. after the trees are replaced by a snapshot.
+func (t *trashIndex) reset(extendTree *BTree) {
+	t.Lock()
+	t.dirs, t.inodes = nil, nil
+	t.Unlock()
+	extendTree.Ascend(func(i BtreeItem) bool {
+		t.update(i.(*Extend))
+		return true
+	})
+}
+
+func (t *trashIndex) empty() bool {
+	t.Lock()
+	defer t.Unlock()
+	return len(t.dirs) == 0 && len(t.inodes) == 0
+}
+
+// expired returns the trash directories and the inodes deleted before the expire time.
+func (t *trashIndex) expired(expireTime int64) (dirs, inodes []uint64) {
+	t.Lock()
+	defer t.Unlock()
+	for dir := range t.dirs {
+		dirs = append(dirs, dir)
+	}
+	for ino, deleteTime := range t.inodes {
+		if deleteTime < expireTime {
+			inodes = append(inodes, ino)
+		}
+	}
+	return
+}
+
+// trashWorker purges the expired entries of the volume trash.
+// The entries in the trash directory and the inodes moved into the trash may live
+// in different partitions, so each partition purges its own part of them:
+// the dentries under the trash directories it holds, and the inodes it holds
+// which carry an expired deletion time.
+func (mp *metaPartition) trashWorker() {
+	t := time.NewTicker(TrashPurgeInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-mp.stopC:
+			log.LogDebugf("[metaPartition] trashWorker stop partition: %v", mp.config.PartitionId)
+			return
+		case <-t.C:
+			if _, isLeader := mp.IsLeader(); !isLeader {
+				continue
+			}
+			mp.purgeTrash()
+		}
+	}
+}
+
+func (mp *metaPartition) purgeTrash() {
+	if mp.trash.empty() {
+		return
+	}
+	volName := mp.config.VolName
+	view, err := masterClient.AdminAPI().GetVolumeSimpleInfo(volName)
+	if err != nil {
+		log.LogErrorf("purgeTrash: get volume info fail: volume(%v) err(%v)", volName, err)
+		return
+	}
+	// entries already in the trash are kept until the trash is enabled again
+	if view.TrashDays == 0 {
+		return
+	}
+	expireTime := time.Now().Unix() - int64(view.TrashDays)*secondsPerDay
+
+	trashDirs, expiredInodes := mp.trash.expired(expireTime)
+	for _, dir := range trashDirs {
+		mp.purgeTrashDentries(dir, expireTime)
+	}
+	for _, ino := range expiredInodes {
+		mp.purgeTrashInode(ino)
+	}
+}
+
+func (mp *metaPartition) purgeTrashDentries(dir uint64, expireTime int64) {
+	expired := make([]*Dentry, 0)
+	begin := &Dentry{ParentId: dir}
+	end := &Dentry{ParentId: dir + 1}
+	mp.dentryTree.GetTree().AscendRange(begin, end, func(i BtreeItem) bool {
+		den := i.(*Dentry)
+		if _, _, deleteTime, ok := proto.ParseTrashEntryName(den.Name); ok && deleteTime < expireTime {
+			expired = append(expired, den)
+		}
+		return true
+	})
+	for _, den := range expired {
+		val, err := (&Dentry{ParentId: den.ParentId, Name: den.Name}).Marshal()
+		if err != nil {
+			continue
+		}
+		if _, err = mp.submit(opFSMDeleteDentry, val); err != nil {
+			log.LogWarnf("purgeTrash: delete dentry fail: partition(%v) dentry(%v) err(%v)", mp.config.PartitionId, den, err)
+			return
+		}
+		log.LogInfof("purgeTrash: delete dentry: partition(%v) dentry(%v)", mp.config.PartitionId, den)
+	}
+}
+
+func (mp *metaPartition) purgeTrashInode(ino uint64) {
+	// Remove the deletion time first, so that the inode is never unlinked twice.
+	extend := NewExtend(ino)
+	extend.Put([]byte(proto.TrashTimeXAttr), nil)
+	if _, err := mp.putExtend(opFSMRemoveXAttr, extend); err != nil {
+		log.LogWarnf("purgeTrash: remove xattr fail: partition(%v) ino(%v) err(%v)", mp.config.PartitionId, ino, err)
+		return
+	}
+	val, err := NewInode(ino, 0).Marshal()
+	if err != nil {
+		return
+	}
+	if _, err = mp.submit(opFSMUnlinkInode, val); err != nil {
+		log.LogWarnf("purgeTrash: unlink inode fail: partition(%v) ino(%v) err(%v)", mp.config.PartitionId, ino, err)
+		return
+	}
+	if _, err = mp.submit(opFSMEvictInode, val); err != nil {
+		log.LogWarnf("purgeTrash: evict inode fail: partition(%v) ino(%v) err(%v)", mp.config.PartitionId, ino, err)
+		return
+	}
+	log.LogInfof("purgeTrash: unlink inode: partition(%v) ino(%v)", mp.config.PartitionId, ino)
+}
@@ -0,0 +1,56 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestTrashIndex(t *testing.T) {
+	mp := &metaPartition{extendTree: NewBtree(), inodeTree: NewBtree(), freeList: newFreeList()}
+	setXAttr := func(ino uint64, key, value string) {
+		extend := NewExtend(ino)
+		extend.Put([]byte(key), []byte(value))
+		_ = mp.fsmSetXAttr(extend)
+	}
+	setXAttr(1, proto.TrashDirXAttr, "1")
+	setXAttr(2, proto.TrashTimeXAttr, "100")
+	setXAttr(3, proto.TrashTimeXAttr, "300")
+	setXAttr(4, "user.k", "v")
+
+	dirs, inodes := mp.trash.expired(200)
+	if len(dirs) != 1 || dirs[0] != 1 || len(inodes) != 1 || inodes[0] != 2 {
+		t.Fatalf("expired dirs(%v) inodes(%v)", dirs, inodes)
+	}
+
+	// restored from the trash
+	extend := NewExtend(2)
+	extend.Put([]byte(proto.TrashTimeXAttr), nil)
+	_ = mp.fsmRemoveXAttr(extend)
+	if _, inodes = mp.trash.expired(400); len(inodes) != 1 || inodes[0] != 3 {
+		t.Fatalf("expired inodes(%v) after restore", inodes)
+	}
+	mp.internalDeleteInode(NewInode(3, 0))
+	if _, inodes = mp.trash.expired(400); len(inodes) != 0 {
+		t.Fatalf("expired inodes(%v) after delete", inodes)
+	}
+
+	mp.trash.reset(mp.extendTree)
+	if dirs, _ = mp.trash.expired(400); len(dirs) != 1 || mp.trash.empty() {
+		t.Fatalf("dirs(%v) after reset", dirs)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	TTLScanInterval = 10 * time.Minute
+	// ttlScanBatchSize is the max number of inodes got from a partition in a request.
+	ttlScanBatchSize = 100
+)
+
+// ttlWorker deletes the expired files in the directories with a time to live.
+// The dentries of a directory live in the partition holding the directory, so that
+// partition scans them, while the inodes of the files may live in other partitions
+// and are checked and released through the leaders of those partitions.
+func (mp *metaPartition) ttlWorker() {
+	t := time.NewTicker(TTLScanInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-mp.stopC:
+			log.LogDebugf("[metaPartition] ttlWorker stop partition: %v", mp.config.PartitionId)
+			return
+		case <-t.C:
+			if _, isLeader := mp.IsLeader(); !isLeader {
+				continue
+			}
+			mp.expireTTLDirs(time.Now())
+		}
+	}
+}
+
+func (mp *metaPartition) expireTTLDirs(now time.Time) {
+	var dirs = make(map[uint64]time.Duration)
+	mp.extendTree.GetTree().Ascend(func(i BtreeItem) bool {
+		extend := i.(*Extend)
+		if value, ok := extend.Get([]byte(proto.ExpireTTLXAttr)); ok {
+			if ttl, err := proto.ParseExpireTTL(value); err == nil {
+				dirs[extend.inode] = ttl
+			}
+		}
+		return true
+	})
+	if len(dirs) == 0 {
+		return
+	}
+
+	volName := mp.config.VolName
+	views, err := masterClient.ClientAPI().GetMetaPartitions(volName)
+	if err != nil {
+		log.LogErrorf("expireTTLDirs: get meta partitions fail: volume(%v) err(%v)", volName, err)
+		return
+	}
+	for dir, ttl := range dirs {
+		mp.expireTTLDir(views, dir, now.Add(-ttl))
+	}
+}
+
+// expireTTLDir deletes the regular files in the directory which are not modified since the expire time.
+// Subdirectories are kept, they can have a time to live of their own.
+func (mp *metaPartition) expireTTLDir(views []*proto.MetaPartitionView, dir uint64, expireTime time.Time) {
+	var dentries = make(map[uint64]*Dentry)
+	begin := &Dentry{ParentId: dir}
+	end := &Dentry{ParentId: dir + 1}
+	mp.dentryTree.GetTree().AscendRange(begin, end, func(i BtreeItem) bool {
+		den := i.(*Dentry)
+		if proto.IsRegular(den.Type) {
+			dentries[den.Inode] = den
+		}
+		return true
+	})
+	if len(dentries) == 0 {
+		return
+	}
+	var batches = make(map[*proto.MetaPartitionView][]uint64)
+	for ino := range dentries {
+		view, err := findPartitionView(views, ino)
+		if err != nil {
+			log.LogWarnf("expireTTLDir: partition(%v) dir(%v) ino(%v) err(%v)", mp.config.PartitionId, dir, ino, err)
+			continue
+		}
+		batches[view] = append(batches[view], ino)
+	}
+	for view, inodes := range batches {
+		for len(inodes) > 0 {
+			n := len(inodes)
+			if n > ttlScanBatchSize {
+				n = ttlScanBatchSize
+			}
+			infos, err := mp.batchGetRemoteInodes(view, inodes[:n])
+			inodes = inodes[n:]
+			if err != nil {
+				log.LogWarnf("expireTTLDir: get inodes fail: partition(%v) dir(%v) target(%v) err(%v)",
+					mp.config.PartitionId, dir, view.PartitionID, err)
+				continue
+			}
+			for _, info := range infos {
+				if info.ModifyTime.Before(expireTime) {
+					mp.expireTTLFile(views, dentries[info.Inode])
+				}
+			}
+		}
+	}
+}
+
+func (mp *metaPartition) expireTTLFile(views []*proto.MetaPartitionView, den *Dentry) {
+	if den == nil {
+		return
+	}
+	val, err := (&Dentry{ParentId: den.ParentId, Name: den.Name}).Marshal()
+	if err != nil {
+		return
+	}
+	r, err := mp.submit(opFSMDeleteDentry, val)
+	if err != nil {
+		log.LogWarnf("expireTTLFile: delete dentry fail: partition(%v) dentry(%v) err(%v)", mp.config.PartitionId, den, err)
+		return
+	}
+	resp := r.(*DentryResponse)
+	if resp.Status != proto.OpOk {
+		return
+	}
+	// The dentry may have been pointed to another inode since it was scanned,
+	// release the one actually removed along with the dentry.
+	ino := resp.Msg.Inode
+	if err = mp.releaseRemoteInode(views, ino); err != nil {
+		log.LogWarnf("expireTTLFile: release inode fail: partition(%v) dentry(%v) ino(%v) err(%v)",
+			mp.config.PartitionId, den, ino, err)
+		return
+	}
+	log.LogInfof("expireTTLFile: delete expired file: partition(%v) parent(%v) name(%v) ino(%v)",
+		mp.config.PartitionId, den.ParentId, den.Name, ino)
+}
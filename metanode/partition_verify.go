@@ -0,0 +1,143 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The names of the meta trees in the digests of the verification.
+const (
+	verifyTreeInode     = "inode"
+	verifyTreeDentry    = "dentry"
+	verifyTreeExtend    = "extend"
+	verifyTreeMultipart = "multipart"
+	verifyTreeTx        = "transaction"
+)
+
+// inodeAccessTimeOffset is the offset of the access time in the marshaled value of an inode.
+const inodeAccessTimeOffset = 36
+
+// VerifyReplica starts the verification of the replicas if req.Start is set, which must be sent to
+// the leader, or returns the digests of the meta trees this replica took for the verification.
+func (mp *metaPartition) VerifyReplica(req *proto.MetaPartitionVerifyRequest) (resp *proto.MetaPartitionVerifyResponse, err error) {
+	if req.Start {
+		if _, ok := mp.IsLeader(); !ok {
+			return nil, ErrNotALeader
+		}
+		val := make([]byte, 8)
+		binary.BigEndian.PutUint64(val, req.VerifyID)
+		if _, err = mp.submit(opFSMVerify, val); err != nil {
+			return
+		}
+	}
+	resp = &proto.MetaPartitionVerifyResponse{
+		PartitionID: mp.config.PartitionId,
+		VerifyID:    req.VerifyID,
+	}
+	if last, ok := mp.verifyResult.Load().(*proto.MetaPartitionVerifyResponse); ok && last.VerifyID == req.VerifyID {
+		resp = last
+	}
+	return
+}
+
+// fsmVerify takes the digests of the meta trees at the log index of the verification. They are taken
+// in the apply so that the items are not mutated meanwhile, which stalls the partition for a while.
+func (mp *metaPartition) fsmVerify(verifyID, index uint64) {
+	start := time.Now()
+	resp := &proto.MetaPartitionVerifyResponse{
+		PartitionID: mp.config.PartitionId,
+		VerifyID:    verifyID,
+		ApplyID:     index,
+		Done:        true,
+	}
+	resp.Trees = append(resp.Trees,
+		digestTree(verifyTreeInode, mp.inodeTree, func(item BtreeItem) ([]byte, error) {
+			ino := item.(*Inode)
+			val := ino.MarshalValue()
+			// the access time is set by each replica on its own
+			for i := inodeAccessTimeOffset; i < inodeAccessTimeOffset+8 && i < len(val); i++ {
+				val[i] = 0
+			}
+			return append(ino.MarshalKey(), val...), nil
+		}),
+		digestTree(verifyTreeDentry, mp.dentryTree, func(item BtreeItem) ([]byte, error) {
+			dentry := item.(*Dentry)
+			return append(dentry.MarshalKey(), dentry.MarshalValue()...), nil
+		}),
+		digestTree(verifyTreeExtend, mp.extendTree, func(item BtreeItem) ([]byte, error) {
+			return item.(*Extend).digestBytes(), nil
+		}),
+		digestTree(verifyTreeMultipart, mp.multipartTree, func(item BtreeItem) ([]byte, error) {
+			return item.(*Multipart).Bytes()
+		}),
+		digestTree(verifyTreeTx, mp.txTree, func(item BtreeItem) ([]byte, error) {
+			return item.(*Transaction).Bytes()
+		}),
+	)
+	mp.verifyResult.Store(resp)
+	log.LogInfof("fsmVerify: partition(%v) verifyID(%v) applyID(%v) digests(%v) cost(%v)",
+		mp.config.PartitionId, verifyID, index, resp.Trees, time.Since(start))
+}
+
+// digestTree hashes the items of the tree in order, each prefixed with its length.
+func digestTree(name string, tree *BTree, marshal func(item BtreeItem) ([]byte, error)) *proto.MetaTreeDigest {
+	var (
+		h     hash.Hash64 = fnv.New64a()
+		count uint64
+		size  = make([]byte, 8)
+	)
+	tree.Ascend(func(item BtreeItem) bool {
+		data, err := marshal(item)
+		if err != nil {
+			data = []byte(fmt.Sprintf("marshal error: %v", err))
+		}
+		binary.BigEndian.PutUint64(size, uint64(len(data)))
+		h.Write(size)
+		h.Write(data)
+		count++
+		return true
+	})
+	return &proto.MetaTreeDigest{Tree: name, Count: count, Hash: h.Sum64()}
+}
+
+// digestBytes returns the attributes in the order of the keys, unlike Bytes.
+func (e *Extend) digestBytes() []byte {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	keys := make([]string, 0, len(e.dataMap))
+	for k := range e.dataMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	tmp := make([]byte, binary.MaxVarintLen64)
+	data := make([]byte, 0, 64)
+	data = append(data, tmp[:binary.PutUvarint(tmp, e.inode)]...)
+	for _, k := range keys {
+		data = append(data, tmp[:binary.PutUvarint(tmp, uint64(len(k)))]...)
+		data = append(data, k...)
+		data = append(data, tmp[:binary.PutUvarint(tmp, uint64(len(e.dataMap[k])))]...)
+		data = append(data, e.dataMap[k]...)
+	}
+	return data
+}
@@ -0,0 +1,92 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestVerifyReplicaDigests(t *testing.T) {
+	newReplica := func(accessTime int64) *metaPartition {
+		mp := &metaPartition{
+			config:        &MetaPartitionConfig{PartitionId: 1},
+			inodeTree:     NewBtree(),
+			dentryTree:    NewBtree(),
+			extendTree:    NewBtree(),
+			multipartTree: NewBtree(),
+			txTree:        NewBtree(),
+		}
+		for ino := uint64(1); ino <= 10; ino++ {
+			inode := NewInode(ino, proto.Mode(0644))
+			inode.Size = ino * 4096
+			inode.AccessTime = accessTime
+			mp.inodeTree.ReplaceOrInsert(inode, true)
+			mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: string(rune('a' + ino)), Inode: ino}, true)
+		}
+		extend := NewExtend(2)
+		extend.Put([]byte("user.a"), []byte("1"))
+		extend.Put([]byte("user.b"), []byte("2"))
+		mp.extendTree.ReplaceOrInsert(extend, true)
+		return mp
+	}
+	verify := func(mp *metaPartition) *proto.MetaPartitionVerifyResponse {
+		val := make([]byte, 8)
+		binary.BigEndian.PutUint64(val, 7)
+		cmd, err := NewMetaItem(opFSMVerify, nil, val).MarshalJson()
+		if err != nil {
+			t.Fatalf("marshal command: %v", err)
+		}
+		if _, err = mp.Apply(cmd, 100); err != nil {
+			t.Fatalf("apply verify: %v", err)
+		}
+		resp, err := mp.VerifyReplica(&proto.MetaPartitionVerifyRequest{PartitionID: 1, VerifyID: 7})
+		if err != nil {
+			t.Fatalf("verify result: %v", err)
+		}
+		if !resp.Done || resp.ApplyID != 100 || len(resp.Trees) != 5 {
+			t.Fatalf("verify result(%v)", resp)
+		}
+		return resp
+	}
+	equal := func(a, b *proto.MetaPartitionVerifyResponse) bool {
+		for i := range a.Trees {
+			if *a.Trees[i] != *b.Trees[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	a, b := newReplica(100), newReplica(200)
+	digestA, digestB := verify(a), verify(b)
+	if !equal(digestA, digestB) {
+		t.Fatalf("digests of the same trees differ: %v %v", digestA.Trees, digestB.Trees)
+	}
+	if digestA.Trees[0].Count != 10 || digestA.Trees[1].Count != 10 || digestA.Trees[2].Count != 1 {
+		t.Fatalf("unexpected counts: %v", digestA.Trees)
+	}
+	if resp, _ := a.VerifyReplica(&proto.MetaPartitionVerifyRequest{PartitionID: 1, VerifyID: 8}); resp.Done {
+		t.Fatalf("verification 8 not applied yet but done: %v", resp)
+	}
+
+	b.inodeTree.Get(NewInode(3, 0)).(*Inode).Size++
+	digestB = verify(b)
+	if equal(digestA, digestB) || *digestA.Trees[1] != *digestB.Trees[1] {
+		t.Fatalf("diverged inode not detected: %v %v", digestA.Trees, digestB.Trees)
+	}
+}
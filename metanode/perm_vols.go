@@ -0,0 +1,54 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"net"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// updatePermVols replaces the volumes enforcing the permission checks by the ones told by the master with the heartbeat.
+func (m *metadataManager) updatePermVols(names []string) {
+	old, _ := m.permVols.Load().(map[string]bool)
+	changed := len(old) != len(names)
+	vols := make(map[string]bool, len(names))
+	for _, name := range names {
+		vols[name] = true
+		changed = changed || !old[name]
+	}
+	if changed {
+		log.LogWarnf("updatePermVols: volumes enforcing permissions %v", names)
+	}
+	m.permVols.Store(vols)
+}
+
+func (m *metadataManager) isPermVol(name string) bool {
+	vols, _ := m.permVols.Load().(map[string]bool)
+	return vols[name]
+}
+
+// rejectNoCred replies OpNotPerm to the request without the credential of the user to the partition of a volume
+// enforcing the permissions, and tells whether the request is rejected. The requests of the cluster nodes, such as
+// the object nodes checking the permissions by themselves, are taken only if the access tokens are configured.
+func (m *metadataManager) rejectNoCred(conn net.Conn, mp MetaPartition, p *Packet, cred *proto.Credential) bool {
+	if cred != nil || p.fromNode || !m.isPermVol(mp.GetBaseConfig().VolName) {
+		return false
+	}
+	p.PacketErrorWithBody(proto.OpNotPerm, []byte(proto.ErrNoPermission.Error()))
+	m.respondToClient(conn, p)
+	return true
+}
@@ -0,0 +1,66 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"net"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestRejectNoCred(t *testing.T) {
+	mp := newPermissionTestPartition()
+	mp.config.VolName = "vol"
+	m := &metadataManager{}
+
+	reject := func(cred *proto.Credential, fromNode bool) (rejected bool, result uint8) {
+		server, client := net.Pipe()
+		defer client.Close()
+		reply := make(chan uint8, 1)
+		go func() {
+			p := &proto.Packet{}
+			if err := p.ReadFromConn(client, proto.NoReadDeadlineTime); err != nil {
+				reply <- 0
+				return
+			}
+			reply <- p.ResultCode
+		}()
+		p := &Packet{Packet: *proto.NewPacket(), fromNode: fromNode}
+		p.Opcode = proto.OpMetaLookup
+		rejected = m.rejectNoCred(server, mp, p, cred)
+		server.Close()
+		result = <-reply
+		return
+	}
+
+	if rejected, _ := reject(nil, false); rejected {
+		t.Errorf("the request without credential to the volume not enforcing permissions is rejected")
+	}
+	m.updatePermVols([]string{"other", "vol"})
+	if rejected, result := reject(nil, false); !rejected || result != proto.OpNotPerm {
+		t.Errorf("the request without credential: rejected(%v) result(%v)", rejected, result)
+	}
+	if rejected, _ := reject(&proto.Credential{Uid: 1000, Gid: 1000}, false); rejected {
+		t.Errorf("the request with credential is rejected")
+	}
+	if rejected, _ := reject(nil, true); rejected {
+		t.Errorf("the request of a node is rejected")
+	}
+	m.updatePermVols(nil)
+	if m.isPermVol("vol") {
+		t.Errorf("the volume still enforces permissions after it is disabled")
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"sync"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// partitionUsage keeps the number of files and bytes of the partition in total and by the
+// directory quotas. The counters follow the inode tree in the apply path, so the heartbeat
+// reports them without scanning the tree.
+type partitionUsage struct {
+	sync.Mutex
+	total  proto.DirStat
+	quotas map[uint64]*proto.QuotaUsage
+}
+
+// inodeUsage returns the quota id and the counted size of the inode,
+// or false if the inode is marked deleted and not counted.
+func inodeUsage(ino *Inode) (quotaId, size uint64, isDir, ok bool) {
+	ino.RLock()
+	defer ino.RUnlock()
+	if ino.Flag&DeleteMarkFlag > 0 {
+		return
+	}
+	if proto.IsRegular(ino.Type) {
+		size = ino.Size
+	}
+	return ino.QuotaId, size, proto.IsDir(ino.Type), true
+}
+
+func (u *partitionUsage) update(quotaId uint64, isDir bool, files int64, bytes int64) {
+	if isDir {
+		u.total.Dirs = uint64(int64(u.total.Dirs) + files)
+	} else {
+		u.total.Files = uint64(int64(u.total.Files) + files)
+	}
+	u.total.Bytes = uint64(int64(u.total.Bytes) + bytes)
+	if quotaId == 0 {
+		return
+	}
+	if u.quotas == nil {
+		u.quotas = make(map[uint64]*proto.QuotaUsage)
+	}
+	quota, ok := u.quotas[quotaId]
+	if !ok {
+		quota = &proto.QuotaUsage{QuotaId: quotaId}
+		u.quotas[quotaId] = quota
+	}
+	quota.Files = uint64(int64(quota.Files) + files)
+	quota.Bytes = uint64(int64(quota.Bytes) + bytes)
+	if quota.Files == 0 && quota.Bytes == 0 {
+		delete(u.quotas, quotaId)
+	}
+}
+
+// addInode counts the inode attached to the inode tree.
+func (u *partitionUsage) addInode(ino *Inode) {
+	quotaId, size, isDir, ok := inodeUsage(ino)
+	if !ok {
+		return
+	}
+	u.Lock()
+	u.update(quotaId, isDir, 1, int64(size))
+	u.Unlock()
+}
+
+// removeInode uncounts the inode deleted from the inode tree or marked deleted.
+func (u *partitionUsage) removeInode(ino *Inode) {
+	quotaId, size, isDir, ok := inodeUsage(ino)
+	if !ok {
+		return
+	}
+	u.Lock()
+	u.update(quotaId, isDir, -1, -int64(size))
+	u.Unlock()
+}
+
+// resize counts the change of the size of the inode, which was oldSize before the change.
+func (u *partitionUsage) resize(ino *Inode, oldSize uint64) {
+	quotaId, size, isDir, ok := inodeUsage(ino)
+	if !ok || size == oldSize {
+		return
+	}
+	u.Lock()
+	u.update(quotaId, isDir, 0, int64(size)-int64(oldSize))
+	u.Unlock()
+}
+
+// reset recounts the usage from the inode tree, which replaced the tree of the partition.
+func (u *partitionUsage) reset(tree *BTree) {
+	u.Lock()
+	defer u.Unlock()
+	u.total = proto.DirStat{}
+	u.quotas = nil
+	tree.Ascend(func(i BtreeItem) bool {
+		if quotaId, size, isDir, ok := inodeUsage(i.(*Inode)); ok {
+			u.update(quotaId, isDir, 1, int64(size))
+		}
+		return true
+	})
+}
+
+// snapshot returns a copy of the counters.
+func (u *partitionUsage) snapshot() (usage *proto.DirStat, quotaUsage []*proto.QuotaUsage) {
+	u.Lock()
+	defer u.Unlock()
+	usage = new(proto.DirStat)
+	*usage = u.total
+	quotaUsage = make([]*proto.QuotaUsage, 0, len(u.quotas))
+	for _, quota := range u.quotas {
+		q := *quota
+		quotaUsage = append(quotaUsage, &q)
+	}
+	return
+}
+
+// GetUsage returns the number of files and bytes of the partition in total and by the directory quotas.
+func (mp *metaPartition) GetUsage() (usage *proto.DirStat, quotaUsage []*proto.QuotaUsage) {
+	return mp.usage.snapshot()
+}
@@ -0,0 +1,351 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/binary"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The inodes are tagged with the nearest directory quota above them by the meta nodes, which the partitions
+// count the usage by. The partition holding a directory tags the children of it once a dentry is created
+// or updated in it, so the files created, linked or renamed into a quota are tagged whichever client sends
+// the requests. The subtree of a directory is retagged level by level once the directory is moved, or a
+// quota is set on or deleted from it: the partition holding a directory tags the children, and tells the
+// partitions holding the child directories, which hold their dentries as well, to go on with their children.
+// The tasks are kept in the memory of the leaders, and the quotas are retagged on the leader changes.
+
+const (
+	quotaTagQueueSize = 1000
+	quotaTagBatchSize = 1000
+)
+
+// quotaTagTask tags the inodes with the quota of the children of the parent directory,
+// or with quotaId if the parent is zero.
+type quotaTagTask struct {
+	parent  uint64
+	inodes  []uint64 // all the children of the parent if nil
+	quotaId uint64
+}
+
+// updateQuotas replaces the directory quotas of the volumes by the ones told by the master with the heartbeat,
+// and the partitions holding the roots of the quotas set or deleted since the last heartbeat retag the subtrees.
+func (m *metadataManager) updateQuotas(quotas map[string][]*proto.QuotaInfo) {
+	vols := make(map[string]map[uint64]*proto.QuotaInfo, len(quotas))
+	for name, list := range quotas {
+		vol := make(map[uint64]*proto.QuotaInfo, len(list))
+		for _, quota := range list {
+			vol[quota.QuotaId] = quota
+		}
+		vols[name] = vol
+	}
+	old, _ := m.quotas.Load().(map[string]map[uint64]*proto.QuotaInfo)
+	m.quotas.Store(vols)
+	m.Range(func(id uint64, p MetaPartition) bool {
+		mp, ok := p.(*metaPartition)
+		if !ok {
+			return true
+		}
+		name := mp.GetBaseConfig().VolName
+		for root := range vols[name] {
+			if _, ok := old[name][root]; !ok {
+				mp.retagQuota(root)
+			}
+		}
+		for root := range old[name] {
+			if _, ok := vols[name][root]; !ok {
+				mp.retagQuota(root)
+			}
+		}
+		return true
+	})
+}
+
+func (m *metadataManager) getQuotas(volName string) map[uint64]*proto.QuotaInfo {
+	quotas, _ := m.quotas.Load().(map[string]map[uint64]*proto.QuotaInfo)
+	return quotas[volName]
+}
+
+// quotas returns the directory quotas of the volume indexed by the root inodes.
+func (mp *metaPartition) quotas() map[uint64]*proto.QuotaInfo {
+	if mp.manager == nil {
+		return nil
+	}
+	return mp.manager.getQuotas(mp.config.VolName)
+}
+
+// childQuota returns the quota of the children of the directory.
+func childQuota(dir *Inode, quotas map[uint64]*proto.QuotaInfo) uint64 {
+	if _, ok := quotas[dir.Inode]; ok {
+		return dir.Inode
+	}
+	dir.RLock()
+	defer dir.RUnlock()
+	return dir.QuotaId
+}
+
+// quotaExceeded tells whether the quota or any quota enclosing it is exceeded. The usage of a quota
+// includes the quotas nested in it, so a quota is exceeded if any quota above is.
+func quotaExceeded(quotas map[uint64]*proto.QuotaInfo, quotaId uint64) bool {
+	for i := 0; quotaId != 0 && i < len(quotas); i++ {
+		quota, ok := quotas[quotaId]
+		if !ok {
+			return false
+		}
+		if quota.Exceeded {
+			return true
+		}
+		quotaId = quota.ParentId
+	}
+	return false
+}
+
+// checkQuotaToCreate replies OpQuotaExceeded if the quota of the children of the directory is exceeded.
+func (mp *metaPartition) checkQuotaToCreate(parentID uint64) uint8 {
+	quotas := mp.quotas()
+	if len(quotas) == 0 {
+		return proto.OpOk
+	}
+	if parent := mp.getLocalInode(parentID); parent != nil && quotaExceeded(quotas, childQuota(parent, quotas)) {
+		return proto.OpQuotaExceeded
+	}
+	return proto.OpOk
+}
+
+// checkQuotaToGrow replies OpQuotaExceeded if the file grows to the size beyond its size in an exceeded quota.
+func (mp *metaPartition) checkQuotaToGrow(ino uint64, size uint64) uint8 {
+	quotas := mp.quotas()
+	if len(quotas) == 0 {
+		return proto.OpOk
+	}
+	inode := mp.getLocalInode(ino)
+	if inode == nil {
+		return proto.OpOk
+	}
+	inode.RLock()
+	oldSize, quotaId := inode.Size, inode.QuotaId
+	inode.RUnlock()
+	if size > oldSize && quotaExceeded(quotas, quotaId) {
+		return proto.OpQuotaExceeded
+	}
+	return proto.OpOk
+}
+
+// GetQuotaRoots returns the quotas rooted in the partition with the quotas enclosing them,
+// which the master charges the usage of the nested quotas to.
+func (mp *metaPartition) GetQuotaRoots() (roots []*proto.QuotaRoot) {
+	for root := range mp.quotas() {
+		inode := mp.getLocalInode(root)
+		if inode == nil {
+			continue
+		}
+		inode.RLock()
+		roots = append(roots, &proto.QuotaRoot{QuotaId: root, ParentId: inode.QuotaId})
+		inode.RUnlock()
+	}
+	return
+}
+
+// tagChild tags the child of the directory with its quota once the dentry is created or updated.
+func (mp *metaPartition) tagChild(parentID, ino uint64) {
+	if len(mp.quotas()) == 0 {
+		return
+	}
+	mp.queueQuotaTag(&quotaTagTask{parent: parentID, inodes: []uint64{ino}})
+}
+
+// retagQuota retags the children of the root of the quota set or deleted.
+func (mp *metaPartition) retagQuota(root uint64) {
+	if mp.getLocalInode(root) == nil {
+		return
+	}
+	mp.queueQuotaTag(&quotaTagTask{parent: root})
+}
+
+// retagQuotas retags the children of the roots of all the quotas in the partition,
+// which resumes the retagging lost with the previous leader.
+func (mp *metaPartition) retagQuotas() {
+	for root := range mp.quotas() {
+		mp.retagQuota(root)
+	}
+}
+
+func (mp *metaPartition) queueQuotaTag(task *quotaTagTask) bool {
+	select {
+	case mp.quotaTagCh <- task:
+		return true
+	default:
+		log.LogWarnf("queueQuotaTag: queue is full, partition(%v) drop task(%+v)", mp.config.PartitionId, task)
+		return false
+	}
+}
+
+// QuotaTag tags the inodes with the quota for the partition holding their parent directory.
+func (mp *metaPartition) QuotaTag(req *proto.QuotaTagRequest, p *Packet) (err error) {
+	if !mp.queueQuotaTag(&quotaTagTask{inodes: req.Inodes, quotaId: req.QuotaId}) {
+		p.PacketErrorWithBody(proto.OpAgain, nil)
+		return
+	}
+	p.PacketOkReply()
+	return
+}
+
+// quotaTagWorker runs the tasks to tag the inodes on the leader. The directories to go on with are kept
+// in the pending list instead of the queue, so that a large subtree is not dropped with a full queue.
+func (mp *metaPartition) quotaTagWorker() {
+	var pending []*quotaTagTask
+	for {
+		var task *quotaTagTask
+		if len(pending) > 0 {
+			select {
+			case <-mp.stopC:
+				return
+			case task = <-mp.quotaTagCh:
+			default:
+				task, pending = pending[0], pending[1:]
+			}
+		} else {
+			select {
+			case <-mp.stopC:
+				log.LogDebugf("[metaPartition] quotaTagWorker stop partition: %v", mp.config.PartitionId)
+				return
+			case task = <-mp.quotaTagCh:
+			}
+		}
+		if _, isLeader := mp.IsLeader(); !isLeader {
+			pending = nil
+			continue
+		}
+		pending = append(pending, mp.tagQuota(task)...)
+	}
+}
+
+// tagQuota tags the inodes of the task, and returns the tasks of the local directories whose children are
+// to be retagged. The inodes in the other partitions are sent to the leaders of those partitions.
+func (mp *metaPartition) tagQuota(task *quotaTagTask) (next []*quotaTagTask) {
+	quotas := mp.quotas()
+	quotaId := task.quotaId
+	inodes := task.inodes
+	if task.parent != 0 {
+		parent := mp.getLocalInode(task.parent)
+		if parent == nil {
+			return
+		}
+		quotaId = childQuota(parent, quotas)
+		if inodes == nil {
+			inodes = mp.childInodes(task.parent)
+		}
+	}
+	var remote []uint64
+	for _, ino := range inodes {
+		inode := mp.getLocalInode(ino)
+		if inode == nil {
+			if task.parent != 0 {
+				remote = append(remote, ino)
+			}
+			continue
+		}
+		inode.RLock()
+		old, isDir := inode.QuotaId, proto.IsDir(inode.Type)
+		inode.RUnlock()
+		if old == quotaId {
+			continue
+		}
+		if err := mp.setInodeQuota(ino, quotaId); err != nil {
+			log.LogWarnf("tagQuota: partition(%v) ino(%v) quota(%v) err(%v)", mp.config.PartitionId, ino, quotaId, err)
+			continue
+		}
+		// the children of a quota root are tagged with the root itself, which does not change
+		if _, isRoot := quotas[ino]; isDir && !isRoot {
+			next = append(next, &quotaTagTask{parent: ino})
+		}
+	}
+	if len(remote) > 0 {
+		mp.tagRemoteInodes(remote, quotaId)
+	}
+	return
+}
+
+// childInodes returns the inodes of the children of the directory.
+func (mp *metaPartition) childInodes(dir uint64) (inodes []uint64) {
+	begin := &Dentry{ParentId: dir}
+	end := &Dentry{ParentId: dir + 1}
+	mp.dentryTree.GetTree().AscendRange(begin, end, func(i BtreeItem) bool {
+		inodes = append(inodes, i.(*Dentry).Inode)
+		return true
+	})
+	return
+}
+
+func (mp *metaPartition) tagRemoteInodes(inodes []uint64, quotaId uint64) {
+	views, err := mp.remoteViews.get(mp.config.VolName)
+	if err != nil {
+		log.LogWarnf("tagRemoteInodes: partition(%v) err(%v)", mp.config.PartitionId, err)
+		return
+	}
+	var batches = make(map[*proto.MetaPartitionView][]uint64)
+	for _, ino := range inodes {
+		view, e := findPartitionView(views, ino)
+		if e != nil {
+			log.LogWarnf("tagRemoteInodes: partition(%v) ino(%v) err(%v)", mp.config.PartitionId, ino, e)
+			continue
+		}
+		batches[view] = append(batches[view], ino)
+	}
+	for view, inodes := range batches {
+		for len(inodes) > 0 {
+			n := len(inodes)
+			if n > quotaTagBatchSize {
+				n = quotaTagBatchSize
+			}
+			if _, err = mp.sendRemoteRequest(view.LeaderAddr, proto.OpMetaQuotaTag, &proto.QuotaTagRequest{
+				VolName:     mp.config.VolName,
+				PartitionID: view.PartitionID,
+				Inodes:      inodes[:n],
+				QuotaId:     quotaId,
+			}); err != nil {
+				log.LogWarnf("tagRemoteInodes: partition(%v) target(%v) quota(%v) err(%v)",
+					mp.config.PartitionId, view.PartitionID, quotaId, err)
+				break
+			}
+			inodes = inodes[n:]
+		}
+	}
+}
+
+func (mp *metaPartition) setInodeQuota(ino, quotaId uint64) (err error) {
+	val := make([]byte, 16)
+	binary.BigEndian.PutUint64(val, ino)
+	binary.BigEndian.PutUint64(val[8:], quotaId)
+	_, err = mp.submit(opFSMSetQuota, val)
+	return
+}
+
+// fsmSetQuota tags the inode with the quota, and moves the usage of it to the quota.
+func (mp *metaPartition) fsmSetQuota(ino, quotaId uint64) (status uint8) {
+	item := mp.inodeTree.CopyGet(NewInode(ino, 0))
+	if item == nil {
+		return proto.OpNotExistErr
+	}
+	inode := item.(*Inode)
+	mp.usage.removeInode(inode)
+	inode.Lock()
+	inode.QuotaId = quotaId
+	inode.Unlock()
+	mp.usage.addInode(inode)
+	return proto.OpOk
+}
@@ -0,0 +1,221 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestInodeQuotaIdMarshal(t *testing.T) {
+	ino := NewInode(10, proto.Mode(0644))
+	ino.Reserved = 7
+	ino.QuotaId = 3
+	ino2 := NewInode(10, 0)
+	if err := ino2.UnmarshalValue(ino.MarshalValue()); err != nil {
+		t.Fatalf("unmarshal inode: %v", err)
+	}
+	if ino2.QuotaId != 3 || ino2.Reserved != 7 || ino2.Flag != 0 {
+		t.Fatalf("unmarshal inode expect quota(3) reserved(7) flag(0) actual %v", ino2)
+	}
+
+	// the inodes out of the quotas keep the format without the quota id
+	ino.QuotaId = 0
+	withQuota := NewInode(10, proto.Mode(0644))
+	withQuota.Reserved = 7
+	withQuota.QuotaId = 3
+	if len(withQuota.MarshalValue())-len(ino.MarshalValue()) != 8 {
+		t.Fatalf("marshal inode without the quota id expect 8 bytes less")
+	}
+}
+
+func TestPartitionUsage(t *testing.T) {
+	mp := &metaPartition{
+		config:     &MetaPartitionConfig{PartitionId: 1},
+		inodeTree:  NewBtree(),
+		extendTree: NewBtree(),
+		freeList:   newFreeList(),
+		extDelCh:   make(chan []proto.ExtentKey, 10),
+	}
+	dir := NewInode(10, proto.Mode(os.ModeDir|0755))
+	dir.QuotaId = 10
+	file := NewInode(11, proto.Mode(0644))
+	file.QuotaId = 10
+	other := NewInode(12, proto.Mode(0644))
+	for _, ino := range []*Inode{dir, file, other} {
+		if status := mp.fsmCreateInode(ino); status != proto.OpOk {
+			t.Fatalf("create inode(%v) status(%v)", ino.Inode, status)
+		}
+	}
+
+	check := func(files, dirs, bytes, quotaFiles, quotaBytes uint64) {
+		t.Helper()
+		usage, quotaUsage := mp.GetUsage()
+		if usage.Files != files || usage.Dirs != dirs || usage.Bytes != bytes {
+			t.Fatalf("usage expect files(%v) dirs(%v) bytes(%v) actual %+v", files, dirs, bytes, usage)
+		}
+		var quota proto.QuotaUsage
+		for _, q := range quotaUsage {
+			if q.QuotaId == 10 {
+				quota = *q
+			}
+		}
+		if quota.Files != quotaFiles || quota.Bytes != quotaBytes {
+			t.Fatalf("quota usage expect files(%v) bytes(%v) actual %+v", quotaFiles, quotaBytes, quota)
+		}
+		// the counters kept in the apply path match a full scan of the tree
+		var scanned partitionUsage
+		scanned.reset(mp.inodeTree)
+		if expect, _ := scanned.snapshot(); *expect != *usage {
+			t.Fatalf("usage expect %+v as the scan actual %+v", expect, usage)
+		}
+	}
+	check(2, 1, 0, 2, 0)
+
+	extents := NewInode(11, 0)
+	extents.Extents.Append(proto.ExtentKey{FileOffset: 0, PartitionId: 1, ExtentId: 1, Size: 4096})
+	if status := mp.fsmAppendExtents(extents); status != proto.OpOk {
+		t.Fatalf("append extents status(%v)", status)
+	}
+	check(2, 1, 4096, 2, 4096)
+
+	truncate := NewInode(11, 0)
+	truncate.Size = 1024
+	if resp := mp.fsmExtentsTruncate(truncate); resp.Status != proto.OpOk {
+		t.Fatalf("truncate status(%v)", resp.Status)
+	}
+	check(2, 1, 1024, 2, 1024)
+
+	mp.fsmUnlinkInode(NewInode(11, 0))
+	mp.fsmEvictInode(NewInode(11, 0))
+	check(1, 1, 0, 1, 0)
+	mp.internalDeleteInode(NewInode(11, 0))
+	check(1, 1, 0, 1, 0)
+
+	mp.fsmUnlinkInode(NewInode(10, 0))
+	check(1, 0, 0, 0, 0)
+	if _, quotaUsage := mp.GetUsage(); len(quotaUsage) != 0 {
+		t.Fatalf("quota usage of the removed quota expect none actual %v", quotaUsage)
+	}
+}
+
+func TestFsmSetQuota(t *testing.T) {
+	mp := &metaPartition{
+		config:     &MetaPartitionConfig{PartitionId: 1},
+		inodeTree:  NewBtree(),
+		extendTree: NewBtree(),
+		freeList:   newFreeList(),
+		extDelCh:   make(chan []proto.ExtentKey, 10),
+	}
+	file := NewInode(11, proto.Mode(0644))
+	file.QuotaId = 10
+	if status := mp.fsmCreateInode(file); status != proto.OpOk {
+		t.Fatalf("create inode status(%v)", status)
+	}
+	extents := NewInode(11, 0)
+	extents.Extents.Append(proto.ExtentKey{FileOffset: 0, PartitionId: 1, ExtentId: 1, Size: 4096})
+	mp.fsmAppendExtents(extents)
+
+	if status := mp.fsmSetQuota(11, 20); status != proto.OpOk {
+		t.Fatalf("set quota status(%v)", status)
+	}
+	if status := mp.fsmSetQuota(12, 20); status != proto.OpNotExistErr {
+		t.Fatalf("set quota of a missing inode expect OpNotExistErr actual(%v)", status)
+	}
+	if quotaId := mp.getLocalInode(11).QuotaId; quotaId != 20 {
+		t.Fatalf("quota expect 20 actual %v", quotaId)
+	}
+	_, quotaUsage := mp.GetUsage()
+	if len(quotaUsage) != 1 || quotaUsage[0].QuotaId != 20 || quotaUsage[0].Files != 1 || quotaUsage[0].Bytes != 4096 {
+		t.Fatalf("usage expect moved to quota 20 actual %v", quotaUsage)
+	}
+}
+
+func TestQuotaExceeded(t *testing.T) {
+	// 30 is nested in 20, which is nested in 10
+	quotas := map[uint64]*proto.QuotaInfo{
+		10: {QuotaId: 10},
+		20: {QuotaId: 20, ParentId: 10},
+		30: {QuotaId: 30, ParentId: 20},
+	}
+	for _, id := range []uint64{0, 10, 20, 30, 40} {
+		if quotaExceeded(quotas, id) {
+			t.Fatalf("quota(%v) expect not exceeded", id)
+		}
+	}
+	quotas[20].Exceeded = true
+	for id, expect := range map[uint64]bool{10: false, 20: true, 30: true} {
+		if quotaExceeded(quotas, id) != expect {
+			t.Fatalf("quota(%v) expect exceeded(%v)", id, expect)
+		}
+	}
+	// a loop of the parents does not hang the check
+	quotas[20].Exceeded = false
+	quotas[10].ParentId = 30
+	if quotaExceeded(quotas, 30) {
+		t.Fatalf("quota(30) expect not exceeded")
+	}
+}
+
+func TestCheckQuota(t *testing.T) {
+	m := &metadataManager{}
+	mp := &metaPartition{
+		config:     &MetaPartitionConfig{PartitionId: 1, VolName: "vol"},
+		inodeTree:  NewBtree(),
+		extendTree: NewBtree(),
+		freeList:   newFreeList(),
+		extDelCh:   make(chan []proto.ExtentKey, 10),
+		manager:    m,
+	}
+	outer := NewInode(10, proto.Mode(os.ModeDir|0755))
+	inner := NewInode(20, proto.Mode(os.ModeDir|0755))
+	inner.QuotaId = 10
+	file := NewInode(21, proto.Mode(0644))
+	file.QuotaId = 20
+	for _, ino := range []*Inode{outer, inner, file} {
+		mp.fsmCreateInode(ino)
+	}
+	m.quotas.Store(map[string]map[uint64]*proto.QuotaInfo{"vol": {
+		10: {QuotaId: 10, Exceeded: true},
+		20: {QuotaId: 20, ParentId: 10},
+	}})
+
+	roots := make(map[uint64]uint64)
+	for _, root := range mp.GetQuotaRoots() {
+		roots[root.QuotaId] = root.ParentId
+	}
+	if len(roots) != 2 || roots[10] != 0 || roots[20] != 10 {
+		t.Fatalf("quota roots expect 10 in none and 20 in 10 actual %v", roots)
+	}
+	// the outer quota is exceeded, which counts the inner quota
+	if status := mp.checkQuotaToCreate(20); status != proto.OpQuotaExceeded {
+		t.Fatalf("create in the inner quota expect OpQuotaExceeded actual(%v)", status)
+	}
+	if status := mp.checkQuotaToGrow(21, 1); status != proto.OpQuotaExceeded {
+		t.Fatalf("grow in the inner quota expect OpQuotaExceeded actual(%v)", status)
+	}
+	if status := mp.checkQuotaToGrow(21, 0); status != proto.OpOk {
+		t.Fatalf("no growth expect OpOk actual(%v)", status)
+	}
+	m.quotas.Store(map[string]map[uint64]*proto.QuotaInfo{"vol": {
+		10: {QuotaId: 10},
+		20: {QuotaId: 20, ParentId: 10},
+	}})
+	if status := mp.checkQuotaToCreate(20); status != proto.OpOk {
+		t.Fatalf("create in the inner quota expect OpOk actual(%v)", status)
+	}
+}
@@ -41,6 +41,7 @@ func (m *MetaNode) startRaftServer() (err error) {
 		HeartbeatPort:     heartbeatPort,
 		ReplicaPort:       replicaPort,
 		NumOfLogsToRetain: raftstore.DefaultNumOfLogsToRetain * 2,
+		MaxInflightMsgs:   m.raftMaxInflight,
 	}
 	m.raftStore, err = raftstore.NewRaftStore(raftConf)
 	if err != nil {
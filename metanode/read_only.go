@@ -0,0 +1,53 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"net"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// updateReadOnlyVols replaces the volumes frozen read-only by the ones told by the master with the heartbeat.
+func (m *metadataManager) updateReadOnlyVols(names []string) {
+	old, _ := m.readOnlyVols.Load().(map[string]bool)
+	changed := len(old) != len(names)
+	vols := make(map[string]bool, len(names))
+	for _, name := range names {
+		vols[name] = true
+		changed = changed || !old[name]
+	}
+	if changed {
+		log.LogWarnf("updateReadOnlyVols: read only volumes %v", names)
+	}
+	m.readOnlyVols.Store(vols)
+}
+
+func (m *metadataManager) isReadOnlyVol(name string) bool {
+	vols, _ := m.readOnlyVols.Load().(map[string]bool)
+	return vols[name]
+}
+
+// rejectReadOnly replies OpVolReadOnly to the write to the partition of a volume frozen read-only,
+// and tells whether the write is rejected.
+func (m *metadataManager) rejectReadOnly(conn net.Conn, mp MetaPartition, p *Packet) bool {
+	if !m.isReadOnlyVol(mp.GetBaseConfig().VolName) {
+		return false
+	}
+	p.PacketErrorWithBody(proto.OpVolReadOnly, []byte(proto.ErrVolReadOnly.Error()))
+	m.respondToClient(conn, p)
+	return true
+}
@@ -0,0 +1,60 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"net"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestRejectReadOnly(t *testing.T) {
+	mp := newPermissionTestPartition()
+	mp.config.VolName = "vol"
+	m := &metadataManager{}
+
+	reject := func() (rejected bool, result uint8) {
+		server, client := net.Pipe()
+		defer client.Close()
+		reply := make(chan uint8, 1)
+		go func() {
+			p := &proto.Packet{}
+			if err := p.ReadFromConn(client, proto.NoReadDeadlineTime); err != nil {
+				reply <- 0
+				return
+			}
+			reply <- p.ResultCode
+		}()
+		p := &Packet{Packet: *proto.NewPacket()}
+		p.Opcode = proto.OpMetaCreateInode
+		rejected = m.rejectReadOnly(server, mp, p)
+		server.Close()
+		result = <-reply
+		return
+	}
+
+	if rejected, _ := reject(); rejected {
+		t.Errorf("the write to the volume not frozen is rejected")
+	}
+	m.updateReadOnlyVols([]string{"other", "vol"})
+	if rejected, result := reject(); !rejected || result != proto.OpVolReadOnly {
+		t.Errorf("the write to the frozen volume: rejected(%v) result(%v)", rejected, result)
+	}
+	m.updateReadOnlyVols(nil)
+	if m.isReadOnlyVol("vol") {
+		t.Errorf("the volume is still read only after it is thawed")
+	}
+}
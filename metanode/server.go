@@ -15,10 +15,12 @@
 package metanode
 
 import (
+	"fmt"
 	"io"
 	"net"
 
 	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util"
 	"github.com/chubaofs/chubaofs/util/log"
 )
 
@@ -66,7 +68,9 @@ func (m *MetaNode) serveConn(conn net.Conn, stopC chan uint8) {
 	c := conn.(*net.TCPConn)
 	c.SetKeepAlive(true)
 	c.SetNoDelay(true)
+	conn = util.ServerConn(c)
 	remoteAddr := conn.RemoteAddr().String()
+	access := new(proto.ConnAccess)
 	for {
 		select {
 		case <-stopC:
@@ -80,12 +84,55 @@ func (m *MetaNode) serveConn(conn net.Conn, stopC chan uint8) {
 			}
 			return
 		}
+		if err := m.checkAccess(conn, p, access, remoteAddr); err != nil {
+			log.LogErrorf("serve checkAccess fail: %v", err)
+			return
+		}
+		if p.Opcode == proto.OpAuthConn {
+			continue
+		}
+		p.fromNode = access.IsNode()
+		if p.Opcode == proto.OpHandshake {
+			p.HandshakeReply(proto.MetaNodeFeatures)
+			if err := p.WriteToConn(conn); err != nil {
+				log.LogErrorf("serve handshake fail: remote(%v) err(%v)", remoteAddr, err)
+				return
+			}
+			continue
+		}
 		if err := m.handlePacket(conn, p, remoteAddr); err != nil {
 			log.LogErrorf("serve handlePacket fail: %v", err)
 		}
 	}
 }
 
+// checkAccess authenticates the connection with the OpAuthConn packet,
+// and checks the access of the other packets if the access token key is configured.
+// The connection is closed once the access is denied.
+func (m *MetaNode) checkAccess(conn net.Conn, p *Packet, access *proto.ConnAccess, remoteAddr string) (err error) {
+	if p.Opcode == proto.OpAuthConn {
+		if m.accessTokenKey != nil {
+			err = access.Authenticate(m.accessTokenKey, p.Data[:p.Size])
+		}
+	} else if m.accessTokenKey != nil {
+		var volName string
+		if mp, mpErr := m.metadataManager.GetPartition(p.PartitionID); mpErr == nil {
+			volName = mp.GetBaseConfig().VolName
+		}
+		err = access.Check(p.Opcode, volName)
+	}
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte(err.Error()))
+		p.WriteToConn(conn)
+		return fmt.Errorf("op(%v) partition(%v) from remote(%v) err(%v)", p.GetOpMsg(), p.PartitionID, remoteAddr, err)
+	}
+	if p.Opcode == proto.OpAuthConn {
+		p.PacketOkReply()
+		err = p.WriteToConn(conn)
+	}
+	return
+}
+
 func (m *MetaNode) handlePacket(conn net.Conn, p *Packet,
 	remoteAddr string) (err error) {
 	// Handle request
@@ -0,0 +1,58 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util"
+)
+
+func TestServeConn_Handshake(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	m := &MetaNode{}
+	stopC := make(chan uint8)
+	defer close(stopC)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go m.serveConn(conn, stopC)
+		}
+	}()
+
+	pool := util.NewConnectPool()
+	defer pool.Close()
+	features := proto.NewPeerFeatures(pool, proto.MetaNodeFeatures)
+	addr := ln.Addr().String()
+	if features.Supports(addr, proto.FeatureMetaReadDirPlus) {
+		t.Fatalf("feature supported before the negotiation")
+	}
+	for deadline := time.Now().Add(5 * time.Second); !features.Supports(addr, proto.FeatureMetaReadDirPlus); {
+		if time.Now().After(deadline) {
+			t.Fatalf("feature not negotiated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
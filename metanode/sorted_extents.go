@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/storage"
 )
 
 type SortedExtents struct {
@@ -148,6 +149,138 @@ func (se *SortedExtents) Truncate(offset uint64) (deleteExtents []proto.ExtentKe
 	return
 }
 
+// TruncatedHoles returns the range of the normal extent released by truncating the extents to the offset,
+// which is the truncated tail of the last key left, excluding the parts referenced by the other keys left.
+// The keys after the offset are deleted as a whole, so they are not returned.
+func (se *SortedExtents) TruncatedHoles(offset uint64) (holes []proto.ExtentKey) {
+	se.RLock()
+	defer se.RUnlock()
+
+	last := -1
+	for idx, key := range se.eks {
+		if key.FileOffset >= offset {
+			break
+		}
+		last = idx
+	}
+	if last < 0 {
+		return
+	}
+	key := se.eks[last]
+	if key.FileOffset+uint64(key.Size) <= offset || storage.IsTinyExtent(key.ExtentId) {
+		return
+	}
+	tail := offset - key.FileOffset
+	key.FileOffset = offset
+	key.ExtentOffset += tail
+	key.Size -= uint32(tail)
+	if hole, ok := unreferencedHole(key, se.eks[:last]); ok {
+		holes = append(holes, hole)
+	}
+	return
+}
+
+// PunchHole removes the range of the file from the extents, and the keys partially in the range are split.
+// The keys in the range are deleted as a whole unless their extents are still referenced by the keys left,
+// and the released ranges of the normal extents, excluding the parts referenced by the keys left, are returned as holes.
+// The removed ranges of the tiny extents are deleted by range, so they are returned as the keys to delete.
+func (se *SortedExtents) PunchHole(offset, size uint64) (deleteExtents, holes []proto.ExtentKey) {
+	end := offset + size
+
+	se.Lock()
+	defer se.Unlock()
+
+	eks := make([]proto.ExtentKey, 0, len(se.eks)+1)
+	punched := make([]proto.ExtentKey, 0)
+	for _, key := range se.eks {
+		keyEnd := key.FileOffset + uint64(key.Size)
+		if keyEnd <= offset || key.FileOffset >= end {
+			eks = append(eks, key)
+			continue
+		}
+		removed := key
+		if key.FileOffset < offset {
+			left := key
+			left.Size = uint32(offset - key.FileOffset)
+			eks = append(eks, left)
+			removed.FileOffset = offset
+			removed.ExtentOffset += uint64(left.Size)
+			removed.Size -= left.Size
+		}
+		if keyEnd > end {
+			right := key
+			right.FileOffset = end
+			right.ExtentOffset += end - key.FileOffset
+			right.Size = uint32(keyEnd - end)
+			eks = append(eks, right)
+			removed.Size -= right.Size
+		}
+		punched = append(punched, removed)
+	}
+	se.eks = eks
+
+	for _, key := range punched {
+		if storage.IsTinyExtent(key.ExtentId) {
+			deleteExtents = append(deleteExtents, key)
+			continue
+		}
+		if !isReferenced(key, eks) {
+			deleteExtents = append(deleteExtents, key)
+			continue
+		}
+		if hole, ok := unreferencedHole(key, eks); ok {
+			holes = append(holes, hole)
+		}
+	}
+	return
+}
+
+// isReferenced returns true if the extent of the key is referenced by the keys.
+func isReferenced(key proto.ExtentKey, eks []proto.ExtentKey) bool {
+	for _, other := range eks {
+		if other.PartitionId == key.PartitionId && other.ExtentId == key.ExtentId {
+			return true
+		}
+	}
+	return false
+}
+
+// unreferencedHole shrinks the range of the extent of the key to exclude the parts referenced by the other keys,
+// ok is false if nothing is left.
+func unreferencedHole(key proto.ExtentKey, others []proto.ExtentKey) (hole proto.ExtentKey, ok bool) {
+	start := key.ExtentOffset
+	end := key.ExtentOffset + uint64(key.Size)
+	for shrunk := true; shrunk && start < end; {
+		shrunk = false
+		for _, other := range others {
+			if other.PartitionId != key.PartitionId || other.ExtentId != key.ExtentId {
+				continue
+			}
+			otherStart, otherEnd := other.ExtentOffset, other.ExtentOffset+uint64(other.Size)
+			if otherEnd <= start || otherStart >= end {
+				continue
+			}
+			if otherStart <= start {
+				start = otherEnd
+			} else {
+				end = otherStart
+			}
+			shrunk = true
+		}
+	}
+	if start >= end {
+		return
+	}
+	hole = proto.ExtentKey{
+		FileOffset:   key.FileOffset + (start - key.ExtentOffset),
+		PartitionId:  key.PartitionId,
+		ExtentId:     key.ExtentId,
+		ExtentOffset: start,
+		Size:         uint32(end - start),
+	}
+	return hole, true
+}
+
 func (se *SortedExtents) Len() int {
 	se.RLock()
 	defer se.RUnlock()
@@ -84,3 +84,74 @@ func TestTruncate01(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestTruncatedHoles(t *testing.T) {
+	se := NewSortedExtents()
+	se.Append(proto.ExtentKey{FileOffset: 0, Size: 1000, ExtentId: 1025, ExtentOffset: 0})
+	se.Append(proto.ExtentKey{FileOffset: 1000, Size: 3000, ExtentId: 1026, ExtentOffset: 0})
+	holes := se.TruncatedHoles(1500)
+	t.Logf("\nholes: %v\neks: %v", holes, se.eks)
+	if len(holes) != 1 || holes[0].ExtentId != 1026 || holes[0].FileOffset != 1500 ||
+		holes[0].ExtentOffset != 500 || holes[0].Size != 2500 {
+		t.Fail()
+	}
+	// the keys after the offset are deleted as a whole
+	if holes = se.TruncatedHoles(1000); len(holes) != 0 {
+		t.Errorf("unexpected holes %v", holes)
+	}
+	// the range referenced by another key of the same extent is kept
+	se = NewSortedExtents()
+	se.Append(proto.ExtentKey{FileOffset: 0, Size: 2000, ExtentId: 1025, ExtentOffset: 0})
+	se.Append(proto.ExtentKey{FileOffset: 1000, Size: 3000, ExtentId: 1025, ExtentOffset: 1000})
+	holes = se.TruncatedHoles(1500)
+	t.Logf("\nholes: %v\neks: %v", holes, se.eks)
+	if len(holes) != 1 || holes[0].ExtentOffset != 2000 || holes[0].Size != 2000 || holes[0].FileOffset != 2000 {
+		t.Fail()
+	}
+	// the tiny extents are punched by the deletion of the keys
+	se = NewSortedExtents()
+	se.Append(proto.ExtentKey{FileOffset: 0, Size: 1000, ExtentId: 1})
+	if holes = se.TruncatedHoles(500); len(holes) != 0 {
+		t.Errorf("unexpected holes %v", holes)
+	}
+}
+
+func TestPunchHole(t *testing.T) {
+	se := NewSortedExtents()
+	se.Append(proto.ExtentKey{FileOffset: 0, Size: 1000, ExtentId: 1025, ExtentOffset: 0})
+	se.Append(proto.ExtentKey{FileOffset: 1000, Size: 1000, ExtentId: 1026, ExtentOffset: 0})
+	se.Append(proto.ExtentKey{FileOffset: 2000, Size: 1000, ExtentId: 1, ExtentOffset: 4096})
+	se.Append(proto.ExtentKey{FileOffset: 3000, Size: 1000, ExtentId: 1027, ExtentOffset: 0})
+	delExtents, holes := se.PunchHole(500, 3000)
+	t.Logf("\ndelete: %v\nholes: %v\neks: %v", delExtents, holes, se.eks)
+	// the middle of the extent is punched and the whole extent in the range is deleted
+	if len(se.eks) != 2 || se.eks[0].ExtentId != 1025 || se.eks[0].Size != 500 ||
+		se.eks[1].ExtentId != 1027 || se.eks[1].FileOffset != 3500 || se.eks[1].ExtentOffset != 500 || se.eks[1].Size != 500 {
+		t.Fatalf("unexpected eks %v", se.eks)
+	}
+	if len(delExtents) != 2 || delExtents[0].ExtentId != 1026 || delExtents[1].ExtentId != 1 ||
+		delExtents[1].ExtentOffset != 4096 || delExtents[1].Size != 1000 {
+		t.Fatalf("unexpected delete extents %v", delExtents)
+	}
+	if len(holes) != 2 || holes[0].ExtentId != 1025 || holes[0].ExtentOffset != 500 || holes[0].Size != 500 ||
+		holes[1].ExtentId != 1027 || holes[1].ExtentOffset != 0 || holes[1].Size != 500 {
+		t.Fatalf("unexpected holes %v", holes)
+	}
+	if se.Size() != 4000 {
+		t.Fatalf("size expect(4000) actual(%v)", se.Size())
+	}
+
+	// the hole inside a key splits it, and a range of a tiny extent is deleted by range
+	se = NewSortedExtents()
+	se.Append(proto.ExtentKey{FileOffset: 0, Size: 3000, ExtentId: 1, ExtentOffset: 0})
+	se.Append(proto.ExtentKey{FileOffset: 3000, Size: 3000, ExtentId: 1025, ExtentOffset: 0})
+	delExtents, holes = se.PunchHole(1000, 1000)
+	if len(se.eks) != 3 || se.eks[1].FileOffset != 2000 || se.eks[1].ExtentOffset != 2000 || se.eks[1].Size != 1000 ||
+		len(delExtents) != 1 || delExtents[0].ExtentOffset != 1000 || delExtents[0].Size != 1000 || len(holes) != 0 {
+		t.Fatalf("unexpected eks %v delete %v holes %v", se.eks, delExtents, holes)
+	}
+	delExtents, holes = se.PunchHole(4000, 1000)
+	if len(se.eks) != 4 || len(delExtents) != 0 || len(holes) != 1 || holes[0].ExtentOffset != 1000 || holes[0].Size != 1000 {
+		t.Fatalf("unexpected eks %v delete %v holes %v", se.eks, delExtents, holes)
+	}
+}
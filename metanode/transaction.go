@@ -0,0 +1,217 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/btree"
+)
+
+// TxState is the state of a transaction.
+// A transaction begins in TxStateBegin, moves to TxStatePrepared once all the participants
+// are prepared, and ends in either TxStateCommitted or TxStateRolledBack.
+type TxState uint8
+
+const (
+	TxStateBegin      = TxState(proto.TxStateBegin)
+	TxStatePrepared   = TxState(proto.TxStatePrepared)
+	TxStateCommitted  = TxState(proto.TxStateCommitted)
+	TxStateRolledBack = TxState(proto.TxStateRolledBack)
+)
+
+func (s TxState) String() string {
+	switch s {
+	case TxStateBegin:
+		return "begin"
+	case TxStatePrepared:
+		return "prepared"
+	case TxStateCommitted:
+		return "committed"
+	case TxStateRolledBack:
+		return "rolledback"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(s))
+	}
+}
+
+// canMoveTo checks the state transition, moving to the current state again is allowed
+// so that the requests can be retried.
+func (s TxState) canMoveTo(to TxState) bool {
+	if s == to {
+		return true
+	}
+	switch s {
+	case TxStateBegin:
+		return to == TxStatePrepared || to == TxStateRolledBack
+	case TxStatePrepared:
+		return to == TxStateCommitted || to == TxStateRolledBack
+	default:
+		return false
+	}
+}
+
+// TxType identifies the kind of the multi-step operation a transaction performs,
+// which decides the handler to complete or revert it.
+type TxType uint8
+
+// TxHandler completes or reverts the transactions of a type abandoned by their coordinators.
+// Commit and Rollback may be called more than once for the same transaction, so they must be
+// idempotent. Release is called once the record of the transaction is removed, with the state
+// the transaction ended in, for the work which must not be done twice, like unlinking an inode.
+type TxHandler interface {
+	Commit(mp *metaPartition, tx *Transaction) error
+	Rollback(mp *metaPartition, tx *Transaction) error
+	Release(mp *metaPartition, tx *Transaction)
+}
+
+var (
+	txHandlers   = make(map[TxType]TxHandler)
+	txHandlersMu sync.RWMutex
+)
+
+// RegisterTxHandler registers the handler of the transactions of the type.
+func RegisterTxHandler(txType TxType, handler TxHandler) {
+	txHandlersMu.Lock()
+	defer txHandlersMu.Unlock()
+	txHandlers[txType] = handler
+}
+
+func getTxHandler(txType TxType) (handler TxHandler, ok bool) {
+	txHandlersMu.RLock()
+	defer txHandlersMu.RUnlock()
+	handler, ok = txHandlers[txType]
+	return
+}
+
+// Transaction is the record of a multi-step metadata operation coordinated by a meta partition.
+// The record is replicated through raft, so the operation can be completed or reverted by
+// the new leader if the coordinator fails in the middle of it.
+type Transaction struct {
+	id         string
+	txType     TxType
+	state      TxState
+	payload    []byte // operation specific, interpreted by the handler of the type
+	createTime int64
+	updateTime int64
+}
+
+func (tx *Transaction) Less(than btree.Item) bool {
+	t, is := than.(*Transaction)
+	return is && tx.id < t.id
+}
+
+func (tx *Transaction) Copy() btree.Item {
+	return &Transaction{
+		id:         tx.id,
+		txType:     tx.txType,
+		state:      tx.state,
+		payload:    append([]byte(nil), tx.payload...),
+		createTime: tx.createTime,
+		updateTime: tx.updateTime,
+	}
+}
+
+func (tx *Transaction) ID() string {
+	return tx.id
+}
+
+func (tx *Transaction) Type() TxType {
+	return tx.txType
+}
+
+func (tx *Transaction) State() TxState {
+	return tx.state
+}
+
+func (tx *Transaction) Payload() []byte {
+	return tx.payload
+}
+
+func (tx *Transaction) String() string {
+	return fmt.Sprintf("Transaction{id(%v) type(%v) state(%v) createTime(%v) updateTime(%v)}",
+		tx.id, tx.txType, tx.state, tx.createTime, tx.updateTime)
+}
+
+func (tx *Transaction) Bytes() ([]byte, error) {
+	var buffer = bytes.NewBuffer(nil)
+	tmp := make([]byte, binary.MaxVarintLen64)
+	var marshalBytes = func(src []byte) {
+		n := binary.PutUvarint(tmp, uint64(len(src)))
+		buffer.Write(tmp[:n])
+		buffer.Write(src)
+	}
+	marshalBytes([]byte(tx.id))
+	buffer.WriteByte(byte(tx.txType))
+	buffer.WriteByte(byte(tx.state))
+	n := binary.PutVarint(tmp, tx.createTime)
+	buffer.Write(tmp[:n])
+	n = binary.PutVarint(tmp, tx.updateTime)
+	buffer.Write(tmp[:n])
+	marshalBytes(tx.payload)
+	return buffer.Bytes(), nil
+}
+
+var errTxCorrupted = errors.New("corrupted transaction")
+
+func TransactionFromBytes(raw []byte) (tx *Transaction, err error) {
+	var offset int
+	var unmarshalBytes = func() ([]byte, error) {
+		length, n := binary.Uvarint(raw[offset:])
+		if n <= 0 || offset+n+int(length) > len(raw) {
+			return nil, errTxCorrupted
+		}
+		offset += n
+		data := raw[offset : offset+int(length)]
+		offset += int(length)
+		return data, nil
+	}
+	var unmarshalVarint = func() (int64, error) {
+		value, n := binary.Varint(raw[offset:])
+		if n <= 0 {
+			return 0, errTxCorrupted
+		}
+		offset += n
+		return value, nil
+	}
+	tx = &Transaction{}
+	var id []byte
+	if id, err = unmarshalBytes(); err != nil {
+		return nil, err
+	}
+	tx.id = string(id)
+	if offset+2 > len(raw) {
+		return nil, errTxCorrupted
+	}
+	tx.txType, tx.state = TxType(raw[offset]), TxState(raw[offset+1])
+	offset += 2
+	if tx.createTime, err = unmarshalVarint(); err != nil {
+		return nil, err
+	}
+	if tx.updateTime, err = unmarshalVarint(); err != nil {
+		return nil, err
+	}
+	var payload []byte
+	if payload, err = unmarshalBytes(); err != nil {
+		return nil, err
+	}
+	tx.payload = append([]byte(nil), payload...)
+	return tx, nil
+}
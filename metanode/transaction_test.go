@@ -0,0 +1,90 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestTransaction_Bytes(t *testing.T) {
+	tx := &Transaction{
+		id:         "1_1600000000000000000_42",
+		txType:     TxType(3),
+		state:      TxStatePrepared,
+		payload:    []byte("payload"),
+		createTime: 1600000000,
+		updateTime: 1600000060,
+	}
+	raw, err := tx.Bytes()
+	if err != nil {
+		t.Fatalf("marshal transaction fail: err(%v)", err)
+	}
+	decoded, err := TransactionFromBytes(raw)
+	if err != nil {
+		t.Fatalf("unmarshal transaction fail: err(%v)", err)
+	}
+	if !reflect.DeepEqual(tx, decoded) {
+		t.Fatalf("transaction mismatch: expect(%v) actual(%v)", tx, decoded)
+	}
+	if _, err = TransactionFromBytes(raw[:len(raw)-1]); err == nil {
+		t.Fatalf("unmarshal truncated transaction should fail")
+	}
+}
+
+func TestTransaction_FSM(t *testing.T) {
+	mp := &metaPartition{txTree: NewBtree()}
+	var id = "tx"
+	var update = func(state TxState) uint8 {
+		return mp.fsmTxUpdate(&Transaction{id: id, state: state, updateTime: 1})
+	}
+	if status := update(TxStatePrepared); status != proto.OpNotExistErr {
+		t.Fatalf("update not exist transaction: status(%v)", status)
+	}
+	if status := mp.fsmTxBegin(&Transaction{id: id}); status != proto.OpOk {
+		t.Fatalf("begin transaction: status(%v)", status)
+	}
+	if status := mp.fsmTxBegin(&Transaction{id: id}); status != proto.OpExistErr {
+		t.Fatalf("begin transaction twice: status(%v)", status)
+	}
+	if status := update(TxStateCommitted); status != proto.OpArgMismatchErr {
+		t.Fatalf("commit transaction not prepared: status(%v)", status)
+	}
+	for _, state := range []TxState{TxStatePrepared, TxStatePrepared, TxStateCommitted} {
+		if status := update(state); status != proto.OpOk {
+			t.Fatalf("move transaction to state(%v): status(%v)", state, status)
+		}
+	}
+	if status := update(TxStateRolledBack); status != proto.OpArgMismatchErr {
+		t.Fatalf("roll back committed transaction: status(%v)", status)
+	}
+	if tx := mp.GetTx(id); tx == nil || tx.State() != TxStateCommitted || tx.updateTime != 1 {
+		t.Fatalf("transaction state mismatch: tx(%v)", tx)
+	}
+	if status := mp.fsmTxUpdate(&Transaction{id: id, state: TxStateCommitted, payload: []byte("payload")}); status != proto.OpOk {
+		t.Fatalf("update transaction payload: status(%v)", status)
+	}
+	if tx := mp.GetTx(id); tx == nil || string(tx.Payload()) != "payload" {
+		t.Fatalf("transaction payload mismatch: tx(%v)", tx)
+	}
+	if status := mp.fsmTxRemove(&Transaction{id: id}); status != proto.OpOk {
+		t.Fatalf("remove transaction: status(%v)", status)
+	}
+	if tx := mp.GetTx(id); tx != nil {
+		t.Fatalf("transaction should be removed: tx(%v)", tx)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/errors"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// renameVolReq is the value of opFSMRenameVol.
+type renameVolReq struct {
+	OldName string `json:"old"`
+	NewName string `json:"new"`
+}
+
+// renameVols renames the partitions of the volumes renamed on the master, which sends the former names
+// mapped to the names with the heartbeat. The leaders rename the partitions through raft, so the name
+// is only changed in the apply path like the rest of the config. The followers and the partitions
+// failed to be renamed wait for the next heartbeat.
+func (m *metadataManager) renameVols(renamed map[string]string) {
+	proto.SetVolRenames(renamed)
+	if len(renamed) == 0 {
+		return
+	}
+	m.Range(func(id uint64, p MetaPartition) bool {
+		mp, ok := p.(*metaPartition)
+		if !ok {
+			return true
+		}
+		oldName := mp.GetBaseConfig().VolName
+		newName, ok := renamed[oldName]
+		if !ok {
+			return true
+		}
+		if _, isLeader := mp.IsLeader(); !isLeader {
+			return true
+		}
+		if err := mp.renameVol(oldName, newName); err != nil {
+			log.LogErrorf("renameVols: mp(%v) rename vol(%v) to (%v) err(%v)", id, oldName, newName, err)
+			return true
+		}
+		log.LogWarnf("renameVols: mp(%v) vol(%v) renamed to (%v)", id, oldName, newName)
+		return true
+	})
+}
+
+func (mp *metaPartition) renameVol(oldName, newName string) (err error) {
+	val, err := json.Marshal(&renameVolReq{OldName: oldName, NewName: newName})
+	if err != nil {
+		return
+	}
+	resp, err := mp.submit(opFSMRenameVol, val)
+	if err != nil {
+		return
+	}
+	if status := resp.(uint8); status != proto.OpOk {
+		p := &Packet{}
+		p.ResultCode = status
+		err = errors.New(p.GetResultMsg())
+	}
+	return
+}
+
+// fsmRenameVol renames the volume of the partition if it still has the former name,
+// so that the repeated proposals and the replayed logs change nothing.
+func (mp *metaPartition) fsmRenameVol(req *renameVolReq) (status uint8, err error) {
+	status = proto.OpOk
+	if mp.config.VolName != req.OldName {
+		return
+	}
+	mp.config.VolName = req.NewName
+	if err = mp.PersistMetadata(); err != nil {
+		log.LogErrorf("fsmRenameVol: mp(%v) persist vol(%v) renamed to (%v) err(%v)",
+			mp.config.PartitionId, req.OldName, req.NewName, err)
+		mp.config.VolName = req.OldName
+		status, err = proto.OpDiskErr, nil
+	}
+	return
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestFsmRenameVol(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rename_vol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	mp := &metaPartition{config: &MetaPartitionConfig{
+		PartitionId: 1,
+		VolName:     "vol1",
+		End:         100,
+		Peers:       []proto.Peer{{ID: 1, Addr: "127.0.0.1:17210"}},
+		RootDir:     dir,
+	}}
+	rename := func(oldName, newName string) interface{} {
+		val, err := json.Marshal(&renameVolReq{OldName: oldName, NewName: newName})
+		if err != nil {
+			t.Fatalf("marshal request: %v", err)
+		}
+		cmd, err := NewMetaItem(opFSMRenameVol, nil, val).MarshalJson()
+		if err != nil {
+			t.Fatalf("marshal command: %v", err)
+		}
+		resp, err := mp.Apply(cmd, 100)
+		if err != nil {
+			t.Fatalf("apply rename: %v", err)
+		}
+		return resp
+	}
+	if status := rename("vol1", "vol2"); status != proto.OpOk || mp.config.VolName != "vol2" {
+		t.Fatalf("rename vol expect(vol2) actual(%v) status(%v)", mp.config.VolName, status)
+	}
+	// a repeated proposal changes nothing
+	if status := rename("vol1", "vol3"); status != proto.OpOk || mp.config.VolName != "vol2" {
+		t.Fatalf("rename vol again expect(vol2) actual(%v) status(%v)", mp.config.VolName, status)
+	}
+	mp2 := &metaPartition{config: &MetaPartitionConfig{RootDir: dir}}
+	if err = mp2.loadMetadata(); err != nil || mp2.config.VolName != "vol2" {
+		t.Fatalf("load renamed vol expect(vol2) actual(%v) err(%v)", mp2.config.VolName, err)
+	}
+}
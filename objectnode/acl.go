@@ -73,6 +73,7 @@ var (
 	aclObjectPermissionActions = map[Permission]proto.Actions{
 		ReadPermission: {
 			proto.OSSGetObjectAction,
+			proto.OSSHeadObjectAction,
 			proto.OSSGetObjectTorrentAction,
 		},
 		WritePermission: {},
@@ -83,6 +84,7 @@ var (
 			proto.OSSPutObjectAclAction},
 		FullControlPermission: {
 			proto.OSSGetObjectAction,
+			proto.OSSHeadObjectAction,
 			proto.OSSGetObjectTorrentAction,
 			proto.OSSGetObjectAclAction,
 			proto.OSSPutObjectAclAction,
@@ -116,6 +118,7 @@ const (
 	objectOwnerRole AclRole = "owner"
 	bucketOwnerRole         = "bucket-owner"
 	allUsersRole            = "AllUsers"
+	authUsersRole           = "AuthenticatedUsers"
 	LogDeliveryRole         = "LogDelivery"
 )
 
@@ -125,7 +128,7 @@ var (
 		PublicReadACL:             {"bucket": {"owner": {FullControlPermission}, "AllUsers": {ReadPermission}}, "object": {"owner": {FullControlPermission}, "AllUsers": {ReadPermission}}},
 		PubliceReadWriteACL:       {"bucket": {"owner": {FullControlPermission}, "AllUsers": {ReadPermission, WritePermission}}, "object": {"owner": {FullControlPermission}, "AllUsers": {ReadPermission, WritePermission}}},
 		AwsExecReadACL:            {"bucket": {"owner": {FullControlPermission}}, "object": {"owner": {FullControlPermission}}},
-		AuthenticatedReadACL:      {"bucket": {"owner": {FullControlPermission}}, "object": {"owner": {FullControlPermission}}},
+		AuthenticatedReadACL:      {"bucket": {"owner": {FullControlPermission}, "AuthenticatedUsers": {ReadPermission}}, "object": {"owner": {FullControlPermission}, "AuthenticatedUsers": {ReadPermission}}},
 		BucketOwnerReadACL:        {"object": {"owner": {FullControlPermission}, "bucket-owner": {ReadPermission}}},
 		BucketOwnerFullControlACL: {"object": {"owner": {FullControlPermission}, "bucket-owner": {FullControlPermission}}},
 		LogDeliveryWriteACL:       {"bucket": {"LogDelivery": {WriteACPPermission, ReadACPPermission}}},
@@ -205,6 +208,29 @@ func (acp *AccessControlPolicy) IsAllowed(param *RequestParam, isOwner bool) boo
 	return false
 }
 
+// IsObjectAllowed checks the request against the ACL of an object, the grants of which
+// are mapped to the object actions instead of the bucket actions.
+func (acp *AccessControlPolicy) IsObjectAllowed(param *RequestParam, isOwner bool) bool {
+	log.LogDebugf("object acl is allowed: %v param: %v", acp, param)
+	if len(acp.Acl.Grants) == 0 {
+		return true
+	}
+	if isOwner {
+		return true
+	}
+	for _, grant := range acp.Acl.Grants {
+		if grant.isGrantee(param) && IsIntersectionActions(aclObjectPermissionActions[grant.Permission], param.Action()) {
+			return true
+		}
+	}
+	return false
+}
+
+// isObjectACLAction checks whether the access to the action is controlled by the ACL of object.
+func isObjectACLAction(action proto.Action) bool {
+	return IsIntersectionActions(aclObjectPermissionActions[FullControlPermission], action)
+}
+
 var (
 	aclGrantKeyPermissionMap = map[string]Permission{
 		"x-amz-grant-full-control": FullControlPermission,
@@ -214,26 +240,40 @@ var (
 		"x-amz-grant-write-acp":    WriteACPPermission,
 	}
 	aclRoleURIMap = map[string]string{
-		"AllUsers":    "http://acs.amazonaws.com/groups/global/AllUsers",
-		"LogDelivery": "http://acs.amazonaws.com/groups/s3/LogDelivery",
+		"AllUsers":           "http://acs.amazonaws.com/groups/global/AllUsers",
+		"AuthenticatedUsers": "http://acs.amazonaws.com/groups/global/AuthenticatedUsers",
+		"LogDelivery":        "http://acs.amazonaws.com/groups/s3/LogDelivery",
 	}
 )
 
 // https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketAcl.html
 func (acp *AccessControlPolicy) SetBucketStandardACL(param *RequestParam, acl string) {
+	acp.setStandardACL(param, acl, bucketResource, "")
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectAcl.html
+// The bucket owner is identified by the access key of the bucket.
+func (acp *AccessControlPolicy) SetObjectStandardACL(param *RequestParam, acl string, bucketOwner string) {
+	acp.setStandardACL(param, acl, objectResource, bucketOwner)
+}
+
+func (acp *AccessControlPolicy) setStandardACL(param *RequestParam, acl string, resource ResourceType, bucketOwner string) {
 	sacl := StandardACL(acl)
 	var (
 		rolePermissionsMap map[string][]Permission
 		ok                 bool
 	)
 
-	if rolePermissionsMap, ok = aclPermissions[sacl]["bucket"]; !ok {
+	if rolePermissionsMap, ok = aclPermissions[sacl][resource]; !ok {
 		return
 	}
 	for role, permissions := range rolePermissionsMap {
 		grantee := Grantee{}
 		if uri, ok := aclRoleURIMap[role]; ok {
 			grantee.URI = uri
+		} else if role == bucketOwnerRole {
+			grantee.Id = bucketOwner
+			grantee.DisplayName = bucketOwner
 		} else {
 			grantee.Id = param.accessKey
 			grantee.DisplayName = param.accessKey
@@ -307,9 +347,90 @@ func (g Grant) Validate() bool {
 }
 
 func (g *Grant) IsAllowed(param *RequestParam) bool {
-	if param.accessKey != g.Grantee.Id {
+	if !g.isGrantee(param) {
 		return false
 	}
 	actions := aclBucketPermissionActions[g.Permission]
 	return IsIntersectionActions(actions, param.Action())
 }
+
+func (g *Grant) isGrantee(param *RequestParam) bool {
+	switch g.Grantee.URI {
+	case "":
+	case aclRoleURIMap[allUsersRole]:
+		return true
+	case aclRoleURIMap[authUsersRole]:
+		return param.accessKey != ""
+	default:
+		return false
+	}
+	return param.accessKey == g.Grantee.Id
+}
+
+// storeObjectACL stores the ACL of the object by the permission priority of the volume. The ACL only
+// changes the mode bits of the object for the POSIX priority, while the mode bits follow the stored ACL
+// for the S3 priority, so that the file system sees the permissions granted by the S3 interface.
+func storeObjectACL(bytes []byte, path string, vol *Volume) (*AccessControlPolicy, error) {
+	acl, err := ParseACL(bytes, vol.name)
+	if err != nil {
+		return nil, err
+	}
+	var priority = vol.mw.PermissionPriority()
+	if priority != proto.PermissionPriorityPOSIX {
+		if err = vol.SetXAttr(path, XAttrKeyOSSACL, bytes, false); err != nil {
+			return nil, err
+		}
+	}
+	if priority != proto.PermissionPriorityNone {
+		if err = vol.setModeByACL(path, acl); err != nil {
+			return nil, err
+		}
+	}
+	return acl, nil
+}
+
+// The permission bits of the group and the others, which the grants to the S3 groups are mapped to.
+const aclGroupModeMask = 0066
+
+// modeFromACL maps the ACL of an object onto its mode bits, keeping the file type and the bits of the owner.
+// The permissions granted to the AllUsers or AuthenticatedUsers group are those of the group and the
+// others, since every user of the file system is authenticated. The grants to other accounts are not
+// mapped, for the accounts of S3 are not the users of the file system.
+func modeFromACL(acp *AccessControlPolicy, mode uint32) uint32 {
+	mode &^= aclGroupModeMask
+	for _, grant := range acp.Acl.Grants {
+		if grant.Grantee.URI != aclRoleURIMap[allUsersRole] && grant.Grantee.URI != aclRoleURIMap[authUsersRole] {
+			continue
+		}
+		switch grant.Permission {
+		case ReadPermission:
+			mode |= 0044
+		case WritePermission:
+			mode |= 0022
+		case FullControlPermission:
+			mode |= 0066
+		}
+	}
+	return mode
+}
+
+// newACLFromMode derives the ACL of an object from its mode bits, which is the inverse of modeFromACL.
+// The others are mapped to the AuthenticatedUsers group rather than AllUsers, so that the mode bits never
+// grant the anonymous access.
+func newACLFromMode(owner string, mode uint32) *AccessControlPolicy {
+	var acp = &AccessControlPolicy{
+		Owner: Owner{Id: owner, DisplayName: owner},
+	}
+	acp.Acl.Grants = append(acp.Acl.Grants, Grant{
+		Grantee:    Grantee{Xmlns: XMLNS, Type: XSI_TYPE, Id: owner, DisplayName: owner},
+		Permission: FullControlPermission,
+	})
+	var others = Grantee{Xmlns: XMLNS, Type: "Group", URI: aclRoleURIMap[authUsersRole]}
+	if mode&0004 != 0 {
+		acp.Acl.Grants = append(acp.Acl.Grants, Grant{Grantee: others, Permission: ReadPermission})
+	}
+	if mode&0002 != 0 {
+		acp.Acl.Grants = append(acp.Acl.Grants, Grant{Grantee: others, Permission: WritePermission})
+	}
+	return acp
+}
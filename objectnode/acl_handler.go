@@ -21,6 +21,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"syscall"
 
 	"github.com/chubaofs/chubaofs/util/log"
 )
@@ -48,7 +49,9 @@ func (o *ObjectNode) getBucketACLHandler(w http.ResponseWriter, r *http.Request)
 		err error
 		ec  *ErrorCode
 	)
-	defer o.errorResponse(w, r, err, ec)
+	defer func() {
+		o.errorResponse(w, r, err, ec)
+	}()
 
 	var param *RequestParam
 	param = ParseRequestParam(r)
@@ -96,7 +99,9 @@ func (o *ObjectNode) putBucketACLHandler(w http.ResponseWriter, r *http.Request)
 		err error
 		ec  *ErrorCode
 	)
-	defer o.errorResponse(w, r, err, ec)
+	defer func() {
+		o.errorResponse(w, r, err, ec)
+	}()
 
 	log.LogInfof("Put bucket acl")
 
@@ -149,14 +154,126 @@ func (o *ObjectNode) putBucketACLHandler(w http.ResponseWriter, r *http.Request)
 	return
 }
 
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectAcl.html
 func (o *ObjectNode) getObjectACLHandler(w http.ResponseWriter, r *http.Request) {
-	//TODO: implement get object acl handler
+	var (
+		err error
+		ec  *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, ec)
+	}()
 
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		ec = NoSuchBucket
+		return
+	}
+	if param.Object() == "" {
+		ec = InvalidKey
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		ec = NoSuchBucket
+		return
+	}
+	var acl *AccessControlPolicy
+	if acl, err = vol.loadObjectACL(param.Object()); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+			ec = NoSuchKey
+			return
+		}
+		ec = InternalErrorCode(err)
+		return
+	}
+	if acl == nil || acl.IsAclEmpty() {
+		acl = &AccessControlPolicy{
+			Owner: Owner{Id: param.AccessKey(), DisplayName: param.AccessKey()},
+		}
+		acl.Acl.Grants = append(acl.Acl.Grants, defaultGrant)
+	}
+
+	acl.Acl.Grants[0].Grantee.Xmlxsi = acl.Acl.Grants[0].Grantee.Xmlns
+	acl.Acl.Grants[0].Grantee.XsiType = acl.Acl.Grants[0].Grantee.Type
+
+	var aclData []byte
+	if aclData, err = xml.Marshal(acl); err != nil {
+		ec = InternalErrorCode(err)
+		return
+	}
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	_, _ = w.Write(aclData)
 	return
 }
 
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectAcl.html
 func (o *ObjectNode) putObjectACLHandler(w http.ResponseWriter, r *http.Request) {
-	//TODO: implement get object acl handler
+	var (
+		err error
+		ec  *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, ec)
+	}()
+
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		ec = NoSuchBucket
+		return
+	}
+	if param.Object() == "" {
+		ec = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		ec = NoSuchBucket
+		return
+	}
+
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil && err != io.EOF {
+		return
+	}
+
+	// The ACL can be specified either in the request body or by the request headers.
+	var acp = &AccessControlPolicy{
+		Owner: Owner{Id: param.AccessKey(), DisplayName: param.AccessKey()},
+	}
+	if len(bytes) > 0 {
+		if acp, err = ParseACL(bytes, param.Bucket()); err != nil {
+			err = nil
+			ec = MalformedACLError
+			return
+		}
+	} else if standardAcl := r.Header.Get(HeaderNameXAmzACL); standardAcl != "" {
+		bucketOwner, _ := vol.OSSSecure()
+		acp.SetObjectStandardACL(param, standardAcl, bucketOwner)
+	} else {
+		for grant, permission := range aclGrantKeyPermissionMap {
+			if r.Header.Get(grant) != "" {
+				acp.SetBucketGrantACL(param, permission)
+			}
+		}
+	}
 
+	var newBytes []byte
+	if newBytes, err = acp.Marshal(); err != nil {
+		return
+	}
+
+	if _, err = storeObjectACL(newBytes, param.Object(), vol); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+			ec = NoSuchKey
+		}
+		return
+	}
+	log.LogInfof("Audit: put object acl: requestID(%v) remote(%v) volume(%v) object(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object())
 	return
 }
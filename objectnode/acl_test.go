@@ -13,3 +13,67 @@
 // permissions and limitations under the License.
 
 package objectnode
+
+import (
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestObjectACLIsAllowed(t *testing.T) {
+	var owner = &RequestParam{accessKey: "owner", action: proto.OSSPutObjectAclAction}
+	var acp = &AccessControlPolicy{}
+	acp.SetObjectStandardACL(owner, string(PublicReadACL), "bucketOwner")
+
+	var samples = []struct {
+		param   *RequestParam
+		allowed bool
+	}{
+		{&RequestParam{accessKey: "owner", action: proto.OSSPutObjectAclAction}, true},
+		{&RequestParam{accessKey: "other", action: proto.OSSGetObjectAction}, true},
+		{&RequestParam{accessKey: "other", action: proto.OSSHeadObjectAction}, true},
+		{&RequestParam{accessKey: "other", action: proto.OSSGetObjectAclAction}, false},
+		{&RequestParam{accessKey: "other", action: proto.OSSPutObjectAclAction}, false},
+	}
+	for _, sample := range samples {
+		if allowed := acp.IsObjectAllowed(sample.param, false); allowed != sample.allowed {
+			t.Fatalf("access key(%v) action(%v) allowed mismatch: expect(%v) actual(%v)",
+				sample.param.accessKey, sample.param.action, sample.allowed, allowed)
+		}
+	}
+}
+
+func TestACLModeMapping(t *testing.T) {
+	var owner = &RequestParam{accessKey: "owner", action: proto.OSSPutObjectAclAction}
+	var samples = []struct {
+		acl  StandardACL
+		mode uint32
+	}{
+		{PrivateACL, 0600},
+		{PublicReadACL, 0644},
+		{PubliceReadWriteACL, 0666},
+		{AuthenticatedReadACL, 0644},
+	}
+	for _, sample := range samples {
+		var acp = &AccessControlPolicy{}
+		acp.SetObjectStandardACL(owner, string(sample.acl), "bucketOwner")
+		if mode := modeFromACL(acp, 0640); mode != sample.mode {
+			t.Fatalf("acl(%v) mode mismatch: expect(%o) actual(%o)", sample.acl, sample.mode, mode)
+		}
+	}
+
+	var acp = newACLFromMode("owner", 0644)
+	if mode := modeFromACL(acp, 0600); mode != 0644 {
+		t.Fatalf("mode mismatch: expect(%o) actual(%o)", 0644, mode)
+	}
+	var other = &RequestParam{accessKey: "other", action: proto.OSSGetObjectAction}
+	if !acp.IsObjectAllowed(other, false) {
+		t.Fatalf("expect authenticated user allowed to read")
+	}
+	if acp.IsObjectAllowed(&RequestParam{action: proto.OSSGetObjectAction}, false) {
+		t.Fatalf("expect anonymous user denied to read")
+	}
+	if newACLFromMode("owner", 0600).IsObjectAllowed(other, false) {
+		t.Fatalf("expect other user denied to read")
+	}
+}
@@ -320,6 +320,9 @@ func calculateAuthKey(key string) (authKey string, err error) {
 }
 
 func (o *ObjectNode) getUserInfoByAccessKey(accessKey string) (userInfo *proto.UserInfo, err error) {
+	if _, _, is := parseTemporaryAccessKey(accessKey); is {
+		return o.loadTemporaryUser(accessKey)
+	}
 	userInfo, err = o.userStore.LoadUser(accessKey)
 	return
 }
@@ -57,6 +57,10 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 		errorCode = NoSuchBucket
 		return
 	}
+	if hasEncryptionHeaders(r) {
+		errorCode = SSENotSupported
+		return
+	}
 
 	// system metadata
 	// Get the requested content-type.
@@ -215,6 +219,174 @@ func (o *ObjectNode) uploadPartHandler(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// Upload part copy
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_UploadPartCopy.html
+func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Request) {
+
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+
+	defer func() {
+		if errorCode != nil {
+			_ = errorCode.ServeResponse(w, r)
+			return
+		}
+	}()
+
+	// check args
+	var param = ParseRequestParam(r)
+
+	// get upload id and part number
+	uploadId := param.GetVar(ParamUploadId)
+	partNumber := param.GetVar(ParamPartNumber)
+	if uploadId == "" || partNumber == "" {
+		log.LogErrorf("uploadPartCopyHandler: illegal uploadID or partNumber, requestID(%v)", GetRequestID(r))
+		errorCode = InvalidArgument
+		return
+	}
+
+	var partNumberInt uint64
+	if partNumberInt, err = strconv.ParseUint(partNumber, 10, 64); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: parse part number fail, requestID(%v) raw(%v) err(%v)",
+			GetRequestID(r), partNumber, err)
+		errorCode = InvalidArgument
+		return
+	}
+
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: load volume fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		errorCode = NoSuchBucket
+		return
+	}
+
+	// get copy source
+	sourceBucket, sourceObject := parseCopySourceInfo(r)
+	if sourceBucket == "" || sourceObject == "" {
+		log.LogErrorf("uploadPartCopyHandler: illegal copy source, requestID(%v) source(%v)",
+			GetRequestID(r), r.Header.Get(HeaderNameXAmzCopySource))
+		errorCode = InvalidArgument
+		return
+	}
+
+	// check ACL
+	userInfo, err := o.getUserInfoByAccessKey(param.AccessKey())
+	if err != nil {
+		log.LogErrorf("uploadPartCopyHandler: get user info from master fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), param.AccessKey(), err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+	if !userInfo.Policy.IsAuthorized(sourceBucket, "", proto.OSSUploadPartCopyAction) {
+		log.LogErrorf("uploadPartCopyHandler: no permission to copy from source bucket, requestID(%v), source bucket(%v), source file(%v), target bucket(%v), target file(%v)",
+			GetRequestID(r), sourceBucket, sourceObject, param.Bucket(), param.Object())
+		errorCode = AccessDenied
+		return
+	}
+
+	var sourceVol *Volume
+	if sourceVol, err = o.getVol(sourceBucket); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: load source volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), sourceBucket, err)
+		errorCode = NoSuchBucket
+		return
+	}
+
+	var fileInfo *FSFileInfo
+	if fileInfo, err = sourceVol.ObjectMeta(sourceObject); err != nil {
+		if err == syscall.ENOENT {
+			errorCode = NoSuchKey
+			return
+		}
+		log.LogErrorf("uploadPartCopyHandler: get source file info fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), sourceBucket, sourceObject, err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+	if fileInfo.Mode.IsDir() {
+		errorCode = InvalidArgument
+		return
+	}
+
+	if errorCode = checkCopySourcePreconditions(r, fileInfo); errorCode != nil {
+		return
+	}
+	// the data is copied as is, so the source can not be encrypted
+	if fileInfo.Encryption != nil {
+		errorCode = SSENotSupported
+		return
+	}
+
+	// parse copy source range, copy the whole source object if absent
+	var offset, size = uint64(0), uint64(fileInfo.Size)
+	if rangeValue := r.Header.Get(HeaderNameXAmzCopySourceRange); rangeValue != "" {
+		if offset, size, err = parseCopySourceRange(rangeValue, uint64(fileInfo.Size)); err != nil {
+			log.LogErrorf("uploadPartCopyHandler: illegal copy source range: requestID(%v) range(%v) size(%v) err(%v)",
+				GetRequestID(r), rangeValue, fileInfo.Size, err)
+			if err == syscall.ERANGE {
+				errorCode = InvalidRange
+			} else {
+				errorCode = InvalidArgument
+			}
+			return
+		}
+	}
+	if size > MaxCopyObjectSize {
+		errorCode = EntityTooLarge
+		return
+	}
+
+	// The data extents of the data node are not reference counted, so the extents can not be
+	// shared between the source object and the part, and the data is copied through the object node.
+	var reader, writer = io.Pipe()
+	go func() {
+		_ = writer.CloseWithError(sourceVol.ReadFile(sourceObject, writer, offset, size))
+	}()
+	var fsFileInfo *FSFileInfo
+	fsFileInfo, err = vol.WritePart(param.Object(), uploadId, uint16(partNumberInt), reader)
+	_ = reader.Close()
+	if err == syscall.ENOENT {
+		errorCode = NoSuchUpload
+		return
+	}
+	if err != nil {
+		log.LogErrorf("uploadPartCopyHandler: write part fail: requestID(%v) volume(%v) path(%v) uploadId(%v) part(%v) source(%v/%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), uploadId, partNumberInt, sourceBucket, sourceObject, err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+	log.LogDebugf("uploadPartCopyHandler: copy part success: requestID(%v) volume(%v) path(%v) uploadId(%v) part(%v) source(%v/%v) range(%v,%v)",
+		GetRequestID(r), vol.Name(), param.Object(), uploadId, partNumberInt, sourceBucket, sourceObject, offset, size)
+
+	var copyPartResult = &CopyPartResult{
+		ETag:         wrapUnescapedQuot(fsFileInfo.ETag),
+		LastModified: formatTimeISO(fsFileInfo.ModifyTime),
+	}
+	var bytes []byte
+	if bytes, err = MarshalXMLEntity(copyPartResult); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: marshal result fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(bytes))}
+	_, _ = w.Write(bytes)
+	return
+}
+
 // List parts
 // API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListParts.html
 func (o *ObjectNode) listPartsHandler(w http.ResponseWriter, r *http.Request) {
@@ -22,7 +22,6 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -32,10 +31,6 @@ import (
 	"github.com/chubaofs/chubaofs/util/log"
 )
 
-var (
-	rangeRegexp = regexp.MustCompile("^bytes=(\\d)+-(\\d)*$")
-)
-
 // Get object
 // API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObject.html
 func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
@@ -68,57 +63,6 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 		errorCode = NoSuchBucket
 		return
 	}
-	// parse http range option
-	var rangeOpt = strings.TrimSpace(r.Header.Get(HeaderNameRange))
-	var rangeLower uint64
-	var rangeUpper uint64
-	var isRangeRead bool
-	var partSize uint64
-	var partCount uint64
-	if len(rangeOpt) > 0 && rangeRegexp.MatchString(rangeOpt) {
-
-		var hyphenIndex = strings.Index(rangeOpt, "-")
-		if hyphenIndex < 0 {
-			errorCode = InvalidArgument
-			return
-		}
-
-		var lowerPart = rangeOpt[len("bytes="):hyphenIndex]
-		var upperPart = ""
-		if hyphenIndex+1 < len(rangeOpt) {
-			upperPart = rangeOpt[hyphenIndex+1:]
-		}
-
-		if len(lowerPart) > 0 {
-			if rangeLower, err = strconv.ParseUint(lowerPart, 10, 64); err != nil {
-				log.LogErrorf("getObjectHandler: parse range lower fail: requestID(%v) rangeOpt(%v) err(%v)",
-					GetRequestID(r), rangeOpt, err)
-				ServeInternalStaticErrorResponse(w, r)
-				return
-			}
-		}
-		if len(upperPart) > 0 {
-			if rangeUpper, err = strconv.ParseUint(upperPart, 10, 64); err != nil {
-				log.LogErrorf("getObjectHandler: parse range upper fail: requestID(%v) rangeOpt(%v) err(%v)",
-					GetRequestID(r), rangeOpt, err)
-				ServeInternalStaticErrorResponse(w, r)
-				return
-			}
-		}
-		if rangeUpper > 0 && rangeUpper < rangeLower {
-			// upper enabled and lower than lower side
-			if err = InvalidArgument.ServeResponse(w, r); err != nil {
-				log.LogErrorf("getObjectHandler: serve response fail: requestID(%v) err(%v)",
-					GetRequestID(r), err)
-				return
-			}
-		}
-
-		isRangeRead = true
-		log.LogDebugf("getObjectHandler: parse range option: requestID(%v) rangeOpt(%v) rangeLower(%v) rangeUpper(%v)",
-			GetRequestID(r), rangeOpt, rangeLower, rangeUpper)
-	}
-
 	responseCacheControl := r.URL.Query().Get(ParamResponseCacheControl)
 	if len(responseCacheControl) > 0 && !ValidateCacheControl(responseCacheControl) {
 		errorCode = InvalidCacheArgument
@@ -134,7 +78,22 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// get object meta
 	var fileInfo *FSFileInfo
-	fileInfo, err = vol.ObjectMeta(param.Object())
+	var versionId = r.URL.Query().Get(ParamVersionId)
+	if versionId != "" {
+		var deleteMarker bool
+		fileInfo, deleteMarker, err = vol.ObjectVersionMeta(param.Object(), versionId)
+		if err == nil && deleteMarker {
+			serveVersionHeaders(w, versionId, true)
+			errorCode = MethodNotAllowed
+			return
+		}
+		if err == syscall.ENOENT {
+			errorCode = NoSuchVersion
+			return
+		}
+	} else {
+		fileInfo, err = vol.ObjectMeta(param.Object())
+	}
 	if err == syscall.ENOENT {
 		errorCode = NoSuchKey
 		return
@@ -145,95 +104,54 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 		errorCode = InternalErrorCode(err)
 		return
 	}
-
-	// parse request header
-	match := r.Header.Get(HeaderNameIfMatch)
-	noneMatch := r.Header.Get(HeaderNameIfNoneMatch)
-	modified := r.Header.Get(HeaderNameIfModifiedSince)
-	unmodified := r.Header.Get(HeaderNameIfUnmodifiedSince)
-
-	// Checking precondition: If-Match
-	// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObject.html#API_GetObject_RequestSyntax
-	if match != "" {
-		if matchEag := strings.Trim(match, "\""); matchEag != fileInfo.ETag {
-			log.LogDebugf("getObjectHandler: object eTag(%s) not match If-Match header value(%s), requestId(%v)",
-				fileInfo.ETag, matchEag, GetRequestID(r))
-			errorCode = PreconditionFailed
-			return
-		}
-	}
-	// Checking precondition: If-Modified-Since
-	// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObject.html#API_GetObject_RequestSyntax
-	if modified != "" {
-		fileModTime := fileInfo.ModifyTime
-		modifiedTime, err := parseTimeRFC1123(modified)
-		if err != nil {
-			log.LogErrorf("getObjectHandler: parse RFC1123 time fail: requestID(%v) err(%v)", GetRequestID(r), err)
-			errorCode = InvalidArgument
-			return
-		}
-		if !fileModTime.After(modifiedTime) {
-			log.LogInfof("getObjectHandler: file modified time not after than specified time: requestID(%v)", GetRequestID(r))
-			errorCode = NotModified
-			return
-		}
+	// resolve the key of the encrypted object
+	if errorCode = o.openObjectEncryption(r, fileInfo.Encryption); errorCode != nil {
+		return
 	}
-	// Checking precondition: If-None-Match
+
+	// Checking preconditions: If-Match, If-Unmodified-Since, If-None-Match and If-Modified-Since
 	// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObject.html#API_GetObject_RequestSyntax
-	if noneMatch != "" {
-		if noneMatchEtag := strings.Trim(noneMatch, "\""); noneMatchEtag == fileInfo.ETag {
-			log.LogErrorf("getObjectHandler: object eTag(%s) match If-None-Match header value(%s), requestId(%v)",
-				fileInfo.ETag, noneMatchEtag, GetRequestID(r))
-			errorCode = NotModified
-			return
-		}
+	if errorCode = checkObjectPreconditions(w, r, fileInfo); errorCode != nil {
+		log.LogDebugf("getObjectHandler: precondition not hold: requestID(%v) eTag(%v) modifyTime(%v) errorCode(%v)",
+			GetRequestID(r), fileInfo.ETag, fileInfo.ModifyTime, errorCode.ErrorCode)
+		return
 	}
-	// Checking precondition: If-Unmodified-Since
-	// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObject.html#API_GetObject_RequestSyntax
-	if unmodified != "" && match == "" {
-		fileModTime := fileInfo.ModifyTime
-		modifiedTime, err := parseTimeRFC1123(unmodified)
-		if err != nil {
-			log.LogErrorf("getObjectHandler: parse RFC1123 time fail: requestID(%v) err(%v)", GetRequestID(r), err)
-			errorCode = InvalidArgument
-			return
-		}
-		if fileModTime.After(modifiedTime) {
-			log.LogInfof("getObjectHandler: file modified time after than specified time: requestID(%v)", GetRequestID(r))
-			errorCode = PreconditionFailed
+
+	// parse http range option, the malformed ranges are ignored
+	var ranges []httpRange
+	var rangeOpt = strings.TrimSpace(r.Header.Get(HeaderNameRange))
+	if len(rangeOpt) > 0 && !fileInfo.Mode.IsDir() && checkIfRange(r, fileInfo) {
+		ranges, err = parseRange(rangeOpt, fileInfo.Size)
+		switch err {
+		case nil:
+		case errNoOverlap:
+			w.Header()[HeaderNameContentRange] = []string{fmt.Sprintf("bytes */%d", fileInfo.Size)}
+			errorCode = InvalidRange
 			return
+		default:
+			log.LogDebugf("getObjectHandler: ignore invalid range option: requestID(%v) rangeOpt(%v)",
+				GetRequestID(r), rangeOpt)
 		}
-	}
-
-	// validate and fix range
-	if isRangeRead && rangeUpper > uint64(fileInfo.Size)-1 {
-		rangeUpper = uint64(fileInfo.Size) - 1
-	}
-
-	// compute content length
-	var contentLength = uint64(fileInfo.Size)
-	if isRangeRead {
-		contentLength = rangeUpper - rangeLower + 1
+		log.LogDebugf("getObjectHandler: parse range option: requestID(%v) rangeOpt(%v) ranges(%v)",
+			GetRequestID(r), rangeOpt, ranges)
 	}
 
 	// get object tagging size
-	var xattrInfo *proto.XAttrInfo
-	if xattrInfo, err = vol.GetXAttr(param.object, XAttrKeyOSSTagging); err != nil && err != syscall.ENOENT {
+	var taggingCount int
+	if taggingCount, err = getObjectTaggingCount(vol, param.object); err != nil {
 		log.LogErrorf("getObjectHandler: Volume get XAttr fail: requestID(%v) err(%v)", GetRequestID(r), err)
 		errorCode = InternalErrorCode(err)
 		return
 	}
-	if xattrInfo != nil {
-		ossTaggingData := xattrInfo.Get(XAttrKeyOSSTagging)
-		output, _ := ParseTagging(string(ossTaggingData))
-		if output != nil && len(output.TagSet) > 0 {
-			w.Header()[HeaderNameXAmzTaggingCount] = []string{strconv.Itoa(len(output.TagSet))}
-		}
+	if taggingCount > 0 {
+		w.Header()[HeaderNameXAmzTaggingCount] = []string{strconv.Itoa(taggingCount)}
 	}
 
 	// set response header for GetObject
 	w.Header()[HeaderNameAcceptRange] = []string{HeaderValueAcceptRange}
 	w.Header()[HeaderNameLastModified] = []string{formatTimeRFC1123(fileInfo.ModifyTime)}
+	serveVersionHeaders(w, fileInfo.VersionId, false)
+	serveEncryptionHeaders(w, fileInfo.Encryption)
 	if len(responseContentType) > 0 {
 		w.Header()[HeaderNameContentType] = []string{responseContentType}
 	} else if len(fileInfo.MIMEType) > 0 {
@@ -266,7 +184,7 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 			errorCode = InvalidArgument
 			return
 		}
-		partSize, partCount, rangeLower, rangeUpper, err = parsePartInfo(partNumberInt, uint64(fileInfo.Size))
+		partSize, partCount, rangeLower, rangeUpper, err := parsePartInfo(partNumberInt, uint64(fileInfo.Size))
 		log.LogDebugf("getObjectHandler: parsed partSize(%d), partCount(%d), rangeLower(%d), rangeUpper(%d)", partSize, partCount, rangeLower, rangeUpper)
 		if err != nil {
 			errorCode = InternalErrorCode(err)
@@ -279,20 +197,13 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		// Header : Accept-Range, Content-Length, Content-Range, ETag, x-amz-mp-parts-count
-		w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(int(partSize))}
-		w.Header()[HeaderNameContentRange] = []string{fmt.Sprintf("bytes %d-%d/%d", rangeLower, rangeUpper, fileInfo.Size)}
+		ranges = []httpRange{{start: int64(rangeLower), length: int64(rangeUpper - rangeLower + 1)}}
 		w.Header()[HeaderNameXAmzDownloadPartCount] = []string{strconv.Itoa(int(partCount))}
 		if len(fileInfo.ETag) > 0 && !strings.Contains(fileInfo.ETag, "-") {
 			w.Header()[HeaderNameETag] = []string{fmt.Sprintf("%s-%d", fileInfo.ETag, partCount)}
 		}
-	} else {
-		w.Header()[HeaderNameContentLength] = []string{strconv.FormatUint(contentLength, 10)}
-		if len(fileInfo.ETag) > 0 {
-			w.Header()[HeaderNameETag] = []string{wrapUnescapedQuot(fileInfo.ETag)}
-		}
-		if isRangeRead {
-			w.Header()[HeaderNameContentRange] = []string{fmt.Sprintf("bytes %d-%d/%d", rangeLower, rangeUpper, fileInfo.Size)}
-		}
+	} else if len(fileInfo.ETag) > 0 {
+		w.Header()[HeaderNameETag] = []string{wrapUnescapedQuot(fileInfo.ETag)}
 	}
 
 	// User-defined metadata
@@ -301,31 +212,48 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if fileInfo.Mode.IsDir() {
+		w.Header()[HeaderNameContentLength] = []string{strconv.FormatInt(fileInfo.Size, 10)}
 		return
 	}
 
 	// get object content
-	var offset = rangeLower
-	var size = uint64(fileInfo.Size)
-	if isRangeRead || len(partNumber) > 0 {
-		if rangeUpper == 0 {
-			size = uint64(fileInfo.Size) - rangeLower
-		} else {
-			size = rangeUpper - rangeLower + 1
+	var readRange = func(writer io.Writer, offset, size uint64) (err error) {
+		if fileInfo.Encryption != nil {
+			if writer, err = fileInfo.Encryption.DecryptWriter(writer, offset); err != nil {
+				return
+			}
 		}
-	}
-	err = vol.ReadFile(param.Object(), w, offset, size)
-	if err == syscall.ENOENT {
+		if versionId != "" {
+			return vol.readInode(param.Object(), fileInfo.Inode, writer, offset, size)
+		}
+		return vol.ReadFile(param.Object(), writer, offset, size)
+	}
+	var offset, size = uint64(0), uint64(fileInfo.Size)
+	switch {
+	case len(ranges) == 1:
+		offset, size = uint64(ranges[0].start), uint64(ranges[0].length)
+		w.Header()[HeaderNameContentLength] = []string{strconv.FormatUint(size, 10)}
+		w.Header()[HeaderNameContentRange] = []string{ranges[0].contentRange(fileInfo.Size)}
+		w.WriteHeader(http.StatusPartialContent)
+		err = readRange(w, offset, size)
+	case len(ranges) > 1:
+		// multiple ranges are served in multipart/byteranges
+		err = writeRanges(w, ranges, fileInfo.Size, readRange)
+	default:
+		w.Header()[HeaderNameContentLength] = []string{strconv.FormatUint(size, 10)}
+		err = readRange(w, offset, size)
+	}
+	if err == syscall.ENOENT && len(ranges) == 0 {
 		errorCode = NoSuchKey
 		return
 	}
 	if err != nil {
-		log.LogErrorf("getObjectHandler: read from Volume fail: requestId(%v) volume(%v) path(%v) offset(%v) size(%v) err(%v)",
-			GetRequestID(r), param.Bucket(), param.Object(), offset, size, err)
+		log.LogErrorf("getObjectHandler: read from Volume fail: requestId(%v) volume(%v) path(%v) ranges(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), param.Object(), ranges, err)
 		return
 	}
-	log.LogDebugf("getObjectHandler: Volume read file: requestID(%v) Volume(%v) path(%v) offset(%v) size(%v)",
-		GetRequestID(r), param.Bucket(), param.Object(), offset, size)
+	log.LogDebugf("getObjectHandler: Volume read file: requestID(%v) Volume(%v) path(%v) ranges(%v)",
+		GetRequestID(r), param.Bucket(), param.Object(), ranges)
 	return
 }
 
@@ -364,7 +292,22 @@ func (o *ObjectNode) headObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// get object meta
 	var fileInfo *FSFileInfo
-	fileInfo, err = vol.ObjectMeta(param.Object())
+	var versionId = r.URL.Query().Get(ParamVersionId)
+	if versionId != "" {
+		var deleteMarker bool
+		fileInfo, deleteMarker, err = vol.ObjectVersionMeta(param.Object(), versionId)
+		if err == nil && deleteMarker {
+			serveVersionHeaders(w, versionId, true)
+			errorCode = MethodNotAllowed
+			return
+		}
+		if err == syscall.ENOENT {
+			errorCode = NoSuchVersion
+			return
+		}
+	} else {
+		fileInfo, err = vol.ObjectMeta(param.Object())
+	}
 	if err == syscall.ENOENT {
 		errorCode = NoSuchKey
 		return
@@ -375,69 +318,35 @@ func (o *ObjectNode) headObjectHandler(w http.ResponseWriter, r *http.Request) {
 		errorCode = InternalErrorCode(err)
 		return
 	}
-
-	// parse request header
-	match := r.Header.Get(HeaderNameIfMatch)
-	noneMatch := r.Header.Get(HeaderNameIfNoneMatch)
-	modified := r.Header.Get(HeaderNameIfModifiedSince)
-	unmodified := r.Header.Get(HeaderNameIfUnmodifiedSince)
-
-	// Checking precondition: If-Match
-	// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_HeadObject.html#API_HeadObject_RequestSyntax
-	if match != "" {
-		if matchEag := strings.Trim(match, "\""); matchEag != fileInfo.ETag {
-			log.LogDebugf("headObjectHandler: object eTag(%s) not match If-Match header value(%s), requestId(%v)",
-				fileInfo.ETag, matchEag, GetRequestID(r))
-			errorCode = PreconditionFailed
-			return
-		}
+	// check the key of the encrypted object
+	if errorCode = o.openObjectEncryption(r, fileInfo.Encryption); errorCode != nil {
+		return
 	}
-	// Checking precondition: If-Modified-Since
+
+	// Checking preconditions: If-Match, If-Unmodified-Since, If-None-Match and If-Modified-Since
 	// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_HeadObject.html#API_HeadObject_RequestSyntax
-	if modified != "" {
-		fileModTime := fileInfo.ModifyTime
-		modifiedTime, err := parseTimeRFC1123(modified)
-		if err != nil {
-			log.LogDebugf("headObjectHandler: parse RFC1123 time fail: requestID(%v) err(%v)", GetRequestID(r), err)
-			errorCode = InvalidArgument
-			return
-		}
-		if !fileModTime.After(modifiedTime) {
-			log.LogDebugf("headObjectHandler: file modified time not after than specified time: requestID(%v)", GetRequestID(r))
-			errorCode = NotModified
-			return
-		}
+	if errorCode = checkObjectPreconditions(w, r, fileInfo); errorCode != nil {
+		log.LogDebugf("headObjectHandler: precondition not hold: requestID(%v) eTag(%v) modifyTime(%v) errorCode(%v)",
+			GetRequestID(r), fileInfo.ETag, fileInfo.ModifyTime, errorCode.ErrorCode)
+		return
 	}
-	// Checking precondition: If-None-Match
-	// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_HeadObject.html#API_HeadObject_RequestSyntax
-	if noneMatch != "" {
-		if noneMatchEtag := strings.Trim(noneMatch, "\""); noneMatchEtag == fileInfo.ETag {
-			log.LogDebugf("headObjectHandler: object eTag(%s) match If-None-Match header value(%s), requestId(%v)",
-				fileInfo.ETag, noneMatchEtag, GetRequestID(r))
-			errorCode = NotModified
-			return
-		}
+
+	// get object tagging size
+	var taggingCount int
+	if taggingCount, err = getObjectTaggingCount(vol, param.Object()); err != nil {
+		log.LogErrorf("headObjectHandler: Volume get XAttr fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		errorCode = InternalErrorCode(err)
+		return
 	}
-	// Checking precondition: If-Unmodified-Since
-	// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_HeadObject.html#API_HeadObject_RequestSyntax
-	if unmodified != "" && match == "" {
-		fileModTime := fileInfo.ModifyTime
-		modifiedTime, err := parseTimeRFC1123(unmodified)
-		if err != nil {
-			log.LogDebugf("headObjectHandler: parse RFC1123 time fail: requestID(%v) err(%v)", GetRequestID(r), err)
-			errorCode = InvalidArgument
-			return
-		}
-		if fileModTime.After(modifiedTime) {
-			log.LogDebugf("headObjectHandler: file modified time after than specified time: requestID(%v)", GetRequestID(r))
-			errorCode = PreconditionFailed
-			return
-		}
+	if taggingCount > 0 {
+		w.Header()[HeaderNameXAmzTaggingCount] = []string{strconv.Itoa(taggingCount)}
 	}
 
 	// set response header
 	w.Header()[HeaderNameAcceptRange] = []string{HeaderValueAcceptRange}
 	w.Header()[HeaderNameLastModified] = []string{formatTimeRFC1123(fileInfo.ModifyTime)}
+	serveVersionHeaders(w, fileInfo.VersionId, false)
+	serveEncryptionHeaders(w, fileInfo.Encryption)
 	w.Header()[HeaderNameContentMD5] = []string{EmptyContentMD5String}
 	if len(fileInfo.MIMEType) > 0 {
 		w.Header()[HeaderNameContentType] = []string{fileInfo.MIMEType}
@@ -565,18 +474,32 @@ func (o *ObjectNode) deleteObjectsHandler(w http.ResponseWriter, r *http.Request
 		return deleteReq.Objects[i].Key > deleteReq.Objects[j].Key
 	})
 
+	var versioning = vol.versioningStatus() != ""
 	var objectKeys = make([]string, 0, len(deleteReq.Objects))
 	for _, object := range deleteReq.Objects {
 		objectKeys = append(objectKeys, object.Key)
-		err = vol.DeletePath(object.Key)
+		var deleted = Deleted{Key: object.Key}
+		if object.VersionId != "" || versioning {
+			var versionId string
+			var deleteMarker bool
+			versionId, deleteMarker, err = vol.DeleteVersion(object.Key, object.VersionId)
+			if object.VersionId != "" {
+				deleted.VersionId = versionId
+			}
+			if deleteMarker {
+				deleted.DeleteMarker, deleted.DeleteMarkerVersionId = "true", versionId
+			}
+		} else {
+			err = vol.DeletePath(object.Key)
+		}
 		log.LogWarnf("deleteObjectsHandler: delete: requestID(%v) volume(%v) path(%v)",
 			GetRequestID(r), vol.Name(), object.Key)
 		if err != nil {
-			deletedErrors = append(deletedErrors, Error{Key: object.Key, Message: err.Error()})
+			deletedErrors = append(deletedErrors, Error{Key: object.Key, VersionId: object.VersionId, Message: err.Error()})
 			log.LogErrorf("deleteObjectsHandler: delete object failed: requestID(%v) volume(%v) path(%v) err(%v)",
 				GetRequestID(r), vol.Name(), object.Key, err)
 		} else {
-			deletedObjects = append(deletedObjects, Deleted{Key: object.Key})
+			deletedObjects = append(deletedObjects, deleted)
 			log.LogDebugf("deleteObjectsHandler: delete object success: requestID(%v) volume(%v) path(%v)", GetRequestID(r),
 				vol.Name(), object.Key)
 		}
@@ -629,6 +552,73 @@ func parseCopySourceInfo(r *http.Request) (sourceBucket, sourceObject string) {
 	return
 }
 
+// checkCopySourcePreconditions checks the x-amz-copy-source-if-* headers against the source object.
+func checkCopySourcePreconditions(r *http.Request, fileInfo *FSFileInfo) *ErrorCode {
+	// get header
+	copyMatch := r.Header.Get(HeaderNameXAmzCopyMatch)
+	noneMatch := r.Header.Get(HeaderNameXAmzCopyNoneMatch)
+	modified := r.Header.Get(HeaderNameXAmzCopyModified)
+	unModified := r.Header.Get(HeaderNameXAmzCopyUnModified)
+
+	// response 412
+	if modified != "" {
+		fileModTime := fileInfo.ModifyTime
+		modifiedTime, err := parseTimeRFC1123(modified)
+		if err != nil {
+			log.LogErrorf("checkCopySourcePreconditions: parse RFC1123 time fail: requestID(%v) err(%v)", GetRequestID(r), err)
+			return InvalidArgument
+		}
+		if fileModTime.Before(modifiedTime) {
+			log.LogInfof("checkCopySourcePreconditions: file modified time not after than specified time: requestID(%v)", GetRequestID(r))
+			return PreconditionFailed
+		}
+	}
+	if unModified != "" {
+		fileModTime := fileInfo.ModifyTime
+		unmodifiedTime, err := parseTimeRFC1123(unModified)
+		if err != nil {
+			log.LogErrorf("checkCopySourcePreconditions: parse RFC1123 time fail: requestID(%v) err(%v)", GetRequestID(r), err)
+			return InvalidArgument
+		}
+		if fileModTime.After(unmodifiedTime) {
+			log.LogInfof("checkCopySourcePreconditions: file modified time not before than specified time: requestID(%v)", GetRequestID(r))
+			return PreconditionFailed
+		}
+	}
+	if copyMatch != "" && fileInfo.ETag != strings.Trim(copyMatch, "\"") {
+		log.LogInfof("checkCopySourcePreconditions: eTag mismatched with specified: requestID(%v)", GetRequestID(r))
+		return PreconditionFailed
+	}
+	if noneMatch != "" && fileInfo.ETag == strings.Trim(noneMatch, "\"") {
+		log.LogInfof("checkCopySourcePreconditions: eTag same with specified: requestID(%v)", GetRequestID(r))
+		return PreconditionFailed
+	}
+	return nil
+}
+
+// parseCopySourceRange parses the x-amz-copy-source-range header in the form of 'bytes=first-last',
+// and returns the offset and size of the range in the source object.
+func parseCopySourceRange(value string, objectSize uint64) (offset, size uint64, err error) {
+	if !strings.HasPrefix(value, "bytes=") {
+		return 0, 0, syscall.EINVAL
+	}
+	var bounds = strings.SplitN(strings.TrimPrefix(value, "bytes="), "-", 2)
+	if len(bounds) != 2 {
+		return 0, 0, syscall.EINVAL
+	}
+	var first, last uint64
+	if first, err = strconv.ParseUint(bounds[0], 10, 64); err != nil {
+		return 0, 0, syscall.EINVAL
+	}
+	if last, err = strconv.ParseUint(bounds[1], 10, 64); err != nil {
+		return 0, 0, syscall.EINVAL
+	}
+	if first > last || last >= objectSize {
+		return 0, 0, syscall.ERANGE
+	}
+	return first, last - first + 1, nil
+}
+
 // Copy object
 // API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_CopyObject.html .
 func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
@@ -708,9 +698,18 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// open source object stream
+	var sourceVol *Volume
+	if sourceVol, err = o.getVol(sourceBucket); err != nil {
+		log.LogErrorf("copyObjectHandler: load source volume fail: vol(%v) requestID(%v) err(%v)",
+			sourceBucket, getRequestIP(r), err)
+		errorCode = NoSuchBucket
+		return
+	}
+
 	// get object meta
 	var fileInfo *FSFileInfo
-	fileInfo, err = vol.ObjectMeta(sourceObject)
+	fileInfo, err = sourceVol.ObjectMeta(sourceObject)
 	if err != nil {
 		if err == syscall.ENOENT {
 			errorCode = NoSuchKey
@@ -721,58 +720,12 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// get header
-	copyMatch := r.Header.Get(HeaderNameXAmzCopyMatch)
-	noneMatch := r.Header.Get(HeaderNameXAmzCopyNoneMatch)
-	modified := r.Header.Get(HeaderNameXAmzCopyModified)
-	unModified := r.Header.Get(HeaderNameXAmzCopyUnModified)
-
-	// response 412
-	if modified != "" {
-		fileModTime := fileInfo.ModifyTime
-		modifiedTime, err := parseTimeRFC1123(modified)
-		if err != nil {
-			log.LogErrorf("copyObjectHandler: parse RFC1123 time fail: requestID(%v) err(%v)", GetRequestID(r), err)
-			errorCode = InvalidArgument
-			return
-		}
-		if fileModTime.Before(modifiedTime) {
-			log.LogInfof("copyObjectHandler: file modified time not after than specified time: requestID(%v)", GetRequestID(r))
-			errorCode = PreconditionFailed
-			return
-		}
-	}
-	if unModified != "" {
-		fileModTime := fileInfo.ModifyTime
-		unmodifiedTime, err := parseTimeRFC1123(unModified)
-		if err != nil {
-			log.LogErrorf("copyObjectHandler: parse RFC1123 time fail: requestID(%v) err(%v)", GetRequestID(r), err)
-			errorCode = InvalidArgument
-			return
-		}
-		if fileModTime.After(unmodifiedTime) {
-			log.LogInfof("copyObjectHandler: file modified time not before than specified time: requestID(%v)", GetRequestID(r))
-			errorCode = PreconditionFailed
-			return
-		}
-	}
-	if copyMatch != "" && fileInfo.ETag != copyMatch {
-		log.LogInfof("copyObjectHandler: eTag mismatched with specified: requestID(%v)", GetRequestID(r))
-		errorCode = PreconditionFailed
+	if errorCode = checkCopySourcePreconditions(r, fileInfo); errorCode != nil {
 		return
 	}
-	if noneMatch != "" && fileInfo.ETag == noneMatch {
-		log.LogInfof("copyObjectHandler: eTag same with specified: requestID(%v)", GetRequestID(r))
-		errorCode = PreconditionFailed
-		return
-	}
-
-	// open source object stream
-	var sourceVol *Volume
-	if sourceVol, err = o.getVol(sourceBucket); err != nil {
-		log.LogErrorf("copyObjectHandler: load source volume fail: vol(%v) requestID(%v) err(%v)",
-			sourceBucket, getRequestIP(r), err)
-		errorCode = NoSuchBucket
+	// the data is copied as is, so neither the source nor the target can be encrypted
+	if fileInfo.Encryption != nil || hasEncryptionHeaders(r) {
+		errorCode = SSENotSupported
 		return
 	}
 
@@ -1009,11 +962,22 @@ func (o *ObjectNode) getBucketV2Handler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// The continuation token is opaque to the clients
+	var marker string
+	if contToken != "" {
+		if marker, err = decodeContinuationToken(contToken); err != nil {
+			log.LogErrorf("getBucketV2Handler: decode continuation token fail: requestID(%v) token(%v) err(%v)",
+				GetRequestID(r), contToken, err)
+			errorCode = InvalidArgument
+			return
+		}
+	}
+
 	var option = &ListFilesV2Option{
 		Delimiter:  delimiter,
 		MaxKeys:    maxKeysInt,
 		Prefix:     prefix,
-		ContToken:  contToken,
+		ContToken:  marker,
 		FetchOwner: fetchOwnerBool,
 		StartAfter: startAfter,
 	}
@@ -1056,19 +1020,25 @@ func (o *ObjectNode) getBucketV2Handler(w http.ResponseWriter, r *http.Request)
 	var commonPrefixes = make([]*CommonPrefix, 0)
 	for _, prefix := range result.CommonPrefixes {
 		commonPrefix := &CommonPrefix{
-			Prefix: prefix,
+			Prefix: encodeKey(prefix, encodingType),
 		}
 		commonPrefixes = append(commonPrefixes, commonPrefix)
 	}
 
+	var nextToken string
+	if result.NextToken != "" {
+		nextToken = encodeContinuationToken(result.NextToken)
+	}
 	listBucketResult := ListBucketResultV2{
 		Name:           param.Bucket(),
-		Prefix:         prefix,
+		Prefix:         encodeKey(prefix, encodingType),
 		Token:          contToken,
-		NextToken:      result.NextToken,
+		NextToken:      nextToken,
+		StartAfter:     encodeKey(startAfter, encodingType),
 		KeyCount:       result.KeyCount,
 		MaxKeys:        maxKeysInt,
-		Delimiter:      delimiter,
+		Delimiter:      encodeKey(delimiter, encodingType),
+		EncodingType:   encodingType,
 		IsTruncated:    result.Truncated,
 		Contents:       contents,
 		CommonPrefixes: commonPrefixes,
@@ -1091,6 +1061,19 @@ func (o *ObjectNode) getBucketV2Handler(w http.ResponseWriter, r *http.Request)
 	return
 }
 
+// encodeContinuationToken makes the next marker of ListObjectsV2 an opaque continuation token.
+func encodeContinuationToken(marker string) string {
+	return base64.URLEncoding.EncodeToString([]byte(marker))
+}
+
+func decodeContinuationToken(token string) (string, error) {
+	marker, err := base64.URLEncoding.DecodeString(token)
+	if err != nil || len(marker) == 0 {
+		return "", syscall.EINVAL
+	}
+	return string(marker), nil
+}
+
 // Put object
 // API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObject.html
 func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
@@ -1164,6 +1147,11 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 		errorCode = InvalidCacheArgument
 		return
 	}
+	// Check request header : x-amz-server-side-encryption and SSE-C headers
+	var encryption *ObjectEncryption
+	if encryption, errorCode = o.newObjectEncryption(r); errorCode != nil {
+		return
+	}
 
 	// Audit file write
 	log.LogInfof("Audit: put object: requestID(%v) remote(%v) volume(%v) path(%v) type(%v)",
@@ -1177,6 +1165,7 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 		Metadata:     metadata,
 		CacheControl: cacheControl,
 		Expires:      expires,
+		Encryption:   encryption,
 	}
 	fsFileInfo, err = vol.PutObject(param.Object(), r.Body, opt)
 	if err == syscall.EINVAL {
@@ -1220,6 +1209,8 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 	// set response header
 	w.Header()[HeaderNameETag] = []string{wrapUnescapedQuot(fsFileInfo.ETag)}
 	w.Header()[HeaderNameContentLength] = []string{"0"}
+	serveVersionHeaders(w, fsFileInfo.VersionId, false)
+	serveEncryptionHeaders(w, fsFileInfo.Encryption)
 	return
 }
 
@@ -1262,7 +1253,14 @@ func (o *ObjectNode) deleteObjectHandler(w http.ResponseWriter, r *http.Request)
 	log.LogInfof("Audit: delete object: requestID(%v) remote(%v) volume(%v) path(%v)",
 		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object())
 
-	err = vol.DeletePath(param.Object())
+	var versionId = r.URL.Query().Get(ParamVersionId)
+	if versionId != "" || vol.versioningStatus() != "" {
+		var deleteMarker bool
+		versionId, deleteMarker, err = vol.DeleteVersion(param.Object(), versionId)
+		serveVersionHeaders(w, versionId, deleteMarker)
+	} else {
+		err = vol.DeletePath(param.Object())
+	}
 	if err != nil {
 		log.LogErrorf("deleteObjectHandler: Volume delete file fail: "+
 			"requestID(%v) volume(%v) path(%v) err(%v)", GetRequestID(r), vol.Name(), param.Object(), err)
@@ -1331,6 +1329,22 @@ func (o *ObjectNode) getObjectTaggingHandler(w http.ResponseWriter, r *http.Requ
 	return
 }
 
+// getObjectTaggingCount returns the number of tags of the object for the x-amz-tagging-count header.
+func getObjectTaggingCount(vol *Volume, path string) (count int, err error) {
+	var xattrInfo *proto.XAttrInfo
+	if xattrInfo, err = vol.GetXAttr(path, XAttrKeyOSSTagging); err != nil {
+		if err == syscall.ENOENT {
+			return 0, nil
+		}
+		return
+	}
+	output, _ := ParseTagging(string(xattrInfo.Get(XAttrKeyOSSTagging)))
+	if output != nil {
+		count = len(output.TagSet)
+	}
+	return
+}
+
 // Put object tagging
 // API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectTagging.html
 func (o *ObjectNode) putObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
@@ -1427,6 +1441,10 @@ func (o *ObjectNode) deleteObjectTaggingHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 	if err = vol.DeleteXAttr(param.object, XAttrKeyOSSTagging); err != nil {
+		if err == syscall.ENOENT {
+			errorCode = NoSuchKey
+			return
+		}
 		log.LogErrorf("deleteObjectTaggingHandler: volume delete tagging fail: requestID(%v) volume(%v) object(%v) err(%v)",
 			GetRequestID(r), param.Bucket(), param.Object(), err)
 		errorCode = InternalErrorCode(err)
@@ -0,0 +1,61 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestParseCopySourceRange(t *testing.T) {
+	type expect struct {
+		offset uint64
+		size   uint64
+		err    error
+	}
+	var samples = map[string]expect{
+		"bytes=0-99":   {offset: 0, size: 100},
+		"bytes=10-10":  {offset: 10, size: 1},
+		"bytes=50-199": {offset: 50, size: 150},
+		"bytes=0-200":  {err: syscall.ERANGE},
+		"bytes=20-10":  {err: syscall.ERANGE},
+		"bytes=10-":    {err: syscall.EINVAL},
+		"bytes=-10":    {err: syscall.EINVAL},
+		"0-99":         {err: syscall.EINVAL},
+	}
+	for value, e := range samples {
+		offset, size, err := parseCopySourceRange(value, 200)
+		if err != e.err {
+			t.Fatalf("range(%v) error mismatch: expect(%v) actual(%v)", value, e.err, err)
+		}
+		if err == nil && (offset != e.offset || size != e.size) {
+			t.Fatalf("range(%v) result mismatch: expect(%v,%v) actual(%v,%v)", value, e.offset, e.size, offset, size)
+		}
+	}
+}
+
+func TestContinuationToken(t *testing.T) {
+	for _, marker := range []string{"a", "dir/sub/", "中文/key?x=1"} {
+		token := encodeContinuationToken(marker)
+		if decoded, err := decodeContinuationToken(token); err != nil || decoded != marker {
+			t.Fatalf("continuation token of marker(%v): decoded(%v) err(%v)", marker, decoded, err)
+		}
+	}
+	for _, token := range []string{"", "not base64!"} {
+		if _, err := decodeContinuationToken(token); err != syscall.EINVAL {
+			t.Fatalf("invalid continuation token(%v): err(%v)", token, err)
+		}
+	}
+}
@@ -174,10 +174,29 @@ func (o *ObjectNode) authMiddleware(next http.Handler) http.Handler {
 					_ = NoSuchBucket.ServeResponse(w, r)
 					return
 				}
+				if err == ErrRequestExpired {
+					_ = ExpiredRequest.ServeResponse(w, r)
+					return
+				}
+				if err == ErrExpiredToken {
+					_ = ExpiredToken.ServeResponse(w, r)
+					return
+				}
 				_ = InternalErrorCode(err).ServeResponse(w, r)
 				return
 			}
 
+			if pass {
+				// The requests signed by the temporary credentials must carry the session token.
+				if pass, err = o.checkSessionToken(r); err == ErrExpiredToken {
+					_ = ExpiredToken.ServeResponse(w, r)
+					return
+				} else if err != nil {
+					_ = InternalErrorCode(err).ServeResponse(w, r)
+					return
+				}
+			}
+
 			if !pass {
 				_ = AccessDenied.ServeResponse(w, r)
 				return
@@ -30,6 +30,9 @@ const (
 type RequestAuthInfo struct {
 	authType  AuthType
 	accessKey string
+	// temporaryAccessKey is the access key of the temporary credential used to sign the
+	// request, in which case accessKey is the access key of the parent user.
+	temporaryAccessKey string
 }
 
 func parseRequestAuthInfo(r *http.Request) *RequestAuthInfo {
@@ -59,6 +62,10 @@ func parseRequestAuthInfo(r *http.Request) *RequestAuthInfo {
 			auth.accessKey = ai.Credential.AccessKey
 		}
 	}
+	if parentAccessKey, _, is := parseTemporaryAccessKey(auth.accessKey); is {
+		auth.temporaryAccessKey = auth.accessKey
+		auth.accessKey = parentAccessKey
+	}
 
 	return auth
 }
@@ -33,10 +33,13 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// ErrRequestExpired is returned when the presigned URL is out of its expiration time.
+var ErrRequestExpired = errors.New("request has expired")
+
 const (
-	SignatureExpires    = time.Hour * 24 * 7     // Signature is valid for seven days after the specified date.
-	MaxPresignedExpires = 3 * 365 * 24 * 60 * 60 //10years
-	DateFormatISO8601   = "20060102T150405Z"     //"yyyyMMddTHHmmssZ"
+	SignatureExpires    = time.Hour * 24 * 7 // Signature is valid for seven days after the specified date.
+	MaxPresignedExpires = 7 * 24 * 60 * 60   // Presigned URL is valid for at most seven days.
+	DateFormatISO8601   = "20060102T150405Z" //"yyyyMMddTHHmmssZ"
 	MaxSkewTime         = 15 * time.Minute
 
 	XAmzContentSha256 = "X-Amz-Content-Sha256"
@@ -168,6 +171,9 @@ func (o *ObjectNode) validateUrlBySignatureAlgorithmV4(r *http.Request) (pass bo
 	var ok bool
 	if ok, err = req.isValid(); !ok {
 		log.LogErrorf("validateUrlBySignatureAlgorithmV4: request invalid: requestID(%v) err(%v)", GetRequestID(r), err)
+		if err == ErrRequestExpired {
+			return false, err
+		}
 		return false, nil
 	}
 
@@ -284,16 +290,22 @@ func (req *signatureRequestV4) isValid() (bool, error) {
 	if expires.Seconds() > MaxPresignedExpires {
 		return false, errors.New("expires > MaxPresignedExpires ")
 	}
+	if req.Algorithm != SignatureV4Algorithm {
+		return false, errors.New("algorithm is invalid ")
+	}
 	utcNow := time.Now().UTC()
 	ts, err1 := req.GetTimestamp()
 	if err1 != nil {
 		return false, errors.New("expires is invalid ")
 	}
+	if ts.Format("20060102") != req.Credential.Date {
+		return false, errors.New("credential date not match req date ")
+	}
 	if ts.After(utcNow.Add(MaxSkewTime)) {
 		return false, errors.New("req date invalid ")
 	}
 	if utcNow.Sub(ts) > expires {
-		return false, errors.New("expires time out ")
+		return false, ErrRequestExpired
 	}
 
 	return true, nil
@@ -425,36 +437,17 @@ func parseRequestV4(r *http.Request) (req *signatureRequestV4, err error) {
 	return
 }
 
-// create canonical query not contain X-Amz-Signature query
+// create canonical query not contain X-Amz-Signature query.
+// All the other query parameters are signed, and the space is encoded as %20 rather than '+'.
 func createCanonicalQueryV4(req *signatureRequestV4) string {
 	newQuery := make(url.Values)
-	newQuery.Set(XAmzAlgorithm, req.Algorithm)
-	newQuery.Set(XAmzExpires, req.Expires)
-	newQuery.Set(XAmzDate, req.Timestamp)
-	newQuery.Set(XAmzSignedHeaders, req.Query().Get(XAmzSignedHeaders))
-	newQuery.Set(XAmzCredential, req.Query().Get(XAmzCredential))
-	hashContent := req.Query().Get(XAmzContentSha256)
-	if hashContent != "" {
-		newQuery.Set(XAmzContentSha256, hashContent)
-	} else {
-	}
-
 	for k, v := range req.Query() {
-		key := strings.ToLower(k)
-		if strings.Contains(key, "x-amz-meta-") {
-			newQuery.Set(k, v[0])
-			continue
-		}
-		if strings.Contains(key, "x-amz-server-side-") {
-			newQuery.Set(k, v[0])
-		}
-		if strings.HasPrefix(key, "x-amz") {
+		if k == XAmzSignature {
 			continue
 		}
 		newQuery[k] = v
 	}
-
-	return newQuery.Encode()
+	return strings.ReplaceAll(newQuery.Encode(), "+", "%20")
 }
 
 func buildSigningKey(scheme, secret, date, region, service, terminator string) []byte {
@@ -488,13 +481,21 @@ func calculateSignatureV4(r *http.Request, cred credential, secretKey string, si
 	canonicalHeaderString := buildCanonicalHeaderString(r.Host, headers, signedHeaders)
 	headerNames := getCanonicalHeaderNames(signedHeaders)
 	contentHash := getContentHash(headers)
+	service := SERVICE
+	if cred.Service == STSServiceName {
+		// The STS clients sign the form in body without the content hash header.
+		service = STSServiceName
+		if contentHash == "" {
+			contentHash = hashSTSRequestBody(r)
+		}
+	}
 	encodeQuery := getEncodeQuery(r)
 	canonicalURI := getCanonicalURI(r)
 	canonicalRequest := createCanonicalRequestString(
 		r.Method, canonicalURI, encodeQuery, canonicalHeaderString, headerNames, contentHash)
 
-	signingKey := buildSigningKey(SCHEME, secretKey, cred.Date, cred.Region, SERVICE, TERMINATOR)
-	scope := buildScope(cred.Date, cred.Region, SERVICE, TERMINATOR)
+	signingKey := buildSigningKey(SCHEME, secretKey, cred.Date, cred.Region, service, TERMINATOR)
+	scope := buildScope(cred.Date, cred.Region, service, TERMINATOR)
 
 	var timestamp = getStartTime(headers)
 	stringToSign := buildStringToSign(SignatureV4Algorithm, timestamp, scope, canonicalRequest)
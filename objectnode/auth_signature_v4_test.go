@@ -0,0 +1,66 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newPresignedRequestV4(t *testing.T, date time.Time, expires string) *signatureRequestV4 {
+	var timestamp = date.UTC().Format(DateFormatISO8601)
+	var target = "/bucket/a.txt?X-Amz-Algorithm=AWS4-HMAC-SHA256" +
+		"&X-Amz-Credential=AK%2F" + date.UTC().Format("20060102") + "%2Fus-east-1%2Fs3%2Faws4_request" +
+		"&X-Amz-Date=" + timestamp + "&X-Amz-Expires=" + expires + "&X-Amz-SignedHeaders=host" +
+		"&X-Amz-Security-Token=token&response-content-disposition=attachment%3B%20filename%3Da.txt" +
+		"&X-Amz-Signature=abc"
+	var req, err = parseRequestV4(httptest.NewRequest("GET", target, nil))
+	if err != nil {
+		t.Fatalf("parse request fail: err(%v)", err)
+	}
+	return req
+}
+
+func TestPresignedRequestV4Expires(t *testing.T) {
+	if ok, err := newPresignedRequestV4(t, time.Now(), "3600").isValid(); !ok {
+		t.Fatalf("valid request rejected: err(%v)", err)
+	}
+	if _, err := newPresignedRequestV4(t, time.Now().Add(-2*time.Hour), "3600").isValid(); err != ErrRequestExpired {
+		t.Fatalf("expired request not detected: err(%v)", err)
+	}
+	if ok, _ := newPresignedRequestV4(t, time.Now(), "604801").isValid(); ok {
+		t.Fatalf("expires over seven days accepted")
+	}
+	var req = newPresignedRequestV4(t, time.Now(), "3600")
+	req.Credential.Date = "20000101"
+	if ok, _ := req.isValid(); ok {
+		t.Fatalf("credential date mismatch accepted")
+	}
+}
+
+func TestCreateCanonicalQueryV4(t *testing.T) {
+	var query = createCanonicalQueryV4(newPresignedRequestV4(t, time.Now(), "3600"))
+	if strings.Contains(query, XAmzSignature) {
+		t.Fatalf("signature in canonical query: %v", query)
+	}
+	if !strings.Contains(query, "X-Amz-Security-Token=token") {
+		t.Fatalf("x-amz parameter missing in canonical query: %v", query)
+	}
+	if !strings.Contains(query, "attachment%3B%20filename%3Da.txt") {
+		t.Fatalf("space not encoded as %%20 in canonical query: %v", query)
+	}
+}
@@ -14,7 +14,11 @@
 
 package objectnode
 
-import "os"
+import (
+	"os"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
 
 const (
 	MaxRetry = 3
@@ -59,18 +63,29 @@ const (
 	HeaderNameXAmzCopyNoneMatch       = "x-amz-copy-source-if-none-match"
 	HeaderNameXAmzCopyModified        = "x-amz-copy-source-if-modified-since"
 	HeaderNameXAmzCopyUnModified      = "x-amz-copy-source-if-unmodified-since"
+	HeaderNameXAmzCopySourceRange     = "x-amz-copy-source-range"
 	HeaderNameXAmzDecodeContentLength = "x-amz-decoded-content-length"
 	HeaderNameXAmzTagging             = "x-amz-tagging"
+	HeaderNameXAmzACL                 = "x-amz-acl"
 	HeaderNameXAmzMetaPrefix          = "x-amz-meta-"
 	HeaderNameXAmzDownloadPartCount   = "x-amz-mp-parts-count"
 	HeaderNameXAmzMetadataDirective   = "x-amz-metadata-directive"
 	HeaderNameXAmzBucketRegion        = "x-amz-bucket-region"
 	HeaderNameXAmzTaggingCount        = "x-amz-tagging-count"
+	HeaderNameXAmzVersionId           = "x-amz-version-id"
+	HeaderNameXAmzDeleteMarker        = "x-amz-delete-marker"
+	HeaderNameXAmzSecurityToken       = "X-Amz-Security-Token"
+
+	HeaderNameXAmzServerSideEncryption = "x-amz-server-side-encryption"
+	HeaderNameXAmzSSECustomerAlgorithm = "x-amz-server-side-encryption-customer-algorithm"
+	HeaderNameXAmzSSECustomerKey       = "x-amz-server-side-encryption-customer-key"
+	HeaderNameXAmzSSECustomerKeyMD5    = "x-amz-server-side-encryption-customer-key-MD5"
 
 	HeaderNameIfMatch           = "If-Match"
 	HeaderNameIfNoneMatch       = "If-None-Match"
 	HeaderNameIfModifiedSince   = "If-Modified-Since"
 	HeaderNameIfUnmodifiedSince = "If-Unmodified-Since"
+	HeaderNameIfRange           = "If-Range"
 )
 
 const (
@@ -78,7 +93,9 @@ const (
 	HeaderValueAcceptRange          = "bytes"
 	HeaderValueTypeStream           = "application/octet-stream"
 	HeaderValueContentTypeXML       = "application/xml"
+	HeaderValueContentTypeJSON      = "application/json"
 	HeaderValueContentTypeDirectory = "application/directory"
+	HeaderValueSSEAlgorithmAES256   = "AES256"
 )
 
 const (
@@ -97,6 +114,9 @@ const (
 	ParamMaxKeys    = "max-keys"
 	ParamStartAfter = "start-after"
 	ParamKey        = "key"
+	ParamVersionId  = "versionId"
+
+	ParamVersionIdMarker = "version-id-marker"
 
 	ParamMaxParts       = "max-parts"
 	ParamUploadIdMarker = "upload-id-marker"
@@ -126,12 +146,28 @@ const (
 	XAttrKeyOSSETag         = "oss:etag"
 	XAttrKeyOSSTagging      = "oss:tagging"
 	XAttrKeyOSSPolicy       = "oss:policy"
-	XAttrKeyOSSACL          = "oss:acl"
+	XAttrKeyOSSACL          = proto.XAttrKeyOSSACL
 	XAttrKeyOSSMIME         = "oss:mime"
 	XAttrKeyOSSDISPOSITION  = "oss:disposition"
 	XAttrKeyOSSCORS         = "oss:cors"
 	XAttrKeyOSSCacheControl = "oss:cache"
 	XAttrKeyOSSExpires      = "oss:expires"
+	XAttrKeyOSSVersioning   = "oss:versioning"
+	XAttrKeyOSSVersionId    = "oss:version"
+	XAttrKeyOSSLifecycle    = "oss:lifecycle"
+	XAttrKeyOSSNotification = "oss:notification"
+	XAttrKeyOSSEncryption   = "oss:encryption"
+
+	// The state of the publishing of the notifications, see notificationWorker.
+	XAttrKeyOSSNotificationState = "oss:notification-state"
+
+	// Prefix of the keys pointing to the version chains, which are stored on the parent directory
+	// of the objects and followed by the object names, see versionChainXAttrKey.
+	XAttrKeyOSSVersionsPrefix = "oss:versions:"
+
+	// Prefix of the keys of the noncurrent versions, which are stored on the chain inode
+	// of the object, see versionXAttrKey.
+	XAttrKeyOSSVersionEntryPrefix = "oss:version-entry:"
 
 	// Deprecated
 	XAttrKeyOSSETagDeprecated = "oss:tag"
@@ -32,7 +32,21 @@ type FSFileInfo struct {
 	Disposition  string
 	CacheControl string
 	Expires      string
+	VersionId    string
 	Metadata   map[string]string `graphql:"-"` // User-defined metadata
+	Encryption *ObjectEncryption `graphql:"-"` // Server side encryption, nil if not encrypted
+}
+
+// FSVersionInfo is a version of an object, or a delete marker.
+type FSVersionInfo struct {
+	Key          string
+	VersionId    string
+	IsLatest     bool
+	DeleteMarker bool
+	Inode        uint64
+	Size         int64
+	ETag         string
+	ModifyTime   time.Time
 }
 
 type Prefixes []string
@@ -41,6 +41,7 @@ type VolumeLoader struct {
 	closeOnce  sync.Once
 	closeCh    chan struct{}
 	metaStrict bool
+	notifier   *notifier
 }
 
 func (loader *VolumeLoader) blacklistCleanup() {
@@ -136,6 +137,7 @@ func (loader *VolumeLoader) loadVolume(volName string) (*Volume, error) {
 			Store:            loader.store,
 			OnAsyncTaskError: onAsyncTaskError,
 			MetaStrict:       loader.metaStrict,
+			Notifier:         loader.notifier,
 		}
 		if volume, err = NewVolume(config); err != nil {
 			if err != proto.ErrVolNotExists {
@@ -173,13 +175,14 @@ func (loader *VolumeLoader) Close() {
 	})
 }
 
-func NewVolumeLoader(masters []string, store Store, strict bool) *VolumeLoader {
+func NewVolumeLoader(masters []string, store Store, strict bool, notifier *notifier) *VolumeLoader {
 	loader := &VolumeLoader{
 		masters:    masters,
 		store:      store,
 		volumes:    make(map[string]*Volume),
 		closeCh:    make(chan struct{}),
 		metaStrict: strict,
+		notifier:   notifier,
 	}
 	go loader.blacklistCleanup()
 	return loader
@@ -191,6 +194,7 @@ type VolumeManager struct {
 	loaders    [volumeLoaderNum]*VolumeLoader
 	store      Store
 	metaStrict bool
+	notifier   *notifier
 	closeOnce  sync.Once
 	closeCh    chan struct{}
 }
@@ -222,15 +226,16 @@ func (m *VolumeManager) init() {
 		vm: m,
 	}
 	for i := 0; i < len(m.loaders); i++ {
-		m.loaders[i] = NewVolumeLoader(m.masters, m.store, m.metaStrict)
+		m.loaders[i] = NewVolumeLoader(m.masters, m.store, m.metaStrict, m.notifier)
 	}
 }
 
-func NewVolumeManager(masters []string, strict bool) *VolumeManager {
+func NewVolumeManager(masters []string, strict bool, notifier *notifier) *VolumeManager {
 	manager := &VolumeManager{
 		masters:    masters,
 		closeCh:    make(chan struct{}),
 		metaStrict: strict,
+		notifier:   notifier,
 	}
 	manager.init()
 	return manager
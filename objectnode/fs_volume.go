@@ -76,6 +76,9 @@ type VolumeConfig struct {
 
 	// Get OSSMeta from the MetaNode every time if it is set true.
 	MetaStrict bool
+
+	// Publishes the notifications of the bucket if it is set.
+	Notifier *notifier
 }
 
 type PutFileOption struct {
@@ -85,6 +88,7 @@ type PutFileOption struct {
 	Metadata     map[string]string
 	CacheControl string
 	Expires      string
+	Encryption   *ObjectEncryption
 }
 
 type ListFilesV1Option struct {
@@ -118,6 +122,20 @@ type ListFilesV2Result struct {
 	CommonPrefixes []string
 }
 
+type ListFileVersionsOption struct {
+	Prefix          string
+	KeyMarker       string
+	VersionIdMarker string
+	MaxKeys         uint64
+}
+
+type ListFileVersionsResult struct {
+	Versions            []*FSVersionInfo
+	NextKeyMarker       string
+	NextVersionIdMarker string
+	Truncated           bool
+}
+
 // Volume is a high-level encapsulation of meta sdk and data sdk methods.
 // A high-level approach that exposes the semantics of object storage to the outside world.
 // Volume escapes high-level object storage semantics to low-level POSIX semantics.
@@ -129,10 +147,13 @@ type Volume struct {
 	metaLoader ossMetaLoader
 	ticker     *time.Ticker
 	createTime int64
+	flat       bool // the objects are the dentries in the root directory named by their keys
 
 	closeOnce sync.Once
 	closeCh   chan struct{}
 
+	notifier *notifier
+
 	onAsyncTaskError AsyncTaskErrorFunc
 }
 
@@ -174,6 +195,12 @@ func (v *Volume) loadOSSMeta() {
 		return
 	}
 	v.metaLoader.storeCors(cors)
+
+	var versioning *VersioningConfiguration
+	if versioning, err = v.loadBucketVersioning(); err != nil {
+		return
+	}
+	v.metaLoader.storeVersioning(versioning)
 }
 
 func (v *Volume) Name() string {
@@ -221,6 +248,37 @@ func (v *Volume) loadBucketACL() (acp *AccessControlPolicy, err error) {
 	return
 }
 
+// loadObjectACL loads the ACL of the object, and returns nil if the object has no ACL.
+// The ACL is derived from the mode bits of the object if the volume is of the POSIX priority.
+func (v *Volume) loadObjectACL(path string) (acp *AccessControlPolicy, err error) {
+	if v.mw.PermissionPriority() == proto.PermissionPriorityPOSIX {
+		var inode uint64
+		if inode, err = v.getInodeFromPath(path); err != nil {
+			return
+		}
+		var inodeInfo *proto.InodeInfo
+		if inodeInfo, err = v.mw.InodeGet_ll(inode); err != nil {
+			return
+		}
+		owner, _ := v.OSSSecure()
+		acp = newACLFromMode(owner, inodeInfo.Mode)
+		return
+	}
+	var info *proto.XAttrInfo
+	if info, err = v.GetXAttr(path, XAttrKeyOSSACL); err != nil {
+		return
+	}
+	var raw = info.Get(XAttrKeyOSSACL)
+	if len(raw) == 0 {
+		return
+	}
+	acp = &AccessControlPolicy{}
+	if err = xml.Unmarshal(raw, acp); err != nil {
+		return
+	}
+	return
+}
+
 func (v *Volume) loadBucketCors() (configuration *CORSConfiguration, err error) {
 	var raw []byte
 	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSCORS); err != nil {
@@ -236,12 +294,40 @@ func (v *Volume) loadBucketCors() (configuration *CORSConfiguration, err error)
 	return configuration, nil
 }
 
+func (v *Volume) loadBucketVersioning() (configuration *VersioningConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSVersioning); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &VersioningConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+// pathIterator returns the iterator of the path in the namespace of the volume.
+func (v *Volume) pathIterator(path string) PathIterator {
+	return PathIterator{path: path, flat: v.flat}
+}
+
+// splitPath splits the path into the directories and the file name in the namespace of the volume.
+func (v *Volume) splitPath(path string) (dirs []string, filename string) {
+	if v.flat {
+		return nil, path
+	}
+	return splitPath(path)
+}
+
 func (v *Volume) getInodeFromPath(path string) (inode uint64, err error) {
 	if path == "/" {
 		return volumeRootInode, nil
 	}
 
-	dirs, filename := splitPath(path)
+	dirs, filename := v.splitPath(path)
 
 	if len(dirs) == 0 && filename == "" {
 		return volumeRootInode, nil
@@ -277,7 +363,7 @@ func (v *Volume) SetXAttr(path string, key string, data []byte, autoCreate bool)
 		return err
 	}
 	if err == syscall.ENOENT {
-		var dirs, filename = splitPath(path)
+		var dirs, filename = v.splitPath(path)
 		var parentID uint64
 		if parentID, err = v.lookupDirectories(dirs, true); err != nil {
 			return err
@@ -291,6 +377,28 @@ func (v *Volume) SetXAttr(path string, key string, data []byte, autoCreate bool)
 	return v.mw.XAttrSet_ll(inode, []byte(key), data)
 }
 
+// setModeByACL changes the mode bits of the object to those mapped from its ACL.
+func (v *Volume) setModeByACL(path string, acp *AccessControlPolicy) (err error) {
+	var inode uint64
+	if inode, err = v.getInodeFromPath(path); err != nil {
+		return
+	}
+	var info *proto.InodeInfo
+	if info, err = v.mw.InodeGet_ll(inode); err != nil {
+		return
+	}
+	var mode = modeFromACL(acp, info.Mode)
+	if mode == info.Mode {
+		return
+	}
+	if err = v.mw.Setattr(inode, proto.AttrMode, mode, 0, 0, 0, 0); err != nil {
+		log.LogErrorf("setModeByACL: meta set attr fail: volume(%v) path(%v) inode(%v) mode(%v) err(%v)",
+			v.name, path, inode, os.FileMode(mode), err)
+		return
+	}
+	return
+}
+
 func (v *Volume) GetXAttr(path string, key string) (info *proto.XAttrInfo, err error) {
 	var inode uint64
 	inode, err = v.getInodeFromPath(path)
@@ -394,7 +502,7 @@ func (v *Volume) ListFilesV2(opt *ListFilesV2Option) (result *ListFilesV2Result,
 	}
 
 	result.Files = infos
-	result.KeyCount = uint64(len(infos))
+	result.KeyCount = uint64(len(infos) + len(prefixes))
 	if nextMarker != "" {
 		result.Truncated = true
 		result.NextToken = nextMarker
@@ -428,7 +536,7 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		fixedPath = path + pathSep
 	}
 
-	var pathItems = NewPathIterator(fixedPath).ToSlice()
+	var pathItems = v.pathIterator(fixedPath).ToSlice()
 	if len(pathItems) == 0 {
 		// A blank directory entry indicates that the path after the validation is the volume
 		// root directory itself.
@@ -514,7 +622,16 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		md5Hash  = md5.New()
 		md5Value string
 	)
-	if _, err = v.streamWrite(invisibleTempDataInode.Inode, reader, md5Hash); err != nil {
+	if opt != nil && opt.Encryption != nil {
+		// The ETag is the MD5 of the plain text.
+		if reader, err = opt.Encryption.EncryptReader(io.TeeReader(reader, md5Hash)); err != nil {
+			return
+		}
+		_, err = v.streamWrite(invisibleTempDataInode.Inode, reader, nil)
+	} else {
+		_, err = v.streamWrite(invisibleTempDataInode.Inode, reader, md5Hash)
+	}
+	if err != nil {
 		return
 	}
 	// compute file md5
@@ -545,6 +662,14 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 			v.name, path, invisibleTempDataInode.Inode, XAttrKeyOSSETag, md5Value, err)
 		return nil, err
 	}
+	// If the data is encrypted, store the encryption without the plain object key.
+	if opt != nil && opt.Encryption != nil {
+		if err = v.mw.XAttrSet_ll(invisibleTempDataInode.Inode, []byte(XAttrKeyOSSEncryption), opt.Encryption.Encode()); err != nil {
+			log.LogErrorf("PutObject: store encryption fail: volume(%v) path(%v) inode(%v) type(%v) err(%v)",
+				v.name, path, invisibleTempDataInode.Inode, opt.Encryption.Type, err)
+			return nil, err
+		}
+	}
 	// If MIME information is valid, use extended attributes for storage.
 	if opt != nil && opt.MIMEType != "" {
 		if err = v.mw.XAttrSet_ll(invisibleTempDataInode.Inode, []byte(XAttrKeyOSSMIME), []byte(opt.MIMEType)); err != nil {
@@ -610,9 +735,12 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		ETag:       etagValue.ETag(),
 		Inode:      finalInode.Inode,
 	}
+	if opt != nil {
+		fsInfo.Encryption = opt.Encryption
+	}
 
 	// apply new inode to dentry
-	err = v.applyInodeToDEntry(parentId, lastPathItem.Name, invisibleTempDataInode.Inode)
+	fsInfo.VersionId, err = v.applyInodeToDEntry(parentId, lastPathItem.Name, invisibleTempDataInode.Inode)
 	if err != nil {
 		log.LogErrorf("PutObject: apply new inode to dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
 			parentId, lastPathItem.Name, invisibleTempDataInode.Inode, err)
@@ -621,7 +749,10 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 	return fsInfo, nil
 }
 
-func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64) (err error) {
+func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64) (versionId string, err error) {
+	if versionId, err = v.assignVersionId(inode); err != nil {
+		return
+	}
 	var existMode uint32
 	_, existMode, err = v.mw.Lookup_ll(parentId, name)
 	if err != nil && err != syscall.ENOENT {
@@ -650,6 +781,9 @@ func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64)
 			return
 		}
 	}
+	if versionId == NullVersionId {
+		v.discardNullVersion(parentId, name)
+	}
 	return
 }
 
@@ -690,6 +824,10 @@ func (v *Volume) DeletePath(path string) (err error) {
 		if err != nil || len(dentries) > 0 {
 			return
 		}
+		// The directory still holds the noncurrent versions of the deleted objects.
+		if v.hasVersions(ino) {
+			return
+		}
 	}
 	log.LogWarnf("DeletePath: delete: volume(%v) path(%v) inode(%v)", v.name, path, ino)
 	if _, err = v.mw.Delete_ll(parent, name, mode.IsDir()); err != nil {
@@ -761,7 +899,7 @@ func (v *Volume) WritePart(path string, multipartId string, partId uint16, reade
 	}()
 
 	var fInfo *FSFileInfo
-	_, fileName := splitPath(path)
+	_, fileName := v.splitPath(path)
 
 	// create temp file (inode only, invisible for user)
 	var tempInodeInfo *proto.InodeInfo
@@ -951,7 +1089,7 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 	}
 
 	var (
-		pathItems = NewPathIterator(path).ToSlice()
+		pathItems = v.pathIterator(path).ToSlice()
 		filename  = pathItems[len(pathItems)-1].Name
 		parentId  uint64
 	)
@@ -1026,7 +1164,7 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 	}
 
 	// apply new inode to dentry
-	err = v.applyInodeToDEntry(parentId, filename, completeInodeInfo.Inode)
+	fInfo.VersionId, err = v.applyInodeToDEntry(parentId, filename, completeInodeInfo.Inode)
 	if err != nil {
 		log.LogErrorf("CompleteMultipart: apply new inode to dentry fail, parent id (%v), file name(%v), inode(%v)",
 			parentId, filename, completeInodeInfo.Inode)
@@ -1135,6 +1273,16 @@ func (v *Volume) applyInodeToExistDentry(parentID uint64, name string, inode uin
 		return
 	}
 
+	// keep old inode as a noncurrent version if the versioning is enabled
+	var archived bool
+	if archived, err = v.archiveVersion(parentID, name, oldInode); err != nil {
+		log.LogErrorf("applyInodeToExistDentry: archive version fail: volume(%v) parentID(%v) name(%v) inode(%v) err(%v)",
+			v.name, parentID, name, oldInode, err)
+	}
+	if archived {
+		return
+	}
+
 	// unlink and evict old inode
 	log.LogWarnf("applyInodeToExistDentry: unlink inode: volume(%v) inode(%v)", v.name, oldInode)
 	if _, err = v.mw.InodeUnlink_ll(oldInode); err != nil {
@@ -1191,7 +1339,10 @@ func (v *Volume) ReadFile(path string, writer io.Writer, offset, size uint64) er
 	if mode.IsDir() {
 		return nil
 	}
+	return v.readInode(path, ino, writer, offset, size)
+}
 
+func (v *Volume) readInode(path string, ino uint64, writer io.Writer, offset, size uint64) (err error) {
 	// read file data
 	var inoInfo *proto.InodeInfo
 	if inoInfo, err = v.mw.InodeGet_ll(ino); err != nil {
@@ -1269,13 +1420,20 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, err error) {
 		}
 		break
 	}
+	return v.inodeMeta(path, inoInfo, mode)
+}
 
+// inodeMeta builds the meta of the object from the inode and its extended attributes.
+func (v *Volume) inodeMeta(path string, inoInfo *proto.InodeInfo, mode os.FileMode) (info *FSFileInfo, err error) {
+	var inode = inoInfo.Inode
 	var (
 		etagValue    ETagValue
 		mimeType     string
 		disposition  string
 		cacheControl string
 		expires      string
+		versionId    string
+		encryption   *ObjectEncryption
 	)
 
 	if mode.IsDir() {
@@ -1289,7 +1447,7 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, err error) {
 		// 2. MIME type
 		var xattrs []*proto.XAttrInfo
 		var xattrKeys = []string{XAttrKeyOSSETag, XAttrKeyOSSETagDeprecated, XAttrKeyOSSMIME, XAttrKeyOSSDISPOSITION,
-			XAttrKeyOSSCacheControl, XAttrKeyOSSExpires}
+			XAttrKeyOSSCacheControl, XAttrKeyOSSExpires, XAttrKeyOSSVersionId, XAttrKeyOSSEncryption}
 		if xattrs, err = v.mw.BatchGetXAttr([]uint64{inode}, xattrKeys); err != nil {
 			log.LogErrorf("ObjectMeta: meta get xattr fail, volume(%v) inode(%v) path(%v) keys(%v) err(%v)",
 				v.name, inode, path, strings.Join(xattrKeys, ","), err)
@@ -1309,6 +1467,17 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, err error) {
 			disposition = string(xattr.Get(XAttrKeyOSSDISPOSITION))
 			cacheControl = string(xattr.Get(XAttrKeyOSSCacheControl))
 			expires = string(xattr.Get(XAttrKeyOSSExpires))
+			versionId = string(xattr.Get(XAttrKeyOSSVersionId))
+			if raw := xattr.Get(XAttrKeyOSSEncryption); len(raw) > 0 {
+				if encryption, err = ParseObjectEncryption(raw); err != nil {
+					log.LogErrorf("ObjectMeta: parse encryption fail: volume(%v) inode(%v) path(%v) err(%v)",
+						v.name, inode, path, err)
+					return
+				}
+			}
+		}
+		if len(versionId) == 0 && v.versioningStatus() != "" {
+			versionId = NullVersionId
 		}
 	}
 
@@ -1343,7 +1512,9 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, err error) {
 		Disposition:  disposition,
 		CacheControl: cacheControl,
 		Expires:      expires,
+		VersionId:    versionId,
 		Metadata:     metadata,
+		Encryption:   encryption,
 	}
 	return
 }
@@ -1366,7 +1537,7 @@ func (v *Volume) Close() error {
 // 		pathname did not exist, or the pathname was an empty string.
 func (v *Volume) recursiveLookupTarget(path string) (parent uint64, ino uint64, name string, mode os.FileMode, err error) {
 	parent = rootIno
-	var pathIterator = NewPathIterator(path)
+	var pathIterator = v.pathIterator(path)
 	if !pathIterator.HasNext() {
 		err = syscall.ENOENT
 		return
@@ -1405,7 +1576,7 @@ func (v *Volume) recursiveLookupTarget(path string) (parent uint64, ino uint64,
 
 func (v *Volume) recursiveMakeDirectory(path string) (ino uint64, err error) {
 	ino = rootIno
-	var pathIterator = NewPathIterator(path)
+	var pathIterator = v.pathIterator(path)
 	if !pathIterator.HasNext() {
 		err = syscall.ENOENT
 		return
@@ -1528,7 +1699,7 @@ func (v *Volume) listFilesV1(prefix, marker, delimiter string, maxKeys uint64) (
 	// return if it reach to max keys
 	var rc uint64
 	// recursion scan
-	infos, prefixMap, nextMarker, _, err = v.recursiveScan(infos, prefixMap, parentId, maxKeys, rc, dirs, prefix, marker, delimiter)
+	infos, prefixMap, nextMarker, _, err = v.recursiveScan(infos, prefixMap, parentId, maxKeys, rc, dirs, prefix, marker, delimiter, true)
 	if err != nil {
 		log.LogErrorf("listFilesV1: volume list dir fail: Volume(%v) err(%v)", v.name, err)
 		return
@@ -1551,12 +1722,11 @@ func (v *Volume) listFilesV1(prefix, marker, delimiter string, maxKeys uint64) (
 func (v *Volume) listFilesV2(prefix, startAfter, contToken, delimiter string, maxKeys uint64) (infos []*FSFileInfo, prefixes Prefixes, nextMarker string, err error) {
 	var prefixMap = PrefixMap(make(map[string]struct{}))
 
-	var marker string
-	if startAfter != "" {
-		marker = startAfter
-	}
+	// The continuation token is the next key to list, while the keys after start-after are listed.
+	var marker = startAfter
+	var inclusive bool
 	if contToken != "" {
-		marker = contToken
+		marker, inclusive = contToken, true
 	}
 	parentId, dirs, err := v.findParentId(prefix)
 
@@ -1579,7 +1749,7 @@ func (v *Volume) listFilesV2(prefix, startAfter, contToken, delimiter string, ma
 	// return if it reach to max keys
 	var rc uint64
 	// recursion scan
-	infos, prefixMap, nextMarker, _, err = v.recursiveScan(infos, prefixMap, parentId, maxKeys, rc, dirs, prefix, marker, delimiter)
+	infos, prefixMap, nextMarker, _, err = v.recursiveScan(infos, prefixMap, parentId, maxKeys, rc, dirs, prefix, marker, delimiter, inclusive)
 	if err != nil {
 		log.LogErrorf("listFilesV2: Volume list dir fail, Volume(%v) err(%v)", v.name, err)
 		return
@@ -1602,6 +1772,10 @@ func (v *Volume) listFilesV2(prefix, startAfter, contToken, delimiter string, ma
 
 func (v *Volume) findParentId(prefix string) (inode uint64, prefixDirs []string, err error) {
 	prefixDirs = make([]string, 0)
+	if v.flat {
+		// all the objects are in the root directory
+		return proto.RootIno, prefixDirs, nil
+	}
 
 	// if prefix and marker are both not empty, use marker
 	var dirs []string
@@ -1651,12 +1825,28 @@ func (v *Volume) findParentId(prefix string) (inode uint64, prefixDirs []string,
 // Recursive scan of the directory starting from the given parentID. Match files and directories
 // that match the prefix and delimiter criteria. Stop when the number of matches reaches a threshold
 // or all files and directories are scanned.
+//
+// The children are read by the name prefix at this level from the meta partition page by page, and the
+// names sharing a common prefix within this level are skipped at once. The marker restricts the child of
+// the same name at each level only, so the scan resumes in the same order, and the marker itself is
+// listed again if inclusive, which is the case of the next marker returned.
 func (v *Volume) recursiveScan(fileInfos []*FSFileInfo, prefixMap PrefixMap, parentId, maxKeys, rc uint64,
-	dirs []string, prefix, marker, delimiter string) ([]*FSFileInfo, PrefixMap, string, uint64, error) {
+	dirs []string, prefix, marker, delimiter string, inclusive bool) ([]*FSFileInfo, PrefixMap, string, uint64, error) {
 	var err error
 	var nextMarker string
 
 	var currentPath = strings.Join(dirs, pathSep) + pathSep
+	var basePath string
+	if len(dirs) > 0 {
+		basePath = currentPath
+	}
+	if marker != "" && !strings.HasPrefix(marker, basePath) {
+		if marker > basePath {
+			// all the paths in this directory are before the marker
+			return fileInfos, prefixMap, "", rc, nil
+		}
+		marker = ""
+	}
 
 	if len(dirs) > 0 && prefix != "" && strings.HasSuffix(currentPath, prefix) {
 		// When the current scanning position is not the root directory, a prefix matching
@@ -1666,9 +1856,9 @@ func (v *Volume) recursiveScan(fileInfos []*FSFileInfo, prefixMap PrefixMap, par
 		// interface, directory entries that meet the exact prefix match will be returned as
 		// a Content result, not as a CommonPrefix.
 
-		// Add check to marker, if request contain marker, current path must greater than marker,
-		// otherwise can not put it to prefix map
-		if (len(marker) > 0 && currentPath > marker) || len(marker) == 0 {
+		// Add check to marker, if request contain marker, current path must be the marker
+		// and the marker must be inclusive, otherwise can not put it to prefix map
+		if len(marker) == 0 || (inclusive && currentPath == marker) {
 			fileInfo := &FSFileInfo{
 				Inode: parentId,
 				Path:  currentPath,
@@ -1685,41 +1875,48 @@ func (v *Volume) recursiveScan(fileInfos []*FSFileInfo, prefixMap PrefixMap, par
 		}
 	}
 
-	// During the process of scanning the child nodes of the current directory, there may be other
-	// parallel operations that may delete the current directory.
-	// If got the syscall.ENOENT error when invoke readdir, it means that the above situation has occurred.
-	// At this time, stops process and returns success.
-	var children []proto.Dentry
-	children, err = v.mw.ReadDir_ll(parentId)
-	if err != nil && err != syscall.ENOENT {
-		return fileInfos, prefixMap, "", 0, err
+	// Only the children having the name prefix at this level can match the prefix.
+	var namePrefix string
+	if strings.HasPrefix(prefix, basePath) {
+		namePrefix = strings.SplitN(prefix[len(basePath):], pathSep, 2)[0]
 	}
-	if err == syscall.ENOENT {
-		return fileInfos, prefixMap, "", 0, nil
+	var markerName, markerRest string
+	var markerDeeper bool
+	if v.flat {
+		// The children of the root are the objects named by their keys, so the prefix and the marker
+		// are compared with the names as they are, and the keys are listed in the lexicographical order.
+		namePrefix, markerName = prefix, marker
+	} else if marker != "" {
+		var parts = strings.SplitN(marker[len(basePath):], pathSep, 2)
+		markerName = parts[0]
+		if len(parts) == 2 {
+			markerDeeper, markerRest = true, parts[1]
+		}
 	}
 
-	for _, child := range children {
-		var path = strings.Join(append(dirs, child.Name), pathSep)
-		if os.FileMode(child.Type).IsDir() {
+	// The names before skipUntil share a common prefix already listed.
+	var skipUntil string
+
+	var visit = func(child proto.Dentry) (stop bool, err error) {
+		var isDir = os.FileMode(child.Type).IsDir()
+		var path = basePath + child.Name
+		if isDir {
 			path += pathSep
 		}
 		if prefix != "" && !strings.HasPrefix(path, prefix) {
-			continue
+			return false, nil
 		}
 
-		if marker != "" {
-			if !os.FileMode(child.Type).IsDir() && path < marker {
-				continue
+		var listed = true
+		var childMarker string
+		var restricted = marker != "" && child.Name == markerName
+		if restricted {
+			if !isDir && (markerDeeper || !inclusive) {
+				return false, nil
 			}
-			if os.FileMode(child.Type).IsDir() && path < marker {
-				fileInfos, prefixMap, nextMarker, rc, err = v.recursiveScan(fileInfos, prefixMap, child.Inode, maxKeys, rc, append(dirs, child.Name), prefix, marker, delimiter)
-				if err != nil {
-					return fileInfos, prefixMap, nextMarker, rc, err
-				}
-				if rc >= maxKeys && nextMarker != "" {
-					return fileInfos, prefixMap, nextMarker, rc, err
-				}
-				continue
+			if isDir && markerDeeper {
+				listed = markerRest == "" && inclusive
+				childMarker = marker
 			}
 		}
 
@@ -1727,37 +1924,105 @@ func (v *Volume) recursiveScan(fileInfos []*FSFileInfo, prefixMap PrefixMap, par
 			var nonPrefixPart = strings.Replace(path, prefix, "", 1)
 			if idx := strings.Index(nonPrefixPart, delimiter); idx >= 0 {
 				var commonPrefix = prefix + util.SubString(nonPrefixPart, 0, idx) + delimiter
+				if len(commonPrefix) <= len(basePath)+len(child.Name) {
+					// Byte 0xFF never appears in UTF-8, so it is after all the names with the common prefix.
+					skipUntil = commonPrefix[len(basePath):] + "\xff"
+				}
 				if prefixMap.contain(commonPrefix) {
-					continue
+					return false, nil
+				}
+				if restricted && (commonPrefix == marker && !inclusive ||
+					commonPrefix < marker && !strings.HasPrefix(marker, commonPrefix)) {
+					return false, nil
 				}
 				if rc >= maxKeys {
-					return fileInfos, prefixMap, commonPrefix, rc, nil
+					nextMarker = commonPrefix
+					return true, nil
 				}
 				prefixMap.AddPrefix(commonPrefix)
 				rc++
-				continue
+				return false, nil
 			}
 		}
 
-		fileInfo := &FSFileInfo{
-			Inode: child.Inode,
-			Path:  path,
-		}
-		if rc >= maxKeys {
-			return fileInfos, prefixMap, path, rc, nil
+		if listed {
+			if rc >= maxKeys {
+				nextMarker = path
+				return true, nil
+			}
+			fileInfos = append(fileInfos, &FSFileInfo{
+				Inode: child.Inode,
+				Path:  path,
+			})
+			rc++
 		}
-		fileInfos = append(fileInfos, fileInfo)
-		rc++
 
-		if os.FileMode(child.Type).IsDir() {
-			fileInfos, prefixMap, nextMarker, rc, err = v.recursiveScan(fileInfos, prefixMap, child.Inode, maxKeys, rc, append(dirs, child.Name), prefix, marker, delimiter)
+		if isDir {
+			var childDirs = append(append(make([]string, 0, len(dirs)+1), dirs...), child.Name)
+			fileInfos, prefixMap, nextMarker, rc, err = v.recursiveScan(fileInfos, prefixMap, child.Inode, maxKeys, rc, childDirs, prefix, childMarker, delimiter, inclusive)
 			if err != nil {
-				return fileInfos, prefixMap, nextMarker, rc, err
+				return true, err
 			}
 			if rc >= maxKeys && nextMarker != "" {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	// The child of the marker name is looked up first since the meta partition reads the children after the marker.
+	var readMarker string
+	if markerName != "" && strings.HasPrefix(markerName, namePrefix) {
+		var inode uint64
+		var mode uint32
+		inode, mode, err = v.mw.Lookup_ll(parentId, markerName)
+		if err != nil && err != syscall.ENOENT {
+			return fileInfos, prefixMap, "", 0, err
+		}
+		if err == nil {
+			var stop bool
+			if stop, err = visit(proto.Dentry{Name: markerName, Inode: inode, Type: mode}); stop || err != nil {
+				return fileInfos, prefixMap, nextMarker, rc, err
+			}
+		}
+	}
+	if markerName > readMarker {
+		readMarker = markerName
+	}
+
+	// During the process of scanning the child nodes of the current directory, there may be other
+	// parallel operations that may delete the current directory.
+	// If got the syscall.ENOENT error when invoke readdir, it means that the above situation has occurred.
+	// At this time, stops process and returns success.
+	for first := true; ; first = false {
+		if skipUntil > readMarker {
+			readMarker = skipUntil
+		}
+		var children []proto.Dentry
+		children, err = v.mw.ReadDirPrefixLimit_ll(parentId, namePrefix, readMarker, meta.ReadDirLimit)
+		if err != nil && err != syscall.ENOENT {
+			return fileInfos, prefixMap, "", 0, err
+		}
+		if err == syscall.ENOENT {
+			return fileInfos, prefixMap, "", 0, nil
+		}
+		// the meta nodes not supporting the pagination reply all the dentries at once
+		if !first && len(children) > 0 && children[0].Name <= readMarker {
+			break
+		}
+		for _, child := range children {
+			if child.Name <= readMarker || child.Name < skipUntil || !strings.HasPrefix(child.Name, namePrefix) {
+				continue
+			}
+			var stop bool
+			if stop, err = visit(child); stop || err != nil {
 				return fileInfos, prefixMap, nextMarker, rc, err
 			}
 		}
+		if uint64(len(children)) < meta.ReadDirLimit {
+			break
+		}
+		readMarker = children[len(children)-1].Name
 	}
 	return fileInfos, prefixMap, nextMarker, rc, nil
 }
@@ -2089,7 +2354,7 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			v.name, targetPath, err)
 		return
 	}
-	pathItems = NewPathIterator(targetPath).ToSlice()
+	pathItems = v.pathIterator(targetPath).ToSlice()
 	if len(pathItems) <= 0 {
 		log.LogErrorf("CopyFile: get target path pathItems is empty: volume(%v) path(%v) err(%v)",
 			v.name, targetPath, err)
@@ -2097,9 +2362,22 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 	}
 	tLastName = pathItems[len(pathItems)-1].Name
 
-	// create target file inode and set target inode to be source file inode
-	if tInodeInfo, err = v.mw.InodeCreate_ll(uint32(sMode), 0, 0, nil); err != nil {
-		return
+	// clone the target file sharing the extents of the source file if its ETag is up to date,
+	// otherwise create the target file inode and copy the data
+	var md5Value string
+	if sv == v {
+		if md5Value = v.cloneableETag(sInodeInfo); md5Value != "" {
+			if tInodeInfo, err = v.mw.InodeClone_ll(sInode, 0, 0); err != nil {
+				log.LogWarnf("CopyFile: clone source path fail, copy the data instead: volume(%v) source path(%v) inode(%v) err(%v)",
+					v.name, sourcePath, sInode, err)
+				md5Value = ""
+			}
+		}
+	}
+	if md5Value == "" {
+		if tInodeInfo, err = v.mw.InodeCreate_ll(uint32(sMode), 0, 0, nil); err != nil {
+			return
+		}
 	}
 	defer func() {
 		// An error has caused the entire process to fail. Delete the inode and release the written data.
@@ -2122,55 +2400,11 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		}
 	}()
 
-	// write data to invisibleTempDataInode from source object
-	var (
-		fileSize    = sInodeInfo.Size
-		md5Hash     = md5.New()
-		md5Value    string
-		readN       int
-		writeN      int
-		readOffset  int
-		writeOffset int
-		readSize    int
-		buf         = make([]byte, 2*util.BlockSize)
-		hashBuf     = make([]byte, 2*util.BlockSize)
-	)
-	for {
-		readSize = len(buf)
-		if (int(fileSize) - readOffset) <= 0 {
-			break
-		}
-		if (int(fileSize) - readOffset) < len(buf) {
-			readSize = int(fileSize) - readOffset
-		}
-		readN, err = sv.ec.Read(sInode, buf, readOffset, readSize)
-		if err != nil && err != io.EOF {
+	if md5Value == "" {
+		if md5Value, err = v.copyFileData(sv, sInode, sInodeInfo.Size, tInodeInfo.Inode, targetPath); err != nil {
 			return
 		}
-		if readN > 0 {
-			if writeN, err = v.ec.Write(tInodeInfo.Inode, writeOffset, buf[:readN], 0); err != nil {
-				log.LogErrorf("CopyFile: write target path from source fail, volume(%v) path(%v) inode(%v) target offset(%v) err(%v)",
-					v.name, targetPath, tInodeInfo.Inode, writeOffset, err)
-				return
-			}
-			readOffset += readN
-			writeOffset += writeN
-			// copy to md5 buffer, and then write to md5
-			copy(hashBuf, buf[:readN])
-			md5Hash.Write(hashBuf[:readN])
-		}
-		if err == io.EOF {
-			err = nil
-			break
-		}
-	}
-	// flush
-	if err = v.ec.Flush(tInodeInfo.Inode); err != nil {
-		log.LogErrorf("CopyFile: data flush inode fail, volume(%v) inode(%v), path (%v) err(%v)", v.name, tInodeInfo.Inode, targetPath, err)
-		return
 	}
-	md5Value = hex.EncodeToString(md5Hash.Sum(nil))
-	log.LogDebugf("Audit: copy file: write file finished, volume(%v), path(%v), etag(%v)", v.name, targetPath, md5Value)
 
 	var finalInode *proto.InodeInfo
 	if finalInode, err = v.mw.InodeGet_ll(tInodeInfo.Inode); err != nil {
@@ -2215,7 +2449,7 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		// set tar xattr
 		if len(xattrs) > 0 {
 			for xk, xv := range xattrs[0].XAttrs {
-				if xk == XAttrKeyOSSETag {
+				if xk == XAttrKeyOSSETag || xk == proto.SharedExtentsXAttr {
 					continue
 				}
 				if err = v.mw.XAttrSet_ll(tInodeInfo.Inode, []byte(xk), []byte(xv)); err != nil {
@@ -2270,7 +2504,7 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 	// create file info
 	info = &FSFileInfo{
 		Path:       targetPath,
-		Size:       int64(fileSize),
+		Size:       int64(sInodeInfo.Size),
 		Mode:       sMode,
 		ModifyTime: tInodeInfo.ModifyTime,
 		CreateTime: tInodeInfo.CreateTime,
@@ -2279,7 +2513,7 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 	}
 
 	// apply new inode to dentry
-	err = v.applyInodeToDEntry(tParentId, tLastName, tInodeInfo.Inode)
+	info.VersionId, err = v.applyInodeToDEntry(tParentId, tLastName, tInodeInfo.Inode)
 	if err != nil {
 		log.LogErrorf("CopyFile: apply inode to new dentry fail: path(%v) parentID(%v) name(%v) inode(%v) err(%v)",
 			targetPath, tParentId, tLastName, tInodeInfo.Inode, err)
@@ -2287,6 +2521,70 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 	return
 }
 
+// copyFileData writes the data of the source file to the target inode and returns the MD5 of the data.
+func (v *Volume) copyFileData(sv *Volume, sInode, fileSize, tInode uint64, targetPath string) (md5Value string, err error) {
+	// write data to invisibleTempDataInode from source object
+	var (
+		md5Hash     = md5.New()
+		readN       int
+		writeN      int
+		readOffset  int
+		writeOffset int
+		readSize    int
+		buf         = make([]byte, 2*util.BlockSize)
+		hashBuf     = make([]byte, 2*util.BlockSize)
+	)
+	for {
+		readSize = len(buf)
+		if (int(fileSize) - readOffset) <= 0 {
+			break
+		}
+		if (int(fileSize) - readOffset) < len(buf) {
+			readSize = int(fileSize) - readOffset
+		}
+		readN, err = sv.ec.Read(sInode, buf, readOffset, readSize)
+		if err != nil && err != io.EOF {
+			return
+		}
+		if readN > 0 {
+			if writeN, err = v.ec.Write(tInode, writeOffset, buf[:readN], 0); err != nil {
+				log.LogErrorf("CopyFile: write target path from source fail, volume(%v) path(%v) inode(%v) target offset(%v) err(%v)",
+					v.name, targetPath, tInode, writeOffset, err)
+				return
+			}
+			readOffset += readN
+			writeOffset += writeN
+			// copy to md5 buffer, and then write to md5
+			copy(hashBuf, buf[:readN])
+			md5Hash.Write(hashBuf[:readN])
+		}
+		if err == io.EOF {
+			err = nil
+			break
+		}
+	}
+	if err = v.ec.Flush(tInode); err != nil {
+		log.LogErrorf("CopyFile: data flush inode fail, volume(%v) inode(%v), path (%v) err(%v)", v.name, tInode, targetPath, err)
+		return
+	}
+	md5Value = hex.EncodeToString(md5Hash.Sum(nil))
+	log.LogDebugf("Audit: copy file: write file finished, volume(%v), path(%v), etag(%v)", v.name, targetPath, md5Value)
+	return
+}
+
+// cloneableETag returns the MD5 of the file if its ETag is up to date, so it can be cloned without reading the data.
+func (v *Volume) cloneableETag(inoInfo *proto.InodeInfo) string {
+	xattr, err := v.mw.XAttrGet_ll(inoInfo.Inode, XAttrKeyOSSETag)
+	if err != nil {
+		return ""
+	}
+	etagValue := ParseETagValue(string(xattr.Get(XAttrKeyOSSETag)))
+	if !etagValue.Valid() || etagValue.PartNum > 0 || etagValue.TS.Before(inoInfo.ModifyTime) {
+		return ""
+	}
+	return etagValue.Value
+}
+
 func (v *Volume) copyFile(parentID uint64, newFileName string, sourceFileInode uint64, mode uint32) (info *proto.InodeInfo, err error) {
 
 	if err = v.mw.DentryCreate_ll(parentID, newFileName, sourceFileInode, mode); err != nil {
@@ -2326,6 +2624,8 @@ func NewVolume(config *VolumeConfig) (*Volume, error) {
 		OnAppendExtentKey: metaWrapper.AppendExtentKey,
 		OnGetExtents:      metaWrapper.GetExtents,
 		OnTruncate:        metaWrapper.Truncate,
+		OnIsShared:        metaWrapper.IsShared,
+		OnCheckShared:     metaWrapper.CheckShared,
 	}
 	var extentClient *stream.ExtentClient
 	if extentClient, err = stream.NewExtentClient(extentConfig); err != nil {
@@ -2338,7 +2638,9 @@ func NewVolume(config *VolumeConfig) (*Volume, error) {
 		name:       config.Volume,
 		store:      config.Store,
 		createTime: metaWrapper.VolCreateTime(),
+		flat:       metaWrapper.Flat(),
 		closeCh:    make(chan struct{}),
+		notifier:   config.Notifier,
 		onAsyncTaskError: func(err error) {
 			if err == syscall.ENOENT {
 				config.OnAsyncTaskError.OnError(proto.ErrVolNotExists)
@@ -2351,6 +2653,10 @@ func NewVolume(config *VolumeConfig) (*Volume, error) {
 		v.metaLoader = &cacheMetaLoader{om: new(OSSMeta)}
 		go v.syncOSSMeta()
 	}
+	go v.lifecycleWorker()
+	if v.notifier != nil {
+		go v.notificationWorker()
+	}
 
 	return v, nil
 }
@@ -0,0 +1,153 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	LifecycleScanInterval = time.Hour
+)
+
+func (v *Volume) loadBucketLifecycle() (configuration *LifecycleConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSLifecycle); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &LifecycleConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+// lifecycleWorker applies the lifecycle rules of the bucket periodically.
+// Every object node which has loaded the volume runs the worker, and it is fine
+// since expiring an object or aborting an upload twice has no side effect.
+func (v *Volume) lifecycleWorker() {
+	t := time.NewTicker(LifecycleScanInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			v.applyLifecycle()
+		case <-v.closeCh:
+			return
+		}
+	}
+}
+
+func (v *Volume) applyLifecycle() {
+	lifecycle, err := v.loadBucketLifecycle()
+	if err != nil {
+		log.LogErrorf("applyLifecycle: load lifecycle fail: volume(%v) err(%v)", v.name, err)
+		return
+	}
+	if lifecycle == nil {
+		return
+	}
+	var now = time.Now()
+	for _, rule := range lifecycle.Rules {
+		if rule.Status != LifecycleStatusEnabled {
+			continue
+		}
+		if rule.Expiration != nil {
+			v.expireObjects(rule, now)
+		}
+		if rule.AbortIncompleteMultipartUpload != nil {
+			v.abortIncompleteMultiparts(rule, now)
+		}
+	}
+}
+
+func (v *Volume) expireObjects(rule *LifecycleRule, now time.Time) {
+	var option = &ListFilesV1Option{
+		Prefix:  rule.prefix(),
+		MaxKeys: MaxKeys,
+	}
+	for {
+		result, err := v.ListFilesV1(option)
+		if err != nil {
+			log.LogErrorf("expireObjects: list files fail: volume(%v) rule(%v) err(%v)", v.name, rule.ID, err)
+			return
+		}
+		for _, file := range result.Files {
+			if file.Mode.IsDir() || !rule.Expiration.expired(file.ModifyTime, now) {
+				continue
+			}
+			// In a bucket with versioning the expiration places a delete marker.
+			if v.versioningStatus() != "" {
+				_, _, err = v.DeleteVersion(file.Path, "")
+			} else {
+				err = v.DeletePath(file.Path)
+			}
+			if err != nil {
+				log.LogWarnf("expireObjects: delete object fail: volume(%v) rule(%v) path(%v) err(%v)",
+					v.name, rule.ID, file.Path, err)
+				continue
+			}
+			log.LogInfof("Audit: expire object: volume(%v) rule(%v) path(%v)", v.name, rule.ID, file.Path)
+		}
+		if !result.Truncated {
+			return
+		}
+		option.Marker = result.NextMarker
+	}
+}
+
+func (v *Volume) abortIncompleteMultiparts(rule *LifecycleRule, now time.Time) {
+	var (
+		keyMarker         string
+		multipartIdMarker string
+		expiration        = time.Duration(rule.AbortIncompleteMultipartUpload.DaysAfterInitiation) * 24 * time.Hour
+	)
+	for {
+		sessions, err := v.mw.ListMultipart_ll(rule.prefix(), "", keyMarker, multipartIdMarker, MaxUploads)
+		if err != nil {
+			log.LogErrorf("abortIncompleteMultiparts: list multipart fail: volume(%v) rule(%v) err(%v)",
+				v.name, rule.ID, err)
+			return
+		}
+		var next *proto.MultipartInfo
+		if len(sessions) > MaxUploads {
+			next = sessions[MaxUploads]
+			sessions = sessions[:MaxUploads]
+		}
+		for _, session := range sessions {
+			if now.Sub(session.InitTime) <= expiration {
+				continue
+			}
+			if err = v.AbortMultipart(session.Path, session.ID); err != nil {
+				log.LogWarnf("abortIncompleteMultiparts: abort multipart fail: volume(%v) rule(%v) path(%v) multipartID(%v) err(%v)",
+					v.name, rule.ID, session.Path, session.ID, err)
+				continue
+			}
+			log.LogInfof("Audit: abort incomplete multipart: volume(%v) rule(%v) path(%v) multipartID(%v)",
+				v.name, rule.ID, session.Path, session.ID)
+		}
+		if next == nil {
+			return
+		}
+		keyMarker, multipartIdMarker = next.Path, next.ID
+	}
+}
@@ -20,9 +20,11 @@ type ossMetaLoader interface {
 	loadPolicy() (p *Policy, err error)
 	loadACL() (p *AccessControlPolicy, err error)
 	loadCors() (cors *CORSConfiguration, err error)
+	loadVersioning() (versioning *VersioningConfiguration, err error)
 	storePolicy(p *Policy)
 	storeACL(p *AccessControlPolicy)
 	storeCors(cors *CORSConfiguration)
+	storeVersioning(versioning *VersioningConfiguration)
 }
 
 type strictMetaLoader struct {
@@ -38,9 +40,11 @@ type OSSMeta struct {
 	policy     *Policy
 	acl        *AccessControlPolicy
 	corsConfig *CORSConfiguration
+	versioning *VersioningConfiguration
 	policyLock sync.RWMutex
 	aclLock    sync.RWMutex
 	corsLock   sync.RWMutex
+	verLock    sync.RWMutex
 }
 
 func (c *cacheMetaLoader) loadPolicy() (p *Policy, err error) {
@@ -85,6 +89,20 @@ func (c *cacheMetaLoader) storeCors(cors *CORSConfiguration) {
 	return
 }
 
+func (c *cacheMetaLoader) loadVersioning() (versioning *VersioningConfiguration, err error) {
+	c.om.verLock.RLock()
+	versioning = c.om.versioning
+	c.om.verLock.RUnlock()
+	return
+}
+
+func (c *cacheMetaLoader) storeVersioning(versioning *VersioningConfiguration) {
+	c.om.verLock.Lock()
+	c.om.versioning = versioning
+	c.om.verLock.Unlock()
+	return
+}
+
 func (s *strictMetaLoader) loadPolicy() (p *Policy, err error) {
 	return s.v.loadBucketPolicy()
 }
@@ -102,3 +120,9 @@ func (s *strictMetaLoader) loadCors() (cors *CORSConfiguration, err error) {
 }
 
 func (s *strictMetaLoader) storeCors(cors *CORSConfiguration) {}
+
+func (s *strictMetaLoader) loadVersioning() (versioning *VersioningConfiguration, err error) {
+	return s.v.loadBucketVersioning()
+}
+
+func (s *strictMetaLoader) storeVersioning(versioning *VersioningConfiguration) {}
@@ -0,0 +1,397 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"math"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	NotificationPublishInterval = time.Second
+
+	// the object node holding the lease publishes the notifications of the bucket, others take over
+	// once the lease is not renewed for this long
+	notificationLeaseTime = 30 * time.Second
+	notificationReadLimit = 1024
+	// the events failed to be published are retried for this many rounds and then dropped
+	notificationMaxRetries = 10
+	// the files created and the directories deleted are remembered for this long, to find the keys
+	// of the writes and of the objects deleted along with their directories
+	notificationPathTTL = 10 * time.Minute
+)
+
+// notificationState is the state of the publishing kept on the root of the bucket, so that another
+// object node can take over the publishing from where it stops.
+type notificationState struct {
+	Owner   string            `json:"owner"`
+	Expire  int64             `json:"expire"`
+	Cursors map[uint64]uint64 `json:"cursors"` // mapping: meta partition ID -> the index of the events published
+}
+
+type pathEntry struct {
+	parent uint64
+	name   string
+	expire int64 // the entry is kept for good if it is zero
+}
+
+// notificationWorker turns the events of the volume into the events of the objects. The events of the
+// volume carry the parent inodes and the names of the dentries, so the worker tracks the directories
+// of the bucket to build the keys of the objects.
+type notificationWorker struct {
+	dirs     map[uint64]*pathEntry // nil until the directories are loaded
+	files    map[uint64]*pathEntry // the files created recently, to find the keys of the writes
+	state    *notificationState    // nil unless the object node holds the lease
+	failures int
+}
+
+func newNotificationWorker() *notificationWorker {
+	return &notificationWorker{files: make(map[uint64]*pathEntry)}
+}
+
+// key returns the key of the dentry, or false if any of its ancestors is unknown.
+func (w *notificationWorker) key(parent uint64, name string) (string, bool) {
+	var names = []string{name}
+	for parent != proto.RootIno {
+		entry, ok := w.dirs[parent]
+		if !ok {
+			return "", false
+		}
+		names = append(names, entry.name)
+		parent = entry.parent
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return path.Join(names...), true
+}
+
+// convert returns the events of the objects derived from the event of the volume, it returns false
+// if the key of the object is not found. The directories are tracked but not published.
+func (w *notificationWorker) convert(event *proto.VolumeEvent) (events []*objectEvent, ok bool) {
+	var expire = event.Time + int64(notificationPathTTL/time.Second)
+	var newEvent = func(name, key string) *objectEvent {
+		return &objectEvent{name: name, key: key, inode: event.Inode, sequence: event.Index, time: event.Time}
+	}
+	if proto.IsDir(event.Mode) {
+		switch event.Type {
+		case proto.EventCreate, proto.EventRename:
+			w.dirs[event.Inode] = &pathEntry{parent: event.ParentID, name: event.Name}
+		case proto.EventDelete:
+			if entry, found := w.dirs[event.Inode]; found {
+				entry.expire = expire
+			}
+		}
+		return nil, true
+	}
+
+	switch event.Type {
+	case proto.EventCreate:
+		var key string
+		if key, ok = w.key(event.ParentID, event.Name); !ok {
+			return
+		}
+		w.files[event.Inode] = &pathEntry{parent: event.ParentID, name: event.Name, expire: expire}
+		events = append(events, newEvent(NotificationEventObjectCreatedPut, key))
+	case proto.EventDelete:
+		var key string
+		if key, ok = w.key(event.ParentID, event.Name); !ok {
+			return
+		}
+		events = append(events, newEvent(NotificationEventObjectRemovedDelete, key))
+	case proto.EventRename:
+		oldKey, oldOK := w.key(event.OldParentID, event.OldName)
+		newKey, newOK := w.key(event.ParentID, event.Name)
+		if ok = oldOK && newOK; !ok {
+			return
+		}
+		w.files[event.Inode] = &pathEntry{parent: event.ParentID, name: event.Name, expire: expire}
+		events = append(events, newEvent(NotificationEventObjectRemovedDelete, oldKey),
+			newEvent(NotificationEventObjectCreatedPut, newKey))
+	case proto.EventWriteClose:
+		// the writes to the files not created recently are not published, since their keys are unknown
+		entry, found := w.files[event.Inode]
+		if !found {
+			return nil, true
+		}
+		var key string
+		if key, ok = w.key(entry.parent, entry.name); !ok {
+			return
+		}
+		events = append(events, newEvent(NotificationEventObjectCreatedPut, key))
+	default:
+		ok = true
+	}
+	return
+}
+
+func (w *notificationWorker) prune(now int64) {
+	for ino, entry := range w.files {
+		if entry.expire < now {
+			delete(w.files, ino)
+		}
+	}
+	for ino, entry := range w.dirs {
+		if entry.expire != 0 && entry.expire < now {
+			delete(w.dirs, ino)
+		}
+	}
+}
+
+// notificationWorker publishes the notifications of the bucket. Every object node which has loaded
+// the volume runs the worker, while only the one holding the lease publishes. The notifications are
+// delivered at least once, some may be published again after the lease is taken over.
+func (v *Volume) notificationWorker() {
+	t := time.NewTicker(NotificationPublishInterval)
+	defer t.Stop()
+	var w = newNotificationWorker()
+	for {
+		select {
+		case <-t.C:
+			v.publishNotifications(w)
+		case <-v.closeCh:
+			return
+		}
+	}
+}
+
+func (v *Volume) loadBucketNotification() (configuration *NotificationConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSNotification); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &NotificationConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) storeNotificationState(state *notificationState) (err error) {
+	var data []byte
+	if data, err = json.Marshal(state); err != nil {
+		return
+	}
+	return v.store.Put(v.name, bucketRootPath, XAttrKeyOSSNotificationState, data)
+}
+
+// holdNotificationLease checks if the object node holds the lease of the publishing, and takes
+// the lease over if it is expired. The lease is taken over in a round and used from the next one,
+// so that the object nodes taking it over at the same time find the winner.
+func (v *Volume) holdNotificationLease(w *notificationWorker, now time.Time) bool {
+	raw, err := v.store.Get(v.name, bucketRootPath, XAttrKeyOSSNotificationState)
+	if err != nil {
+		log.LogErrorf("holdNotificationLease: load state fail: volume(%v) err(%v)", v.name, err)
+		return false
+	}
+	var state = &notificationState{}
+	if len(raw) > 0 {
+		if err = json.Unmarshal(raw, state); err != nil {
+			log.LogErrorf("holdNotificationLease: unmarshal state fail: volume(%v) err(%v)", v.name, err)
+			return false
+		}
+	}
+	if state.Owner == v.notifier.nodeID {
+		if w.state == nil {
+			w.state, w.dirs = state, nil
+		}
+		return true
+	}
+	w.state = nil
+	if state.Expire > now.Unix() {
+		return false
+	}
+	log.LogInfof("holdNotificationLease: take over the lease: volume(%v) owner(%v) expire(%v)",
+		v.name, state.Owner, state.Expire)
+	state.Owner = v.notifier.nodeID
+	state.Expire = now.Add(notificationLeaseTime).Unix()
+	if err = v.storeNotificationState(state); err != nil {
+		log.LogErrorf("holdNotificationLease: store state fail: volume(%v) err(%v)", v.name, err)
+	}
+	return false
+}
+
+// loadDirectories walks the directories of the bucket.
+func (v *Volume) loadDirectories(w *notificationWorker) (err error) {
+	var start = time.Now()
+	var dirs = make(map[uint64]*pathEntry)
+	var queue = []uint64{proto.RootIno}
+	for len(queue) > 0 {
+		var parent = queue[0]
+		queue = queue[1:]
+		var children []proto.Dentry
+		if children, err = v.mw.ReadDir_ll(parent); err != nil {
+			return
+		}
+		for _, child := range children {
+			if proto.IsDir(child.Type) {
+				dirs[child.Inode] = &pathEntry{parent: parent, name: child.Name}
+				queue = append(queue, child.Inode)
+			}
+		}
+	}
+	w.dirs = dirs
+	log.LogInfof("loadDirectories: volume(%v) directories(%v) elapsed(%v)", v.name, len(dirs), time.Since(start))
+	return
+}
+
+// initCursor returns the index applied by the meta partition, the events after it are published.
+func (v *Volume) initCursor(pid uint64) (cursor uint64, err error) {
+	var resp *proto.ReadChangesResponse
+	if resp, err = v.mw.ReadChanges(pid, math.MaxUint64, 1); err != nil {
+		return
+	}
+	return resp.ApplyID, nil
+}
+
+func (v *Volume) publishNotifications(w *notificationWorker) {
+	var now = time.Now()
+	notification, err := v.loadBucketNotification()
+	if err != nil {
+		log.LogErrorf("publishNotifications: load notification fail: volume(%v) err(%v)", v.name, err)
+		return
+	}
+	if notification == nil || len(notification.configs()) == 0 {
+		if w.state != nil {
+			// publish the events after the notifications are enabled again
+			if err = v.store.Delete(v.name, bucketRootPath, XAttrKeyOSSNotificationState); err != nil {
+				log.LogErrorf("publishNotifications: delete state fail: volume(%v) err(%v)", v.name, err)
+				return
+			}
+			w.state, w.dirs = nil, nil
+		}
+		return
+	}
+	if !v.holdNotificationLease(w, now) {
+		return
+	}
+
+	// read the events of the meta partitions
+	var cursors = make(map[uint64]uint64)
+	var events []*proto.VolumeEvent
+	for _, pid := range v.mw.PartitionIDs() {
+		cursor, ok := w.state.Cursors[pid]
+		if !ok {
+			if cursor, err = v.initCursor(pid); err != nil {
+				log.LogErrorf("publishNotifications: init cursor fail: volume(%v) mp(%v) err(%v)", v.name, pid, err)
+				return
+			}
+			cursors[pid] = cursor
+			continue
+		}
+		var resp *proto.ReadEventsResponse
+		if resp, err = v.mw.ReadEvents(pid, cursor, notificationReadLimit, 0); err != nil {
+			log.LogErrorf("publishNotifications: read events fail: volume(%v) mp(%v) err(%v)", v.name, pid, err)
+			return
+		}
+		if resp.Truncated {
+			log.LogWarnf("publishNotifications: events lost: volume(%v) mp(%v) cursor(%v)", v.name, pid, cursor)
+			if cursor, err = v.initCursor(pid); err != nil {
+				log.LogErrorf("publishNotifications: init cursor fail: volume(%v) mp(%v) err(%v)", v.name, pid, err)
+				return
+			}
+			cursors[pid] = cursor
+			w.dirs = nil
+			continue
+		}
+		cursors[pid] = resp.Cursor
+		events = append(events, resp.Events...)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time < events[j].Time })
+
+	// turn them into the events of the objects
+	var reloaded bool
+	if w.dirs == nil {
+		if err = v.loadDirectories(w); err != nil {
+			log.LogErrorf("publishNotifications: load directories fail: volume(%v) err(%v)", v.name, err)
+			return
+		}
+		reloaded = true
+	}
+	var objectEvents []*objectEvent
+	for _, event := range events {
+		converted, ok := w.convert(event)
+		if !ok && !reloaded {
+			if err = v.loadDirectories(w); err != nil {
+				log.LogErrorf("publishNotifications: load directories fail: volume(%v) err(%v)", v.name, err)
+				return
+			}
+			reloaded = true
+			converted, ok = w.convert(event)
+		}
+		if !ok {
+			log.LogWarnf("publishNotifications: key not found: volume(%v) event(%v)", v.name, *event)
+			continue
+		}
+		objectEvents = append(objectEvents, converted...)
+	}
+	w.prune(now.Unix())
+
+	// publish them to the targets
+	var records = make(map[string][]*notificationRecord)
+	var arns []string
+	for _, event := range objectEvents {
+		if event.name == NotificationEventObjectCreatedPut {
+			if info, err := v.mw.InodeGet_ll(event.inode); err == nil {
+				event.size = info.Size
+			}
+		}
+		for _, config := range notification.configs() {
+			if !config.match(event.name, event.key) {
+				continue
+			}
+			var arn = config.arn()
+			if _, ok := records[arn]; !ok {
+				arns = append(arns, arn)
+			}
+			records[arn] = append(records[arn], newNotificationRecord(v.notifier.region, v.name, config.Id, event))
+		}
+	}
+	for _, arn := range arns {
+		if err = v.notifier.publish(arn, v.name, records[arn]); err != nil {
+			break
+		}
+	}
+	if err != nil {
+		if w.failures++; w.failures < notificationMaxRetries {
+			log.LogWarnf("publishNotifications: publish fail: volume(%v) failures(%v) err(%v)", v.name, w.failures, err)
+			return
+		}
+		log.LogErrorf("publishNotifications: drop events: volume(%v) events(%v) err(%v)", v.name, len(objectEvents), err)
+	}
+	w.failures = 0
+
+	var changed = len(cursors) != len(w.state.Cursors)
+	for pid, cursor := range cursors {
+		changed = changed || w.state.Cursors[pid] != cursor
+	}
+	if !changed && w.state.Expire-now.Unix() > int64(notificationLeaseTime/time.Second/2) {
+		return
+	}
+	w.state.Cursors = cursors
+	w.state.Expire = now.Add(notificationLeaseTime).Unix()
+	if err = v.storeNotificationState(w.state); err != nil {
+		log.LogErrorf("publishNotifications: store state fail: volume(%v) err(%v)", v.name, err)
+	}
+}
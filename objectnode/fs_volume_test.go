@@ -13,3 +13,32 @@
 // permissions and limitations under the License.
 
 package objectnode
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlatPathIterator(t *testing.T) {
+	var samples = []struct {
+		flat  bool
+		path  string
+		items []PathItem
+	}{
+		{false, "a/b//c", []PathItem{{"a", true}, {"b", true}, {"c", false}}},
+		{false, "/a/b/", []PathItem{{"a", true}, {"b", true}}},
+		{true, "a/b//c", []PathItem{{"a/b//c", false}}},
+		{true, "a/b/", []PathItem{{"a/b/", false}}},
+		{true, "", []PathItem{}},
+	}
+	for _, sample := range samples {
+		var v = &Volume{flat: sample.flat}
+		if items := v.pathIterator(sample.path).ToSlice(); !reflect.DeepEqual(items, sample.items) {
+			t.Fatalf("path(%v) flat(%v): expect(%v) actual(%v)", sample.path, sample.flat, sample.items, items)
+		}
+		var dirs, name = v.splitPath(sample.path)
+		if sample.flat && (len(dirs) != 0 || name != sample.path) {
+			t.Fatalf("split path(%v) of flat namespace: dirs(%v) name(%v)", sample.path, dirs, name)
+		}
+	}
+}
@@ -0,0 +1,698 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/meta"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// versioningStatus returns the versioning status of the bucket,
+// which is blank if the versioning has never been enabled.
+func (v *Volume) versioningStatus() string {
+	versioning, err := v.metaLoader.loadVersioning()
+	if err != nil || versioning == nil {
+		return ""
+	}
+	return versioning.Status
+}
+
+// assignVersionId stores a new version id to the inode if the versioning is enabled.
+func (v *Volume) assignVersionId(inode uint64) (versionId string, err error) {
+	switch v.versioningStatus() {
+	case VersioningStatusEnabled:
+		versionId = newVersionId()
+		if err = v.mw.XAttrSet_ll(inode, []byte(XAttrKeyOSSVersionId), []byte(versionId)); err != nil {
+			log.LogErrorf("assignVersionId: store version id fail: volume(%v) inode(%v) err(%v)", v.name, inode, err)
+			return "", err
+		}
+	case VersioningStatusSuspended:
+		versionId = NullVersionId
+	}
+	return
+}
+
+func (v *Volume) inodeVersionId(inode uint64) string {
+	info, err := v.mw.XAttrGet_ll(inode, XAttrKeyOSSVersionId)
+	if err != nil {
+		log.LogWarnf("inodeVersionId: get version id fail: volume(%v) inode(%v) err(%v)", v.name, inode, err)
+		return NullVersionId
+	}
+	if versionId := info.Get(XAttrKeyOSSVersionId); len(versionId) > 0 {
+		return string(versionId)
+	}
+	return NullVersionId
+}
+
+// versionChain returns the chain inode of the object, which is 0 if the object has no versions.
+// The chain inode is created if needed when create is true.
+func (v *Volume) versionChain(parentId uint64, name string, create bool) (chain uint64, err error) {
+	var key = versionChainXAttrKey(name)
+	var info *proto.XAttrInfo
+	if info, err = v.mw.XAttrGet_ll(parentId, key); err != nil {
+		return
+	}
+	if raw := info.Get(key); len(raw) > 0 {
+		return strconv.ParseUint(string(raw), 10, 64)
+	}
+	if !create {
+		return
+	}
+	var inoInfo *proto.InodeInfo
+	if inoInfo, err = v.mw.InodeCreate_ll(DefaultFileMode, 0, 0, nil); err != nil {
+		return
+	}
+	err = v.mw.XAttrSetFlag_ll(parentId, []byte(key), []byte(strconv.FormatUint(inoInfo.Inode, 10)), proto.XAttrCreate)
+	if err == syscall.EEXIST {
+		// created by another request at the same time
+		v.purgeVersionInode(inoInfo.Inode)
+		return v.versionChain(parentId, name, false)
+	}
+	if err != nil {
+		v.purgeVersionInode(inoInfo.Inode)
+		return
+	}
+	return inoInfo.Inode, nil
+}
+
+// loadVersions loads the version chain of the object, it returns the chain inode as well
+// which is 0 if the object has no versions.
+func (v *Volume) loadVersions(parentId uint64, name string) (chain uint64, versions []*versionEntry, err error) {
+	if chain, err = v.versionChain(parentId, name, false); err != nil || chain == 0 {
+		return
+	}
+	versions, err = v.loadChainVersions(chain)
+	return
+}
+
+// loadChainVersions loads the versions stored in the extended attributes of the chain inode.
+func (v *Volume) loadChainVersions(chain uint64) (versions []*versionEntry, err error) {
+	var keys []string
+	if keys, err = v.mw.XAttrsList_ll(chain); err != nil {
+		return
+	}
+	if keys = versionKeys(keys); len(keys) == 0 {
+		return
+	}
+	var xattrs []*proto.XAttrInfo
+	if xattrs, err = v.mw.BatchGetXAttr([]uint64{chain}, keys); err != nil || len(xattrs) == 0 {
+		return
+	}
+	versions = make([]*versionEntry, 0, len(keys))
+	for _, key := range keys {
+		var raw = xattrs[0].Get(key)
+		if len(raw) == 0 {
+			// removed after the keys are listed
+			continue
+		}
+		var version = &versionEntry{key: key}
+		if err = json.Unmarshal(raw, version); err != nil {
+			return
+		}
+		versions = append(versions, version)
+	}
+	return
+}
+
+// storeVersion adds the version as the newest one of the version chain of the object.
+func (v *Volume) storeVersion(parentId uint64, name string, version *versionEntry) (err error) {
+	var raw []byte
+	if raw, err = json.Marshal(version); err != nil {
+		return
+	}
+	var chain uint64
+	if chain, err = v.versionChain(parentId, name, true); err != nil {
+		return
+	}
+	version.key = versionXAttrKey(version.VersionId, time.Now().UnixNano())
+	return v.mw.XAttrSet_ll(chain, []byte(version.key), raw)
+}
+
+// removeVersion removes the version from the version chain of the object,
+// and releases the chain inode once the chain is empty.
+func (v *Volume) removeVersion(parentId uint64, name string, chain uint64, version *versionEntry) (err error) {
+	if err = v.mw.XAttrDel_ll(chain, version.key); err != nil {
+		return
+	}
+	var keys []string
+	if keys, err = v.mw.XAttrsList_ll(chain); err != nil || len(versionKeys(keys)) > 0 {
+		return
+	}
+	var key = versionChainXAttrKey(name)
+	if err = v.mw.XAttrDel_ll(parentId, key); err != nil {
+		return
+	}
+	// A version may be stored before the pointer is removed, the chain is kept then.
+	if keys, err = v.mw.XAttrsList_ll(chain); err != nil {
+		return
+	}
+	if len(versionKeys(keys)) > 0 {
+		return v.mw.XAttrSetFlag_ll(parentId, []byte(key), []byte(strconv.FormatUint(chain, 10)), proto.XAttrCreate)
+	}
+	v.purgeVersionInode(chain)
+	return
+}
+
+// hasVersions checks if there are version chains pointed to by the directory.
+func (v *Volume) hasVersions(dir uint64) bool {
+	keys, err := v.mw.XAttrsList_ll(dir)
+	if err != nil {
+		return false
+	}
+	for _, key := range keys {
+		if strings.HasPrefix(key, XAttrKeyOSSVersionsPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveVersion keeps the inode replaced by a new version of the object as a noncurrent version.
+// It returns false if the inode is not kept, and the caller should release it.
+func (v *Volume) archiveVersion(parentId uint64, name string, inode uint64) (archived bool, err error) {
+	var status = v.versioningStatus()
+	if status == "" {
+		return
+	}
+	var versionId = v.inodeVersionId(inode)
+	if versionId == NullVersionId && status == VersioningStatusSuspended {
+		// The null version is replaced by the new one.
+		return
+	}
+	var info *proto.InodeInfo
+	if info, err = v.mw.InodeGet_ll(inode); err != nil {
+		return
+	}
+	var version = &versionEntry{VersionId: versionId, Inode: inode, ModifyTime: info.ModifyTime.Unix()}
+	if err = v.storeVersion(parentId, name, version); err != nil {
+		return
+	}
+	return true, nil
+}
+
+// discardNullVersion permanently removes the noncurrent null version of the object,
+// because there is at most one null version.
+func (v *Volume) discardNullVersion(parentId uint64, name string) {
+	chain, versions, err := v.loadVersions(parentId, name)
+	if err != nil {
+		log.LogWarnf("discardNullVersion: load versions fail: volume(%v) parentID(%v) name(%v) err(%v)",
+			v.name, parentId, name, err)
+		return
+	}
+	var index = findVersion(versions, NullVersionId)
+	if index < 0 {
+		return
+	}
+	var version = versions[index]
+	if err = v.removeVersion(parentId, name, chain, version); err != nil {
+		log.LogWarnf("discardNullVersion: remove version fail: volume(%v) parentID(%v) name(%v) err(%v)",
+			v.name, parentId, name, err)
+		return
+	}
+	if !version.DeleteMarker {
+		v.purgeVersionInode(version.Inode)
+	}
+}
+
+func (v *Volume) purgeVersionInode(inode uint64) {
+	log.LogInfof("purgeVersionInode: unlink inode: volume(%v) inode(%v)", v.name, inode)
+	if _, err := v.mw.InodeUnlink_ll(inode); err != nil {
+		log.LogWarnf("purgeVersionInode: unlink inode fail: volume(%v) inode(%v) err(%v)", v.name, inode, err)
+	}
+	if err := v.mw.Evict(inode); err != nil {
+		log.LogWarnf("purgeVersionInode: evict inode fail: volume(%v) inode(%v) err(%v)", v.name, inode, err)
+	}
+}
+
+func (v *Volume) lookupParent(path string) (parentId uint64, name string, err error) {
+	var pathItems = v.pathIterator(path).ToSlice()
+	if len(pathItems) == 0 {
+		err = syscall.EINVAL
+		return
+	}
+	var dirs = make([]string, 0, len(pathItems)-1)
+	for _, item := range pathItems[:len(pathItems)-1] {
+		dirs = append(dirs, item.Name)
+	}
+	if parentId, err = v.lookupDirectories(dirs, false); err != nil {
+		return
+	}
+	name = pathItems[len(pathItems)-1].Name
+	return
+}
+
+// DeleteVersion deletes the object in a bucket with versioning.
+// Without a version id, the current version becomes a noncurrent version and a delete marker
+// is placed on top of it. With a version id, the specified version is permanently removed, and
+// if it was the current one, the newest noncurrent version becomes current again.
+// It returns the version id of the created or removed version.
+func (v *Volume) DeleteVersion(path, versionId string) (resultVersionId string, deleteMarker bool, err error) {
+	defer func() {
+		log.LogInfof("Audit: DeleteVersion: volume(%v) path(%v) versionId(%v) err(%v)", v.name, path, versionId, err)
+	}()
+	var parentId uint64
+	var name string
+	if parentId, name, err = v.lookupParent(path); err == syscall.ENOENT {
+		return "", false, nil
+	}
+	if err != nil {
+		return
+	}
+	var mode uint32
+	var currentIno uint64
+	currentIno, mode, err = v.mw.Lookup_ll(parentId, name)
+	if err != nil && err != syscall.ENOENT {
+		return
+	}
+	if err == nil && os.FileMode(mode).IsDir() {
+		return "", false, v.DeletePath(path)
+	}
+	var hasCurrent = err == nil
+
+	var chain uint64
+	var versions []*versionEntry
+	if chain, versions, err = v.loadVersions(parentId, name); err != nil {
+		return
+	}
+
+	if versionId == "" {
+		var status = v.versioningStatus()
+		resultVersionId, deleteMarker = NullVersionId, true
+		if status == VersioningStatusEnabled {
+			resultVersionId = newVersionId()
+		}
+		if resultVersionId == NullVersionId {
+			if index := findVersion(versions, NullVersionId); index >= 0 {
+				if err = v.removeVersion(parentId, name, chain, versions[index]); err != nil {
+					return
+				}
+				if !versions[index].DeleteMarker {
+					v.purgeVersionInode(versions[index].Inode)
+				}
+			}
+		}
+		if hasCurrent {
+			var currentVersionId = v.inodeVersionId(currentIno)
+			if currentVersionId == NullVersionId && status != VersioningStatusEnabled {
+				if err = v.deleteCurrentVersion(parentId, name, currentIno); err != nil {
+					return
+				}
+			} else {
+				// Keep one more link of the current inode before removing its dentry.
+				var info *proto.InodeInfo
+				if info, err = v.mw.InodeLink_ll(currentIno); err != nil {
+					return
+				}
+				if _, err = v.mw.Delete_ll(parentId, name, false); err != nil {
+					_, _ = v.mw.InodeUnlink_ll(currentIno)
+					return
+				}
+				if err = v.storeVersion(parentId, name, &versionEntry{
+					VersionId:  currentVersionId,
+					Inode:      currentIno,
+					ModifyTime: info.ModifyTime.Unix(),
+				}); err != nil {
+					return
+				}
+			}
+		}
+		err = v.storeVersion(parentId, name, &versionEntry{
+			VersionId:    resultVersionId,
+			DeleteMarker: true,
+			ModifyTime:   time.Now().Unix(),
+		})
+		return
+	}
+
+	resultVersionId = versionId
+	if hasCurrent && v.inodeVersionId(currentIno) == versionId {
+		if err = v.deleteCurrentVersion(parentId, name, currentIno); err != nil {
+			return
+		}
+		hasCurrent = false
+	} else {
+		var index = findVersion(versions, versionId)
+		if index < 0 {
+			return
+		}
+		var version = versions[index]
+		if err = v.removeVersion(parentId, name, chain, version); err != nil {
+			return
+		}
+		versions = append(versions[:index], versions[index+1:]...)
+		if version.DeleteMarker {
+			deleteMarker = true
+		} else {
+			v.purgeVersionInode(version.Inode)
+		}
+	}
+	// The newest noncurrent version becomes current unless it is a delete marker.
+	if !hasCurrent && len(versions) > 0 && !versions[0].DeleteMarker {
+		if err = v.mw.DentryCreate_ll(parentId, name, versions[0].Inode, DefaultFileMode); err != nil {
+			log.LogErrorf("DeleteVersion: restore version fail: volume(%v) path(%v) version(%v) err(%v)",
+				v.name, path, versions[0].VersionId, err)
+			return
+		}
+		err = v.removeVersion(parentId, name, chain, versions[0])
+	}
+	return
+}
+
+func (v *Volume) deleteCurrentVersion(parentId uint64, name string, inode uint64) (err error) {
+	if _, err = v.mw.Delete_ll(parentId, name, false); err != nil {
+		return
+	}
+	if err = v.ec.EvictStream(inode); err != nil {
+		log.LogWarnf("deleteCurrentVersion: evict stream fail: volume(%v) inode(%v) err(%v)", v.name, inode, err)
+	}
+	if err = v.mw.Evict(inode); err != nil {
+		log.LogWarnf("deleteCurrentVersion: evict inode fail: volume(%v) inode(%v) err(%v)", v.name, inode, err)
+	}
+	return nil
+}
+
+// ObjectVersionMeta returns the meta of the specified version of the object.
+// If the version is a delete marker, it returns no file info and true.
+func (v *Volume) ObjectVersionMeta(path, versionId string) (info *FSFileInfo, deleteMarker bool, err error) {
+	var parentId uint64
+	var name string
+	if parentId, name, err = v.lookupParent(path); err != nil {
+		return
+	}
+	var currentIno uint64
+	var mode uint32
+	currentIno, mode, err = v.mw.Lookup_ll(parentId, name)
+	if err != nil && err != syscall.ENOENT {
+		return
+	}
+	if err == nil && !os.FileMode(mode).IsDir() && v.inodeVersionId(currentIno) == versionId {
+		info, err = v.ObjectMeta(path)
+		return
+	}
+	var versions []*versionEntry
+	if _, versions, err = v.loadVersions(parentId, name); err != nil {
+		return
+	}
+	var index = findVersion(versions, versionId)
+	if index < 0 {
+		err = syscall.ENOENT
+		return
+	}
+	if versions[index].DeleteMarker {
+		return nil, true, nil
+	}
+	var inoInfo *proto.InodeInfo
+	if inoInfo, err = v.mw.InodeGet_ll(versions[index].Inode); err != nil {
+		return
+	}
+	info, err = v.inodeMeta(path, inoInfo, os.FileMode(inoInfo.Mode))
+	return
+}
+
+// ListObjectVersions lists the versions of the objects whose key starts with the prefix,
+// ordered by the key and then from the newest version to the oldest one.
+// The directories are walked from the key marker, and the walk stops once the page is full.
+func (v *Volume) ListObjectVersions(opt *ListFileVersionsOption) (result *ListFileVersionsResult, err error) {
+	var lister = &versionLister{
+		vol:    v,
+		opt:    opt,
+		result: &ListFileVersionsResult{Versions: make([]*FSVersionInfo, 0)},
+	}
+	if _, err = lister.walk(rootIno, "", opt.KeyMarker); err != nil {
+		return
+	}
+	result = lister.result
+	if err = v.supplyCurrentVersionIds(result.Versions); err != nil {
+		return
+	}
+	if result.Truncated && len(result.Versions) > 0 {
+		var last = result.Versions[len(result.Versions)-1]
+		result.NextKeyMarker, result.NextVersionIdMarker = last.Key, last.VersionId
+	}
+	err = v.supplyVersionInfo(result.Versions)
+	return
+}
+
+// versionLister walks the directories of the bucket in the order of the names for ListObjectVersions.
+type versionLister struct {
+	vol    *Volume
+	opt    *ListFileVersionsOption
+	result *ListFileVersionsResult
+}
+
+// walk lists the versions of the objects in the directory from the marker, which is blank
+// if the whole directory is after the key marker. It returns true once the page is full.
+func (l *versionLister) walk(dir uint64, dirPath, marker string) (full bool, err error) {
+	var v = l.vol
+	var prefix = l.opt.Prefix
+	// Only the children having the name prefix at this level can match the prefix.
+	var namePrefix, markerName string
+	if v.flat {
+		namePrefix, markerName = prefix, marker
+	} else {
+		if strings.HasPrefix(prefix, dirPath) {
+			namePrefix = strings.SplitN(prefix[len(dirPath):], pathSep, 2)[0]
+		}
+		if marker != "" {
+			markerName = strings.SplitN(marker[len(dirPath):], pathSep, 2)[0]
+		}
+	}
+
+	// The objects having no current version only have the version chains pointed to by the directory.
+	var keys []string
+	if keys, err = v.mw.XAttrsList_ll(dir); err == syscall.ENOENT {
+		return false, nil
+	}
+	if err != nil {
+		return
+	}
+	var names = versionedNames(keys)
+	var visitNames = func(before string) (full bool, err error) {
+		for len(names) > 0 && (before == "" || names[0] < before) {
+			var name = names[0]
+			names = names[1:]
+			if name < markerName || !strings.HasPrefix(name, namePrefix) {
+				continue
+			}
+			if full, err = l.visit(dir, dirPath, marker, proto.Dentry{Name: name}, true); full || err != nil {
+				return
+			}
+		}
+		return
+	}
+	var visitDentry = func(dentry proto.Dentry) (full bool, err error) {
+		if full, err = visitNames(dentry.Name); full || err != nil {
+			return
+		}
+		var versioned bool
+		if len(names) > 0 && names[0] == dentry.Name {
+			versioned, names = true, names[1:]
+		}
+		return l.visit(dir, dirPath, marker, dentry, versioned)
+	}
+
+	// The child of the marker name is looked up first since the meta partition reads the children after the marker.
+	if markerName != "" && strings.HasPrefix(markerName, namePrefix) {
+		var inode uint64
+		var mode uint32
+		inode, mode, err = v.mw.Lookup_ll(dir, markerName)
+		if err != nil && err != syscall.ENOENT {
+			return
+		}
+		if err == nil {
+			if full, err = visitDentry(proto.Dentry{Name: markerName, Inode: inode, Type: mode}); full || err != nil {
+				return
+			}
+		}
+		err = nil
+	}
+	var readMarker = markerName
+	for first := true; ; first = false {
+		var children []proto.Dentry
+		children, err = v.mw.ReadDirPrefixLimit_ll(dir, namePrefix, readMarker, meta.ReadDirLimit)
+		if err == syscall.ENOENT {
+			return false, nil
+		}
+		if err != nil {
+			return
+		}
+		// the meta nodes not supporting the pagination reply all the dentries at once
+		if !first && len(children) > 0 && children[0].Name <= readMarker {
+			break
+		}
+		for _, child := range children {
+			if child.Name <= readMarker || !strings.HasPrefix(child.Name, namePrefix) {
+				continue
+			}
+			if full, err = visitDentry(child); full || err != nil {
+				return
+			}
+		}
+		if uint64(len(children)) < meta.ReadDirLimit {
+			break
+		}
+		readMarker = children[len(children)-1].Name
+	}
+	return visitNames("")
+}
+
+// visit lists the versions of the object, or walks the sub directory. The dentry has no inode
+// if the object has no current version, and versioned tells if the object has a version chain.
+func (l *versionLister) visit(dir uint64, dirPath, marker string, dentry proto.Dentry, versioned bool) (full bool, err error) {
+	var path = dirPath + dentry.Name
+	if dentry.Inode != 0 && os.FileMode(dentry.Type).IsDir() {
+		var subPath = path + pathSep
+		if !strings.HasPrefix(subPath, l.opt.Prefix) && !strings.HasPrefix(l.opt.Prefix, subPath) {
+			return
+		}
+		var subMarker string
+		if strings.HasPrefix(marker, subPath) {
+			subMarker = marker
+		}
+		return l.walk(dentry.Inode, subPath, subMarker)
+	}
+	if !strings.HasPrefix(path, l.opt.Prefix) || path < marker {
+		return
+	}
+
+	var entries []*versionEntry
+	if versioned {
+		if _, entries, err = l.vol.loadVersions(dir, dentry.Name); err != nil {
+			return
+		}
+	}
+	var versions = make([]*FSVersionInfo, 0, len(entries)+1)
+	if dentry.Inode != 0 {
+		versions = append(versions, &FSVersionInfo{Key: path, Inode: dentry.Inode, IsLatest: true})
+	}
+	for _, entry := range entries {
+		versions = append(versions, newFSVersionInfo(path, entry, len(versions) == 0))
+	}
+	if path == l.opt.KeyMarker {
+		// The versions of the key marker are listed after the version id marker only.
+		var start = len(versions)
+		if len(versions) > 0 && versions[0].IsLatest && dentry.Inode != 0 {
+			versions[0].VersionId = l.vol.inodeVersionId(dentry.Inode)
+		}
+		for i, version := range versions {
+			if l.opt.VersionIdMarker != "" && version.VersionId == l.opt.VersionIdMarker {
+				start = i + 1
+				break
+			}
+		}
+		versions = versions[start:]
+	}
+	for _, version := range versions {
+		if uint64(len(l.result.Versions)) >= l.opt.MaxKeys {
+			l.result.Truncated = true
+			return true, nil
+		}
+		l.result.Versions = append(l.result.Versions, version)
+	}
+	return
+}
+
+// supplyCurrentVersionIds fills the version ids of the current versions.
+func (v *Volume) supplyCurrentVersionIds(versions []*FSVersionInfo) (err error) {
+	var inodes = make([]uint64, 0)
+	for _, version := range versions {
+		if version.IsLatest && !version.DeleteMarker && version.VersionId == "" {
+			inodes = append(inodes, version.Inode)
+		}
+	}
+	if len(inodes) == 0 {
+		return
+	}
+	var xattrs []*proto.XAttrInfo
+	if xattrs, err = v.mw.BatchGetXAttr(inodes, []string{XAttrKeyOSSVersionId}); err != nil {
+		return
+	}
+	var versionIds = make(map[uint64]string, len(xattrs))
+	for _, xattr := range xattrs {
+		if versionId := xattr.Get(XAttrKeyOSSVersionId); len(versionId) > 0 {
+			versionIds[xattr.Inode] = string(versionId)
+		}
+	}
+	for _, version := range versions {
+		if version.IsLatest && !version.DeleteMarker && version.VersionId == "" {
+			version.VersionId = NullVersionId
+			if versionId, ok := versionIds[version.Inode]; ok {
+				version.VersionId = versionId
+			}
+		}
+	}
+	return
+}
+
+func newFSVersionInfo(key string, version *versionEntry, isLatest bool) *FSVersionInfo {
+	return &FSVersionInfo{
+		Key:          key,
+		VersionId:    version.VersionId,
+		IsLatest:     isLatest,
+		DeleteMarker: version.DeleteMarker,
+		Inode:        version.Inode,
+		ModifyTime:   time.Unix(version.ModifyTime, 0),
+	}
+}
+
+// supplyVersionInfo fills the size, ETag and modify time of the versions which are not delete markers.
+func (v *Volume) supplyVersionInfo(versions []*FSVersionInfo) (err error) {
+	var inodes = make([]uint64, 0, len(versions))
+	for _, version := range versions {
+		if !version.DeleteMarker {
+			inodes = append(inodes, version.Inode)
+		}
+	}
+	if len(inodes) == 0 {
+		return
+	}
+	var inoInfos = make(map[uint64]*proto.InodeInfo, len(inodes))
+	for _, inoInfo := range v.mw.BatchInodeGet(inodes) {
+		inoInfos[inoInfo.Inode] = inoInfo
+	}
+	var xattrs []*proto.XAttrInfo
+	if xattrs, err = v.mw.BatchGetXAttr(inodes, []string{XAttrKeyOSSETag, XAttrKeyOSSETagDeprecated}); err != nil {
+		return
+	}
+	var etags = make(map[uint64]string, len(xattrs))
+	for _, xattr := range xattrs {
+		var rawETag = string(xattr.Get(XAttrKeyOSSETag))
+		if len(rawETag) == 0 {
+			rawETag = string(xattr.Get(XAttrKeyOSSETagDeprecated))
+		}
+		if len(rawETag) > 0 {
+			etags[xattr.Inode] = ParseETagValue(rawETag).ETag()
+		}
+	}
+	for _, version := range versions {
+		if version.DeleteMarker {
+			continue
+		}
+		if inoInfo, ok := inoInfos[version.Inode]; ok {
+			version.Size = int64(inoInfo.Size)
+			version.ModifyTime = inoInfo.ModifyTime
+		}
+		version.ETag = etags[version.Inode]
+	}
+	return
+}
@@ -0,0 +1,129 @@
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/object-lifecycle-mgmt.html
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/errors"
+)
+
+const (
+	LifecycleStatusEnabled  = "Enabled"
+	LifecycleStatusDisabled = "Disabled"
+
+	MaxLifecycleRules = 1000
+)
+
+type LifecycleConfiguration struct {
+	XMLName xml.Name         `xml:"LifecycleConfiguration" json:"xml_name"`
+	Rules   []*LifecycleRule `xml:"Rule" json:"rules"`
+}
+
+type LifecycleRule struct {
+	ID     string           `xml:"ID,omitempty" json:"id"`
+	Status string           `xml:"Status" json:"status"`
+	Prefix string           `xml:"Prefix,omitempty" json:"prefix,omitempty"` // Deprecated, replaced by Filter
+	Filter *LifecycleFilter `xml:"Filter,omitempty" json:"filter,omitempty"`
+
+	Expiration                     *LifecycleExpiration            `xml:"Expiration,omitempty" json:"expiration,omitempty"`
+	AbortIncompleteMultipartUpload *AbortIncompleteMultipartUpload `xml:"AbortIncompleteMultipartUpload,omitempty" json:"abort_multipart,omitempty"`
+}
+
+type LifecycleFilter struct {
+	Prefix string `xml:"Prefix" json:"prefix"`
+}
+
+type LifecycleExpiration struct {
+	Days int    `xml:"Days,omitempty" json:"days,omitempty"`
+	Date string `xml:"Date,omitempty" json:"date,omitempty"`
+}
+
+type AbortIncompleteMultipartUpload struct {
+	DaysAfterInitiation int `xml:"DaysAfterInitiation" json:"days_after_initiation"`
+}
+
+func (rule *LifecycleRule) prefix() string {
+	if rule.Filter != nil {
+		return rule.Filter.Prefix
+	}
+	return rule.Prefix
+}
+
+func (rule *LifecycleRule) validate() bool {
+	if rule.Status != LifecycleStatusEnabled && rule.Status != LifecycleStatusDisabled {
+		return false
+	}
+	if rule.Expiration == nil && rule.AbortIncompleteMultipartUpload == nil {
+		return false
+	}
+	if expiration := rule.Expiration; expiration != nil {
+		if (expiration.Days > 0) == (expiration.Date != "") {
+			return false
+		}
+		if expiration.Date != "" {
+			if _, err := time.Parse(time.RFC3339, expiration.Date); err != nil {
+				return false
+			}
+		}
+	}
+	if abort := rule.AbortIncompleteMultipartUpload; abort != nil && abort.DaysAfterInitiation <= 0 {
+		return false
+	}
+	return true
+}
+
+// expired checks if the object modified at the specified time is expired by the rule.
+func (expiration *LifecycleExpiration) expired(modifyTime, now time.Time) bool {
+	if expiration.Days > 0 {
+		return now.Sub(modifyTime) > time.Duration(expiration.Days)*24*time.Hour
+	}
+	date, err := time.Parse(time.RFC3339, expiration.Date)
+	return err == nil && now.After(date)
+}
+
+func (lifecycle *LifecycleConfiguration) validate() bool {
+	if len(lifecycle.Rules) == 0 || len(lifecycle.Rules) > MaxLifecycleRules {
+		return false
+	}
+	var ids = make(map[string]struct{})
+	for _, rule := range lifecycle.Rules {
+		if !rule.validate() {
+			return false
+		}
+		if rule.ID == "" {
+			continue
+		}
+		if _, ok := ids[rule.ID]; ok {
+			return false
+		}
+		ids[rule.ID] = struct{}{}
+	}
+	return true
+}
+
+func parseLifecycleConfig(bytes []byte) (lifecycle *LifecycleConfiguration, err error) {
+	lifecycle = &LifecycleConfiguration{}
+	if err = xml.Unmarshal(bytes, lifecycle); err != nil {
+		return
+	}
+	if ok := lifecycle.validate(); !ok {
+		return nil, errors.New("invalid lifecycle configuration")
+	}
+	return
+}
+
+func storeBucketLifecycle(bytes []byte, vol *Volume) (err error) {
+	if err = vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSLifecycle, bytes); err != nil {
+		return
+	}
+	return nil
+}
+
+func deleteBucketLifecycle(vol *Volume) (err error) {
+	if err = vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSLifecycle); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/object-lifecycle-mgmt.html
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketLifecycleConfiguration.html
+func (o *ObjectNode) getBucketLifecycleHandler(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var lifecycle *LifecycleConfiguration
+	if lifecycle, err = vol.loadBucketLifecycle(); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if lifecycle == nil {
+		_ = NoSuchLifecycleConfiguration.ServeResponse(w, r)
+		return
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(lifecycle); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	_, _ = w.Write(data)
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketLifecycleConfiguration.html
+func (o *ObjectNode) putBucketLifecycleHandler(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil && err != io.EOF {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	var lifecycle *LifecycleConfiguration
+	if lifecycle, err = parseLifecycleConfig(bytes); err != nil {
+		_ = MalformedXML.ServeResponse(w, r)
+		return
+	}
+
+	var newBytes []byte
+	if newBytes, err = json.Marshal(lifecycle); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if err = storeBucketLifecycle(newBytes, vol); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	log.LogInfof("Audit: put bucket lifecycle: requestID(%v) remote(%v) volume(%v) rules(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), len(lifecycle.Rules))
+
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketLifecycle.html
+func (o *ObjectNode) deleteBucketLifecycleHandler(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	if err = deleteBucketLifecycle(vol); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	log.LogInfof("Audit: delete bucket lifecycle: requestID(%v) remote(%v) volume(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name())
+
+	w.WriteHeader(http.StatusNoContent)
+	return
+}
@@ -0,0 +1,59 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLifecycleConfig(t *testing.T) {
+	var samples = map[string]bool{
+		"<LifecycleConfiguration><Rule><ID>r1</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter>" +
+			"<Expiration><Days>30</Days></Expiration></Rule></LifecycleConfiguration>": true,
+		"<LifecycleConfiguration><Rule><Status>Enabled</Status><Prefix>tmp/</Prefix>" +
+			"<AbortIncompleteMultipartUpload><DaysAfterInitiation>7</DaysAfterInitiation></AbortIncompleteMultipartUpload>" +
+			"</Rule></LifecycleConfiguration>": true,
+		"<LifecycleConfiguration><Rule><Status>Enabled</Status>" +
+			"<Expiration><Date>2020-01-01T00:00:00Z</Date></Expiration></Rule></LifecycleConfiguration>": true,
+		"<LifecycleConfiguration><Rule><Status>Enabled</Status></Rule></LifecycleConfiguration>": false,
+		"<LifecycleConfiguration><Rule><Status>On</Status>" +
+			"<Expiration><Days>1</Days></Expiration></Rule></LifecycleConfiguration>": false,
+		"<LifecycleConfiguration><Rule><Status>Enabled</Status>" +
+			"<Expiration><Days>1</Days><Date>2020-01-01T00:00:00Z</Date></Expiration></Rule></LifecycleConfiguration>": false,
+		"<LifecycleConfiguration></LifecycleConfiguration>": false,
+	}
+	for raw, valid := range samples {
+		lifecycle, err := parseLifecycleConfig([]byte(raw))
+		if valid && (err != nil || lifecycle == nil) {
+			t.Fatalf("parse lifecycle config fail: raw(%v) err(%v)", raw, err)
+		}
+		if !valid && err == nil {
+			t.Fatalf("invalid lifecycle config accepted: raw(%v)", raw)
+		}
+	}
+}
+
+func TestLifecycleExpiration(t *testing.T) {
+	var now = time.Now()
+	var byDays = &LifecycleExpiration{Days: 2}
+	if byDays.expired(now.Add(-24*time.Hour), now) || !byDays.expired(now.Add(-72*time.Hour), now) {
+		t.Fatalf("expiration by days mismatch")
+	}
+	var byDate = &LifecycleExpiration{Date: now.Add(time.Hour).UTC().Format(time.RFC3339)}
+	if byDate.expired(now.Add(-72*time.Hour), now) || !byDate.expired(now, now.Add(2*time.Hour)) {
+		t.Fatalf("expiration by date mismatch")
+	}
+}
@@ -0,0 +1,230 @@
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/NotificationHowTo.html
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/errors"
+)
+
+const (
+	NotificationEventObjectCreatedAll    = "s3:ObjectCreated:*"
+	NotificationEventObjectCreatedPut    = "s3:ObjectCreated:Put"
+	NotificationEventObjectRemovedAll    = "s3:ObjectRemoved:*"
+	NotificationEventObjectRemovedDelete = "s3:ObjectRemoved:Delete"
+
+	NotificationFilterPrefix = "prefix"
+	NotificationFilterSuffix = "suffix"
+
+	MaxNotificationConfigurations = 100
+)
+
+// The configuration refers to an unknown target, or it has the events or the filters not supported.
+var errInvalidNotificationConfig = errors.New("invalid notification configuration")
+
+type NotificationConfiguration struct {
+	XMLName xml.Name                    `xml:"NotificationConfiguration" json:"xml_name"`
+	Queues  []*NotificationTargetConfig `xml:"QueueConfiguration,omitempty" json:"queues,omitempty"`
+	Topics  []*NotificationTargetConfig `xml:"TopicConfiguration,omitempty" json:"topics,omitempty"`
+}
+
+// NotificationTargetConfig is a queue or a topic configuration, the events are published
+// to the target Queue or Topic, which is the ARN of a target configured on the object nodes.
+type NotificationTargetConfig struct {
+	Id     string              `xml:"Id,omitempty" json:"id,omitempty"`
+	Queue  string              `xml:"Queue,omitempty" json:"queue,omitempty"`
+	Topic  string              `xml:"Topic,omitempty" json:"topic,omitempty"`
+	Events []string            `xml:"Event" json:"events"`
+	Filter *NotificationFilter `xml:"Filter,omitempty" json:"filter,omitempty"`
+}
+
+type NotificationFilter struct {
+	S3Key NotificationKeyFilter `xml:"S3Key" json:"s3_key"`
+}
+
+type NotificationKeyFilter struct {
+	FilterRules []*NotificationFilterRule `xml:"FilterRule" json:"rules"`
+}
+
+type NotificationFilterRule struct {
+	Name  string `xml:"Name" json:"name"`
+	Value string `xml:"Value" json:"value"`
+}
+
+func (config *NotificationTargetConfig) arn() string {
+	if config.Queue != "" {
+		return config.Queue
+	}
+	return config.Topic
+}
+
+func (config *NotificationTargetConfig) validate(n *notifier) bool {
+	if (config.Queue == "") == (config.Topic == "") || !n.hasTarget(config.arn()) || len(config.Events) == 0 {
+		return false
+	}
+	for _, event := range config.Events {
+		switch event {
+		case NotificationEventObjectCreatedAll, NotificationEventObjectCreatedPut,
+			NotificationEventObjectRemovedAll, NotificationEventObjectRemovedDelete:
+		default:
+			return false
+		}
+	}
+	if config.Filter != nil {
+		var names = make(map[string]struct{})
+		for _, rule := range config.Filter.S3Key.FilterRules {
+			name := strings.ToLower(rule.Name)
+			if name != NotificationFilterPrefix && name != NotificationFilterSuffix {
+				return false
+			}
+			if _, ok := names[name]; ok {
+				return false
+			}
+			names[name] = struct{}{}
+		}
+	}
+	return true
+}
+
+// match checks if the event of the object is published by the configuration.
+func (config *NotificationTargetConfig) match(eventName, key string) bool {
+	if config.Filter != nil {
+		for _, rule := range config.Filter.S3Key.FilterRules {
+			switch strings.ToLower(rule.Name) {
+			case NotificationFilterPrefix:
+				if !strings.HasPrefix(key, rule.Value) {
+					return false
+				}
+			case NotificationFilterSuffix:
+				if !strings.HasSuffix(key, rule.Value) {
+					return false
+				}
+			}
+		}
+	}
+	for _, event := range config.Events {
+		if event == eventName || strings.HasSuffix(event, "*") && strings.HasPrefix(eventName, strings.TrimSuffix(event, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+func (notification *NotificationConfiguration) configs() []*NotificationTargetConfig {
+	return append(append([]*NotificationTargetConfig{}, notification.Queues...), notification.Topics...)
+}
+
+func (notification *NotificationConfiguration) validate(n *notifier) bool {
+	var configs = notification.configs()
+	if len(configs) > MaxNotificationConfigurations {
+		return false
+	}
+	var ids = make(map[string]struct{})
+	for _, config := range configs {
+		if !config.validate(n) {
+			return false
+		}
+		if config.Id == "" {
+			continue
+		}
+		if _, ok := ids[config.Id]; ok {
+			return false
+		}
+		ids[config.Id] = struct{}{}
+	}
+	return true
+}
+
+// parseNotificationConfig parses the notification configuration, an empty one disables the notifications.
+func parseNotificationConfig(bytes []byte, n *notifier) (notification *NotificationConfiguration, err error) {
+	notification = &NotificationConfiguration{}
+	if err = xml.Unmarshal(bytes, notification); err != nil {
+		return
+	}
+	if ok := notification.validate(n); !ok {
+		return nil, errInvalidNotificationConfig
+	}
+	return
+}
+
+func storeBucketNotification(bytes []byte, vol *Volume) (err error) {
+	if err = vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSNotification, bytes); err != nil {
+		return
+	}
+	return nil
+}
+
+func deleteBucketNotification(vol *Volume) (err error) {
+	if err = vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSNotification); err != nil {
+		return err
+	}
+	return nil
+}
+
+// notificationMessage is the message published for the events, in the format of the event messages of S3.
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/notification-content-structure.html
+type notificationMessage struct {
+	Records []*notificationRecord `json:"Records"`
+}
+
+type notificationRecord struct {
+	EventVersion string               `json:"eventVersion"`
+	EventSource  string               `json:"eventSource"`
+	AwsRegion    string               `json:"awsRegion"`
+	EventTime    string               `json:"eventTime"`
+	EventName    string               `json:"eventName"`
+	S3           notificationS3Entity `json:"s3"`
+}
+
+type notificationS3Entity struct {
+	SchemaVersion   string                   `json:"s3SchemaVersion"`
+	ConfigurationId string                   `json:"configurationId"`
+	Bucket          notificationBucketEntity `json:"bucket"`
+	Object          notificationObjectEntity `json:"object"`
+}
+
+type notificationBucketEntity struct {
+	Name string `json:"name"`
+	Arn  string `json:"arn"`
+}
+
+type notificationObjectEntity struct {
+	Key       string `json:"key"`
+	Size      uint64 `json:"size,omitempty"`
+	Sequencer string `json:"sequencer"`
+}
+
+// objectEvent is an event of an object derived from the events of the volume.
+type objectEvent struct {
+	name     string // the name of the event, such as s3:ObjectCreated:Put
+	key      string
+	inode    uint64
+	size     uint64
+	sequence uint64
+	time     int64
+}
+
+func newNotificationRecord(region, bucket, configId string, event *objectEvent) *notificationRecord {
+	return &notificationRecord{
+		EventVersion: "2.1",
+		EventSource:  "chubaofs:s3",
+		AwsRegion:    region,
+		EventTime:    time.Unix(event.time, 0).UTC().Format(time.RFC3339),
+		EventName:    strings.TrimPrefix(event.name, "s3:"),
+		S3: notificationS3Entity{
+			SchemaVersion:   "1.0",
+			ConfigurationId: configId,
+			Bucket:          notificationBucketEntity{Name: bucket, Arn: "arn:aws:s3:::" + bucket},
+			Object: notificationObjectEntity{
+				Key:       url.QueryEscape(event.key),
+				Size:      event.size,
+				Sequencer: fmt.Sprintf("%016X", event.sequence),
+			},
+		},
+	}
+}
@@ -0,0 +1,100 @@
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/NotificationHowTo.html
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketNotificationConfiguration.html
+func (o *ObjectNode) getBucketNotificationHandler(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var notification *NotificationConfiguration
+	if notification, err = vol.loadBucketNotification(); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if notification == nil {
+		notification = &NotificationConfiguration{}
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(notification); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	_, _ = w.Write(data)
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketNotificationConfiguration.html
+func (o *ObjectNode) putBucketNotificationHandler(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil && err != io.EOF {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	var notification *NotificationConfiguration
+	if notification, err = parseNotificationConfig(bytes, o.notifier); err != nil {
+		if err == errInvalidNotificationConfig {
+			_ = InvalidArgument.ServeResponse(w, r)
+			return
+		}
+		_ = MalformedXML.ServeResponse(w, r)
+		return
+	}
+
+	// an empty configuration disables the notifications
+	var configs = notification.configs()
+	if len(configs) == 0 {
+		err = deleteBucketNotification(vol)
+	} else {
+		var newBytes []byte
+		if newBytes, err = json.Marshal(notification); err != nil {
+			_ = InternalErrorCode(err).ServeResponse(w, r)
+			return
+		}
+		err = storeBucketNotification(newBytes, vol)
+	}
+	if err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	log.LogInfof("Audit: put bucket notification: requestID(%v) remote(%v) volume(%v) configurations(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), len(configs))
+
+	return
+}
@@ -0,0 +1,157 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/errors"
+)
+
+// The types of the targets the notifications are published to.
+const (
+	// Posts the event messages to the endpoint one by one.
+	NotificationTargetWebhook = "webhook"
+	// Produces the event messages to the topic of Kafka through the Kafka REST Proxy at the endpoint.
+	NotificationTargetKafka = "kafka"
+
+	notificationSendTimeout = 10 * time.Second
+	contentTypeKafkaJSON    = "application/vnd.kafka.json.v2+json"
+)
+
+// NotificationTarget defines a target of the notifications configured on the object nodes, which is
+// referred to by its ARN in the notification configurations of the buckets.
+type NotificationTarget struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Endpoint string `json:"endpoint"`
+	Topic    string `json:"topic,omitempty"`
+}
+
+func (t *NotificationTarget) ARN() string {
+	return fmt.Sprintf("arn:chubaofs:sqs::%s:%s", t.ID, t.Type)
+}
+
+type kafkaRecord struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+type kafkaRecords struct {
+	Records []*kafkaRecord `json:"records"`
+}
+
+// notifier publishes the notifications of the buckets to the targets.
+type notifier struct {
+	targets map[string]*NotificationTarget // mapping: ARN -> target
+	nodeID  string                         // identifies the object node holding the lease of the publishing
+	region  string
+	client  *http.Client
+}
+
+func newNotifier(targets []*NotificationTarget, nodeID string) (n *notifier, err error) {
+	n = &notifier{
+		targets: make(map[string]*NotificationTarget),
+		nodeID:  nodeID,
+		client:  &http.Client{Timeout: notificationSendTimeout},
+	}
+	for _, target := range targets {
+		if target.ID == "" || target.Endpoint == "" {
+			return nil, errors.NewErrorf("invalid notification target: id(%v) endpoint(%v)", target.ID, target.Endpoint)
+		}
+		switch target.Type {
+		case NotificationTargetWebhook:
+		case NotificationTargetKafka:
+			if target.Topic == "" {
+				return nil, errors.NewErrorf("notification target %v: no topic", target.ID)
+			}
+		default:
+			return nil, errors.NewErrorf("notification target %v: unknown type %v", target.ID, target.Type)
+		}
+		if _, ok := n.targets[target.ARN()]; ok {
+			return nil, errors.NewErrorf("duplicate notification target: %v", target.ID)
+		}
+		n.targets[target.ARN()] = target
+	}
+	return
+}
+
+func (n *notifier) hasTarget(arn string) bool {
+	if n == nil {
+		return false
+	}
+	_, ok := n.targets[arn]
+	return ok
+}
+
+// publish sends the records of the bucket to the target in order.
+func (n *notifier) publish(arn, bucket string, records []*notificationRecord) (err error) {
+	target, ok := n.targets[arn]
+	if !ok {
+		return errors.NewErrorf("unknown notification target: %v", arn)
+	}
+	switch target.Type {
+	case NotificationTargetWebhook:
+		for _, record := range records {
+			var data []byte
+			if data, err = json.Marshal(&notificationMessage{Records: []*notificationRecord{record}}); err != nil {
+				return
+			}
+			if err = n.post(target.Endpoint, HeaderValueContentTypeJSON, data); err != nil {
+				return
+			}
+		}
+	case NotificationTargetKafka:
+		var message = &kafkaRecords{}
+		for _, record := range records {
+			key, err := url.QueryUnescape(record.S3.Object.Key)
+			if err != nil {
+				key = record.S3.Object.Key
+			}
+			message.Records = append(message.Records, &kafkaRecord{
+				Key:   bucket + "/" + key,
+				Value: &notificationMessage{Records: []*notificationRecord{record}},
+			})
+		}
+		var data []byte
+		if data, err = json.Marshal(message); err != nil {
+			return
+		}
+		err = n.post(strings.TrimSuffix(target.Endpoint, "/")+"/topics/"+target.Topic, contentTypeKafkaJSON, data)
+	}
+	return
+}
+
+func (n *notifier) post(endpoint, contentType string, data []byte) (err error) {
+	var resp *http.Response
+	if resp, err = n.client.Post(endpoint, contentType, bytes.NewReader(data)); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.NewErrorf("post to %v: status(%v) body(%v)", endpoint, resp.StatusCode, string(body))
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return
+}
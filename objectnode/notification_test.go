@@ -0,0 +1,155 @@
+package objectnode
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestParseNotificationConfig(t *testing.T) {
+	n, err := newNotifier([]*NotificationTarget{{ID: "1", Type: NotificationTargetWebhook, Endpoint: "http://127.0.0.1"}}, "node")
+	if err != nil {
+		t.Fatalf("new notifier fail: err(%v)", err)
+	}
+	var samples = map[string]bool{
+		"<NotificationConfiguration><QueueConfiguration><Id>c1</Id><Queue>arn:chubaofs:sqs::1:webhook</Queue>" +
+			"<Event>s3:ObjectCreated:*</Event><Filter><S3Key><FilterRule><Name>prefix</Name><Value>logs/</Value></FilterRule>" +
+			"</S3Key></Filter></QueueConfiguration></NotificationConfiguration>": true,
+		"<NotificationConfiguration><TopicConfiguration><Topic>arn:chubaofs:sqs::1:webhook</Topic>" +
+			"<Event>s3:ObjectRemoved:Delete</Event></TopicConfiguration></NotificationConfiguration>": true,
+		"<NotificationConfiguration></NotificationConfiguration>": true,
+		"<NotificationConfiguration><QueueConfiguration><Queue>arn:chubaofs:sqs::2:webhook</Queue>" +
+			"<Event>s3:ObjectCreated:*</Event></QueueConfiguration></NotificationConfiguration>": false,
+		"<NotificationConfiguration><QueueConfiguration><Queue>arn:chubaofs:sqs::1:webhook</Queue>" +
+			"<Event>s3:ObjectRestore:Post</Event></QueueConfiguration></NotificationConfiguration>": false,
+		"<NotificationConfiguration><QueueConfiguration><Queue>arn:chubaofs:sqs::1:webhook</Queue>" +
+			"<Event>s3:ObjectCreated:*</Event><Filter><S3Key><FilterRule><Name>infix</Name><Value>a</Value></FilterRule>" +
+			"</S3Key></Filter></QueueConfiguration></NotificationConfiguration>": false,
+	}
+	for raw, valid := range samples {
+		notification, err := parseNotificationConfig([]byte(raw), n)
+		if valid && (err != nil || notification == nil) {
+			t.Fatalf("parse notification config fail: raw(%v) err(%v)", raw, err)
+		}
+		if !valid && err != errInvalidNotificationConfig {
+			t.Fatalf("invalid notification config accepted: raw(%v) err(%v)", raw, err)
+		}
+	}
+}
+
+func TestNotificationConfigMatch(t *testing.T) {
+	var config = &NotificationTargetConfig{
+		Events: []string{NotificationEventObjectCreatedAll},
+		Filter: &NotificationFilter{S3Key: NotificationKeyFilter{FilterRules: []*NotificationFilterRule{
+			{Name: "Prefix", Value: "logs/"}, {Name: "suffix", Value: ".gz"}}}},
+	}
+	var samples = []struct {
+		event string
+		key   string
+		match bool
+	}{
+		{NotificationEventObjectCreatedPut, "logs/a.gz", true},
+		{NotificationEventObjectRemovedDelete, "logs/a.gz", false},
+		{NotificationEventObjectCreatedPut, "logs/a.txt", false},
+		{NotificationEventObjectCreatedPut, "data/a.gz", false},
+	}
+	for _, sample := range samples {
+		if config.match(sample.event, sample.key) != sample.match {
+			t.Fatalf("match mismatch: event(%v) key(%v) expect(%v)", sample.event, sample.key, sample.match)
+		}
+	}
+}
+
+func TestNotificationWorkerConvert(t *testing.T) {
+	var w = newNotificationWorker()
+	w.dirs = map[uint64]*pathEntry{10: {parent: proto.RootIno, name: "logs"}}
+	var dirMode = proto.Mode(os.ModeDir | 0755)
+	var fileMode = proto.Mode(0644)
+	var events = []*proto.VolumeEvent{
+		{Index: 1, Type: proto.EventCreate, Inode: 11, Mode: dirMode, ParentID: 10, Name: "2020"},
+		{Index: 2, Type: proto.EventCreate, Inode: 100, Mode: fileMode, ParentID: 11, Name: "a.log"},
+		{Index: 3, Type: proto.EventWriteClose, Inode: 100},
+		{Index: 4, Type: proto.EventRename, Inode: 100, Mode: fileMode, ParentID: 10, Name: "b.log", OldParentID: 11, OldName: "a.log"},
+		{Index: 5, Type: proto.EventWriteClose, Inode: 101},
+		{Index: 6, Type: proto.EventDelete, Inode: 11, Mode: dirMode, ParentID: 10, Name: "2020"},
+		{Index: 7, Type: proto.EventDelete, Inode: 102, Mode: fileMode, ParentID: 11, Name: "c.log"},
+	}
+	var expected = []string{
+		NotificationEventObjectCreatedPut + " logs/2020/a.log",
+		NotificationEventObjectCreatedPut + " logs/2020/a.log",
+		NotificationEventObjectRemovedDelete + " logs/2020/a.log",
+		NotificationEventObjectCreatedPut + " logs/b.log",
+		NotificationEventObjectRemovedDelete + " logs/2020/c.log",
+	}
+	var actual []string
+	for _, event := range events {
+		converted, ok := w.convert(event)
+		if !ok {
+			t.Fatalf("convert event fail: event(%v)", *event)
+		}
+		for _, e := range converted {
+			actual = append(actual, e.name+" "+e.key)
+		}
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("converted events mismatch: expect(%v) actual(%v)", expected, actual)
+	}
+	if _, ok := w.convert(&proto.VolumeEvent{Type: proto.EventCreate, Inode: 103, Mode: fileMode, ParentID: 12, Name: "d"}); ok {
+		t.Fatalf("the key under an unknown directory is resolved")
+	}
+	w.prune(events[0].Time + int64(notificationPathTTL.Seconds()) + 1)
+	if _, ok := w.dirs[11]; ok || len(w.files) != 0 {
+		t.Fatalf("expired paths are not pruned: dirs(%v) files(%v)", w.dirs, w.files)
+	}
+}
+
+func TestNotifierPublish(t *testing.T) {
+	var bodies = make(map[string][]byte)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies[r.URL.Path+" "+r.Header.Get(HeaderNameContentType)] = body
+	}))
+	defer server.Close()
+
+	n, err := newNotifier([]*NotificationTarget{
+		{ID: "1", Type: NotificationTargetWebhook, Endpoint: server.URL + "/hook"},
+		{ID: "2", Type: NotificationTargetKafka, Endpoint: server.URL, Topic: "events"},
+	}, "node")
+	if err != nil {
+		t.Fatalf("new notifier fail: err(%v)", err)
+	}
+	var record = newNotificationRecord("cfs", "bucket", "c1",
+		&objectEvent{name: NotificationEventObjectCreatedPut, key: "a b", size: 10, sequence: 1, time: 1})
+	for _, arn := range []string{"arn:chubaofs:sqs::1:webhook", "arn:chubaofs:sqs::2:kafka"} {
+		if err = n.publish(arn, "bucket", []*notificationRecord{record}); err != nil {
+			t.Fatalf("publish to %v fail: err(%v)", arn, err)
+		}
+	}
+
+	var message notificationMessage
+	if err = json.Unmarshal(bodies["/hook "+HeaderValueContentTypeJSON], &message); err != nil || len(message.Records) != 1 {
+		t.Fatalf("webhook message mismatch: body(%s) err(%v)", bodies["/hook "+HeaderValueContentTypeJSON], err)
+	}
+	if r := message.Records[0]; r.EventName != "ObjectCreated:Put" || r.S3.Object.Key != "a+b" || r.S3.Bucket.Name != "bucket" {
+		t.Fatalf("webhook record mismatch: %v", *r)
+	}
+	var records struct {
+		Records []struct {
+			Key   string              `json:"key"`
+			Value notificationMessage `json:"value"`
+		} `json:"records"`
+	}
+	if err = json.Unmarshal(bodies["/topics/events "+contentTypeKafkaJSON], &records); err != nil ||
+		len(records.Records) != 1 || records.Records[0].Key != "bucket/a b" {
+		t.Fatalf("kafka records mismatch: body(%s) err(%v)", bodies["/topics/events "+contentTypeKafkaJSON], err)
+	}
+	if err = n.publish("arn:chubaofs:sqs::3:webhook", "bucket", []*notificationRecord{record}); err == nil {
+		t.Fatalf("publish to unknown target succeeded")
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"syscall"
 
 	"github.com/gorilla/mux"
 
@@ -301,6 +302,28 @@ func (o *ObjectNode) policyCheck(f http.HandlerFunc) http.HandlerFunc {
 			}
 		}
 
+		// The ACL of object takes the place of the bucket ACL for the object actions if exists.
+		if vol != nil && param.Object() != "" && isObjectACLAction(param.Action()) {
+			var objectACL *AccessControlPolicy
+			if objectACL, err = vol.loadObjectACL(param.Object()); err != nil && err != syscall.ENOENT {
+				log.LogErrorf("policyCheck: load object ACL fail: requestID(%v) volume(%v) object(%v) err(%v)",
+					GetRequestID(r), param.Bucket(), param.Object(), err)
+				allowed = false
+				ec = InternalErrorCode(err)
+				return
+			}
+			err = nil
+			if objectACL != nil && !objectACL.IsAclEmpty() {
+				allowed = objectACL.IsObjectAllowed(param, isOwner)
+				if !allowed {
+					log.LogWarnf("policyCheck: object ACL not allowed: requestID(%v) userID(%v) accessKey(%v) volume(%v) object(%v) action(%v)",
+						GetRequestID(r), userInfo, param.AccessKey(), param.Bucket(), param.Object(), param.Action())
+					return
+				}
+				acl = nil
+			}
+		}
+
 		if vol != nil && acl != nil && !acl.IsAclEmpty() {
 			allowed = acl.IsAllowed(param, isOwner)
 			if !allowed {
@@ -0,0 +1,224 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errInvalidRange = errors.New("invalid range")
+	errNoOverlap    = errors.New("range does not overlap the object")
+)
+
+// httpRange is a byte range of the object to read, specified by the Range header.
+type httpRange struct {
+	start, length int64
+}
+
+func (ra httpRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", ra.start, ra.start+ra.length-1, size)
+}
+
+func (ra httpRange) mimeHeader(contentType string, size int64) textproto.MIMEHeader {
+	return textproto.MIMEHeader{
+		HeaderNameContentRange: {ra.contentRange(size)},
+		HeaderNameContentType:  {contentType},
+	}
+}
+
+// parseRange parses the Range header in the form of 'bytes=first-last,first-,-suffix' against
+// the size of the object. The ranges not overlapping the object are dropped, errNoOverlap is
+// returned if none of the ranges overlaps, and errInvalidRange if the header is malformed.
+// Reference: https://tools.ietf.org/html/rfc7233#section-2.1
+func parseRange(value string, size int64) ([]httpRange, error) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(value, prefix) {
+		return nil, errInvalidRange
+	}
+	var ranges []httpRange
+	var noOverlap bool
+	for _, spec := range strings.Split(value[len(prefix):], ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		var hyphenIndex = strings.Index(spec, "-")
+		if hyphenIndex < 0 {
+			return nil, errInvalidRange
+		}
+		var first, last = strings.TrimSpace(spec[:hyphenIndex]), strings.TrimSpace(spec[hyphenIndex+1:])
+		var ra httpRange
+		if first == "" {
+			// suffix range '-N' selects the last N bytes of the object
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n < 0 {
+				return nil, errInvalidRange
+			}
+			if n == 0 {
+				noOverlap = true
+				continue
+			}
+			if n > size {
+				n = size
+			}
+			ra.start, ra.length = size-n, n
+		} else {
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || start < 0 {
+				return nil, errInvalidRange
+			}
+			if start >= size {
+				noOverlap = true
+				continue
+			}
+			ra.start = start
+			if last == "" {
+				ra.length = size - start
+			} else {
+				end, err := strconv.ParseInt(last, 10, 64)
+				if err != nil || end < start {
+					return nil, errInvalidRange
+				}
+				if end >= size {
+					end = size - 1
+				}
+				ra.length = end - start + 1
+			}
+		}
+		ranges = append(ranges, ra)
+	}
+	if len(ranges) == 0 {
+		if noOverlap {
+			return nil, errNoOverlap
+		}
+		return nil, errInvalidRange
+	}
+	return ranges, nil
+}
+
+type countingWriter int64
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+// rangesMIMESize returns the length of the multipart/byteranges body of the ranges.
+func rangesMIMESize(ranges []httpRange, contentType string, size int64) int64 {
+	var w countingWriter
+	var mw = multipart.NewWriter(&w)
+	var length int64
+	for _, ra := range ranges {
+		_, _ = mw.CreatePart(ra.mimeHeader(contentType, size))
+		length += ra.length
+	}
+	_ = mw.Close()
+	return length + int64(w)
+}
+
+// etagMatch returns true if the entity tag list of the If-Match or If-None-Match header
+// contains the ETag of the object, the weak tags are compared as strong ones.
+func etagMatch(value, etag string) bool {
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strings.Trim(strings.TrimPrefix(tag, "W/"), "\"") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkObjectPreconditions evaluates the conditional headers of GetObject and HeadObject
+// in the order of RFC 7232, returns PreconditionFailed or NotModified if a condition fails.
+// The invalid dates are ignored as the RFC requires.
+// Reference: https://tools.ietf.org/html/rfc7232#section-6
+func checkObjectPreconditions(w http.ResponseWriter, r *http.Request, fileInfo *FSFileInfo) *ErrorCode {
+	var modifyTime = fileInfo.ModifyTime.Truncate(time.Second)
+	var match = r.Header.Get(HeaderNameIfMatch)
+	if match != "" {
+		if !etagMatch(match, fileInfo.ETag) {
+			return PreconditionFailed
+		}
+	} else if unmodified := r.Header.Get(HeaderNameIfUnmodifiedSince); unmodified != "" {
+		if t, err := parseTimeRFC1123(unmodified); err == nil && modifyTime.After(t) {
+			return PreconditionFailed
+		}
+	}
+
+	var notModified bool
+	if noneMatch := r.Header.Get(HeaderNameIfNoneMatch); noneMatch != "" {
+		notModified = etagMatch(noneMatch, fileInfo.ETag)
+	} else if modified := r.Header.Get(HeaderNameIfModifiedSince); modified != "" {
+		if t, err := parseTimeRFC1123(modified); err == nil && !modifyTime.After(t) {
+			notModified = true
+		}
+	}
+	if notModified {
+		// the validators are required in the response of 304
+		w.Header()[HeaderNameLastModified] = []string{formatTimeRFC1123(fileInfo.ModifyTime)}
+		if len(fileInfo.ETag) > 0 {
+			w.Header()[HeaderNameETag] = []string{wrapUnescapedQuot(fileInfo.ETag)}
+		}
+		return NotModified
+	}
+	return nil
+}
+
+// checkIfRange returns true if the Range header should be honored by the If-Range header,
+// which holds either the ETag or the last modified time of the object.
+func checkIfRange(r *http.Request, fileInfo *FSFileInfo) bool {
+	var ifRange = r.Header.Get(HeaderNameIfRange)
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, "\"") {
+		return strings.Trim(ifRange, "\"") == fileInfo.ETag
+	}
+	t, err := parseTimeRFC1123(ifRange)
+	return err == nil && fileInfo.ModifyTime.Truncate(time.Second).Equal(t)
+}
+
+// writeRanges writes the multipart/byteranges response of the ranges, and reads each range
+// by the read function.
+func writeRanges(w http.ResponseWriter, ranges []httpRange, size int64,
+	read func(w io.Writer, offset, size uint64) error) (err error) {
+	var contentType = w.Header().Get(HeaderNameContentType)
+	var mw = multipart.NewWriter(w)
+	w.Header()[HeaderNameContentType] = []string{"multipart/byteranges; boundary=" + mw.Boundary()}
+	w.Header()[HeaderNameContentLength] = []string{strconv.FormatInt(rangesMIMESize(ranges, contentType, size), 10)}
+	w.WriteHeader(http.StatusPartialContent)
+	for _, ra := range ranges {
+		var part io.Writer
+		if part, err = mw.CreatePart(ra.mimeHeader(contentType, size)); err != nil {
+			return
+		}
+		if err = read(part, uint64(ra.start), uint64(ra.length)); err != nil {
+			return
+		}
+	}
+	return mw.Close()
+}
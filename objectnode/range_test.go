@@ -0,0 +1,147 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseRange(t *testing.T) {
+	var cases = []struct {
+		value  string
+		ranges []httpRange
+		err    error
+	}{
+		{"bytes=0-9", []httpRange{{0, 10}}, nil},
+		{"bytes=90-", []httpRange{{90, 10}}, nil},
+		{"bytes=-10", []httpRange{{90, 10}}, nil},
+		{"bytes=-200", []httpRange{{0, 100}}, nil},
+		{"bytes=50-200", []httpRange{{50, 50}}, nil},
+		{"bytes=0-0, 10-19,-5", []httpRange{{0, 1}, {10, 10}, {95, 5}}, nil},
+		{"bytes=0-9,100-", []httpRange{{0, 10}}, nil},
+		{"bytes=100-", nil, errNoOverlap},
+		{"bytes=-0", nil, errNoOverlap},
+		{"bytes=9-0", nil, errInvalidRange},
+		{"bytes=a-b", nil, errInvalidRange},
+		{"bytes=10", nil, errInvalidRange},
+		{"items=0-9", nil, errInvalidRange},
+	}
+	for _, c := range cases {
+		ranges, err := parseRange(c.value, 100)
+		if err != c.err || !reflect.DeepEqual(ranges, c.ranges) {
+			t.Fatalf("parse range(%v): expect(%v, %v) actual(%v, %v)", c.value, c.ranges, c.err, ranges, err)
+		}
+	}
+}
+
+func TestCheckObjectPreconditions(t *testing.T) {
+	var modifyTime = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var fileInfo = &FSFileInfo{ETag: "abc", ModifyTime: modifyTime.Add(300 * time.Millisecond)}
+	var before, after = formatTimeRFC1123(modifyTime.Add(-time.Hour)), formatTimeRFC1123(modifyTime)
+	var cases = []struct {
+		headers   map[string]string
+		errorCode *ErrorCode
+	}{
+		{map[string]string{}, nil},
+		{map[string]string{HeaderNameIfMatch: `"abc"`}, nil},
+		{map[string]string{HeaderNameIfMatch: `"x", "abc"`}, nil},
+		{map[string]string{HeaderNameIfMatch: `*`}, nil},
+		{map[string]string{HeaderNameIfMatch: `"x"`}, PreconditionFailed},
+		{map[string]string{HeaderNameIfUnmodifiedSince: before}, PreconditionFailed},
+		{map[string]string{HeaderNameIfUnmodifiedSince: after}, nil},
+		{map[string]string{HeaderNameIfMatch: `"abc"`, HeaderNameIfUnmodifiedSince: before}, nil},
+		{map[string]string{HeaderNameIfUnmodifiedSince: "yesterday"}, nil},
+		{map[string]string{HeaderNameIfNoneMatch: `W/"abc"`}, NotModified},
+		{map[string]string{HeaderNameIfNoneMatch: `"x"`}, nil},
+		{map[string]string{HeaderNameIfModifiedSince: after}, NotModified},
+		{map[string]string{HeaderNameIfModifiedSince: before}, nil},
+		{map[string]string{HeaderNameIfNoneMatch: `"x"`, HeaderNameIfModifiedSince: after}, nil},
+		{map[string]string{HeaderNameIfMatch: `"x"`, HeaderNameIfNoneMatch: `"abc"`}, PreconditionFailed},
+	}
+	for i, c := range cases {
+		var r = httptest.NewRequest(http.MethodGet, "/bucket/object", nil)
+		for name, value := range c.headers {
+			r.Header.Set(name, value)
+		}
+		var w = httptest.NewRecorder()
+		if errorCode := checkObjectPreconditions(w, r, fileInfo); errorCode != c.errorCode {
+			t.Fatalf("case(%v) headers(%v): expect(%v) actual(%v)", i, c.headers, c.errorCode, errorCode)
+		}
+		if c.errorCode == NotModified && !reflect.DeepEqual(w.Header()[HeaderNameETag], []string{`"abc"`}) {
+			t.Fatalf("case(%v): ETag not served with 304", i)
+		}
+	}
+
+	var r = httptest.NewRequest(http.MethodGet, "/bucket/object", nil)
+	r.Header.Set(HeaderNameIfRange, after)
+	if !checkIfRange(r, fileInfo) {
+		t.Fatalf("If-Range of the last modified time not hold")
+	}
+	r.Header.Set(HeaderNameIfRange, `"x"`)
+	if checkIfRange(r, fileInfo) {
+		t.Fatalf("If-Range of another ETag hold")
+	}
+}
+
+func TestWriteRanges(t *testing.T) {
+	var data = make([]byte, 100)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	var ranges = []httpRange{{0, 10}, {50, 5}, {99, 1}}
+	var w = httptest.NewRecorder()
+	w.Header().Set(HeaderNameContentType, HeaderValueTypeStream)
+	err := writeRanges(w, ranges, int64(len(data)), func(w io.Writer, offset, size uint64) error {
+		_, err := w.Write(data[offset : offset+size])
+		return err
+	})
+	if err != nil {
+		t.Fatalf("write ranges fail: err(%v)", err)
+	}
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("unexpected status code: %v", w.Code)
+	}
+	if length := w.Header().Get(HeaderNameContentLength); length != strconv.Itoa(w.Body.Len()) {
+		t.Fatalf("content length mismatch: header(%v) body(%v)", length, w.Body.Len())
+	}
+	mediaType, params, err := mime.ParseMediaType(w.Header().Get(HeaderNameContentType))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("unexpected content type: %v", w.Header().Get(HeaderNameContentType))
+	}
+	var mr = multipart.NewReader(w.Body, params["boundary"])
+	for _, ra := range ranges {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatalf("read part fail: err(%v)", err)
+		}
+		if part.Header.Get(HeaderNameContentRange) != ra.contentRange(int64(len(data))) {
+			t.Fatalf("unexpected content range: %v", part.Header.Get(HeaderNameContentRange))
+		}
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(part)
+		if !bytes.Equal(buf.Bytes(), data[ra.start:ra.start+ra.length]) {
+			t.Fatalf("part data mismatch: range(%v)", ra)
+		}
+	}
+}
@@ -117,6 +117,41 @@ type ListPartsResult struct {
 	Parts            []*Part      `xml:"Parts"`
 }
 
+type ObjectVersion struct {
+	XMLName      xml.Name     `xml:"Version"`
+	Key          string       `xml:"Key"`
+	VersionId    string       `xml:"VersionId"`
+	IsLatest     bool         `xml:"IsLatest"`
+	LastModified string       `xml:"LastModified"`
+	ETag         string       `xml:"ETag"`
+	Size         int64        `xml:"Size"`
+	StorageClass string       `xml:"StorageClass"`
+	Owner        *BucketOwner `xml:"Owner"`
+}
+
+type DeleteMarkerEntry struct {
+	XMLName      xml.Name     `xml:"DeleteMarker"`
+	Key          string       `xml:"Key"`
+	VersionId    string       `xml:"VersionId"`
+	IsLatest     bool         `xml:"IsLatest"`
+	LastModified string       `xml:"LastModified"`
+	Owner        *BucketOwner `xml:"Owner"`
+}
+
+type ListVersionsResult struct {
+	XMLName             xml.Name             `xml:"ListVersionsResult"`
+	Name                string               `xml:"Name"`
+	Prefix              string               `xml:"Prefix"`
+	KeyMarker           string               `xml:"KeyMarker"`
+	VersionIdMarker     string               `xml:"VersionIdMarker"`
+	NextKeyMarker       string               `xml:"NextKeyMarker,omitempty"`
+	NextVersionIdMarker string               `xml:"NextVersionIdMarker,omitempty"`
+	MaxKeys             int                  `xml:"MaxKeys"`
+	IsTruncated         bool                 `xml:"IsTruncated"`
+	Versions            []*ObjectVersion     `xml:"Version"`
+	DeleteMarkers       []*DeleteMarkerEntry `xml:"DeleteMarker"`
+}
+
 type CommonPrefix struct {
 	XMLName xml.Name `xml:"CommonPrefixes"`
 	Prefix  string
@@ -215,15 +250,23 @@ type CopyResult struct {
 	ETag         string   `xml:"ETag,omitempty"`
 }
 
+type CopyPartResult struct {
+	XMLName      xml.Name `xml:"CopyPartResult"`
+	LastModified string   `xml:"LastModified,omitempty"`
+	ETag         string   `xml:"ETag,omitempty"`
+}
+
 type ListBucketResultV2 struct {
 	XMLName        xml.Name        `xml:"ListBucketResult"`
 	Name           string          `xml:"Name"`
 	Prefix         string          `xml:"Prefix,omitempty"`
 	Token          string          `xml:"ContinuationToken,omitempty"`
 	NextToken      string          `xml:"NextContinuationToken,omitempty"`
+	StartAfter     string          `xml:"StartAfter,omitempty"`
 	KeyCount       uint64          `xml:"KeyCount"`
 	MaxKeys        uint64          `xml:"MaxKeys"`
 	Delimiter      string          `xml:"Delimiter,omitempty"`
+	EncodingType   string          `xml:"EncodingType,omitempty"`
 	IsTruncated    bool            `xml:"IsTruncated,omitempty"`
 	Contents       []*Content      `xml:"Contents"`
 	CommonPrefixes []*CommonPrefix `xml:"CommonPrefixes"`
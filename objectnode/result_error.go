@@ -74,6 +74,8 @@ func ServeInternalStaticErrorResponse(w http.ResponseWriter, r *http.Request) {
 var (
 	UnsupportedOperation                = &ErrorCode{ErrorCode: "UnsupportedOperation", ErrorMessage: "Operation is not supported", StatusCode: http.StatusBadRequest}
 	AccessDenied                        = &ErrorCode{ErrorCode: "AccessDenied", ErrorMessage: "Access Denied", StatusCode: http.StatusForbidden}
+	ExpiredRequest                      = &ErrorCode{ErrorCode: "AccessDenied", ErrorMessage: "Request has expired", StatusCode: http.StatusForbidden}
+	ExpiredToken                        = &ErrorCode{ErrorCode: "ExpiredToken", ErrorMessage: "The provided token has expired.", StatusCode: http.StatusBadRequest}
 	BadDigest                           = &ErrorCode{ErrorCode: "BadDigest", ErrorMessage: "The Content-MD5 you specified did not match what we received.", StatusCode: http.StatusBadRequest}
 	BucketNotExisted                    = &ErrorCode{ErrorCode: "BucketNotExisted", ErrorMessage: "The requested bucket name is not existed.", StatusCode: http.StatusNotFound}
 	BucketNotExistedForHead             = &ErrorCode{ErrorCode: "BucketNotExisted", ErrorMessage: "The requested bucket name is not existed.", StatusCode: http.StatusConflict}
@@ -95,6 +97,12 @@ var (
 	DuplicatedBucket                    = &ErrorCode{ErrorCode: "CreateBucketFailed", ErrorMessage: "Duplicate bucket name.", StatusCode: http.StatusBadRequest}
 	ObjectModeConflict                  = &ErrorCode{ErrorCode: "ObjectModeConflict", ErrorMessage: "Object already exists but file mode conflicts", StatusCode: http.StatusConflict}
 	NotModified                         = &ErrorCode{ErrorCode: "MaxContentLength", ErrorMessage: "Not modified.", StatusCode: http.StatusNotModified}
+	NoSuchVersion                       = &ErrorCode{ErrorCode: "NoSuchVersion", ErrorMessage: "The specified version does not exist.", StatusCode: http.StatusNotFound}
+	MethodNotAllowed                    = &ErrorCode{ErrorCode: "MethodNotAllowed", ErrorMessage: "The specified method is not allowed against this resource.", StatusCode: http.StatusMethodNotAllowed}
+	IllegalVersioningConfiguration      = &ErrorCode{ErrorCode: "IllegalVersioningConfigurationException", ErrorMessage: "The versioning configuration specified in the request is invalid.", StatusCode: http.StatusBadRequest}
+	NoSuchLifecycleConfiguration        = &ErrorCode{ErrorCode: "NoSuchLifecycleConfiguration", ErrorMessage: "The lifecycle configuration does not exist.", StatusCode: http.StatusNotFound}
+	MalformedXML                        = &ErrorCode{ErrorCode: "MalformedXML", ErrorMessage: "The XML you provided was not well-formed or did not validate against our published schema.", StatusCode: http.StatusBadRequest}
+	MalformedACLError                   = &ErrorCode{ErrorCode: "MalformedACLError", ErrorMessage: "The XML you provided was not well-formed or did not validate against our published schema.", StatusCode: http.StatusBadRequest}
 	NoSuchUpload                        = &ErrorCode{ErrorCode: "NoSuchUpload", ErrorMessage: "The specified upload does not exist.", StatusCode: http.StatusNotFound}
 	OverMaxRecordSize                   = &ErrorCode{ErrorCode: "OverMaxRecordSize", ErrorMessage: "The length of a record in the input or result is greater than maxCharsPerRecord of 1 MB.", StatusCode: http.StatusBadRequest}
 	CopySourceSizeTooLarge              = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The specified copy source is larger than the maximum allowable size for a copy source: 5368709120", StatusCode: http.StatusBadRequest}
@@ -104,6 +112,11 @@ var (
 	TagsGreaterThen10                   = &ErrorCode{ErrorCode: "BadRequest", ErrorMessage: "Object tags cannot be greater than 10", StatusCode: http.StatusBadRequest}
 	InvalidTagKey                       = &ErrorCode{ErrorCode: "InvalidTag", ErrorMessage: "The TagKey you have provided is invalid", StatusCode: http.StatusBadRequest}
 	InvalidTagValue                     = &ErrorCode{ErrorCode: "InvalidTag", ErrorMessage: "The TagValue you have provided is invalid", StatusCode: http.StatusBadRequest}
+	InvalidEncryptionAlgorithm          = &ErrorCode{ErrorCode: "InvalidEncryptionAlgorithmError", ErrorMessage: "The encryption request you specified is not valid. The valid value is AES256.", StatusCode: http.StatusBadRequest}
+	InvalidSSECustomerKey               = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The secret key was invalid for the specified algorithm.", StatusCode: http.StatusBadRequest}
+	SSECustomerKeyRequired              = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The object was stored using a form of Server Side Encryption. The correct parameters must be provided to retrieve the object.", StatusCode: http.StatusBadRequest}
+	SSENotEnabled                       = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Server side encryption with the keys of the object node is not enabled.", StatusCode: http.StatusBadRequest}
+	SSENotSupported                     = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "Server side encryption is not supported by the operation.", StatusCode: http.StatusNotImplemented}
 )
 
 func HttpStatusErrorCode(code int) *ErrorCode {
@@ -16,13 +16,16 @@ package objectnode
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
 	"regexp"
 	"strings"
 	"sync"
 
+	"github.com/chubaofs/chubaofs/util"
 	"github.com/chubaofs/chubaofs/util/config"
 	"github.com/chubaofs/chubaofs/util/exporter"
 
@@ -79,6 +82,23 @@ const (
 
 	disabledActions               = "disabledActions"
 	configSignatureIgnoredActions = "signatureIgnoredActions"
+
+	// Object array configuration item, used to configure the targets the notifications of the buckets are
+	// published to, which are referred to by their ARNs "arn:chubaofs:sqs::<id>:<type>" in the notification
+	// configurations of the buckets. All the object nodes should have the same targets.
+	// Example:
+	//		{
+	//			"notificationTargets": [
+	//				{"id": "1", "type": "webhook", "endpoint": "http://hook.chubao.io/events"},
+	//				{"id": "2", "type": "kafka", "endpoint": "http://kafka-rest.chubao.io:8082", "topic": "events"}
+	//			]
+	//		}
+	configNotificationTargets = "notificationTargets"
+
+	// String type configuration item, used to configure the file of the SSE keys which wrap the
+	// object keys of the objects encrypted by SSE-S3, in the same format as the encryption key file
+	// of the master. SSE-S3 is disabled if absent. All the object nodes should have the same keys.
+	configSSEKeyFile = "sseKeyFile"
 )
 
 // Default of configuration value
@@ -103,6 +123,8 @@ type ObjectNode struct {
 	state      uint32
 	wg         sync.WaitGroup
 	userStore  UserInfoStore
+	notifier   *notifier
+	sseKeys    *sseKeyRing // nil if SSE-S3 is not enabled
 
 	signatureIgnoredActions proto.Actions // signature ignored actions
 	disabledActions         proto.Actions // disabled actions
@@ -176,8 +198,50 @@ func (o *ObjectNode) loadConfig(cfg *config.Config) (err error) {
 	strict := cfg.GetBool(configStrict)
 	log.LogInfof("loadConfig: strict: %v", strict)
 
+	// parse notification targets
+	if targets := cfg.GetSlice(configNotificationTargets); len(targets) > 0 {
+		var raw []byte
+		if raw, err = json.Marshal(targets); err != nil {
+			return
+		}
+		var notificationTargets []*NotificationTarget
+		if err = json.Unmarshal(raw, &notificationTargets); err != nil {
+			return config.NewIllegalConfigError(configNotificationTargets)
+		}
+		var hostname string
+		if hostname, err = os.Hostname(); err != nil {
+			return
+		}
+		if o.notifier, err = newNotifier(notificationTargets, hostname+":"+listen); err != nil {
+			return
+		}
+		for _, target := range notificationTargets {
+			log.LogInfof("loadConfig: notification target: %v(%v)", target.ARN(), target.Endpoint)
+		}
+	}
+
+	// parse SSE key file
+	if keyFile := cfg.GetString(configSSEKeyFile); keyFile != "" {
+		if o.sseKeys, err = loadSSEKeyRing(keyFile); err != nil {
+			return
+		}
+		log.LogInfof("loadConfig: SSE keys loaded: active key(%v)", o.sseKeys.activeKey)
+	}
+
+	if err = util.InitTLSFromConfig(cfg, false); err != nil {
+		return
+	}
+	// the object node serves all the volumes, so it presents a node token instead of the tokens of the volumes
+	var accessTokenKey []byte
+	if accessTokenKey, err = proto.AccessTokenKeyFromConfig(cfg); err != nil {
+		return
+	}
+	if accessTokenKey != nil {
+		proto.EnableNodeAccessToken(accessTokenKey)
+	}
+
 	o.mc = master.NewMasterClient(masters, false)
-	o.vm = NewVolumeManager(masters, strict)
+	o.vm = NewVolumeManager(masters, strict, o.notifier)
 	o.userStore = NewUserInfoStore(masters, strict)
 
 	return
@@ -205,6 +269,9 @@ func handleStart(s common.Server, cfg *config.Config) (err error) {
 		return
 	}
 	o.updateRegion(ci.Cluster)
+	if o.notifier != nil {
+		o.notifier.region = o.region
+	}
 	log.LogInfof("handleStart: get cluster information: region(%v)", o.region)
 
 	// start rest api
@@ -0,0 +1,295 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/serv-side-encryption.html
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	SSETypeS3 = "SSE-S3"
+	SSETypeC  = "SSE-C"
+
+	sseKeySize   = 32
+	sseNonceSize = 8
+)
+
+// ObjectEncryption is the encryption of the data of an object, which is stored in the
+// extended attribute of the object. The data is encrypted with the object key by AES-CTR,
+// and the counter of a byte is derived from the nonce and the offset of the byte, so that
+// any range of the object can be decrypted independently.
+//
+// The object key of SSE-S3 is generated for each object and wrapped by the SSE keys of the
+// object nodes, while the object key of SSE-C is provided by the client on every request
+// and only its MD5 is stored to verify the key.
+type ObjectEncryption struct {
+	Type       string `json:"type"`
+	Nonce      []byte `json:"nonce"`
+	KeyID      string `json:"keyId,omitempty"`      // SSE-S3: id of the SSE key which wraps the object key
+	WrappedKey []byte `json:"wrappedKey,omitempty"` // SSE-S3: the wrapped object key
+	KeyMD5     string `json:"keyMD5,omitempty"`     // SSE-C: base64 encoded MD5 of the customer key
+
+	key []byte // the plain object key, never stored
+}
+
+func (e *ObjectEncryption) Encode() []byte {
+	data, _ := json.Marshal(e)
+	return data
+}
+
+func ParseObjectEncryption(raw []byte) (*ObjectEncryption, error) {
+	var e = &ObjectEncryption{}
+	if err := json.Unmarshal(raw, e); err != nil {
+		return nil, err
+	}
+	if len(e.Nonce) != sseNonceSize || (e.Type != SSETypeS3 && e.Type != SSETypeC) {
+		return nil, fmt.Errorf("invalid object encryption: %v", string(raw))
+	}
+	return e, nil
+}
+
+func (e *ObjectEncryption) stream(offset uint64) (cipher.Stream, error) {
+	block, err := aes.NewCipher(e.key)
+	if err != nil {
+		return nil, err
+	}
+	var iv = make([]byte, aes.BlockSize)
+	copy(iv[:sseNonceSize], e.Nonce)
+	binary.BigEndian.PutUint64(iv[sseNonceSize:], offset/aes.BlockSize)
+	var stream = cipher.NewCTR(block, iv)
+	if skip := int(offset % aes.BlockSize); skip != 0 {
+		var pad = make([]byte, skip)
+		stream.XORKeyStream(pad, pad)
+	}
+	return stream, nil
+}
+
+// EncryptReader returns the reader of the cipher text of the object data read from r.
+func (e *ObjectEncryption) EncryptReader(r io.Reader) (io.Reader, error) {
+	stream, err := e.stream(0)
+	if err != nil {
+		return nil, err
+	}
+	return &cipher.StreamReader{S: stream, R: r}, nil
+}
+
+// DecryptWriter returns the writer which decrypts the cipher text of the object data
+// starting from the offset and writes the plain text to w.
+func (e *ObjectEncryption) DecryptWriter(w io.Writer, offset uint64) (io.Writer, error) {
+	stream, err := e.stream(offset)
+	if err != nil {
+		return nil, err
+	}
+	return &cipher.StreamWriter{S: stream, W: w}, nil
+}
+
+// sseKeyRing keeps the SSE keys of the object node which wrap the object keys of SSE-S3,
+// in the same format as the encryption key file of the master, e.g.
+// {"activeKey":"2","keys":{"1":"<hex of 32 bytes>","2":"<hex of 32 bytes>"}}
+// All the object nodes must have the same keys, and the old keys must be kept as long as
+// there are objects whose keys are wrapped by them.
+type sseKeyRing struct {
+	activeKey string
+	keys      map[string]cipher.AEAD
+}
+
+func loadSSEKeyRing(keyFile string) (kr *sseKeyRing, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(keyFile); err != nil {
+		return
+	}
+	var kf = struct {
+		ActiveKey string            `json:"activeKey"`
+		Keys      map[string]string `json:"keys"`
+	}{}
+	if err = json.Unmarshal(data, &kf); err != nil {
+		return
+	}
+	kr = &sseKeyRing{activeKey: kf.ActiveKey, keys: make(map[string]cipher.AEAD)}
+	for id, hexKey := range kf.Keys {
+		var (
+			key   []byte
+			block cipher.Block
+		)
+		if key, err = hex.DecodeString(hexKey); err != nil || len(key) != sseKeySize {
+			return nil, fmt.Errorf("SSE key[%v] is not hex encoded %v bytes", id, sseKeySize)
+		}
+		if block, err = aes.NewCipher(key); err != nil {
+			return
+		}
+		if kr.keys[id], err = cipher.NewGCM(block); err != nil {
+			return
+		}
+	}
+	if _, ok := kr.keys[kr.activeKey]; !ok {
+		return nil, fmt.Errorf("active SSE key[%v] not found", kr.activeKey)
+	}
+	return
+}
+
+func (kr *sseKeyRing) wrap(key []byte) (keyID string, wrapped []byte, err error) {
+	var aead = kr.keys[kr.activeKey]
+	var nonce = make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return
+	}
+	return kr.activeKey, aead.Seal(nonce, nonce, key, nil), nil
+}
+
+func (kr *sseKeyRing) unwrap(keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := kr.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("SSE key[%v] not found", keyID)
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, fmt.Errorf("wrapped object key is too short")
+	}
+	return aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], nil)
+}
+
+// hasEncryptionHeaders returns true if the request specifies SSE-S3 or SSE-C.
+func hasEncryptionHeaders(r *http.Request) bool {
+	return r.Header.Get(HeaderNameXAmzServerSideEncryption) != "" ||
+		r.Header.Get(HeaderNameXAmzSSECustomerAlgorithm) != "" ||
+		r.Header.Get(HeaderNameXAmzSSECustomerKey) != ""
+}
+
+// parseCustomerKey parses the SSE-C headers of the request, returns nil if absent.
+func parseCustomerKey(r *http.Request) (key []byte, keyMD5 string, errorCode *ErrorCode) {
+	var algorithm = r.Header.Get(HeaderNameXAmzSSECustomerAlgorithm)
+	var encodedKey = r.Header.Get(HeaderNameXAmzSSECustomerKey)
+	if algorithm == "" && encodedKey == "" {
+		return
+	}
+	if algorithm != HeaderValueSSEAlgorithmAES256 {
+		return nil, "", InvalidEncryptionAlgorithm
+	}
+	var err error
+	if key, err = base64.StdEncoding.DecodeString(encodedKey); err != nil || len(key) != sseKeySize {
+		return nil, "", InvalidSSECustomerKey
+	}
+	var digest = md5.Sum(key)
+	keyMD5 = base64.StdEncoding.EncodeToString(digest[:])
+	if requestMD5 := r.Header.Get(HeaderNameXAmzSSECustomerKeyMD5); requestMD5 != "" && requestMD5 != keyMD5 {
+		return nil, "", InvalidSSECustomerKey
+	}
+	return
+}
+
+// newObjectEncryption generates the encryption of the object to put by the SSE headers
+// of the request, returns nil if the encryption is not requested.
+func (o *ObjectNode) newObjectEncryption(r *http.Request) (e *ObjectEncryption, errorCode *ErrorCode) {
+	var sse = r.Header.Get(HeaderNameXAmzServerSideEncryption)
+	var customerKeyMD5 string
+	var key []byte
+	if key, customerKeyMD5, errorCode = parseCustomerKey(r); errorCode != nil {
+		return
+	}
+	if sse == "" && key == nil {
+		return
+	}
+	if sse != "" && key != nil {
+		return nil, InvalidArgument
+	}
+
+	e = &ObjectEncryption{Nonce: make([]byte, sseNonceSize)}
+	var err error
+	if _, err = io.ReadFull(rand.Reader, e.Nonce); err != nil {
+		return nil, InternalErrorCode(err)
+	}
+	if key != nil {
+		e.Type, e.KeyMD5, e.key = SSETypeC, customerKeyMD5, key
+		return
+	}
+	if sse != HeaderValueSSEAlgorithmAES256 {
+		return nil, InvalidEncryptionAlgorithm
+	}
+	if o.sseKeys == nil {
+		return nil, SSENotEnabled
+	}
+	e.Type, e.key = SSETypeS3, make([]byte, sseKeySize)
+	if _, err = io.ReadFull(rand.Reader, e.key); err != nil {
+		return nil, InternalErrorCode(err)
+	}
+	if e.KeyID, e.WrappedKey, err = o.sseKeys.wrap(e.key); err != nil {
+		return nil, InternalErrorCode(err)
+	}
+	return
+}
+
+// openObjectEncryption resolves the object key of the encrypted object to read, by the SSE
+// keys for SSE-S3 or by the customer key in the SSE-C headers of the request.
+func (o *ObjectNode) openObjectEncryption(r *http.Request, e *ObjectEncryption) (errorCode *ErrorCode) {
+	key, keyMD5, errorCode := parseCustomerKey(r)
+	if errorCode != nil {
+		return
+	}
+	if e == nil || e.Type == SSETypeS3 {
+		if key != nil {
+			// The SSE-C headers are specified for an object not encrypted by SSE-C.
+			return InvalidArgument
+		}
+		if e == nil {
+			return
+		}
+		if o.sseKeys == nil {
+			return SSENotEnabled
+		}
+		var err error
+		if e.key, err = o.sseKeys.unwrap(e.KeyID, e.WrappedKey); err != nil {
+			log.LogErrorf("openObjectEncryption: unwrap object key fail: requestID(%v) keyID(%v) err(%v)",
+				GetRequestID(r), e.KeyID, err)
+			return InternalErrorCode(err)
+		}
+		return
+	}
+	if key == nil {
+		return SSECustomerKeyRequired
+	}
+	if keyMD5 != e.KeyMD5 {
+		return AccessDenied
+	}
+	e.key = key
+	return
+}
+
+// serveEncryptionHeaders writes the SSE headers of the encrypted object to the response.
+func serveEncryptionHeaders(w http.ResponseWriter, e *ObjectEncryption) {
+	if e == nil {
+		return
+	}
+	switch e.Type {
+	case SSETypeS3:
+		w.Header()[HeaderNameXAmzServerSideEncryption] = []string{HeaderValueSSEAlgorithmAES256}
+	case SSETypeC:
+		w.Header()[HeaderNameXAmzSSECustomerAlgorithm] = []string{HeaderValueSSEAlgorithmAES256}
+		w.Header()[HeaderNameXAmzSSECustomerKeyMD5] = []string{e.KeyMD5}
+	}
+}
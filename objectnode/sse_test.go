@@ -0,0 +1,128 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestObjectEncryptionRange(t *testing.T) {
+	var o = &ObjectNode{}
+	var key = bytes.Repeat([]byte{0x5a}, sseKeySize)
+	var keyMD5 = md5.Sum(key)
+	var r = httptest.NewRequest("PUT", "/bucket/object", nil)
+	r.Header.Set(HeaderNameXAmzSSECustomerAlgorithm, HeaderValueSSEAlgorithmAES256)
+	r.Header.Set(HeaderNameXAmzSSECustomerKey, base64.StdEncoding.EncodeToString(key))
+	e, errorCode := o.newObjectEncryption(r)
+	if errorCode != nil {
+		t.Fatalf("new object encryption fail: errorCode(%v)", errorCode)
+	}
+	if e.Type != SSETypeC || e.KeyMD5 != base64.StdEncoding.EncodeToString(keyMD5[:]) {
+		t.Fatalf("unexpected object encryption: type(%v) keyMD5(%v)", e.Type, e.KeyMD5)
+	}
+
+	var plain = make([]byte, 1000)
+	for i := range plain {
+		plain[i] = byte(i)
+	}
+	reader, err := e.EncryptReader(bytes.NewReader(plain))
+	if err != nil {
+		t.Fatalf("encrypt fail: err(%v)", err)
+	}
+	cipherText, _ := ioutil.ReadAll(reader)
+	if bytes.Equal(cipherText, plain) {
+		t.Fatalf("object data is not encrypted")
+	}
+
+	// read back by a new request through the encoded xattr
+	stored, err := ParseObjectEncryption(e.Encode())
+	if err != nil {
+		t.Fatalf("parse object encryption fail: err(%v)", err)
+	}
+	if errorCode = o.openObjectEncryption(r, stored); errorCode != nil {
+		t.Fatalf("open object encryption fail: errorCode(%v)", errorCode)
+	}
+	for _, offset := range []int{0, 1, 15, 16, 17, 999} {
+		var buf = bytes.NewBuffer(nil)
+		writer, err := stored.DecryptWriter(buf, uint64(offset))
+		if err != nil {
+			t.Fatalf("decrypt fail: err(%v)", err)
+		}
+		_, _ = writer.Write(cipherText[offset:])
+		if !bytes.Equal(buf.Bytes(), plain[offset:]) {
+			t.Fatalf("decrypted data mismatch: offset(%v)", offset)
+		}
+	}
+
+	// wrong customer key
+	stored.key = nil
+	r.Header.Set(HeaderNameXAmzSSECustomerKey, base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0x01}, sseKeySize)))
+	if errorCode = o.openObjectEncryption(r, stored); errorCode != AccessDenied {
+		t.Fatalf("unexpected error code for wrong key: %v", errorCode)
+	}
+	// missing customer key
+	if errorCode = o.openObjectEncryption(httptest.NewRequest("GET", "/bucket/object", nil), stored); errorCode != SSECustomerKeyRequired {
+		t.Fatalf("unexpected error code for missing key: %v", errorCode)
+	}
+}
+
+func TestSSEKeyRing(t *testing.T) {
+	var newAEAD = func(key []byte) cipher.AEAD {
+		block, _ := aes.NewCipher(key)
+		aead, _ := cipher.NewGCM(block)
+		return aead
+	}
+	var kr = &sseKeyRing{
+		activeKey: "k2",
+		keys: map[string]cipher.AEAD{
+			"k1": newAEAD(bytes.Repeat([]byte{1}, sseKeySize)),
+			"k2": newAEAD(bytes.Repeat([]byte{2}, sseKeySize)),
+		},
+	}
+	var o = &ObjectNode{sseKeys: kr}
+	var r = httptest.NewRequest("PUT", "/bucket/object", nil)
+	r.Header.Set(HeaderNameXAmzServerSideEncryption, HeaderValueSSEAlgorithmAES256)
+	e, errorCode := o.newObjectEncryption(r)
+	if errorCode != nil {
+		t.Fatalf("new object encryption fail: errorCode(%v)", errorCode)
+	}
+	if e.Type != SSETypeS3 || e.KeyID != "k2" {
+		t.Fatalf("unexpected object encryption: type(%v) keyID(%v)", e.Type, e.KeyID)
+	}
+	var key = e.key
+	e.key = nil
+	if errorCode = o.openObjectEncryption(httptest.NewRequest("GET", "/bucket/object", nil), e); errorCode != nil {
+		t.Fatalf("open object encryption fail: errorCode(%v)", errorCode)
+	}
+	if !bytes.Equal(e.key, key) {
+		t.Fatalf("unwrapped object key mismatch")
+	}
+
+	// SSE-S3 is refused if no SSE keys are configured
+	if _, errorCode = (&ObjectNode{}).newObjectEncryption(r); errorCode != SSENotEnabled {
+		t.Fatalf("unexpected error code without SSE keys: %v", errorCode)
+	}
+	r.Header.Set(HeaderNameXAmzServerSideEncryption, "aws:kms")
+	if _, errorCode = o.newObjectEncryption(r); errorCode != InvalidEncryptionAlgorithm {
+		t.Fatalf("unexpected error code for aws:kms: %v", errorCode)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/STS/latest/APIReference/API_GetSessionToken.html
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// The temporary credentials are stateless. The temporary access key carries the access key of
+// the parent user and the expiration, while the secret key and the session token are derived
+// from it by the secret key of the parent user. Changing the secret key of the parent user
+// revokes all the temporary credentials issued to it.
+const (
+	STSActionGetSessionToken = "GetSessionToken"
+	STSServiceName           = "sts"
+	STSXMLNamespace          = "https://sts.amazonaws.com/doc/2011-06-15/"
+
+	DefaultSessionDuration = time.Hour
+	MinSessionDuration     = 15 * time.Minute
+	MaxSessionDuration     = 36 * time.Hour
+
+	temporaryAccessKeyPrefix = "STS"
+	temporarySecretKeyUsage  = "secret"
+	sessionTokenUsage        = "token"
+
+	stsRequestBodyLimit = 64 * 1024
+)
+
+// ErrExpiredToken is returned when the temporary credential is out of its expiration time.
+var ErrExpiredToken = errors.New("security token has expired")
+
+var temporaryAccessKeyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type TemporaryCredentials struct {
+	AccessKeyId     string `xml:"AccessKeyId"`
+	SecretAccessKey string `xml:"SecretAccessKey"`
+	SessionToken    string `xml:"SessionToken"`
+	Expiration      string `xml:"Expiration"`
+}
+
+type GetSessionTokenResult struct {
+	XMLName     xml.Name             `xml:"GetSessionTokenResponse"`
+	XMLNS       string               `xml:"xmlns,attr"`
+	Credentials TemporaryCredentials `xml:"GetSessionTokenResult>Credentials"`
+	RequestId   string               `xml:"ResponseMetadata>RequestId"`
+}
+
+func newTemporaryCredentials(parent *proto.UserInfo, duration time.Duration, now time.Time) (*TemporaryCredentials, error) {
+	var nonce = make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	var expiration = now.Add(duration)
+	var payload = fmt.Sprintf("%s\n%d\n%s", parent.AccessKey, expiration.Unix(), hex.EncodeToString(nonce))
+	var accessKey = temporaryAccessKeyPrefix + temporaryAccessKeyEncoding.EncodeToString([]byte(payload))
+	return &TemporaryCredentials{
+		AccessKeyId:     accessKey,
+		SecretAccessKey: temporarySecretKey(parent.SecretKey, accessKey),
+		SessionToken:    sessionToken(parent.SecretKey, accessKey),
+		Expiration:      expiration.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// parseTemporaryAccessKey returns the access key of the parent user and the expiration
+// carried by the temporary access key.
+func parseTemporaryAccessKey(accessKey string) (parentAccessKey string, expiration time.Time, is bool) {
+	if !strings.HasPrefix(accessKey, temporaryAccessKeyPrefix) {
+		return
+	}
+	payload, err := temporaryAccessKeyEncoding.DecodeString(accessKey[len(temporaryAccessKeyPrefix):])
+	if err != nil {
+		return
+	}
+	items := strings.Split(string(payload), "\n")
+	if len(items) != 3 || len(items[0]) == 0 {
+		return
+	}
+	unix, err := strconv.ParseInt(items[1], 10, 64)
+	if err != nil {
+		return
+	}
+	return items[0], time.Unix(unix, 0), true
+}
+
+func deriveTemporaryKey(parentSecretKey, accessKey, usage string) []byte {
+	var h = hmac.New(sha256.New, []byte(parentSecretKey))
+	h.Write([]byte(usage + "\n" + accessKey))
+	return h.Sum(nil)
+}
+
+func temporarySecretKey(parentSecretKey, accessKey string) string {
+	return hex.EncodeToString(deriveTemporaryKey(parentSecretKey, accessKey, temporarySecretKeyUsage))
+}
+
+func sessionToken(parentSecretKey, accessKey string) string {
+	return base64.StdEncoding.EncodeToString(deriveTemporaryKey(parentSecretKey, accessKey, sessionTokenUsage))
+}
+
+// hashSTSRequestBody returns the hex encoded SHA256 digest of the form in the body of the STS
+// request and restores the body for the handler.
+func hashSTSRequestBody(r *http.Request) string {
+	var body []byte
+	if r.Body != nil {
+		body, _ = ioutil.ReadAll(io.LimitReader(r.Body, stsRequestBodyLimit))
+		_ = r.Body.Close()
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	var digest = sha256.Sum256(body)
+	return hex.EncodeToString(digest[:])
+}
+
+// loadTemporaryUser returns the user info of the parent user with the temporary access key
+// and the derived secret key, so that the signature of the request can be validated as usual.
+func (o *ObjectNode) loadTemporaryUser(accessKey string) (*proto.UserInfo, error) {
+	parentAccessKey, expiration, is := parseTemporaryAccessKey(accessKey)
+	if !is {
+		return nil, proto.ErrAccessKeyNotExists
+	}
+	if time.Now().After(expiration) {
+		return nil, ErrExpiredToken
+	}
+	parent, err := o.userStore.LoadUser(parentAccessKey)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.UserInfo{
+		UserID:      parent.UserID,
+		AccessKey:   accessKey,
+		SecretKey:   temporarySecretKey(parent.SecretKey, accessKey),
+		Policy:      parent.Policy,
+		UserType:    parent.UserType,
+		CreateTime:  parent.CreateTime,
+		Description: parent.Description,
+	}, nil
+}
+
+// checkSessionToken checks the session token which must be carried by the requests signed
+// by the temporary credentials, either in header or in URL parameter for presigned requests.
+func (o *ObjectNode) checkSessionToken(r *http.Request) (bool, error) {
+	var accessKey = parseRequestAuthInfo(r).temporaryAccessKey
+	if accessKey == "" {
+		return true, nil
+	}
+	var token = r.Header.Get(HeaderNameXAmzSecurityToken)
+	if token == "" {
+		token = r.URL.Query().Get(HeaderNameXAmzSecurityToken)
+	}
+	if token == "" {
+		return false, nil
+	}
+	parentAccessKey, expiration, _ := parseTemporaryAccessKey(accessKey)
+	if time.Now().After(expiration) {
+		return false, ErrExpiredToken
+	}
+	parent, err := o.userStore.LoadUser(parentAccessKey)
+	if err == proto.ErrUserNotExists || err == proto.ErrAccessKeyNotExists {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return hmac.Equal([]byte(token), []byte(sessionToken(parent.SecretKey, accessKey))), nil
+}
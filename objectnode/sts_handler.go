@@ -0,0 +1,89 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// Get session token
+// API reference: https://docs.aws.amazon.com/STS/latest/APIReference/API_GetSessionToken.html
+func (o *ObjectNode) getSessionTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	if action := r.FormValue("Action"); action != STSActionGetSessionToken {
+		log.LogDebugf("getSessionTokenHandler: unsupported action: requestID(%v) action(%v)", GetRequestID(r), action)
+		_ = UnsupportedOperation.ServeResponse(w, r)
+		return
+	}
+
+	var auth = parseRequestAuthInfo(r)
+	// The temporary credentials can not be used to issue new ones.
+	if auth.temporaryAccessKey != "" {
+		_ = AccessDenied.ServeResponse(w, r)
+		return
+	}
+
+	var duration = DefaultSessionDuration
+	if value := r.FormValue("DurationSeconds"); value != "" {
+		var seconds int64
+		if seconds, err = strconv.ParseInt(value, 10, 64); err != nil {
+			_ = InvalidArgument.ServeResponse(w, r)
+			return
+		}
+		if duration = time.Duration(seconds) * time.Second; duration < MinSessionDuration || duration > MaxSessionDuration {
+			_ = InvalidArgument.ServeResponse(w, r)
+			return
+		}
+	}
+
+	var userInfo *proto.UserInfo
+	if userInfo, err = o.getUserInfoByAccessKey(auth.accessKey); err != nil {
+		log.LogErrorf("getSessionTokenHandler: get user info from master fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), auth.accessKey, err)
+		if err == proto.ErrUserNotExists || err == proto.ErrAccessKeyNotExists {
+			_ = AccessDenied.ServeResponse(w, r)
+			return
+		}
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	var credentials *TemporaryCredentials
+	if credentials, err = newTemporaryCredentials(userInfo, duration, time.Now()); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	var result = GetSessionTokenResult{
+		XMLNS:       STSXMLNamespace,
+		Credentials: *credentials,
+		RequestId:   GetRequestID(r),
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(&result); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	log.LogInfof("Audit: issue temporary credentials: requestID(%v) userID(%v) accessKey(%v) temporaryAccessKey(%v) expiration(%v)",
+		GetRequestID(r), userInfo.UserID, auth.accessKey, credentials.AccessKeyId, credentials.Expiration)
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	_, _ = w.Write(data)
+	return
+}
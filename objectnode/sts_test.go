@@ -0,0 +1,96 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+type mockUserInfoStore map[string]*proto.UserInfo
+
+func (s mockUserInfoStore) LoadUser(accessKey string) (*proto.UserInfo, error) {
+	if userInfo, is := s[accessKey]; is {
+		return userInfo, nil
+	}
+	return nil, proto.ErrAccessKeyNotExists
+}
+
+func TestTemporaryCredentials(t *testing.T) {
+	var parent = &proto.UserInfo{UserID: "user", AccessKey: "AK1234567890ABCD", SecretKey: "SK", Policy: proto.NewUserPolicy()}
+	var o = &ObjectNode{userStore: mockUserInfoStore{parent.AccessKey: parent}}
+
+	credentials, err := newTemporaryCredentials(parent, time.Hour, time.Now())
+	if err != nil {
+		t.Fatalf("issue temporary credentials fail: err(%v)", err)
+	}
+	if parentAccessKey, _, is := parseTemporaryAccessKey(credentials.AccessKeyId); !is || parentAccessKey != parent.AccessKey {
+		t.Fatalf("parse temporary access key fail: parent(%v) is(%v)", parentAccessKey, is)
+	}
+	for _, accessKey := range []string{parent.AccessKey, "STSABCDEFGHIJKLM", "STS"} {
+		if _, _, is := parseTemporaryAccessKey(accessKey); is {
+			t.Fatalf("access key %v parsed as temporary", accessKey)
+		}
+	}
+	userInfo, err := o.getUserInfoByAccessKey(credentials.AccessKeyId)
+	if err != nil {
+		t.Fatalf("load temporary user fail: err(%v)", err)
+	}
+	if userInfo.UserID != parent.UserID || userInfo.SecretKey != credentials.SecretAccessKey {
+		t.Fatalf("temporary user mismatch: %v", userInfo)
+	}
+
+	var check = func(accessKey, token string) (bool, error) {
+		var r = httptest.NewRequest("GET", "/bucket/a.txt", nil)
+		r.Header.Set(HeaderNameAuthorization, SignatureV4Algorithm+" Credential="+accessKey+
+			"/20200101/us-east-1/s3/aws4_request, SignedHeaders=host, Signature=abc")
+		if token != "" {
+			r.Header.Set(HeaderNameXAmzSecurityToken, token)
+		}
+		if auth := parseRequestAuthInfo(r); auth.accessKey != parent.AccessKey {
+			t.Fatalf("request not authorized as parent user: %v", auth.accessKey)
+		}
+		return o.checkSessionToken(r)
+	}
+	if pass, err := check(credentials.AccessKeyId, credentials.SessionToken); !pass || err != nil {
+		t.Fatalf("valid session token rejected: err(%v)", err)
+	}
+	if pass, _ := check(credentials.AccessKeyId, ""); pass {
+		t.Fatalf("missing session token accepted")
+	}
+	if pass, _ := check(credentials.AccessKeyId, credentials.AccessKeyId); pass {
+		t.Fatalf("invalid session token accepted")
+	}
+	if pass, err := check(parent.AccessKey, ""); !pass || err != nil {
+		t.Fatalf("long-lived access key rejected: err(%v)", err)
+	}
+
+	expired, _ := newTemporaryCredentials(parent, time.Hour, time.Now().Add(-2*time.Hour))
+	if _, err = check(expired.AccessKeyId, expired.SessionToken); err != ErrExpiredToken {
+		t.Fatalf("expired session token not detected: err(%v)", err)
+	}
+	if _, err = o.getUserInfoByAccessKey(expired.AccessKeyId); err != ErrExpiredToken {
+		t.Fatalf("expired temporary user loaded: err(%v)", err)
+	}
+
+	// Changing the secret key of the parent user revokes the temporary credentials.
+	parent.SecretKey = "NEWSK"
+	if pass, _ := check(credentials.AccessKeyId, credentials.SessionToken); pass {
+		t.Fatalf("revoked session token accepted")
+	}
+}
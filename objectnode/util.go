@@ -58,9 +58,14 @@ type PathIterator struct {
 	cursor int
 	path   string
 	inited bool
+	flat   bool // the whole path is a single file node for the volumes of the flat namespace
 }
 
 func (p *PathIterator) init() {
+	if !p.inited && p.flat {
+		// the keys of the flat namespace are kept as they are
+		p.inited = true
+	}
 	if !p.inited {
 		p.path = strings.TrimSpace(p.path)
 		loc := regexpSepPrefix.FindStringIndex(p.path)
@@ -83,7 +88,7 @@ func (p *PathIterator) Reset() {
 }
 
 func (p PathIterator) ToSlice() []PathItem {
-	newIterator := NewPathIterator(p.path)
+	newIterator := PathIterator{path: p.path, flat: p.flat}
 	result := make([]PathItem, 0)
 	for newIterator.HasNext() {
 		result = append(result, newIterator.Next())
@@ -98,7 +103,7 @@ func (p *PathIterator) Next() PathItem {
 	}
 	var item PathItem
 	index := strings.Index(p.path[p.cursor:], pathSep)
-	if index >= 0 {
+	if index >= 0 && !p.flat {
 		item = PathItem{
 			Name:        p.path[p.cursor : p.cursor+index],
 			IsDirectory: true,
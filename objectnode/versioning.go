@@ -0,0 +1,115 @@
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/Versioning.html
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/errors"
+)
+
+const (
+	VersioningStatusEnabled   = "Enabled"
+	VersioningStatusSuspended = "Suspended"
+
+	// NullVersionId is the version id of the objects written while the versioning is not enabled.
+	NullVersionId = "null"
+)
+
+type VersioningConfiguration struct {
+	XMLName xml.Name `xml:"VersioningConfiguration" json:"xml_name"`
+	Status  string   `xml:"Status,omitempty" json:"status"`
+}
+
+// versionEntry is an element of the version chain of an object. The chain only holds
+// the noncurrent versions and the delete markers, from the newest to the oldest one,
+// while the current version is the inode the dentry of the object points to.
+// The inodes of the noncurrent versions have no dentry, just like the temporary
+// inodes used during the writing of objects.
+// The chain is stored on a chain inode of the object, which has no dentry either and is
+// pointed to by an extended attribute of the parent directory, see versionChainXAttrKey.
+// Each entry is an extended attribute of the chain inode on its own, so the entries
+// of an object are added and removed by the meta node one by one without reading the chain,
+// and the parent directory holds one attribute for each object having versions.
+type versionEntry struct {
+	VersionId    string `json:"vid"`
+	Inode        uint64 `json:"ino,omitempty"`
+	DeleteMarker bool   `json:"dm,omitempty"`
+	ModifyTime   int64  `json:"mt"`
+	key          string // the key of the extended attribute of the chain inode storing the entry
+}
+
+func parseVersioningConfig(bytes []byte) (versioning *VersioningConfiguration, err error) {
+	versioning = &VersioningConfiguration{}
+	if err = xml.Unmarshal(bytes, versioning); err != nil {
+		return
+	}
+	if versioning.Status != VersioningStatusEnabled && versioning.Status != VersioningStatusSuspended {
+		return nil, errors.New("invalid versioning status")
+	}
+	return
+}
+
+func storeBucketVersioning(bytes []byte, vol *Volume) (err error) {
+	if err = vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSVersioning, bytes); err != nil {
+		return
+	}
+	return nil
+}
+
+// newVersionId generates a version id which sorts in the order of creation.
+func newVersionId() string {
+	return fmt.Sprintf("%016x%08x", time.Now().UnixNano(), rand.Uint32())
+}
+
+// versionChainXAttrKey returns the key of the extended attribute of the parent directory
+// pointing to the chain inode of the object.
+func versionChainXAttrKey(name string) string {
+	return XAttrKeyOSSVersionsPrefix + name
+}
+
+// versionXAttrKey returns the key of the extended attribute of the chain inode storing a version
+// which becomes noncurrent at the time. The keys of the versions of an object sort from
+// the newest version to the oldest one.
+func versionXAttrKey(versionId string, time int64) string {
+	return fmt.Sprintf("%s%016x%s", XAttrKeyOSSVersionEntryPrefix, uint64(math.MaxInt64-time), versionId)
+}
+
+// versionedNames returns the names of the objects having version chains in order,
+// from the keys of the extended attributes of the parent directory.
+func versionedNames(keys []string) (names []string) {
+	for _, key := range keys {
+		if strings.HasPrefix(key, XAttrKeyOSSVersionsPrefix) {
+			names = append(names, key[len(XAttrKeyOSSVersionsPrefix):])
+		}
+	}
+	sort.Strings(names)
+	return
+}
+
+// versionKeys returns the keys of the versions in order, from the keys of the extended attributes
+// of the chain inode.
+func versionKeys(keys []string) (versionKeys []string) {
+	for _, key := range keys {
+		if strings.HasPrefix(key, XAttrKeyOSSVersionEntryPrefix) {
+			versionKeys = append(versionKeys, key)
+		}
+	}
+	sort.Strings(versionKeys)
+	return
+}
+
+func findVersion(versions []*versionEntry, versionId string) int {
+	for i, version := range versions {
+		if version.VersionId == versionId {
+			return i
+		}
+	}
+	return -1
+}
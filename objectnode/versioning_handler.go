@@ -0,0 +1,212 @@
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/Versioning.html
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketVersioning.html
+func (o *ObjectNode) getBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var output = VersioningConfiguration{}
+
+	var versioning *VersioningConfiguration
+	if versioning, err = vol.metaLoader.loadVersioning(); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if versioning != nil {
+		output.Status = versioning.Status
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(output); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	_, _ = w.Write(data)
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketVersioning.html
+func (o *ObjectNode) putBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil && err != io.EOF {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	var versioning *VersioningConfiguration
+	if versioning, err = parseVersioningConfig(bytes); err != nil {
+		_ = IllegalVersioningConfiguration.ServeResponse(w, r)
+		return
+	}
+
+	var newBytes []byte
+	if newBytes, err = json.Marshal(versioning); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if err = storeBucketVersioning(newBytes, vol); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	vol.metaLoader.storeVersioning(versioning)
+	log.LogInfof("Audit: put bucket versioning: requestID(%v) remote(%v) volume(%v) status(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), versioning.Status)
+
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListObjectVersions.html
+func (o *ObjectNode) listObjectVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var errorCode *ErrorCode
+	defer func() {
+		if errorCode != nil {
+			_ = errorCode.ServeResponse(w, r)
+			return
+		}
+	}()
+
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("listObjectVersionsHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		errorCode = NoSuchBucket
+		return
+	}
+	// get options
+	prefix := r.URL.Query().Get(ParamPrefix)
+	keyMarker := r.URL.Query().Get(ParamKeyMarker)
+	versionIdMarker := r.URL.Query().Get(ParamVersionIdMarker)
+	maxKeys := r.URL.Query().Get(ParamMaxKeys)
+
+	var maxKeysInt uint64
+	if maxKeys != "" {
+		maxKeysInt, err = strconv.ParseUint(maxKeys, 10, 16)
+		if err != nil {
+			log.LogErrorf("listObjectVersionsHandler: parse max key fail, requestID(%v) err(%v)", GetRequestID(r), err)
+			errorCode = InvalidArgument
+			return
+		}
+		if maxKeysInt > MaxKeys {
+			maxKeysInt = MaxKeys
+		}
+	} else {
+		maxKeysInt = uint64(MaxKeys)
+	}
+
+	var option = &ListFileVersionsOption{
+		Prefix:          prefix,
+		KeyMarker:       keyMarker,
+		VersionIdMarker: versionIdMarker,
+		MaxKeys:         maxKeysInt,
+	}
+
+	var result *ListFileVersionsResult
+	if result, err = vol.ListObjectVersions(option); err != nil {
+		log.LogErrorf("listObjectVersionsHandler: list versions fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.name, err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+
+	var bucketOwner = NewBucketOwner(vol)
+	var listVersionsResult = &ListVersionsResult{
+		Name:                param.Bucket(),
+		Prefix:              prefix,
+		KeyMarker:           keyMarker,
+		VersionIdMarker:     versionIdMarker,
+		NextKeyMarker:       result.NextKeyMarker,
+		NextVersionIdMarker: result.NextVersionIdMarker,
+		MaxKeys:             int(maxKeysInt),
+		IsTruncated:         result.Truncated,
+		Versions:            make([]*ObjectVersion, 0),
+		DeleteMarkers:       make([]*DeleteMarkerEntry, 0),
+	}
+	for _, version := range result.Versions {
+		if version.DeleteMarker {
+			listVersionsResult.DeleteMarkers = append(listVersionsResult.DeleteMarkers, &DeleteMarkerEntry{
+				Key:          version.Key,
+				VersionId:    version.VersionId,
+				IsLatest:     version.IsLatest,
+				LastModified: formatTimeISO(version.ModifyTime),
+				Owner:        bucketOwner,
+			})
+			continue
+		}
+		listVersionsResult.Versions = append(listVersionsResult.Versions, &ObjectVersion{
+			Key:          version.Key,
+			VersionId:    version.VersionId,
+			IsLatest:     version.IsLatest,
+			LastModified: formatTimeISO(version.ModifyTime),
+			ETag:         wrapUnescapedQuot(version.ETag),
+			Size:         version.Size,
+			StorageClass: StorageClassStandard,
+			Owner:        bucketOwner,
+		})
+	}
+
+	var bytes []byte
+	if bytes, err = MarshalXMLEntity(listVersionsResult); err != nil {
+		log.LogErrorf("listObjectVersionsHandler: marshal result fail, requestID(%v) err(%v)", GetRequestID(r), err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(bytes))}
+	_, _ = w.Write(bytes)
+	return
+}
+
+// serveVersionHeaders sets the version id and delete marker headers of the response,
+// which are only present for the buckets with versioning.
+func serveVersionHeaders(w http.ResponseWriter, versionId string, deleteMarker bool) {
+	if versionId != "" {
+		w.Header()[HeaderNameXAmzVersionId] = []string{versionId}
+	}
+	if deleteMarker {
+		w.Header()[HeaderNameXAmzDeleteMarker] = []string{"true"}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"testing"
+)
+
+func TestParseVersioningConfig(t *testing.T) {
+	var samples = map[string]bool{
+		"<VersioningConfiguration><Status>Enabled</Status></VersioningConfiguration>":   true,
+		"<VersioningConfiguration><Status>Suspended</Status></VersioningConfiguration>": true,
+		"<VersioningConfiguration><Status>Disabled</Status></VersioningConfiguration>":  false,
+		"<VersioningConfiguration></VersioningConfiguration>":                           false,
+		"Enabled": false,
+	}
+	for raw, valid := range samples {
+		versioning, err := parseVersioningConfig([]byte(raw))
+		if valid && (err != nil || versioning == nil) {
+			t.Fatalf("parse versioning config fail: raw(%v) err(%v)", raw, err)
+		}
+		if !valid && err == nil {
+			t.Fatalf("invalid versioning config accepted: raw(%v)", raw)
+		}
+	}
+}
+
+func TestNewVersionId(t *testing.T) {
+	var prev = newVersionId()
+	for i := 0; i < 100; i++ {
+		var next = newVersionId()
+		if next[:16] < prev[:16] {
+			t.Fatalf("version id not in order: prev(%v) next(%v)", prev, next)
+		}
+		prev = next
+	}
+	var versions = []*versionEntry{{VersionId: "b"}, {VersionId: NullVersionId}}
+	if findVersion(versions, NullVersionId) != 1 || findVersion(versions, "c") != -1 {
+		t.Fatalf("find version fail")
+	}
+}
+
+func TestVersionXAttrKeys(t *testing.T) {
+	var names = versionedNames([]string{
+		versionChainXAttrKey("b"),
+		versionChainXAttrKey("a/b"),
+		XAttrKeyOSSVersionId,
+		versionChainXAttrKey("a"),
+	})
+	if len(names) != 3 || names[0] != "a" || names[1] != "a/b" || names[2] != "b" {
+		t.Fatalf("versioned names(%v)", names)
+	}
+	var keys = []string{
+		versionXAttrKey("v3", 100),
+		XAttrKeyOSSVersionId,
+		versionXAttrKey("v4", 200),
+		versionXAttrKey(NullVersionId, 150),
+	}
+	// the newest version first
+	var expect = []string{keys[2], keys[3], keys[0]}
+	var actual = versionKeys(keys)
+	if len(actual) != len(expect) {
+		t.Fatalf("version keys(%v)", actual)
+	}
+	for i, key := range actual {
+		if key != expect[i] {
+			t.Fatalf("version key(%v) expect(%v) actual(%v)", i, expect[i], key)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/config"
+)
+
+const (
+	// ConfigKeyAccessTokenKey is the key shared by the masters, the meta nodes, the data nodes
+	// and the object nodes of a cluster to sign and verify the access tokens.
+	// The meta nodes and the data nodes only serve the authenticated connections if it is configured.
+	ConfigKeyAccessTokenKey = "accessTokenKey"
+
+	minAccessTokenKeyLen = 16
+	nodeAccessTokenTTL   = time.Hour
+)
+
+// VolAccessToken grants the access to the partitions of a volume on the meta nodes and the data nodes.
+// The tokens of the clients are signed by the master according to the owner or the token of the volume.
+// The tokens of the cluster nodes have an empty volume name and grant the access to all the partitions.
+type VolAccessToken struct {
+	VolName   string
+	TokenType int8 // ReadOnlyToken or ReadWriteToken
+	Expire    int64
+	Signature []byte
+}
+
+// AccessTokenKeyFromConfig returns the access token key of the module, nil if it is not configured.
+func AccessTokenKeyFromConfig(cfg *config.Config) (key []byte, err error) {
+	str := cfg.GetString(ConfigKeyAccessTokenKey)
+	if str == "" {
+		return
+	}
+	if len(str) < minAccessTokenKeyLen {
+		return nil, fmt.Errorf("%v must have at least %v characters", ConfigKeyAccessTokenKey, minAccessTokenKeyLen)
+	}
+	return []byte(str), nil
+}
+
+// NewVolAccessToken creates a new access token signed with the key.
+func NewVolAccessToken(key []byte, volName string, tokenType int8, ttl time.Duration) (t *VolAccessToken) {
+	t = &VolAccessToken{
+		VolName:   volName,
+		TokenType: tokenType,
+		Expire:    time.Now().Add(ttl).Unix(),
+	}
+	t.Signature = t.sign(key)
+	return
+}
+
+func (t *VolAccessToken) sign(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(fmt.Sprintf("%v\n%v\n%v", t.VolName, t.TokenType, t.Expire)))
+	return mac.Sum(nil)
+}
+
+// Verify checks the signature and the expiration of the token.
+func (t *VolAccessToken) Verify(key []byte) error {
+	if !hmac.Equal(t.Signature, t.sign(key)) {
+		return ErrInvalidTicket
+	}
+	if time.Now().Unix() > t.Expire {
+		return ErrExpiredTicket
+	}
+	return nil
+}
+
+// IsNode tells if the token is of a cluster node.
+func (t *VolAccessToken) IsNode() bool {
+	return t.VolName == ""
+}
+
+// The operations the clients are allowed to send with a read-only token.
+var clientReadOps = map[uint8]bool{
+	OpRead:               true,
+	OpStreamRead:         true,
+	OpStreamFollowerRead: true,
+	OpMetaLookup:         true,
+	OpMetaReadDir:        true,
+	OpMetaReadDirPlus:    true,
+	OpMetaInodeGet:       true,
+	OpMetaBatchInodeGet:  true,
+	OpMetaExtentsList:    true,
+	OpMetaGetXAttr:       true,
+	OpMetaListXAttr:      true,
+	OpMetaBatchGetXAttr:  true,
+	OpMetaGetLock:        true,
+	OpMetaSetLock:        true,
+	OpMetaGetDirStat:     true,
+	OpGetMultipart:       true,
+	OpListMultiparts:     true,
+}
+
+// The operations the clients are allowed to send with a read-write token.
+var clientWriteOps = map[uint8]bool{
+	OpCreateExtent:        true,
+	OpWrite:               true,
+	OpRandomWrite:         true,
+	OpSyncWrite:           true,
+	OpSyncRandomWrite:     true,
+	OpMetaCreateInode:     true,
+	OpMetaUnlinkInode:     true,
+	OpMetaCreateDentry:    true,
+	OpMetaDeleteDentry:    true,
+	OpMetaUpdateDentry:    true,
+	OpMetaLinkInode:       true,
+	OpMetaEvictInode:      true,
+	OpMetaDeleteInode:     true,
+	OpMetaSetattr:         true,
+	OpMetaTruncate:        true,
+	OpMetaExtentsAdd:      true,
+	OpMetaBatchExtentsAdd: true,
+	OpMetaSetXAttr:        true,
+	OpMetaRemoveXAttr:     true,
+	OpCreateMultipart:     true,
+	OpAddMultipartPart:    true,
+	OpRemoveMultipart:     true,
+	OpMetaTxBegin:         true,
+	OpMetaTxUpdate:        true,
+	OpMetaTxFinish:        true,
+}
+
+// volRenames holds the former names of the renamed volumes mapped to their names,
+// which the nodes receive with the heartbeat.
+var volRenames atomic.Value // map[string]string
+
+// SetVolRenames updates the renamed volumes, so that the tokens with the former names
+// keep granting the access to the renamed partitions until they expire.
+func SetVolRenames(renamed map[string]string) {
+	volRenames.Store(renamed)
+}
+
+// currentVolName returns the name of the volume the name or a former name refers to.
+func currentVolName(name string) string {
+	renamed, _ := volRenames.Load().(map[string]string)
+	// at most one step for each renaming
+	for i := 0; i < len(renamed); i++ {
+		newName, ok := renamed[name]
+		if !ok || newName == name {
+			break
+		}
+		name = newName
+	}
+	return name
+}
+
+// ConnAccess records the access granted to a connection by the tokens presented on it.
+// A connection stays authorized after the tokens expire, the tokens are only verified
+// when the connection is authenticated.
+type ConnAccess struct {
+	sync.RWMutex
+	node bool
+	vols map[string]int8
+}
+
+// Authenticate verifies the tokens in the data of an OpAuthConn packet and grants their access to the connection.
+func (a *ConnAccess) Authenticate(key []byte, data []byte) (err error) {
+	var tokens []*VolAccessToken
+	if err = json.Unmarshal(data, &tokens); err != nil {
+		return ErrInvalidTicket
+	}
+	for _, t := range tokens {
+		if err = t.Verify(key); err != nil {
+			return fmt.Errorf("vol(%v) access token: %v", t.VolName, err)
+		}
+	}
+	a.Lock()
+	defer a.Unlock()
+	if a.vols == nil {
+		a.vols = make(map[string]int8)
+	}
+	for _, t := range tokens {
+		if t.IsNode() {
+			a.node = true
+			continue
+		}
+		if t.TokenType > a.vols[t.VolName] {
+			a.vols[t.VolName] = t.TokenType
+		}
+	}
+	return
+}
+
+// IsNode tells if the connection is authenticated by a node token.
+func (a *ConnAccess) IsNode() bool {
+	a.RLock()
+	defer a.RUnlock()
+	return a.node
+}
+
+// Check checks the access of the operation to a partition of the volume.
+// The clients are only allowed to read or write the partitions of the volumes of their tokens,
+// the other operations are reserved for the cluster nodes.
+func (a *ConnAccess) Check(opcode uint8, volName string) error {
+	if opcode == OpPing || opcode == OpHandshake {
+		return nil
+	}
+	a.RLock()
+	defer a.RUnlock()
+	if a.node {
+		return nil
+	}
+	tokenType, ok := a.vols[volName]
+	if !ok {
+		tokenType, ok = a.renamedVolToken(volName)
+	}
+	switch {
+	case ok && clientReadOps[opcode]:
+		return nil
+	case ok && clientWriteOps[opcode] && tokenType == ReadWriteToken:
+		return nil
+	}
+	return ErrNoPermission
+}
+
+// The access tokens presented by the process on the new connections.
+var (
+	accessMu      sync.RWMutex
+	accessKey     []byte
+	accessTokens  = make(map[string]*VolAccessToken)
+	handshakeOnce sync.Once
+)
+
+// EnableNodeAccessToken makes the process present a node token signed with the key on the new connections.
+func EnableNodeAccessToken(key []byte) {
+	accessMu.Lock()
+	accessKey = key
+	accessMu.Unlock()
+	handshakeOnce.Do(func() { util.SetConnHandshake(authConn) })
+}
+
+// NodeAccessTokenEnabled tells if the process presents a node token, which grants the access to all the volumes.
+func NodeAccessTokenEnabled() bool {
+	accessMu.RLock()
+	defer accessMu.RUnlock()
+	return accessKey != nil
+}
+
+// SetVolAccessToken makes the process present the token on the new connections,
+// the previous token of the volume is replaced.
+func SetVolAccessToken(t *VolAccessToken) {
+	accessMu.Lock()
+	accessTokens[t.VolName] = t
+	accessMu.Unlock()
+	handshakeOnce.Do(func() { util.SetConnHandshake(authConn) })
+}
+
+func authConn(conn net.Conn) (err error) {
+	accessMu.RLock()
+	tokens := make([]*VolAccessToken, 0, len(accessTokens)+1)
+	if accessKey != nil {
+		tokens = append(tokens, NewVolAccessToken(accessKey, "", ReadWriteToken, nodeAccessTokenTTL))
+	}
+	for _, t := range accessTokens {
+		tokens = append(tokens, t)
+	}
+	accessMu.RUnlock()
+	if len(tokens) == 0 {
+		return
+	}
+	p := NewPacket()
+	p.Opcode = OpAuthConn
+	p.ReqID = GenerateRequestID()
+	if p.Data, err = json.Marshal(tokens); err != nil {
+		return
+	}
+	p.Size = uint32(len(p.Data))
+	if err = p.WriteToConn(conn); err != nil {
+		return
+	}
+	if err = p.ReadFromConn(conn, ReadDeadlineTime); err != nil {
+		return
+	}
+	conn.SetDeadline(time.Time{})
+	if p.ResultCode != OpOk {
+		return fmt.Errorf("auth conn with %v: %v", conn.RemoteAddr(), string(p.Data[:p.Size]))
+	}
+	return
+}
+
+// renamedVolToken returns the access of the tokens whose volume is renamed to or from the volume,
+// the tokens and the partitions get the new name at different times.
+func (a *ConnAccess) renamedVolToken(volName string) (tokenType int8, ok bool) {
+	current := currentVolName(volName)
+	for name, t := range a.vols {
+		if currentVolName(name) == current && (!ok || t > tokenType) {
+			tokenType, ok = t, true
+		}
+	}
+	return
+}
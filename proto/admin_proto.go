@@ -23,17 +23,32 @@ const (
 	AdminCreateDataPartition       = "/dataPartition/create"
 	AdminDecommissionDataPartition = "/dataPartition/decommission"
 	AdminDiagnoseDataPartition     = "/dataPartition/diagnose"
+	AdminDataPartitionDivergence   = "/dataPartition/divergence"
+	AdminRepairDataPartition       = "/dataPartition/repair"
 	AdminDeleteDataReplica         = "/dataReplica/delete"
 	AdminAddDataReplica            = "/dataReplica/add"
 	AdminDeleteVol                 = "/vol/delete"
 	AdminUpdateVol                 = "/vol/update"
 	AdminVolShrink                 = "/vol/shrink"
 	AdminVolExpand                 = "/vol/expand"
+	AdminVolCapacityProgress       = "/vol/capacityProgress"
+	AdminVolUsage                  = "/vol/usage"
+	AdminGetVolDataKey             = "/vol/dataKey"
+	AdminRotateVolKey              = "/vol/rotateKey"
+	AdminVolReadOnly               = "/vol/readOnly"
+	AdminRenameVol                 = "/vol/rename"
+	AdminRestoreVol                = "/vol/restore"
+	AdminVolReplicaNum             = "/vol/replicaNum"
+	AdminVolReplicaNumProgress     = "/vol/replicaNumProgress"
 	AdminCreateVol                 = "/admin/createVol"
 	AdminGetVol                    = "/admin/getVol"
 	AdminClusterFreeze             = "/cluster/freeze"
 	AdminClusterStat               = "/cluster/stat"
+	AdminClusterOverview           = "/cluster/overview"
+	AdminClusterUpgrade            = "/cluster/upgrade"
+	AdminUpgradeStatus             = "/upgrade/status"
 	AdminGetIP                     = "/admin/getIp"
+	AdminGetRaftStatus             = "/raftStatus"
 	AdminCreateMetaPartition       = "/metaPartition/create"
 	AdminSetMetaNodeThreshold      = "/threshold/set"
 	AdminListVols                  = "/vol/list"
@@ -54,11 +69,15 @@ const (
 	ConsoleFileUpload = "/file/upload"
 
 	// Client APIs
-	ClientDataPartitions = "/client/partitions"
-	ClientVol            = "/client/vol"
-	ClientMetaPartition  = "/metaPartition/get"
-	ClientVolStat        = "/client/volStat"
-	ClientMetaPartitions = "/client/metaPartitions"
+	ClientDataPartitions         = "/client/partitions"
+	ClientDataPartitionLocations = "/client/partitionLocations"
+	ClientVol                    = "/client/vol"
+	ClientMetaPartition          = "/metaPartition/get"
+	ClientVolStat                = "/client/volStat"
+	ClientVolAccessToken         = "/client/volAccessToken"
+	ClientMetaPartitions         = "/client/metaPartitions"
+	ClientSessionRegister        = "/client/register"
+	ClientSessionHeartbeat       = "/client/heartbeat"
 
 	//raft node APIs
 	AddRaftNode    = "/raftNode/add"
@@ -67,16 +86,21 @@ const (
 	// Node APIs
 	AddDataNode                    = "/dataNode/add"
 	DecommissionDataNode           = "/dataNode/decommission"
+	DecommissionDataNodeProgress   = "/dataNode/decommissionProgress"
+	TransferDataNodeLeaders        = "/dataNode/transferLeaders"
 	DecommissionDisk               = "/disk/decommission"
 	GetDataNode                    = "/dataNode/get"
 	AddMetaNode                    = "/metaNode/add"
 	DecommissionMetaNode           = "/metaNode/decommission"
+	DecommissionMetaNodeProgress   = "/metaNode/decommissionProgress"
+	TransferMetaNodeLeaders        = "/metaNode/transferLeaders"
 	GetMetaNode                    = "/metaNode/get"
 	AdminUpdateMetaNode            = "/metaNode/update"
 	AdminUpdateDataNode            = "/dataNode/update"
 	AdminGetInvalidNodes           = "/invalid/nodes"
 	AdminLoadMetaPartition         = "/metaPartition/load"
 	AdminDiagnoseMetaPartition     = "/metaPartition/diagnose"
+	AdminVerifyMetaPartition       = "/metaPartition/verify"
 	AdminDecommissionMetaPartition = "/metaPartition/decommission"
 	AdminAddMetaReplica            = "/metaReplica/add"
 	AdminDeleteMetaReplica         = "/metaReplica/delete"
@@ -88,6 +112,20 @@ const (
 	GetTopologyView = "/topo/get"
 	UpdateZone      = "/zone/update"
 	GetAllZones     = "/zone/list"
+	GetFaultDomains = "/topo/faultDomains"
+
+	AdminGetZonePlacement    = "/zone/placement"
+	AdminRepairZonePlacement = "/zone/placement/repair"
+
+	AdminGetRebalance = "/rebalance/get"
+	AdminSetRebalance = "/rebalance/set"
+
+	// client session APIs
+	AdminListClients = "/client/list"
+	AdminEvictClient = "/client/evict"
+
+	// the audit log of the admin APIs
+	AdminGetAudit = "/admin/audit"
 
 	//token
 	TokenGetURI    = "/token/get"
@@ -95,9 +133,17 @@ const (
 	TokenDelURI    = "/token/delete"
 	TokenUpdateURI = "/token/update"
 
+	//quota
+	QuotaSet    = "/quota/set"
+	QuotaDelete = "/quota/delete"
+	QuotaList   = "/quota/list"
+
 	// Header keys
 	SkipOwnerValidation = "Skip-Owner-Validation"
 	ForceDelete         = "Force-Delete"
+	MasterLeader        = "X-Cfs-Master-Leader" // the address of the master leader in the responses
+	MasterProxiedBy     = "X-Cfs-Proxied-By"    // the address of the master proxying the request to the leader
+	AdminOperator       = "X-Cfs-Operator"      // the user calling the admin APIs, recorded in the audit log
 
 	// APIs for user management
 	UserCreate          = "/user/create"
@@ -110,6 +156,7 @@ const (
 	UserGetAKInfo       = "/user/akInfo"
 	UserTransferVol     = "/user/transferVol"
 	UserList            = "/user/list"
+	UserAuthorize       = "/user/authorize"
 	UsersOfVol          = "/vol/users"
 	//graphql api for header
 	HeadAuthorized  = "Authorization"
@@ -125,6 +172,26 @@ const (
 	ReadWriteToken = 2
 )
 
+// The permission priority of a volume decides which one of the POSIX mode bits and the S3 ACLs takes
+// effect on the volume accessed through both the file system and the object storage interface.
+const (
+	PermissionPriorityNone  = ""      // the mode bits and the ACLs are independent of each other
+	PermissionPriorityPOSIX = "posix" // the ACLs of the objects are derived from the mode bits
+	PermissionPriorityS3    = "s3"    // the mode bits follow the ACLs of the objects
+)
+
+// XAttrKeyOSSACL is the extended attribute storing the S3 ACL of an object.
+const XAttrKeyOSSACL = "oss:acl"
+
+// ValidPermissionPriority checks whether the permission priority is one of the defined.
+func ValidPermissionPriority(priority string) bool {
+	switch priority {
+	case PermissionPriorityNone, PermissionPriorityPOSIX, PermissionPriorityS3:
+		return true
+	}
+	return false
+}
+
 type Token struct {
 	TokenType int8
 	Value     string
@@ -151,6 +218,11 @@ type ClusterInfo struct {
 	MetaNodeDeleteWorkerSleepMs uint64
 	DataNodeDeleteLimitRate     uint64
 	DataNodeAutoRepairLimitRate uint64
+	DataNodeDiskWriteLimitRate  uint64 // MB/s of the client writes on each disk of the data nodes, 0 means unlimited
+	DataNodeDiskRepairLimitRate uint64 // MB/s of the repair writes on each disk of the data nodes
+	DataNodeDpWriteLimitRate    uint64 // MB/s of the client writes on each data partition
+	MetaNodeDeleteLimitRate     uint64 // extents deleted by each meta node per second, the share of the cluster-wide rate
+	AccessTokenEnabled          bool   // the clients must present the access tokens to the meta nodes and the data nodes
 }
 
 // CreateDataPartitionRequest defines the request to create a data partition.
@@ -163,6 +235,9 @@ type CreateDataPartitionRequest struct {
 	Members       []Peer
 	Hosts         []string
 	CreateType    int
+	MediaType     string // the partition is created on a disk of the media type if there is any
+	Encrypted     bool   // the extents are encrypted with the data key of the volume
+	Cache         bool   // the single replica partition of a cache volume, whose random writes skip raft
 }
 
 // CreateDataPartitionResponse defines the response to the request of creating a data partition.
@@ -226,6 +301,7 @@ type LoadDataPartitionRequest struct {
 type LoadDataPartitionResponse struct {
 	PartitionId       uint64
 	Used              uint64
+	AppliedID         uint64
 	PartitionSnapshot []*File
 	Status            uint8
 	PartitionStatus   int
@@ -233,6 +309,14 @@ type LoadDataPartitionResponse struct {
 	VolName           string
 }
 
+// RepairDataPartitionExtentsRequest defines the request to overwrite the extents of a replica
+// with the data of the authoritative replica.
+type RepairDataPartitionExtentsRequest struct {
+	PartitionId uint64
+	Source      string
+	Extents     []uint64
+}
+
 // File defines the file struct.
 type File struct {
 	Name     string
@@ -259,8 +343,13 @@ type LoadMetaPartitionMetricResponse struct {
 
 // HeartBeatRequest define the heartbeat request.
 type HeartBeatRequest struct {
-	CurrTime   int64
-	MasterAddr string
+	CurrTime     int64
+	MasterAddr   string
+	DeadClients  []uint64                `json:",omitempty"` // the clients whose sessions expired or were evicted, sent to the meta nodes
+	ReadOnlyVols []string                `json:",omitempty"` // the volumes frozen read-only, whose writes are rejected by the nodes
+	RenamedVols  map[string]string       `json:",omitempty"` // the former names of the renamed volumes mapped to their names
+	PermVols     []string                `json:",omitempty"` // the volumes whose meta nodes reject the client requests without credentials
+	Quotas       map[string][]*QuotaInfo `json:",omitempty"` // the directory quotas of the volumes, enforced by the meta nodes
 }
 
 // PartitionReport defines the partition report.
@@ -274,6 +363,9 @@ type PartitionReport struct {
 	IsLeader        bool
 	ExtentCount     int
 	NeedCompare     bool
+	MediaType       string
+	LastAccessTime  int64    // the last time the partition was read or written by the clients, 0 if not since the data node started
+	CorruptExtents  []uint64 // the extents found corrupt by the scrubber and not repaired from the other replicas
 }
 
 // DataNodeHeartbeatResponse defines the response to the data node heartbeat.
@@ -290,6 +382,24 @@ type DataNodeHeartbeatResponse struct {
 	Status              uint8
 	Result              string
 	BadDisks            []string
+	MediaSpaces         map[string]uint64 // key: media type, value: remaining capacity to create partition
+	Version             string
+	DiskSmart           []*DiskSmartInfo
+}
+
+// DiskSmartInfo defines the SMART attributes of a disk collected by the data node.
+type DiskSmartInfo struct {
+	Path                 string
+	Device               string
+	Healthy              bool // the overall health self-assessment passed
+	PredictFailure       bool // the disk is predicted to fail, see Reason
+	Reason               string
+	Temperature          int64 // in Celsius
+	PowerOnHours         int64
+	ReallocatedSectors   int64
+	PendingSectors       int64
+	UncorrectableSectors int64
+	UpdateTime           int64
 }
 
 // MetaPartitionReport defines the meta partition report.
@@ -303,6 +413,34 @@ type MetaPartitionReport struct {
 	VolName     string
 	InodeCnt    uint64
 	DentryCnt   uint64
+	QuotaUsage  []*QuotaUsage
+	QuotaRoots  []*QuotaRoot `json:",omitempty"` // the quotas rooted in the partition
+	Usage       *DirStat     // the usage of the inodes in the partition, only reported by the leader
+}
+
+// QuotaUsage defines the usage of a directory quota in a meta partition.
+type QuotaUsage struct {
+	QuotaId uint64
+	Files   uint64
+	Bytes   uint64
+}
+
+// QuotaRoot defines the root of a directory quota in a meta partition with the quota enclosing it.
+type QuotaRoot struct {
+	QuotaId  uint64
+	ParentId uint64 // 0 if none
+}
+
+// QuotaInfo defines the limits and the usage of a directory quota.
+// A quota is identified by the inode of its root directory.
+type QuotaInfo struct {
+	QuotaId   uint64
+	ParentId  uint64 // the quota enclosing the root of the quota, 0 if none
+	MaxFiles  uint64 // 0 means unlimited
+	MaxBytes  uint64 // 0 means unlimited
+	UsedFiles uint64 // including the usage of the quotas nested in it
+	UsedBytes uint64
+	Exceeded  bool
 }
 
 // MetaNodeHeartbeatResponse defines the response to the meta node heartbeat request.
@@ -313,6 +451,7 @@ type MetaNodeHeartbeatResponse struct {
 	MetaPartitionReports []*MetaPartitionReport
 	Status               uint8
 	Result               string
+	Version              string
 }
 
 // DeleteFileRequest defines the request to delete a file.
@@ -390,6 +529,42 @@ type MetaPartitionLoadResponse struct {
 	Addr        string
 }
 
+// MetaPartitionVerifyRequest defines the request to verify the replicas of a meta partition.
+// With Start set the leader proposes the verification through raft, so that every replica takes
+// its snapshot at the same log index, otherwise the replica returns the result of the verification.
+type MetaPartitionVerifyRequest struct {
+	PartitionID uint64
+	VerifyID    uint64
+	Start       bool
+}
+
+// MetaTreeDigest defines the number of the items and the hash of a meta tree.
+type MetaTreeDigest struct {
+	Tree  string
+	Count uint64
+	Hash  uint64
+}
+
+// MetaPartitionVerifyResponse defines the digests of the meta trees taken by a replica.
+type MetaPartitionVerifyResponse struct {
+	PartitionID uint64
+	VerifyID    uint64
+	ApplyID     uint64
+	Done        bool
+	Trees       []*MetaTreeDigest
+	Addr        string
+}
+
+// MetaPartitionVerifyResult defines the result of the verification of the replicas of a meta partition.
+type MetaPartitionVerifyResult struct {
+	PartitionID uint64
+	VolName     string
+	VerifyID    uint64
+	Consistent  bool
+	Mismatches  []string
+	Replicas    []*MetaPartitionVerifyResponse
+}
+
 // DataPartitionResponse defines the response from a data node to the master that is related to a data partition.
 type DataPartitionResponse struct {
 	PartitionID uint64
@@ -401,6 +576,15 @@ type DataPartitionResponse struct {
 	IsRecover   bool
 }
 
+// DataPartitionLocation defines the hosts of a data partition and their zones, which locate the blocks
+// of a file for the computing frameworks like Hadoop.
+type DataPartitionLocation struct {
+	PartitionID uint64
+	LeaderAddr  string
+	Hosts       []string
+	Zones       []string // zone of each host, empty if the host is not known
+}
+
 // DataPartitionsView defines the view of a data partition
 type DataPartitionsView struct {
 	DataPartitions []*DataPartitionResponse
@@ -433,14 +617,19 @@ type OSSSecure struct {
 
 // VolView defines the view of a volume
 type VolView struct {
-	Name           string
-	Owner          string
-	Status         uint8
-	FollowerRead   bool
-	MetaPartitions []*MetaPartitionView
-	DataPartitions []*DataPartitionResponse
-	OSSSecure      *OSSSecure
-	CreateTime     int64
+	Name               string
+	Owner              string
+	Status             uint8
+	FollowerRead       bool
+	MetaPartitions     []*MetaPartitionView
+	DataPartitions     []*DataPartitionResponse
+	OSSSecure          *OSSSecure
+	CreateTime         int64
+	TrashDays          uint32
+	CaseInsensitive    bool
+	Flat               bool
+	PermissionPriority string
+	ReadOnly           bool
 }
 
 func (v *VolView) SetOwner(owner string) {
@@ -498,6 +687,108 @@ type SimpleVolView struct {
 	Description        string
 	DpSelectorName     string
 	DpSelectorParm     string
+	TrashDays          uint32
+	HotMedia           string
+	ColdMedia          string
+	ColdDays           uint32
+	CaseInsensitive    bool
+	EnforcePerm        bool // the meta nodes reject the requests of the clients without the credentials of the users
+	SmallFileSize      uint32
+	DisablePacking     bool
+	Encrypted          bool
+	DataKeyID          string // id of the master key which wraps the data key of the volume
+	Flat               bool   // the objects are stored by their keys without intermediate directories
+	PermissionPriority string // which one of the mode bits and the S3 ACLs takes effect, see PermissionPriorityPOSIX
+	ReadOnly           bool   // the volume is frozen read-only, and the writes are rejected
+	Cache              bool   // the data has a single replica without durability guarantee
+	PurgeTime          int64  // unix time to purge the volume marked deleted, which can be restored before it
+}
+
+// VolDataKey defines the data key of an encrypted volume sent to the data nodes.
+type VolDataKey struct {
+	Name    string
+	DataKey []byte
+}
+
+// VolUsage defines the usage of the files of a volume reported by the meta partition leaders.
+// The files in the trash are counted until they are deleted.
+type VolUsage struct {
+	Name               string
+	InodeCount         uint64
+	DentryCount        uint64
+	Files              uint64
+	Dirs               uint64
+	Bytes              uint64
+	MetaPartitions     int
+	ReportedPartitions int // the meta partitions whose leaders have reported the usage
+}
+
+// MasterRaftStatus defines the raft status of a master, the peers are only known by the leader.
+type MasterRaftStatus struct {
+	NodeID     uint64
+	Addr       string
+	LeaderID   uint64
+	LeaderAddr string
+	IsLeader   bool
+	MetaReady  bool // the leader serves the requests after it loads the metadata
+	State      string
+	Term       uint64
+	Index      uint64
+	Commit     uint64
+	Applied    uint64
+	Peers      []*MasterPeerStatus
+}
+
+// MasterPeerStatus defines the replication of the raft log to a master.
+type MasterPeerStatus struct {
+	NodeID     uint64
+	Addr       string
+	Match      uint64
+	Commit     uint64
+	State      string
+	Active     bool
+	LastActive int64
+}
+
+// AdminAuditRecord is a record of an admin API call in the audit log of the master.
+type AdminAuditRecord struct {
+	ID       uint64
+	Time     int64  // unix seconds
+	Operator string // the user reported by the client, empty if unknown
+	Addr     string // the address of the client
+	API      string
+	Params   map[string]string // the secrets such as the auth keys are masked
+	Code     int32             // the code of the reply, ErrCodeSuccess if succeeded
+	Msg      string
+}
+
+// VolReplicaNumProgress defines the progress of the data partitions converted after the replica number of a volume changes.
+type VolReplicaNumProgress struct {
+	Name          string
+	OldReplicaNum uint8
+	NewReplicaNum uint8
+	MaxMigrations int // max number of data partitions being recovered at a time
+	Total         int // number of data partitions to convert
+	Done          int
+	Failed        int
+	Status        string // running or finished
+	Msg           string // error of the last failed data partition
+	StartTime     string
+	EndTime       string
+}
+
+// VolCapacityProgress defines the progress of the data partitions adjusted after the capacity of a volume changes.
+type VolCapacityProgress struct {
+	Name        string
+	Action      string // expand or shrink
+	OldCapacity uint64 // GB
+	NewCapacity uint64 // GB
+	Total       int    // number of data partitions to adjust
+	Done        int
+	Status      string // running or finished
+	Msg         string
+	StartTime   string
+	EndTime     string
 }
 
 // MasterAPIAccessResp defines the response for getting meta partition
@@ -513,6 +804,7 @@ type VolInfo struct {
 	Status     uint8
 	TotalSize  uint64
 	UsedSize   uint64
+	PurgeTime  int64 // unix time to purge the volume marked deleted
 }
 
 func NewVolInfo(name, owner string, createTime int64, status uint8, totalSize, usedSize uint64) *VolInfo {
@@ -540,6 +832,85 @@ type NodeSetView struct {
 	DataNodes   []NodeView
 }
 
+// ZonePlacementViolation defines a partition of a cross zone volume with all the replicas in one zone.
+type ZonePlacementViolation struct {
+	VolName       string
+	PartitionID   uint64
+	PartitionType string // data or meta
+	Hosts         []string
+	Zones         []string
+	NewHost       string // the host in another zone a replica is moved to by the repair
+	Msg           string
+}
+
+// RebalanceView defines the settings of the partition rebalancer and the migrations of its last check.
+type RebalanceView struct {
+	Enable        bool // balance the data partitions by the disk usage of the data nodes
+	MetaEnable    bool // balance the meta partitions by the meta partition count of the meta nodes
+	DryRun        bool
+	MaxMigrations int     // max number of data or meta partitions being recovered at a time
+	Threshold     float64 // ratio over the average of the zone for a node to be taken as hot
+	LastCheckTime string
+	Migrations    []*RebalanceMigration
+}
+
+// RebalanceMigration defines the move of a partition replica from a hot node to a cold one.
+// The usage of a meta node is its meta partition count over the average of the zone.
+type RebalanceMigration struct {
+	VolName       string
+	PartitionID   uint64
+	PartitionType string
+	Zone          string
+	From          string
+	To            string
+	FromUsage     float64
+	ToUsage       float64
+	DryRun        bool
+	Msg           string
+}
+
+// DecommissionProgress defines the progress of draining the partition replicas off a node gracefully.
+type DecommissionProgress struct {
+	Addr             string
+	NodeType         string // dataNode or metaNode
+	Status           string // draining, recovering, finished or failed
+	Total            int    // number of partitions on the node when the decommission starts
+	Migrated         int    // number of partitions with the replica moved to another node
+	Recovered        int    // number of the migrated partitions with the new replica caught up
+	FailedPartitions []uint64
+	Msg              string
+	StartTime        string
+	EndTime          string
+}
+
+// UpgradeStatus defines the progress of a rolling upgrade by the versions the nodes report in the heartbeats.
+type UpgradeStatus struct {
+	Upgrading     bool
+	MasterVersion string
+	Versions      map[string]int // the number of nodes by version
+	DataNodes     []*NodeVersion
+	MetaNodes     []*NodeVersion
+}
+
+// NodeVersion defines the version of a node and the number of the partitions it leads.
+type NodeVersion struct {
+	Addr        string
+	Version     string
+	IsActive    bool
+	LeaderCount int
+}
+
+// TransferLeadersResult defines the result of moving the raft leaders of the partitions off a node.
+// The leaders are transferred asynchronously, so the node may lead some of the partitions for a while.
+type TransferLeadersResult struct {
+	Addr               string
+	NodeType           string // dataNode or metaNode
+	Leaders            int    // number of partitions led by the node when the transfer starts
+	Transferred        int    // number of partitions of which another replica is asked to be the leader
+	FailedPartitions   []uint64
+	NoTargetPartitions []uint64 // the partitions without another active replica to lead them
+}
+
 // TopologyView provides the view of the topology view of the cluster
 type TopologyView struct {
 	Zones []*ZoneView
@@ -0,0 +1,49 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// The capabilities the master grants to a client session.
+const (
+	ClientCapRead uint32 = 1 << iota
+	ClientCapWrite
+)
+
+// ClientSessionView is the session of a client mounting a volume. A client registers the session with
+// the master, and keeps it alive by the heartbeats within the lease. The session expires once the client
+// stops the heartbeats, and the meta nodes release the locks and the orphan inodes held by the client.
+type ClientSessionView struct {
+	ID            uint64
+	Volume        string
+	Host          string
+	Version       string
+	MountPoint    string
+	MountOptions  map[string]string
+	MountTime     int64
+	RegisterTime  int64
+	LastHeartbeat int64
+	Lease         int64 // seconds
+	Capabilities  uint32
+	OpRates       map[string]float64 // the ops per second between the last two heartbeats, by the op
+}
+
+// ClientSessionReport is reported by a client when it registers the session and heartbeats.
+type ClientSessionReport struct {
+	Host         string
+	Version      string
+	MountPoint   string
+	MountOptions map[string]string
+	MountTime    int64
+	Ops          map[string]uint64 // the number of the ops served since the mount, by the op
+}
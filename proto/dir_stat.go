@@ -0,0 +1,54 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"fmt"
+)
+
+const (
+	// DirStatXAttr is a read-only extended attribute of the directories, whose value is the
+	// number of files and directories and the bytes of the files in the directory recursively.
+	DirStatXAttr = "user.cfs.dirstat"
+)
+
+// DirStat defines the usage of a directory, or of a meta partition and a volume.
+// Files counts the dentries other than directories, so a file with hard links is counted by each link.
+type DirStat struct {
+	Files uint64 `json:"files"`
+	Dirs  uint64 `json:"dirs"`
+	Bytes uint64 `json:"bytes"` // the size of the regular files
+}
+
+// Add adds the usage of another directory to the stat.
+func (s *DirStat) Add(o *DirStat) {
+	s.Files += o.Files
+	s.Dirs += o.Dirs
+	s.Bytes += o.Bytes
+}
+
+// String returns the value of DirStatXAttr.
+func (s *DirStat) String() string {
+	return fmt.Sprintf("files=%v dirs=%v bytes=%v", s.Files, s.Dirs, s.Bytes)
+}
+
+// DirSummary defines the usage of the direct children of a directory, which is
+// computed by the meta partition holding the dentries of the directory.
+type DirSummary struct {
+	Inode    uint64   `json:"ino"`
+	Stat     DirStat  `json:"stat"`
+	SubDirs  []uint64 `json:"subdirs"`
+	StatTime int64    `json:"st"` // the unix time when the summary was computed
+}
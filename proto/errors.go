@@ -81,6 +81,10 @@ var (
 	ErrInvalidAccessKey                = errors.New("invalid access key")
 	ErrInvalidSecretKey                = errors.New("invalid secret key")
 	ErrIsOwner                         = errors.New("user owns the volume")
+	ErrQuotaNotExists                  = errors.New("quota not exists")
+	ErrClientSessionNotExists          = errors.New("client session not exists")
+	ErrClientSessionExpired            = errors.New("client session expired or evicted")
+	ErrVolReadOnly                     = errors.New("volume is read only")
 )
 
 // http response error code and error message definitions
@@ -144,6 +148,10 @@ const (
 	ErrCodeInvalidAccessKey
 	ErrCodeInvalidSecretKey
 	ErrCodeIsOwner
+	ErrCodeQuotaNotExists
+	ErrCodeClientSessionNotExists
+	ErrCodeClientSessionExpired
+	ErrCodeVolReadOnly
 )
 
 // Err2CodeMap error map to code
@@ -205,6 +213,10 @@ var Err2CodeMap = map[error]int32{
 	ErrInvalidAccessKey:                ErrCodeInvalidAccessKey,
 	ErrInvalidSecretKey:                ErrCodeInvalidSecretKey,
 	ErrIsOwner:                         ErrCodeIsOwner,
+	ErrQuotaNotExists:                  ErrCodeQuotaNotExists,
+	ErrClientSessionNotExists:          ErrCodeClientSessionNotExists,
+	ErrClientSessionExpired:            ErrCodeClientSessionExpired,
+	ErrVolReadOnly:                     ErrCodeVolReadOnly,
 }
 
 func ParseErrorCode(code int32) error {
@@ -273,6 +285,10 @@ var code2ErrMap = map[int32]error{
 	ErrCodeInvalidAccessKey:                ErrInvalidAccessKey,
 	ErrCodeInvalidSecretKey:                ErrInvalidSecretKey,
 	ErrCodeIsOwner:                         ErrIsOwner,
+	ErrCodeQuotaNotExists:                  ErrQuotaNotExists,
+	ErrCodeClientSessionNotExists:          ErrClientSessionNotExists,
+	ErrCodeClientSessionExpired:            ErrClientSessionExpired,
+	ErrCodeVolReadOnly:                     ErrVolReadOnly,
 }
 
 type GeneralResp struct {
@@ -0,0 +1,178 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// ProtocolVersion is the version of the packet protocol between the clients and the meta nodes or the data nodes.
+// The nodes before the negotiation are taken as the version 0.
+const ProtocolVersion uint32 = 1
+
+// The features of the packet protocol, which are only used by a client once the node has it negotiated.
+// A new bit is added for each operation or change of the packets the nodes before it do not understand.
+const (
+	FeatureMetaReadDirPlus           uint64 = 1 << iota // OpMetaReadDirPlus
+	FeatureMetaCaseInsensitiveLookup                    // CaseInsensitive of LookupRequest
+	FeatureMetaCloneInode                               // OpMetaCloneInode
+	FeatureMetaPunchHole                                // OpMetaPunchHole
+	FeatureMetaFollowerRead                             // FollowerRead of the lookup, getattr and readdir requests
+	FeatureMetaTransaction                              // OpMetaTxBegin, OpMetaTxUpdate and OpMetaTxFinish
+)
+
+// The features supported by the nodes of this release.
+const (
+	MetaNodeFeatures = FeatureMetaReadDirPlus | FeatureMetaCaseInsensitiveLookup | FeatureMetaCloneInode |
+		FeatureMetaPunchHole | FeatureMetaFollowerRead | FeatureMetaTransaction
+	DataNodeFeatures = uint64(0)
+)
+
+// FeatureNegotiationInterval is the interval a node is negotiated again, so a node upgraded or
+// downgraded in a rolling upgrade is found out.
+const FeatureNegotiationInterval = 5 * time.Minute
+
+type HandshakeRequest struct {
+	Version  uint32 `json:"version"`
+	Features uint64 `json:"features"`
+}
+
+type HandshakeResponse struct {
+	Version  uint32 `json:"version"`
+	Features uint64 `json:"features"`
+}
+
+// HandshakeReply answers the OpHandshake packet with the version and the features of the node
+// which are also supported by the peer.
+func (p *Packet) HandshakeReply(features uint64) {
+	req := &HandshakeRequest{}
+	if err := json.Unmarshal(p.Data[:p.Size], req); err != nil {
+		p.PacketErrorWithBody(OpArgMismatchErr, []byte(err.Error()))
+		return
+	}
+	resp := &HandshakeResponse{Version: ProtocolVersion, Features: features & req.Features}
+	if req.Version < resp.Version {
+		resp.Version = req.Version
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(data)
+}
+
+// PeerFeatures records the features negotiated with the nodes by their addresses.
+// A node is negotiated in the background the first time it is asked for and once its negotiation expires,
+// the node is taken as not supporting any feature until the negotiation succeeds.
+type PeerFeatures struct {
+	sync.Mutex
+	pool     *util.ConnectPool
+	features uint64
+	peers    map[string]*peerFeatures
+}
+
+type peerFeatures struct {
+	features    uint64
+	expire      time.Time
+	negotiating bool
+}
+
+// NewPeerFeatures returns the features negotiated through the connections of the pool,
+// the features of the nodes are limited to the given ones of the client.
+func NewPeerFeatures(pool *util.ConnectPool, features uint64) *PeerFeatures {
+	return &PeerFeatures{
+		pool:     pool,
+		features: features,
+		peers:    make(map[string]*peerFeatures),
+	}
+}
+
+// Supports tells if the node has negotiated all the given features.
+func (pf *PeerFeatures) Supports(addr string, features uint64) bool {
+	pf.Lock()
+	defer pf.Unlock()
+	peer, ok := pf.peers[addr]
+	if !ok {
+		peer = new(peerFeatures)
+		pf.peers[addr] = peer
+	}
+	if !peer.negotiating && time.Now().After(peer.expire) {
+		peer.negotiating = true
+		go pf.negotiate(addr, peer)
+	}
+	return peer.features&features == features
+}
+
+func (pf *PeerFeatures) negotiate(addr string, peer *peerFeatures) {
+	features, err := pf.handshake(addr)
+	pf.Lock()
+	defer pf.Unlock()
+	peer.negotiating = false
+	peer.expire = time.Now().Add(FeatureNegotiationInterval)
+	if err != nil {
+		log.LogWarnf("negotiate features: addr(%v) err(%v)", addr, err)
+		return
+	}
+	if peer.features != features {
+		log.LogInfof("negotiate features: addr(%v) features(%x) -> (%x)", addr, peer.features, features)
+	}
+	peer.features = features
+}
+
+// handshake returns the features negotiated with the node, a node before the negotiation
+// rejects the OpHandshake packet and supports none of the features.
+func (pf *PeerFeatures) handshake(addr string) (features uint64, err error) {
+	conn, err := pf.pool.GetConnect(addr)
+	if err != nil {
+		return
+	}
+	defer func() {
+		pf.pool.PutConnect(conn, err != nil)
+	}()
+	p := NewPacket()
+	p.Opcode = OpHandshake
+	p.ReqID = GenerateRequestID()
+	if p.Data, err = json.Marshal(&HandshakeRequest{Version: ProtocolVersion, Features: pf.features}); err != nil {
+		return
+	}
+	p.Size = uint32(len(p.Data))
+	if err = p.WriteToConn(conn); err != nil {
+		return
+	}
+	reply := NewPacket()
+	if err = reply.ReadFromConn(conn, ReadDeadlineTime); err != nil {
+		return
+	}
+	if reply.ReqID != p.ReqID || reply.Opcode != p.Opcode {
+		err = fmt.Errorf("mismatched reply: req(%v) reply(%v)", p.GetUniqueLogId(), reply.GetUniqueLogId())
+		return
+	}
+	if reply.ResultCode != OpOk {
+		log.LogDebugf("negotiate features: addr(%v) rejected(%v)", addr, reply.GetResultMsg())
+		return 0, nil
+	}
+	resp := &HandshakeResponse{}
+	if err = json.Unmarshal(reply.Data[:reply.Size], resp); err != nil {
+		return
+	}
+	return resp.Features & pf.features, nil
+}
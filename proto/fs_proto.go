@@ -73,6 +73,7 @@ type InodeInfo struct {
 	CreateTime time.Time `json:"ct"`
 	AccessTime time.Time `json:"at"`
 	Target     []byte    `json:"tgt"`
+	QuotaId    uint64    `json:"qid"`
 
 	expiration int64
 }
@@ -138,6 +139,7 @@ type CreateInodeRequest struct {
 	Uid         uint32 `json:"uid"`
 	Gid         uint32 `json:"gid"`
 	Target      []byte `json:"tgt"`
+	ClientID    uint64 `json:"cid,omitempty"` // tells the retries of the request by the id of the packet
 }
 
 // CreateInodeResponse defines the response to the request of creating an inode.
@@ -145,11 +147,41 @@ type CreateInodeResponse struct {
 	Info *InodeInfo `json:"info"`
 }
 
+// QuotaTagRequest tags the inodes of a partition with the directory quota they belong to, which is sent
+// by the partition holding their parent directory. The quota is the root inode of the nearest quota above.
+type QuotaTagRequest struct {
+	VolName     string   `json:"vol"`
+	PartitionID uint64   `json:"pid"`
+	Inodes      []uint64 `json:"inos"`
+	QuotaId     uint64   `json:"qid"`
+}
+
+// CloneInodeRequest defines the request to create an inode sharing the extents of a file.
+// The new inode is created in the partition of the file, so the extents are shared within the partition.
+type CloneInodeRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	Inode       uint64 `json:"ino"`
+	Uid         uint32 `json:"uid"`
+	Gid         uint32 `json:"gid"`
+}
+
+// CloneInodeResponse defines the response to the request of cloning an inode.
+type CloneInodeResponse struct {
+	Info *InodeInfo `json:"info"`
+}
+
+// SharedExtentsXAttr marks the inodes sharing extents with others, which are written copy-on-write,
+// and whose extents are deleted once no other inode of the partition refers to them.
+const SharedExtentsXAttr = "cfs.shared"
+
 // LinkInodeRequest defines the request to link an inode.
 type LinkInodeRequest struct {
 	VolName     string `json:"vol"`
 	PartitionID uint64 `json:"pid"`
 	Inode       uint64 `json:"ino"`
+	ClientID    uint64 `json:"cid,omitempty"`
+	HardLink    bool   `json:"hl,omitempty"` // set by the hard links, which are refused for the directories
 }
 
 // LinkInodeResponse defines the response to the request of linking an inode.
@@ -162,6 +194,7 @@ type UnlinkInodeRequest struct {
 	VolName     string `json:"vol"`
 	PartitionID uint64 `json:"pid"`
 	Inode       uint64 `json:"ino"`
+	ClientID    uint64 `json:"cid,omitempty"`
 }
 
 // UnlinkInodeRequest defines the request to unlink an inode.
@@ -198,23 +231,56 @@ type BatchEvictInodeRequest struct {
 	Inodes      []uint64 `json:"inos"`
 }
 
+// Credential identifies the user on whose behalf a request is sent, the meta node checks the permissions of
+// the user on the inodes of the request. The requests without it are not checked, unless the volume enforces the
+// permissions. The user is asserted by the client, so any client holding a token of the volume may claim any user.
+type Credential struct {
+	Uid  uint32   `json:"uid"`
+	Gid  uint32   `json:"gid"`
+	Gids []uint32 `json:"gids,omitempty"` // the supplementary groups
+}
+
+// InGroup checks whether the user is a member of the group.
+func (c *Credential) InGroup(gid uint32) bool {
+	if c.Gid == gid {
+		return true
+	}
+	for _, g := range c.Gids {
+		if g == gid {
+			return true
+		}
+	}
+	return false
+}
+
+// The permissions checked for a Credential, the same as R_OK, W_OK and X_OK of access(2).
+const (
+	PermRead  uint32 = 4
+	PermWrite uint32 = 2
+	PermExec  uint32 = 1
+)
+
 // CreateDentryRequest defines the request to create a dentry.
 type CreateDentryRequest struct {
-	VolName     string `json:"vol"`
-	PartitionID uint64 `json:"pid"`
-	ParentID    uint64 `json:"pino"`
-	Inode       uint64 `json:"ino"`
-	Name        string `json:"name"`
-	Mode        uint32 `json:"mode"`
+	VolName     string      `json:"vol"`
+	PartitionID uint64      `json:"pid"`
+	ParentID    uint64      `json:"pino"`
+	Inode       uint64      `json:"ino"`
+	Name        string      `json:"name"`
+	Mode        uint32      `json:"mode"`
+	Cred        *Credential `json:"cred,omitempty"`
+	ClientID    uint64      `json:"cid,omitempty"`
 }
 
 // UpdateDentryRequest defines the request to update a dentry.
 type UpdateDentryRequest struct {
-	VolName     string `json:"vol"`
-	PartitionID uint64 `json:"pid"`
-	ParentID    uint64 `json:"pino"`
-	Name        string `json:"name"`
-	Inode       uint64 `json:"ino"` // new inode number
+	VolName     string      `json:"vol"`
+	PartitionID uint64      `json:"pid"`
+	ParentID    uint64      `json:"pino"`
+	Name        string      `json:"name"`
+	Inode       uint64      `json:"ino"` // new inode number
+	Cred        *Credential `json:"cred,omitempty"`
+	ClientID    uint64      `json:"cid,omitempty"`
 }
 
 // UpdateDentryResponse defines the response to the request of updating a dentry.
@@ -224,10 +290,12 @@ type UpdateDentryResponse struct {
 
 // DeleteDentryRequest define the request tp delete a dentry.
 type DeleteDentryRequest struct {
-	VolName     string `json:"vol"`
-	PartitionID uint64 `json:"pid"`
-	ParentID    uint64 `json:"pino"`
-	Name        string `json:"name"`
+	VolName     string      `json:"vol"`
+	PartitionID uint64      `json:"pid"`
+	ParentID    uint64      `json:"pino"`
+	Name        string      `json:"name"`
+	Cred        *Credential `json:"cred,omitempty"`
+	ClientID    uint64      `json:"cid,omitempty"`
 }
 
 type BatchDeleteDentryRequest struct {
@@ -252,35 +320,44 @@ type BatchDeleteDentryResponse struct {
 
 // LookupRequest defines the request for lookup.
 type LookupRequest struct {
-	VolName     string `json:"vol"`
-	PartitionID uint64 `json:"pid"`
-	ParentID    uint64 `json:"pino"`
-	Name        string `json:"name"`
+	VolName         string      `json:"vol"`
+	PartitionID     uint64      `json:"pid"`
+	ParentID        uint64      `json:"pino"`
+	Name            string      `json:"name"`
+	CaseInsensitive bool        `json:"ci,omitempty"` // match the name ignoring the case if there is no exact match
+	FollowerRead    bool        `json:"fr,omitempty"` // may be served by a follower, see FeatureMetaFollowerRead
+	Cred            *Credential `json:"cred,omitempty"`
 }
 
 // LookupResponse defines the response for the loopup request.
 type LookupResponse struct {
 	Inode uint64 `json:"ino"`
 	Mode  uint32 `json:"mode"`
+	Name  string `json:"name,omitempty"` // name of the dentry matched by a case-insensitive lookup
 }
 
 // InodeGetRequest defines the request to get the inode.
 type InodeGetRequest struct {
-	VolName     string `json:"vol"`
-	PartitionID uint64 `json:"pid"`
-	Inode       uint64 `json:"ino"`
+	VolName      string      `json:"vol"`
+	PartitionID  uint64      `json:"pid"`
+	Inode        uint64      `json:"ino"`
+	FollowerRead bool        `json:"fr,omitempty"`
+	Cred         *Credential `json:"cred,omitempty"`
+	Mask         uint32      `json:"mask,omitempty"` // the permissions of Cred to check, see PermRead
 }
 
 // InodeGetResponse defines the response to the InodeGetRequest.
 type InodeGetResponse struct {
-	Info *InodeInfo `json:"info"`
+	Info   *InodeInfo `json:"info"`
+	Shared bool       `json:"shared,omitempty"` // the extents are shared with other inodes, see SharedExtentsXAttr
 }
 
 // BatchInodeGetRequest defines the request to get the inode in batch.
 type BatchInodeGetRequest struct {
-	VolName     string   `json:"vol"`
-	PartitionID uint64   `json:"pid"`
-	Inodes      []uint64 `json:"inos"`
+	VolName      string   `json:"vol"`
+	PartitionID  uint64   `json:"pid"`
+	Inodes       []uint64 `json:"inos"`
+	FollowerRead bool     `json:"fr,omitempty"`
 }
 
 // BatchInodeGetResponse defines the response to the request of getting the inode in batch.
@@ -289,10 +366,17 @@ type BatchInodeGetResponse struct {
 }
 
 // ReadDirRequest defines the request to read dir.
+// The dentries are replied in the order of the names, starting after Marker,
+// and all of them are replied if Limit is zero.
 type ReadDirRequest struct {
-	VolName     string `json:"vol"`
-	PartitionID uint64 `json:"pid"`
-	ParentID    uint64 `json:"pino"`
+	VolName      string      `json:"vol"`
+	PartitionID  uint64      `json:"pid"`
+	ParentID     uint64      `json:"pino"`
+	Marker       string      `json:"marker"`
+	Limit        uint64      `json:"limit"`
+	Prefix       string      `json:"prefix,omitempty"` // only the dentries whose names have the prefix are read
+	FollowerRead bool        `json:"fr,omitempty"`
+	Cred         *Credential `json:"cred,omitempty"`
 }
 
 // ReadDirResponse defines the response to the request of reading dir.
@@ -300,6 +384,13 @@ type ReadDirResponse struct {
 	Children []Dentry `json:"children"`
 }
 
+// ReadDirPlusResponse defines the response to the request of reading dir with the inodes of the dentries,
+// the inodes which can not be got from their partitions are left out.
+type ReadDirPlusResponse struct {
+	Children []Dentry     `json:"children"`
+	Infos    []*InodeInfo `json:"infos"`
+}
+
 // BatchAppendExtentKeyRequest defines the request to append an extent key.
 type AppendExtentKeyRequest struct {
 	VolName     string    `json:"vol"`
@@ -320,6 +411,7 @@ type GetExtentsResponse struct {
 	Generation uint64      `json:"gen"`
 	Size       uint64      `json:"sz"`
 	Extents    []ExtentKey `json:"eks"`
+	Shared     bool        `json:"shared,omitempty"` // the extents are shared with other inodes, see SharedExtentsXAttr
 }
 
 // TruncateRequest defines the request to truncate.
@@ -330,17 +422,27 @@ type TruncateRequest struct {
 	Size        uint64 `json:"sz"`
 }
 
-// SetAttrRequest defines the request to set attribute.
-type SetAttrRequest struct {
+// PunchHoleRequest defines the request to remove a range of a file from its extents, the size of the file is kept.
+type PunchHoleRequest struct {
 	VolName     string `json:"vol"`
 	PartitionID uint64 `json:"pid"`
 	Inode       uint64 `json:"ino"`
-	Mode        uint32 `json:"mode"`
-	Uid         uint32 `json:"uid"`
-	Gid         uint32 `json:"gid"`
-	ModifyTime  int64  `json:"mt"`
-	AccessTime  int64  `json:"at"`
-	Valid       uint32 `json:"valid"`
+	Offset      uint64 `json:"off"`
+	Size        uint64 `json:"sz"`
+}
+
+// SetAttrRequest defines the request to set attribute.
+type SetAttrRequest struct {
+	VolName     string      `json:"vol"`
+	PartitionID uint64      `json:"pid"`
+	Inode       uint64      `json:"ino"`
+	Mode        uint32      `json:"mode"`
+	Uid         uint32      `json:"uid"`
+	Gid         uint32      `json:"gid"`
+	ModifyTime  int64       `json:"mt"`
+	AccessTime  int64       `json:"at"`
+	Valid       uint32      `json:"valid"`
+	Cred        *Credential `json:"cred,omitempty"`
 }
 
 const (
@@ -373,12 +475,19 @@ type AppendExtentKeysRequest struct {
 	Extents     []ExtentKey `json:"eks"`
 }
 
+// Flags of the SetXAttrRequest, the same as setxattr(2).
+const (
+	XAttrCreate  = 1
+	XAttrReplace = 2
+)
+
 type SetXAttrRequest struct {
 	VolName     string `json:"vol"`
 	PartitionId uint64 `json:"pid"`
 	Inode       uint64 `json:"ino"`
 	Key         string `json:"key"`
 	Value       string `json:"val"`
+	Flag        int    `json:"flag,omitempty"` // XAttrCreate or XAttrReplace, checked by the meta node
 }
 
 type GetXAttrRequest struct {
@@ -429,6 +538,187 @@ type BatchGetXAttrResponse struct {
 	XAttrs      []*XAttrInfo
 }
 
+// Types of a file lock.
+const (
+	FileLockRead uint8 = iota + 1
+	FileLockWrite
+	FileLockUnlock
+)
+
+// FileLock defines a byte range lock on an inode. End is inclusive.
+// Locks taken by flock(2) are kept apart from fcntl(2) locks and always
+// cover the whole file.
+type FileLock struct {
+	Client uint64 `json:"cli"`
+	Owner  uint64 `json:"owner"`
+	Pid    int32  `json:"lpid"`
+	Start  uint64 `json:"start"`
+	End    uint64 `json:"end"`
+	Type   uint8  `json:"type"`
+	Flock  bool   `json:"flock"`
+}
+
+// LockReclaimInterval is the interval the clients reclaim the locks they hold, so that
+// a new leader of the meta partition gets them back in its grace period.
+const LockReclaimInterval = 10 * time.Second
+
+// SameOwner tells whether the locks are taken by the same owner of the same kind.
+func (lk *FileLock) SameOwner(other *FileLock) bool {
+	return lk.Client == other.Client && lk.Owner == other.Owner && lk.Flock == other.Flock
+}
+
+// Overlaps tells whether the ranges of the locks overlap.
+func (lk *FileLock) Overlaps(other *FileLock) bool {
+	return lk.Start <= other.End && other.Start <= lk.End
+}
+
+// MergeFileLock returns the locks held on an inode after the lock is taken or released
+// without conflicts. The range is cut out of the locks of the same owner first, so that
+// taking a lock converts or splits the existing ones as POSIX requires.
+func MergeFileLock(held []*FileLock, lk *FileLock) []*FileLock {
+	result := make([]*FileLock, 0, len(held)+1)
+	for _, h := range held {
+		if !h.SameOwner(lk) || !h.Overlaps(lk) {
+			result = append(result, h)
+			continue
+		}
+		if h.Start < lk.Start {
+			left := *h
+			left.End = lk.Start - 1
+			result = append(result, &left)
+		}
+		if h.End > lk.End {
+			right := *h
+			right.Start = lk.End + 1
+			result = append(result, &right)
+		}
+	}
+	if lk.Type != FileLockUnlock {
+		l := *lk
+		result = append(result, &l)
+	}
+	return result
+}
+
+// SetLockRequest defines the request to acquire or release a file lock.
+// Reclaim is set by the client holding the lock, which is granted in the grace period
+// of a new leader of the meta partition.
+type SetLockRequest struct {
+	VolName     string   `json:"vol"`
+	PartitionId uint64   `json:"pid"`
+	Inode       uint64   `json:"ino"`
+	Lock        FileLock `json:"lk"`
+	Reclaim     bool     `json:"reclaim,omitempty"`
+}
+
+// GetLockRequest defines the request to test whether a file lock could be taken.
+type GetLockRequest struct {
+	VolName     string   `json:"vol"`
+	PartitionId uint64   `json:"pid"`
+	Inode       uint64   `json:"ino"`
+	Lock        FileLock `json:"lk"`
+}
+
+// GetLockResponse defines the response to the request of getting a file lock.
+// Lock is the conflicting lock, or nil if there is none.
+type GetLockResponse struct {
+	Lock *FileLock `json:"lk"`
+}
+
+// GetDirStatRequest defines the request to get the summaries of the directories in a meta partition.
+type GetDirStatRequest struct {
+	VolName     string   `json:"vol"`
+	PartitionId uint64   `json:"pid"`
+	Inodes      []uint64 `json:"inos"`
+}
+
+// GetDirStatResponse defines the response to the request of getting the summaries of the directories.
+type GetDirStatResponse struct {
+	Summaries []*DirSummary `json:"summaries"`
+}
+
+// The operations of the changes of the metadata.
+const (
+	ChangeCreateInode  = "createInode"
+	ChangeLinkInode    = "linkInode"
+	ChangeUnlinkInode  = "unlinkInode"
+	ChangeEvictInode   = "evictInode"
+	ChangeWrite        = "write" // the extents of the file are appended, truncated or punched
+	ChangeSetAttr      = "setAttr"
+	ChangeSetXAttr     = "setXAttr"
+	ChangeRemoveXAttr  = "removeXAttr"
+	ChangeCreateDentry = "createDentry"
+	ChangeDeleteDentry = "deleteDentry"
+	ChangeUpdateDentry = "updateDentry"
+)
+
+// MetaChange defines a change of the metadata applied by a meta partition.
+type MetaChange struct {
+	Index    uint64 `json:"idx"` // the raft log index the change is applied at
+	Op       string `json:"op"`
+	Inode    uint64 `json:"ino"`
+	OldInode uint64 `json:"oino,omitempty"` // the inode the dentry pointed to before updateDentry
+	ParentID uint64 `json:"pino,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Type     uint32 `json:"type,omitempty"`
+	Time     int64  `json:"time"`
+}
+
+// ReadChangesRequest defines the request to read the changes applied after the index From.
+type ReadChangesRequest struct {
+	VolName     string `json:"vol"`
+	PartitionId uint64 `json:"pid"`
+	From        uint64 `json:"from"`
+	Limit       int    `json:"limit"`
+}
+
+// ReadChangesResponse defines the response to the request of reading the changes. Truncated is set
+// if some changes after From are no longer kept, the reader has to rescan the metadata then.
+type ReadChangesResponse struct {
+	Changes   []*MetaChange `json:"changes"`
+	ApplyID   uint64        `json:"apply"`
+	Truncated bool          `json:"truncated"`
+}
+
+// The types of the events of the volume.
+const (
+	EventCreate     = "create"
+	EventDelete     = "delete"
+	EventRename     = "rename"
+	EventWriteClose = "write-close" // the writes to the file have settled
+)
+
+// VolumeEvent defines an event of the volume derived from the changes of a meta partition.
+type VolumeEvent struct {
+	Index       uint64 `json:"idx"`
+	Type        string `json:"type"`
+	Inode       uint64 `json:"ino"`
+	Mode        uint32 `json:"mode,omitempty"`
+	ParentID    uint64 `json:"pino,omitempty"`
+	Name        string `json:"name,omitempty"`
+	OldParentID uint64 `json:"opino,omitempty"` // the source of the rename
+	OldName     string `json:"oname,omitempty"`
+	Time        int64  `json:"time"`
+}
+
+// ReadEventsRequest defines the request to read the events after the index From, waiting
+// at most Wait seconds for the events to come.
+type ReadEventsRequest struct {
+	VolName     string `json:"vol"`
+	PartitionId uint64 `json:"pid"`
+	From        uint64 `json:"from"`
+	Limit       int    `json:"limit"`
+	Wait        int64  `json:"wait"`
+}
+
+// ReadEventsResponse defines the response to the request of reading the events. The reader continues
+// from Cursor, and rescans the metadata if Truncated is set.
+type ReadEventsResponse struct {
+	Events    []*VolumeEvent `json:"events"`
+	Cursor    uint64         `json:"cursor"`
+	Truncated bool           `json:"truncated"`
+}
+
 type MultipartInfo struct {
 	ID       string               `json:"id"`
 	Path     string               `json:"path"`
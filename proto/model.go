@@ -20,6 +20,18 @@ import (
 
 const (
 	DefaultZoneName = "default"
+
+	// the fault domains of the nodes registered without the region or the rack
+	DefaultRegionName = "default"
+	DefaultRackName   = "default"
+)
+
+// the levels of the fault domains in the topology path region/zone/rack/host of the nodes
+const (
+	FaultDomainRegion = "region"
+	FaultDomainZone   = "zone"
+	FaultDomainRack   = "rack"
+	FaultDomainHost   = "host"
 )
 
 // MetaNode defines the structure of a meta node
@@ -28,6 +40,8 @@ type MetaNodeInfo struct {
 	Addr                      string
 	IsActive                  bool
 	ZoneName                  string `json:"Zone"`
+	Region                    string
+	Rack                      string
 	MaxMemAvailWeight         uint64 `json:"MaxMemAvailWeight"`
 	Total                     uint64 `json:"TotalWeight"`
 	Used                      uint64 `json:"UsedWeight"`
@@ -48,6 +62,8 @@ type DataNodeInfo struct {
 	AvailableSpace            uint64
 	ID                        uint64
 	ZoneName                  string `json:"Zone"`
+	Region                    string
+	Rack                      string
 	Addr                      string
 	ReportTime                time.Time
 	IsActive                  bool
@@ -59,6 +75,8 @@ type DataNodeInfo struct {
 	NodeSetID                 uint64
 	PersistenceDataPartitions []uint64
 	BadDisks                  []string
+	MediaSpaces               map[string]uint64
+	DiskSmart                 []*DiskSmartInfo
 }
 
 // MetaPartition defines the structure of a meta partition
@@ -156,6 +174,92 @@ type VolStatInfo struct {
 	EnableToken bool
 }
 
+// ClusterOverview aggregates the capacity and the health of the cluster into one document for the dashboards.
+type ClusterOverview struct {
+	Name             string
+	LeaderAddr       string
+	DataNodeStatInfo *NodeStatInfo
+	MetaNodeStatInfo *NodeStatInfo
+	Zones            []*ZoneOverview
+	DataNodes        []*NodeOverview
+	MetaNodes        []*NodeOverview
+	Vols             []*VolOverview
+	DataPartitions   *PartitionsHealth
+	MetaPartitions   *PartitionsHealth
+	PendingRepairs   *PendingRepairs
+	FaultDomains     []*FaultDomainView // the regions of the cluster down to the racks
+}
+
+// ZoneOverview provides the capacity of the data nodes and the meta nodes of a zone.
+type ZoneOverview struct {
+	Name         string
+	Status       string
+	DataNodeStat *ZoneNodesStat
+	MetaNodeStat *ZoneNodesStat
+}
+
+// NodeOverview provides the capacity, the partitions and the raft leaders of a data or meta node.
+type NodeOverview struct {
+	ID             uint64
+	Addr           string
+	Zone           string
+	Region         string
+	Rack           string
+	IsActive       bool
+	IsWritable     bool
+	ToBeOffline    bool
+	Total          uint64
+	Used           uint64
+	UsageRatio     float64
+	PartitionCount int
+	LeaderCount    int // the partitions of which the node is the raft leader
+	PendingTasks   int // the admin tasks not yet responded by the node
+	BadDisks       []string
+}
+
+// FaultDomainView provides the capacity of the data nodes and the meta nodes of a fault domain,
+// which is a region, a zone, a rack or a host, and the fault domains under it.
+type FaultDomainView struct {
+	Name         string
+	Level        string // region, zone, rack or host
+	Path         string // the topology path of the fault domain, such as region/zone/rack
+	DataNodeStat *ZoneNodesStat
+	MetaNodeStat *ZoneNodesStat
+	Children     []*FaultDomainView `json:",omitempty"`
+}
+
+// VolOverview provides the capacity and the partition health of a volume.
+type VolOverview struct {
+	Name           string
+	Owner          string
+	Status         uint8
+	Capacity       uint64 // GB
+	TotalSize      uint64
+	UsedSize       uint64
+	UsedRatio      string
+	DataPartitions *PartitionsHealth
+	MetaPartitions *PartitionsHealth
+}
+
+// PartitionsHealth counts the partitions by their status.
+type PartitionsHealth struct {
+	Total       int
+	ReadWrite   int
+	ReadOnly    int
+	Unavailable int
+	NoLeader    int
+}
+
+// PendingRepairs provides the partitions and the nodes waiting to be repaired or decommissioned.
+type PendingRepairs struct {
+	BadDataPartitions    []BadPartitionView
+	BadMetaPartitions    []BadPartitionView
+	OfflineDataNodes     []string // the data nodes being decommissioned
+	OfflineMetaNodes     []string // the meta nodes being decommissioned
+	DataNodePendingTasks int
+	MetaNodePendingTasks int
+}
+
 // DataPartition represents the structure of storing the file contents.
 type DataPartitionInfo struct {
 	PartitionID             uint64
@@ -170,8 +274,12 @@ type DataPartitionInfo struct {
 	VolName                 string
 	VolID                   uint64
 	OfflinePeerID           uint64
+	IsManual                bool // set read only manually, such as after shrinking the volume
+	MediaType               string
+	LastAccessTime          int64
 	FileInCoreMap           map[string]*FileInCore
 	FilesWithMissingReplica map[string]int64 // key: file name, value: last time when a missing replica is found
+	Divergences             []*ReplicaDivergence
 }
 
 //FileInCore define file in data partition
@@ -200,14 +308,30 @@ type DataReplica struct {
 	IsLeader        bool
 	NeedsToCompare  bool
 	DiskPath        string
+	MediaType       string
+	CorruptExtents  []uint64 // extents found corrupt by the scrubber of the data node
+	AppliedID       uint64   // applied index reported when loading
 }
 
 // data partition diagnosis represents the inactive data nodes, corrupt data partitions, and data partitions lack of replicas
 type DataPartitionDiagnosis struct {
-	InactiveDataNodes           []string
-	CorruptDataPartitionIDs     []uint64
-	LackReplicaDataPartitionIDs []uint64
-	BadDataPartitionIDs         []BadPartitionView
+	InactiveDataNodes             []string
+	CorruptDataPartitionIDs       []uint64
+	LackReplicaDataPartitionIDs   []uint64
+	BadDataPartitionIDs           []BadPartitionView
+	CorruptExtentDataPartitionIDs []uint64 // partitions with corrupt extents reported by the scrubbers
+	DivergentDataPartitionIDs     []uint64 // partitions whose replicas diverge, see ReplicaDivergence
+}
+
+// ReplicaDivergence defines a divergence between the replicas of a data partition found by the consistency checker.
+type ReplicaDivergence struct {
+	PartitionID uint64
+	VolName     string
+	Kind        string            // crc, size or appliedID
+	ExtentID    uint64            // 0 if the kind is appliedID
+	Values      map[string]uint64 // key: address of the replica, value: crc, size or applied index
+	Authority   string            // address of the replica to repair the others from, empty if it can not be repaired
+	FoundTime   int64
 }
 
 // meta partition diagnosis represents the inactive meta nodes, corrupt meta partitions, and meta partitions lack of replicas
@@ -47,6 +47,31 @@ const (
 	EnableXattr
 	NearRead
 	EnablePosixACL
+	EnablePosixLock
+	ReadCacheSize
+	ReadIops
+	WriteIops
+	ReadBps
+	WriteBps
+	EnableAudit
+	AuditSink
+	SlowOpThreshold
+	ReaddirPlus
+	NdcacheTimeout
+	AsyncRmdir
+	DirectIO
+	MaxReadAhead
+	PrefetchSize
+	ReadPolicy
+	HedgeDelay
+	MetaFollowerRead
+	RootSquash
+	AllSquash
+	AnonUid
+	AnonGid
+	UidMap
+	GidMap
+	PermissionCheck
 
 	MaxMountOption
 )
@@ -112,6 +137,31 @@ func InitMountOptions(opts []MountOption) {
 	opts[MaxCPUs] = MountOption{"maxcpus", "The maximum number of CPUs that can be executing", "", int64(-1)}
 	opts[EnableXattr] = MountOption{"enableXattr", "Enable xattr support", "", false}
 	opts[EnablePosixACL] = MountOption{"enablePosixACL", "enable posix ACL support", "", false}
+	opts[EnablePosixLock] = MountOption{"enablePosixLock", "Enable flock and fcntl lock support across mounts", "", false}
+	opts[ReadCacheSize] = MountOption{"readCacheSize", "Size of the client read cache in MB", "", int64(-1)}
+	opts[ReadIops] = MountOption{"readIops", "Read requests per second limit, same as readRate", "", int64(-1)}
+	opts[WriteIops] = MountOption{"writeIops", "Write requests per second limit, same as writeRate", "", int64(-1)}
+	opts[ReadBps] = MountOption{"readBps", "Read bandwidth limit in bytes per second", "", int64(-1)}
+	opts[WriteBps] = MountOption{"writeBps", "Write bandwidth limit in bytes per second", "", int64(-1)}
+	opts[EnableAudit] = MountOption{"enableAudit", "Record the mutations of the filesystem in the audit log", "", false}
+	opts[AuditSink] = MountOption{"auditSink", "HTTP URL to post the audit log to", "", ""}
+	opts[SlowOpThreshold] = MountOption{"slowOpThreshold", "Log the FUSE requests slower than the threshold in milliseconds", "", int64(-1)}
+	opts[ReaddirPlus] = MountOption{"readdirPlus", "Read the directories with the attributes of the entries", "", false}
+	opts[NdcacheTimeout] = MountOption{"ndcacheTimeout", "Negative Dentry Cache Expiration Time", "", int64(-1)}
+	opts[AsyncRmdir] = MountOption{"asyncRmdir", "Remove the non-empty directories in background on rmdir", "", false}
+	opts[DirectIO] = MountOption{"directIO", "Bypass the page cache and the read cache for all the files", "", false}
+	opts[MaxReadAhead] = MountOption{"maxReadAhead", "Max bytes of the kernel read-ahead", "", int64(-1)}
+	opts[PrefetchSize] = MountOption{"prefetchSize", "Max size in MB prefetched into the read cache ahead of the sequential reads", "", int64(-1)}
+	opts[ReadPolicy] = MountOption{"readPolicy", "Replicas to read from: primary, roundRobin or hedged", "", ""}
+	opts[HedgeDelay] = MountOption{"hedgeDelay", "Delay in milliseconds to send a hedged read to another replica", "", int64(-1)}
+	opts[MetaFollowerRead] = MountOption{"metaFollowerRead", "Read the metadata from the followers of the meta partitions", "", false}
+	opts[RootSquash] = MountOption{"rootSquash", "Create the inodes of the root user as the anonymous user", "", false}
+	opts[AllSquash] = MountOption{"allSquash", "Create the inodes of all the users as the anonymous user", "", false}
+	opts[AnonUid] = MountOption{"anonUid", "Uid of the anonymous user, 65534 by default", "", int64(-1)}
+	opts[AnonGid] = MountOption{"anonGid", "Gid of the anonymous user, 65534 by default", "", int64(-1)}
+	opts[UidMap] = MountOption{"uidMap", "Map the local uids to the uids in the volume, e.g. 1000:2000,1001:2001", "", ""}
+	opts[GidMap] = MountOption{"gidMap", "Map the local gids to the gids in the volume, e.g. 1000:2000,1001:2001", "", ""}
+	opts[PermissionCheck] = MountOption{"permissionCheck", "Where to check the permissions of the users: none, client or server", "", ""}
 
 	for i := 0; i < MaxMountOption; i++ {
 		flag.StringVar(&opts[i].cmdlineValue, opts[i].keyword, "", opts[i].description)
@@ -209,36 +259,79 @@ func (opt *MountOption) GetInt64() int64 {
 }
 
 type MountOptions struct {
-	Config         *config.Config
-	MountPoint     string
-	Volname        string
-	Owner          string
-	Master         string
-	Logpath        string
-	Loglvl         string
-	Profport       string
-	IcacheTimeout  int64
-	LookupValid    int64
-	AttrValid      int64
-	ReadRate       int64
-	WriteRate      int64
-	EnSyncWrite    int64
-	AutoInvalData  int64
-	UmpDatadir     string
-	Rdonly         bool
-	WriteCache     bool
-	KeepCache      bool
-	FollowerRead   bool
-	Authenticate   bool
-	TicketMess     auth.TicketMess
-	TokenKey       string
-	AccessKey      string
-	SecretKey      string
-	DisableDcache  bool
-	SubDir         string
-	FsyncOnClose   bool
-	MaxCPUs        int64
-	EnableXattr    bool
-	NearRead       bool
-	EnablePosixACL bool
+	Config           *config.Config
+	MountPoint       string
+	Volname          string
+	Owner            string
+	Master           string
+	Logpath          string
+	Loglvl           string
+	Profport         string
+	IcacheTimeout    int64
+	LookupValid      int64
+	AttrValid        int64
+	ReadRate         int64
+	WriteRate        int64
+	ReadBps          int64
+	WriteBps         int64
+	EnSyncWrite      int64
+	AutoInvalData    int64
+	UmpDatadir       string
+	Rdonly           bool
+	WriteCache       bool
+	KeepCache        bool
+	FollowerRead     bool
+	Authenticate     bool
+	TicketMess       auth.TicketMess
+	TokenKey         string
+	AccessKey        string
+	SecretKey        string
+	DisableDcache    bool
+	SubDir           string
+	FsyncOnClose     bool
+	MaxCPUs          int64
+	EnableXattr      bool
+	NearRead         bool
+	EnablePosixACL   bool
+	EnablePosixLock  bool
+	ReadCacheSize    int64 // in MB
+	EnableAudit      bool
+	AuditSink        string
+	SlowOpThreshold  int64 // in ms
+	ReaddirPlus      bool
+	NdcacheTimeout   int64 // in s
+	AsyncRmdir       bool
+	DirectIO         bool
+	MaxReadAhead     int64 // in bytes
+	PrefetchSize     int64 // in MB
+	ReadPolicy       string
+	HedgeDelay       int64 // in ms
+	MetaFollowerRead bool
+	RootSquash       bool
+	AllSquash        bool
+	AnonUid          int64
+	AnonGid          int64
+	UidMap           string
+	GidMap           string
+	PermissionCheck  string
+	Reported         map[string]string // reported to the master with the client session, see ReportedMountOptions
+}
+
+// ReportedMountOptions returns the mount options set, which are reported to the master with the client session.
+// The keys and the tokens are left out.
+func ReportedMountOptions(opts []MountOption) map[string]string {
+	reported := make(map[string]string)
+	for i, opt := range opts {
+		switch i {
+		case ClientKey, TokenKey, SecretKey:
+			continue
+		}
+		if opt.keyword == "" || opt.value == nil {
+			continue
+		}
+		if value := fmt.Sprint(opt.value); value != "" {
+			reported[opt.keyword] = value
+		}
+	}
+	return reported
 }
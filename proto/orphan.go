@@ -0,0 +1,53 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// OrphanHoldXAttrPrefix prefixes the extended attributes by which the clients hold the orphan inodes,
+	// which are unlinked but still open, or created by O_TMPFILE. The value of a hold is the unix time
+	// it expires at, and a client renews the holds of its orphan inodes until it closes them.
+	// The meta partition keeps an orphan inode with a live hold, and deletes it once all the holds expire,
+	// so that the orphan inodes of the crashed clients are cleaned up.
+	OrphanHoldXAttrPrefix = "cfs.orphan."
+
+	// OrphanHoldLease is the time a hold lasts, and the clients renew the holds at a third of it.
+	OrphanHoldLease = 10 * time.Minute
+)
+
+// OrphanHoldXAttr returns the name of the hold of the client.
+func OrphanHoldXAttr(clientID uint64) string {
+	return OrphanHoldXAttrPrefix + strconv.FormatUint(clientID, 16)
+}
+
+// OrphanHoldClient returns the client of the hold by the name.
+func OrphanHoldClient(name string) (clientID uint64, err error) {
+	return strconv.ParseUint(strings.TrimPrefix(name, OrphanHoldXAttrPrefix), 16, 64)
+}
+
+// IsOrphanHoldXAttr tells whether the extended attribute is a hold of an orphan inode.
+func IsOrphanHoldXAttr(name string) bool {
+	return strings.HasPrefix(name, OrphanHoldXAttrPrefix)
+}
+
+// ParseOrphanHold parses the value of a hold, which is the unix time in seconds it expires at.
+func ParseOrphanHold(value []byte) (expire int64, err error) {
+	return strconv.ParseInt(string(value), 10, 64)
+}
@@ -98,6 +98,12 @@ const (
 	OpMetaRemoveXAttr     uint8 = 0x37
 	OpMetaListXAttr       uint8 = 0x38
 	OpMetaBatchGetXAttr   uint8 = 0x39
+	OpMetaSetLock         uint8 = 0x3A
+	OpMetaGetLock         uint8 = 0x3B
+	OpMetaGetDirStat      uint8 = 0x3C
+	OpMetaReadDirPlus     uint8 = 0x3D
+	OpMetaCloneInode      uint8 = 0x3E
+	OpMetaPunchHole       uint8 = 0x3F
 
 	// Operations: Master -> MetaNode
 	OpCreateMetaPartition           uint8 = 0x40
@@ -109,6 +115,11 @@ const (
 	OpAddMetaPartitionRaftMember    uint8 = 0x46
 	OpRemoveMetaPartitionRaftMember uint8 = 0x47
 	OpMetaPartitionTryToLeader      uint8 = 0x48
+	OpVerifyMetaPartition           uint8 = 0x49
+
+	// Operations: Client/Node -> MetaNode/DataNode, on a new connection
+	OpAuthConn  uint8 = 0x50 // present the access tokens
+	OpHandshake uint8 = 0x51 // negotiate the protocol version and the features
 
 	// Operations: Master -> DataNode
 	OpCreateDataPartition           uint8 = 0x60
@@ -121,6 +132,7 @@ const (
 	OpAddDataPartitionRaftMember    uint8 = 0x67
 	OpRemoveDataPartitionRaftMember uint8 = 0x68
 	OpDataPartitionTryToLeader      uint8 = 0x69
+	OpRepairDataPartitionExtents    uint8 = 0x6A
 
 	// Operations: MultipartInfo
 	OpCreateMultipart  uint8 = 0x70
@@ -130,6 +142,13 @@ const (
 	OpListMultiparts   uint8 = 0x74
 
 	OpBatchDeleteExtent uint8 = 0x75 // SDK to MetaNode
+	OpBatchPunchExtent  uint8 = 0x76 // MetaNode to DataNode
+	OpMetaReadChanges   uint8 = 0x77 // read the change journal of a meta partition
+	OpMetaReadEvents    uint8 = 0x78 // read the events of the volume from a meta partition
+	OpMetaTxBegin       uint8 = 0x79 // begin a transaction coordinated by a meta partition
+	OpMetaTxUpdate      uint8 = 0x7A
+	OpMetaTxFinish      uint8 = 0x7B
+	OpMetaQuotaTag      uint8 = 0x7C // tag the inodes with their directory quota, sent by the meta nodes
 
 	//Operations: MetaNode Leader -> MetaNode Follower
 	OpMetaBatchDeleteInode  uint8 = 0x90
@@ -138,6 +157,7 @@ const (
 	OpMetaBatchEvictInode   uint8 = 0x93
 
 	// Commons
+	OpCrcErr           uint8 = 0xF2
 	OpIntraGroupNetErr uint8 = 0xF3
 	OpArgMismatchErr   uint8 = 0xF4
 	OpNotExistErr      uint8 = 0xF5
@@ -151,6 +171,9 @@ const (
 	OpNotPerm          uint8 = 0xFD
 	OpNotEmtpy         uint8 = 0xFE
 	OpOk               uint8 = 0xF0
+	OpAccessDenied     uint8 = 0xF1 // the user of the request is not permitted by the mode bits
+	OpVolReadOnly      uint8 = 0xEF // the volume is frozen read-only, and the writes are rejected
+	OpQuotaExceeded    uint8 = 0xEE // a directory quota of the inode is exceeded
 
 	OpPing uint8 = 0xFF
 )
@@ -257,6 +280,10 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpExtentRepairRead"
 	case OpIntraGroupNetErr:
 		m = "IntraGroupNetErr"
+	case OpAuthConn:
+		m = "OpAuthConn"
+	case OpHandshake:
+		m = "OpHandshake"
 	case OpMetaCreateInode:
 		m = "OpMetaCreateInode"
 	case OpMetaUnlinkInode:
@@ -351,8 +378,12 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpRemoveMetaPartitionRaftMember"
 	case OpMetaPartitionTryToLeader:
 		m = "OpMetaPartitionTryToLeader"
+	case OpVerifyMetaPartition:
+		m = "OpVerifyMetaPartition"
 	case OpDataPartitionTryToLeader:
 		m = "OpDataPartitionTryToLeader"
+	case OpRepairDataPartitionExtents:
+		m = "OpRepairDataPartitionExtents"
 	case OpMetaDeleteInode:
 		m = "OpMetaDeleteInode"
 	case OpMetaBatchDeleteInode:
@@ -369,6 +400,18 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpMetaListXAttr"
 	case OpMetaBatchGetXAttr:
 		m = "OpMetaBatchGetXAttr"
+	case OpMetaSetLock:
+		m = "OpMetaSetLock"
+	case OpMetaGetLock:
+		m = "OpMetaGetLock"
+	case OpMetaGetDirStat:
+		m = "OpMetaGetDirStat"
+	case OpMetaReadDirPlus:
+		m = "OpMetaReadDirPlus"
+	case OpMetaCloneInode:
+		m = "OpMetaCloneInode"
+	case OpMetaPunchHole:
+		m = "OpMetaPunchHole"
 	case OpCreateMultipart:
 		m = "OpCreateMultipart"
 	case OpGetMultipart:
@@ -381,6 +424,20 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpListMultiparts"
 	case OpBatchDeleteExtent:
 		m = "OpBatchDeleteExtent"
+	case OpBatchPunchExtent:
+		m = "OpBatchPunchExtent"
+	case OpMetaReadChanges:
+		m = "OpMetaReadChanges"
+	case OpMetaReadEvents:
+		m = "OpMetaReadEvents"
+	case OpMetaTxBegin:
+		m = "OpMetaTxBegin"
+	case OpMetaTxUpdate:
+		m = "OpMetaTxUpdate"
+	case OpMetaTxFinish:
+		m = "OpMetaTxFinish"
+	case OpMetaQuotaTag:
+		m = "OpMetaQuotaTag"
 	}
 	return
 }
@@ -398,6 +455,8 @@ func (p *Packet) GetResultMsg() (m string) {
 		m = "DiskNoSpaceErr"
 	case OpDiskErr:
 		m = "DiskErr"
+	case OpCrcErr:
+		m = "CrcErr"
 	case OpErr:
 		m = "Err: " + string(p.Data)
 	case OpAgain:
@@ -418,6 +477,12 @@ func (p *Packet) GetResultMsg() (m string) {
 		m = "NotPerm"
 	case OpNotEmtpy:
 		m = "DirNotEmpty"
+	case OpAccessDenied:
+		m = "AccessDenied"
+	case OpVolReadOnly:
+		m = "VolReadOnly"
+	case OpQuotaExceeded:
+		m = "QuotaExceeded"
 	default:
 		return fmt.Sprintf("Unknown ResultCode(%v)", p.ResultCode)
 	}
@@ -637,7 +702,7 @@ func (p *Packet) GetUniqueLogId() (m string) {
 			return m
 		}
 	} else if p.Opcode == OpReadTinyDeleteRecord || p.Opcode == OpNotifyReplicasToRepair || p.Opcode == OpDataNodeHeartbeat ||
-		p.Opcode == OpLoadDataPartition || p.Opcode == OpBatchDeleteExtent {
+		p.Opcode == OpLoadDataPartition || p.Opcode == OpBatchDeleteExtent || p.Opcode == OpBatchPunchExtent {
 		p.mesg += fmt.Sprintf("Opcode(%v)", p.GetOpMsg())
 		return
 	} else if p.Opcode == OpBroadcastMinAppliedID || p.Opcode == OpGetAppliedId {
@@ -668,7 +733,7 @@ func (p *Packet) setPacketPrefix() {
 			return
 		}
 	} else if p.Opcode == OpReadTinyDeleteRecord || p.Opcode == OpNotifyReplicasToRepair || p.Opcode == OpDataNodeHeartbeat ||
-		p.Opcode == OpLoadDataPartition || p.Opcode == OpBatchDeleteExtent {
+		p.Opcode == OpLoadDataPartition || p.Opcode == OpBatchDeleteExtent || p.Opcode == OpBatchPunchExtent {
 		p.mesg += fmt.Sprintf("Opcode(%v)", p.GetOpMsg())
 		return
 	} else if p.Opcode == OpBroadcastMinAppliedID || p.Opcode == OpGetAppliedId {
@@ -714,3 +779,7 @@ func (p *Packet) ShouldRetry() bool {
 func (p *Packet) IsBatchDeleteExtents() bool {
 	return p.Opcode == OpBatchDeleteExtent
 }
+
+func (p *Packet) IsBatchPunchExtents() bool {
+	return p.Opcode == OpBatchPunchExtent
+}
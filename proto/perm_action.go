@@ -123,6 +123,10 @@ const (
 	OSSPutBucketVersioningAction Action = OSSActionPrefix + "PutBucketVersioning" // unsupported
 	OSSListObjectVersionsAction  Action = OSSActionPrefix + "ListObjectVersions"  // unsupported
 
+	// Bucket notification actions
+	OSSGetBucketNotificationAction Action = OSSActionPrefix + "GetBucketNotification"
+	OSSPutBucketNotificationAction Action = OSSActionPrefix + "PutBucketNotification"
+
 	// Object legal hold actions
 	OSSGetObjectLegalHoldAction Action = OSSActionPrefix + "GetObjectLegalHold" // unsupported
 	OSSPutObjectLegalHoldAction Action = OSSActionPrefix + "PutObjectLegalHold" // unsupported
@@ -158,6 +162,9 @@ const (
 	OSSPutBucketReplicationAction    Action = OSSActionPrefix + "PutBucketReplicationAction"    // unsupported
 	OSSDeleteBucketReplicationAction Action = OSSActionPrefix + "DeleteBucketReplicationAction" // unsupported
 
+	// Temporary credential actions
+	OSSGetSessionTokenAction Action = OSSActionPrefix + "GetSessionToken"
+
 	// constants for POSIX file system interface
 	POSIXReadAction  Action = POSIXActionPrefix + "Read"
 	POSIXWriteAction Action = POSIXActionPrefix + "Write"
@@ -212,6 +219,8 @@ var (
 		OSSGetBucketVersioningAction,
 		OSSPutBucketVersioningAction,
 		OSSListObjectVersionsAction,
+		OSSGetBucketNotificationAction,
+		OSSPutBucketNotificationAction,
 		OSSGetObjectLegalHoldAction,
 		OSSPutObjectLegalHoldAction,
 		OSSGetObjectRetentionAction,
@@ -235,6 +244,7 @@ var (
 		OSSPutBucketReplicationAction,
 		OSSDeleteBucketReplicationAction,
 		OSSOptionsObjectAction,
+		OSSGetSessionTokenAction,
 
 		// POSIX file system interface actions
 		POSIXReadAction,
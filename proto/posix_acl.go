@@ -0,0 +1,207 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// The POSIX ACLs are kept as the extended attributes in the format of the Linux kernel,
+	// so that they are passed through between the kernel and the meta nodes as they are.
+	XAttrPosixACLAccess  = "system.posix_acl_access"
+	XAttrPosixACLDefault = "system.posix_acl_default"
+)
+
+// The tags of the POSIX ACL entries.
+const (
+	ACLUserObj  uint16 = 0x01
+	ACLUser     uint16 = 0x02
+	ACLGroupObj uint16 = 0x04
+	ACLGroup    uint16 = 0x08
+	ACLMask     uint16 = 0x10
+	ACLOther    uint16 = 0x20
+)
+
+const (
+	posixACLVersion   = 2
+	posixACLHeaderLen = 4
+	posixACLEntryLen  = 8
+	aclUndefinedID    = ^uint32(0)
+)
+
+// PosixACLEntry is an entry of a POSIX ACL, the id is for the ACLUser and ACLGroup entries only.
+type PosixACLEntry struct {
+	Tag  uint16
+	Perm uint16
+	ID   uint32
+}
+
+// PosixACL is a valid POSIX ACL, which has exactly one ACLUserObj, ACLGroupObj and ACLOther entry,
+// and an ACLMask entry if it has any ACLUser or ACLGroup entry.
+type PosixACL []PosixACLEntry
+
+// IsPosixACLXAttr checks whether the extended attribute is a POSIX ACL.
+func IsPosixACLXAttr(name string) bool {
+	return name == XAttrPosixACLAccess || name == XAttrPosixACLDefault
+}
+
+// ParsePosixACL parses the value of XAttrPosixACLAccess or XAttrPosixACLDefault.
+func ParsePosixACL(data []byte) (acl PosixACL, err error) {
+	if len(data) < posixACLHeaderLen || (len(data)-posixACLHeaderLen)%posixACLEntryLen != 0 {
+		return nil, fmt.Errorf("invalid posix acl length(%v)", len(data))
+	}
+	if version := binary.LittleEndian.Uint32(data); version != posixACLVersion {
+		return nil, fmt.Errorf("invalid posix acl version(%v)", version)
+	}
+	var counts = make(map[uint16]int)
+	for off := posixACLHeaderLen; off < len(data); off += posixACLEntryLen {
+		entry := PosixACLEntry{
+			Tag:  binary.LittleEndian.Uint16(data[off:]),
+			Perm: binary.LittleEndian.Uint16(data[off+2:]),
+			ID:   binary.LittleEndian.Uint32(data[off+4:]),
+		}
+		switch entry.Tag {
+		case ACLUserObj, ACLGroupObj, ACLMask, ACLOther, ACLUser, ACLGroup:
+		default:
+			return nil, fmt.Errorf("invalid posix acl tag(%v)", entry.Tag)
+		}
+		if entry.Perm&^0x07 != 0 {
+			return nil, fmt.Errorf("invalid posix acl perm(%v)", entry.Perm)
+		}
+		counts[entry.Tag]++
+		acl = append(acl, entry)
+	}
+	if counts[ACLUserObj] != 1 || counts[ACLGroupObj] != 1 || counts[ACLOther] != 1 || counts[ACLMask] > 1 {
+		return nil, fmt.Errorf("invalid posix acl entries")
+	}
+	if counts[ACLMask] == 0 && (counts[ACLUser] > 0 || counts[ACLGroup] > 0) {
+		return nil, fmt.Errorf("posix acl lacks the mask entry")
+	}
+	return acl, nil
+}
+
+// Bytes returns the ACL in the format of the Linux kernel.
+func (acl PosixACL) Bytes() []byte {
+	data := make([]byte, posixACLHeaderLen+len(acl)*posixACLEntryLen)
+	binary.LittleEndian.PutUint32(data, posixACLVersion)
+	off := posixACLHeaderLen
+	for _, entry := range acl {
+		binary.LittleEndian.PutUint16(data[off:], entry.Tag)
+		binary.LittleEndian.PutUint16(data[off+2:], entry.Perm)
+		id := entry.ID
+		if entry.Tag != ACLUser && entry.Tag != ACLGroup {
+			id = aclUndefinedID
+		}
+		binary.LittleEndian.PutUint32(data[off+4:], id)
+		off += posixACLEntryLen
+	}
+	return data
+}
+
+func (acl PosixACL) find(tag uint16) int {
+	for i, entry := range acl {
+		if entry.Tag == tag {
+			return i
+		}
+	}
+	return -1
+}
+
+// groupClass returns the index of the entry holding the group bits of the mode, which is the mask
+// if there is one, or the owning group otherwise.
+func (acl PosixACL) groupClass() int {
+	if i := acl.find(ACLMask); i >= 0 {
+		return i
+	}
+	return acl.find(ACLGroupObj)
+}
+
+// Equivalent checks whether the ACL has the base entries only, which are represented by the mode bits.
+func (acl PosixACL) Equivalent() bool {
+	return len(acl) == 3
+}
+
+// Mode returns the permission bits of the mode represented by the ACL.
+func (acl PosixACL) Mode() uint32 {
+	return uint32(acl[acl.find(ACLUserObj)].Perm)<<6 |
+		uint32(acl[acl.groupClass()].Perm)<<3 |
+		uint32(acl[acl.find(ACLOther)].Perm)
+}
+
+// Chmod returns a copy of the ACL updated by the permission bits of the mode, as chmod(2) does.
+func (acl PosixACL) Chmod(mode uint32) PosixACL {
+	res := append(PosixACL(nil), acl...)
+	res[res.find(ACLUserObj)].Perm = uint16(mode>>6) & 0x07
+	res[res.groupClass()].Perm = uint16(mode>>3) & 0x07
+	res[res.find(ACLOther)].Perm = uint16(mode) & 0x07
+	return res
+}
+
+// Inherit returns the access ACL of a new inode created with the mode in the directory of the default ACL,
+// and the mode of the inode limited by the ACL.
+func (acl PosixACL) Inherit(mode uint32) (access PosixACL, newMode uint32) {
+	access = append(PosixACL(nil), acl...)
+	access[access.find(ACLUserObj)].Perm &= uint16(mode>>6) & 0x07
+	access[access.groupClass()].Perm &= uint16(mode>>3) & 0x07
+	access[access.find(ACLOther)].Perm &= uint16(mode) & 0x07
+	return access, mode&^0777 | access.Mode()
+}
+
+// Permitted checks the permissions of the user by the ACL of the inode owned by the uid and gid, in the
+// order of the owner, the named users, the groups and the others as the Linux kernel does.
+func (acl PosixACL) Permitted(cred *Credential, uid, gid uint32, mask uint32) bool {
+	var (
+		want     = uint16(mask)
+		maskPerm = uint16(0x07)
+		found    bool
+	)
+	if i := acl.find(ACLMask); i >= 0 {
+		maskPerm = acl[i].Perm
+	}
+	for _, entry := range acl {
+		switch entry.Tag {
+		case ACLUserObj:
+			if cred.Uid == uid {
+				return entry.Perm&want == want
+			}
+		case ACLUser:
+			if cred.Uid == entry.ID {
+				return entry.Perm&maskPerm&want == want
+			}
+		}
+	}
+	for _, entry := range acl {
+		var member bool
+		switch entry.Tag {
+		case ACLGroupObj:
+			member = cred.InGroup(gid)
+		case ACLGroup:
+			member = cred.InGroup(entry.ID)
+		}
+		if !member {
+			continue
+		}
+		found = true
+		if entry.Perm&want == want {
+			return entry.Perm&maskPerm&want == want
+		}
+	}
+	if found {
+		return false
+	}
+	return acl[acl.find(ACLOther)].Perm&want == want
+}
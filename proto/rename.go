@@ -0,0 +1,43 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"encoding/json"
+)
+
+// RenameIntent describes a rename across meta partitions, which is the payload of the transaction
+// of the rename coordinated by the meta partition holding the renamed inode. The renamed inode
+// has one more link during the rename, which is taken by the destination dentry.
+type RenameIntent struct {
+	Inode       uint64 `json:"ino"`
+	SrcParentID uint64 `json:"spino"`
+	SrcName     string `json:"sname"`
+	DstParentID uint64 `json:"dpino"`
+	DstName     string `json:"dname"`
+	OldInode    uint64 `json:"oino,omitempty"` // the inode overwritten by the rename
+}
+
+func (intent *RenameIntent) Marshal() ([]byte, error) {
+	return json.Marshal(intent)
+}
+
+func UnmarshalRenameIntent(raw []byte) (intent *RenameIntent, err error) {
+	intent = &RenameIntent{}
+	if err = json.Unmarshal(raw, intent); err != nil {
+		return nil, err
+	}
+	return
+}
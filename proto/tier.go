@@ -0,0 +1,26 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// The media types of the disks of the data nodes, a volume with tiering keeps
+// the data partitions accessed recently on the hot media and the others on the cold media.
+const (
+	MediaTypeSSD = "ssd"
+	MediaTypeHDD = "hdd"
+)
+
+func IsValidMediaType(mediaType string) bool {
+	return mediaType == MediaTypeSSD || mediaType == MediaTypeHDD
+}
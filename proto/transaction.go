@@ -0,0 +1,56 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// The states of the transactions coordinated by the meta partitions.
+// A transaction begins in TxStateBegin, moves to TxStatePrepared once all the participants
+// are prepared, and ends in either TxStateCommitted or TxStateRolledBack.
+const (
+	TxStateBegin uint8 = iota
+	TxStatePrepared
+	TxStateCommitted
+	TxStateRolledBack
+)
+
+// The types of the transactions, which decide how the meta partition completes or reverts them.
+const (
+	TxTypeRename uint8 = iota + 1 // a rename across meta partitions, the payload of which is a RenameIntent
+)
+
+type TxBeginRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	Type        uint8  `json:"type"`
+	Payload     []byte `json:"payload"`
+}
+
+type TxBeginResponse struct {
+	TxID string `json:"txid"`
+}
+
+// TxUpdateRequest moves the transaction to the state, the payload of it is replaced if one is given.
+type TxUpdateRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	TxID        string `json:"txid"`
+	State       uint8  `json:"state"`
+	Payload     []byte `json:"payload,omitempty"`
+}
+
+type TxFinishRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	TxID        string `json:"txid"`
+}
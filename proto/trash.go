@@ -0,0 +1,65 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// TrashDirName is the name of the trash directory under the root of a volume.
+	TrashDirName = ".Trash"
+	// TrashDirXAttr marks the trash directory, so that the meta partition holding
+	// its entries can find it without resolving the path.
+	TrashDirXAttr = "cfs.trash"
+	// TrashTimeXAttr keeps the deletion time of an inode moved into the trash.
+	TrashTimeXAttr = "cfs.trash.time"
+
+	// PendingDeleteDirName is the name of the directory under the root of a volume holding the
+	// directories being deleted in background, whose entries are named like the trash entries.
+	PendingDeleteDirName = ".PendingDelete"
+	// PendingDeleteDirXAttr marks the pending delete directory like TrashDirXAttr.
+	PendingDeleteDirXAttr = "cfs.pending.delete.dir"
+	// PendingDeleteXAttr marks a directory whose entries are being deleted by its meta partition.
+	PendingDeleteXAttr = "cfs.pending.delete"
+)
+
+// TrashEntryName returns the name of the trash entry of a deleted dentry,
+// in the format of <name>.<parent inode>.<deletion time>.
+func TrashEntryName(name string, parentID uint64, deleteTime int64) string {
+	return fmt.Sprintf("%s.%d.%d", name, parentID, deleteTime)
+}
+
+// ParseTrashEntryName returns the original name, parent inode and deletion time of a trash entry.
+func ParseTrashEntryName(entry string) (name string, parentID uint64, deleteTime int64, ok bool) {
+	var err error
+	i := strings.LastIndexByte(entry, '.')
+	if i <= 0 {
+		return
+	}
+	if deleteTime, err = strconv.ParseInt(entry[i+1:], 10, 64); err != nil {
+		return
+	}
+	j := strings.LastIndexByte(entry[:i], '.')
+	if j <= 0 {
+		return
+	}
+	if parentID, err = strconv.ParseUint(entry[j+1:i], 10, 64); err != nil {
+		return
+	}
+	return entry[:j], parentID, deleteTime, true
+}
@@ -0,0 +1,40 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// ExpireTTLXAttr sets the time to live in seconds of the files in a directory.
+	// A file in the directory is deleted by the meta partition holding the directory
+	// once it has not been modified for the time to live.
+	ExpireTTLXAttr = "user.cfs.ttl"
+)
+
+// ParseExpireTTL parses the value of ExpireTTLXAttr, which must be a positive number of seconds.
+func ParseExpireTTL(value []byte) (ttl time.Duration, err error) {
+	var seconds int64
+	if seconds, err = strconv.ParseInt(string(value), 10, 64); err != nil {
+		return
+	}
+	if seconds <= 0 {
+		return 0, fmt.Errorf("invalid ttl(%v)", seconds)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
@@ -15,9 +15,13 @@
 package proto
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"regexp"
 	"sync"
+	"time"
 )
 
 var (
@@ -219,6 +223,21 @@ func (policy *UserPolicy) RemoveAuthorizedVol(volume string) {
 	delete(policy.AuthorizedVols, volume)
 }
 
+// RenameVol moves the own and the authorized policies of the renamed volume to its name.
+func (policy *UserPolicy) RenameVol(volume, newVolume string) {
+	policy.mu.Lock()
+	defer policy.mu.Unlock()
+	for i, ownVol := range policy.OwnVols {
+		if ownVol == volume {
+			policy.OwnVols[i] = newVolume
+		}
+	}
+	if values, exist := policy.AuthorizedVols[volume]; exist {
+		delete(policy.AuthorizedVols, volume)
+		policy.AuthorizedVols[newVolume] = values
+	}
+}
+
 func (policy *UserPolicy) SetPerm(volume string, perm Permission) {
 	policy.mu.Lock()
 	defer policy.mu.Unlock()
@@ -347,3 +366,22 @@ type UserUpdateParam struct {
 	Password    string   `json:"password"`
 	Description string   `json:"description"`
 }
+
+// UserAuthorizeMaxSkew is the longest skew between the time of an authorization and the master.
+const UserAuthorizeMaxSkew = 15 * time.Minute
+
+// UserAuthorizeResult defines the permission of the user to a volume, returned by the authorization of the mount.
+type UserAuthorizeResult struct {
+	UserID string `json:"user_id"`
+	Volume string `json:"volume"`
+	Own    bool   `json:"own"`
+	Rdonly bool   `json:"rdonly"`
+}
+
+// SignUserAuthorization signs the authorization of the access key to the volume at the time with the secret key,
+// so that the secret key is never sent to prove the identity of the user.
+func SignUserAuthorization(secretKey, accessKey, volume string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(fmt.Sprintf("%s\n%s\n%d", accessKey, volume, timestamp)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -47,6 +47,10 @@ type Config struct {
 	// We suggest to use ElectionTick = 10 * HeartbeatTick to avoid unnecessary leader switching.
 	// The default value is 1s.
 	ElectionTick int
+
+	// MaxInflightMsgs limits the number of the append messages pipelined to a follower without the acknowledgements.
+	// The default value is 128.
+	MaxInflightMsgs int
 }
 
 // PeerAddress defines the set of addresses that will be used by the peers.
@@ -122,6 +122,9 @@ func NewRaftStore(cfg *Config) (mr RaftStore, err error) {
 	rc.RetainLogs = cfg.NumOfLogsToRetain
 	rc.TickInterval = time.Duration(cfg.TickInterval) * time.Millisecond
 	rc.ElectionTick = cfg.ElectionTick
+	if cfg.MaxInflightMsgs > 0 {
+		rc.MaxInflightMsgs = cfg.MaxInflightMsgs
+	}
 	rs, err := raft.NewRaftServer(rc)
 	if err != nil {
 		return
@@ -88,6 +88,8 @@ func (p *FollowerPacket) identificationErrorResultCode(errLog string, errMsg str
 		p.ResultCode = proto.OpAgain
 	} else if strings.Contains(errMsg, raft.ErrNotLeader.Error()) {
 		p.ResultCode = proto.OpTryOtherAddr
+	} else if strings.Contains(errMsg, proto.ErrNoPermission.Error()) {
+		p.ResultCode = proto.OpNotPerm
 	} else {
 		p.ResultCode = proto.OpIntraGroupNetErr
 	}
@@ -300,10 +302,14 @@ func (p *Packet) identificationErrorResultCode(errLog string, errMsg string) {
 		p.ResultCode = proto.OpNotExistErr
 	} else if strings.Contains(errMsg, storage.NoSpaceError.Error()) {
 		p.ResultCode = proto.OpDiskNoSpaceErr
+	} else if p.IsReadOperation() && strings.Contains(errMsg, storage.CrcMismatchError.Error()) {
+		p.ResultCode = proto.OpCrcErr
 	} else if strings.Contains(errMsg, storage.TryAgainError.Error()) {
 		p.ResultCode = proto.OpAgain
 	} else if strings.Contains(errMsg, raft.ErrNotLeader.Error()) {
 		p.ResultCode = proto.OpTryOtherAddr
+	} else if strings.Contains(errMsg, proto.ErrVolReadOnly.Error()) {
+		p.ResultCode = proto.OpVolReadOnly
 	} else {
 		p.ResultCode = proto.OpIntraGroupNetErr
 	}
@@ -370,7 +376,8 @@ func (p *Packet) IsMasterCommand() bool {
 		proto.OpDecommissionDataPartition,
 		proto.OpAddDataPartitionRaftMember,
 		proto.OpRemoveDataPartitionRaftMember,
-		proto.OpDataPartitionTryToLeader:
+		proto.OpDataPartitionTryToLeader,
+		proto.OpRepairDataPartitionExtents:
 		return true
 	}
 	return false
@@ -0,0 +1,585 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package replicator
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/exporter"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	readChangesLimit = 4096
+	inodeBatchSize   = 1000
+	// maxPendingRounds is the rounds a change waits for the path of its parent, or a modified inode
+	// waits for its path, before the volume is fully synchronized again.
+	maxPendingRounds = 10
+)
+
+// The states of the replication.
+const (
+	StateFullSync = "fullSync"
+	StateTailing  = "tailing"
+	StatePromoted = "promoted"
+)
+
+// PartitionStatus defines the progress of the replication of a meta partition of the source volume.
+type PartitionStatus struct {
+	PartitionID uint64
+	Cursor      uint64 // the changes at and below the index are replicated
+	ApplyID     uint64 // the index applied by the partition at the last read
+}
+
+// Status defines the status of the replication.
+type Status struct {
+	SourceVolume   string
+	TargetVolume   string
+	State          string
+	FullSyncs      uint64
+	LastFullSync   int64
+	LagEntries     uint64 // the changes applied by the source volume but not read yet
+	LagSeconds     int64  // the age of the oldest change read but not replicated yet
+	PendingChanges int
+	DirtyInodes    int
+	LastError      string
+	Partitions     []*PartitionStatus
+}
+
+type pendingChange struct {
+	*proto.MetaChange
+	rounds int
+}
+
+type dirtyInode struct {
+	since  int64
+	rounds int
+}
+
+// replication replicates the source volume to the target volume. The volume is fully synchronized
+// first, then the changes of the meta partitions are read from their change journals and replayed
+// by the paths, as the inodes of the target volume differ. The modified files are copied as a whole.
+type replication struct {
+	src, dst *volume
+
+	cursors map[uint64]uint64
+	applied map[uint64]uint64
+	paths   map[uint64][]string // the paths of the inodes of the source volume
+	inodes  map[string]uint64   // the inodes of the paths of the source volume
+	pending []*pendingChange    // the dentry changes waiting for the paths of their parents
+	dirty   map[uint64]*dirtyInode
+	resync  bool
+
+	statusMu sync.RWMutex
+	status   Status
+
+	stopC    chan struct{}
+	promoteC chan chan error
+}
+
+func newReplication(src, dst *volume) *replication {
+	return &replication{
+		src:      src,
+		dst:      dst,
+		resync:   true,
+		status:   Status{SourceVolume: src.name, TargetVolume: dst.name, State: StateFullSync},
+		stopC:    make(chan struct{}),
+		promoteC: make(chan chan error),
+	}
+}
+
+func (r *replication) run(interval time.Duration) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-r.stopC:
+			return
+		case done := <-r.promoteC:
+			// catch up with the source volume as far as it is reachable
+			err := r.round()
+			r.updateStatus(func(s *Status) { s.State = StatePromoted })
+			done <- err
+			return
+		case <-timer.C:
+			if err := r.round(); err != nil {
+				log.LogErrorf("replication: source(%v) target(%v) err(%v)", r.src.name, r.dst.name, err)
+				r.updateStatus(func(s *Status) { s.LastError = err.Error() })
+			}
+			timer.Reset(interval)
+		}
+	}
+}
+
+func (r *replication) stop() {
+	close(r.stopC)
+}
+
+// promote stops the replication after replaying the changes read at the moment, so that the
+// target volume may take over the source volume.
+func (r *replication) promote(timeout time.Duration) error {
+	done := make(chan error, 1)
+	select {
+	case r.promoteC <- done:
+	case <-time.After(timeout):
+		return fmt.Errorf("the replication is busy")
+	}
+	return <-done
+}
+
+func (r *replication) getStatus() Status {
+	r.statusMu.RLock()
+	defer r.statusMu.RUnlock()
+	s := r.status
+	s.Partitions = append([]*PartitionStatus(nil), r.status.Partitions...)
+	return s
+}
+
+func (r *replication) updateStatus(fn func(s *Status)) {
+	r.statusMu.Lock()
+	fn(&r.status)
+	r.statusMu.Unlock()
+}
+
+func (r *replication) round() (err error) {
+	if r.resync {
+		if err = r.fullSync(); err != nil {
+			return
+		}
+	}
+	if err = r.tail(); err != nil {
+		return
+	}
+	r.report()
+	return
+}
+
+// fullSync synchronizes the whole volume. The applied indexes are taken before the scan, so that
+// the changes made during the scan are replayed after it.
+func (r *replication) fullSync() (err error) {
+	r.updateStatus(func(s *Status) { s.State = StateFullSync })
+	start := time.Now()
+	cursors := make(map[uint64]uint64)
+	for _, id := range r.src.mw.PartitionIDs() {
+		var resp *proto.ReadChangesResponse
+		if resp, err = r.src.mw.ReadChanges(id, math.MaxUint64, 1); err != nil {
+			return
+		}
+		cursors[id] = resp.ApplyID
+	}
+	r.paths = make(map[uint64][]string)
+	r.inodes = make(map[string]uint64)
+	r.pending = nil
+	r.dirty = make(map[uint64]*dirtyInode)
+	r.addPath(proto.RootIno, "/")
+	if err = r.syncDir(proto.RootIno, "/"); err != nil {
+		return
+	}
+	r.cursors = cursors
+	r.applied = make(map[uint64]uint64)
+	r.resync = false
+	r.updateStatus(func(s *Status) {
+		s.State = StateTailing
+		s.FullSyncs++
+		s.LastFullSync = time.Now().Unix()
+	})
+	log.LogInfof("replication: source(%v) target(%v) full sync done, inodes(%v) cost(%v)",
+		r.src.name, r.dst.name, len(r.paths), time.Since(start))
+	return
+}
+
+func (r *replication) syncDir(ino uint64, dir string) (err error) {
+	children, err := r.src.mw.ReadDir_ll(ino)
+	if err != nil {
+		return
+	}
+	infos := make(map[uint64]*proto.InodeInfo, len(children))
+	for i := 0; i < len(children); i += inodeBatchSize {
+		end := i + inodeBatchSize
+		if end > len(children) {
+			end = len(children)
+		}
+		inos := make([]uint64, 0, end-i)
+		for _, child := range children[i:end] {
+			inos = append(inos, child.Inode)
+		}
+		for _, info := range r.src.mw.BatchInodeGet(inos) {
+			infos[info.Inode] = info
+		}
+	}
+	names := make(map[string]bool, len(children))
+	for _, child := range children {
+		info, ok := infos[child.Inode]
+		if !ok {
+			// removed during the scan
+			continue
+		}
+		p := path.Join(dir, child.Name)
+		names[child.Name] = true
+		if linked := r.paths[child.Inode]; len(linked) > 0 && !proto.IsDir(info.Mode) {
+			err = r.dst.link(linked[0], p)
+		} else {
+			err = r.syncInode(info, p, false)
+		}
+		if err != nil {
+			return fmt.Errorf("sync %v: %v", p, err)
+		}
+		r.addPath(child.Inode, p)
+		if proto.IsDir(info.Mode) {
+			if err = r.syncDir(child.Inode, p); err != nil {
+				return
+			}
+			if err = r.syncAttrs(info, p); err != nil {
+				return
+			}
+		}
+	}
+	dstIno, _, err := r.dst.lookup(dir)
+	if err != nil {
+		return
+	}
+	dstChildren, err := r.dst.mw.ReadDir_ll(dstIno)
+	if err != nil {
+		return
+	}
+	for _, child := range dstChildren {
+		if !names[child.Name] {
+			if err = r.dst.remove(path.Join(dir, child.Name)); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// syncInode creates the inode at the path of the target volume, and copies the data of a file
+// unless the size and the modification time are the same already.
+func (r *replication) syncInode(info *proto.InodeInfo, p string, force bool) (err error) {
+	if proto.IsSymlink(info.Mode) {
+		_, err = r.dst.create(p, info.Mode, info.Uid, info.Gid, info.Target)
+		return
+	}
+	dstIno, err := r.dst.create(p, info.Mode, info.Uid, info.Gid, nil)
+	if err != nil {
+		return
+	}
+	if proto.IsDir(info.Mode) {
+		return
+	}
+	if !force {
+		if dstInfo, e := r.dst.mw.InodeGet_ll(dstIno); e == nil && dstInfo.Size == info.Size &&
+			dstInfo.ModifyTime.Unix() == info.ModifyTime.Unix() {
+			return r.dst.copyAttrs(r.src, info, dstIno)
+		}
+	}
+	if err = r.dst.copyData(r.src, info.Inode, dstIno, info.Size); err != nil {
+		return
+	}
+	return r.dst.copyAttrs(r.src, info, dstIno)
+}
+
+func (r *replication) syncAttrs(info *proto.InodeInfo, p string) (err error) {
+	dstIno, _, err := r.dst.lookup(p)
+	if err != nil {
+		return
+	}
+	return r.dst.copyAttrs(r.src, info, dstIno)
+}
+
+// tail reads the changes of the partitions and replays them.
+func (r *replication) tail() (err error) {
+	var (
+		changes []*pendingChange
+		cursors = make(map[uint64]uint64)
+	)
+	for _, id := range r.src.mw.PartitionIDs() {
+		cursor := r.cursors[id]
+		var resp *proto.ReadChangesResponse
+		if resp, err = r.src.mw.ReadChanges(id, cursor, readChangesLimit); err != nil {
+			return
+		}
+		if resp.Truncated {
+			log.LogWarnf("replication: source(%v) partition(%v) changes after(%v) are trimmed, sync fully",
+				r.src.name, id, cursor)
+			r.resync = true
+			return
+		}
+		for _, c := range resp.Changes {
+			switch c.Op {
+			case proto.ChangeCreateDentry, proto.ChangeUpdateDentry, proto.ChangeDeleteDentry:
+				changes = append(changes, &pendingChange{MetaChange: c})
+			case proto.ChangeWrite, proto.ChangeSetAttr, proto.ChangeSetXAttr, proto.ChangeRemoveXAttr:
+				r.markDirty(c)
+			}
+		}
+		if n := len(resp.Changes); n > 0 {
+			cursors[id] = resp.Changes[n-1].Index
+		} else if resp.ApplyID > cursor {
+			cursors[id] = resp.ApplyID
+		} else {
+			cursors[id] = cursor
+		}
+		r.applied[id] = resp.ApplyID
+	}
+
+	if err = r.replayDentries(append(r.pending, changes...)); err != nil {
+		return
+	}
+	r.cursors = cursors
+	return r.syncDirty()
+}
+
+// replayDentries replays the dentry changes in order in passes. A change waits for the path of its
+// parent, which may be created by a change of another partition, and so do the later changes of the
+// same path. The changes still waiting after the passes are retried in the next rounds.
+func (r *replication) replayDentries(changes []*pendingChange) (err error) {
+	for progress := true; progress && len(changes) > 0; {
+		progress = false
+		waiting := make([]*pendingChange, 0)
+		blocked := make(map[string]bool)
+		for _, c := range changes {
+			key := fmt.Sprintf("%v/%v", c.ParentID, c.Name)
+			var done bool
+			if !blocked[key] {
+				if done, err = r.replayDentry(c.MetaChange); err != nil {
+					return
+				}
+			}
+			if done {
+				progress = true
+				continue
+			}
+			blocked[key] = true
+			waiting = append(waiting, c)
+		}
+		changes = waiting
+	}
+	for _, c := range changes {
+		if c.rounds++; c.rounds > maxPendingRounds {
+			log.LogWarnf("replication: source(%v) change(%v) waits too long, sync fully", r.src.name, c.MetaChange)
+			r.resync = true
+		}
+	}
+	r.pending = changes
+	return
+}
+
+// replayDentry replays the dentry change, it returns false if the change has to wait.
+func (r *replication) replayDentry(c *proto.MetaChange) (done bool, err error) {
+	parents := r.paths[c.ParentID]
+	if len(parents) == 0 {
+		return false, nil
+	}
+	p := path.Join(parents[0], c.Name)
+	cur, exist := r.inodes[p]
+
+	if c.Op == proto.ChangeDeleteDentry {
+		if !exist || cur != c.Inode {
+			// replaced or moved already
+			return true, nil
+		}
+		if r.hasChildren(p) {
+			// the directory is being moved, or its children are removed by the changes of its partition
+			return false, nil
+		}
+		if err = r.dst.remove(p); err != nil {
+			return
+		}
+		r.dropPath(p)
+		return true, nil
+	}
+
+	if exist && cur == c.Inode {
+		return true, nil
+	}
+	if exist {
+		if err = r.dst.remove(p); err != nil {
+			return
+		}
+		r.dropPath(p)
+	}
+	if linked := r.paths[c.Inode]; len(linked) > 0 {
+		if proto.IsDir(c.Type) {
+			if err = r.dst.rename(linked[0], p); err != nil {
+				return
+			}
+			r.movePath(linked[0], p)
+			return true, nil
+		}
+		if err = r.dst.link(linked[0], p); err != nil {
+			return
+		}
+		r.addPath(c.Inode, p)
+		return true, nil
+	}
+	info, err := r.src.mw.InodeGet_ll(c.Inode)
+	if err == syscall.ENOENT {
+		// removed already, so will be the dentry
+		return true, nil
+	}
+	if err != nil {
+		return
+	}
+	if err = r.syncInode(info, p, true); err != nil {
+		return
+	}
+	r.addPath(c.Inode, p)
+	return true, nil
+}
+
+func (r *replication) markDirty(c *proto.MetaChange) {
+	if _, ok := r.dirty[c.Inode]; !ok {
+		r.dirty[c.Inode] = &dirtyInode{since: c.Time}
+	}
+}
+
+// syncDirty copies the data and the attributes of the modified inodes.
+func (r *replication) syncDirty() (err error) {
+	for ino, d := range r.dirty {
+		linked := r.paths[ino]
+		if len(linked) == 0 {
+			// not linked yet, or unlinked already
+			if d.rounds++; d.rounds > maxPendingRounds {
+				delete(r.dirty, ino)
+			}
+			continue
+		}
+		var info *proto.InodeInfo
+		if info, err = r.src.mw.InodeGet_ll(ino); err == syscall.ENOENT {
+			delete(r.dirty, ino)
+			err = nil
+			continue
+		} else if err != nil {
+			return
+		}
+		if proto.IsDir(info.Mode) {
+			err = r.syncAttrs(info, linked[0])
+		} else {
+			err = r.syncInode(info, linked[0], true)
+		}
+		if err != nil {
+			return fmt.Errorf("sync %v: %v", linked[0], err)
+		}
+		delete(r.dirty, ino)
+	}
+	return
+}
+
+func (r *replication) addPath(ino uint64, p string) {
+	if cur, ok := r.inodes[p]; ok && cur == ino {
+		return
+	}
+	r.inodes[p] = ino
+	r.paths[ino] = append(r.paths[ino], p)
+}
+
+func (r *replication) removePath(ino uint64, p string) {
+	delete(r.inodes, p)
+	linked := r.paths[ino]
+	for i, q := range linked {
+		if q == p {
+			linked = append(linked[:i:i], linked[i+1:]...)
+			break
+		}
+	}
+	if len(linked) == 0 {
+		delete(r.paths, ino)
+	} else {
+		r.paths[ino] = linked
+	}
+}
+
+// dropPath forgets the path and the paths below it.
+func (r *replication) dropPath(p string) {
+	for q, ino := range r.inodes {
+		if q == p || strings.HasPrefix(q, p+"/") {
+			r.removePath(ino, q)
+		}
+	}
+}
+
+// movePath moves the path and the paths below it to the path to.
+func (r *replication) movePath(p, to string) {
+	moved := make(map[string]uint64)
+	for q, ino := range r.inodes {
+		if q == p || strings.HasPrefix(q, p+"/") {
+			moved[q] = ino
+		}
+	}
+	for q, ino := range moved {
+		r.removePath(ino, q)
+		r.addPath(ino, to+q[len(p):])
+	}
+}
+
+func (r *replication) hasChildren(p string) bool {
+	for q := range r.inodes {
+		if strings.HasPrefix(q, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// report updates the status and the metrics of the lag.
+func (r *replication) report() {
+	var (
+		lagEntries uint64
+		oldest     int64
+		partitions = make([]*PartitionStatus, 0, len(r.cursors))
+	)
+	for id, cursor := range r.cursors {
+		applied := r.applied[id]
+		if applied > cursor {
+			lagEntries += applied - cursor
+		}
+		partitions = append(partitions, &PartitionStatus{PartitionID: id, Cursor: cursor, ApplyID: applied})
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].PartitionID < partitions[j].PartitionID })
+	for _, c := range r.pending {
+		if oldest == 0 || c.Time < oldest {
+			oldest = c.Time
+		}
+	}
+	for _, d := range r.dirty {
+		if oldest == 0 || d.since < oldest {
+			oldest = d.since
+		}
+	}
+	var lagSeconds int64
+	if oldest > 0 {
+		lagSeconds = time.Now().Unix() - oldest
+	}
+	r.updateStatus(func(s *Status) {
+		s.LagEntries = lagEntries
+		s.LagSeconds = lagSeconds
+		s.PendingChanges = len(r.pending)
+		s.DirtyInodes = len(r.dirty)
+		s.LastError = ""
+		s.Partitions = partitions
+	})
+	labels := map[string]string{"vol": r.src.name, "target": r.dst.name}
+	exporter.NewGauge("replication_lag_entries").SetWithLabels(float64(lagEntries), labels)
+	exporter.NewGauge("replication_lag_seconds").SetWithLabels(float64(lagSeconds), labels)
+	exporter.NewGauge("replication_pending_changes").SetWithLabels(float64(len(r.pending)+len(r.dirty)), labels)
+}
@@ -0,0 +1,59 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package replicator
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestReplicationPaths(t *testing.T) {
+	r := &replication{paths: make(map[uint64][]string), inodes: make(map[string]uint64)}
+	r.addPath(2, "/a")
+	r.addPath(3, "/a/f")
+	r.addPath(4, "/a/b")
+	r.addPath(5, "/a/b/g")
+	r.addPath(5, "/h")
+	r.addPath(5, "/h")
+
+	if !r.hasChildren("/a") || r.hasChildren("/a/f") || r.hasChildren("/") {
+		t.Fatalf("unexpected children: %v", r.inodes)
+	}
+
+	r.movePath("/a/b", "/c")
+	var expected = map[string]uint64{"/a": 2, "/a/f": 3, "/c": 4, "/c/g": 5, "/h": 5}
+	if !reflect.DeepEqual(r.inodes, expected) {
+		t.Fatalf("inodes after move: expected %v, got %v", expected, r.inodes)
+	}
+	linked := append([]string(nil), r.paths[5]...)
+	sort.Strings(linked)
+	if !reflect.DeepEqual(linked, []string{"/c/g", "/h"}) {
+		t.Fatalf("paths of the hard link after move: %v", linked)
+	}
+
+	r.dropPath("/c")
+	if _, ok := r.paths[4]; ok {
+		t.Fatalf("paths of the dropped directory remain: %v", r.paths)
+	}
+	if !reflect.DeepEqual(r.paths[5], []string{"/h"}) {
+		t.Fatalf("paths of the hard link after drop: %v", r.paths[5])
+	}
+
+	r.removePath(5, "/h")
+	if len(r.paths) != 2 || len(r.inodes) != 2 {
+		t.Fatalf("unexpected paths: %v %v", r.paths, r.inodes)
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package replicator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/cmd/common"
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/config"
+	"github.com/chubaofs/chubaofs/util/exporter"
+	"github.com/chubaofs/chubaofs/util/log"
+	"github.com/gorilla/mux"
+)
+
+// Configuration keys
+const (
+	configVolName          = "volName"
+	configTargetMasterAddr = "targetMasterAddr"
+	configTargetVolName    = "targetVolName"
+	configSyncInterval     = "syncInterval" // in seconds
+	configDataDir          = "dataDir"
+)
+
+// The APIs of the replicator.
+const (
+	ReplicationStatusPath  = "/replication/status"
+	ReplicationPromotePath = "/replication/promote"
+)
+
+const (
+	defaultListen       = "17810"
+	defaultSyncInterval = 5 * time.Second
+	promoteTimeout      = 10 * time.Minute
+	stateFile           = "replication.json"
+)
+
+// promotion is persisted once the target volume is promoted, so that the replication is not resumed.
+type promotion struct {
+	Promoted    bool
+	PromoteTime int64
+}
+
+// Replicator replicates a volume to a volume of a remote cluster asynchronously. It tails the
+// change journals of the meta partitions of the source volume, which must be enabled on the meta
+// nodes by changeJournalSize, and replays the changes to the target volume.
+type Replicator struct {
+	listen       string
+	masters      []string
+	volName      string
+	targetMaster []string
+	targetVol    string
+	syncInterval time.Duration
+	dataDir      string
+
+	src, dst    *volume
+	replication *replication
+	mu          sync.Mutex // guards promoted
+	promoted    *promotion
+	httpServer  *http.Server
+	control     common.Control
+}
+
+// NewServer creates a new replicator.
+func NewServer() *Replicator {
+	return &Replicator{}
+}
+
+func (s *Replicator) Start(cfg *config.Config) (err error) {
+	return s.control.Start(s, cfg, handleStart)
+}
+
+func (s *Replicator) Shutdown() {
+	s.control.Shutdown(s, handleShutdown)
+}
+
+func (s *Replicator) Sync() {
+	s.control.Sync()
+}
+
+func (s *Replicator) loadConfig(cfg *config.Config) (err error) {
+	if s.listen = cfg.GetString(proto.ListenPort); s.listen == "" {
+		s.listen = defaultListen
+	}
+	if s.masters = cfg.GetStringSlice(proto.MasterAddr); len(s.masters) == 0 {
+		return config.NewIllegalConfigError(proto.MasterAddr)
+	}
+	if s.volName = cfg.GetString(configVolName); s.volName == "" {
+		return config.NewIllegalConfigError(configVolName)
+	}
+	if s.targetMaster = cfg.GetStringSlice(configTargetMasterAddr); len(s.targetMaster) == 0 {
+		return config.NewIllegalConfigError(configTargetMasterAddr)
+	}
+	if s.targetVol = cfg.GetString(configTargetVolName); s.targetVol == "" {
+		s.targetVol = s.volName
+	}
+	if s.dataDir = cfg.GetString(configDataDir); s.dataDir == "" {
+		return config.NewIllegalConfigError(configDataDir)
+	}
+	s.syncInterval = defaultSyncInterval
+	if interval := cfg.GetInt64(configSyncInterval); interval > 0 {
+		s.syncInterval = time.Duration(interval) * time.Second
+	}
+	log.LogInfof("loadConfig: source(%v@%v) target(%v@%v) syncInterval(%v) dataDir(%v)",
+		s.volName, s.masters, s.targetVol, s.targetMaster, s.syncInterval, s.dataDir)
+	return
+}
+
+func handleStart(server common.Server, cfg *config.Config) (err error) {
+	s, ok := server.(*Replicator)
+	if !ok {
+		return errors.New("Invalid Node Type!")
+	}
+	if err = s.loadConfig(cfg); err != nil {
+		return
+	}
+	if err = os.MkdirAll(s.dataDir, 0755); err != nil {
+		return
+	}
+	if s.promoted, err = s.loadPromotion(); err != nil {
+		return
+	}
+	if s.promoted.Promoted {
+		log.LogWarnf("handleStart: the target volume(%v) is promoted at(%v), the replication is not resumed",
+			s.targetVol, time.Unix(s.promoted.PromoteTime, 0))
+	} else {
+		if s.src, err = openVolume(s.volName, s.masters); err != nil {
+			return
+		}
+		if s.dst, err = openVolume(s.targetVol, s.targetMaster); err != nil {
+			s.src.close()
+			return
+		}
+		s.replication = newReplication(s.src, s.dst)
+		go s.replication.run(s.syncInterval)
+	}
+
+	router := mux.NewRouter()
+	router.NewRoute().Methods(http.MethodGet).Path(ReplicationStatusPath).HandlerFunc(s.getStatus)
+	router.NewRoute().Methods(http.MethodPost).Path(ReplicationPromotePath).HandlerFunc(s.promote)
+	s.httpServer = &http.Server{Addr: ":" + s.listen, Handler: router}
+	go func() {
+		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.LogErrorf("handleStart: start http server fail, err(%v)", err)
+		}
+	}()
+
+	exporter.Init(cfg.GetString("role"), cfg)
+	log.LogInfo("replicator start success")
+	return
+}
+
+func handleShutdown(server common.Server) {
+	s, ok := server.(*Replicator)
+	if !ok {
+		return
+	}
+	if s.httpServer != nil {
+		_ = s.httpServer.Shutdown(context.Background())
+	}
+	s.mu.Lock()
+	if s.replication != nil && !s.promoted.Promoted {
+		s.replication.stop()
+	}
+	s.mu.Unlock()
+	if s.src != nil {
+		s.src.close()
+		s.dst.close()
+	}
+}
+
+func (s *Replicator) loadPromotion() (p *promotion, err error) {
+	p = new(promotion)
+	data, err := ioutil.ReadFile(path.Join(s.dataDir, stateFile))
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, p)
+	return
+}
+
+func (s *Replicator) storePromotion(p *promotion) (err error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return
+	}
+	tmp := path.Join(s.dataDir, stateFile+".tmp")
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	return os.Rename(tmp, path.Join(s.dataDir, stateFile))
+}
+
+func (s *Replicator) getStatus(w http.ResponseWriter, r *http.Request) {
+	var status Status
+	if s.replication != nil {
+		status = s.replication.getStatus()
+	} else {
+		status = Status{SourceVolume: s.volName, TargetVolume: s.targetVol}
+	}
+	s.mu.Lock()
+	if s.promoted.Promoted {
+		status.State = StatePromoted
+	}
+	s.mu.Unlock()
+	sendReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeSuccess, Msg: "success", Data: status})
+}
+
+// promote replays the changes of the source volume read at the moment as far as it is reachable,
+// then stops the replication for good, so that the clients may switch to the target volume.
+func (s *Replicator) promote(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.promoted.Promoted {
+		sendReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeSuccess, Msg: "promoted already"})
+		return
+	}
+	msg := "success"
+	if err := s.replication.promote(promoteTimeout); err != nil {
+		log.LogWarnf("promote: the last changes of volume(%v) are not replicated, err(%v)", s.volName, err)
+		msg = "promoted, but the last changes are not replicated: " + err.Error()
+	}
+	p := &promotion{Promoted: true, PromoteTime: time.Now().Unix()}
+	if err := s.storePromotion(p); err != nil {
+		sendReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeInternalError, Msg: err.Error()})
+		return
+	}
+	s.promoted = p
+	log.LogWarnf("promote: the target volume(%v) is promoted", s.targetVol)
+	sendReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeSuccess, Msg: msg})
+}
+
+func sendReply(w http.ResponseWriter, r *http.Request, reply *proto.HTTPReply) {
+	data, err := json.Marshal(reply)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(data); err != nil {
+		log.LogErrorf("sendReply: url(%v) err(%v)", r.URL, err)
+	}
+}
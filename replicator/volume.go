@@ -0,0 +1,274 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package replicator
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/data/stream"
+	"github.com/chubaofs/chubaofs/sdk/meta"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// copyBufferSize is the size of the chunks the files are copied in.
+const copyBufferSize = 4 * 1024 * 1024
+
+// volume is a volume of a cluster accessed by the paths.
+type volume struct {
+	name string
+	mw   *meta.MetaWrapper
+	ec   *stream.ExtentClient
+}
+
+func openVolume(name string, masters []string) (v *volume, err error) {
+	var mw *meta.MetaWrapper
+	if mw, err = meta.NewMetaWrapper(&meta.MetaConfig{
+		Volume:        name,
+		Masters:       masters,
+		Authenticate:  false,
+		ValidateOwner: false,
+	}); err != nil {
+		return
+	}
+	var ec *stream.ExtentClient
+	if ec, err = stream.NewExtentClient(&stream.ExtentConfig{
+		Volume:            name,
+		Masters:           masters,
+		FollowerRead:      true,
+		OnAppendExtentKey: mw.AppendExtentKey,
+		OnGetExtents:      mw.GetExtents,
+		OnTruncate:        mw.Truncate,
+		OnIsShared:        mw.IsShared,
+		OnCheckShared:     mw.CheckShared,
+	}); err != nil {
+		_ = mw.Close()
+		return
+	}
+	return &volume{name: name, mw: mw, ec: ec}, nil
+}
+
+func (v *volume) close() {
+	_ = v.ec.Close()
+	_ = v.mw.Close()
+}
+
+// lookup returns the inode and the mode of the path, which is relative to the root of the volume.
+func (v *volume) lookup(p string) (ino uint64, mode uint32, err error) {
+	ino, mode = proto.RootIno, uint32(os.ModeDir)
+	for _, name := range strings.Split(strings.Trim(p, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		if ino, mode, err = v.mw.Lookup_ll(ino, name); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// lookupParent returns the inode of the parent directory and the name of the path.
+func (v *volume) lookupParent(p string) (parent uint64, name string, err error) {
+	dir, name := path.Split(strings.TrimRight(p, "/"))
+	var mode uint32
+	if parent, mode, err = v.lookup(dir); err != nil {
+		return
+	}
+	if !proto.IsDir(mode) {
+		err = syscall.ENOTDIR
+	}
+	return
+}
+
+// create creates the inode of the mode at the path, the existing one of another type is removed.
+func (v *volume) create(p string, mode, uid, gid uint32, target []byte) (ino uint64, err error) {
+	parent, name, err := v.lookupParent(p)
+	if err != nil {
+		return
+	}
+	var existMode uint32
+	if ino, existMode, err = v.mw.Lookup_ll(parent, name); err == nil {
+		if proto.OsModeType(existMode) == proto.OsModeType(mode) && !proto.IsSymlink(mode) {
+			return
+		}
+		if err = v.remove(p); err != nil {
+			return
+		}
+	} else if err != syscall.ENOENT {
+		return
+	}
+	var info *proto.InodeInfo
+	if info, err = v.mw.Create_ll(parent, name, mode, uid, gid, target); err != nil {
+		return
+	}
+	return info.Inode, nil
+}
+
+// link links the existing inode at the path src to the path dst as well.
+func (v *volume) link(src, dst string) (err error) {
+	ino, _, err := v.lookup(src)
+	if err != nil {
+		return
+	}
+	parent, name, err := v.lookupParent(dst)
+	if err != nil {
+		return
+	}
+	if exist, _, e := v.mw.Lookup_ll(parent, name); e == nil {
+		if exist == ino {
+			return
+		}
+		if err = v.remove(dst); err != nil {
+			return
+		}
+	}
+	_, err = v.mw.Link(parent, name, ino)
+	return
+}
+
+// rename moves the path src to dst, the existing dst is replaced.
+func (v *volume) rename(src, dst string) (err error) {
+	srcParent, srcName, err := v.lookupParent(src)
+	if err != nil {
+		return
+	}
+	dstParent, dstName, err := v.lookupParent(dst)
+	if err != nil {
+		return
+	}
+	return v.mw.Rename_ll(srcParent, srcName, dstParent, dstName)
+}
+
+// remove removes the path, including the children of a directory.
+func (v *volume) remove(p string) (err error) {
+	parent, name, err := v.lookupParent(p)
+	if err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+	ino, mode, err := v.mw.Lookup_ll(parent, name)
+	if err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+	if proto.IsDir(mode) {
+		var children []proto.Dentry
+		if children, err = v.mw.ReadDir_ll(ino); err != nil {
+			return
+		}
+		for _, child := range children {
+			if err = v.remove(path.Join(p, child.Name)); err != nil {
+				return
+			}
+		}
+	}
+	var info *proto.InodeInfo
+	if info, err = v.mw.Delete_ll(parent, name, proto.IsDir(mode)); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+	if info != nil {
+		_ = v.ec.EvictStream(info.Inode)
+		if err = v.mw.Evict(info.Inode); err != nil {
+			log.LogWarnf("remove: volume(%v) path(%v) evict inode(%v) err(%v)", v.name, p, info.Inode, err)
+			err = nil
+		}
+	}
+	return
+}
+
+// copyData overwrites the data of the inode dst with that of the inode src of the volume from.
+func (v *volume) copyData(from *volume, src, dst uint64, size uint64) (err error) {
+	if err = from.ec.OpenStream(src); err != nil {
+		return
+	}
+	defer func() { _ = from.ec.CloseStream(src) }()
+	if err = v.ec.OpenStream(dst); err != nil {
+		return
+	}
+	defer func() { _ = v.ec.CloseStream(dst) }()
+	if err = v.ec.Truncate(dst, 0); err != nil {
+		return
+	}
+	buf := make([]byte, copyBufferSize)
+	for offset := 0; uint64(offset) < size; {
+		n := copyBufferSize
+		if rest := int(size - uint64(offset)); rest < n {
+			n = rest
+		}
+		var read int
+		if read, err = from.ec.Read(src, buf, offset, n); err != nil && read <= 0 {
+			return
+		}
+		if read <= 0 {
+			break
+		}
+		if _, err = v.ec.Write(dst, offset, buf[:read], 0); err != nil {
+			return
+		}
+		offset += read
+	}
+	err = v.ec.Flush(dst)
+	return
+}
+
+// copyAttrs sets the attributes and the extended attributes of the inode dst to those of src.
+func (v *volume) copyAttrs(from *volume, src *proto.InodeInfo, dst uint64) (err error) {
+	valid := proto.AttrMode | proto.AttrUid | proto.AttrGid | proto.AttrModifyTime
+	if err = v.mw.Setattr(dst, valid, src.Mode, src.Uid, src.Gid, 0, src.ModifyTime.Unix()); err != nil {
+		return
+	}
+	keys, err := from.mw.XAttrsList_ll(src.Inode)
+	if err != nil {
+		return
+	}
+	expect := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		expect[key] = true
+		var srcAttr, dstAttr *proto.XAttrInfo
+		if srcAttr, err = from.mw.XAttrGet_ll(src.Inode, key); err != nil {
+			return
+		}
+		if dstAttr, err = v.mw.XAttrGet_ll(dst, key); err != nil {
+			return
+		}
+		if !bytes.Equal(srcAttr.Get(key), dstAttr.Get(key)) {
+			if err = v.mw.XAttrSet_ll(dst, []byte(key), srcAttr.Get(key)); err != nil {
+				return
+			}
+		}
+	}
+	if keys, err = v.mw.XAttrsList_ll(dst); err != nil {
+		return
+	}
+	for _, key := range keys {
+		if !expect[key] {
+			if err = v.mw.XAttrDel_ll(dst, key); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
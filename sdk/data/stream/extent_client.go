@@ -16,13 +16,17 @@ package stream
 
 import (
 	"fmt"
+	"sort"
 	"sync"
+	"syscall"
 	"time"
 
+	"golang.org/x/net/context"
 	"golang.org/x/time/rate"
 
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/sdk/data/wrapper"
+	"github.com/chubaofs/chubaofs/util"
 	"github.com/chubaofs/chubaofs/util/errors"
 	"github.com/chubaofs/chubaofs/util/exporter"
 	"github.com/chubaofs/chubaofs/util/log"
@@ -31,7 +35,9 @@ import (
 type AppendExtentKeyFunc func(inode uint64, key proto.ExtentKey) error
 type GetExtentsFunc func(inode uint64) (uint64, uint64, []proto.ExtentKey, error)
 type TruncateFunc func(inode, size uint64) error
+type PunchHoleFunc func(inode, offset, size uint64) error
 type EvictIcacheFunc func(inode uint64)
+type IsSharedFunc func(inode uint64) bool
 
 const (
 	MaxMountRetryLimit = 5
@@ -42,6 +48,18 @@ const (
 
 	defaultWriteLimitRate  = rate.Inf
 	defaultWriteLimitBurst = 128
+
+	// burst of the bandwidth limiters, in bytes
+	defaultBpsLimitBurst = 4 * util.MB
+
+	defaultHedgeDelay = 50 * time.Millisecond
+)
+
+// The policies to choose the replicas to read from.
+const (
+	ReadPolicyPrimary    = "primary"    // read from the leader, or the followers if follower read is enabled
+	ReadPolicyRoundRobin = "roundRobin" // read from the replicas in turn
+	ReadPolicyHedged     = "hedged"     // read from another replica as well if the first one does not reply in time
 )
 
 var (
@@ -51,6 +69,7 @@ var (
 	flushRequestPool   *sync.Pool
 	releaseRequestPool *sync.Pool
 	truncRequestPool   *sync.Pool
+	punchRequestPool   *sync.Pool
 	evictRequestPool   *sync.Pool
 )
 
@@ -71,6 +90,9 @@ func init() {
 	truncRequestPool = &sync.Pool{New: func() interface{} {
 		return &TruncRequest{}
 	}}
+	punchRequestPool = &sync.Pool{New: func() interface{} {
+		return &PunchHoleRequest{}
+	}}
 	evictRequestPool = &sync.Pool{New: func() interface{} {
 		return &EvictRequest{}
 	}}
@@ -81,12 +103,21 @@ type ExtentConfig struct {
 	Masters           []string
 	FollowerRead      bool
 	NearRead          bool
-	ReadRate          int64
-	WriteRate         int64
+	ReadRate          int64         // requests per second
+	WriteRate         int64         // requests per second
+	ReadBps           int64         // bytes per second
+	WriteBps          int64         // bytes per second
+	ReadCacheSize     int64         // in bytes, zero disables the read cache
+	PrefetchSize      int64         // in bytes, zero disables the prefetch of the sequential reads into the read cache
+	ReadPolicy        string        // one of the ReadPolicy*, empty means ReadPolicyPrimary
+	HedgeDelay        time.Duration // delay to send the hedged reads, zero means the default
 	OnAppendExtentKey AppendExtentKeyFunc
 	OnGetExtents      GetExtentsFunc
 	OnTruncate        TruncateFunc
+	OnPunchHole       PunchHoleFunc // May be null, punching holes is not supported then
 	OnEvictIcache     EvictIcacheFunc
+	OnIsShared        IsSharedFunc // May be null, the inodes sharing extents are written copy-on-write
+	OnCheckShared     IsSharedFunc // May be null, asks the meta node before overwriting the extents in place
 }
 
 // ExtentClient defines the struct of the extent client.
@@ -94,20 +125,45 @@ type ExtentClient struct {
 	streamers    map[uint64]*Streamer
 	streamerLock sync.Mutex
 
-	readLimiter  *rate.Limiter
-	writeLimiter *rate.Limiter
+	readLimiter     *rate.Limiter
+	writeLimiter    *rate.Limiter
+	readBpsLimiter  *rate.Limiter
+	writeBpsLimiter *rate.Limiter
+
+	readCache    *ReadCache // May be null, must check before using
+	prefetchSize int        // max bytes prefetched ahead of the sequential reads
+
+	replicaRead bool          // read from any replica, see ReadPolicyRoundRobin
+	hedgeDelay  time.Duration // zero unless ReadPolicyHedged
 
 	dataWrapper     *wrapper.Wrapper
 	appendExtentKey AppendExtentKeyFunc
 	getExtents      GetExtentsFunc
 	truncate        TruncateFunc
+	punchHole       PunchHoleFunc   //May be null, must check before using
 	evictIcache     EvictIcacheFunc //May be null, must check before using
+	isShared        IsSharedFunc    //May be null, must check before using
+	checkShared     IsSharedFunc    //May be null, must check before using
 }
 
 // NewExtentClient returns a new extent client.
 func NewExtentClient(config *ExtentConfig) (client *ExtentClient, err error) {
 	client = new(ExtentClient)
 
+	switch config.ReadPolicy {
+	case "", ReadPolicyPrimary:
+	case ReadPolicyRoundRobin:
+		client.replicaRead = true
+	case ReadPolicyHedged:
+		client.replicaRead = true
+		client.hedgeDelay = config.HedgeDelay
+		if client.hedgeDelay <= 0 {
+			client.hedgeDelay = defaultHedgeDelay
+		}
+	default:
+		return nil, fmt.Errorf("invalid read policy %v", config.ReadPolicy)
+	}
+
 	limit := MaxMountRetryLimit
 retry:
 	client.dataWrapper, err = wrapper.NewDataPartitionWrapper(config.Volume, config.Masters)
@@ -125,7 +181,10 @@ retry:
 	client.appendExtentKey = config.OnAppendExtentKey
 	client.getExtents = config.OnGetExtents
 	client.truncate = config.OnTruncate
+	client.punchHole = config.OnPunchHole
 	client.evictIcache = config.OnEvictIcache
+	client.isShared = config.OnIsShared
+	client.checkShared = config.OnCheckShared
 	client.dataWrapper.InitFollowerRead(config.FollowerRead)
 	client.dataWrapper.SetNearRead(config.NearRead)
 
@@ -143,6 +202,15 @@ retry:
 
 	client.readLimiter = rate.NewLimiter(readLimit, defaultReadLimitBurst)
 	client.writeLimiter = rate.NewLimiter(writeLimit, defaultWriteLimitBurst)
+	client.readBpsLimiter = rate.NewLimiter(rate.Inf, defaultBpsLimitBurst)
+	client.writeBpsLimiter = rate.NewLimiter(rate.Inf, defaultBpsLimitBurst)
+	setRate(client.readBpsLimiter, int(config.ReadBps))
+	setRate(client.writeBpsLimiter, int(config.WriteBps))
+
+	if config.ReadCacheSize > 0 {
+		client.readCache = NewReadCache(config.ReadCacheSize)
+		client.prefetchSize = int(config.PrefetchSize)
+	}
 
 	return
 }
@@ -238,6 +306,28 @@ func (client *ExtentClient) Write(inode uint64, offset int, data []byte, flags i
 	return
 }
 
+// Append writes the data at the end of the file, it returns the offset the data is written at.
+func (client *ExtentClient) Append(inode uint64, data []byte, flags int) (offset int, write int, err error) {
+	prefix := fmt.Sprintf("Append{ino(%v)size(%v)}", inode, len(data))
+
+	s := client.GetStreamer(inode)
+	if s == nil {
+		return 0, 0, fmt.Errorf("Prefix(%v): stream is not opened yet", prefix)
+	}
+
+	s.once.Do(func() {
+		s.GetExtents()
+	})
+
+	offset, write, err = s.IssueAppendRequest(data, flags)
+	if err != nil {
+		err = errors.Trace(err, prefix)
+		log.LogError(errors.Stack(err))
+		exporter.Warning(err.Error())
+	}
+	return
+}
+
 func (client *ExtentClient) Truncate(inode uint64, size int) error {
 	prefix := fmt.Sprintf("Truncate{ino(%v)size(%v)}", inode, size)
 	s := client.GetStreamer(inode)
@@ -253,6 +343,25 @@ func (client *ExtentClient) Truncate(inode uint64, size int) error {
 	return err
 }
 
+// PunchHole removes the range of the file from its extents, the data written so far is flushed first.
+func (client *ExtentClient) PunchHole(inode uint64, offset, size int) error {
+	prefix := fmt.Sprintf("PunchHole{ino(%v)offset(%v)size(%v)}", inode, offset, size)
+	if client.punchHole == nil {
+		return syscall.ENOTSUP
+	}
+	s := client.GetStreamer(inode)
+	if s == nil {
+		return fmt.Errorf("Prefix(%v): stream is not opened yet", prefix)
+	}
+
+	err := s.IssuePunchHoleRequest(offset, size)
+	if err != nil && err != syscall.ENOTSUP {
+		err = errors.Trace(err, prefix)
+		log.LogError(errors.Stack(err))
+	}
+	return err
+}
+
 func (client *ExtentClient) Flush(inode uint64) error {
 	s := client.GetStreamer(inode)
 	if s == nil {
@@ -262,6 +371,15 @@ func (client *ExtentClient) Flush(inode uint64) error {
 }
 
 func (client *ExtentClient) Read(inode uint64, data []byte, offset int, size int) (read int, err error) {
+	return client.read(inode, data, offset, size, false)
+}
+
+// ReadDirect reads the data from the data nodes bypassing the read cache.
+func (client *ExtentClient) ReadDirect(inode uint64, data []byte, offset int, size int) (read int, err error) {
+	return client.read(inode, data, offset, size, true)
+}
+
+func (client *ExtentClient) read(inode uint64, data []byte, offset int, size int, direct bool) (read int, err error) {
 	if size == 0 {
 		return
 	}
@@ -281,7 +399,10 @@ func (client *ExtentClient) Read(inode uint64, data []byte, offset int, size int
 		return
 	}
 
-	read, err = s.read(data, offset, size)
+	read, err = s.read(data, offset, size, direct)
+	if err == nil && !direct {
+		s.prefetch(offset, read)
+	}
 	return
 }
 
@@ -297,7 +418,9 @@ func (client *ExtentClient) GetStreamer(inode uint64) *Streamer {
 }
 
 func (client *ExtentClient) GetRate() string {
-	return fmt.Sprintf("read: %v\nwrite: %v\n", getRate(client.readLimiter), getRate(client.writeLimiter))
+	return fmt.Sprintf("read: %v\nwrite: %v\nreadBps: %v\nwriteBps: %v\n",
+		getRate(client.readLimiter), getRate(client.writeLimiter),
+		getRate(client.readBpsLimiter), getRate(client.writeBpsLimiter))
 }
 
 func getRate(lim *rate.Limiter) string {
@@ -316,6 +439,29 @@ func (client *ExtentClient) SetWriteRate(val int) string {
 	return setRate(client.writeLimiter, val)
 }
 
+func (client *ExtentClient) SetReadBps(val int) string {
+	return setRate(client.readBpsLimiter, val)
+}
+
+func (client *ExtentClient) SetWriteBps(val int) string {
+	return setRate(client.writeBpsLimiter, val)
+}
+
+// waitBytes blocks until n bytes are allowed by the bandwidth limiter.
+// Requests larger than the burst are granted chunk by chunk.
+func waitBytes(ctx context.Context, lim *rate.Limiter, n int) {
+	if lim.Limit() == rate.Inf {
+		return
+	}
+	for n > 0 {
+		chunk := util.Min(n, lim.Burst())
+		if err := lim.WaitN(ctx, chunk); err != nil {
+			return
+		}
+		n -= chunk
+	}
+}
+
 func setRate(lim *rate.Limiter, val int) string {
 	if val > 0 {
 		lim.SetLimit(rate.Limit(val))
@@ -325,6 +471,44 @@ func setRate(lim *rate.Limiter, val int) string {
 	return "unlimited"
 }
 
+// StreamerStat is the state of a streamer, i.e. a file opened or recently accessed by the client.
+type StreamerStat struct {
+	Inode    uint64
+	Refcnt   int // number of the open handles
+	Size     int
+	Dirty    int // number of the extent handlers not flushed yet
+	Requests int // number of the requests queued
+	Idle     int // ticks of the streamer without requests
+}
+
+// StreamerStats returns the state of the streamers sorted by inode. The counters are read
+// without stopping the streamers, so they are approximate.
+func (client *ExtentClient) StreamerStats() []*StreamerStat {
+	client.streamerLock.Lock()
+	stats := make([]*StreamerStat, 0, len(client.streamers))
+	for _, s := range client.streamers {
+		size, _ := s.extents.Size()
+		stats = append(stats, &StreamerStat{
+			Inode:    s.inode,
+			Refcnt:   s.refcnt,
+			Size:     size,
+			Dirty:    s.dirtylist.Len(),
+			Requests: len(s.request),
+			Idle:     s.idle,
+		})
+	}
+	client.streamerLock.Unlock()
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Inode < stats[j].Inode })
+	return stats
+}
+
+// ClearReadCache drops the data in the read cache, if any.
+func (client *ExtentClient) ClearReadCache() {
+	if client.readCache != nil {
+		client.readCache.Clear()
+	}
+}
+
 func (client *ExtentClient) Close() error {
 	// release streamers
 	var inodes []uint64
@@ -23,6 +23,7 @@ import (
 	"github.com/chubaofs/chubaofs/util/log"
 	"hash/crc32"
 	"net"
+	"time"
 )
 
 // ExtentReader defines the struct of the extent reader.
@@ -31,6 +32,7 @@ type ExtentReader struct {
 	key          *proto.ExtentKey
 	dp           *wrapper.DataPartition
 	followerRead bool
+	hedgeDelay   time.Duration // send the read to another replica as well if no reply in the delay, zero disables it
 }
 
 // NewExtentReader returns a new extent reader.
@@ -51,6 +53,13 @@ func (reader *ExtentReader) String() (m string) {
 
 // Read reads the extent request.
 func (reader *ExtentReader) Read(req *ExtentRequest) (readBytes int, err error) {
+	if reader.hedgeDelay > 0 {
+		return reader.hedgedRead(req)
+	}
+	return reader.read(req)
+}
+
+func (reader *ExtentReader) read(req *ExtentRequest) (readBytes int, err error) {
 	offset := req.FileOffset - int(reader.key.FileOffset) + int(reader.key.ExtentOffset)
 	size := req.Size
 
@@ -59,42 +68,139 @@ func (reader *ExtentReader) Read(req *ExtentRequest) (readBytes int, err error)
 
 	log.LogDebugf("ExtentReader Read enter: size(%v) req(%v) reqPacket(%v)", size, req, reqPacket)
 
-	err = sc.Send(reqPacket, func(conn *net.TCPConn) (error, bool) {
-		readBytes = 0
-		for readBytes < size {
-			replyPacket := NewReply(reqPacket.ReqID, reader.dp.PartitionID, reqPacket.ExtentID)
-			bufSize := util.Min(util.ReadBlockSize, size-readBytes)
-			replyPacket.Data = req.Data[readBytes : readBytes+bufSize]
-			e := replyPacket.readFromConn(conn, proto.ReadDeadlineTime)
-			if e != nil {
-				log.LogWarnf("Extent Reader Read: failed to read from connect, ino(%v) req(%v) readBytes(%v) err(%v)", reader.inode, reqPacket, readBytes, e)
-				// Upon receiving TryOtherAddrError, other hosts will be retried.
-				return TryOtherAddrError, false
-			}
+	err = sc.Send(reqPacket, func(conn net.Conn) (e error, again bool) {
+		readBytes, e, again = reader.receive(conn, reqPacket, req.Data[:size])
+		return
+	})
 
-			//log.LogDebugf("ExtentReader Read: ResultCode(%v) req(%v) reply(%v) readBytes(%v)", replyPacket.GetResultMsg(), reqPacket, replyPacket, readBytes)
+	if err != nil {
+		log.LogErrorf("Extent Reader Read: err(%v) req(%v) reqPacket(%v)", err, req, reqPacket)
+	}
 
-			if replyPacket.ResultCode == proto.OpAgain {
-				return nil, true
-			}
+	log.LogDebugf("ExtentReader Read exit: req(%v) reqPacket(%v) readBytes(%v) err(%v)", req, reqPacket, readBytes, err)
+	return
+}
 
-			e = reader.checkStreamReply(reqPacket, replyPacket)
-			if e != nil {
-				// Dont change the error message, since the caller will
-				// check if it is NotLeaderErr.
-				return e, false
-			}
+// receive reads the replies of the read request from the connection into data.
+func (reader *ExtentReader) receive(conn net.Conn, reqPacket *Packet, data []byte) (readBytes int, err error, again bool) {
+	size := len(data)
+	for readBytes < size {
+		replyPacket := NewReply(reqPacket.ReqID, reader.dp.PartitionID, reqPacket.ExtentID)
+		bufSize := util.Min(util.ReadBlockSize, size-readBytes)
+		replyPacket.Data = data[readBytes : readBytes+bufSize]
+		e := replyPacket.readFromConn(conn, proto.ReadDeadlineTime)
+		if e != nil {
+			log.LogWarnf("Extent Reader Read: failed to read from connect, ino(%v) req(%v) readBytes(%v) err(%v)", reader.inode, reqPacket, readBytes, e)
+			// Upon receiving TryOtherAddrError, other hosts will be retried.
+			return readBytes, TryOtherAddrError, false
+		}
+
+		//log.LogDebugf("ExtentReader Read: ResultCode(%v) req(%v) reply(%v) readBytes(%v)", replyPacket.GetResultMsg(), reqPacket, replyPacket, readBytes)
 
-			readBytes += int(replyPacket.Size)
+		if replyPacket.ResultCode == proto.OpAgain {
+			return 0, nil, true
 		}
-		return nil, false
-	})
 
-	if err != nil {
-		log.LogErrorf("Extent Reader Read: err(%v) req(%v) reqPacket(%v)", err, req, reqPacket)
+		e = reader.checkStreamReply(reqPacket, replyPacket)
+		if e == CrcMismatchError {
+			// The range of the extent key has been written to all the replicas,
+			// so it can be read from any of them.
+			log.LogWarnf("Extent Reader Read: crc mismatch, try other replicas, ino(%v) addr(%v) req(%v) reply(%v)",
+				reader.inode, conn.RemoteAddr(), reqPacket, replyPacket)
+			reqPacket.Opcode = proto.OpStreamFollowerRead
+			return readBytes, TryOtherAddrError, false
+		}
+		if e != nil {
+			// Dont change the error message, since the caller will
+			// check if it is NotLeaderErr.
+			return readBytes, e, false
+		}
+
+		readBytes += int(replyPacket.Size)
 	}
+	return readBytes, nil, false
+}
 
-	log.LogDebugf("ExtentReader Read exit: req(%v) reqPacket(%v) readBytes(%v) err(%v)", req, reqPacket, readBytes, err)
+type hedgedReply struct {
+	addr      string
+	data      []byte
+	readBytes int
+	err       error
+}
+
+// hedgedRead reads from a replica, and sends the read to another replica as well
+// if there is no reply in the hedge delay. The first complete reply is taken, and
+// the request falls back to the normal read if both of them fail.
+func (reader *ExtentReader) hedgedRead(req *ExtentRequest) (readBytes int, err error) {
+	hosts := sortByStatus(reader.dp, false)
+	if len(hosts) < 2 {
+		return reader.read(req)
+	}
+	first := NewStreamConn(reader.dp, true).currAddr
+	second := hosts[0]
+	if second == first {
+		second = hosts[1]
+	}
+
+	offset := req.FileOffset - int(reader.key.FileOffset) + int(reader.key.ExtentOffset)
+	size := req.Size
+	// The loser may still be writing its buffer, so each replica is read into its own.
+	replies := make(chan *hedgedReply, 2)
+	send := func(addr string) {
+		go func() {
+			reply := &hedgedReply{addr: addr, data: make([]byte, size)}
+			reqPacket := NewReadPacket(reader.key, offset, size, reader.inode, req.FileOffset, true)
+			reply.readBytes, reply.err = reader.readFromHost(addr, reqPacket, reply.data)
+			replies <- reply
+		}()
+	}
+
+	send(first)
+	timer := time.NewTimer(reader.hedgeDelay)
+	defer timer.Stop()
+
+	pending, hedged := 1, false
+	for pending > 0 {
+		select {
+		case reply := <-replies:
+			pending--
+			if reply.err == nil && reply.readBytes == size {
+				copy(req.Data[:size], reply.data)
+				return size, nil
+			}
+			log.LogWarnf("hedgedRead: ino(%v) req(%v) addr(%v) readBytes(%v) err(%v)", reader.inode, req, reply.addr, reply.readBytes, reply.err)
+			if !hedged {
+				hedged = true
+				pending++
+				send(second)
+			}
+		case <-timer.C:
+			if !hedged {
+				log.LogDebugf("hedgedRead: ino(%v) req(%v) no reply from addr(%v) in %v, send to addr(%v)", reader.inode, req, first, reader.hedgeDelay, second)
+				hedged = true
+				pending++
+				send(second)
+			}
+		}
+	}
+	return reader.read(req)
+}
+
+// readFromHost reads the request from the given replica without retries.
+func (reader *ExtentReader) readFromHost(addr string, reqPacket *Packet, data []byte) (readBytes int, err error) {
+	conn, err := StreamConnPool.GetConnect(addr)
+	if err != nil {
+		return
+	}
+	if err = reqPacket.WriteToConn(conn); err != nil {
+		StreamConnPool.PutConnect(conn, true)
+		return
+	}
+	var again bool
+	if readBytes, err, again = reader.receive(conn, reqPacket, data); again {
+		err = TryOtherAddrError
+	}
+	StreamConnPool.PutConnect(conn, err != nil)
 	return
 }
 
@@ -103,6 +209,10 @@ func (reader *ExtentReader) checkStreamReply(request *Packet, reply *Packet) (er
 		return TryOtherAddrError
 	}
 
+	if reply.ResultCode == proto.OpCrcErr {
+		return CrcMismatchError
+	}
+
 	if reply.ResultCode != proto.OpOk {
 		if request.Opcode == proto.OpStreamFollowerRead {
 			log.LogWarnf("checkStreamReply: ResultCode(%v) NOK, OpStreamFollowerRead return TryOtherAddrError, "+
@@ -118,8 +228,8 @@ func (reader *ExtentReader) checkStreamReply(request *Packet, reply *Packet) (er
 	}
 	expectCrc := crc32.ChecksumIEEE(reply.Data[:reply.Size])
 	if reply.CRC != expectCrc {
-		err = errors.New(fmt.Sprintf("checkStreamReply: inconsistent CRC, expectCRC(%v) replyCRC(%v)", expectCrc, reply.CRC))
-		return
+		log.LogWarnf("checkStreamReply: inconsistent CRC, expectCRC(%v) replyCRC(%v) req(%v) reply(%v)", expectCrc, reply.CRC, request, reply)
+		return CrcMismatchError
 	}
 	return nil
 }
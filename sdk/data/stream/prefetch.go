@@ -0,0 +1,105 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// prefetcher detects the sequential reads of a streamer and reads the
+// following blocks into the read cache ahead of them. The window starts
+// from a cache block and doubles on every sequential read up to the
+// prefetch size of the client, a random read resets it.
+type prefetcher struct {
+	sync.Mutex
+	next     int  // file offset of the next sequential read
+	window   int  // bytes to prefetch ahead of the next sequential read
+	end      int  // end of the range prefetched or being prefetched
+	inflight bool // a prefetch is in progress
+}
+
+// prefetch is called after a read of the range [offset, offset+size) through the read cache.
+func (s *Streamer) prefetch(offset, size int) {
+	max := s.client.prefetchSize
+	if max <= 0 || s.client.readCache == nil || size <= 0 {
+		return
+	}
+
+	p := &s.prefetcher
+	p.Lock()
+	// The async reads of the kernel may arrive out of order, so the reads
+	// close to the expected offset are still taken as sequential.
+	if offset+size < p.next-max || offset > p.next+max {
+		p.next = offset + size
+		p.window = 0
+		p.end = 0
+		p.Unlock()
+		return
+	}
+	p.next = util.Max(p.next, offset+size)
+	p.window = util.Min(util.Max(p.window*2, ReadCacheBlockSize), max)
+	// Keep going once the reads catch up with half of the window.
+	if p.inflight || p.end-p.next >= p.window/2 {
+		p.Unlock()
+		return
+	}
+	start := util.Max(p.end, p.next)
+	end := p.next + p.window
+	p.end = end
+	p.inflight = true
+	p.Unlock()
+
+	go func() {
+		s.prefetchRange(start, end)
+		p.Lock()
+		p.inflight = false
+		p.Unlock()
+	}()
+}
+
+func (s *Streamer) prefetchRange(start, end int) {
+	filesize, _ := s.extents.Size()
+	if end > filesize {
+		end = filesize
+	}
+	if start >= end {
+		return
+	}
+
+	waitBytes(context.Background(), s.client.readBpsLimiter, end-start)
+
+	data := make([]byte, end-start)
+	requests := s.extents.PrepareReadRequests(start, end-start, data)
+	for _, req := range requests {
+		// skip the holes and the data not flushed yet
+		if req.ExtentKey == nil || req.ExtentKey.PartitionId == 0 || req.ExtentKey.ExtentId == 0 {
+			continue
+		}
+		reader, err := s.GetExtentReader(req.ExtentKey)
+		if err != nil {
+			log.LogWarnf("prefetchRange: ino(%v) req(%v) err(%v)", s.inode, req, err)
+			return
+		}
+		if _, err = reader.ReadWithCache(s.client.readCache, req); err != nil {
+			log.LogWarnf("prefetchRange: ino(%v) req(%v) err(%v)", s.inode, req, err)
+			return
+		}
+	}
+	log.LogDebugf("prefetchRange: ino(%v) start(%v) end(%v)", s.inode, start, end)
+}
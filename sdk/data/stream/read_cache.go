@@ -0,0 +1,225 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	ReadCacheBlockSize = util.ReadBlockSize
+	// ReadCacheExpiration bounds how long the data overwritten by the other clients is read from the cache.
+	ReadCacheExpiration = 30 * time.Second
+)
+
+type readCacheKey struct {
+	partitionID uint64
+	extentID    uint64
+	index       uint64 // block index in the extent
+}
+
+// readCacheBlock holds the data of the range [start, end) of an extent.
+// A block never crosses the boundary of ReadCacheBlockSize.
+type readCacheBlock struct {
+	key    readCacheKey
+	start  int
+	end    int
+	data   []byte
+	expire time.Time
+	inodes []uint64 // the inodes read the block, which may share the extent
+}
+
+// ReadCache is an LRU cache of the extent data recently read from the datanodes.
+// Blocks are keyed by partition, extent and block index. The blocks of an inode are
+// invalidated by the writes, truncates and punch holes issued by this client, while
+// the changes made by the other clients are read once the blocks expire.
+type ReadCache struct {
+	sync.Mutex
+	capacity int64
+	used     int64
+	lru      *list.List
+	blocks   map[readCacheKey]*list.Element
+	inodes   map[uint64]map[readCacheKey]struct{}
+}
+
+// NewReadCache returns a new read cache holding at most capacity bytes.
+func NewReadCache(capacity int64) *ReadCache {
+	return &ReadCache{
+		capacity: capacity,
+		lru:      list.New(),
+		blocks:   make(map[readCacheKey]*list.Element),
+		inodes:   make(map[uint64]map[readCacheKey]struct{}),
+	}
+}
+
+func (c *ReadCache) String() string {
+	c.Lock()
+	defer c.Unlock()
+	return fmt.Sprintf("ReadCache{capacity(%v) used(%v) blocks(%v)}", c.capacity, c.used, len(c.blocks))
+}
+
+// Get returns the cached data of the range [start, end) of the block read by the inode, if present.
+func (c *ReadCache) Get(inode uint64, key readCacheKey, start, end int) ([]byte, bool) {
+	c.Lock()
+	defer c.Unlock()
+	elem, ok := c.blocks[key]
+	if !ok {
+		return nil, false
+	}
+	block := elem.Value.(*readCacheBlock)
+	if time.Now().After(block.expire) {
+		c.remove(elem)
+		return nil, false
+	}
+	if start < block.start || end > block.end {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	c.addInode(block, inode)
+	return block.data[start-block.start : end-block.start], true
+}
+
+// Put caches the data of the range [start, start+len(data)) of the block read by the inode.
+func (c *ReadCache) Put(inode uint64, key readCacheKey, start int, data []byte) {
+	if int64(len(data)) > c.capacity {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	if elem, ok := c.blocks[key]; ok {
+		c.remove(elem)
+	}
+	block := &readCacheBlock{
+		key:    key,
+		start:  start,
+		end:    start + len(data),
+		data:   data,
+		expire: time.Now().Add(ReadCacheExpiration),
+	}
+	c.blocks[key] = c.lru.PushFront(block)
+	c.addInode(block, inode)
+	c.used += int64(len(data))
+	for c.used > c.capacity {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Evict drops the blocks covering the range [start, end) of the extent.
+func (c *ReadCache) Evict(partitionID, extentID uint64, start, end int) {
+	if end <= start {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	for index := start / ReadCacheBlockSize; index <= (end-1)/ReadCacheBlockSize; index++ {
+		key := readCacheKey{partitionID: partitionID, extentID: extentID, index: uint64(index)}
+		if elem, ok := c.blocks[key]; ok {
+			c.remove(elem)
+		}
+	}
+}
+
+// EvictInode drops the blocks read by the inode.
+func (c *ReadCache) EvictInode(inode uint64) {
+	c.Lock()
+	defer c.Unlock()
+	for key := range c.inodes[inode] {
+		if elem, ok := c.blocks[key]; ok {
+			c.remove(elem)
+		}
+	}
+	delete(c.inodes, inode)
+}
+
+// Clear removes all the blocks.
+func (c *ReadCache) Clear() {
+	c.Lock()
+	c.blocks = make(map[readCacheKey]*list.Element)
+	c.inodes = make(map[uint64]map[readCacheKey]struct{})
+	c.lru.Init()
+	c.used = 0
+	c.Unlock()
+}
+
+func (c *ReadCache) addInode(block *readCacheBlock, inode uint64) {
+	keys, ok := c.inodes[inode]
+	if !ok {
+		keys = make(map[readCacheKey]struct{})
+		c.inodes[inode] = keys
+	}
+	if _, ok = keys[block.key]; !ok {
+		keys[block.key] = struct{}{}
+		block.inodes = append(block.inodes, inode)
+	}
+}
+
+func (c *ReadCache) remove(elem *list.Element) {
+	block := c.lru.Remove(elem).(*readCacheBlock)
+	delete(c.blocks, block.key)
+	for _, inode := range block.inodes {
+		if keys, ok := c.inodes[inode]; ok {
+			delete(keys, block.key)
+			if len(keys) == 0 {
+				delete(c.inodes, inode)
+			}
+		}
+	}
+	c.used -= int64(len(block.data))
+}
+
+// ReadWithCache reads the extent request through the read cache.
+// Missed blocks are read from the datanode as a whole, bounded by the extent key.
+func (reader *ExtentReader) ReadWithCache(cache *ReadCache, req *ExtentRequest) (readBytes int, err error) {
+	ek := reader.key
+	ekStart := int(ek.ExtentOffset)
+	ekEnd := ekStart + int(ek.Size)
+	start := req.FileOffset - int(ek.FileOffset) + ekStart
+	end := start + req.Size
+
+	for offset := start; offset < end; {
+		index := offset / ReadCacheBlockSize
+		blockStart := util.Max(index*ReadCacheBlockSize, ekStart)
+		blockEnd := util.Min((index+1)*ReadCacheBlockSize, ekEnd)
+		wantEnd := util.Min(blockEnd, end)
+		key := readCacheKey{partitionID: ek.PartitionId, extentID: ek.ExtentId, index: uint64(index)}
+
+		data, ok := cache.Get(reader.inode, key, offset, wantEnd)
+		if !ok {
+			buf := make([]byte, blockEnd-blockStart)
+			blockReq := NewExtentRequest(int(ek.FileOffset)+blockStart-ekStart, len(buf), buf, ek)
+			var n int
+			if n, err = reader.Read(blockReq); err != nil || n < len(buf) {
+				log.LogWarnf("ReadWithCache: ino(%v) req(%v) blockReq(%v) n(%v) err(%v)", reader.inode, req, blockReq, n, err)
+				// fall back to read the rest of the request directly
+				restReq := NewExtentRequest(req.FileOffset+readBytes, req.Size-readBytes, req.Data[readBytes:], ek)
+				n, err = reader.Read(restReq)
+				readBytes += n
+				return
+			}
+			cache.Put(reader.inode, key, blockStart, buf)
+			data = buf[offset-blockStart : wantEnd-blockStart]
+		}
+		copy(req.Data[readBytes:], data)
+		readBytes += len(data)
+		offset += len(data)
+	}
+	return
+}
@@ -20,6 +20,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/sdk/data/wrapper"
 	"github.com/chubaofs/chubaofs/util"
 	"github.com/chubaofs/chubaofs/util/errors"
@@ -28,6 +29,7 @@ import (
 
 var (
 	TryOtherAddrError = errors.New("TryOtherAddrError")
+	CrcMismatchError  = errors.New("CrcMismatchError")
 )
 
 const (
@@ -35,7 +37,7 @@ const (
 	StreamSendSleepInterval = 100 * time.Millisecond
 )
 
-type GetReplyFunc func(conn *net.TCPConn) (err error, again bool)
+type GetReplyFunc func(conn net.Conn) (err error, again bool)
 
 // StreamConn defines the struct of the stream connection.
 type StreamConn struct {
@@ -45,6 +47,9 @@ type StreamConn struct {
 
 var (
 	StreamConnPool = util.NewConnectPool()
+	// DataNodeFeatures are the features negotiated with the data nodes, a new data feature is only used
+	// on the data nodes supporting it.
+	DataNodeFeatures = proto.NewPeerFeatures(StreamConnPool, proto.DataNodeFeatures)
 )
 
 // NewStreamConn returns a new stream connection.
@@ -139,7 +144,7 @@ func (sc *StreamConn) sendToPartition(req *Packet, getReply GetReplyFunc) (err e
 	return errors.New(fmt.Sprintf("sendToPatition Failed: sc(%v) reqPacket(%v)", sc, req))
 }
 
-func (sc *StreamConn) sendToConn(conn *net.TCPConn, req *Packet, getReply GetReplyFunc) (err error) {
+func (sc *StreamConn) sendToConn(conn net.Conn, req *Packet, getReply GetReplyFunc) (err error) {
 	for i := 0; i < StreamSendMaxRetry; i++ {
 		log.LogDebugf("sendToConn: send to addr(%v), reqPacket(%v)", sc.currAddr, req)
 		err = req.WriteToConn(conn)
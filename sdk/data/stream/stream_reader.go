@@ -48,6 +48,8 @@ type Streamer struct {
 	done    chan struct{}    // stream writer is being closed
 
 	writeLock sync.Mutex
+
+	prefetcher prefetcher
 }
 
 // NewStreamer returns a new streamer.
@@ -80,11 +82,12 @@ func (s *Streamer) GetExtentReader(ek *proto.ExtentKey) (*ExtentReader, error) {
 	if err != nil {
 		return nil, err
 	}
-	reader := NewExtentReader(s.inode, ek, partition, s.client.dataWrapper.FollowerRead())
+	reader := NewExtentReader(s.inode, ek, partition, s.client.dataWrapper.FollowerRead() || s.client.replicaRead)
+	reader.hedgeDelay = s.client.hedgeDelay
 	return reader, nil
 }
 
-func (s *Streamer) read(data []byte, offset int, size int) (total int, err error) {
+func (s *Streamer) read(data []byte, offset int, size int, direct bool) (total int, err error) {
 	var (
 		readBytes       int
 		reader          *ExtentReader
@@ -94,6 +97,7 @@ func (s *Streamer) read(data []byte, offset int, size int) (total int, err error
 
 	ctx := context.Background()
 	s.client.readLimiter.Wait(ctx)
+	waitBytes(ctx, s.client.readBpsLimiter, size)
 
 	requests = s.extents.PrepareReadRequests(offset, size, data)
 	for _, req := range requests {
@@ -145,7 +149,11 @@ func (s *Streamer) read(data []byte, offset int, size int) (total int, err error
 			if err != nil {
 				break
 			}
-			readBytes, err = reader.Read(req)
+			if s.client.readCache != nil && !direct {
+				readBytes, err = reader.ReadWithCache(s.client.readCache, req)
+			} else {
+				readBytes, err = reader.Read(req)
+			}
 			log.LogDebugf("Stream read: ino(%v) req(%v) readBytes(%v) err(%v)", s.inode, req, readBytes, err)
 			total += readBytes
 			if err != nil || readBytes < req.Size {
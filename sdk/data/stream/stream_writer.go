@@ -82,6 +82,14 @@ type TruncRequest struct {
 	done chan struct{}
 }
 
+// PunchHoleRequest defines a request to punch a hole in the file.
+type PunchHoleRequest struct {
+	offset int
+	size   int
+	err    error
+	done   chan struct{}
+}
+
 // EvictRequest defines an evict request.
 type EvictRequest struct {
 	err  error
@@ -100,8 +108,18 @@ func (s *Streamer) IssueOpenRequest() error {
 }
 
 func (s *Streamer) IssueWriteRequest(offset int, data []byte, flags int) (write int, err error) {
+	_, write, err = s.issueWriteRequest(offset, data, flags)
+	return
+}
+
+// IssueAppendRequest writes the data at the end of the file, it returns the offset the data is written at.
+func (s *Streamer) IssueAppendRequest(data []byte, flags int) (offset int, write int, err error) {
+	return s.issueWriteRequest(0, data, flags|proto.FlagsAppend)
+}
+
+func (s *Streamer) issueWriteRequest(offset int, data []byte, flags int) (fileOffset int, write int, err error) {
 	if atomic.LoadInt32(&s.status) >= StreamerError {
-		return 0, errors.New(fmt.Sprintf("IssueWriteRequest: stream writer in error status, ino(%v)", s.inode))
+		return 0, 0, errors.New(fmt.Sprintf("IssueWriteRequest: stream writer in error status, ino(%v)", s.inode))
 	}
 
 	s.writeLock.Lock()
@@ -116,6 +134,7 @@ func (s *Streamer) IssueWriteRequest(offset int, data []byte, flags int) (write
 
 	<-request.done
 	err = request.err
+	fileOffset = request.fileOffset
 	write = request.writeBytes
 	writeRequestPool.Put(request)
 	return
@@ -153,6 +172,18 @@ func (s *Streamer) IssueTruncRequest(size int) error {
 	return err
 }
 
+func (s *Streamer) IssuePunchHoleRequest(offset, size int) error {
+	request := punchRequestPool.Get().(*PunchHoleRequest)
+	request.offset = offset
+	request.size = size
+	request.done = make(chan struct{}, 1)
+	s.request <- request
+	<-request.done
+	err := request.err
+	punchRequestPool.Put(request)
+	return err
+}
+
 func (s *Streamer) IssueEvictRequest() error {
 	request := evictRequestPool.Get().(*EvictRequest)
 	request.done = make(chan struct{}, 1)
@@ -222,6 +253,9 @@ func (s *Streamer) abortRequest(request interface{}) {
 	case *TruncRequest:
 		request.err = syscall.EAGAIN
 		request.done <- struct{}{}
+	case *PunchHoleRequest:
+		request.err = syscall.EAGAIN
+		request.done <- struct{}{}
 	case *FlushRequest:
 		request.err = syscall.EAGAIN
 		request.done <- struct{}{}
@@ -241,11 +275,18 @@ func (s *Streamer) handleRequest(request interface{}) {
 		s.open()
 		request.done <- struct{}{}
 	case *WriteRequest:
+		// the appends are resolved here, so the concurrent appends of the file never overlap
+		if request.flags&proto.FlagsAppend != 0 {
+			request.fileOffset, _ = s.extents.Size()
+		}
 		request.writeBytes, request.err = s.write(request.data, request.fileOffset, request.size, request.flags)
 		request.done <- struct{}{}
 	case *TruncRequest:
 		request.err = s.truncate(request.size)
 		request.done <- struct{}{}
+	case *PunchHoleRequest:
+		request.err = s.punchHole(request.offset, request.size)
+		request.done <- struct{}{}
 	case *FlushRequest:
 		request.err = s.flush()
 		request.done <- struct{}{}
@@ -266,15 +307,17 @@ func (s *Streamer) write(data []byte, offset, size, flags int) (total int, err e
 		direct = true
 	}
 
-	if flags&proto.FlagsAppend != 0 {
-		filesize, _ := s.extents.Size()
-		offset = filesize
-	}
-
 	log.LogDebugf("Streamer write enter: ino(%v) offset(%v) size(%v)", s.inode, offset, size)
 
 	ctx := context.Background()
 	s.client.writeLimiter.Wait(ctx)
+	waitBytes(ctx, s.client.writeBpsLimiter, size)
+
+	if s.client.isShared != nil && s.client.isShared(s.inode) {
+		// the extents may be shared with a cloned file, so write the data to new extents instead
+		requests := []*ExtentRequest{NewExtentRequest(offset, size, data, nil)}
+		return s.writeRequests(requests, offset, size, direct)
+	}
 
 	requests := s.extents.PrepareWriteRequests(offset, size, data)
 	log.LogDebugf("Streamer write: ino(%v) prepared requests(%v)", s.inode, requests)
@@ -284,6 +327,12 @@ func (s *Streamer) write(data []byte, offset, size, flags int) (total int, err e
 		if req.ExtentKey == nil {
 			continue
 		}
+		// the file may be cloned by another client since the extents were got,
+		// so the meta node is asked before overwriting the extents in place
+		if s.client.checkShared != nil && s.client.checkShared(s.inode) {
+			requests = []*ExtentRequest{NewExtentRequest(offset, size, data, nil)}
+			return s.writeRequests(requests, offset, size, direct)
+		}
 		err = s.flush()
 		if err != nil {
 			return
@@ -292,7 +341,10 @@ func (s *Streamer) write(data []byte, offset, size, flags int) (total int, err e
 		log.LogDebugf("Streamer write: ino(%v) prepared requests after flush(%v)", s.inode, requests)
 		break
 	}
+	return s.writeRequests(requests, offset, size, direct)
+}
 
+func (s *Streamer) writeRequests(requests []*ExtentRequest, offset, size int, direct bool) (total int, err error) {
 	for _, req := range requests {
 		var writeSize int
 		if req.ExtentKey != nil {
@@ -306,6 +358,7 @@ func (s *Streamer) write(data []byte, offset, size, flags int) (total int, err e
 		}
 		total += writeSize
 	}
+	s.evictReadCache()
 	if filesize, _ := s.extents.Size(); offset+total > filesize {
 		s.extents.SetSize(uint64(offset+total), false)
 		log.LogDebugf("Streamer write: ino(%v) filesize changed to (%v)", s.inode, offset+total)
@@ -341,6 +394,12 @@ func (s *Streamer) doOverwrite(req *ExtentRequest, direct bool) (total int, err
 		return
 	}
 
+	if cache := s.client.readCache; cache != nil {
+		// evict after the overwrite so that no stale block read meanwhile is left
+		ek := req.ExtentKey
+		defer cache.Evict(ek.PartitionId, ek.ExtentId, offset-ekFileOffset+ekExtOffset, offset-ekFileOffset+ekExtOffset+size)
+	}
+
 	sc := NewStreamConn(dp, false)
 
 	for total < size {
@@ -354,7 +413,7 @@ func (s *Streamer) doOverwrite(req *ExtentRequest, direct bool) (total int, err
 		reqPacket.CRC = crc32.ChecksumIEEE(reqPacket.Data[:packSize])
 
 		replyPacket := new(Packet)
-		err = sc.Send(reqPacket, func(conn *net.TCPConn) (error, bool) {
+		err = sc.Send(reqPacket, func(conn net.Conn) (error, bool) {
 			e := replyPacket.ReadFromConn(conn, proto.ReadDeadlineTime)
 			if e != nil {
 				log.LogWarnf("Stream Writer doOverwrite: ino(%v) failed to read from connect, req(%v) err(%v)", s.inode, reqPacket, e)
@@ -572,6 +631,7 @@ func (s *Streamer) truncate(size int) error {
 	if err != nil {
 		return err
 	}
+	s.evictReadCache()
 
 	oldsize, _ := s.extents.Size()
 	if oldsize <= size {
@@ -582,6 +642,28 @@ func (s *Streamer) truncate(size int) error {
 	return s.GetExtents()
 }
 
+func (s *Streamer) punchHole(offset, size int) error {
+	s.closeOpenHandler()
+	err := s.flush()
+	if err != nil {
+		return err
+	}
+
+	err = s.client.punchHole(s.inode, uint64(offset), uint64(size))
+	if err != nil {
+		return err
+	}
+	s.evictReadCache()
+	return s.GetExtents()
+}
+
+// evictReadCache drops the blocks of the inode in the read cache once the data is changed by this client.
+func (s *Streamer) evictReadCache() {
+	if cache := s.client.readCache; cache != nil {
+		cache.EvictInode(s.inode)
+	}
+}
+
 func (s *Streamer) tinySizeLimit() int {
-	return util.DefaultTinySizeLimit
+	return s.client.dataWrapper.TinySizeLimit()
 }
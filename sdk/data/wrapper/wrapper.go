@@ -19,10 +19,12 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/chubaofs/chubaofs/proto"
 	masterSDK "github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/util"
 	"github.com/chubaofs/chubaofs/util/errors"
 	"github.com/chubaofs/chubaofs/util/iputil"
 	"github.com/chubaofs/chubaofs/util/log"
@@ -50,6 +52,7 @@ type Wrapper struct {
 	dpSelectorChanged     bool
 	dpSelectorName        string
 	dpSelectorParm        string
+	tinySizeLimit         int32 // the files up to the size are packed into the tiny extents
 	mc                    *masterSDK.MasterClient
 	stopOnce              sync.Once
 	stopC                 chan struct{}
@@ -127,6 +130,7 @@ func (w *Wrapper) getSimpleVolView() (err error) {
 	w.followerRead = view.FollowerRead
 	w.dpSelectorName = view.DpSelectorName
 	w.dpSelectorParm = view.DpSelectorParm
+	w.updateTinySizeLimit(view)
 
 	log.LogInfof("getSimpleVolView: get volume simple info: ID(%v) name(%v) owner(%v) status(%v) capacity(%v) "+
 		"metaReplicas(%v) dataReplicas(%v) mpCnt(%v) dpCnt(%v) followerRead(%v) createTime(%v) dpSelectorName(%v) "+
@@ -174,9 +178,27 @@ func (w *Wrapper) updateSimpleVolView() (err error) {
 		w.Unlock()
 	}
 
+	w.updateTinySizeLimit(view)
 	return nil
 }
 
+func (w *Wrapper) updateTinySizeLimit(view *proto.SimpleVolView) {
+	limit := int32(util.DefaultTinySizeLimit)
+	if view.DisablePacking {
+		limit = 0
+	} else if view.SmallFileSize != 0 {
+		limit = int32(view.SmallFileSize)
+	}
+	if old := atomic.SwapInt32(&w.tinySizeLimit, limit); old != limit {
+		log.LogInfof("updateTinySizeLimit: update tinySizeLimit from old(%v) to new(%v)", old, limit)
+	}
+}
+
+// TinySizeLimit returns the max size of the files packed into the tiny extents, 0 if the packing is disabled.
+func (w *Wrapper) TinySizeLimit() int {
+	return int(atomic.LoadInt32(&w.tinySizeLimit))
+}
+
 func (w *Wrapper) updateDataPartition(isInit bool) (err error) {
 
 	var dpv *proto.DataPartitionsView
@@ -0,0 +1,391 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package fsclient accesses the files of a volume directly through the meta and the data SDK,
+// so applications can use a volume without mounting it. The paths are slash separated and
+// relative to the root of the volume, or to the sub directory of the Config if it is given.
+package fsclient
+
+import (
+	"os"
+	gopath "path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/data/stream"
+	masterSDK "github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/sdk/meta"
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/errors"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// Config is the configuration of a Client.
+type Config struct {
+	Masters  []string
+	Volume   string
+	Owner    string
+	SubDir   string // root of the client inside the volume
+	TokenKey string // access token of the volume if the cluster enables it
+
+	// Owner of the files and the directories created by the client
+	Uid uint32
+	Gid uint32
+
+	FollowerRead  bool
+	NearRead      bool
+	ReadRate      int64
+	WriteRate     int64
+	ReadCacheSize int64 // MB, 0 disables the read cache
+}
+
+// Client accesses a volume, it is safe to be used by multiple goroutines.
+type Client struct {
+	volname string
+	uid     uint32
+	gid     uint32
+	rootIno uint64
+	mc      *masterSDK.MasterClient
+	mw      *meta.MetaWrapper
+	ec      *stream.ExtentClient
+}
+
+// BlockLocation is a range of a file stored by a data partition.
+type BlockLocation struct {
+	Offset      int64
+	Size        int64
+	PartitionID uint64
+	Hosts       []string
+	Zones       []string // zone of each host
+}
+
+// NewClient connects to the volume.
+func NewClient(cfg *Config) (c *Client, err error) {
+	c = &Client{volname: cfg.Volume, uid: cfg.Uid, gid: cfg.Gid}
+	c.mc = masterSDK.NewMasterClient(cfg.Masters, false)
+	var metaConfig = &meta.MetaConfig{
+		Volume:        cfg.Volume,
+		Owner:         cfg.Owner,
+		Masters:       cfg.Masters,
+		TokenKey:      cfg.TokenKey,
+		ValidateOwner: true,
+	}
+	if c.mw, err = meta.NewMetaWrapper(metaConfig); err != nil {
+		return nil, errors.Trace(err, "NewMetaWrapper failed!")
+	}
+	if c.mw.Flat() {
+		_ = c.mw.Close()
+		return nil, errors.New("volume of the flat namespace can not be accessed by the file system interface")
+	}
+	var extentConfig = &stream.ExtentConfig{
+		Volume:            cfg.Volume,
+		Masters:           cfg.Masters,
+		FollowerRead:      cfg.FollowerRead,
+		NearRead:          cfg.NearRead,
+		ReadRate:          cfg.ReadRate,
+		WriteRate:         cfg.WriteRate,
+		ReadCacheSize:     cfg.ReadCacheSize * util.MB,
+		OnAppendExtentKey: c.mw.AppendExtentKey,
+		OnGetExtents:      c.mw.GetExtents,
+		OnTruncate:        c.mw.Truncate,
+		OnPunchHole:       c.mw.PunchHole,
+		OnIsShared:        c.mw.IsShared,
+		OnCheckShared:     c.mw.CheckShared,
+	}
+	if c.ec, err = stream.NewExtentClient(extentConfig); err != nil {
+		c.mw.Close()
+		return nil, errors.Trace(err, "NewExtentClient failed!")
+	}
+	if c.rootIno, err = c.mw.GetRootIno(cfg.SubDir); err != nil {
+		c.Close()
+		return nil, err
+	}
+	log.LogInfof("NewClient: volume(%v) subdir(%v) root(%v)", cfg.Volume, cfg.SubDir, c.rootIno)
+	return c, nil
+}
+
+// Close closes the client, the files opened are not usable any more.
+func (c *Client) Close() error {
+	c.ec.Close()
+	return c.mw.Close()
+}
+
+// Stat returns the information of the file or the directory, the Sys of which is the *proto.InodeInfo.
+func (c *Client) Stat(path string) (os.FileInfo, error) {
+	ino, err := c.lookupPath(path)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: path, Err: err}
+	}
+	info, err := c.inodeGet(ino)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: path, Err: err}
+	}
+	return newFileInfo(gopath.Base(cleanPath(path)), info), nil
+}
+
+// Mkdir creates the directory, the parent of which must exist.
+func (c *Client) Mkdir(path string, perm os.FileMode) error {
+	parentIno, name, err := c.lookupParent(path)
+	if err == nil {
+		_, err = c.mw.Create_ll(parentIno, name, proto.Mode(os.ModeDir|perm.Perm()), c.uid, c.gid, nil)
+	}
+	if err != nil {
+		return &os.PathError{Op: "mkdir", Path: path, Err: err}
+	}
+	return nil
+}
+
+// MkdirAll creates the directory and the parents which do not exist.
+func (c *Client) MkdirAll(path string, perm os.FileMode) error {
+	ino := c.rootIno
+	for _, name := range splitPath(path) {
+		child, mode, _, err := c.lookup(ino, name)
+		if err == syscall.ENOENT {
+			var info *proto.InodeInfo
+			if info, err = c.mw.Create_ll(ino, name, proto.Mode(os.ModeDir|perm.Perm()), c.uid, c.gid, nil); err == nil {
+				child, mode = info.Inode, info.Mode
+			} else if err == syscall.EEXIST {
+				child, mode, _, err = c.lookup(ino, name)
+			}
+		}
+		if err != nil {
+			return &os.PathError{Op: "mkdir", Path: path, Err: err}
+		}
+		if !proto.IsDir(mode) {
+			return &os.PathError{Op: "mkdir", Path: path, Err: syscall.ENOTDIR}
+		}
+		ino = child
+	}
+	return nil
+}
+
+// ReadDir returns the entries of the directory sorted by name.
+func (c *Client) ReadDir(path string) ([]os.FileInfo, error) {
+	ino, err := c.lookupPath(path)
+	if err != nil {
+		return nil, &os.PathError{Op: "readdir", Path: path, Err: err}
+	}
+	entries := make([]os.FileInfo, 0)
+	var marker string
+	for {
+		children, infos, err := c.mw.ReadDirPlusLimit_ll(ino, marker, meta.ReadDirLimit)
+		if err != nil {
+			return nil, &os.PathError{Op: "readdir", Path: path, Err: err}
+		}
+		inodes := make(map[uint64]*proto.InodeInfo, len(infos))
+		for _, info := range infos {
+			inodes[info.Inode] = info
+		}
+		for _, child := range children {
+			// the dentries removed meanwhile are left out
+			if info, ok := inodes[child.Inode]; ok {
+				entries = append(entries, newFileInfo(child.Name, info))
+			}
+		}
+		if uint64(len(children)) < meta.ReadDirLimit {
+			return entries, nil
+		}
+		marker = children[len(children)-1].Name
+	}
+}
+
+// Rename renames the file or the directory, the target is replaced if it is a file.
+func (c *Client) Rename(oldpath, newpath string) error {
+	srcParent, srcName, err := c.lookupParent(oldpath)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	// the dentry is renamed by its real name, the case of the target name is kept
+	if _, _, srcName, err = c.lookup(srcParent, srcName); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	dstParent, dstName, err := c.lookupParent(newpath)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	if err = c.mw.Rename_ll(srcParent, srcName, dstParent, dstName); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	return nil
+}
+
+// Remove removes the file or the empty directory.
+func (c *Client) Remove(path string) error {
+	parentIno, name, err := c.lookupParent(path)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: path, Err: err}
+	}
+	_, mode, name, err := c.lookup(parentIno, name)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: path, Err: err}
+	}
+	info, err := c.mw.Delete_ll(parentIno, name, proto.IsDir(mode))
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: path, Err: err}
+	}
+	if info != nil {
+		if err = c.ec.EvictStream(info.Inode); err != nil {
+			log.LogWarnf("Remove: EvictStream path(%v) ino(%v) err(%v)", path, info.Inode, err)
+		}
+		if err = c.mw.Evict(info.Inode); err != nil {
+			log.LogWarnf("Remove: Evict path(%v) ino(%v) err(%v)", path, info.Inode, err)
+		}
+	}
+	return nil
+}
+
+// Truncate changes the size of the file.
+func (c *Client) Truncate(path string, size int64) error {
+	f, err := c.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if err = f.Truncate(size); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// BlockLocations returns the locations of the data of the file in the range, for the computing frameworks
+// to schedule the tasks near the data. The holes and the data not flushed yet are left out.
+func (c *Client) BlockLocations(path string, off, length int64) ([]*BlockLocation, error) {
+	ino, err := c.lookupPath(path)
+	if err != nil {
+		return nil, &os.PathError{Op: "locate", Path: path, Err: err}
+	}
+	_, _, extents, err := c.mw.GetExtents(ino)
+	if err != nil {
+		return nil, &os.PathError{Op: "locate", Path: path, Err: err}
+	}
+	blocks := make([]*BlockLocation, 0)
+	ids := make([]uint64, 0)
+	end := off + length
+	for _, ek := range extents {
+		start, stop := int64(ek.FileOffset), int64(ek.FileOffset)+int64(ek.Size)
+		if stop <= off || start >= end {
+			continue
+		}
+		if start < off {
+			start = off
+		}
+		if stop > end {
+			stop = end
+		}
+		// the adjacent extents of a partition are a block
+		if n := len(blocks); n > 0 && blocks[n-1].PartitionID == ek.PartitionId && blocks[n-1].Offset+blocks[n-1].Size == start {
+			blocks[n-1].Size = stop - blocks[n-1].Offset
+			continue
+		}
+		blocks = append(blocks, &BlockLocation{Offset: start, Size: stop - start, PartitionID: ek.PartitionId})
+		ids = append(ids, ek.PartitionId)
+	}
+	if len(blocks) == 0 {
+		return blocks, nil
+	}
+	locations, err := c.mc.ClientAPI().GetDataPartitionLocations(c.volname, ids)
+	if err != nil {
+		return nil, &os.PathError{Op: "locate", Path: path, Err: err}
+	}
+	partitions := make(map[uint64]*proto.DataPartitionLocation, len(locations))
+	for _, loc := range locations {
+		partitions[loc.PartitionID] = loc
+	}
+	for _, block := range blocks {
+		if loc, ok := partitions[block.PartitionID]; ok {
+			block.Hosts, block.Zones = loc.Hosts, loc.Zones
+		}
+	}
+	return blocks, nil
+}
+
+func (c *Client) inodeGet(ino uint64) (info *proto.InodeInfo, err error) {
+	if info, err = c.mw.InodeGet_ll(ino); err != nil {
+		return
+	}
+	// the data written but not flushed yet
+	if size, _, valid := c.ec.FileSize(ino); valid && proto.IsRegular(info.Mode) {
+		info.Size = uint64(size)
+	}
+	return
+}
+
+// lookup looks up the name in the directory, ignoring the case if the volume is case-insensitive,
+// realName is the name of the matched dentry.
+func (c *Client) lookup(parentIno uint64, name string) (ino uint64, mode uint32, realName string, err error) {
+	if c.mw.CaseInsensitive() {
+		return c.mw.LookupCaseInsensitive_ll(parentIno, name)
+	}
+	ino, mode, err = c.mw.Lookup_ll(parentIno, name)
+	return ino, mode, name, err
+}
+
+func (c *Client) lookupPath(path string) (ino uint64, err error) {
+	ino = c.rootIno
+	for _, name := range splitPath(path) {
+		if ino, _, _, err = c.lookup(ino, name); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// lookupParent returns the parent directory and the name of the path, the path must not be the root.
+func (c *Client) lookupParent(path string) (parentIno uint64, name string, err error) {
+	names := splitPath(path)
+	if len(names) == 0 {
+		return 0, "", syscall.EINVAL
+	}
+	parentIno = c.rootIno
+	for _, dir := range names[:len(names)-1] {
+		var mode uint32
+		if parentIno, mode, _, err = c.lookup(parentIno, dir); err != nil {
+			return
+		}
+		if !proto.IsDir(mode) {
+			return 0, "", syscall.ENOTDIR
+		}
+	}
+	return parentIno, names[len(names)-1], nil
+}
+
+func cleanPath(path string) string {
+	return gopath.Clean("/" + path)
+}
+
+func splitPath(path string) []string {
+	path = cleanPath(path)
+	if path == "/" {
+		return nil
+	}
+	return strings.Split(path[1:], "/")
+}
+
+type fileInfo struct {
+	name string
+	info *proto.InodeInfo
+}
+
+func newFileInfo(name string, info *proto.InodeInfo) *fileInfo {
+	return &fileInfo{name: name, info: info}
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return int64(fi.info.Size) }
+func (fi *fileInfo) Mode() os.FileMode  { return proto.OsMode(fi.info.Mode) }
+func (fi *fileInfo) ModTime() time.Time { return fi.info.ModifyTime }
+func (fi *fileInfo) IsDir() bool        { return proto.IsDir(fi.info.Mode) }
+func (fi *fileInfo) Sys() interface{}   { return fi.info }
@@ -0,0 +1,304 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fsclient
+
+import (
+	"io"
+	"os"
+	"sync"
+	"syscall"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// File is an opened file of the volume, it is safe to be used by multiple goroutines.
+type File struct {
+	sync.Mutex
+	c      *Client
+	path   string
+	ino    uint64
+	flag   int
+	offset int64
+	closed bool
+}
+
+// Open opens the file for reading.
+func (c *Client) Open(path string) (*File, error) {
+	return c.OpenFile(path, os.O_RDONLY, 0)
+}
+
+// Create creates the file or truncates it if it exists, the file is opened for reading and writing.
+func (c *Client) Create(path string) (*File, error) {
+	return c.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// OpenFile opens the file with the flags of os.OpenFile, the O_CREATE, O_EXCL, O_TRUNC, O_APPEND and O_SYNC are supported.
+func (c *Client) OpenFile(path string, flag int, perm os.FileMode) (f *File, err error) {
+	ino, err := c.openInode(path, flag, perm)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	if err = c.ec.OpenStream(ino); err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	if err = c.ec.RefreshExtentsCache(ino); err != nil {
+		log.LogWarnf("OpenFile: RefreshExtentsCache path(%v) ino(%v) err(%v)", path, ino, err)
+	}
+	f = &File{c: c, path: path, ino: ino, flag: flag}
+	if flag&os.O_TRUNC != 0 && writable(flag) {
+		if err = c.ec.Truncate(ino, 0); err != nil {
+			c.ec.CloseStream(ino)
+			return nil, &os.PathError{Op: "open", Path: path, Err: err}
+		}
+	}
+	return f, nil
+}
+
+func (c *Client) openInode(path string, flag int, perm os.FileMode) (ino uint64, err error) {
+	parentIno, name, err := c.lookupParent(path)
+	if err != nil {
+		return
+	}
+	ino, mode, _, err := c.lookup(parentIno, name)
+	if err == syscall.ENOENT && flag&os.O_CREATE != 0 {
+		var info *proto.InodeInfo
+		if info, err = c.mw.Create_ll(parentIno, name, proto.Mode(perm.Perm()), c.uid, c.gid, nil); err == nil {
+			return info.Inode, nil
+		}
+		if err != syscall.EEXIST || flag&os.O_EXCL != 0 {
+			return
+		}
+		// created by another client meanwhile
+		ino, mode, _, err = c.lookup(parentIno, name)
+	}
+	if err != nil {
+		return
+	}
+	if flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL {
+		return 0, syscall.EEXIST
+	}
+	if proto.IsDir(mode) {
+		return 0, syscall.EISDIR
+	}
+	if !proto.IsRegular(mode) {
+		return 0, syscall.EINVAL
+	}
+	return ino, nil
+}
+
+func writable(flag int) bool {
+	return flag&(os.O_WRONLY|os.O_RDWR) != 0
+}
+
+// Name returns the path the file is opened with.
+func (f *File) Name() string {
+	return f.path
+}
+
+// Read reads from the offset of the file, it returns io.EOF at the end of the file.
+func (f *File) Read(b []byte) (n int, err error) {
+	f.Lock()
+	defer f.Unlock()
+	if n, err = f.readAt(b, f.offset); n > 0 {
+		f.offset += int64(n)
+	}
+	return
+}
+
+// ReadAt reads len(b) bytes from the offset, it returns an error if less bytes are read.
+func (f *File) ReadAt(b []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, &os.PathError{Op: "readat", Path: f.path, Err: syscall.EINVAL}
+	}
+	for n < len(b) {
+		var m int
+		m, err = f.readAt(b[n:], off+int64(n))
+		n += m
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (f *File) readAt(b []byte, off int64) (n int, err error) {
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.flag&os.O_WRONLY != 0 {
+		return 0, &os.PathError{Op: "read", Path: f.path, Err: syscall.EBADF}
+	}
+	if len(b) == 0 {
+		return
+	}
+	n, err = f.c.ec.Read(f.ino, b, int(off), len(b))
+	if err != nil && err != io.EOF {
+		return n, &os.PathError{Op: "read", Path: f.path, Err: err}
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// Write writes to the offset of the file, or to the end of the file if it is opened with O_APPEND.
+// The appends of the files opened by the client never overlap.
+func (f *File) Write(b []byte) (n int, err error) {
+	f.Lock()
+	defer f.Unlock()
+	if f.flag&os.O_APPEND == 0 {
+		if n, err = f.writeAt(b, f.offset); n > 0 {
+			f.offset += int64(n)
+		}
+		return
+	}
+	if err = f.checkWrite(); err != nil {
+		return
+	}
+	off, n, err := f.c.ec.Append(f.ino, b, f.writeFlags())
+	if err != nil {
+		return n, &os.PathError{Op: "write", Path: f.path, Err: err}
+	}
+	f.offset = int64(off + n)
+	return n, f.syncWrite()
+}
+
+// WriteAt writes to the offset of the file, it is not allowed for a file opened with O_APPEND.
+func (f *File) WriteAt(b []byte, off int64) (n int, err error) {
+	if f.flag&os.O_APPEND != 0 {
+		return 0, &os.PathError{Op: "writeat", Path: f.path, Err: syscall.EINVAL}
+	}
+	if off < 0 {
+		return 0, &os.PathError{Op: "writeat", Path: f.path, Err: syscall.EINVAL}
+	}
+	return f.writeAt(b, off)
+}
+
+func (f *File) writeAt(b []byte, off int64) (n int, err error) {
+	if err = f.checkWrite(); err != nil {
+		return
+	}
+	if n, err = f.c.ec.Write(f.ino, int(off), b, f.writeFlags()); err != nil {
+		return n, &os.PathError{Op: "write", Path: f.path, Err: err}
+	}
+	return n, f.syncWrite()
+}
+
+func (f *File) checkWrite() error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	if !writable(f.flag) {
+		return &os.PathError{Op: "write", Path: f.path, Err: syscall.EBADF}
+	}
+	return nil
+}
+
+func (f *File) writeFlags() (flags int) {
+	if f.flag&os.O_SYNC != 0 {
+		flags |= proto.FlagsSyncWrite
+	}
+	return
+}
+
+// syncWrite flushes the data written at once if the file is opened with O_SYNC.
+func (f *File) syncWrite() error {
+	if f.flag&os.O_SYNC == 0 {
+		return nil
+	}
+	if err := f.c.ec.Flush(f.ino); err != nil {
+		return &os.PathError{Op: "write", Path: f.path, Err: err}
+	}
+	return nil
+}
+
+// Seek sets the offset of the next Read or Write.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	f.Lock()
+	defer f.Unlock()
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		size, _, _ := f.c.ec.FileSize(f.ino)
+		offset += int64(size)
+	default:
+		return 0, &os.PathError{Op: "seek", Path: f.path, Err: syscall.EINVAL}
+	}
+	if offset < 0 {
+		return 0, &os.PathError{Op: "seek", Path: f.path, Err: syscall.EINVAL}
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+// Stat returns the information of the file, the size includes the data not flushed yet.
+func (f *File) Stat() (os.FileInfo, error) {
+	info, err := f.c.inodeGet(f.ino)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: f.path, Err: err}
+	}
+	return newFileInfo(f.path, info), nil
+}
+
+// Truncate changes the size of the file.
+func (f *File) Truncate(size int64) error {
+	if size < 0 || !writable(f.flag) {
+		return &os.PathError{Op: "truncate", Path: f.path, Err: syscall.EINVAL}
+	}
+	if err := f.c.ec.Truncate(f.ino, int(size)); err != nil {
+		return &os.PathError{Op: "truncate", Path: f.path, Err: err}
+	}
+	return nil
+}
+
+// Flush flushes the data written to the data nodes and the extents to the meta nodes,
+// so the data is visible to the files opened afterwards by any client, like the hflush of HDFS.
+func (f *File) Flush() error {
+	if err := f.c.ec.Flush(f.ino); err != nil {
+		return &os.PathError{Op: "flush", Path: f.path, Err: err}
+	}
+	return nil
+}
+
+// Sync is Flush, the data written to a file opened with O_SYNC is also persisted by the data nodes
+// before the writes return, like the hsync of HDFS.
+func (f *File) Sync() error {
+	if err := f.c.ec.Flush(f.ino); err != nil {
+		return &os.PathError{Op: "sync", Path: f.path, Err: err}
+	}
+	return nil
+}
+
+// Close flushes the data written and closes the file.
+func (f *File) Close() (err error) {
+	f.Lock()
+	defer f.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	f.closed = true
+	if writable(f.flag) {
+		if err = f.c.ec.Flush(f.ino); err != nil {
+			err = &os.PathError{Op: "close", Path: f.path, Err: err}
+		}
+	}
+	if e := f.c.ec.CloseStream(f.ino); e != nil && err == nil {
+		err = &os.PathError{Op: "close", Path: f.path, Err: e}
+	}
+	return
+}
@@ -51,6 +51,34 @@ func (api *AdminAPI) GetClusterStat() (cs *proto.ClusterStatInfo, err error) {
 	}
 	return
 }
+
+// GetRaftStatus returns the raft status of the master leader.
+func (api *AdminAPI) GetRaftStatus() (rs *proto.MasterRaftStatus, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminGetRaftStatus)
+	var buf []byte
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	rs = &proto.MasterRaftStatus{}
+	if err = json.Unmarshal(buf, rs); err != nil {
+		return
+	}
+	return
+}
+
+func (api *AdminAPI) GetClusterOverview() (ov *proto.ClusterOverview, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminClusterOverview)
+	var buf []byte
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	ov = &proto.ClusterOverview{}
+	if err = json.Unmarshal(buf, ov); err != nil {
+		return
+	}
+	return
+}
+
 func (api *AdminAPI) ListZones() (zoneViews []*proto.ZoneView, err error) {
 	var request = newAPIRequest(http.MethodGet, proto.GetAllZones)
 	var buf []byte
@@ -76,6 +104,21 @@ func (api *AdminAPI) Topo() (topo *proto.TopologyView, err error ){
 	return
 }
 
+// GetFaultDomains returns the capacity of the fault domains of the cluster, under the topology path only if not empty.
+func (api *AdminAPI) GetFaultDomains(path string) (domains []*proto.FaultDomainView, err error) {
+	var buf []byte
+	var request = newAPIRequest(http.MethodGet, proto.GetFaultDomains)
+	request.addParam("path", path)
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	domains = make([]*proto.FaultDomainView, 0)
+	if err = json.Unmarshal(buf, &domains); err != nil {
+		return
+	}
+	return
+}
+
 func (api *AdminAPI) GetDataPartition(volName string, partitionID uint64) (partition *proto.DataPartitionInfo, err error) {
 	var buf []byte
 	var request = newAPIRequest(http.MethodGet, proto.AdminGetDataPartition)
@@ -117,6 +160,29 @@ func (api *AdminAPI) DiagnoseMetaPartition() (diagnosis *proto.MetaPartitionDiag
 	return
 }
 
+func (api *AdminAPI) GetDataPartitionDivergence(volName string) (divergences []*proto.ReplicaDivergence, err error) {
+	var buf []byte
+	var request = newAPIRequest(http.MethodGet, proto.AdminDataPartitionDivergence)
+	request.addParam("name", volName)
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	divergences = make([]*proto.ReplicaDivergence, 0)
+	if err = json.Unmarshal(buf, &divergences); err != nil {
+		return
+	}
+	return
+}
+
+func (api *AdminAPI) RepairDataPartition(partitionID uint64) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminRepairDataPartition)
+	request.addParam("id", strconv.FormatUint(partitionID, 10))
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
 func (api *AdminAPI) LoadDataPartition(volName string, partitionID uint64) (err error) {
 	var request = newAPIRequest(http.MethodGet, proto.AdminLoadDataPartition)
 	request.addParam("id", strconv.Itoa(int(partitionID)))
@@ -177,6 +243,20 @@ func (api *AdminAPI) AddDataReplica(dataPartitionID uint64, nodeAddr string) (er
 	return
 }
 
+func (api *AdminAPI) VerifyMetaPartition(metaPartitionID uint64) (result *proto.MetaPartitionVerifyResult, err error) {
+	var buf []byte
+	var request = newAPIRequest(http.MethodGet, proto.AdminVerifyMetaPartition)
+	request.addParam("id", strconv.FormatUint(metaPartitionID, 10))
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	result = &proto.MetaPartitionVerifyResult{}
+	if err = json.Unmarshal(buf, result); err != nil {
+		return
+	}
+	return
+}
+
 func (api *AdminAPI) DeleteMetaReplica(metaPartitionID uint64, nodeAddr string) (err error) {
 	var request = newAPIRequest(http.MethodGet, proto.AdminDeleteMetaReplica)
 	request.addParam("id", strconv.FormatUint(metaPartitionID, 10))
@@ -198,9 +278,50 @@ func (api *AdminAPI) AddMetaReplica(metaPartitionID uint64, nodeAddr string) (er
 }
 
 func (api *AdminAPI) DeleteVolume(volName, authKey string) (err error) {
+	return api.DeleteVolumeWithForce(volName, authKey, false)
+}
+
+// DeleteVolumeWithForce deletes the volume, which is purged at once with force
+// instead of being kept restorable until the deletion delay expires.
+func (api *AdminAPI) DeleteVolumeWithForce(volName, authKey string, force bool) (err error) {
 	var request = newAPIRequest(http.MethodGet, proto.AdminDeleteVol)
 	request.addParam("name", volName)
 	request.addParam("authKey", authKey)
+	request.addParam("force", strconv.FormatBool(force))
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+func (api *AdminAPI) RestoreVolume(volName, authKey string) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminRestoreVol)
+	request.addParam("name", volName)
+	request.addParam("authKey", authKey)
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+func (api *AdminAPI) SetQuota(volName string, inode, maxFiles, maxBytes uint64, authKey string) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.QuotaSet)
+	request.addParam("name", volName)
+	request.addParam("authKey", authKey)
+	request.addParam("inode", strconv.FormatUint(inode, 10))
+	request.addParam("maxFiles", strconv.FormatUint(maxFiles, 10))
+	request.addParam("maxBytes", strconv.FormatUint(maxBytes, 10))
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+func (api *AdminAPI) DeleteQuota(volName string, inode uint64, authKey string) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.QuotaDelete)
+	request.addParam("name", volName)
+	request.addParam("authKey", authKey)
+	request.addParam("inode", strconv.FormatUint(inode, 10))
 	if _, err = api.mc.serveRequest(request); err != nil {
 		return
 	}
@@ -223,6 +344,48 @@ func (api *AdminAPI) UpdateVolume(volName string, capacity uint64, replicas int,
 	return
 }
 
+// SetVolumeTiering sets the media types of the hot and cold tiers of the volume, the tiering is disabled if coldDays is 0.
+func (api *AdminAPI) SetVolumeTiering(volName, authKey, hotMedia, coldMedia string, coldDays uint32) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminUpdateVol)
+	request.addParam("name", volName)
+	request.addParam("authKey", authKey)
+	request.addParam("hotMedia", hotMedia)
+	request.addParam("coldMedia", coldMedia)
+	request.addParam("coldDays", strconv.FormatUint(uint64(coldDays), 10))
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+// SetVolumeSmallFileSize sets the max size of the files packed into the tiny extents, the packing is disabled if size is 0.
+func (api *AdminAPI) SetVolumeSmallFileSize(volName, authKey string, size uint32) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminUpdateVol)
+	request.addParam("name", volName)
+	request.addParam("authKey", authKey)
+	request.addParam("smallFileSize", strconv.FormatUint(uint64(size), 10))
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+// SetVolumePermissionPriority sets which one of the mode bits and the S3 ACLs takes effect on the volume,
+// the empty priority makes them independent of each other.
+func (api *AdminAPI) SetVolumePermissionPriority(volName, authKey, priority string) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminUpdateVol)
+	request.addParam("name", volName)
+	request.addParam("authKey", authKey)
+	if priority == proto.PermissionPriorityNone {
+		priority = "none"
+	}
+	request.addParam("permissionPriority", priority)
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
 func (api *AdminAPI) VolShrink(volName string, capacity uint64, authKey string) (err error) {
 	var request = newAPIRequest(http.MethodGet, proto.AdminVolShrink)
 	request.addParam("name", volName)
@@ -245,16 +408,128 @@ func (api *AdminAPI) VolExpand(volName string, capacity uint64, authKey string)
 	return
 }
 
+func (api *AdminAPI) GetVolCapacityProgress(volName string) (progress *proto.VolCapacityProgress, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminVolCapacityProgress)
+	request.addParam("name", volName)
+	var buf []byte
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	progress = &proto.VolCapacityProgress{}
+	if err = json.Unmarshal(buf, progress); err != nil {
+		return
+	}
+	return
+}
+
+// SetVolumeReplicaNum changes the replica number of the data partitions of the volume, the existing data partitions
+// are converted in background with at most maxMigrations data partitions being recovered at a time, 0 for the default.
+func (api *AdminAPI) SetVolumeReplicaNum(volName, authKey string, replicaNum, maxMigrations int) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminVolReplicaNum)
+	request.addParam("name", volName)
+	request.addParam("authKey", authKey)
+	request.addParam("replicaNum", strconv.Itoa(replicaNum))
+	if maxMigrations > 0 {
+		request.addParam("maxMigrations", strconv.Itoa(maxMigrations))
+	}
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+func (api *AdminAPI) GetVolReplicaNumProgress(volName string) (progress *proto.VolReplicaNumProgress, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminVolReplicaNumProgress)
+	request.addParam("name", volName)
+	var buf []byte
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	progress = &proto.VolReplicaNumProgress{}
+	if err = json.Unmarshal(buf, progress); err != nil {
+		return
+	}
+	return
+}
+
+func (api *AdminAPI) GetVolUsage(volName string) (usage *proto.VolUsage, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminVolUsage)
+	request.addParam("name", volName)
+	var buf []byte
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	usage = &proto.VolUsage{}
+	if err = json.Unmarshal(buf, usage); err != nil {
+		return
+	}
+	return
+}
+
 func (api *AdminAPI) CreateVolume(volName, owner string, mpCount int,
-	dpSize uint64, capacity uint64, replicas int, followerRead bool, zoneName string) (err error) {
+	dpSize uint64, capacity uint64, replicas int, followerRead bool, zoneName string, encrypt, flat, cache bool) (err error) {
 	var request = newAPIRequest(http.MethodGet, proto.AdminCreateVol)
 	request.addParam("name", volName)
 	request.addParam("owner", owner)
 	request.addParam("mpCount", strconv.Itoa(mpCount))
 	request.addParam("size", strconv.FormatUint(dpSize, 10))
 	request.addParam("capacity", strconv.FormatUint(capacity, 10))
+	request.addParam("replicaNum", strconv.Itoa(replicas))
 	request.addParam("followerRead", strconv.FormatBool(followerRead))
 	request.addParam("zoneName", zoneName)
+	request.addParam("encrypt", strconv.FormatBool(encrypt))
+	request.addParam("flat", strconv.FormatBool(flat))
+	request.addParam("cache", strconv.FormatBool(cache))
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+// GetVolDataKey returns the data key of the encrypted volume, only the data node of nodeAddr is allowed.
+func (api *AdminAPI) GetVolDataKey(volName, nodeAddr string) (dataKey []byte, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminGetVolDataKey)
+	request.addParam("name", volName)
+	request.addParam("addr", nodeAddr)
+	var buf []byte
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	key := &proto.VolDataKey{}
+	if err = json.Unmarshal(buf, key); err != nil {
+		return
+	}
+	return key.DataKey, nil
+}
+
+// RotateVolumeKey re-wraps the data key of the encrypted volume with the active master key.
+func (api *AdminAPI) RotateVolumeKey(volName, authKey string) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminRotateVolKey)
+	request.addParam("name", volName)
+	request.addParam("authKey", authKey)
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+// SetVolumeReadOnly freezes the volume read-only or thaws it.
+func (api *AdminAPI) SetVolumeReadOnly(volName, authKey string, readOnly bool) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminVolReadOnly)
+	request.addParam("name", volName)
+	request.addParam("authKey", authKey)
+	request.addParam("enable", strconv.FormatBool(readOnly))
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+func (api *AdminAPI) RenameVolume(volName, newName, authKey string) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminRenameVol)
+	request.addParam("name", volName)
+	request.addParam("newName", newName)
+	request.addParam("authKey", authKey)
 	if _, err = api.mc.serveRequest(request); err != nil {
 		return
 	}
@@ -332,6 +607,28 @@ func (api *AdminAPI) IsFreezeCluster(isFreeze bool) (err error) {
 	return
 }
 
+func (api *AdminAPI) SetUpgrade(enable bool) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminClusterUpgrade)
+	request.addParam("enable", strconv.FormatBool(enable))
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+func (api *AdminAPI) GetUpgradeStatus() (status *proto.UpgradeStatus, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminUpgradeStatus)
+	var buf []byte
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	status = &proto.UpgradeStatus{}
+	if err = json.Unmarshal(buf, status); err != nil {
+		return
+	}
+	return
+}
+
 func (api *AdminAPI) SetMetaNodeThreshold(threshold float64) (err error) {
 	var request = newAPIRequest(http.MethodGet, proto.AdminSetMetaNodeThreshold)
 	request.addParam("threshold", strconv.FormatFloat(threshold, 'f', 6, 64))
@@ -354,6 +651,30 @@ func (api *AdminAPI) SetDeleteParas(batchCount, markDeleteRate, deleteWorkerSlee
 	return
 }
 
+// SetClusterDeleteRate sets the extents deleted by the whole cluster per second, which are shared by the nodes.
+func (api *AdminAPI) SetClusterDeleteRate(clusterDeleteRate string) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminSetNodeInfo)
+	request.addParam("clusterDeleteRate", clusterDeleteRate)
+
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+// SetWriteLimits sets the write throttles of the data nodes in MB/s, the empty ones are left unchanged.
+func (api *AdminAPI) SetWriteLimits(diskWriteRate, diskRepairRate, dpWriteRate string) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminSetNodeInfo)
+	request.addParam("diskWriteRate", diskWriteRate)
+	request.addParam("diskRepairRate", diskRepairRate)
+	request.addParam("dpWriteRate", dpWriteRate)
+
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
 func (api *AdminAPI) GetDeleteParas() (delParas map[string]string, err error) {
 	var request = newAPIRequest(http.MethodGet, proto.AdminGetNodeInfo)
 	if _, err = api.mc.serveRequest(request); err != nil {
@@ -369,3 +690,53 @@ func (api *AdminAPI) GetDeleteParas() (delParas map[string]string, err error) {
 	}
 	return
 }
+
+func (api *AdminAPI) ListClients(volName string) (sessions []*proto.ClientSessionView, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminListClients)
+	request.addParam("name", volName)
+	var buf []byte
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	sessions = make([]*proto.ClientSessionView, 0)
+	if err = json.Unmarshal(buf, &sessions); err != nil {
+		return
+	}
+	return
+}
+
+// GetAdminAudit queries the audit log of the admin APIs from the newest record, the empty filters and the zero times are ignored.
+func (api *AdminAPI) GetAdminAudit(apiPath, operator, volName string, start, end int64, limit int) (records []*proto.AdminAuditRecord, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminGetAudit)
+	request.addParam("api", apiPath)
+	request.addParam("operator", operator)
+	request.addParam("name", volName)
+	if start > 0 {
+		request.addParam("start", strconv.FormatInt(start, 10))
+	}
+	if end > 0 {
+		request.addParam("end", strconv.FormatInt(end, 10))
+	}
+	if limit > 0 {
+		request.addParam("limit", strconv.Itoa(limit))
+	}
+	var buf []byte
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	records = make([]*proto.AdminAuditRecord, 0)
+	if err = json.Unmarshal(buf, &records); err != nil {
+		return
+	}
+	return
+}
+
+func (api *AdminAPI) EvictClient(volName string, clientID uint64) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminEvictClient)
+	request.addParam("name", volName)
+	request.addParam("id", strconv.FormatUint(clientID, 10))
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
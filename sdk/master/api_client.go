@@ -19,6 +19,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/chubaofs/chubaofs/proto"
 )
@@ -113,6 +114,40 @@ func (api *ClientAPI) GetToken(volName, tokenKey string) (token *proto.Token, er
 	return
 }
 
+// GetVolAccessToken gets an access token of the volume with the auth key of the owner or a token of the volume.
+func (api *ClientAPI) GetVolAccessToken(volName, authKey, tokenKey string) (token *proto.VolAccessToken, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.ClientVolAccessToken)
+	request.addParam("name", volName)
+	if authKey != "" {
+		request.addParam("authKey", authKey)
+	}
+	if tokenKey != "" {
+		request.addParam("token", url.QueryEscape(tokenKey))
+	}
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	token = &proto.VolAccessToken{}
+	if err = json.Unmarshal(data, token); err != nil {
+		return
+	}
+	return
+}
+
+func (api *ClientAPI) ListQuotas(volName string) (quotas []*proto.QuotaInfo, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.QuotaList)
+	request.addParam("name", volName)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	if err = json.Unmarshal(data, &quotas); err != nil {
+		return
+	}
+	return
+}
+
 func (api *ClientAPI) GetMetaPartition(partitionID uint64) (partition *proto.MetaPartitionInfo, err error) {
 	var request = newAPIRequest(http.MethodGet, proto.ClientMetaPartition)
 	request.addParam("id", strconv.FormatUint(partitionID, 10))
@@ -140,6 +175,25 @@ func (api *ClientAPI) GetMetaPartitions(volName string) (views []*proto.MetaPart
 	return
 }
 
+func (api *ClientAPI) GetDataPartitionLocations(volName string, ids []uint64) (locations []*proto.DataPartitionLocation, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.ClientDataPartitionLocations)
+	request.addParam("name", volName)
+	strIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strIDs = append(strIDs, strconv.FormatUint(id, 10))
+	}
+	request.addParam("ids", strings.Join(strIDs, ","))
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	locations = make([]*proto.DataPartitionLocation, 0)
+	if err = json.Unmarshal(data, &locations); err != nil {
+		return
+	}
+	return
+}
+
 func (api *ClientAPI) GetDataPartitions(volName string) (view *proto.DataPartitionsView, err error) {
 	var request = newAPIRequest(http.MethodGet, proto.ClientDataPartitions)
 	request.addParam("name", volName)
@@ -153,3 +207,33 @@ func (api *ClientAPI) GetDataPartitions(volName string) (view *proto.DataPartiti
 	}
 	return
 }
+
+// RegisterClientSession registers the session of the client mounting the volume.
+func (api *ClientAPI) RegisterClientSession(volName string, clientID uint64, report *proto.ClientSessionReport) (session *proto.ClientSessionView, err error) {
+	return api.clientSession(proto.ClientSessionRegister, volName, clientID, report)
+}
+
+// ClientSessionHeartbeat keeps the session of the client alive.
+func (api *ClientAPI) ClientSessionHeartbeat(volName string, clientID uint64, report *proto.ClientSessionReport) (session *proto.ClientSessionView, err error) {
+	return api.clientSession(proto.ClientSessionHeartbeat, volName, clientID, report)
+}
+
+func (api *ClientAPI) clientSession(path, volName string, clientID uint64, report *proto.ClientSessionReport) (session *proto.ClientSessionView, err error) {
+	var request = newAPIRequest(http.MethodPost, path)
+	request.addParam("name", volName)
+	request.addParam("id", strconv.FormatUint(clientID, 10))
+	var body []byte
+	if body, err = json.Marshal(report); err != nil {
+		return
+	}
+	request.addBody(body)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	session = &proto.ClientSessionView{}
+	if err = json.Unmarshal(data, session); err != nil {
+		return
+	}
+	return
+}
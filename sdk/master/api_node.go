@@ -27,9 +27,17 @@ type NodeAPI struct {
 }
 
 func (api *NodeAPI) AddDataNode(serverAddr, zoneName string) (id uint64, err error) {
+	return api.AddDataNodeWithTopology(serverAddr, "", zoneName, "")
+}
+
+// AddDataNodeWithTopology registers the data node in the fault domains region/zone/rack,
+// the master places it in the default region or rack if the region or the rack is empty.
+func (api *NodeAPI) AddDataNodeWithTopology(serverAddr, region, zoneName, rack string) (id uint64, err error) {
 	var request = newAPIRequest(http.MethodGet, proto.AddDataNode)
 	request.addParam("addr", serverAddr)
+	request.addParam("region", region)
 	request.addParam("zoneName", zoneName)
+	request.addParam("rack", rack)
 	var data []byte
 	if data, err = api.mc.serveRequest(request); err != nil {
 		return
@@ -39,9 +47,17 @@ func (api *NodeAPI) AddDataNode(serverAddr, zoneName string) (id uint64, err err
 }
 
 func (api *NodeAPI) AddMetaNode(serverAddr, zoneName string) (id uint64, err error) {
+	return api.AddMetaNodeWithTopology(serverAddr, "", zoneName, "")
+}
+
+// AddMetaNodeWithTopology registers the meta node in the fault domains region/zone/rack,
+// the master places it in the default region or rack if the region or the rack is empty.
+func (api *NodeAPI) AddMetaNodeWithTopology(serverAddr, region, zoneName, rack string) (id uint64, err error) {
 	var request = newAPIRequest(http.MethodGet, proto.AddMetaNode)
 	request.addParam("addr", serverAddr)
+	request.addParam("region", region)
 	request.addParam("zoneName", zoneName)
+	request.addParam("rack", rack)
 	var data []byte
 	if data, err = api.mc.serveRequest(request); err != nil {
 		return
@@ -115,6 +131,54 @@ func (api *NodeAPI) DataNodeDecommission(nodeAddr string) (err error) {
 	return
 }
 
+// DataNodeDecommissionGracefully starts to drain the data node, the progress is got by GetDataNodeDecommissionProgress.
+func (api *NodeAPI) DataNodeDecommissionGracefully(nodeAddr string) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.DecommissionDataNode)
+	request.addParam("addr", nodeAddr)
+	request.addParam("graceful", "true")
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+func (api *NodeAPI) GetDataNodeDecommissionProgress(nodeAddr string) (progress *proto.DecommissionProgress, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.DecommissionDataNodeProgress)
+	request.addParam("addr", nodeAddr)
+	var buf []byte
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	progress = &proto.DecommissionProgress{}
+	if err = json.Unmarshal(buf, progress); err != nil {
+		return
+	}
+	return
+}
+
+func (api *NodeAPI) TransferDataNodeLeaders(nodeAddr string) (result *proto.TransferLeadersResult, err error) {
+	return api.transferLeaders(proto.TransferDataNodeLeaders, nodeAddr)
+}
+
+func (api *NodeAPI) TransferMetaNodeLeaders(nodeAddr string) (result *proto.TransferLeadersResult, err error) {
+	return api.transferLeaders(proto.TransferMetaNodeLeaders, nodeAddr)
+}
+
+func (api *NodeAPI) transferLeaders(path, nodeAddr string) (result *proto.TransferLeadersResult, err error) {
+	var request = newAPIRequest(http.MethodGet, path)
+	request.addParam("addr", nodeAddr)
+	request.addHeader("isTimeOut", "false")
+	var buf []byte
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	result = &proto.TransferLeadersResult{}
+	if err = json.Unmarshal(buf, result); err != nil {
+		return
+	}
+	return
+}
+
 func (api *NodeAPI) MetaNodeDecommission(nodeAddr string) (err error) {
 	var request = newAPIRequest(http.MethodGet, proto.DecommissionMetaNode)
 	request.addParam("addr", nodeAddr)
@@ -124,3 +188,28 @@ func (api *NodeAPI) MetaNodeDecommission(nodeAddr string) (err error) {
 	}
 	return
 }
+
+// MetaNodeDecommissionGracefully starts to drain the meta node, the progress is got by GetMetaNodeDecommissionProgress.
+func (api *NodeAPI) MetaNodeDecommissionGracefully(nodeAddr string) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.DecommissionMetaNode)
+	request.addParam("addr", nodeAddr)
+	request.addParam("graceful", "true")
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+func (api *NodeAPI) GetMetaNodeDecommissionProgress(nodeAddr string) (progress *proto.DecommissionProgress, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.DecommissionMetaNodeProgress)
+	request.addParam("addr", nodeAddr)
+	var buf []byte
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	progress = &proto.DecommissionProgress{}
+	if err = json.Unmarshal(buf, progress); err != nil {
+		return
+	}
+	return
+}
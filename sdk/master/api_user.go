@@ -3,6 +3,8 @@ package master
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/chubaofs/chubaofs/proto"
 )
@@ -70,6 +72,27 @@ func (api *UserAPI) GetAKInfo(accesskey string) (userInfo *proto.UserInfo, err e
 	return
 }
 
+// Authorize proves the possession of the secret key by a signature and returns
+// the permission of the user to the volume.
+func (api *UserAPI) Authorize(accesskey, secretkey, volume, subdir string) (result *proto.UserAuthorizeResult, err error) {
+	var timestamp = time.Now().Unix()
+	var request = newAPIRequest(http.MethodGet, proto.UserAuthorize)
+	request.addParam("ak", accesskey)
+	request.addParam("name", volume)
+	request.addParam("subdir", subdir)
+	request.addParam("ts", strconv.FormatInt(timestamp, 10))
+	request.addParam("sign", proto.SignUserAuthorization(secretkey, accesskey, volume, timestamp))
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	result = &proto.UserAuthorizeResult{}
+	if err = json.Unmarshal(data, result); err != nil {
+		return
+	}
+	return
+}
+
 func (api *UserAPI) GetUserInfo(userID string) (userInfo *proto.UserInfo, err error) {
 	var request = newAPIRequest(http.MethodGet, proto.UserGetInfo)
 	request.addParam("user", userID)
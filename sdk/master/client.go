@@ -21,12 +21,15 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"os/user"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util"
 	"github.com/chubaofs/chubaofs/util/log"
 )
 
@@ -36,6 +39,14 @@ const (
 
 var (
 	ErrNoValidMaster = errors.New("no valid master")
+
+	// operator is the local user reported to the master, which records it in the audit log of the admin APIs.
+	operator = func() string {
+		if u, err := user.Current(); err == nil {
+			return u.Username
+		}
+		return os.Getenv("USER")
+	}()
 )
 
 type MasterClient struct {
@@ -109,7 +120,7 @@ func (c *MasterClient) serveRequest(r *request) (repsData []byte, err error) {
 		}
 		var resp *http.Response
 		var schema string
-		if c.useSSL {
+		if c.useSSL || util.TLSEnabled() {
 			schema = "https"
 		} else {
 			schema = "http"
@@ -141,6 +152,10 @@ func (c *MasterClient) serveRequest(r *request) (repsData []byte, err error) {
 			repsData, err = c.serveRequest(r)
 			return
 		case http.StatusOK:
+			// the request may be proxied to the leader by a follower
+			if leader := resp.Header.Get(proto.MasterLeader); leader != "" {
+				host = leader
+			}
 			if leaderAddr != host {
 				c.setLeader(host)
 			}
@@ -189,6 +204,9 @@ func (c *MasterClient) prepareRequest() (addr string, nodes []string) {
 
 func (c *MasterClient) httpRequest(method, url string, param, header map[string]string, reqData []byte) (resp *http.Response, err error) {
 	client := http.DefaultClient
+	if util.TLSEnabled() {
+		client = &http.Client{Transport: util.TLSTransport()}
+	}
 	reader := bytes.NewReader(reqData)
 	if header["isTimeOut"] != "" {
 		var isTimeOut bool
@@ -209,6 +227,9 @@ func (c *MasterClient) httpRequest(method, url string, param, header map[string]
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Connection", "close")
+	if operator != "" {
+		req.Header.Set(proto.AdminOperator, operator)
+	}
 	for k, v := range header {
 		req.Header.Set(k, v)
 	}
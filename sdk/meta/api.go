@@ -34,6 +34,8 @@ import (
 
 const (
 	BatchIgetRespBuf = 1000
+	// ReadDirLimit is the max number of dentries read from a meta partition in a request.
+	ReadDirLimit uint64 = 1000
 )
 
 const (
@@ -72,6 +74,10 @@ func (mw *MetaWrapper) Statfs() (total, used uint64) {
 }
 
 func (mw *MetaWrapper) Create_ll(parentID uint64, name string, mode, uid, gid uint32, target []byte) (*proto.InodeInfo, error) {
+	return mw.createAs(nil, parentID, name, mode, uid, gid, target)
+}
+
+func (mw *MetaWrapper) createAs(cred *proto.Credential, parentID uint64, name string, mode, uid, gid uint32, target []byte) (*proto.InodeInfo, error) {
 	var (
 		status       int
 		err          error
@@ -122,7 +128,7 @@ func (mw *MetaWrapper) Create_ll(parentID uint64, name string, mode, uid, gid ui
 	return nil, syscall.ENOMEM
 
 create_dentry:
-	status, err = mw.dcreate(parentMP, parentID, name, info.Inode, mode)
+	status, err = mw.dcreate(parentMP, cred, parentID, name, info.Inode, mode)
 	if err != nil {
 		return nil, statusToErrno(status)
 	} else if status != statusOK {
@@ -135,20 +141,127 @@ create_dentry:
 	return info, nil
 }
 
+// InodeClone_ll creates an inode in the partition of the source file, which shares the extents of the file.
+func (mw *MetaWrapper) InodeClone_ll(srcIno uint64, uid, gid uint32) (*proto.InodeInfo, error) {
+	mp := mw.getPartitionByInode(srcIno)
+	if mp == nil {
+		log.LogErrorf("InodeClone_ll: No such partition, ino(%v)", srcIno)
+		return nil, syscall.ENOENT
+	}
+	if !mw.features.Supports(mp.LeaderAddr, proto.FeatureMetaCloneInode) {
+		return nil, syscall.ENOTSUP
+	}
+
+	status, info, err := mw.icloneInode(mp, srcIno, uid, gid)
+	if err != nil || status != statusOK {
+		return nil, statusToErrno(status)
+	}
+	mw.sharedInodes.Store(srcIno, struct{}{})
+	mw.sharedInodes.Store(info.Inode, struct{}{})
+	return info, nil
+}
+
+// Clone_ll creates the name in the parent as a clone of the source file.
+func (mw *MetaWrapper) Clone_ll(srcIno, parentID uint64, name string, uid, gid uint32) (*proto.InodeInfo, error) {
+	parentMP := mw.getPartitionByInode(parentID)
+	if parentMP == nil {
+		log.LogErrorf("Clone_ll: No parent partition, parentID(%v)", parentID)
+		return nil, syscall.ENOENT
+	}
+
+	info, err := mw.InodeClone_ll(srcIno, uid, gid)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := mw.dcreate(parentMP, nil, parentID, name, info.Inode, info.Mode)
+	if err != nil || status != statusOK {
+		if mp := mw.getPartitionByInode(info.Inode); mp != nil {
+			mw.iunlink(mp, info.Inode)
+			mw.ievict(mp, info.Inode)
+		}
+		return nil, statusToErrno(status)
+	}
+	return info, nil
+}
+
+// IsShared returns true if the inode shares the extents with a cloned file.
+func (mw *MetaWrapper) IsShared(ino uint64) bool {
+	_, ok := mw.sharedInodes.Load(ino)
+	return ok
+}
+
+// CheckShared is IsShared asking the leader of the meta partition if the inode is not known shared,
+// since the file may be cloned by another client. The inode is taken as shared if it fails to ask,
+// so that the extents are written copy-on-write.
+func (mw *MetaWrapper) CheckShared(ino uint64) bool {
+	if mw.IsShared(ino) {
+		return true
+	}
+	mp := mw.getPartitionByInode(ino)
+	if mp == nil {
+		return true
+	}
+	status, shared, err := mw.ishared(mp, ino)
+	if err != nil || status != statusOK {
+		return true
+	}
+	if shared {
+		mw.sharedInodes.Store(ino, struct{}{})
+	}
+	return shared
+}
+
 func (mw *MetaWrapper) Lookup_ll(parentID uint64, name string) (inode uint64, mode uint32, err error) {
+	return mw.lookupAs(nil, parentID, name)
+}
+
+func (mw *MetaWrapper) lookupAs(cred *proto.Credential, parentID uint64, name string) (inode uint64, mode uint32, err error) {
 	parentMP := mw.getPartitionByInode(parentID)
 	if parentMP == nil {
 		log.LogErrorf("Lookup_ll: No parent partition, parentID(%v) name(%v)", parentID, name)
 		return 0, 0, syscall.ENOENT
 	}
 
-	status, inode, mode, err := mw.lookup(parentMP, parentID, name)
+	status, inode, mode, err := mw.lookup(parentMP, cred, parentID, name)
 	if err != nil || status != statusOK {
 		return 0, 0, statusToErrno(status)
 	}
 	return inode, mode, nil
 }
 
+// LookupCaseInsensitive_ll is Lookup_ll ignoring the case of the name if there is no exact match,
+// the name of the matched dentry is returned as realName.
+func (mw *MetaWrapper) LookupCaseInsensitive_ll(parentID uint64, name string) (inode uint64, mode uint32, realName string, err error) {
+	parentMP := mw.getPartitionByInode(parentID)
+	if parentMP == nil {
+		log.LogErrorf("LookupCaseInsensitive_ll: No parent partition, parentID(%v) name(%v)", parentID, name)
+		return 0, 0, "", syscall.ENOENT
+	}
+
+	if !mw.features.Supports(parentMP.LeaderAddr, proto.FeatureMetaCaseInsensitiveLookup) {
+		if inode, mode, err = mw.Lookup_ll(parentID, name); err != syscall.ENOENT {
+			return inode, mode, name, err
+		}
+		children, err := mw.ReadDir_ll(parentID)
+		if err != nil {
+			return 0, 0, "", err
+		}
+		for _, child := range children {
+			if strings.EqualFold(child.Name, name) {
+				return child.Inode, child.Type, child.Name, nil
+			}
+		}
+		return 0, 0, "", syscall.ENOENT
+	}
+
+	status, inode, mode, realName, err := mw.lookupCaseInsensitive(parentMP, parentID, name)
+	if err != nil || status != statusOK {
+		return 0, 0, "", statusToErrno(status)
+	}
+	return inode, mode, realName, nil
+}
+
 func (mw *MetaWrapper) InodeGet_ll(inode uint64) (*proto.InodeInfo, error) {
 	mp := mw.getPartitionByInode(inode)
 	if mp == nil {
@@ -156,7 +269,7 @@ func (mw *MetaWrapper) InodeGet_ll(inode uint64) (*proto.InodeInfo, error) {
 		return nil, syscall.ENOENT
 	}
 
-	status, info, err := mw.iget(mp, inode)
+	status, info, err := mw.iget(mp, nil, inode, 0)
 	if err != nil || status != statusOK {
 		if status == statusNoent {
 			// For NOENT error, pull the latest mp and give it another try,
@@ -178,7 +291,7 @@ func (mw *MetaWrapper) doInodeGet(inode uint64) (*proto.InodeInfo, error) {
 		return nil, syscall.ENOENT
 	}
 
-	status, info, err := mw.iget(mp, inode)
+	status, info, err := mw.iget(mp, nil, inode, 0)
 	if err != nil || status != statusOK {
 		return nil, statusToErrno(status)
 	}
@@ -302,6 +415,10 @@ func (mw *MetaWrapper) BatchGetXAttr(inodes []uint64, keys []string) ([]*proto.X
  * and the caller should make sure InodeInfo is valid before using it.
  */
 func (mw *MetaWrapper) Delete_ll(parentID uint64, name string, isDir bool) (*proto.InodeInfo, error) {
+	return mw.deleteAs(nil, parentID, name, isDir)
+}
+
+func (mw *MetaWrapper) deleteAs(cred *proto.Credential, parentID uint64, name string, isDir bool) (*proto.InodeInfo, error) {
 	var (
 		status int
 		inode  uint64
@@ -318,7 +435,7 @@ func (mw *MetaWrapper) Delete_ll(parentID uint64, name string, isDir bool) (*pro
 	}
 
 	if isDir {
-		status, inode, mode, err = mw.lookup(parentMP, parentID, name)
+		status, inode, mode, err = mw.lookup(parentMP, cred, parentID, name)
 		if err != nil || status != statusOK {
 			return nil, statusToErrno(status)
 		}
@@ -330,7 +447,7 @@ func (mw *MetaWrapper) Delete_ll(parentID uint64, name string, isDir bool) (*pro
 			log.LogErrorf("Delete_ll: No inode partition, parentID(%v) name(%v) ino(%v)", parentID, name, inode)
 			return nil, syscall.EAGAIN
 		}
-		status, info, err = mw.iget(mp, inode)
+		status, info, err = mw.iget(mp, cred, inode, 0)
 		if err != nil || status != statusOK {
 			return nil, statusToErrno(status)
 		}
@@ -339,7 +456,7 @@ func (mw *MetaWrapper) Delete_ll(parentID uint64, name string, isDir bool) (*pro
 		}
 	}
 
-	status, inode, err = mw.ddelete(parentMP, parentID, name)
+	status, inode, err = mw.ddelete(parentMP, cred, parentID, name)
 	if err != nil || status != statusOK {
 		if status == statusNoent {
 			return nil, nil
@@ -362,6 +479,10 @@ func (mw *MetaWrapper) Delete_ll(parentID uint64, name string, isDir bool) (*pro
 }
 
 func (mw *MetaWrapper) Rename_ll(srcParentID uint64, srcName string, dstParentID uint64, dstName string) (err error) {
+	return mw.renameAs(nil, srcParentID, srcName, dstParentID, dstName)
+}
+
+func (mw *MetaWrapper) renameAs(cred *proto.Credential, srcParentID uint64, srcName string, dstParentID uint64, dstName string) (err error) {
 	var oldInode uint64
 
 	srcParentMP := mw.getPartitionByInode(srcParentID)
@@ -374,7 +495,7 @@ func (mw *MetaWrapper) Rename_ll(srcParentID uint64, srcName string, dstParentID
 	}
 
 	// look up for the src ino
-	status, inode, mode, err := mw.lookup(srcParentMP, srcParentID, srcName)
+	status, inode, mode, err := mw.lookup(srcParentMP, cred, srcParentID, srcName)
 	if err != nil || status != statusOK {
 		return statusToErrno(status)
 	}
@@ -383,20 +504,25 @@ func (mw *MetaWrapper) Rename_ll(srcParentID uint64, srcName string, dstParentID
 		return syscall.ENOENT
 	}
 
-	status, _, err = mw.ilink(srcMP, inode)
+	// the meta nodes not supporting the transactions rename across partitions like in one partition
+	if srcParentMP.PartitionID != dstParentMP.PartitionID && mw.features.Supports(srcMP.LeaderAddr, proto.FeatureMetaTransaction) {
+		return mw.renameAcrossPartitions(cred, srcParentMP, srcParentID, srcName, dstParentMP, dstParentID, dstName, srcMP, inode, mode)
+	}
+
+	status, _, err = mw.ilink(srcMP, inode, false)
 	if err != nil || status != statusOK {
 		return statusToErrno(status)
 	}
 
 	// create dentry in dst parent
-	status, err = mw.dcreate(dstParentMP, dstParentID, dstName, inode, mode)
+	status, err = mw.dcreate(dstParentMP, cred, dstParentID, dstName, inode, mode)
 	if err != nil {
 		return syscall.EAGAIN
 	}
 
 	// Note that only regular files are allowed to be overwritten.
 	if status == statusExist && proto.IsRegular(mode) {
-		status, oldInode, err = mw.dupdate(dstParentMP, dstParentID, dstName, inode)
+		status, oldInode, err = mw.dupdate(dstParentMP, cred, dstParentID, dstName, inode)
 		if err != nil {
 			return syscall.EAGAIN
 		}
@@ -408,7 +534,7 @@ func (mw *MetaWrapper) Rename_ll(srcParentID uint64, srcName string, dstParentID
 	}
 
 	// delete dentry from src parent
-	status, _, err = mw.ddelete(srcParentMP, srcParentID, srcName)
+	status, _, err = mw.ddelete(srcParentMP, cred, srcParentID, srcName)
 	if err != nil {
 		return statusToErrno(status)
 	} else if status != statusOK {
@@ -417,9 +543,9 @@ func (mw *MetaWrapper) Rename_ll(srcParentID uint64, srcName string, dstParentID
 			e   error
 		)
 		if oldInode == 0 {
-			sts, _, e = mw.ddelete(dstParentMP, dstParentID, dstName)
+			sts, _, e = mw.ddelete(dstParentMP, cred, dstParentID, dstName)
 		} else {
-			sts, _, e = mw.dupdate(dstParentMP, dstParentID, dstName, oldInode)
+			sts, _, e = mw.dupdate(dstParentMP, cred, dstParentID, dstName, oldInode)
 		}
 		if e == nil && sts == statusOK {
 			mw.iunlink(srcMP, inode)
@@ -441,19 +567,215 @@ func (mw *MetaWrapper) Rename_ll(srcParentID uint64, srcName string, dstParentID
 	return nil
 }
 
+// renameAcrossPartitions renames a dentry to a parent which lives in another meta partition.
+// The two dentry operations can not be done atomically, so the rename is a transaction coordinated
+// on the meta partition holding the renamed inode, see TxTypeRename of the meta node. The inode is
+// linked once more for the destination dentry like a rename in one partition, and the rename is
+// committed once the destination dentry points to the inode. If the client fails before the
+// transaction is finished, the meta partition rolls back or completes the rename. The inode is only
+// unlinked once the transaction is finished, since unlinking it twice is harmful while an extra link
+// is only a leak.
+func (mw *MetaWrapper) renameAcrossPartitions(cred *proto.Credential, srcParentMP *MetaPartition, srcParentID uint64, srcName string,
+	dstParentMP *MetaPartition, dstParentID uint64, dstName string, srcMP *MetaPartition, inode uint64, mode uint32) (err error) {
+
+	var intent = &proto.RenameIntent{
+		Inode:       inode,
+		SrcParentID: srcParentID,
+		SrcName:     srcName,
+		DstParentID: dstParentID,
+		DstName:     dstName,
+	}
+	// The inode to be overwritten is recorded ahead, so that the destination dentry can be reverted
+	// if the client fails before the commit. Note that only regular files are allowed to be overwritten.
+	if proto.IsRegular(mode) {
+		status, oldInode, _, err := mw.lookup(dstParentMP, cred, dstParentID, dstName)
+		if err != nil || (status != statusOK && status != statusNoent) {
+			return statusToErrno(status)
+		}
+		intent.OldInode = oldInode
+	}
+	payload, err := intent.Marshal()
+	if err != nil {
+		return syscall.EINVAL
+	}
+	status, txID, err := mw.txBegin(srcMP, proto.TxTypeRename, payload)
+	if err != nil || status != statusOK {
+		return statusToErrno(status)
+	}
+
+	status, _, err = mw.ilink(srcMP, inode, false)
+	if err != nil || status != statusOK {
+		mw.txFinish(srcMP, txID)
+		return statusToErrno(status)
+	}
+	status, err = mw.txUpdate(srcMP, txID, proto.TxStatePrepared, nil)
+	if err != nil || status != statusOK {
+		mw.abortRename(srcMP, txID, inode)
+		return statusToErrno(status)
+	}
+
+	// point the destination dentry to the inode, the meta partition reverts it if the client fails
+	var replaced uint64
+	status, err = mw.dcreate(dstParentMP, cred, dstParentID, dstName, inode, mode)
+	if err != nil {
+		return syscall.EAGAIN
+	}
+	if status == statusExist && proto.IsRegular(mode) {
+		status, replaced, err = mw.dupdate(dstParentMP, cred, dstParentID, dstName, inode)
+		if err != nil {
+			return syscall.EAGAIN
+		}
+	}
+	if status != statusOK {
+		mw.abortRename(srcMP, txID, inode)
+		return statusToErrno(status)
+	}
+
+	// commit with the inode actually overwritten, which is released by the meta partition
+	// if the client fails from here
+	intent.OldInode = replaced
+	if payload, err = intent.Marshal(); err != nil {
+		return syscall.EINVAL
+	}
+	status, err = mw.txUpdate(srcMP, txID, proto.TxStateCommitted, payload)
+	if err != nil || status != statusOK {
+		if sts, e := mw.txUpdate(srcMP, txID, proto.TxStateRolledBack, payload); e == nil && sts == statusOK {
+			if replaced == 0 {
+				sts, _, e = mw.ddelete(dstParentMP, cred, dstParentID, dstName)
+			} else {
+				sts, _, e = mw.dupdate(dstParentMP, cred, dstParentID, dstName, replaced)
+			}
+			if e == nil && sts == statusOK {
+				mw.abortRename(srcMP, txID, inode)
+			}
+		}
+		return statusToErrno(status)
+	}
+
+	// delete dentry from src parent, the meta partition deletes it if the client fails
+	status, _, err = mw.ddelete(srcParentMP, cred, srcParentID, srcName)
+	if err != nil {
+		return statusToErrno(status)
+	}
+	// The source dentry removed by others has taken its link of the inode with it.
+	var unlink = status == statusOK
+	if status, err = mw.txFinish(srcMP, txID); err != nil || status != statusOK {
+		return nil
+	}
+	if unlink {
+		mw.iunlink(srcMP, inode)
+	}
+	if replaced != 0 {
+		inodeMP := mw.getPartitionByInode(replaced)
+		if inodeMP != nil {
+			mw.iunlink(inodeMP, replaced)
+			// evict replaced inode to avoid it becomes orphan inode
+			mw.ievict(inodeMP, replaced)
+		}
+	}
+	return nil
+}
+
+// abortRename finishes the transaction of the rename not committed, and unlinks the inode
+// for the link taken for the destination dentry.
+func (mw *MetaWrapper) abortRename(mp *MetaPartition, txID string, inode uint64) {
+	if status, err := mw.txFinish(mp, txID); err == nil && status == statusOK {
+		mw.iunlink(mp, inode)
+	}
+}
+
+// ReadDir_ll returns all the dentries of the directory, which are read in batches of ReadDirLimit.
 func (mw *MetaWrapper) ReadDir_ll(parentID uint64) ([]proto.Dentry, error) {
+	return mw.readDirAs(nil, parentID)
+}
+
+func (mw *MetaWrapper) readDirAs(cred *proto.Credential, parentID uint64) ([]proto.Dentry, error) {
+	var children []proto.Dentry
+	var marker string
+	for {
+		batch, err := mw.readDirLimitAs(cred, parentID, marker, ReadDirLimit)
+		if err != nil {
+			return nil, err
+		}
+		// the meta nodes not supporting the pagination reply all the dentries at once
+		if marker != "" && len(batch) > 0 && batch[0].Name <= marker {
+			break
+		}
+		children = append(children, batch...)
+		if uint64(len(batch)) < ReadDirLimit {
+			break
+		}
+		marker = batch[len(batch)-1].Name
+	}
+	return children, nil
+}
+
+// ReadDirLimit_ll returns at most limit dentries of the directory in the order of the names, starting after the marker.
+func (mw *MetaWrapper) ReadDirLimit_ll(parentID uint64, marker string, limit uint64) ([]proto.Dentry, error) {
+	return mw.readDirLimitAs(nil, parentID, marker, limit)
+}
+
+func (mw *MetaWrapper) readDirLimitAs(cred *proto.Credential, parentID uint64, marker string, limit uint64) ([]proto.Dentry, error) {
+	parentMP := mw.getPartitionByInode(parentID)
+	if parentMP == nil {
+		return nil, syscall.ENOENT
+	}
+
+	status, children, err := mw.readdir(parentMP, cred, parentID, "", marker, limit)
+	if err != nil || status != statusOK {
+		return nil, statusToErrno(status)
+	}
+	return children, nil
+}
+
+// ReadDirPrefixLimit_ll is ReadDirLimit_ll reading the dentries whose names have the prefix only.
+// The meta nodes not supporting the prefix reply the dentries regardless of it, so the caller
+// has to check the names as well.
+func (mw *MetaWrapper) ReadDirPrefixLimit_ll(parentID uint64, prefix, marker string, limit uint64) ([]proto.Dentry, error) {
 	parentMP := mw.getPartitionByInode(parentID)
 	if parentMP == nil {
 		return nil, syscall.ENOENT
 	}
 
-	status, children, err := mw.readdir(parentMP, parentID)
+	status, children, err := mw.readdir(parentMP, nil, parentID, prefix, marker, limit)
 	if err != nil || status != statusOK {
 		return nil, statusToErrno(status)
 	}
 	return children, nil
 }
 
+// ReadDirPlusLimit_ll is ReadDirLimit_ll with the inodes of the dentries, which are got in the same request.
+// The inodes failed to be got by the meta partition are left out. The inodes are got in batches instead
+// if the leader of the meta partition has not negotiated the readdirplus.
+func (mw *MetaWrapper) ReadDirPlusLimit_ll(parentID uint64, marker string, limit uint64) ([]proto.Dentry, []*proto.InodeInfo, error) {
+	return mw.readDirPlusLimitAs(nil, parentID, marker, limit)
+}
+
+func (mw *MetaWrapper) readDirPlusLimitAs(cred *proto.Credential, parentID uint64, marker string, limit uint64) ([]proto.Dentry, []*proto.InodeInfo, error) {
+	parentMP := mw.getPartitionByInode(parentID)
+	if parentMP == nil {
+		return nil, nil, syscall.ENOENT
+	}
+
+	if !mw.features.Supports(parentMP.LeaderAddr, proto.FeatureMetaReadDirPlus) {
+		children, err := mw.readDirLimitAs(cred, parentID, marker, limit)
+		if err != nil {
+			return nil, nil, err
+		}
+		inodes := make([]uint64, 0, len(children))
+		for _, child := range children {
+			inodes = append(inodes, child.Inode)
+		}
+		return children, mw.BatchInodeGet(inodes), nil
+	}
+
+	status, children, infos, err := mw.readdirplus(parentMP, cred, parentID, marker, limit)
+	if err != nil || status != statusOK {
+		return nil, nil, statusToErrno(status)
+	}
+	return children, infos, nil
+}
+
 func (mw *MetaWrapper) DentryCreate_ll(parentID uint64, name string, inode uint64, mode uint32) error {
 	parentMP := mw.getPartitionByInode(parentID)
 	if parentMP == nil {
@@ -461,7 +783,7 @@ func (mw *MetaWrapper) DentryCreate_ll(parentID uint64, name string, inode uint6
 	}
 	var err error
 	var status int
-	if status, err = mw.dcreate(parentMP, parentID, name, inode, mode); err != nil || status != statusOK {
+	if status, err = mw.dcreate(parentMP, nil, parentID, name, inode, mode); err != nil || status != statusOK {
 		return statusToErrno(status)
 	}
 	return nil
@@ -474,7 +796,7 @@ func (mw *MetaWrapper) DentryUpdate_ll(parentID uint64, name string, inode uint6
 		return
 	}
 	var status int
-	status, oldInode, err = mw.dupdate(parentMP, parentID, name, inode)
+	status, oldInode, err = mw.dupdate(parentMP, nil, parentID, name, inode)
 	if err != nil || status != statusOK {
 		err = statusToErrno(status)
 		return
@@ -544,7 +866,29 @@ func (mw *MetaWrapper) Truncate(inode, size uint64) error {
 
 }
 
+// PunchHole removes the range of the file from its extents and releases the space, the size of the file is kept.
+func (mw *MetaWrapper) PunchHole(inode, offset, size uint64) error {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("PunchHole: No inode partition, ino(%v)", inode)
+		return syscall.ENOENT
+	}
+	if !mw.features.Supports(mp.LeaderAddr, proto.FeatureMetaPunchHole) {
+		return syscall.ENOTSUP
+	}
+
+	status, err := mw.punchHole(mp, inode, offset, size)
+	if err != nil || status != statusOK {
+		return statusToErrno(status)
+	}
+	return nil
+}
+
 func (mw *MetaWrapper) Link(parentID uint64, name string, ino uint64) (*proto.InodeInfo, error) {
+	return mw.linkAs(nil, parentID, name, ino)
+}
+
+func (mw *MetaWrapper) linkAs(cred *proto.Credential, parentID uint64, name string, ino uint64) (*proto.InodeInfo, error) {
 	parentMP := mw.getPartitionByInode(parentID)
 	if parentMP == nil {
 		log.LogErrorf("Link: No parent partition, parentID(%v)", parentID)
@@ -558,13 +902,20 @@ func (mw *MetaWrapper) Link(parentID uint64, name string, ino uint64) (*proto.In
 	}
 
 	// increase inode nlink
-	status, info, err := mw.ilink(mp, ino)
+	status, info, err := mw.ilink(mp, ino, true)
 	if err != nil || status != statusOK {
 		return nil, statusToErrno(status)
 	}
+	// the directories are linked by the renames only, refuse the hard links to them
+	// in case the meta node does not check the hard links
+	if proto.IsDir(info.Mode) {
+		log.LogErrorf("Link: is directory, parentID(%v) name(%v) ino(%v)", parentID, name, ino)
+		mw.iunlink(mp, ino)
+		return nil, syscall.EPERM
+	}
 
 	// create new dentry and refer to the inode
-	status, err = mw.dcreate(parentMP, parentID, name, ino, info.Mode)
+	status, err = mw.dcreate(parentMP, cred, parentID, name, ino, info.Mode)
 	if err != nil {
 		return nil, statusToErrno(status)
 	} else if status != statusOK {
@@ -592,13 +943,17 @@ func (mw *MetaWrapper) Evict(inode uint64) error {
 }
 
 func (mw *MetaWrapper) Setattr(inode uint64, valid, mode, uid, gid uint32, atime, mtime int64) error {
+	return mw.setattrAs(nil, inode, valid, mode, uid, gid, atime, mtime)
+}
+
+func (mw *MetaWrapper) setattrAs(cred *proto.Credential, inode uint64, valid, mode, uid, gid uint32, atime, mtime int64) error {
 	mp := mw.getPartitionByInode(inode)
 	if mp == nil {
 		log.LogErrorf("Setattr: No such partition, ino(%v)", inode)
 		return syscall.EINVAL
 	}
 
-	status, err := mw.setattr(mp, inode, valid, mode, uid, gid, atime, mtime)
+	status, err := mw.setattr(mp, cred, inode, valid, mode, uid, gid, atime, mtime)
 	if err != nil || status != statusOK {
 		log.LogErrorf("Setattr: ino(%v) err(%v) status(%v)", inode, err, status)
 		return statusToErrno(status)
@@ -637,7 +992,7 @@ func (mw *MetaWrapper) InodeLink_ll(inode uint64) (*proto.InodeInfo, error) {
 		log.LogErrorf("InodeLink_ll: No such partition, ino(%v)", inode)
 		return nil, syscall.EINVAL
 	}
-	status, info, err := mw.ilink(mp, inode)
+	status, info, err := mw.ilink(mp, inode, false)
 	if err != nil || status != statusOK {
 		log.LogErrorf("InodeLink_ll: ino(%v) err(%v) status(%v)", inode, err, status)
 		return nil, statusToErrno(status)
@@ -830,6 +1185,12 @@ func (mw *MetaWrapper) ListMultipart_ll(prefix, delimiter, keyMarker string, mul
 }
 
 func (mw *MetaWrapper) XAttrSet_ll(inode uint64, name, value []byte) error {
+	return mw.XAttrSetFlag_ll(inode, name, value, 0)
+}
+
+// XAttrSetFlag_ll sets the xattr with the flag of proto.XAttrCreate or proto.XAttrReplace,
+// it returns EEXIST if the xattr exists with XAttrCreate, or ENOENT if not with XAttrReplace.
+func (mw *MetaWrapper) XAttrSetFlag_ll(inode uint64, name, value []byte, flag int) error {
 	var err error
 	mp := mw.getPartitionByInode(inode)
 	if mp == nil {
@@ -837,7 +1198,7 @@ func (mw *MetaWrapper) XAttrSet_ll(inode uint64, name, value []byte) error {
 		return syscall.ENOENT
 	}
 	var status int
-	status, err = mw.setXAttr(mp, inode, name, value)
+	status, err = mw.setXAttr(mp, inode, name, value, flag)
 	if err != nil || status != statusOK {
 		return statusToErrno(status)
 	}
@@ -888,6 +1249,47 @@ func (mw *MetaWrapper) XAttrDel_ll(inode uint64, name string) error {
 	return nil
 }
 
+// SetLock_ll is a low-level meta api that acquires or releases an advisory lock.
+// It returns EAGAIN if the lock conflicts with one held by another owner, or the new leader
+// of the meta partition has not got the locks reclaimed yet.
+func (mw *MetaWrapper) SetLock_ll(inode uint64, lock *proto.FileLock) error {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("SetLock_ll: no such partition, inode(%v)", inode)
+		return syscall.ENOENT
+	}
+	// the locks are not reclaimed in the middle, or an unlock may be undone by the reclaim
+	mw.heldLockLock.Lock()
+	defer mw.heldLockLock.Unlock()
+	conflict, status, err := mw.setLock(mp, inode, lock, false)
+	if err != nil || status != statusOK {
+		if status == statusExist {
+			log.LogDebugf("SetLock_ll: lock conflict, inode(%v) lock(%v) conflict(%v)", inode, lock, conflict)
+			return syscall.EAGAIN
+		}
+		return statusToErrno(status)
+	}
+	mw.holdLock(inode, lock)
+	log.LogDebugf("SetLock_ll: inode(%v) lock(%v)", inode, lock)
+	return nil
+}
+
+// GetLock_ll is a low-level meta api that returns the lock conflicting with the given one,
+// or nil if the lock could be taken.
+func (mw *MetaWrapper) GetLock_ll(inode uint64, lock *proto.FileLock) (*proto.FileLock, error) {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("GetLock_ll: no such partition, inode(%v)", inode)
+		return nil, syscall.ENOENT
+	}
+	conflict, status, err := mw.getLock(mp, inode, lock)
+	if err != nil || status != statusOK {
+		return nil, statusToErrno(status)
+	}
+	log.LogDebugf("GetLock_ll: inode(%v) lock(%v) conflict(%v)", inode, lock, conflict)
+	return conflict, nil
+}
+
 func (mw *MetaWrapper) XAttrsList_ll(inode uint64) ([]string, error) {
 	var err error
 	mp := mw.getPartitionByInode(inode)
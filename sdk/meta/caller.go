@@ -0,0 +1,101 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"syscall"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// Caller issues the meta operations on behalf of a user, whose credential is checked
+// against the permissions of the inodes by the meta nodes.
+type Caller struct {
+	mw   *MetaWrapper
+	cred *proto.Credential
+}
+
+// As returns the caller of the meta operations with the credential. A nil credential
+// is the same as calling the meta wrapper directly.
+func (mw *MetaWrapper) As(cred *proto.Credential) *Caller {
+	return &Caller{mw: mw, cred: cred}
+}
+
+// SetDefaultCredential sets the credential sent with the requests issued by the meta wrapper directly or
+// by a caller without a credential, which keeps the requests of the client itself, such as moving the
+// deleted files to the trash, working on the volumes enforcing the permission checks.
+func (mw *MetaWrapper) SetDefaultCredential(cred *proto.Credential) {
+	mw.defaultCred = cred
+}
+
+func (mw *MetaWrapper) credential(cred *proto.Credential) *proto.Credential {
+	if cred == nil {
+		return mw.defaultCred
+	}
+	return cred
+}
+
+func (c *Caller) Create_ll(parentID uint64, name string, mode, uid, gid uint32, target []byte) (*proto.InodeInfo, error) {
+	return c.mw.createAs(c.cred, parentID, name, mode, uid, gid, target)
+}
+
+func (c *Caller) Lookup_ll(parentID uint64, name string) (inode uint64, mode uint32, err error) {
+	return c.mw.lookupAs(c.cred, parentID, name)
+}
+
+func (c *Caller) Delete_ll(parentID uint64, name string, isDir bool) (*proto.InodeInfo, error) {
+	return c.mw.deleteAs(c.cred, parentID, name, isDir)
+}
+
+func (c *Caller) Rename_ll(srcParentID uint64, srcName string, dstParentID uint64, dstName string) error {
+	return c.mw.renameAs(c.cred, srcParentID, srcName, dstParentID, dstName)
+}
+
+func (c *Caller) ReadDir_ll(parentID uint64) ([]proto.Dentry, error) {
+	return c.mw.readDirAs(c.cred, parentID)
+}
+
+func (c *Caller) ReadDirLimit_ll(parentID uint64, marker string, limit uint64) ([]proto.Dentry, error) {
+	return c.mw.readDirLimitAs(c.cred, parentID, marker, limit)
+}
+
+func (c *Caller) ReadDirPlusLimit_ll(parentID uint64, marker string, limit uint64) ([]proto.Dentry, []*proto.InodeInfo, error) {
+	return c.mw.readDirPlusLimitAs(c.cred, parentID, marker, limit)
+}
+
+func (c *Caller) Link(parentID uint64, name string, ino uint64) (*proto.InodeInfo, error) {
+	return c.mw.linkAs(c.cred, parentID, name, ino)
+}
+
+func (c *Caller) Setattr(inode uint64, valid, mode, uid, gid uint32, atime, mtime int64) error {
+	return c.mw.setattrAs(c.cred, inode, valid, mode, uid, gid, atime, mtime)
+}
+
+// InodeAccess_ll checks if the caller is permitted to access the inode by the mask of
+// proto.PermRead, proto.PermWrite and proto.PermExec.
+func (c *Caller) InodeAccess_ll(inode uint64, mask uint32) error {
+	mp := c.mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("InodeAccess_ll: No such partition, ino(%v)", inode)
+		return syscall.ENOENT
+	}
+
+	status, _, err := c.mw.iget(mp, c.cred, inode, mask)
+	if err != nil || status != statusOK {
+		return statusToErrno(status)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"fmt"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/exporter"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// PartitionIDs returns the IDs of the meta partitions of the volume in order.
+func (mw *MetaWrapper) PartitionIDs() []uint64 {
+	mw.RLock()
+	defer mw.RUnlock()
+	ids := make([]uint64, 0, len(mw.partitions))
+	for id := range mw.partitions {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// ReadChanges returns the changes of the metadata applied by the meta partition after the index from,
+// which requires the change journal enabled on the meta nodes.
+func (mw *MetaWrapper) ReadChanges(partitionID, from uint64, limit int) (resp *proto.ReadChangesResponse, err error) {
+	mp := mw.getPartitionByID(partitionID)
+	if mp == nil {
+		return nil, syscall.ENOENT
+	}
+	req := &proto.ReadChangesRequest{
+		VolName:     mw.volname,
+		PartitionId: partitionID,
+		From:        from,
+		Limit:       limit,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaReadChanges
+	if err = packet.MarshalData(req); err != nil {
+		log.LogErrorf("read changes: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("read changes: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+	if status := parseStatus(packet.ResultCode); status != statusOK {
+		err = fmt.Errorf("read changes: mp(%v) result(%v)", partitionID, packet.GetResultMsg())
+		log.LogErrorf("%v", err)
+		return
+	}
+
+	resp = new(proto.ReadChangesResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("read changes: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+	log.LogDebugf("read changes: mp(%v) from(%v) changes(%v) applyID(%v) truncated(%v)",
+		partitionID, from, len(resp.Changes), resp.ApplyID, resp.Truncated)
+	return
+}
+
+// ReadEvents returns the events of the volume derived from the changes applied by the meta partition
+// after the index from, waiting at most for a few seconds if there are none yet.
+func (mw *MetaWrapper) ReadEvents(partitionID, from uint64, limit int, wait time.Duration) (resp *proto.ReadEventsResponse, err error) {
+	mp := mw.getPartitionByID(partitionID)
+	if mp == nil {
+		return nil, syscall.ENOENT
+	}
+	req := &proto.ReadEventsRequest{
+		VolName:     mw.volname,
+		PartitionId: partitionID,
+		From:        from,
+		Limit:       limit,
+		Wait:        int64(wait / time.Second),
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaReadEvents
+	if err = packet.MarshalData(req); err != nil {
+		log.LogErrorf("read events: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("read events: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+	if status := parseStatus(packet.ResultCode); status != statusOK {
+		err = fmt.Errorf("read events: mp(%v) result(%v)", partitionID, packet.GetResultMsg())
+		log.LogErrorf("%v", err)
+		return
+	}
+
+	resp = new(proto.ReadEventsResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("read events: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+	log.LogDebugf("read events: mp(%v) from(%v) events(%v) cursor(%v) truncated(%v)",
+		partitionID, from, len(resp.Events), resp.Cursor, resp.Truncated)
+	return
+}
@@ -16,6 +16,7 @@ package meta
 
 import (
 	"fmt"
+	"math/rand"
 	"net"
 	"syscall"
 	"time"
@@ -33,7 +34,7 @@ const (
 )
 
 type MetaConn struct {
-	conn *net.TCPConn
+	conn net.Conn
 	id   uint64 //PartitionID
 	addr string //MetaNode addr
 }
@@ -123,6 +124,46 @@ out:
 	return resp, nil
 }
 
+// sendReadToMetaPartition sends the read request to a follower of the meta partition if follower read is enabled.
+// The follower serves it if it is not far behind the leader, or proxies it to the leader.
+func (mw *MetaWrapper) sendReadToMetaPartition(mp *MetaPartition, req *proto.Packet) (*proto.Packet, error) {
+	if !mw.followerRead {
+		return mw.sendToMetaPartition(mp, req)
+	}
+	addr := mw.followerAddr(mp)
+	if addr == "" {
+		return mw.sendToMetaPartition(mp, req)
+	}
+	mc, err := mw.getConn(mp.PartitionID, addr)
+	if err == nil {
+		var resp *proto.Packet
+		resp, err = mc.send(req)
+		mw.putConn(mc, err)
+		if err == nil && !resp.ShouldRetry() {
+			log.LogDebugf("sendReadToMetaPartition successful: req(%v) mc(%v) resp(%v)", req, mc, resp)
+			return resp, nil
+		}
+	}
+	log.LogWarnf("sendReadToMetaPartition: follower failed, retry the leader, req(%v) mp(%v) addr(%v) err(%v)", req, mp, addr, err)
+	return mw.sendToMetaPartition(mp, req)
+}
+
+// followerAddr returns a random follower of the meta partition supporting follower read, or empty if none.
+func (mw *MetaWrapper) followerAddr(mp *MetaPartition) string {
+	n := len(mp.Members)
+	if n == 0 {
+		return ""
+	}
+	start := rand.Intn(n)
+	for i := 0; i < n; i++ {
+		addr := mp.Members[(start+i)%n]
+		if addr != mp.LeaderAddr && mw.features.Supports(addr, proto.FeatureMetaFollowerRead) {
+			return addr
+		}
+	}
+	return ""
+}
+
 func (mc *MetaConn) send(req *proto.Packet) (resp *proto.Packet, err error) {
 	err = req.WriteToConn(mc.conn)
 	if err != nil {
@@ -0,0 +1,88 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"sync"
+	"syscall"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// DirStatBatchSize is the max number of directories got from a partition in a request.
+const DirStatBatchSize = 1000
+
+// DirStat returns the usage of the directory recursively, not including the directory itself.
+// Only the subdirectories are walked level by level, the files of each directory are
+// summarized by the meta partition holding the directory.
+func (mw *MetaWrapper) DirStat(ino uint64) (stat *proto.DirStat, err error) {
+	stat = new(proto.DirStat)
+	dirs := []uint64{ino}
+	for level := 0; len(dirs) > 0; level++ {
+		if dirs, err = mw.dirStatLevel(dirs, stat); err != nil {
+			log.LogErrorf("DirStat: ino(%v) level(%v) err(%v)", ino, level, err)
+			return nil, err
+		}
+	}
+	log.LogDebugf("DirStat: ino(%v) stat(%v)", ino, stat)
+	return
+}
+
+// dirStatLevel adds the summaries of the directories to the stat, and returns their subdirectories.
+func (mw *MetaWrapper) dirStatLevel(dirs []uint64, stat *proto.DirStat) (subDirs []uint64, err error) {
+	candidates := make(map[*MetaPartition][]uint64)
+	for _, ino := range dirs {
+		mp := mw.getPartitionByInode(ino)
+		if mp == nil {
+			return nil, syscall.ENOENT
+		}
+		candidates[mp] = append(candidates[mp], ino)
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for mp, inodes := range candidates {
+		wg.Add(1)
+		go func(mp *MetaPartition, inodes []uint64) {
+			defer wg.Done()
+			for len(inodes) > 0 {
+				n := len(inodes)
+				if n > DirStatBatchSize {
+					n = DirStatBatchSize
+				}
+				summaries, status, e := mw.getDirStat(mp, inodes[:n])
+				inodes = inodes[n:]
+				mu.Lock()
+				if e != nil || status != statusOK {
+					if err == nil {
+						err = statusToErrno(status)
+					}
+					mu.Unlock()
+					return
+				}
+				for _, summary := range summaries {
+					stat.Add(&summary.Stat)
+					subDirs = append(subDirs, summary.SubDirs...)
+				}
+				mu.Unlock()
+			}
+		}(mp, inodes)
+	}
+	wg.Wait()
+	return
+}
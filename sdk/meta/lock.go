@@ -0,0 +1,76 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// holdLock records the lock taken or released by the client, heldLockLock must be held.
+func (mw *MetaWrapper) holdLock(inode uint64, lock *proto.FileLock) {
+	held := proto.MergeFileLock(mw.heldLocks[inode], lock)
+	if len(held) == 0 {
+		delete(mw.heldLocks, inode)
+	} else {
+		mw.heldLocks[inode] = held
+	}
+}
+
+// reclaimLocks sends the locks held by the client to the meta partitions periodically, so that
+// a new leader of a partition, which knows no locks, gets them back before granting new ones.
+func (mw *MetaWrapper) reclaimLocks() {
+	t := time.NewTicker(proto.LockReclaimInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			mw.reclaimHeldLocks()
+		case <-mw.closeCh:
+			return
+		}
+	}
+}
+
+func (mw *MetaWrapper) reclaimHeldLocks() {
+	mw.heldLockLock.Lock()
+	defer mw.heldLockLock.Unlock()
+	for inode, held := range mw.heldLocks {
+		mp := mw.getPartitionByInode(inode)
+		if mp == nil {
+			continue
+		}
+		kept := held[:0]
+		for _, lock := range held {
+			conflict, status, err := mw.setLock(mp, inode, lock, true)
+			switch {
+			case err == nil && status == statusExist:
+				// granted to another client after the grace period of the new leader
+				log.LogWarnf("reclaimLocks: lock lost, inode(%v) lock(%v) conflict(%v)", inode, lock, conflict)
+			case err == nil && status == statusNoent:
+				log.LogDebugf("reclaimLocks: inode deleted, inode(%v) lock(%v)", inode, lock)
+			default:
+				kept = append(kept, lock)
+			}
+		}
+		if len(kept) == 0 {
+			delete(mw.heldLocks, inode)
+		} else {
+			mw.heldLocks[inode] = kept
+		}
+	}
+}
@@ -16,7 +16,9 @@ package meta
 
 import (
 	"fmt"
+	"math/rand"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -34,6 +36,8 @@ import (
 const (
 	HostsSeparator                = ","
 	RefreshMetaPartitionsInterval = time.Minute * 5
+	RefreshQuotaInterval          = time.Minute
+	RefreshVolAccessTokenBefore   = time.Minute * 30
 )
 
 const (
@@ -46,6 +50,9 @@ const (
 	statusError
 	statusInval
 	statusNotPerm
+	statusAccess
+	statusROFS
+	statusQuota
 )
 
 const (
@@ -73,7 +80,9 @@ type MetaConfig struct {
 	Masters          []string
 	Authenticate     bool
 	TicketMess       auth.TicketMess
+	TokenKey         string
 	ValidateOwner    bool
+	FollowerRead     bool // read the metadata from the followers with bounded staleness
 	OnAsyncTaskError AsyncTaskErrorFunc
 }
 
@@ -84,11 +93,24 @@ type MetaWrapper struct {
 	volname         string
 	ossSecure       *OSSSecure
 	volCreateTime   int64
+	flat            bool         // the volume stores the objects by their keys without intermediate directories, immutable
+	permPriority    atomic.Value // string, which one of the mode bits and the S3 ACLs takes effect
+	trashDays       uint32
+	caseInsensitive uint32 // 1 if the lookups of the volume ignore the case
 	owner           string
 	ownerValidation bool
 	mc              *masterSDK.MasterClient
 	ac              *authSDK.AuthClient
 	conns           *util.ConnectPool
+	features        *proto.PeerFeatures // features negotiated with the meta nodes
+	followerRead    bool                // send the lookups, getattrs and readdirs to the followers
+	clientID        uint64              // tells the retried requests apart on the meta nodes with the packet ids, and owns the session
+	capabilities    uint32              // granted to the session by the master, see StartSession
+	sessionVolume   string              // the volume of the session, differs from volname once the volume is renamed
+	defaultCred     *proto.Credential   // sent with the requests issued without a caller, see SetDefaultCredential
+
+	// Inodes sharing the extents with the cloned files, which are written copy-on-write
+	sharedInodes sync.Map
 
 	// Callback handler for handling asynchronous task errors.
 	onAsyncTaskError AsyncTaskErrorFunc
@@ -109,12 +131,25 @@ type MetaWrapper struct {
 	totalSize uint64
 	usedSize  uint64
 
+	// Directory quotas of the volume indexed by the root inode
+	quotas    map[uint64]*proto.QuotaInfo
+	quotaLock sync.RWMutex
+
+	// File locks held by the client indexed by the inode, which are reclaimed periodically
+	heldLocks    map[uint64][]*proto.FileLock
+	heldLockLock sync.Mutex
+
 	authenticate bool
 	Ticket       auth.Ticket
 	accessToken  proto.APIAccessReq
 	sessionKey   string
 	ticketMess   auth.TicketMess
 
+	// Access token presented to the meta nodes and the data nodes if the cluster enables it
+	tokenKey           string
+	accessTokenEnabled bool
+	volAccessToken     *proto.VolAccessToken
+
 	closeCh   chan struct{}
 	closeOnce sync.Once
 
@@ -139,6 +174,7 @@ func NewMetaWrapper(config *MetaConfig) (*MetaWrapper, error) {
 	var err error
 	mw := new(MetaWrapper)
 	mw.closeCh = make(chan struct{}, 1)
+	mw.heldLocks = make(map[uint64][]*proto.FileLock)
 
 	if config.Authenticate {
 		var ticketMess = config.TicketMess
@@ -158,12 +194,19 @@ func NewMetaWrapper(config *MetaConfig) (*MetaWrapper, error) {
 	mw.volname = config.Volume
 	mw.owner = config.Owner
 	mw.ownerValidation = config.ValidateOwner
+	mw.followerRead = config.FollowerRead
+	for mw.clientID == 0 {
+		mw.clientID = rand.New(rand.NewSource(time.Now().UnixNano())).Uint64()
+	}
+	mw.tokenKey = config.TokenKey
 	mw.mc = masterSDK.NewMasterClient(config.Masters, false)
 	mw.onAsyncTaskError = config.OnAsyncTaskError
 	mw.conns = util.NewConnectPool()
+	mw.features = proto.NewPeerFeatures(mw.conns, proto.MetaNodeFeatures)
 	mw.partitions = make(map[uint64]*MetaPartition)
 	mw.ranges = btree.New(32)
 	mw.rwPartitions = make([]*MetaPartition, 0)
+	mw.quotas = make(map[uint64]*proto.QuotaInfo)
 	mw.partCond = sync.NewCond(&mw.partMutex)
 	mw.forceUpdate = make(chan struct{}, 1)
 	mw.forceUpdateLimit = rate.NewLimiter(1, MinForceUpdateMetaPartitionsInterval)
@@ -190,6 +233,8 @@ func NewMetaWrapper(config *MetaConfig) (*MetaWrapper, error) {
 	}
 
 	go mw.refresh()
+	go mw.refreshQuota()
+	go mw.reclaimLocks()
 	return mw, nil
 }
 
@@ -198,6 +243,10 @@ func (mw *MetaWrapper) initMetaWrapper() (err error) {
 		return err
 	}
 
+	if err = mw.updateVolAccessToken(); err != nil {
+		return err
+	}
+
 	if err = mw.updateVolStatInfo(); err != nil {
 		return err
 	}
@@ -221,6 +270,29 @@ func (mw *MetaWrapper) VolCreateTime() int64 {
 	return mw.volCreateTime
 }
 
+// TrashDays returns the days to keep the deleted files in the trash, 0 means the trash is disabled.
+func (mw *MetaWrapper) TrashDays() uint32 {
+	return atomic.LoadUint32(&mw.trashDays)
+}
+
+// CaseInsensitive returns whether the lookups of the volume ignore the case of the names, see LookupCaseInsensitive_ll.
+func (mw *MetaWrapper) CaseInsensitive() bool {
+	return atomic.LoadUint32(&mw.caseInsensitive) == 1
+}
+
+// Flat returns whether the volume is of the flat namespace, whose objects are the dentries in the root
+// directory named by their keys, so it can be accessed by the object nodes only.
+func (mw *MetaWrapper) Flat() bool {
+	return mw.flat
+}
+
+// PermissionPriority returns which one of the mode bits and the S3 ACLs takes effect on the volume,
+// see proto.PermissionPriorityPOSIX and proto.PermissionPriorityS3.
+func (mw *MetaWrapper) PermissionPriority() string {
+	priority, _ := mw.permPriority.Load().(string)
+	return priority
+}
+
 func (mw *MetaWrapper) Close() error {
 	mw.closeOnce.Do(func() {
 		close(mw.closeCh)
@@ -258,6 +330,12 @@ func parseStatus(result uint8) (status int) {
 		status = statusInval
 	case proto.OpNotPerm:
 		status = statusNotPerm
+	case proto.OpAccessDenied:
+		status = statusAccess
+	case proto.OpVolReadOnly:
+		status = statusROFS
+	case proto.OpQuotaExceeded:
+		status = statusQuota
 	default:
 		status = statusError
 	}
@@ -281,6 +359,12 @@ func statusToErrno(status int) error {
 		return syscall.EINVAL
 	case statusNotPerm:
 		return syscall.EPERM
+	case statusAccess:
+		return syscall.EACCES
+	case statusROFS:
+		return syscall.EROFS
+	case statusQuota:
+		return syscall.EDQUOT
 	case statusError:
 		return syscall.EAGAIN
 	default:
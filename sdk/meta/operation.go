@@ -36,6 +36,7 @@ func (mw *MetaWrapper) icreate(mp *MetaPartition, mode, uid, gid uint32, target
 		Uid:         uid,
 		Gid:         gid,
 		Target:      target,
+		ClientID:    mw.clientID,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -76,11 +77,59 @@ func (mw *MetaWrapper) icreate(mp *MetaPartition, mode, uid, gid uint32, target
 	return statusOK, resp.Info, nil
 }
 
+func (mw *MetaWrapper) icloneInode(mp *MetaPartition, inode uint64, uid, gid uint32) (status int, info *proto.InodeInfo, err error) {
+	req := &proto.CloneInodeRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Inode:       inode,
+		Uid:         uid,
+		Gid:         gid,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaCloneInode
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("icloneInode: ino(%v) err(%v)", inode, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("icloneInode: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("icloneInode: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.CloneInodeResponse)
+	err = packet.UnmarshalData(resp)
+	if err != nil {
+		log.LogErrorf("icloneInode: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	if resp.Info == nil {
+		err = errors.New(fmt.Sprintf("icloneInode: info is nil, packet(%v) mp(%v) req(%v) PacketData(%v)", packet, mp, *req, string(packet.Data)))
+		log.LogWarn(err)
+		return
+	}
+	log.LogDebugf("icloneInode: packet(%v) mp(%v) req(%v) info(%v)", packet, mp, *req, resp.Info)
+	return statusOK, resp.Info, nil
+}
+
 func (mw *MetaWrapper) iunlink(mp *MetaPartition, inode uint64) (status int, info *proto.InodeInfo, err error) {
 	req := &proto.UnlinkInodeRequest{
 		VolName:     mw.volname,
 		PartitionID: mp.PartitionID,
 		Inode:       inode,
+		ClientID:    mw.clientID,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -151,7 +200,7 @@ func (mw *MetaWrapper) ievict(mp *MetaPartition, inode uint64) (status int, err
 	return statusOK, nil
 }
 
-func (mw *MetaWrapper) dcreate(mp *MetaPartition, parentID uint64, name string, inode uint64, mode uint32) (status int, err error) {
+func (mw *MetaWrapper) dcreate(mp *MetaPartition, cred *proto.Credential, parentID uint64, name string, inode uint64, mode uint32) (status int, err error) {
 	if parentID == inode {
 		return statusExist, nil
 	}
@@ -163,6 +212,8 @@ func (mw *MetaWrapper) dcreate(mp *MetaPartition, parentID uint64, name string,
 		Inode:       inode,
 		Name:        name,
 		Mode:        mode,
+		Cred:        mw.credential(cred),
+		ClientID:    mw.clientID,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -192,7 +243,7 @@ func (mw *MetaWrapper) dcreate(mp *MetaPartition, parentID uint64, name string,
 	return
 }
 
-func (mw *MetaWrapper) dupdate(mp *MetaPartition, parentID uint64, name string, newInode uint64) (status int, oldInode uint64, err error) {
+func (mw *MetaWrapper) dupdate(mp *MetaPartition, cred *proto.Credential, parentID uint64, name string, newInode uint64) (status int, oldInode uint64, err error) {
 	if parentID == newInode {
 		return statusExist, 0, nil
 	}
@@ -203,6 +254,8 @@ func (mw *MetaWrapper) dupdate(mp *MetaPartition, parentID uint64, name string,
 		ParentID:    parentID,
 		Name:        name,
 		Inode:       newInode,
+		Cred:        mw.credential(cred),
+		ClientID:    mw.clientID,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -238,12 +291,14 @@ func (mw *MetaWrapper) dupdate(mp *MetaPartition, parentID uint64, name string,
 	return statusOK, resp.Inode, nil
 }
 
-func (mw *MetaWrapper) ddelete(mp *MetaPartition, parentID uint64, name string) (status int, inode uint64, err error) {
+func (mw *MetaWrapper) ddelete(mp *MetaPartition, cred *proto.Credential, parentID uint64, name string) (status int, inode uint64, err error) {
 	req := &proto.DeleteDentryRequest{
 		VolName:     mw.volname,
 		PartitionID: mp.PartitionID,
 		ParentID:    parentID,
 		Name:        name,
+		Cred:        mw.credential(cred),
+		ClientID:    mw.clientID,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -279,13 +334,45 @@ func (mw *MetaWrapper) ddelete(mp *MetaPartition, parentID uint64, name string)
 	return statusOK, resp.Inode, nil
 }
 
-func (mw *MetaWrapper) lookup(mp *MetaPartition, parentID uint64, name string) (status int, inode uint64, mode uint32, err error) {
+func (mw *MetaWrapper) lookup(mp *MetaPartition, cred *proto.Credential, parentID uint64, name string) (status int, inode uint64, mode uint32, err error) {
 	req := &proto.LookupRequest{
-		VolName:     mw.volname,
-		PartitionID: mp.PartitionID,
-		ParentID:    parentID,
-		Name:        name,
+		VolName:      mw.volname,
+		PartitionID:  mp.PartitionID,
+		ParentID:     parentID,
+		Name:         name,
+		FollowerRead: mw.followerRead,
+		Cred:         mw.credential(cred),
+	}
+	status, resp, err := mw.doLookup(mp, req)
+	if err != nil || status != statusOK {
+		return
+	}
+	return statusOK, resp.Inode, resp.Mode, nil
+}
+
+// lookupCaseInsensitive is lookup ignoring the case of the name if there is no exact match,
+// the name of the matched dentry is returned as realName.
+func (mw *MetaWrapper) lookupCaseInsensitive(mp *MetaPartition, parentID uint64, name string) (status int, inode uint64, mode uint32, realName string, err error) {
+	req := &proto.LookupRequest{
+		VolName:         mw.volname,
+		PartitionID:     mp.PartitionID,
+		ParentID:        parentID,
+		Name:            name,
+		CaseInsensitive: true,
+		FollowerRead:    mw.followerRead,
+	}
+	status, resp, err := mw.doLookup(mp, req)
+	if err != nil || status != statusOK {
+		return
 	}
+	realName = name
+	if resp.Name != "" {
+		realName = resp.Name
+	}
+	return statusOK, resp.Inode, resp.Mode, realName, nil
+}
+
+func (mw *MetaWrapper) doLookup(mp *MetaPartition, req *proto.LookupRequest) (status int, resp *proto.LookupResponse, err error) {
 	packet := proto.NewPacketReqID()
 	packet.Opcode = proto.OpMetaLookup
 	err = packet.MarshalData(req)
@@ -299,7 +386,7 @@ func (mw *MetaWrapper) lookup(mp *MetaPartition, parentID uint64, name string) (
 	metric := exporter.NewTPCnt(packet.GetOpMsg())
 	defer metric.Set(err)
 
-	packet, err = mw.sendToMetaPartition(mp, packet)
+	packet, err = mw.sendReadToMetaPartition(mp, packet)
 	if err != nil {
 		log.LogErrorf("lookup: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
 		return
@@ -315,21 +402,24 @@ func (mw *MetaWrapper) lookup(mp *MetaPartition, parentID uint64, name string) (
 		return
 	}
 
-	resp := new(proto.LookupResponse)
+	resp = new(proto.LookupResponse)
 	err = packet.UnmarshalData(resp)
 	if err != nil {
 		log.LogErrorf("lookup: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
 		return
 	}
 	log.LogDebugf("lookup exit: packet(%v) mp(%v) req(%v) ino(%v) mode(%v)", packet, mp, *req, resp.Inode, resp.Mode)
-	return statusOK, resp.Inode, resp.Mode, nil
+	return statusOK, resp, nil
 }
 
-func (mw *MetaWrapper) iget(mp *MetaPartition, inode uint64) (status int, info *proto.InodeInfo, err error) {
+func (mw *MetaWrapper) iget(mp *MetaPartition, cred *proto.Credential, inode uint64, mask uint32) (status int, info *proto.InodeInfo, err error) {
 	req := &proto.InodeGetRequest{
-		VolName:     mw.volname,
-		PartitionID: mp.PartitionID,
-		Inode:       inode,
+		VolName:      mw.volname,
+		PartitionID:  mp.PartitionID,
+		Inode:        inode,
+		FollowerRead: mw.followerRead,
+		Cred:         cred,
+		Mask:         mask,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -343,7 +433,7 @@ func (mw *MetaWrapper) iget(mp *MetaPartition, inode uint64) (status int, info *
 	metric := exporter.NewTPCnt(packet.GetOpMsg())
 	defer metric.Set(err)
 
-	packet, err = mw.sendToMetaPartition(mp, packet)
+	packet, err = mw.sendReadToMetaPartition(mp, packet)
 	if err != nil {
 		log.LogErrorf("iget: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
 		return
@@ -370,9 +460,10 @@ func (mw *MetaWrapper) batchIget(wg *sync.WaitGroup, mp *MetaPartition, inodes [
 		err error
 	)
 	req := &proto.BatchInodeGetRequest{
-		VolName:     mw.volname,
-		PartitionID: mp.PartitionID,
-		Inodes:      inodes,
+		VolName:      mw.volname,
+		PartitionID:  mp.PartitionID,
+		Inodes:       inodes,
+		FollowerRead: mw.followerRead,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -385,7 +476,7 @@ func (mw *MetaWrapper) batchIget(wg *sync.WaitGroup, mp *MetaPartition, inodes [
 	metric := exporter.NewTPCnt(packet.GetOpMsg())
 	defer metric.Set(err)
 
-	packet, err = mw.sendToMetaPartition(mp, packet)
+	packet, err = mw.sendReadToMetaPartition(mp, packet)
 	if err != nil {
 		log.LogErrorf("batchIget: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
 		return
@@ -414,11 +505,16 @@ func (mw *MetaWrapper) batchIget(wg *sync.WaitGroup, mp *MetaPartition, inodes [
 	}
 }
 
-func (mw *MetaWrapper) readdir(mp *MetaPartition, parentID uint64) (status int, children []proto.Dentry, err error) {
+func (mw *MetaWrapper) readdir(mp *MetaPartition, cred *proto.Credential, parentID uint64, prefix, marker string, limit uint64) (status int, children []proto.Dentry, err error) {
 	req := &proto.ReadDirRequest{
-		VolName:     mw.volname,
-		PartitionID: mp.PartitionID,
-		ParentID:    parentID,
+		VolName:      mw.volname,
+		PartitionID:  mp.PartitionID,
+		ParentID:     parentID,
+		Marker:       marker,
+		Limit:        limit,
+		Prefix:       prefix,
+		FollowerRead: mw.followerRead,
+		Cred:         mw.credential(cred),
 	}
 
 	packet := proto.NewPacketReqID()
@@ -432,7 +528,7 @@ func (mw *MetaWrapper) readdir(mp *MetaPartition, parentID uint64) (status int,
 	metric := exporter.NewTPCnt(packet.GetOpMsg())
 	defer metric.Set(err)
 
-	packet, err = mw.sendToMetaPartition(mp, packet)
+	packet, err = mw.sendReadToMetaPartition(mp, packet)
 	if err != nil {
 		log.LogErrorf("readdir: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
 		return
@@ -455,6 +551,50 @@ func (mw *MetaWrapper) readdir(mp *MetaPartition, parentID uint64) (status int,
 	return statusOK, resp.Children, nil
 }
 
+func (mw *MetaWrapper) readdirplus(mp *MetaPartition, cred *proto.Credential, parentID uint64, marker string, limit uint64) (status int, children []proto.Dentry, infos []*proto.InodeInfo, err error) {
+	req := &proto.ReadDirRequest{
+		VolName:      mw.volname,
+		PartitionID:  mp.PartitionID,
+		ParentID:     parentID,
+		Marker:       marker,
+		Limit:        limit,
+		FollowerRead: mw.followerRead,
+		Cred:         mw.credential(cred),
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaReadDirPlus
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("readdirplus: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	packet, err = mw.sendReadToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("readdirplus: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("readdirplus: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.ReadDirPlusResponse)
+	err = packet.UnmarshalData(resp)
+	if err != nil {
+		log.LogErrorf("readdirplus: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("readdirplus: packet(%v) mp(%v) req(%v) children(%v) infos(%v)", packet, mp, *req, len(resp.Children), len(resp.Infos))
+	return statusOK, resp.Children, resp.Infos, nil
+}
+
 func (mw *MetaWrapper) appendExtentKey(mp *MetaPartition, inode uint64, extent proto.ExtentKey) (status int, err error) {
 	req := &proto.AppendExtentKeyRequest{
 		VolName:     mw.volname,
@@ -524,6 +664,9 @@ func (mw *MetaWrapper) getExtents(mp *MetaPartition, inode uint64) (status int,
 		log.LogErrorf("getExtents: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
 		return
 	}
+	if resp.Shared {
+		mw.sharedInodes.Store(inode, struct{}{})
+	}
 	return statusOK, resp.Generation, resp.Size, resp.Extents, nil
 }
 
@@ -564,11 +707,49 @@ func (mw *MetaWrapper) truncate(mp *MetaPartition, inode, size uint64) (status i
 	return statusOK, nil
 }
 
-func (mw *MetaWrapper) ilink(mp *MetaPartition, inode uint64) (status int, info *proto.InodeInfo, err error) {
+func (mw *MetaWrapper) punchHole(mp *MetaPartition, inode, offset, size uint64) (status int, err error) {
+	req := &proto.PunchHoleRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Inode:       inode,
+		Offset:      offset,
+		Size:        size,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaPunchHole
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("punchHole: ino(%v) offset(%v) size(%v) err(%v)", inode, offset, size, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("punchHole: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("punchHole: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	log.LogDebugf("punchHole exit: packet(%v) mp(%v) req(%v)", packet, mp, *req)
+	return statusOK, nil
+}
+
+func (mw *MetaWrapper) ilink(mp *MetaPartition, inode uint64, hardLink bool) (status int, info *proto.InodeInfo, err error) {
 	req := &proto.LinkInodeRequest{
 		VolName:     mw.volname,
 		PartitionID: mp.PartitionID,
 		Inode:       inode,
+		ClientID:    mw.clientID,
+		HardLink:    hardLink,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -611,7 +792,7 @@ func (mw *MetaWrapper) ilink(mp *MetaPartition, inode uint64) (status int, info
 	return statusOK, resp.Info, nil
 }
 
-func (mw *MetaWrapper) setattr(mp *MetaPartition, inode uint64, valid, mode, uid, gid uint32, atime, mtime int64) (status int, err error) {
+func (mw *MetaWrapper) setattr(mp *MetaPartition, cred *proto.Credential, inode uint64, valid, mode, uid, gid uint32, atime, mtime int64) (status int, err error) {
 	req := &proto.SetAttrRequest{
 		VolName:     mw.volname,
 		PartitionID: mp.PartitionID,
@@ -622,6 +803,7 @@ func (mw *MetaWrapper) setattr(mp *MetaPartition, inode uint64, valid, mode, uid
 		Gid:         gid,
 		AccessTime:  atime,
 		ModifyTime:  mtime,
+		Cred:        mw.credential(cred),
 	}
 
 	packet := proto.NewPacketReqID()
@@ -883,13 +1065,14 @@ func (mw *MetaWrapper) appendExtentKeys(mp *MetaPartition, inode uint64, extents
 	return
 }
 
-func (mw *MetaWrapper) setXAttr(mp *MetaPartition, inode uint64, name []byte, value []byte) (status int, err error) {
+func (mw *MetaWrapper) setXAttr(mp *MetaPartition, inode uint64, name []byte, value []byte, flag int) (status int, err error) {
 	req := &proto.SetXAttrRequest{
 		VolName:     mw.volname,
 		PartitionId: mp.PartitionID,
 		Inode:       inode,
 		Key:         string(name),
 		Value:       string(value),
+		Flag:        flag,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -1125,3 +1308,270 @@ func (mw *MetaWrapper) batchGetXAttr(mp *MetaPartition, inodes []uint64, keys []
 
 	return resp.XAttrs, nil
 }
+
+func (mw *MetaWrapper) setLock(mp *MetaPartition, inode uint64, lock *proto.FileLock, reclaim bool) (conflict *proto.FileLock, status int, err error) {
+	req := &proto.SetLockRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		Inode:       inode,
+		Lock:        *lock,
+		Reclaim:     reclaim,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaSetLock
+	if err = packet.MarshalData(req); err != nil {
+		log.LogErrorf("set lock: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("set lock: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status == statusExist {
+		resp := new(proto.GetLockResponse)
+		if err = packet.UnmarshalData(resp); err != nil {
+			log.LogErrorf("set lock: packet(%v) mp(%v) req(%v) err(%v) PacketData(%v)", packet, mp, *req, err, string(packet.Data))
+			return
+		}
+		conflict = resp.Lock
+		log.LogDebugf("set lock: packet(%v) mp(%v) req(%v) conflict(%v)", packet, mp, *req, conflict)
+		return
+	}
+	if status != statusOK {
+		log.LogErrorf("set lock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	log.LogDebugf("set lock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+	return
+}
+
+func (mw *MetaWrapper) getLock(mp *MetaPartition, inode uint64, lock *proto.FileLock) (conflict *proto.FileLock, status int, err error) {
+	req := &proto.GetLockRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		Inode:       inode,
+		Lock:        *lock,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaGetLock
+	if err = packet.MarshalData(req); err != nil {
+		log.LogErrorf("get lock: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("get lock: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("get lock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.GetLockResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("get lock: packet(%v) mp(%v) req(%v) err(%v) PacketData(%v)", packet, mp, *req, err, string(packet.Data))
+		return
+	}
+	conflict = resp.Lock
+
+	log.LogDebugf("get lock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+	return
+}
+
+func (mw *MetaWrapper) getDirStat(mp *MetaPartition, inodes []uint64) (summaries []*proto.DirSummary, status int, err error) {
+	req := &proto.GetDirStatRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		Inodes:      inodes,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaGetDirStat
+	if err = packet.MarshalData(req); err != nil {
+		log.LogErrorf("get dir stat: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("get dir stat: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("get dir stat: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.GetDirStatResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("get dir stat: packet(%v) mp(%v) req(%v) err(%v) PacketData(%v)", packet, mp, *req, err, string(packet.Data))
+		return
+	}
+	summaries = resp.Summaries
+
+	log.LogDebugf("get dir stat: packet(%v) mp(%v) req(%v) summaries(%v)", packet, mp, *req, len(summaries))
+	return
+}
+
+func (mw *MetaWrapper) txBegin(mp *MetaPartition, txType uint8, payload []byte) (status int, txID string, err error) {
+	req := &proto.TxBeginRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Type:        txType,
+		Payload:     payload,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaTxBegin
+	if err = packet.MarshalData(req); err != nil {
+		log.LogErrorf("txBegin: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	if packet, err = mw.sendToMetaPartition(mp, packet); err != nil {
+		log.LogErrorf("txBegin: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("txBegin: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.TxBeginResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("txBegin: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("txBegin: packet(%v) mp(%v) req(%v) txID(%v)", packet, mp, *req, resp.TxID)
+	return statusOK, resp.TxID, nil
+}
+
+// txUpdate moves the transaction to the state, the payload of it is kept if the given one is empty.
+func (mw *MetaWrapper) txUpdate(mp *MetaPartition, txID string, state uint8, payload []byte) (status int, err error) {
+	req := &proto.TxUpdateRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		TxID:        txID,
+		State:       state,
+		Payload:     payload,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaTxUpdate
+	if err = packet.MarshalData(req); err != nil {
+		log.LogErrorf("txUpdate: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	if packet, err = mw.sendToMetaPartition(mp, packet); err != nil {
+		log.LogErrorf("txUpdate: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("txUpdate: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+	log.LogDebugf("txUpdate: packet(%v) mp(%v) req(%v)", packet, mp, *req)
+	return
+}
+
+func (mw *MetaWrapper) txFinish(mp *MetaPartition, txID string) (status int, err error) {
+	req := &proto.TxFinishRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		TxID:        txID,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaTxFinish
+	if err = packet.MarshalData(req); err != nil {
+		log.LogErrorf("txFinish: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	if packet, err = mw.sendToMetaPartition(mp, packet); err != nil {
+		log.LogErrorf("txFinish: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("txFinish: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+	log.LogDebugf("txFinish: packet(%v) mp(%v) req(%v)", packet, mp, *req)
+	return
+}
+
+// ishared tells whether the inode shares the extents with other inodes, which is asked to the leader
+// of the meta partition, since a follower may not apply the clone yet.
+func (mw *MetaWrapper) ishared(mp *MetaPartition, inode uint64) (status int, shared bool, err error) {
+	req := &proto.InodeGetRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Inode:       inode,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaInodeGet
+	if err = packet.MarshalData(req); err != nil {
+		log.LogErrorf("ishared: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	if packet, err = mw.sendToMetaPartition(mp, packet); err != nil {
+		log.LogErrorf("ishared: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("ishared: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.InodeGetResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("ishared: packet(%v) mp(%v) req(%v) err(%v) PacketData(%v)", packet, mp, *req, err, string(packet.Data))
+		return
+	}
+	return statusOK, resp.Shared, nil
+}
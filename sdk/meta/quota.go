@@ -0,0 +1,74 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+func (mw *MetaWrapper) refreshQuota() {
+	t := time.NewTimer(0)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			mw.updateQuotas()
+			t.Reset(RefreshQuotaInterval)
+		case <-mw.closeCh:
+			return
+		}
+	}
+}
+
+func (mw *MetaWrapper) updateQuotas() {
+	quotas, err := mw.mc.ClientAPI().ListQuotas(mw.volname)
+	if err != nil {
+		log.LogWarnf("updateQuotas: list quotas fail: volume(%v) err(%v)", mw.volname, err)
+		return
+	}
+	newQuotas := make(map[uint64]*proto.QuotaInfo, len(quotas))
+	for _, quota := range quotas {
+		newQuotas[quota.QuotaId] = quota
+	}
+	mw.quotaLock.Lock()
+	mw.quotas = newQuotas
+	mw.quotaLock.Unlock()
+	log.LogDebugf("updateQuotas: volume(%v) quotas(%v)", mw.volname, len(quotas))
+}
+
+// IsQuotaExceeded returns true if the usage of the quota, or of any quota enclosing it, has reached its limits.
+// The quota of an inode is tagged by the meta nodes, which also reject the changes beyond the limits,
+// so this is only a cheap check to fail the writes early.
+func (mw *MetaWrapper) IsQuotaExceeded(quotaId uint64) bool {
+	mw.quotaLock.RLock()
+	defer mw.quotaLock.RUnlock()
+	visited := make(map[uint64]bool)
+	for quotaId != 0 && !visited[quotaId] {
+		visited[quotaId] = true
+		quota, ok := mw.quotas[quotaId]
+		if !ok {
+			return false
+		}
+		if quota.Exceeded {
+			return true
+		}
+		quotaId = quota.ParentId
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const defaultSessionHeartbeatInterval = 20 * time.Second
+
+// ClientID returns the id of the client, which owns the session, the locks and the holds of the orphan inodes.
+func (mw *MetaWrapper) ClientID() uint64 {
+	return mw.clientID
+}
+
+// SessionCapabilities returns the capabilities granted by the master to the session of the client.
+func (mw *MetaWrapper) SessionCapabilities() uint32 {
+	return atomic.LoadUint32(&mw.capabilities)
+}
+
+// ReadOnly tells whether the master withdrew the write capability of the session, e.g. the volume is
+// frozen read-only. The client without a session is not read-only.
+func (mw *MetaWrapper) ReadOnly() bool {
+	caps := mw.SessionCapabilities()
+	return caps != 0 && caps&proto.ClientCapWrite == 0
+}
+
+// StartSession registers the session of the client with the master, and keeps it alive by the heartbeats
+// until the meta wrapper is closed. The session is registered again if the master loses it, e.g. after
+// the master leader changes. The client reports itself by report with the registration and the heartbeats.
+func (mw *MetaWrapper) StartSession(report func() *proto.ClientSessionReport) (err error) {
+	var session *proto.ClientSessionView
+	if session, err = mw.mc.ClientAPI().RegisterClientSession(mw.volname, mw.clientID, mw.sessionReport(report)); err != nil {
+		log.LogErrorf("StartSession: volume(%v) client(%v) err(%v)", mw.volname, mw.clientID, err)
+		return
+	}
+	mw.updateSession(session)
+	go mw.keepSession(heartbeatInterval(session), report)
+	return
+}
+
+func (mw *MetaWrapper) sessionReport(report func() *proto.ClientSessionReport) *proto.ClientSessionReport {
+	r := &proto.ClientSessionReport{}
+	if report != nil {
+		r = report()
+	}
+	if r.Host == "" {
+		r.Host = mw.localIP
+	}
+	return r
+}
+
+func (mw *MetaWrapper) keepSession(interval time.Duration, report func() *proto.ClientSessionReport) {
+	t := time.NewTimer(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			r := mw.sessionReport(report)
+			session, err := mw.mc.ClientAPI().ClientSessionHeartbeat(mw.volname, mw.clientID, r)
+			if err == proto.ErrClientSessionNotExists {
+				log.LogWarnf("keepSession: volume(%v) client(%v) session lost, register again", mw.volname, mw.clientID)
+				session, err = mw.mc.ClientAPI().RegisterClientSession(mw.volname, mw.clientID, r)
+			}
+			if err != nil {
+				// the locks and the orphan inodes of the client are released once the session expired
+				mw.onAsyncTaskError.OnError(err)
+				log.LogErrorf("keepSession: volume(%v) client(%v) err(%v)", mw.volname, mw.clientID, err)
+			} else {
+				mw.updateSession(session)
+				interval = heartbeatInterval(session)
+			}
+			t.Reset(interval)
+		case <-mw.closeCh:
+			return
+		}
+	}
+}
+
+func (mw *MetaWrapper) updateSession(session *proto.ClientSessionView) {
+	if old := atomic.SwapUint32(&mw.capabilities, session.Capabilities); old != session.Capabilities {
+		log.LogInfof("updateSession: volume(%v) client(%v) capabilities(%v) -> (%v)", mw.volname, mw.clientID, old, session.Capabilities)
+	}
+	if session.Volume != mw.sessionVolume {
+		mw.sessionVolume = session.Volume
+		if session.Volume != mw.volname {
+			// the former name keeps working as an alias of the renamed volume until it is deleted
+			log.LogWarnf("updateSession: volume(%v) client(%v) renamed to (%v)", mw.volname, mw.clientID, session.Volume)
+		}
+	}
+}
+
+func heartbeatInterval(session *proto.ClientSessionView) time.Duration {
+	if session.Lease <= 0 {
+		return defaultSessionHeartbeatInterval
+	}
+	return time.Duration(session.Lease) * time.Second / 3
+}
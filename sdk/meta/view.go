@@ -38,11 +38,15 @@ const (
 )
 
 type VolumeView struct {
-	Name           string
-	Owner          string
-	MetaPartitions []*MetaPartition
-	OSSSecure      *OSSSecure
-	CreateTime     int64
+	Name            string
+	Owner           string
+	MetaPartitions  []*MetaPartition
+	OSSSecure       *OSSSecure
+	CreateTime      int64
+	TrashDays       uint32
+	CaseInsensitive bool
+	Flat            bool
+	PermPriority    string
 }
 
 type OSSSecure struct {
@@ -87,11 +91,15 @@ func (mw *MetaWrapper) fetchVolumeView() (view *VolumeView, err error) {
 	}
 	var convert = func(volView *proto.VolView) *VolumeView {
 		result := &VolumeView{
-			Name:           volView.Name,
-			Owner:          volView.Owner,
-			MetaPartitions: make([]*MetaPartition, len(volView.MetaPartitions)),
-			OSSSecure:      &OSSSecure{},
-			CreateTime:     volView.CreateTime,
+			Name:            volView.Name,
+			Owner:           volView.Owner,
+			MetaPartitions:  make([]*MetaPartition, len(volView.MetaPartitions)),
+			OSSSecure:       &OSSSecure{},
+			CreateTime:      volView.CreateTime,
+			TrashDays:       volView.TrashDays,
+			CaseInsensitive: volView.CaseInsensitive,
+			Flat:            volView.Flat,
+			PermPriority:    volView.PermissionPriority,
 		}
 		if volView.OSSSecure != nil {
 			result.OSSSecure.AccessKey = volView.OSSSecure.AccessKey
@@ -124,6 +132,33 @@ func (mw *MetaWrapper) updateClusterInfo() (err error) {
 		info.Cluster, info.Ip)
 	mw.cluster = info.Cluster
 	mw.localIP = info.Ip
+	mw.accessTokenEnabled = info.AccessTokenEnabled
+	return
+}
+
+// updateVolAccessToken gets a new access token of the volume from the master if the current one
+// is about to expire. The token is presented on the new connections to the meta nodes and the data nodes.
+func (mw *MetaWrapper) updateVolAccessToken() (err error) {
+	if !mw.accessTokenEnabled || proto.NodeAccessTokenEnabled() {
+		return
+	}
+	if mw.volAccessToken != nil && time.Until(time.Unix(mw.volAccessToken.Expire, 0)) > RefreshVolAccessTokenBefore {
+		return
+	}
+	var authKey string
+	if mw.owner != "" {
+		if authKey, err = calculateAuthKey(mw.owner); err != nil {
+			return
+		}
+	}
+	var token *proto.VolAccessToken
+	if token, err = mw.mc.ClientAPI().GetVolAccessToken(mw.volname, authKey, mw.tokenKey); err != nil {
+		log.LogWarnf("updateVolAccessToken: get access token fail: volume(%v) err(%v)", mw.volname, err)
+		return
+	}
+	mw.volAccessToken = token
+	proto.SetVolAccessToken(token)
+	log.LogInfof("updateVolAccessToken: volume(%v) tokenType(%v) expire(%v)", mw.volname, token.TokenType, token.Expire)
 	return
 }
 
@@ -174,6 +209,14 @@ func (mw *MetaWrapper) updateMetaPartitions() error {
 	}
 	mw.ossSecure = view.OSSSecure
 	mw.volCreateTime = view.CreateTime
+	mw.flat = view.Flat
+	mw.permPriority.Store(view.PermPriority)
+	atomic.StoreUint32(&mw.trashDays, view.TrashDays)
+	if view.CaseInsensitive {
+		atomic.StoreUint32(&mw.caseInsensitive, 1)
+	} else {
+		atomic.StoreUint32(&mw.caseInsensitive, 0)
+	}
 
 	if len(rwPartitions) == 0 {
 		log.LogInfof("updateMetaPartition: no valid partitions")
@@ -223,6 +266,10 @@ func (mw *MetaWrapper) refresh() {
 				mw.onAsyncTaskError.OnError(err)
 				log.LogErrorf("updateVolStatInfo fail cause: %v", err)
 			}
+			if err = mw.updateVolAccessToken(); err != nil {
+				mw.onAsyncTaskError.OnError(err)
+				log.LogErrorf("updateVolAccessToken fail cause: %v", err)
+			}
 			t.Reset(RefreshMetaPartitionsInterval)
 		case <-mw.forceUpdate:
 			log.LogInfof("Start forceUpdateMetaPartitions")
@@ -66,6 +66,7 @@ type Extent struct {
 	dataSize   int64
 	hasClose   int32
 	header     []byte
+	cipher     *ExtentCipher
 	sync.Mutex
 }
 
@@ -78,6 +79,26 @@ func NewExtentInCore(name string, extentID uint64) *Extent {
 	return e
 }
 
+// readAt reads the data of the extent, and decrypts it if the extent is encrypted.
+func (e *Extent) readAt(data []byte, offset int64) (n int, err error) {
+	n, err = e.file.ReadAt(data, offset)
+	if e.cipher != nil && n > 0 {
+		e.cipher.XORKeyStream(e.extentID, offset, data[:n], data[:n])
+	}
+	return
+}
+
+// writeAt writes the data to the extent, and encrypts it if the extent is encrypted.
+// The data of the caller is never modified since it is still used to reply and forward.
+func (e *Extent) writeAt(data []byte, offset int64) (n int, err error) {
+	if e.cipher == nil {
+		return e.file.WriteAt(data, offset)
+	}
+	encrypted := make([]byte, len(data))
+	e.cipher.XORKeyStream(e.extentID, offset, encrypted, data)
+	return e.file.WriteAt(encrypted, offset)
+}
+
 func (e *Extent) HasClosed() bool {
 	return atomic.LoadInt32(&e.hasClose) == ExtentHasClose
 }
@@ -186,7 +207,7 @@ func (e *Extent) WriteTiny(data []byte, offset, size int64, crc uint32, writeTyp
 		return ParameterMismatchError
 	}
 
-	if _, err = e.file.WriteAt(data[:size], int64(offset)); err != nil {
+	if _, err = e.writeAt(data[:size], int64(offset)); err != nil {
 		return
 	}
 	if isSync {
@@ -216,7 +237,7 @@ func (e *Extent) Write(data []byte, offset, size int64, crc uint32, writeType in
 	if err = e.checkOffsetAndSize(offset, size); err != nil {
 		return
 	}
-	if _, err = e.file.WriteAt(data[:size], int64(offset)); err != nil {
+	if _, err = e.writeAt(data[:size], int64(offset)); err != nil {
 		return
 	}
 	blockNo := offset / util.BlockSize
@@ -255,16 +276,31 @@ func (e *Extent) Read(data []byte, offset, size int64, isRepairRead bool) (crc u
 	if err = e.checkOffsetAndSize(offset, size); err != nil {
 		return
 	}
-	if _, err = e.file.ReadAt(data[:size], offset); err != nil {
+	if _, err = e.readAt(data[:size], offset); err != nil {
 		return
 	}
-	crc = crc32.ChecksumIEEE(data)
+	crc = crc32.ChecksumIEEE(data[:size])
+	err = e.verifyBlockCrc(offset, size, crc)
 	return
 }
 
+// verifyBlockCrc checks the data read from a whole block against the crc stored in the header,
+// so that the corruption on the disk is not sent to the client with a valid packet crc.
+// A block rewritten concurrently may be reported as corrupt, which only makes the client
+// read another replica.
+func (e *Extent) verifyBlockCrc(offset, size int64, crc uint32) error {
+	if offset%util.BlockSize != 0 || (size != util.BlockSize && offset+size != e.Size()) {
+		return nil
+	}
+	if expectCrc := e.blockCrc(int(offset / util.BlockSize)); expectCrc != 0 && expectCrc != crc {
+		return CrcMismatchError
+	}
+	return nil
+}
+
 // ReadTiny read data from a tiny extent.
 func (e *Extent) ReadTiny(data []byte, offset, size int64, isRepairRead bool) (crc uint32, err error) {
-	_, err = e.file.ReadAt(data[:size], offset)
+	_, err = e.readAt(data[:size], offset)
 	if isRepairRead && err == io.EOF {
 		err = nil
 	}
@@ -308,7 +344,7 @@ func (e *Extent) autoComputeExtentCrc(crcFunc UpdateCrcFunc) (crc uint32, err er
 		}
 		bdata := make([]byte, util.BlockSize)
 		offset := int64(blockNo * util.BlockSize)
-		readN, err := e.file.ReadAt(bdata[:util.BlockSize], offset)
+		readN, err := e.readAt(bdata[:util.BlockSize], offset)
 		if readN == 0 && err != nil {
 			break
 		}
@@ -330,6 +366,36 @@ const (
 	FallocFLPunchHole = 2
 )
 
+// punchHole releases the space of the range of a normal extent, the range is shrunk to the pages
+// inside it except that the end of the extent is always covered. The size of the extent is kept,
+// and the crcs of the blocks in the range are reset since the data of them is changed.
+func (e *Extent) punchHole(offset, size int64, crcFunc UpdateCrcFunc) (released int64, err error) {
+	start, end := offset, offset+size
+	if start%PageSize != 0 {
+		start += PageSize - start%PageSize
+	}
+	if dataSize := e.Size(); end >= dataSize {
+		end = dataSize
+		if end%PageSize != 0 {
+			end += PageSize - end%PageSize
+		}
+	} else {
+		end -= end % PageSize
+	}
+	if start >= end {
+		return
+	}
+	if err = fallocate(int(e.file.Fd()), FallocFLPunchHole|FallocFLKeepSize, start, end-start); err != nil {
+		return
+	}
+	for blockNo := start / util.BlockSize; blockNo <= (end-1)/util.BlockSize; blockNo++ {
+		if err = crcFunc(e, int(blockNo), 0); err != nil {
+			return
+		}
+	}
+	return end - start, nil
+}
+
 // DeleteTiny deletes a tiny extent.
 func (e *Extent) DeleteTiny(offset, size int64) (hasDelete bool, err error) {
 	if int(offset)%PageSize != 0 {
@@ -390,7 +456,7 @@ func (e *Extent) TinyExtentRecover(data []byte, offset, size int64, crc uint32,
 		}
 		err = fallocate(int(e.file.Fd()), FallocFLPunchHole|FallocFLKeepSize, offset, size)
 	} else {
-		_, err = e.file.WriteAt(data[:size], int64(offset))
+		_, err = e.writeAt(data[:size], int64(offset))
 	}
 	if err != nil {
 		return
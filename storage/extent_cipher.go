@@ -0,0 +1,62 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// ExtentCipher encrypts the extent data of an encrypted volume with AES-CTR.
+// The counter of a byte is derived from the partition, the extent and the offset of the byte,
+// so that any range of an extent can be encrypted or decrypted independently, and all the
+// replicas of a data partition store the same cipher text.
+// The crc of blocks and packets are always computed over the plain text.
+//
+// Note that the key stream of a range is reused when the range is overwritten, which is
+// acceptable for the threat of a stolen disk but not for an attacker who can watch the disk.
+type ExtentCipher struct {
+	block       cipher.Block
+	partitionID uint64
+}
+
+// NewExtentCipher creates a new extent cipher with the data key of the volume.
+func NewExtentCipher(key []byte, partitionID uint64) (c *ExtentCipher, err error) {
+	var block cipher.Block
+	if block, err = aes.NewCipher(key); err != nil {
+		return nil, fmt.Errorf("NewExtentCipher partition(%v) err(%v)", partitionID, err)
+	}
+	return &ExtentCipher{block: block, partitionID: partitionID}, nil
+}
+
+// XORKeyStream encrypts or decrypts the src read from or written to the offset of the extent.
+func (c *ExtentCipher) XORKeyStream(extentID uint64, offset int64, dst, src []byte) {
+	iv := make([]byte, aes.BlockSize)
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf[:8], c.partitionID)
+	binary.BigEndian.PutUint64(buf[8:], extentID)
+	nonce := sha256.Sum256(buf)
+	copy(iv[:8], nonce[:8])
+	binary.BigEndian.PutUint64(iv[8:], uint64(offset)/aes.BlockSize)
+	stream := cipher.NewCTR(c.block, iv)
+	if skip := int(offset % aes.BlockSize); skip != 0 {
+		pad := make([]byte, skip)
+		stream.XORKeyStream(pad, pad)
+	}
+	stream.XORKeyStream(dst, src)
+}
@@ -0,0 +1,101 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+
+	"github.com/chubaofs/chubaofs/util"
+)
+
+// scrubbableExtent returns the extent whose block crcs can be verified, which is a normal extent
+// not modified for a while so that the crcs of all its blocks have been computed.
+func (s *ExtentStore) scrubbableExtent(extentID uint64) (e *Extent, err error) {
+	s.eiMutex.RLock()
+	ei := s.extentInfoMap[extentID]
+	s.eiMutex.RUnlock()
+	if ei == nil || IsTinyExtent(extentID) || ei.IsDeleted || ei.Crc == 0 {
+		return nil, nil
+	}
+	return s.extentWithHeader(ei)
+}
+
+func (e *Extent) blockCrc(blockNo int) uint32 {
+	return binary.BigEndian.Uint32(e.header[blockNo*util.PerBlockCrcSize : (blockNo+1)*util.PerBlockCrcSize])
+}
+
+func (e *Extent) blockCount() int {
+	blockCnt := int(e.Size() / util.BlockSize)
+	if e.Size()%util.BlockSize != 0 {
+		blockCnt++
+	}
+	return blockCnt
+}
+
+// ScrubExtent reads the blocks of the extent and verifies them with the stored block crcs,
+// and returns the numbers of the corrupt blocks. The extents whose crcs are not computed yet are skipped.
+// The wait function is called with the size of every block before reading it, so that the caller
+// can limit the bandwidth of scrubbing.
+func (s *ExtentStore) ScrubExtent(extentID uint64, wait func(size int)) (corruptBlocks []int, err error) {
+	var e *Extent
+	if e, err = s.scrubbableExtent(extentID); err != nil || e == nil {
+		return
+	}
+	data := make([]byte, util.BlockSize)
+	size := e.Size()
+	for blockNo := 0; blockNo < e.blockCount(); blockNo++ {
+		expectCrc := e.blockCrc(blockNo)
+		if expectCrc == 0 {
+			continue
+		}
+		offset := int64(blockNo) * util.BlockSize
+		length := int(util.Min(util.BlockSize, int(size-offset)))
+		wait(length)
+		var n int
+		if n, err = e.readAt(data[:length], offset); err != nil && err != io.EOF {
+			return
+		}
+		err = nil
+		// the block may be rewritten while scrubbing, and the crc is reset
+		if crc32.ChecksumIEEE(data[:n]) != expectCrc && e.blockCrc(blockNo) == expectCrc {
+			corruptBlocks = append(corruptBlocks, blockNo)
+		}
+	}
+	return
+}
+
+// RepairBlock overwrites the block of the extent with the data fetched from another replica,
+// the data is accepted only if it matches the stored block crc.
+func (s *ExtentStore) RepairBlock(extentID uint64, blockNo int, data []byte) (err error) {
+	var e *Extent
+	if e, err = s.scrubbableExtent(extentID); err != nil {
+		return
+	}
+	if e == nil {
+		return ExtentNotFoundError
+	}
+	if blockNo >= e.blockCount() || len(data) > util.BlockSize {
+		return NewParameterMismatchErr("block out of the extent")
+	}
+	if crc32.ChecksumIEEE(data) != e.blockCrc(blockNo) {
+		return CrcMismatchError
+	}
+	if _, err = e.writeAt(data, int64(blockNo)*util.BlockSize); err != nil {
+		return
+	}
+	return e.file.Sync()
+}
@@ -127,16 +127,18 @@ type ExtentStore struct {
 	verifyExtentFp                    *os.File
 	hasAllocSpaceExtentIDOnVerfiyFile uint64
 	hasDeleteNormalExtentsCache       sync.Map
+	cipher                            *ExtentCipher // cipher of the extent data, nil if the volume is not encrypted
 }
 
 func MkdirAll(name string) (err error) {
 	return os.MkdirAll(name, 0755)
 }
 
-func NewExtentStore(dataDir string, partitionID uint64, storeSize int) (s *ExtentStore, err error) {
+func NewExtentStore(dataDir string, partitionID uint64, storeSize int, cipher *ExtentCipher) (s *ExtentStore, err error) {
 	s = new(ExtentStore)
 	s.dataPath = dataDir
 	s.partitionID = partitionID
+	s.cipher = cipher
 	if err = MkdirAll(dataDir); err != nil {
 		return nil, fmt.Errorf("NewExtentStore [%v] err[%v]", dataDir, err)
 	}
@@ -234,6 +236,7 @@ func (s *ExtentStore) Create(extentID uint64) (err error) {
 		return err
 	}
 	e = NewExtentInCore(name, extentID)
+	e.cipher = s.cipher
 	e.header = make([]byte, util.BlockHeaderSize)
 	err = e.InitToFS()
 	if err != nil {
@@ -415,6 +418,30 @@ func (s *ExtentStore) MarkDelete(extentID uint64, offset, size int64) (err error
 	return
 }
 
+// PunchHole releases the space of the range of a normal extent which is no longer referenced by any file.
+// The offsets of the live data in the extent do not change, so the extent keys referring to them are still valid.
+func (s *ExtentStore) PunchHole(extentID uint64, offset, size int64) (released int64, err error) {
+	if IsTinyExtent(extentID) {
+		return 0, NewParameterMismatchErr(fmt.Sprintf("extent(%v) is a tiny extent", extentID))
+	}
+	s.eiMutex.RLock()
+	ei := s.extentInfoMap[extentID]
+	s.eiMutex.RUnlock()
+	if ei == nil || ei.IsDeleted {
+		return
+	}
+	var e *Extent
+	if e, err = s.extentWithHeader(ei); err != nil {
+		return
+	}
+	if released, err = e.punchHole(offset, size, s.PersistenceBlockCrc); err != nil || released == 0 {
+		return
+	}
+	// the crc of the extent is computed again with the new block crcs
+	atomic.StoreUint32(&ei.Crc, 0)
+	return
+}
+
 func (s *ExtentStore) PutNormalExtentToDeleteCache(extentID uint64) {
 	s.hasDeleteNormalExtentsCache.Store(extentID, time.Now().Unix())
 }
@@ -816,6 +843,7 @@ func (s *ExtentStore) GetExtentCount() (count int) {
 func (s *ExtentStore) loadExtentFromDisk(extentID uint64, putCache bool) (e *Extent, err error) {
 	name := path.Join(s.dataPath, strconv.Itoa(int(extentID)))
 	e = NewExtentInCore(name, extentID)
+	e.cipher = s.cipher
 	if err = e.RestoreFromFS(); err != nil {
 		err = fmt.Errorf("restore from file %v putCache %v system: %v", name, putCache, err)
 		return
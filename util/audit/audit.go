@@ -0,0 +1,312 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package audit records the mutations of the filesystem, e.g. create, delete, rename and setattr,
+// into the rotating local files, and optionally ships them to an HTTP sink.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	FileNameSuffix     = "_audit.log"
+	RolledTimeFormat   = "20060102150405"
+	DefaultRollingSize = 100 * 1024 * 1024
+	DefaultMaxBackups  = 30
+
+	entryQueueSize    = 64 * 1024
+	flushInterval     = time.Second
+	sinkBatchSize     = 1024
+	sinkQueueSize     = 16
+	sinkRetryTimes    = 3
+	sinkRetryInterval = time.Second
+	sinkTimeout       = 10 * time.Second
+)
+
+// Result of the succeeded operations.
+const ResultOK = "ok"
+
+// Entry is an audit record of a mutation of the filesystem.
+type Entry struct {
+	Time   string `json:"time"`
+	Volume string `json:"volume"`
+	Op     string `json:"op"`
+	Path   string `json:"path"`
+	Dst    string `json:"dst,omitempty"` // the new path of rename and link
+	Inode  uint64 `json:"inode,omitempty"`
+	Uid    uint32 `json:"uid"`
+	Gid    uint32 `json:"gid"`
+	Pid    uint32 `json:"pid"`
+	Detail string `json:"detail,omitempty"` // e.g. the mode of chmod and the size of truncate
+	Result string `json:"result"`
+}
+
+// Auditor writes the entries in JSON lines to the local file, and posts them to the sink in batches.
+// The entries are dropped rather than blocking the filesystem if the auditor falls behind.
+type Auditor struct {
+	volume      string
+	fileName    string
+	rollingSize int64
+	maxBackups  int
+	sinkURL     string
+
+	file    *os.File
+	writer  *bufio.Writer
+	size    int64
+	batch   bytes.Buffer
+	lines   int // the number of entries in the batch
+	dropped uint64
+
+	entryC chan *Entry
+	sinkC  chan []byte
+	stopC  chan struct{}
+	wg     sync.WaitGroup
+}
+
+var gAuditor *Auditor
+
+// InitAudit starts the auditor of the volume, which writes to <dir>/<module>_audit.log,
+// and posts the entries to sinkURL in the body of application/x-ndjson if it is not empty.
+func InitAudit(dir, module, volume, sinkURL string) (a *Auditor, err error) {
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	a = &Auditor{
+		volume:      volume,
+		fileName:    path.Join(dir, module+FileNameSuffix),
+		rollingSize: DefaultRollingSize,
+		maxBackups:  DefaultMaxBackups,
+		sinkURL:     sinkURL,
+		entryC:      make(chan *Entry, entryQueueSize),
+		sinkC:       make(chan []byte, sinkQueueSize),
+		stopC:       make(chan struct{}),
+	}
+	if err = a.openFile(); err != nil {
+		return nil, err
+	}
+	a.wg.Add(1)
+	go a.writeLoop()
+	if a.sinkURL != "" {
+		a.wg.Add(1)
+		go a.sinkLoop()
+	}
+	gAuditor = a
+	return
+}
+
+// StopAudit flushes the pending entries and stops the auditor.
+func StopAudit() {
+	if gAuditor == nil {
+		return
+	}
+	close(gAuditor.stopC)
+	gAuditor.wg.Wait()
+	gAuditor = nil
+}
+
+// Enabled tells if the audit is enabled.
+func Enabled() bool {
+	return gAuditor != nil
+}
+
+// Log records the entry, the time and the volume of the entry are filled by the auditor.
+func Log(e *Entry) {
+	a := gAuditor
+	if a == nil {
+		return
+	}
+	e.Time = time.Now().Format(time.RFC3339Nano)
+	e.Volume = a.volume
+	select {
+	case a.entryC <- e:
+	default:
+		atomic.AddUint64(&a.dropped, 1)
+	}
+}
+
+// ResultOf returns the result of the operation with the error.
+func ResultOf(err error) string {
+	if err == nil {
+		return ResultOK
+	}
+	return err.Error()
+}
+
+func (a *Auditor) openFile() (err error) {
+	if a.file, err = os.OpenFile(a.fileName, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0640); err != nil {
+		return
+	}
+	var info os.FileInfo
+	if info, err = a.file.Stat(); err != nil {
+		a.file.Close()
+		return
+	}
+	a.size = info.Size()
+	a.writer = bufio.NewWriter(a.file)
+	return
+}
+
+func (a *Auditor) writeLoop() {
+	defer a.wg.Done()
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case e := <-a.entryC:
+			a.write(e)
+		case <-ticker.C:
+			a.flush()
+		case <-a.stopC:
+			for len(a.entryC) > 0 {
+				a.write(<-a.entryC)
+			}
+			a.flush()
+			a.file.Close()
+			if a.sinkURL != "" {
+				close(a.sinkC)
+			}
+			return
+		}
+	}
+}
+
+func (a *Auditor) write(e *Entry) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		log.LogErrorf("audit: marshal entry(%v) err(%v)", e, err)
+		return
+	}
+	data = append(data, '\n')
+	if a.size+int64(len(data)) > a.rollingSize {
+		a.rotate()
+	}
+	if _, err = a.writer.Write(data); err != nil {
+		log.LogErrorf("audit: write file(%v) err(%v)", a.fileName, err)
+		return
+	}
+	a.size += int64(len(data))
+	if a.sinkURL != "" {
+		a.batch.Write(data)
+		if a.lines++; a.lines >= sinkBatchSize {
+			a.shipBatch()
+		}
+	}
+}
+
+func (a *Auditor) flush() {
+	if err := a.writer.Flush(); err != nil {
+		log.LogErrorf("audit: flush file(%v) err(%v)", a.fileName, err)
+	}
+	if a.sinkURL != "" && a.batch.Len() > 0 {
+		a.shipBatch()
+	}
+	if dropped := atomic.SwapUint64(&a.dropped, 0); dropped > 0 {
+		log.LogWarnf("audit: %v entries are dropped since the auditor falls behind", dropped)
+	}
+}
+
+func (a *Auditor) shipBatch() {
+	batch := make([]byte, a.batch.Len())
+	copy(batch, a.batch.Bytes())
+	a.batch.Reset()
+	a.lines = 0
+	select {
+	case a.sinkC <- batch:
+	default:
+		log.LogWarnf("audit: sink(%v) falls behind, a batch of %v bytes is only kept in the local file", a.sinkURL, len(batch))
+	}
+}
+
+// rotate renames the current file to <file>.<time> and removes the oldest files beyond maxBackups.
+func (a *Auditor) rotate() {
+	a.writer.Flush()
+	a.file.Close()
+	rolled := a.fileName + "." + time.Now().Format(RolledTimeFormat)
+	if err := os.Rename(a.fileName, rolled); err != nil {
+		log.LogErrorf("audit: rotate file(%v) err(%v)", a.fileName, err)
+	}
+	if err := a.openFile(); err != nil {
+		log.LogErrorf("audit: reopen file(%v) err(%v)", a.fileName, err)
+		return
+	}
+	a.removeBackups()
+}
+
+func (a *Auditor) removeBackups() {
+	dir, base := path.Split(a.fileName)
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		log.LogErrorf("audit: read dir(%v) err(%v)", dir, err)
+		return
+	}
+	var backups []string
+	for _, info := range infos {
+		if strings.HasPrefix(info.Name(), base+".") {
+			backups = append(backups, info.Name())
+		}
+	}
+	if len(backups) <= a.maxBackups {
+		return
+	}
+	sort.Strings(backups)
+	for _, name := range backups[:len(backups)-a.maxBackups] {
+		if err = os.Remove(path.Join(dir, name)); err != nil {
+			log.LogErrorf("audit: remove file(%v) err(%v)", name, err)
+		}
+	}
+}
+
+func (a *Auditor) sinkLoop() {
+	defer a.wg.Done()
+	client := &http.Client{Timeout: sinkTimeout}
+	for batch := range a.sinkC {
+		var err error
+		for i := 0; i < sinkRetryTimes; i++ {
+			if err = a.post(client, batch); err == nil {
+				break
+			}
+			time.Sleep(sinkRetryInterval)
+		}
+		if err != nil {
+			log.LogErrorf("audit: post a batch of %v bytes to sink(%v) err(%v)", len(batch), a.sinkURL, err)
+		}
+	}
+}
+
+func (a *Auditor) post(client *http.Client, batch []byte) (err error) {
+	var resp *http.Response
+	if resp, err = client.Post(a.sinkURL, "application/x-ndjson", bytes.NewReader(batch)); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("status(%v)", resp.Status)
+	}
+	return
+}
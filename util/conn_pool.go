@@ -21,7 +21,7 @@ import (
 )
 
 type Object struct {
-	conn *net.TCPConn
+	conn net.Conn
 	idle int64
 }
 
@@ -69,19 +69,11 @@ func NewConnectPoolWithTimeout(idleConnTimeout time.Duration, connectTimeout int
 	return cp
 }
 
-func DailTimeOut(target string, timeout time.Duration) (c *net.TCPConn, err error) {
-	var connect net.Conn
-	connect, err = net.DialTimeout("tcp", target, timeout)
-	if err == nil {
-		conn := connect.(*net.TCPConn)
-		conn.SetKeepAlive(true)
-		conn.SetNoDelay(true)
-		c = conn
-	}
-	return
+func DailTimeOut(target string, timeout time.Duration) (c net.Conn, err error) {
+	return DialTLS(target, timeout)
 }
 
-func (cp *ConnectPool) GetConnect(targetAddr string) (c *net.TCPConn, err error) {
+func (cp *ConnectPool) GetConnect(targetAddr string) (c net.Conn, err error) {
 	cp.RLock()
 	pool, ok := cp.pools[targetAddr]
 	cp.RUnlock()
@@ -98,7 +90,7 @@ func (cp *ConnectPool) GetConnect(targetAddr string) (c *net.TCPConn, err error)
 	return pool.GetConnectFromPool()
 }
 
-func (cp *ConnectPool) PutConnect(c *net.TCPConn, forceClose bool) {
+func (cp *ConnectPool) PutConnect(c net.Conn, forceClose bool) {
 	if c == nil {
 		return
 	}
@@ -190,11 +182,8 @@ func NewPool(min, max int, timeout, connectTimeout int64, target string) (p *Poo
 
 func (p *Pool) initAllConnect() {
 	for i := 0; i < p.mincap; i++ {
-		c, err := net.Dial("tcp", p.target)
+		conn, err := DialTLS(p.target, time.Duration(p.connectTimeout)*time.Second)
 		if err == nil {
-			conn := c.(*net.TCPConn)
-			conn.SetKeepAlive(true)
-			conn.SetNoDelay(true)
 			o := &Object{conn: conn, idle: time.Now().UnixNano()}
 			p.PutConnectObjectToPool(o)
 		}
@@ -241,19 +230,11 @@ func (p *Pool) ReleaseAll() {
 	}
 }
 
-func (p *Pool) NewConnect(target string) (c *net.TCPConn, err error) {
-	var connect net.Conn
-	connect, err = net.DialTimeout("tcp", p.target, time.Duration(p.connectTimeout)*time.Second)
-	if err == nil {
-		conn := connect.(*net.TCPConn)
-		conn.SetKeepAlive(true)
-		conn.SetNoDelay(true)
-		c = conn
-	}
-	return
+func (p *Pool) NewConnect(target string) (c net.Conn, err error) {
+	return DialTLS(p.target, time.Duration(p.connectTimeout)*time.Second)
 }
 
-func (p *Pool) GetConnectFromPool() (c *net.TCPConn, err error) {
+func (p *Pool) GetConnectFromPool() (c net.Conn, err error) {
 	var (
 		o *Object
 	)
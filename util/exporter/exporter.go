@@ -131,5 +131,6 @@ func collect() {
 	go collectCounter()
 	go collectGauge()
 	go collectTP()
+	go collectHistogram()
 	go collectAlarm()
 }
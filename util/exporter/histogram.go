@@ -0,0 +1,103 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exporter
+
+import (
+	"sync"
+
+	"github.com/chubaofs/chubaofs/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	HistogramGroup sync.Map
+	HistogramCh    chan *Histogram
+
+	// LatencyBuckets are the buckets of the latencies in seconds, from 100us to about 13s.
+	LatencyBuckets = prometheus.ExponentialBuckets(0.0001, 2, 18)
+)
+
+func collectHistogram() {
+	HistogramCh = make(chan *Histogram, ChSize)
+	for {
+		m := <-HistogramCh
+		metric := m.Metric()
+		metric.Observe(m.val)
+	}
+}
+
+// Histogram samples the observations, e.g. the latencies of the requests, into the buckets.
+type Histogram struct {
+	Gauge
+	buckets []float64
+}
+
+// NewHistogram returns a new histogram with the buckets, LatencyBuckets if buckets is nil.
+func NewHistogram(name string, buckets []float64) (h *Histogram) {
+	if !enabledPrometheus {
+		return
+	}
+	if buckets == nil {
+		buckets = LatencyBuckets
+	}
+	h = new(Histogram)
+	h.name = metricsName(name)
+	h.buckets = buckets
+	return
+}
+
+func (h *Histogram) Observe(val float64) {
+	if !enabledPrometheus {
+		return
+	}
+	h.val = val
+	h.publish()
+}
+
+func (h *Histogram) ObserveWithLabels(val float64, labels map[string]string) {
+	if !enabledPrometheus {
+		return
+	}
+	h.labels = labels
+	h.Observe(val)
+}
+
+func (h *Histogram) publish() {
+	select {
+	case HistogramCh <- h:
+	default:
+	}
+}
+
+func (h *Histogram) Metric() prometheus.Histogram {
+	metric := prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:        h.name,
+			ConstLabels: h.labels,
+			Buckets:     h.buckets,
+		})
+	key := h.Key()
+	actualMetric, load := HistogramGroup.LoadOrStore(key, metric)
+	if !load {
+		err := prometheus.Register(actualMetric.(prometheus.Collector))
+		if err == nil {
+			log.LogInfof("register metric %v", h.Name())
+		} else {
+			log.LogErrorf("register metric %v, %v", h.Name(), err)
+		}
+	}
+
+	return actualMetric.(prometheus.Histogram)
+}
@@ -19,7 +19,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"log/syslog"
 	"math"
 	"net/http"
 	"os"
@@ -229,6 +231,9 @@ func newLogObject(writer *asyncWriter, prefix string, flag int) *LogObject {
 // Log defines the log struct.
 type Log struct {
 	dir            string
+	format         string
+	moduleLevels   map[string]Level // levels of the source directories overriding the level
+	sink           *sink
 	errorLogger    *LogObject
 	warnLogger     *LogObject
 	debugLogger    *LogObject
@@ -258,7 +263,21 @@ var LogDir string
 
 // InitLog initializes the log.
 func InitLog(dir, module string, level Level, rotate *LogRotate) (*Log, error) {
+	return initLog(dir, module, level, rotate, nil)
+}
+
+// InitLogWithOptions initializes the log with the options, e.g. read from the config file by ParseOptions.
+func InitLogWithOptions(dir, module string, level Level, opts *Options) (*Log, error) {
+	return initLog(dir, module, level, nil, opts)
+}
+
+func initLog(dir, module string, level Level, rotate *LogRotate, opts *Options) (*Log, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
 	l := new(Log)
+	l.format = opts.Format
+	l.moduleLevels = opts.ModuleLevels
 	dir = path.Join(dir, module)
 	l.dir = dir
 	LogDir = dir
@@ -285,8 +304,17 @@ func InitLog(dir, module string, level Level, rotate *LogRotate) (*Log, error) {
 			minRollingSize = DefaultMinRollingSize
 		}
 		rotate.SetRollingSizeMb(int64(math.Min(float64(minRollingSize), float64(DefaultRollingSize))))
+		if opts.RollingSizeMB > 0 {
+			rotate.SetRollingSizeMb(opts.RollingSizeMB * 1024 * 1024)
+		}
+		rotate.SetRotateInterval(opts.RotateInterval)
 	}
 	l.rotate = rotate
+	if opts.Sink != "" {
+		if l.sink, err = newSink(opts.Sink, module); err != nil {
+			return nil, err
+		}
+	}
 	err = l.initLog(dir, module, level)
 	if err != nil {
 		return nil, err
@@ -300,21 +328,29 @@ func InitLog(dir, module string, level Level, rotate *LogRotate) (*Log, error) {
 
 func (l *Log) initLog(logDir, module string, level Level) error {
 	logOpt := log.LstdFlags | log.Lmicroseconds
+	if l.format == FormatJSON {
+		// the time is a field of the record
+		logOpt = 0
+	}
 
-	newLog := func(logFileName string) (newLogger *LogObject, err error) {
+	newLog := func(logFileName string, severity syslog.Priority) (newLogger *LogObject, err error) {
 		logName := path.Join(logDir, module+logFileName)
 		w, err := newAsyncWriter(logName, l.rotate.rollingSize)
 		if err != nil {
 			return
 		}
 		newLogger = newLogObject(w, "", logOpt)
+		if l.sink != nil {
+			newLogger.SetOutput(io.MultiWriter(w, l.sink.writer(severity)))
+		}
 		return
 	}
 	var err error
 	logHandles := [...]**LogObject{&l.debugLogger, &l.infoLogger, &l.warnLogger, &l.errorLogger, &l.readLogger, &l.updateLogger, &l.criticalLogger}
 	logNames := [...]string{DebugLogFileName, InfoLogFileName, WarnLogFileName, ErrLogFileName, ReadLogFileName, UpdateLogFileName, CriticalLogFileName}
+	severities := [...]syslog.Priority{syslog.LOG_DEBUG, syslog.LOG_INFO, syslog.LOG_WARNING, syslog.LOG_ERR, syslog.LOG_INFO, syslog.LOG_INFO, syslog.LOG_CRIT}
 	for i := range logHandles {
-		if *logHandles[i], err = newLog(logNames[i]); err != nil {
+		if *logHandles[i], err = newLog(logNames[i], severities[i]); err != nil {
 			return err
 		}
 	}
@@ -336,9 +372,26 @@ func (l *Log) SetPrefix(s, level string) string {
 		}
 	}
 	file = short
+	if l.format == FormatJSON {
+		return jsonRecord(strings.Trim(level, "[] "), file+":"+strconv.Itoa(line), s)
+	}
 	return level + " " + file + ":" + strconv.Itoa(line) + ": " + s
 }
 
+// enabled tells whether the logs of the level are written. The level of the source directory
+// of the caller is used if it is set in the module levels.
+func (l *Log) enabled(level Level) bool {
+	lvl := l.level
+	if len(l.moduleLevels) > 0 {
+		if _, file, _, ok := runtime.Caller(2); ok {
+			if moduleLevel, ok := l.moduleLevels[path.Base(path.Dir(file))]; ok {
+				lvl = moduleLevel
+			}
+		}
+	}
+	return level&lvl == lvl
+}
+
 // Flush flushes the log.
 func (l *Log) Flush() {
 	loggers := []*LogObject{
@@ -393,6 +446,14 @@ func SetLogLevel(w http.ResponseWriter, r *http.Request) {
 	buildSuccessResp(w, "set log level success")
 }
 
+// SetLevel changes the level of the logs.
+func SetLevel(level Level) {
+	if gLog == nil {
+		return
+	}
+	gLog.level = level
+}
+
 func buildSuccessResp(w http.ResponseWriter, data interface{}) {
 	buildJSONResp(w, http.StatusOK, data, "")
 }
@@ -429,7 +490,7 @@ func LogWarn(v ...interface{}) {
 	if gLog == nil {
 		return
 	}
-	if WarnLevel&gLog.level != gLog.level {
+	if !gLog.enabled(WarnLevel) {
 		return
 	}
 	s := fmt.Sprintln(v...)
@@ -442,7 +503,7 @@ func LogWarnf(format string, v ...interface{}) {
 	if gLog == nil {
 		return
 	}
-	if WarnLevel&gLog.level != gLog.level {
+	if !gLog.enabled(WarnLevel) {
 		return
 	}
 	s := fmt.Sprintf(format, v...)
@@ -455,7 +516,7 @@ func LogInfo(v ...interface{}) {
 	if gLog == nil {
 		return
 	}
-	if InfoLevel&gLog.level != gLog.level {
+	if !gLog.enabled(InfoLevel) {
 		return
 	}
 	s := fmt.Sprintln(v...)
@@ -468,7 +529,7 @@ func LogInfof(format string, v ...interface{}) {
 	if gLog == nil {
 		return
 	}
-	if InfoLevel&gLog.level != gLog.level {
+	if !gLog.enabled(InfoLevel) {
 		return
 	}
 	s := fmt.Sprintf(format, v...)
@@ -481,7 +542,7 @@ func LogError(v ...interface{}) {
 	if gLog == nil {
 		return
 	}
-	if ErrorLevel&gLog.level != gLog.level {
+	if !gLog.enabled(ErrorLevel) {
 		return
 	}
 	s := fmt.Sprintln(v...)
@@ -494,7 +555,7 @@ func LogErrorf(format string, v ...interface{}) {
 	if gLog == nil {
 		return
 	}
-	if ErrorLevel&gLog.level != gLog.level {
+	if !gLog.enabled(ErrorLevel) {
 		return
 	}
 	s := fmt.Sprintf(format, v...)
@@ -507,7 +568,7 @@ func LogDebug(v ...interface{}) {
 	if gLog == nil {
 		return
 	}
-	if DebugLevel&gLog.level != gLog.level {
+	if !gLog.enabled(DebugLevel) {
 		return
 	}
 	s := fmt.Sprintln(v...)
@@ -520,7 +581,7 @@ func LogDebugf(format string, v ...interface{}) {
 	if gLog == nil {
 		return
 	}
-	if DebugLevel&gLog.level != gLog.level {
+	if !gLog.enabled(DebugLevel) {
 		return
 	}
 	s := fmt.Sprintf(format, v...)
@@ -575,7 +636,7 @@ func LogRead(v ...interface{}) {
 	if gLog == nil {
 		return
 	}
-	if ReadLevel&gLog.level != gLog.level {
+	if !gLog.enabled(ReadLevel) {
 		return
 	}
 	s := fmt.Sprintln(v...)
@@ -588,7 +649,7 @@ func LogReadf(format string, v ...interface{}) {
 	if gLog == nil {
 		return
 	}
-	if ReadLevel&gLog.level != gLog.level {
+	if !gLog.enabled(ReadLevel) {
 		return
 	}
 	s := fmt.Sprintf(format, v...)
@@ -601,7 +662,7 @@ func LogWrite(v ...interface{}) {
 	if gLog == nil {
 		return
 	}
-	if UpdateLevel&gLog.level != gLog.level {
+	if !gLog.enabled(UpdateLevel) {
 		return
 	}
 	s := fmt.Sprintln(v...)
@@ -614,7 +675,7 @@ func LogWritef(format string, v ...interface{}) {
 	if gLog == nil {
 		return
 	}
-	if UpdateLevel&gLog.level != gLog.level {
+	if !gLog.enabled(UpdateLevel) {
 		return
 	}
 	s := fmt.Sprintf(format, v...)
@@ -648,7 +709,12 @@ func (l *Log) checkLogRotation(logDir, module string) {
 		}
 		// check if it is time to rotate
 		now := time.Now()
-		if now.Day() == l.lastRolledTime.Day() {
+		if interval := l.rotate.rotateInterval; interval > 0 {
+			if now.Truncate(interval).Equal(l.lastRolledTime.Truncate(interval)) {
+				time.Sleep(DefaultRollingInterval)
+				continue
+			}
+		} else if now.Day() == l.lastRolledTime.Day() {
 			time.Sleep(DefaultRollingInterval)
 			continue
 		}
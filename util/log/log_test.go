@@ -17,10 +17,18 @@ package log
 // These tests are too simple.
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	_ "net/http/pprof"
+	"os"
+	"path"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/chubaofs/chubaofs/util/config"
 )
 
 func TestLog(t *testing.T) {
@@ -36,3 +44,36 @@ func TestLog(t *testing.T) {
 		time.Sleep(2 * time.Millisecond)
 	}
 }
+
+func TestLogJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfs_log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	opts, err := ParseOptions(config.LoadConfigString(`{"logFormat":"json","logModuleLevels":"log:debug"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the level of the sources under util/log is overridden
+	if _, err = InitLogWithOptions(dir, "cfs", ErrorLevel, opts); err != nil {
+		t.Fatal(err)
+	}
+	LogDebugf("debug %v", 1)
+	LogFlush()
+	data, err := ioutil.ReadFile(path.Join(dir, "cfs", "cfs"+DebugLogFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := &jsonLogRecord{}
+	if err = json.Unmarshal(bytes.TrimSpace(data), record); err != nil {
+		t.Fatalf("unmarshal record(%s) err(%v)", data, err)
+	}
+	if record.Level != "DEBUG" || record.Msg != "debug 1" || !strings.HasPrefix(record.File, "log_test.go:") {
+		t.Fatalf("unexpected record(%+v)", record)
+	}
+
+	if _, err = ParseOptions(config.LoadConfigString(`{"logModuleLevels":"stream"}`)); err == nil {
+		t.Fatalf("expect invalid module levels")
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/config"
+)
+
+// Formats of the log records.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Keys of the log options in the config files of the components.
+const (
+	ConfigKeyLogFormat         = "logFormat"
+	ConfigKeyLogRollingSize    = "logRollingSize"
+	ConfigKeyLogRotateInterval = "logRotateInterval"
+	ConfigKeyLogModuleLevels   = "logModuleLevels"
+	ConfigKeyLogSink           = "logSink"
+)
+
+// Options are the optional settings of the log.
+type Options struct {
+	Format         string           // FormatText by default, or FormatJSON for a JSON object per line
+	RollingSizeMB  int64            // size to rotate a log file, decided by the free space of the disk if 0
+	RotateInterval time.Duration    // interval to rotate the log files besides the size, every day if 0
+	ModuleLevels   map[string]Level // levels overriding the level for the sources of the directories, e.g. "stream"
+	Sink           string           // syslog://host:port, syslog for the local syslog, or an HTTP URL to post the records to
+}
+
+// ParseOptions reads the log options from the config file.
+func ParseOptions(cfg *config.Config) (opts *Options, err error) {
+	opts = &Options{
+		Format:        strings.ToLower(cfg.GetString(ConfigKeyLogFormat)),
+		RollingSizeMB: cfg.GetInt64(ConfigKeyLogRollingSize),
+		Sink:          cfg.GetString(ConfigKeyLogSink),
+	}
+	switch opts.Format {
+	case "":
+		opts.Format = FormatText
+	case FormatText, FormatJSON:
+	default:
+		return nil, fmt.Errorf("invalid %v(%v), should be %v or %v", ConfigKeyLogFormat, opts.Format, FormatText, FormatJSON)
+	}
+	if value := cfg.GetString(ConfigKeyLogRotateInterval); value != "" {
+		if opts.RotateInterval, err = time.ParseDuration(value); err != nil || opts.RotateInterval < time.Minute {
+			return nil, fmt.Errorf("invalid %v(%v), should be a duration not less than 1m", ConfigKeyLogRotateInterval, value)
+		}
+	}
+	if value := cfg.GetString(ConfigKeyLogModuleLevels); value != "" {
+		if opts.ModuleLevels, err = parseModuleLevels(value); err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+// ParseLevel parses the name of the level, i.e. debug, info, warn, error, critical or fatal.
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return DebugLevel, nil
+	case "info", "read", "write":
+		return InfoLevel, nil
+	case "warn":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	case "critical":
+		return CriticalLevel, nil
+	case "fatal":
+		return FatalLevel, nil
+	}
+	return 0, fmt.Errorf("invalid log level(%v)", name)
+}
+
+// parseModuleLevels parses the levels of the modules like "stream:debug,meta:info".
+func parseModuleLevels(value string) (map[string]Level, error) {
+	levels := make(map[string]Level)
+	for _, item := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid %v(%v), should be like stream:debug,meta:info", ConfigKeyLogModuleLevels, value)
+		}
+		level, err := ParseLevel(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		levels[parts[0]] = level
+	}
+	return levels, nil
+}
+
+type jsonLogRecord struct {
+	Time  string `json:"time"`
+	Level string `json:"level"`
+	File  string `json:"file"`
+	Msg   string `json:"msg"`
+}
+
+func jsonRecord(level, file, msg string) string {
+	data, err := json.Marshal(&jsonLogRecord{
+		Time:  time.Now().Format(time.RFC3339Nano),
+		Level: level,
+		File:  file,
+		Msg:   strings.TrimSuffix(msg, "\n"),
+	})
+	if err != nil {
+		return msg
+	}
+	return string(data)
+}
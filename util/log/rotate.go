@@ -14,6 +14,8 @@
 
 package log
 
+import "time"
+
 const (
 	// DefaultRollingSize Specifies at what size to roll the output log at
 	// Units: MB
@@ -27,8 +29,9 @@ const (
 
 // A log can be rotated by the size or time.
 type LogRotate struct {
-	rollingSize int64 // the size of the rotated log // TODO we should either call rotate or rolling, but not both.
-	headRoom    int64 // capacity reserved for writing the next log on the disk
+	rollingSize    int64         // the size of the rotated log // TODO we should either call rotate or rolling, but not both.
+	headRoom       int64         // capacity reserved for writing the next log on the disk
+	rotateInterval time.Duration // the logs are rotated every interval, or every day if 0
 }
 
 // NewLogRotate returns a new LogRotate instance.
@@ -48,3 +51,8 @@ func (r *LogRotate) SetRollingSizeMb(size int64) {
 func (r *LogRotate) SetHeadRoomMb(size int64) {
 	r.headRoom = size
 }
+
+// SetRotateInterval sets the interval to rotate the logs besides the size, 0 means every day.
+func (r *LogRotate) SetRotateInterval(interval time.Duration) {
+	r.rotateInterval = interval
+}
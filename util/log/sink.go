@@ -0,0 +1,162 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/syslog"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	sinkQueueSize     = 64 * 1024
+	sinkBatchSize     = 1024
+	sinkFlushInterval = time.Second
+	sinkTimeout       = 10 * time.Second
+)
+
+type sinkRecord struct {
+	severity syslog.Priority
+	data     []byte
+}
+
+// sink ships the log records to the syslog or an HTTP server besides the local files. The records are
+// dropped rather than blocking the logging if the sink falls behind, and the number of them is logged.
+type sink struct {
+	addr     string
+	syslog   *syslog.Writer
+	client   *http.Client
+	recordC  chan *sinkRecord
+	dropped  uint64
+	batch    bytes.Buffer
+	batchLen int
+}
+
+func newSink(addr, tag string) (s *sink, err error) {
+	s = &sink{
+		addr:    addr,
+		recordC: make(chan *sinkRecord, sinkQueueSize),
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log sink(%v): %v", addr, err)
+	}
+	switch {
+	case addr == "syslog":
+		s.syslog, err = syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	case u.Scheme == "syslog":
+		s.syslog, err = syslog.Dial("udp", u.Host, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	case u.Scheme == "http" || u.Scheme == "https":
+		s.client = &http.Client{Timeout: sinkTimeout}
+	default:
+		err = fmt.Errorf("invalid log sink(%v), should be syslog, syslog://host:port or an HTTP URL", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	go s.loop()
+	return s, nil
+}
+
+// writer returns the writer of the records of the severity.
+func (s *sink) writer(severity syslog.Priority) io.Writer {
+	return &sinkWriter{sink: s, severity: severity}
+}
+
+type sinkWriter struct {
+	sink     *sink
+	severity syslog.Priority
+}
+
+func (w *sinkWriter) Write(p []byte) (int, error) {
+	// the buffer is reused by the logger
+	data := make([]byte, len(p))
+	copy(data, p)
+	select {
+	case w.sink.recordC <- &sinkRecord{severity: w.severity, data: data}:
+	default:
+		atomic.AddUint64(&w.sink.dropped, 1)
+	}
+	return len(p), nil
+}
+
+func (s *sink) loop() {
+	ticker := time.NewTicker(sinkFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case record := <-s.recordC:
+			s.ship(record)
+		case <-ticker.C:
+			s.flush()
+			if dropped := atomic.SwapUint64(&s.dropped, 0); dropped > 0 {
+				LogWarnf("log sink(%v) falls behind, %v records are only kept in the local files", s.addr, dropped)
+			}
+		}
+	}
+}
+
+func (s *sink) ship(record *sinkRecord) {
+	if s.syslog != nil {
+		var err error
+		msg := string(bytes.TrimSuffix(record.data, []byte("\n")))
+		switch record.severity {
+		case syslog.LOG_DEBUG:
+			err = s.syslog.Debug(msg)
+		case syslog.LOG_WARNING:
+			err = s.syslog.Warning(msg)
+		case syslog.LOG_ERR:
+			err = s.syslog.Err(msg)
+		case syslog.LOG_CRIT:
+			err = s.syslog.Crit(msg)
+		default:
+			err = s.syslog.Info(msg)
+		}
+		if err != nil {
+			atomic.AddUint64(&s.dropped, 1)
+		}
+		return
+	}
+	s.batch.Write(record.data)
+	if s.batchLen++; s.batchLen >= sinkBatchSize {
+		s.flush()
+	}
+}
+
+// flush posts the batch of the records in the body of application/x-ndjson, the batch is dropped if it fails.
+func (s *sink) flush() {
+	if s.client == nil || s.batch.Len() == 0 {
+		return
+	}
+	defer func() {
+		s.batch.Reset()
+		s.batchLen = 0
+	}()
+	resp, err := s.client.Post(s.addr, "application/x-ndjson", bytes.NewReader(s.batch.Bytes()))
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			err = fmt.Errorf("status(%v)", resp.Status)
+		}
+	}
+	if err != nil {
+		atomic.AddUint64(&s.dropped, uint64(s.batchLen))
+	}
+}
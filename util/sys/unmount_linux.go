@@ -0,0 +1,24 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sys
+
+import (
+	"syscall"
+)
+
+// LazyUnmount detaches the mount point even if it is busy or its FUSE server is gone.
+func LazyUnmount(dir string) error {
+	return syscall.Unmount(dir, syscall.MNT_DETACH)
+}
@@ -0,0 +1,174 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package util
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/config"
+)
+
+// The configuration keys of TLS shared by all the modules.
+const (
+	ConfigKeyTLSCertFile = "tlsCertFile" // certificate of the node, required by the servers
+	ConfigKeyTLSKeyFile  = "tlsKeyFile"
+	ConfigKeyTLSCAFile   = "tlsCAFile" // CA to verify the peers, the system CAs are used if empty
+	ConfigKeyTLSMutual   = "tlsMutual" // require the peers to present a certificate signed by the CA
+)
+
+// TLS configuration of the process, nil if TLS is disabled.
+// All the nodes and clients of a cluster are expected to enable TLS together.
+var (
+	tlsServerConfig *tls.Config
+	tlsClientConfig *tls.Config
+	tlsTransport    *http.Transport
+)
+
+// The handshake done on the new connections of DialTLS, nil if no handshake is needed.
+var connHandshake func(conn net.Conn) error
+
+// InitTLS enables TLS for the TCP connections from the connection pools, the TCP services
+// of the meta nodes and the data nodes, and the HTTP APIs of the masters.
+// TLS is disabled if neither the certificate nor the CA is given.
+func InitTLS(certFile, keyFile, caFile string, mutual, isServer bool) (err error) {
+	if certFile == "" && caFile == "" {
+		return
+	}
+	var (
+		certs []tls.Certificate
+		pool  *x509.CertPool
+	)
+	if certFile != "" {
+		var cert tls.Certificate
+		if cert, err = tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+			return fmt.Errorf("load tls cert(%v) key(%v) err(%v)", certFile, keyFile, err)
+		}
+		certs = append(certs, cert)
+	} else if isServer || mutual {
+		return fmt.Errorf("%v is required for the servers and mutual tls", ConfigKeyTLSCertFile)
+	}
+	if caFile != "" {
+		var ca []byte
+		if ca, err = ioutil.ReadFile(caFile); err != nil {
+			return fmt.Errorf("read tls ca(%v) err(%v)", caFile, err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return fmt.Errorf("no certificate found in tls ca(%v)", caFile)
+		}
+	}
+	serverConfig := &tls.Config{
+		Certificates: certs,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	}
+	if mutual {
+		serverConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	clientConfig := &tls.Config{
+		Certificates: certs,
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+	}
+	tlsServerConfig, tlsClientConfig = serverConfig, clientConfig
+	tlsTransport = &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: clientConfig,
+	}
+	return
+}
+
+// InitTLSFromConfig enables TLS with the configuration of the module.
+func InitTLSFromConfig(cfg *config.Config, isServer bool) error {
+	return InitTLS(cfg.GetString(ConfigKeyTLSCertFile), cfg.GetString(ConfigKeyTLSKeyFile),
+		cfg.GetString(ConfigKeyTLSCAFile), cfg.GetBool(ConfigKeyTLSMutual), isServer)
+}
+
+// TLSEnabled tells if TLS is enabled for the process.
+func TLSEnabled() bool {
+	return tlsClientConfig != nil
+}
+
+// TLSServerConfig returns the TLS configuration of the servers, nil if TLS is disabled.
+func TLSServerConfig() *tls.Config {
+	return tlsServerConfig
+}
+
+// TLSTransport returns the HTTP transport with the TLS configuration of the clients,
+// http.DefaultTransport if TLS is disabled.
+func TLSTransport() http.RoundTripper {
+	if tlsTransport == nil {
+		return http.DefaultTransport
+	}
+	return tlsTransport
+}
+
+// ServerConn wraps the accepted connection with TLS if it is enabled.
+// The handshake is done on the first read or write of the connection.
+func ServerConn(conn net.Conn) net.Conn {
+	if tlsServerConfig == nil {
+		return conn
+	}
+	return tls.Server(conn, tlsServerConfig)
+}
+
+// DialTLS connects to the target, and wraps the connection with TLS if it is enabled.
+// The certificate of the target must contain the host of the target, which is an IP in most cases.
+func DialTLS(target string, timeout time.Duration) (c net.Conn, err error) {
+	var conn net.Conn
+	if conn, err = net.DialTimeout("tcp", target, timeout); err != nil {
+		return
+	}
+	tcpConn := conn.(*net.TCPConn)
+	tcpConn.SetKeepAlive(true)
+	tcpConn.SetNoDelay(true)
+	c = tcpConn
+	if tlsClientConfig != nil {
+		clientConfig := tlsClientConfig.Clone()
+		if clientConfig.ServerName, _, err = net.SplitHostPort(target); err != nil {
+			tcpConn.Close()
+			return nil, err
+		}
+		tlsConn := tls.Client(tcpConn, clientConfig)
+		if timeout > 0 {
+			tlsConn.SetDeadline(time.Now().Add(timeout))
+		}
+		if err = tlsConn.Handshake(); err != nil {
+			tlsConn.Close()
+			return nil, fmt.Errorf("tls handshake with %v err(%v)", target, err)
+		}
+		tlsConn.SetDeadline(time.Time{})
+		c = tlsConn
+	}
+	if connHandshake != nil {
+		if err = connHandshake(c); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// SetConnHandshake sets the handshake done by DialTLS on the new connections,
+// e.g. to present the access tokens of the process to the meta nodes and the data nodes.
+func SetConnHandshake(handshake func(conn net.Conn) error) {
+	connHandshake = handshake
+}